@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"log/slog"
 
 	"github.com/datmedevil17/go-vuln/internal/config"
 	"github.com/datmedevil17/go-vuln/internal/database"
@@ -19,6 +20,8 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	// log.Printf output goes through the structured logger from here on
+	slog.SetDefault(utils.NewLogger(cfg.Server.Mode, cfg.Logging.Level))
 
 	// Initialize database connections
 	db, err := database.NewPostgres(cfg)
@@ -37,40 +40,124 @@ func main() {
 
 	// Initialize services
 	authService := services.NewAuthService(db, cfg)
-	scannerService := services.NewScannerService(db)
 	notificationService := services.NewNotificationService(cfg)
-	aiService := services.NewAIService(cfg)
-	githubService := services.NewGitHubService(db)
-	workflowService := services.NewWorkflowService(db, scannerService, notificationService, aiService, githubService)
+	notificationQueue := services.NewNotificationQueue(db, notificationService, cfg)
+	keyRing, err := utils.NewKeyRing(cfg.Security.EncryptionKeys, cfg.Security.EncryptionKeyVersion)
+	if err != nil {
+		log.Fatalf("Failed to load encryption keys: %v", err)
+	}
+	secretStore := services.NewSecretStore(db, keyRing)
+	siemExporter := services.NewSIEMExporter(db, secretStore, notificationQueue, cfg)
+	completionWebhooks := services.NewCompletionWebhooks(db, secretStore, notificationQueue)
+	argPolicy, err := services.NewScannerArgPolicy(cfg.Scanning.AllowedFlags, cfg.Scanning.DeniedFlags)
+	if err != nil {
+		log.Fatalf("Invalid scanner flag configuration: %v", err)
+	}
+	targetPolicy, err := services.NewScanTargetPolicy(cfg.Scanning.TargetAllowlist, cfg.Scanning.TargetBlocklist)
+	if err != nil {
+		log.Fatalf("Invalid scan target configuration: %v", err)
+	}
+	scanLimiter := services.NewScanLimiter(cfg.Scanning.MinConcurrency, cfg.Scanning.MaxConcurrency, cfg.Scanning.PerUserConcurrency, cfg.Scanning.AdjustInterval)
+	resultSigner := services.NewResultSigner(cfg.Security.ResultSigningKey)
+	scannerService := services.NewScannerService(db, services.NewWordlistRegistry(cfg.Scanning.WordlistDir), resultSigner, services.NewRedactor(cfg.Security.Redaction), argPolicy, targetPolicy, scanLimiter, services.NewToolRunner(cfg.Scanning), services.ScanSavePolicy{
+		Attempts:    cfg.Scanning.SaveAttempts,
+		RetryBase:   cfg.Scanning.SaveRetryBase,
+		FallbackDir: cfg.Scanning.FallbackDir,
+	}, services.ScanMockPolicy{
+		Force: cfg.Scanning.ForceMockScanners,
+		Seed:  cfg.Scanning.MockSeed,
+	}, siemExporter)
+	if recovered, err := scannerService.RecoverScanFallbacks(); err != nil {
+		log.Printf("⚠️ Failed to recover saved scan results: %v", err)
+	} else if recovered > 0 {
+		log.Printf("💾 Recovered %d scan result(s) saved during a database outage", recovered)
+	}
+	if err := scannerService.LoadScanDurations(); err != nil {
+		log.Printf("⚠️ Failed to load past scan durations: %v", err)
+	}
+	if cfg.Scanning.ForceMockScanners {
+		log.Printf("🎭 SCANNER_FORCE_MOCK is set: every scanner returns mock data")
+	}
+	capabilities := scannerService.SelfTest()
+	for _, binary := range capabilities.Binaries {
+		if binary.Available {
+			log.Printf("✅ %s found at %s", binary.Name, binary.Path)
+		} else {
+			log.Printf("⚠️ %s not installed", binary.Name)
+		}
+	}
+	for _, node := range capabilities.NodeTypes {
+		if node.Mode == services.ScanModeMock {
+			log.Printf("🎭 %s nodes will return mock data (%s not installed)", node.Type, node.Binary)
+		}
+	}
+	scanProfileService := services.NewScanProfileService(db)
+	targetService := services.NewTargetService(db)
+	breakers := services.NewCircuitBreakers(cfg.Breaker.Failures, cfg.Breaker.Cooldown, services.NewAPIClient(cfg.Breaker.Timeout))
+	aiService := services.NewAIService(cfg, breakers)
+	githubService := services.NewGitHubService(db, breakers, cfg.GitHub)
+	workflowService := services.NewWorkflowService(db, scannerService, notificationService, notificationQueue, secretStore, aiService, githubService, siemExporter, completionWebhooks, cfg.Workflow)
 	embeddingService := services.NewEmbeddingService()
+	apiKeyService := services.NewAPIKeyService(db)
+	reprocessor := services.NewReprocessor(db, resultSigner)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService, jwtUtil, cfg)
 	workflowHandler := handlers.NewWorkflowHandler(workflowService)
 	githubHandler := handlers.NewGitHubHandler(githubService, authService)
 	scannerHandler := handlers.NewScannerHandler(scannerService)
+	scanProfileHandler := handlers.NewScanProfileHandler(scanProfileService)
+	targetHandler := handlers.NewTargetHandler(targetService)
+	secretHandler := handlers.NewSecretHandler(secretStore)
 	codeHandler := handlers.NewCodeHandler(aiService, embeddingService)
 	chatbotHandler := handlers.NewChatbotHandler(aiService)
 	aiWorkflowHandler := handlers.NewAIWorkflowHandler(aiService)
+	adminHandler := handlers.NewAdminHandler(notificationQueue, workflowService, secretStore, scanLimiter, breakers, reprocessor)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+
+	// Start background workers
+	notificationQueue.Start()
+	defer notificationQueue.Stop()
+	scanLimiter.Start()
+	defer scanLimiter.Stop()
+	workflowScheduler := services.NewWorkflowScheduler(db, workflowService)
+	workflowScheduler.Start()
+	defer workflowScheduler.Stop()
+	executionDispatcher := services.NewExecutionDispatcher(redisClient, db, workflowService)
+	executionDispatcher.Start()
+	defer executionDispatcher.Stop()
+	executionMonitor := services.NewExecutionMonitor(db, workflowService)
+	executionMonitor.Start()
+	defer executionMonitor.Stop()
 
 	// Create Gin router
 	router := gin.Default()
+	if err := middleware.ConfigureTrustedProxies(router, cfg.Security.TrustedProxies); err != nil {
+		log.Fatalf("Invalid trusted proxy configuration: %v", err)
+	}
 
 	// Apply global middleware
 	router.Use(middleware.CORSMiddleware(cfg))
-	router.Use(middleware.LoggerMiddleware())
+	router.Use(middleware.LoggerMiddleware(cfg))
 	router.Use(gin.Recovery())
 
 	// Setup routes
 	routes.SetupRoutes(router, &routes.RouterConfig{
-		AuthHandler:       authHandler,
-		WorkflowHandler:   workflowHandler,
-		GitHubHandler:     githubHandler,
-		ScannerHandler:    scannerHandler,
-		CodeHandler:       codeHandler,
-		ChatbotHandler:    chatbotHandler,
-		AIWorkflowHandler: aiWorkflowHandler,
-		JWTUtil:           jwtUtil,
+		AuthHandler:        authHandler,
+		WorkflowHandler:    workflowHandler,
+		GitHubHandler:      githubHandler,
+		ScannerHandler:     scannerHandler,
+		ScanProfileHandler: scanProfileHandler,
+		TargetHandler:      targetHandler,
+		SecretHandler:      secretHandler,
+		CodeHandler:        codeHandler,
+		ChatbotHandler:     chatbotHandler,
+		AIWorkflowHandler:  aiWorkflowHandler,
+		AdminHandler:       adminHandler,
+		APIKeyHandler:      apiKeyHandler,
+		JWTUtil:            jwtUtil,
+		APIKeys:            apiKeyService,
+		Config:             cfg,
 	})
 
 	// Start server
@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"log"
+	"net"
 	"os"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
@@ -21,10 +23,15 @@ type Config struct {
 	AI        AIConfig
 	Email     EmailConfig
 	Slack     SlackConfig
+	Notify    NotifyConfig
 	RateLimit RateLimitConfig
+	Breaker   BreakerConfig
+	SIEM      SIEMConfig
 	Logging   LoggingConfig
 	Scanning  ScanningConfig
+	Workflow  WorkflowConfig
 	Frontend  FrontendConfig
+	Security  SecurityConfig
 }
 
 // ServerConfig holds server-related configuration
@@ -67,12 +74,48 @@ type GitHubConfig struct {
 	ClientID     string
 	ClientSecret string
 	CallbackURL  string
+	Scopes       []string // Requested at sign-in
+	WriteScopes  []string // Extra scopes a user may grant later for issues, comments and auto-fix PRs
+
+	RateLimitRetries int           // Times a rate-limited API call is retried after waiting for the limit to reset
+	RateLimitMaxWait time.Duration // Longest wait for a reset; calls limited for longer fail straight away
 }
 
 // AIConfig holds AI service configuration
 type AIConfig struct {
 	GeminiAPIKey string
 	GroqAPIKey   string
+	ClaudeAPIKey string
+
+	// Models requested from each provider
+	GeminiModel string
+	GroqModel   string
+	ClaudeModel string
+
+	// Order providers are tried in until one answers. Chat replies and scan output summaries
+	// use the fast order, which favors quicker models over stronger ones.
+	ProviderOrder     []string
+	FastProviderOrder []string
+
+	// Sampling parameters per kind of call
+	Analysis AIGeneration
+	Report   AIGeneration
+	Fix      AIGeneration
+	Chat     AIGeneration
+	Workflow AIGeneration
+
+	// Estimated-token budgets for scan output sent to the model; larger output is
+	// summarized chunk by chunk rather than cut off
+	OutputTokenBudget int // Per scan output
+	ReportTokenBudget int // All the output behind one report
+
+	FixContextLines int // Lines either side of a finding sent to the AI with it for a fix
+}
+
+// AIGeneration holds the sampling parameters sent with one kind of AI call
+type AIGeneration struct {
+	Temperature float64
+	MaxTokens   int
 }
 
 // EmailConfig holds email service configuration
@@ -91,6 +134,13 @@ type SlackConfig struct {
 	Enabled    bool
 }
 
+// NotifyConfig holds cross-channel notification behaviour
+type NotifyConfig struct {
+	DedupWindow time.Duration // Suppress identical notifications to the same user within this window (0 disables)
+	MaxAttempts int           // Delivery attempts before a failed notification is dead-lettered
+	RetryBase   time.Duration // Delay before the first retry; doubles on each further attempt
+}
+
 // RateLimitConfig holds rate limiting configuration
 type RateLimitConfig struct {
 	Enabled  bool
@@ -98,11 +148,27 @@ type RateLimitConfig struct {
 	Window   time.Duration
 }
 
+// BreakerConfig holds circuit breaker and timeout settings for calls to the AI and GitHub APIs
+type BreakerConfig struct {
+	Failures int           // Consecutive failures that open a breaker
+	Cooldown time.Duration // How long an open breaker fails fast before a trial call
+	Timeout  time.Duration // Longest one call may take, response body included
+}
+
+// SIEMConfig holds the server-wide endpoint findings are pushed to after each scan
+type SIEMConfig struct {
+	URL           string // Empty disables the server-wide push; users may still set their own
+	Format        string // ecs or cef
+	Authorization string // Sent verbatim as the Authorization header, e.g. "Splunk <token>"
+}
+
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
-	Level  string
-	Format string // json or text
-	File   string
+	Level         string
+	Format        string // json or text
+	File          string
+	CaptureBodies bool // Log redacted request/response bodies (debug level, non-production only)
+	BodyLimit     int  // Max bytes of each body to log
 }
 
 // ScanningConfig holds security tool paths
@@ -112,6 +178,45 @@ type ScanningConfig struct {
 	GobusterPath string
 	SQLMapPath   string
 	WPScanPath   string
+	WordlistDir  string   // Where bundled and uploaded gobuster wordlists are stored
+	AllowedFlags []string // "scanner:flag" entries replacing the default extra-flag allowlist of the named scanners
+	DeniedFlags  []string // "scanner:flag" entries that are always rejected
+
+	TargetAllowlist []string // Hostnames, IPs and CIDRs scanners may reach even though they are private or reserved
+	TargetBlocklist []string // IPs and CIDRs scanners may never reach, on top of the private and reserved ranges
+
+	MinConcurrency     int           // Scans always allowed to run at once
+	MaxConcurrency     int           // Upper bound the adaptive limit may grow to
+	AdjustInterval     time.Duration // How often the concurrency limit is re-evaluated
+	PerUserConcurrency int           // Scans one user may run at once, whatever the limit (0 = no cap)
+
+	SaveAttempts  int           // Tries to save a finished scan before writing it to FallbackDir
+	SaveRetryBase time.Duration // Delay before the first retry; doubles on each further attempt
+	FallbackDir   string        // Where scan results that couldn't be saved are kept until the next start
+
+	Sandbox             string   // Where scanner binaries run: host, or docker for short-lived containers
+	DockerHost          string   // Docker Engine API endpoint, unix:// or tcp://
+	SandboxImages       []string // "tool=image" entries naming the container image each scanner runs in
+	SandboxMemoryMB     int      // Memory limit of each scanner container
+	SandboxCPUs         float64  // CPU limit of each scanner container
+	SandboxNetwork      string   // Docker network of scanners that need one; the others get none
+	SandboxHostFallback bool     // Run on the host when Docker is unreachable or a tool has no image
+
+	ForceMockScanners bool  // Return mock output from every network scanner, installed or not (demos and CI)
+	MockSeed          int64 // Seeds mock output, which is otherwise the same for the same tool and target
+}
+
+// WorkflowConfig bounds the size of workflow graphs users can save or generate, and how
+// many runs of them each user has at once
+type WorkflowConfig struct {
+	MaxNodes int
+	MaxEdges int
+
+	MaxConcurrent        int // Executions that run at once across all users; more wait their turn (0 = no limit)
+	MaxConcurrentPerUser int // Executions each user may run at once; more wait their turn (0 = no limit)
+	MaxParallelNodes     int // Nodes of one execution that run at once once their dependencies finish
+
+	NodeTimeout time.Duration // Default run time limit for a node without its own timeout (0 = none)
 }
 
 // FrontendConfig holds frontend-related configuration
@@ -120,6 +225,20 @@ type FrontendConfig struct {
 	CORSOrigins []string
 }
 
+// SecurityConfig holds network access control configuration
+type SecurityConfig struct {
+	IPAllowlist          []string       // CIDRs or single IPs allowed to reach restricted routes
+	AllowlistAPI         bool           // Apply the allowlist to the whole API instead of only sensitive routes
+	TrustedProxies       []string       // CIDRs of reverse proxies whose X-Forwarded-For is honoured
+	AdminUsers           []string       // GitHub usernames allowed to use /api/admin routes
+	EncryptionKey        string         // Key for secrets stored at rest; falls back to the JWT secret outside production
+	EncryptionKeyVersion int            // Version recorded with data encrypted under EncryptionKey
+	PreviousKeys         []string       // Retired "version:key" pairs still needed to decrypt older data
+	EncryptionKeys       map[int]string // Every key by version, built from the fields above by Validate
+	ResultSigningKey     string         // HMAC key for tamper-evident scan results; empty disables signing
+	Redaction            string         // How secrets in scan output are masked: partial or full
+}
+
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
 	// Load .env file if it exists (for local development)
@@ -157,10 +276,31 @@ func Load() (*Config, error) {
 			ClientID:     getEnv("GITHUB_CLIENT_ID", ""),
 			ClientSecret: getEnv("GITHUB_CLIENT_SECRET", ""),
 			CallbackURL:  getEnv("GITHUB_CALLBACK_URL", "http://localhost:8080/api/auth/github/callback"),
+			Scopes:       getEnvAsSlice("GITHUB_SCOPES", []string{"read:user", "user:email"}),
+			WriteScopes:  getEnvAsSlice("GITHUB_WRITE_SCOPES", []string{"repo"}),
+
+			RateLimitRetries: getEnvAsInt("GITHUB_RATE_LIMIT_RETRIES", 3),
+			RateLimitMaxWait: getEnvAsDuration("GITHUB_RATE_LIMIT_MAX_WAIT", time.Minute),
 		},
 		AI: AIConfig{
 			GeminiAPIKey: getEnv("GEMINI_API_KEY", ""),
 			GroqAPIKey:   getEnv("GROQ_API_KEY", ""),
+			ClaudeAPIKey: getEnv("CLAUDE_API_KEY", ""),
+			GeminiModel:  getEnv("GEMINI_MODEL", "gemini-2.0-flash"),
+			GroqModel:    getEnv("GROQ_MODEL", "llama-3.3-70b-versatile"),
+			ClaudeModel:  getEnv("CLAUDE_MODEL", "claude-sonnet-4-5"),
+			Analysis:     getEnvAsAIGeneration("AI_ANALYSIS", 0.2, 4096),
+			Report:       getEnvAsAIGeneration("AI_REPORT", 0.7, 4096),
+			Fix:          getEnvAsAIGeneration("AI_FIX", 0.1, 8192),
+			Chat:         getEnvAsAIGeneration("AI_CHAT", 0.7, 2048),
+			Workflow:     getEnvAsAIGeneration("AI_WORKFLOW", 0.2, 2048),
+
+			OutputTokenBudget: getEnvAsInt("AI_OUTPUT_TOKEN_BUDGET", 2000),
+			ReportTokenBudget: getEnvAsInt("AI_REPORT_TOKEN_BUDGET", 8000),
+			FixContextLines:   getEnvAsInt("AI_FIX_CONTEXT_LINES", 30),
+
+			ProviderOrder:     getEnvAsSlice("AI_PROVIDER_ORDER", []string{"gemini", "groq", "claude"}),
+			FastProviderOrder: getEnvAsSlice("AI_FAST_PROVIDER_ORDER", []string{"groq", "gemini", "claude"}),
 		},
 		Email: EmailConfig{
 			SMTPHost: getEnv("SMTP_HOST", "smtp.gmail.com"),
@@ -174,15 +314,33 @@ func Load() (*Config, error) {
 			WebhookURL: getEnv("SLACK_WEBHOOK_URL", ""),
 			Enabled:    getEnvAsBool("SLACK_ENABLED", false),
 		},
+		Notify: NotifyConfig{
+			DedupWindow: getEnvAsDuration("NOTIFICATION_DEDUP_WINDOW", 10*time.Minute),
+			MaxAttempts: getEnvAsInt("NOTIFICATION_MAX_ATTEMPTS", 5),
+			RetryBase:   getEnvAsDuration("NOTIFICATION_RETRY_BASE", 30*time.Second),
+		},
 		RateLimit: RateLimitConfig{
 			Enabled:  getEnvAsBool("RATE_LIMIT_ENABLED", true),
 			Requests: getEnvAsInt("RATE_LIMIT_REQUESTS", 100),
 			Window:   getEnvAsDuration("RATE_LIMIT_WINDOW", 15*time.Minute),
 		},
+		Breaker: BreakerConfig{
+			Failures: getEnvAsInt("CIRCUIT_BREAKER_FAILURES", 5),
+			Cooldown: getEnvAsDuration("CIRCUIT_BREAKER_COOLDOWN", 30*time.Second),
+			Timeout:  getEnvAsDuration("EXTERNAL_API_TIMEOUT", 2*time.Minute),
+		},
+		SIEM: SIEMConfig{
+			URL:           getEnv("SIEM_URL", ""),
+			Format:        getEnv("SIEM_FORMAT", "ecs"),
+			Authorization: getEnv("SIEM_AUTHORIZATION", ""),
+		},
 		Logging: LoggingConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
 			File:   getEnv("LOG_FILE", "logs/vulnpilot.log"),
+
+			CaptureBodies: getEnvAsBool("LOG_CAPTURE_BODIES", false),
+			BodyLimit:     getEnvAsInt("LOG_BODY_LIMIT", 4096),
 		},
 		Scanning: ScanningConfig{
 			NmapPath:     getEnv("NMAP_PATH", "/usr/bin/nmap"),
@@ -190,10 +348,57 @@ func Load() (*Config, error) {
 			GobusterPath: getEnv("GOBUSTER_PATH", "/usr/local/bin/gobuster"),
 			SQLMapPath:   getEnv("SQLMAP_PATH", "/usr/bin/sqlmap"),
 			WPScanPath:   getEnv("WPSCAN_PATH", "/usr/bin/wpscan"),
+			WordlistDir:  getEnv("WORDLIST_DIR", "./data/wordlists"),
+			AllowedFlags: getEnvAsSlice("SCANNER_ALLOWED_FLAGS", nil),
+			DeniedFlags:  getEnvAsSlice("SCANNER_DENIED_FLAGS", nil),
+
+			TargetAllowlist: getEnvAsSlice("SCAN_TARGET_ALLOWLIST", nil),
+			TargetBlocklist: getEnvAsSlice("SCAN_TARGET_BLOCKLIST", nil),
+
+			MinConcurrency:     getEnvAsInt("SCAN_CONCURRENCY_MIN", 1),
+			MaxConcurrency:     getEnvAsInt("SCAN_CONCURRENCY_MAX", 2*runtime.NumCPU()),
+			AdjustInterval:     getEnvAsDuration("SCAN_CONCURRENCY_INTERVAL", 15*time.Second),
+			PerUserConcurrency: getEnvAsInt("SCAN_CONCURRENCY_PER_USER", 0),
+
+			SaveAttempts:  getEnvAsInt("SCAN_SAVE_ATTEMPTS", 5),
+			SaveRetryBase: getEnvAsDuration("SCAN_SAVE_RETRY_BASE", 500*time.Millisecond),
+			FallbackDir:   getEnv("SCAN_FALLBACK_DIR", "./data/scan-fallback"),
+
+			Sandbox:             getEnv("SCANNER_SANDBOX", "host"),
+			DockerHost:          getEnv("DOCKER_HOST", "unix:///var/run/docker.sock"),
+			SandboxImages:       getEnvAsSlice("SANDBOX_IMAGES", nil),
+			SandboxMemoryMB:     getEnvAsInt("SANDBOX_MEMORY_MB", 512),
+			SandboxCPUs:         getEnvAsFloat("SANDBOX_CPUS", 1),
+			SandboxNetwork:      getEnv("SANDBOX_NETWORK", "bridge"),
+			SandboxHostFallback: getEnvAsBool("SANDBOX_HOST_FALLBACK", false),
+
+			ForceMockScanners: getEnvAsBool("SCANNER_FORCE_MOCK", false),
+			MockSeed:          int64(getEnvAsInt("SCANNER_MOCK_SEED", 1)),
+		},
+		Workflow: WorkflowConfig{
+			MaxNodes: getEnvAsInt("WORKFLOW_MAX_NODES", 100),
+			MaxEdges: getEnvAsInt("WORKFLOW_MAX_EDGES", 500),
+
+			MaxConcurrent:        getEnvAsInt("WORKFLOW_MAX_CONCURRENT", 10),
+			MaxConcurrentPerUser: getEnvAsInt("WORKFLOW_MAX_CONCURRENT_PER_USER", 0),
+			MaxParallelNodes:     getEnvAsInt("WORKFLOW_MAX_PARALLEL_NODES", 4),
+
+			NodeTimeout: getEnvAsDuration("WORKFLOW_NODE_TIMEOUT", 0),
 		},
 		Frontend: FrontendConfig{
 			URL: getEnv("FRONTEND_URL", "http://localhost:3000"),
 		},
+		Security: SecurityConfig{
+			IPAllowlist:          getEnvAsSlice("IP_ALLOWLIST", nil),
+			AllowlistAPI:         getEnvAsBool("IP_ALLOWLIST_API", false),
+			TrustedProxies:       getEnvAsSlice("TRUSTED_PROXIES", nil),
+			AdminUsers:           getEnvAsSlice("ADMIN_USERS", nil),
+			EncryptionKey:        getEnv("ENCRYPTION_KEY", ""),
+			EncryptionKeyVersion: getEnvAsInt("ENCRYPTION_KEY_VERSION", 1),
+			PreviousKeys:         getEnvAsSlice("ENCRYPTION_PREVIOUS_KEYS", nil),
+			ResultSigningKey:     getEnv("RESULT_SIGNING_KEY", ""),
+			Redaction:            getEnv("OUTPUT_REDACTION", "partial"),
+		},
 	}
 
 	// Build database DSN
@@ -241,6 +446,136 @@ func (c *Config) Validate() error {
 		log.Println("WARNING: GitHub OAuth not configured - auth will not work. Set GITHUB_CLIENT_ID and GITHUB_CLIENT_SECRET")
 	}
 
+	if c.Workflow.MaxNodes < 1 || c.Workflow.MaxEdges < 0 {
+		return fmt.Errorf("WORKFLOW_MAX_NODES must be at least 1 and WORKFLOW_MAX_EDGES at least 0")
+	}
+	if c.Workflow.MaxConcurrent < 0 || c.Workflow.MaxConcurrentPerUser < 0 || c.Scanning.PerUserConcurrency < 0 {
+		return fmt.Errorf("WORKFLOW_MAX_CONCURRENT, WORKFLOW_MAX_CONCURRENT_PER_USER and SCAN_CONCURRENCY_PER_USER must not be negative")
+	}
+	if c.Workflow.MaxParallelNodes < 1 {
+		return fmt.Errorf("WORKFLOW_MAX_PARALLEL_NODES must be at least 1")
+	}
+	if c.Workflow.NodeTimeout < 0 {
+		return fmt.Errorf("WORKFLOW_NODE_TIMEOUT must not be negative")
+	}
+
+	for _, entry := range append(c.Security.IPAllowlist, c.Security.TrustedProxies...) {
+		if _, err := ParseCIDR(entry); err != nil {
+			return err
+		}
+	}
+
+	if c.Security.EncryptionKey == "" {
+		if c.Server.Mode == "production" {
+			return fmt.Errorf("ENCRYPTION_KEY is required in production")
+		}
+		log.Println("WARNING: ENCRYPTION_KEY not set - stored secrets are encrypted with a key derived from JWT_SECRET")
+		c.Security.EncryptionKey = c.JWT.Secret
+	} else if len(c.Security.EncryptionKey) < 32 {
+		return fmt.Errorf("ENCRYPTION_KEY must be at least 32 characters long")
+	}
+	if c.Security.EncryptionKeyVersion < 1 {
+		return fmt.Errorf("ENCRYPTION_KEY_VERSION must be at least 1")
+	}
+	c.Security.EncryptionKeys = map[int]string{c.Security.EncryptionKeyVersion: c.Security.EncryptionKey}
+	for _, entry := range c.Security.PreviousKeys {
+		versionStr, key, ok := strings.Cut(entry, ":")
+		version, err := strconv.Atoi(versionStr)
+		if !ok || err != nil || version < 1 {
+			return fmt.Errorf("ENCRYPTION_PREVIOUS_KEYS entries must be version:key")
+		}
+		if _, dup := c.Security.EncryptionKeys[version]; dup {
+			return fmt.Errorf("ENCRYPTION_PREVIOUS_KEYS has more than one key for version %d", version)
+		}
+		if len(key) < 32 {
+			return fmt.Errorf("ENCRYPTION_PREVIOUS_KEYS key for version %d must be at least 32 characters long", version)
+		}
+		c.Security.EncryptionKeys[version] = key
+	}
+	if c.Security.ResultSigningKey != "" && len(c.Security.ResultSigningKey) < 32 {
+		return fmt.Errorf("RESULT_SIGNING_KEY must be at least 32 characters long")
+	}
+	if c.SIEM.Format != "ecs" && c.SIEM.Format != "cef" {
+		return fmt.Errorf("SIEM_FORMAT must be ecs or cef")
+	}
+	if c.Scanning.ForceMockScanners && c.Server.Mode == "production" {
+		return fmt.Errorf("SCANNER_FORCE_MOCK must not be enabled in production")
+	}
+
+	if c.Scanning.Sandbox != "host" && c.Scanning.Sandbox != "docker" {
+		return fmt.Errorf("SCANNER_SANDBOX must be host or docker")
+	}
+	for _, entry := range c.Scanning.SandboxImages {
+		if tool, image, ok := strings.Cut(entry, "="); !ok || tool == "" || image == "" {
+			return fmt.Errorf("SANDBOX_IMAGES entries must be tool=image")
+		}
+	}
+	if c.Scanning.SandboxMemoryMB < 1 || c.Scanning.SandboxCPUs <= 0 {
+		return fmt.Errorf("SANDBOX_MEMORY_MB and SANDBOX_CPUS must be positive")
+	}
+
+	if c.Security.Redaction != "partial" && c.Security.Redaction != "full" {
+		return fmt.Errorf("OUTPUT_REDACTION must be partial or full")
+	}
+
+	if c.Breaker.Timeout <= 0 {
+		return fmt.Errorf("EXTERNAL_API_TIMEOUT must be positive")
+	}
+
+	if c.GitHub.RateLimitRetries < 0 || c.GitHub.RateLimitMaxWait < 0 {
+		return fmt.Errorf("GITHUB_RATE_LIMIT_RETRIES and GITHUB_RATE_LIMIT_MAX_WAIT must not be negative")
+	}
+
+	for name, gen := range map[string]AIGeneration{
+		"AI_ANALYSIS": c.AI.Analysis,
+		"AI_REPORT":   c.AI.Report,
+		"AI_FIX":      c.AI.Fix,
+		"AI_CHAT":     c.AI.Chat,
+		"AI_WORKFLOW": c.AI.Workflow,
+	} {
+		if gen.Temperature < 0 || gen.Temperature > 2 {
+			return fmt.Errorf("%s_TEMPERATURE must be between 0 and 2", name)
+		}
+		if gen.MaxTokens < 1 {
+			return fmt.Errorf("%s_MAX_TOKENS must be at least 1", name)
+		}
+	}
+
+	for name, model := range map[string]string{
+		"GEMINI_MODEL": c.AI.GeminiModel,
+		"GROQ_MODEL":   c.AI.GroqModel,
+		"CLAUDE_MODEL": c.AI.ClaudeModel,
+	} {
+		if strings.TrimSpace(model) == "" {
+			return fmt.Errorf("%s must not be empty", name)
+		}
+	}
+
+	for name, order := range map[string][]string{
+		"AI_PROVIDER_ORDER":      c.AI.ProviderOrder,
+		"AI_FAST_PROVIDER_ORDER": c.AI.FastProviderOrder,
+	} {
+		if len(order) == 0 {
+			return fmt.Errorf("%s must list at least one provider", name)
+		}
+		for _, provider := range order {
+			if provider != "gemini" && provider != "groq" && provider != "claude" {
+				return fmt.Errorf("%s may only list gemini, groq and claude, got %q", name, provider)
+			}
+		}
+	}
+
+	if c.AI.OutputTokenBudget < 100 || c.AI.ReportTokenBudget < c.AI.OutputTokenBudget {
+		return fmt.Errorf("AI_OUTPUT_TOKEN_BUDGET must be at least 100 and no larger than AI_REPORT_TOKEN_BUDGET")
+	}
+	if c.AI.FixContextLines < 0 {
+		return fmt.Errorf("AI_FIX_CONTEXT_LINES must not be negative")
+	}
+
+	if c.Notify.MaxAttempts < 1 {
+		return fmt.Errorf("NOTIFICATION_MAX_ATTEMPTS must be at least 1")
+	}
+
 	if c.Database.Password == "" {
 		log.Println("WARNING: Database password is empty")
 	}
@@ -265,6 +600,22 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseFloat(valueStr, 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvAsAIGeneration reads <prefix>_TEMPERATURE and <prefix>_MAX_TOKENS
+func getEnvAsAIGeneration(prefix string, temperature float64, maxTokens int) AIGeneration {
+	return AIGeneration{
+		Temperature: getEnvAsFloat(prefix+"_TEMPERATURE", temperature),
+		MaxTokens:   getEnvAsInt(prefix+"_MAX_TOKENS", maxTokens),
+	}
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	valueStr := getEnv(key, "")
 	if value, err := strconv.ParseBool(valueStr); err == nil {
@@ -273,6 +624,20 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+	var values []string
+	for _, v := range strings.Split(valueStr, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	valueStr := getEnv(key, "")
 	if value, err := time.ParseDuration(valueStr); err == nil {
@@ -280,3 +645,23 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+// ParseCIDR parses a CIDR block, accepting bare IP addresses as single-host networks
+func ParseCIDR(entry string) (*net.IPNet, error) {
+	if !strings.Contains(entry, "/") {
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address: %s", entry)
+		}
+		bits := 32
+		if ip.To4() == nil {
+			bits = 128
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(entry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR: %s", entry)
+	}
+	return network, nil
+}
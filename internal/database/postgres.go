@@ -56,7 +56,12 @@ func NewPostgres(cfg *config.Config) (*gorm.DB, error) {
 		&models.Repository{},
 		&models.Workflow{},
 		&models.ScanResult{},
+		&models.ScanBatch{},
 		&models.WorkflowExecution{},
+		&models.ScanProfile{},
+		&models.NotificationDelivery{},
+		&models.Secret{},
+		&models.APIKey{},
 	); err != nil {
 		return nil, fmt.Errorf("failed to auto-migrate database: %w", err)
 	}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/datmedevil17/go-vuln/internal/services"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AdminHandler struct {
+	notificationQueue *services.NotificationQueue
+	workflowService   *services.WorkflowService
+	secretStore       *services.SecretStore
+	scanLimiter       *services.ScanLimiter
+	breakers          *services.CircuitBreakers
+	reprocessor       *services.Reprocessor
+}
+
+func NewAdminHandler(notificationQueue *services.NotificationQueue, workflowService *services.WorkflowService, secretStore *services.SecretStore, scanLimiter *services.ScanLimiter, breakers *services.CircuitBreakers, reprocessor *services.Reprocessor) *AdminHandler {
+	return &AdminHandler{
+		notificationQueue: notificationQueue,
+		workflowService:   workflowService,
+		secretStore:       secretStore,
+		scanLimiter:       scanLimiter,
+		breakers:          breakers,
+		reprocessor:       reprocessor,
+	}
+}
+
+// Metrics reports runtime metrics: the adaptive scan concurrency, execution limits and queue
+// depth, and external API circuit breakers
+func (h *AdminHandler) Metrics(c *gin.Context) {
+	utils.SuccessResponse(c, gin.H{
+		"scan_concurrency": h.scanLimiter.Stats(),
+		"executions":       h.workflowService.ExecutionLimits(),
+		"circuit_breakers": h.breakers.Status(),
+	})
+}
+
+// Rekey re-encrypts all stored secrets with the current encryption key version
+func (h *AdminHandler) Rekey(c *gin.Context) {
+	result, err := h.secretStore.Rekey()
+	if err != nil {
+		utils.InternalErrorResponse(c, "Failed to re-encrypt secrets: "+err.Error())
+		return
+	}
+	utils.SuccessResponse(c, result)
+}
+
+// Reprocess re-parses one page of stored executions or scan results, backfilling structured
+// data, normalized findings and severity summaries. Repeat with the returned cursor until done.
+func (h *AdminHandler) Reprocess(c *gin.Context) {
+	var req services.ReprocessRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequestResponse(c, "Invalid request: "+err.Error())
+			return
+		}
+	}
+
+	result, err := h.reprocessor.Reprocess(c.Request.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidReprocess):
+			utils.BadRequestResponse(c, err.Error())
+		case errors.Is(err, services.ErrReprocessRunning):
+			utils.ErrorResponse(c, http.StatusConflict, err.Error())
+		default:
+			utils.InternalErrorResponse(c, "Failed to reprocess results: "+err.Error())
+		}
+		return
+	}
+	utils.SuccessResponse(c, result)
+}
+
+// ListActiveExecutions lists every execution in flight right now, with the nodes each is running
+func (h *AdminHandler) ListActiveExecutions(c *gin.Context) {
+	utils.SuccessResponse(c, h.workflowService.ActiveExecutions())
+}
+
+// ListNotifications lists queued notifications, e.g. ?status=dead for the dead-letter queue
+func (h *AdminHandler) ListNotifications(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", "pending", "sent", "dead":
+	default:
+		utils.BadRequestResponse(c, "status must be pending, sent or dead")
+		return
+	}
+
+	deliveries, err := h.notificationQueue.List(status)
+	if err != nil {
+		utils.InternalErrorResponse(c, "Failed to fetch notifications")
+		return
+	}
+
+	utils.SuccessResponse(c, deliveries)
+}
+
+// ResendNotification retries a pending or dead-lettered notification immediately
+func (h *AdminHandler) ResendNotification(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid notification ID")
+		return
+	}
+
+	delivery, err := h.notificationQueue.Resend(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.NotFoundResponse(c, "Notification not found")
+		case errors.Is(err, services.ErrNotificationSent):
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			utils.InternalErrorResponse(c, err.Error())
+		}
+		return
+	}
+
+	utils.SuccessMessageResponse(c, "Notification sent", delivery)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/datmedevil17/go-vuln/internal/middleware"
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/datmedevil17/go-vuln/internal/services"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	apiKeys *services.APIKeyService
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes"` // read and/or write; all scopes when omitted
+}
+
+// CreatedAPIKey is a new key with its plaintext value, which is only ever returned here
+type CreatedAPIKey struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+func NewAPIKeyHandler(apiKeys *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeys: apiKeys,
+	}
+}
+
+// ListAPIKeys lists the user's API keys; key values are never returned
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	keys, err := h.apiKeys.List(userID)
+	if err != nil {
+		utils.InternalErrorResponse(c, "Failed to fetch API keys")
+		return
+	}
+
+	utils.SuccessResponse(c, keys)
+}
+
+// CreateAPIKey generates a key and returns it once
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	apiKey, key, err := h.apiKeys.Create(userID, req.Name, req.Scopes)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAPIKeyName),
+			errors.Is(err, services.ErrInvalidAPIKeyScope),
+			errors.Is(err, services.ErrTooManyAPIKeys):
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			utils.InternalErrorResponse(c, "Failed to create API key")
+		}
+		return
+	}
+
+	utils.SuccessMessageResponse(c, "API key created; store it now, it won't be shown again", CreatedAPIKey{APIKey: *apiKey, Key: key})
+}
+
+// RevokeAPIKey disables a key immediately
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid API key ID")
+		return
+	}
+
+	if err := h.apiKeys.Revoke(userID, keyID); err != nil {
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			utils.NotFoundResponse(c, "API key not found")
+			return
+		}
+		utils.InternalErrorResponse(c, "Failed to revoke API key")
+		return
+	}
+
+	utils.SuccessMessageResponse(c, "API key revoked", nil)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/datmedevil17/go-vuln/internal/middleware"
+	"github.com/datmedevil17/go-vuln/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestCreatedAPIKeyIsNotLogged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "api_keys"`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "api_keys"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectCommit()
+
+	var out bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(previous)
+
+	// Bodies are captured as they would be while debugging a development server
+	cfg := &config.Config{}
+	cfg.Server.Mode = "debug"
+	cfg.Logging = config.LoggingConfig{Level: "debug", CaptureBodies: true, BodyLimit: 4096}
+	router := gin.New()
+	router.Use(middleware.LoggerMiddleware(cfg), func(c *gin.Context) { c.Set("user_id", uuid.New()) })
+	router.POST("/api/user/api-keys", NewAPIKeyHandler(services.NewAPIKeyService(db)).CreateAPIKey)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/user/api-keys", strings.NewReader(`{"name":"ci"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Data CreatedAPIKey `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || !strings.HasPrefix(resp.Data.Key, "vpk_") {
+		t.Fatalf("response %s doesn't carry the new key: %v", w.Body, err)
+	}
+
+	logged := out.String()
+	if !strings.Contains(logged, "/api/user/api-keys") {
+		t.Fatalf("request wasn't logged:\n%s", logged)
+	}
+	if strings.Contains(logged, resp.Data.Key) || strings.Contains(logged, strings.TrimPrefix(resp.Data.Key, "vpk_")) {
+		t.Fatalf("logged the new key:\n%s", logged)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
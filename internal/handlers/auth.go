@@ -3,8 +3,10 @@ package handlers
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 
 	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/datmedevil17/go-vuln/internal/middleware"
 	"github.com/datmedevil17/go-vuln/internal/services"
 	"github.com/datmedevil17/go-vuln/internal/utils"
 	"github.com/gin-gonic/gin"
@@ -97,6 +99,87 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 	utils.SuccessResponse(c, user)
 }
 
+// GetPreferences returns the authenticated user's default settings
+func (h *AuthHandler) GetPreferences(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	user, err := h.authService.GetUserByID(userID)
+	if err != nil {
+		utils.NotFoundResponse(c, "User not found")
+		return
+	}
+
+	utils.SuccessResponse(c, user.Preferences)
+}
+
+// UpdatePreferences changes the authenticated user's default settings
+func (h *AuthHandler) UpdatePreferences(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	var req services.PreferencesUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	user, err := h.authService.UpdatePreferences(userID, req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPreference) {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		utils.InternalErrorResponse(c, "Failed to update preferences")
+		return
+	}
+
+	utils.SuccessMessageResponse(c, "Preferences updated successfully", user.Preferences)
+}
+
+// Reauthorize returns a GitHub OAuth URL that adds a write scope to the user's current grant
+func (h *AuthHandler) Reauthorize(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+	if !h.authService.IsConfigured() {
+		utils.InternalErrorResponse(c, "GitHub OAuth is not configured. Set GITHUB_CLIENT_ID and GITHUB_CLIENT_SECRET.")
+		return
+	}
+
+	user, err := h.authService.GetUserByID(userID)
+	if err != nil {
+		utils.NotFoundResponse(c, "User not found")
+		return
+	}
+
+	state := generateRandomState()
+	authURL, scopes, err := h.authService.GetReauthorizeURL(state, user, c.DefaultQuery("scope", "repo"))
+	if err != nil {
+		if errors.Is(err, services.ErrScopeNotAllowed) {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		utils.InternalErrorResponse(c, "Failed to build authorization URL")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"url":     authURL,
+		"state":   state,
+		"scopes":  scopes,
+		"granted": user.GitHubScope,
+	})
+}
+
 // Logout (client-side token deletion)
 func (h *AuthHandler) Logout(c *gin.Context) {
 	utils.SuccessMessageResponse(c, "Logged out successfully", nil)
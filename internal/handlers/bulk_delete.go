@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/services"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// BulkDeleteRequest selects records either by ID or by filter
+type BulkDeleteRequest struct {
+	IDs       []string `json:"ids,omitempty"`
+	Status    string   `json:"status,omitempty"`     // A finished status, e.g. "failed"
+	OlderThan string   `json:"older_than,omitempty"` // Go duration, e.g. "720h"
+}
+
+// bindBulkDelete parses a bulk delete request, writing a 400 response on failure
+func bindBulkDelete(c *gin.Context) ([]uuid.UUID, services.BulkDeleteFilter, bool) {
+	var req BulkDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request: "+err.Error())
+		return nil, services.BulkDeleteFilter{}, false
+	}
+
+	ids := make([]uuid.UUID, 0, len(req.IDs))
+	for _, raw := range req.IDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			utils.BadRequestResponse(c, "Invalid ID: "+raw)
+			return nil, services.BulkDeleteFilter{}, false
+		}
+		ids = append(ids, id)
+	}
+
+	filter := services.BulkDeleteFilter{Status: req.Status}
+	if req.OlderThan != "" {
+		olderThan, err := time.ParseDuration(req.OlderThan)
+		if err != nil || olderThan <= 0 {
+			utils.BadRequestResponse(c, "older_than must be a positive duration such as 720h")
+			return nil, services.BulkDeleteFilter{}, false
+		}
+		filter.OlderThan = olderThan
+	}
+
+	return ids, filter, true
+}
+
+// respondBulkDelete maps bulk delete results to an HTTP response
+func respondBulkDelete(c *gin.Context, deleted int64, err error) {
+	switch {
+	case errors.Is(err, services.ErrNotOwned):
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrEmptyBulkDelete), errors.Is(err, services.ErrInvalidBulkStatus):
+		utils.BadRequestResponse(c, err.Error())
+	case err != nil:
+		utils.InternalErrorResponse(c, "Failed to delete records")
+	default:
+		utils.SuccessMessageResponse(c, "Records deleted successfully", gin.H{"deleted": deleted})
+	}
+}
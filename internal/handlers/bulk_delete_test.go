@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/datmedevil17/go-vuln/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRespondBulkDelete(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"partial ownership", services.ErrNotOwned, http.StatusForbidden},
+		{"no ids or filter", services.ErrEmptyBulkDelete, http.StatusBadRequest},
+		{"active status", fmt.Errorf("%w %q", services.ErrInvalidBulkStatus, "running"), http.StatusBadRequest},
+		{"database failure", errors.New("connection refused"), http.StatusInternalServerError},
+		{"deleted", nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			respondBulkDelete(c, 2, tt.err)
+			if w.Code != tt.want {
+				t.Fatalf("status %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
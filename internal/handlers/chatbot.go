@@ -57,6 +57,48 @@ func (h *ChatbotHandler) Chat(c *gin.Context) {
 	utils.SuccessResponse(c, chatResponse)
 }
 
+// ChatStream handles chatbot interactions, streaming the answer as server-sent events: a
+// "token" event per piece of text, then "done" with the whole response, or "error" if the
+// provider fails part way. Errors before anything was streamed are returned as plain JSON.
+func (h *ChatbotHandler) ChatStream(c *gin.Context) {
+	_, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	var req ChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	// The request context ends when the client disconnects, which cancels the provider call
+	streaming := false
+	response, err := h.aiService.ChatResponseStream(c.Request.Context(), req.Message, req.ConversationHistory, func(token string) error {
+		if !streaming {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			c.Header("X-Accel-Buffering", "no")
+			streaming = true
+		}
+		c.SSEvent("token", gin.H{"text": token})
+		c.Writer.Flush()
+		return c.Request.Context().Err()
+	})
+	if err != nil && !streaming {
+		utils.InternalErrorResponse(c, "Failed to generate response: "+err.Error())
+		return
+	}
+	if err != nil {
+		c.SSEvent("error", gin.H{"error": "Failed to generate response: " + err.Error()})
+	} else {
+		c.SSEvent("done", ChatResponse{Response: response})
+	}
+	c.Writer.Flush()
+}
+
 // ExplainVulnerability explains a specific vulnerability
 func (h *ChatbotHandler) ExplainVulnerability(c *gin.Context) {
 	_, ok := middleware.GetUserID(c)
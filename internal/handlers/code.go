@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/datmedevil17/go-vuln/internal/middleware"
@@ -15,9 +16,10 @@ type CodeHandler struct {
 }
 
 type AnalyzeCodeRequest struct {
-	Code     string `json:"code" binding:"required"`
-	Language string `json:"language" binding:"required"`
-	Filename string `json:"filename,omitempty"`
+	Code           string `json:"code" binding:"required"`
+	Language       string `json:"language" binding:"required"`
+	Filename       string `json:"filename,omitempty"`
+	ReportLanguage string `json:"report_language,omitempty"` // Locale for AI recommendations, defaults to English
 }
 
 type AnalyzeCodeResponse struct {
@@ -49,8 +51,14 @@ func (h *CodeHandler) AnalyzeCode(c *gin.Context) {
 		return
 	}
 
+	reportLanguage, err := services.NormalizeLanguage(req.ReportLanguage)
+	if err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
 	// Generate embedding and find patterns
-	_, err := h.embeddingService.GenerateCodeEmbedding(req.Code, req.Language)
+	_, err = h.embeddingService.GenerateCodeEmbedding(req.Code, req.Language)
 	if err != nil {
 		utils.InternalErrorResponse(c, "Failed to generate code embedding")
 		return
@@ -61,7 +69,9 @@ func (h *CodeHandler) AnalyzeCode(c *gin.Context) {
 
 	// Get AI analysis
 	analysis, err := h.aiService.AnalyzeCode(c.Request.Context(), req.Code, req.Language)
-	if err != nil {
+	if errors.Is(err, services.ErrContentBlocked) {
+		analysis = "AI analysis was blocked by the provider's safety filters. Showing pattern-based analysis only."
+	} else if err != nil {
 		// If AI fails, still return pattern-based results
 		analysis = "AI analysis unavailable. Showing pattern-based analysis only."
 	}
@@ -73,7 +83,11 @@ func (h *CodeHandler) AnalyzeCode(c *gin.Context) {
 	recommendations := ""
 	if len(vulnerabilities) > 0 {
 		vulnDescription := "Found: " + joinStrings(vulnerabilities, ", ")
-		recs, err := h.aiService.GenerateSecurityRecommendations(c.Request.Context(), vulnDescription)
+		findings := make([]services.ReportFinding, len(vulnerabilities))
+		for i, v := range vulnerabilities {
+			findings[i] = services.ReportFinding{Title: v, Severity: services.SeverityMedium}
+		}
+		recs, err := h.aiService.GenerateSecurityRecommendations(c.Request.Context(), vulnDescription, findings, reportLanguage)
 		if err == nil {
 			recommendations = recs
 		}
@@ -164,6 +178,67 @@ func (h *CodeHandler) CompareCode(c *gin.Context) {
 	utils.SuccessResponse(c, response)
 }
 
+// maxDiffInputSize caps each side of a diff request
+const maxDiffInputSize = 1 << 20
+
+type DiffCodeRequest struct {
+	Original string `json:"original"`
+	Modified string `json:"modified"`
+	Filename string `json:"filename,omitempty"`
+	Context  *int   `json:"context,omitempty"`
+}
+
+// DiffCode returns a unified diff and per-hunk metadata between two versions of a file
+func (h *CodeHandler) DiffCode(c *gin.Context) {
+	_, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	var req DiffCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request: "+err.Error())
+		return
+	}
+	if len(req.Original) > maxDiffInputSize || len(req.Modified) > maxDiffInputSize {
+		utils.BadRequestResponse(c, "Each side of the diff must be at most 1 MB")
+		return
+	}
+
+	context := utils.DefaultDiffContext
+	if req.Context != nil {
+		if *req.Context < 0 || *req.Context > 100 {
+			utils.BadRequestResponse(c, "context must be between 0 and 100")
+			return
+		}
+		context = *req.Context
+	}
+
+	filename := req.Filename
+	if filename == "" {
+		filename = "file"
+	}
+
+	hunks := utils.DiffLines(req.Original, req.Modified, context)
+	added, removed := 0, 0
+	for _, hunk := range hunks {
+		added += hunk.Added
+		removed += hunk.Removed
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"unified":   utils.UnifiedDiff("a/"+filename, "b/"+filename, hunks),
+		"hunks":     hunks,
+		"identical": len(hunks) == 0,
+		"stats": gin.H{
+			"hunks":   len(hunks),
+			"added":   added,
+			"removed": removed,
+		},
+	})
+}
+
 // Helper functions
 
 func calculateSecurityScore(vulnerabilities []string) int {
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"net/http"
 	"strings"
 
 	"github.com/datmedevil17/go-vuln/internal/middleware"
@@ -76,7 +78,7 @@ func (h *GitHubHandler) ListRepositories(c *gin.Context) {
 
 	repositories, err := h.githubService.ListRepositories(c.Request.Context(), user.AccessToken, userID)
 	if err != nil {
-		utils.InternalErrorResponse(c, "Failed to fetch repositories: "+err.Error())
+		githubErrorResponse(c, "Failed to fetch repositories: ", err)
 		return
 	}
 
@@ -107,7 +109,7 @@ func (h *GitHubHandler) GetRepositoryFiles(c *gin.Context) {
 
 	files, err := h.githubService.GetRepositoryFiles(c.Request.Context(), user.AccessToken, owner, repo, path)
 	if err != nil {
-		utils.InternalErrorResponse(c, "Failed to fetch files: "+err.Error())
+		githubErrorResponse(c, "Failed to fetch files: ", err)
 		return
 	}
 
@@ -139,7 +141,7 @@ func (h *GitHubHandler) GetFileContent(c *gin.Context) {
 
 	content, err := h.githubService.GetFileContent(c.Request.Context(), user.AccessToken, owner, repo, path)
 	if err != nil {
-		utils.InternalErrorResponse(c, "Failed to fetch file content: "+err.Error())
+		githubErrorResponse(c, "Failed to fetch file content: ", err)
 		return
 	}
 
@@ -148,3 +150,13 @@ func (h *GitHubHandler) GetFileContent(c *gin.Context) {
 		"content": content,
 	})
 }
+
+// githubErrorResponse reports a failed GitHub call, as 429 when GitHub's rate limit outlasted
+// the retries so clients know to come back later
+func githubErrorResponse(c *gin.Context, message string, err error) {
+	if errors.Is(err, services.ErrRateLimited) {
+		utils.ErrorResponse(c, http.StatusTooManyRequests, message+err.Error())
+		return
+	}
+	utils.InternalErrorResponse(c, message+err.Error())
+}
@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"errors"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/datmedevil17/go-vuln/internal/middleware"
@@ -45,6 +48,10 @@ func (h *ScannerHandler) NmapScan(c *gin.Context) {
 
 	result, err := h.scannerService.NmapScan(c.Request.Context(), userID, req.Target, ports)
 	if err != nil {
+		if errors.Is(err, services.ErrTargetNotAllowed) {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
 		utils.InternalErrorResponse(c, "Failed to start scan: "+err.Error())
 		return
 	}
@@ -68,6 +75,10 @@ func (h *ScannerHandler) NiktoScan(c *gin.Context) {
 
 	result, err := h.scannerService.NiktoScan(c.Request.Context(), userID, req.Target)
 	if err != nil {
+		if errors.Is(err, services.ErrTargetNotAllowed) {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
 		utils.InternalErrorResponse(c, "Failed to start scan: "+err.Error())
 		return
 	}
@@ -91,6 +102,10 @@ func (h *ScannerHandler) GobusterScan(c *gin.Context) {
 
 	result, err := h.scannerService.GobusterScan(c.Request.Context(), userID, req.Target, req.Wordlist)
 	if err != nil {
+		if errors.Is(err, services.ErrWordlistNotFound) || errors.Is(err, services.ErrTargetNotAllowed) {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
 		utils.InternalErrorResponse(c, "Failed to start scan: "+err.Error())
 		return
 	}
@@ -121,7 +136,39 @@ func (h *ScannerHandler) GetScanResult(c *gin.Context) {
 	utils.SuccessResponse(c, result)
 }
 
-// ListScanResults lists all scan results
+// VerifyScanResult checks that a stored scan result still matches the signature taken when it finished
+func (h *ScannerHandler) VerifyScanResult(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	scanID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid scan ID")
+		return
+	}
+
+	verification, err := h.scannerService.VerifyScanResult(scanID, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrSigningDisabled) {
+			utils.ErrorResponse(c, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		utils.NotFoundResponse(c, "Scan result not found")
+		return
+	}
+
+	utils.SuccessResponse(c, verification)
+}
+
+// GetCapabilities reports which scanner binaries are installed and which node types will return mock data
+func (h *ScannerHandler) GetCapabilities(c *gin.Context) {
+	utils.SuccessResponse(c, h.scannerService.SelfTest())
+}
+
+// ListScanResults lists all scan results, or with ?execution_id= those of one workflow execution
 func (h *ScannerHandler) ListScanResults(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
 	if !ok {
@@ -129,11 +176,38 @@ func (h *ScannerHandler) ListScanResults(c *gin.Context) {
 		return
 	}
 
-	results, err := h.scannerService.ListScanResults(userID)
+	var executionID *uuid.UUID
+	if raw := c.Query("execution_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			utils.BadRequestResponse(c, "Invalid execution ID")
+			return
+		}
+		executionID = &id
+	}
+
+	results, err := h.scannerService.ListScanResults(userID, executionID)
 	if err != nil {
 		utils.InternalErrorResponse(c, "Failed to fetch scan results")
 		return
 	}
 
 	utils.SuccessResponse(c, results)
-}
\ No newline at end of file
+}
+
+// DeleteScanResults bulk-deletes scan results by ID or filter
+func (h *ScannerHandler) DeleteScanResults(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	ids, filter, ok := bindBulkDelete(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.scannerService.DeleteScanResults(userID, ids, filter)
+	respondBulkDelete(c, deleted, err)
+}
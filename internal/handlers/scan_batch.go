@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/datmedevil17/go-vuln/internal/middleware"
+	"github.com/datmedevil17/go-vuln/internal/services"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// BulkScanRequest is the JSON form of a bulk scan; the multipart form takes the same
+// fields with the targets as a CSV upload in "file"
+type BulkScanRequest struct {
+	ScanType string   `json:"scan_type" form:"scan_type" binding:"required"`
+	Targets  []string `json:"targets"`
+	Ports    string   `json:"ports,omitempty" form:"ports"`
+	Wordlist string   `json:"wordlist,omitempty" form:"wordlist"`
+}
+
+// BulkScan starts one scan per target from a JSON list or an uploaded CSV file
+func (h *ScannerHandler) BulkScan(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	var req BulkScanRequest
+	var rows []services.BatchRow
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		if err := c.ShouldBind(&req); err != nil {
+			utils.BadRequestResponse(c, "Invalid request: "+err.Error())
+			return
+		}
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			utils.BadRequestResponse(c, "A CSV file of targets is required")
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			utils.BadRequestResponse(c, "Failed to read uploaded file")
+			return
+		}
+		defer file.Close()
+		if rows, err = services.ParseTargetCSV(file); err != nil {
+			utils.BadRequestResponse(c, "Failed to read CSV: "+err.Error())
+			return
+		}
+	} else {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequestResponse(c, "Invalid request: "+err.Error())
+			return
+		}
+		rows = services.TargetRows(req.Targets)
+	}
+
+	batch, err := h.scannerService.StartBatch(userID, req.ScanType, rows, services.BatchScanOptions{
+		Ports:    req.Ports,
+		Wordlist: req.Wordlist,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnsupportedBatchScan),
+			errors.Is(err, services.ErrEmptyBatch),
+			errors.Is(err, services.ErrBatchTooLarge),
+			errors.Is(err, services.ErrWordlistNotFound):
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			utils.InternalErrorResponse(c, "Failed to start batch: "+err.Error())
+		}
+		return
+	}
+
+	utils.SuccessMessageResponse(c, "Batch scan started", batch)
+}
+
+// GetBatch retrieves a batch with the progress of each target
+func (h *ScannerHandler) GetBatch(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	batchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid batch ID")
+		return
+	}
+
+	batch, err := h.scannerService.GetBatch(batchID, userID)
+	if err != nil {
+		utils.NotFoundResponse(c, "Batch not found")
+		return
+	}
+
+	utils.SuccessResponse(c, batch)
+}
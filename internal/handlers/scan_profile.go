@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/datmedevil17/go-vuln/internal/middleware"
+	"github.com/datmedevil17/go-vuln/internal/services"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ScanProfileHandler struct {
+	profileService *services.ScanProfileService
+}
+
+type CreateScanProfileRequest struct {
+	Name        string                 `json:"name" binding:"required"`
+	ScannerType string                 `json:"scanner_type" binding:"required"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+type UpdateScanProfileRequest struct {
+	Name        *string                `json:"name,omitempty"`
+	Description *string                `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+func NewScanProfileHandler(profileService *services.ScanProfileService) *ScanProfileHandler {
+	return &ScanProfileHandler{
+		profileService: profileService,
+	}
+}
+
+// CreateProfile saves a reusable scanner configuration
+func (h *ScanProfileHandler) CreateProfile(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	var req CreateScanProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	profile, err := h.profileService.CreateProfile(userID, req.Name, req.ScannerType, req.Description, req.Parameters)
+	if err != nil {
+		respondScanProfileError(c, err)
+		return
+	}
+
+	utils.SuccessMessageResponse(c, "Scan profile created successfully", profile)
+}
+
+// ListProfiles retrieves all scan profiles for the user
+func (h *ScanProfileHandler) ListProfiles(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	profiles, err := h.profileService.ListProfiles(userID)
+	if err != nil {
+		utils.InternalErrorResponse(c, "Failed to fetch scan profiles")
+		return
+	}
+
+	utils.SuccessResponse(c, profiles)
+}
+
+// GetProfile retrieves a specific scan profile
+func (h *ScanProfileHandler) GetProfile(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid profile ID")
+		return
+	}
+
+	profile, err := h.profileService.GetProfile(profileID, userID)
+	if err != nil {
+		utils.NotFoundResponse(c, "Scan profile not found")
+		return
+	}
+
+	utils.SuccessResponse(c, profile)
+}
+
+// UpdateProfile updates a scan profile
+func (h *ScanProfileHandler) UpdateProfile(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid profile ID")
+		return
+	}
+
+	var req UpdateScanProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	profile, err := h.profileService.UpdateProfile(profileID, userID, req.Name, req.Description, req.Parameters)
+	if err != nil {
+		respondScanProfileError(c, err)
+		return
+	}
+
+	utils.SuccessMessageResponse(c, "Scan profile updated successfully", profile)
+}
+
+// DeleteProfile deletes a scan profile
+func (h *ScanProfileHandler) DeleteProfile(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid profile ID")
+		return
+	}
+
+	if err := h.profileService.DeleteProfile(profileID, userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Scan profile not found")
+			return
+		}
+		utils.InternalErrorResponse(c, "Failed to delete scan profile")
+		return
+	}
+
+	utils.SuccessMessageResponse(c, "Scan profile deleted successfully", nil)
+}
+
+// respondScanProfileError maps service errors to HTTP responses
+func respondScanProfileError(c *gin.Context, err error) {
+	switch {
+	case err == gorm.ErrRecordNotFound:
+		utils.NotFoundResponse(c, "Scan profile not found")
+	case strings.Contains(err.Error(), "duplicate key"):
+		utils.ErrorResponse(c, http.StatusConflict, "A scan profile with this name already exists")
+	case strings.HasPrefix(err.Error(), "failed to"):
+		utils.InternalErrorResponse(c, "Failed to save scan profile")
+	default:
+		utils.BadRequestResponse(c, err.Error())
+	}
+}
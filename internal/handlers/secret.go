@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/datmedevil17/go-vuln/internal/middleware"
+	"github.com/datmedevil17/go-vuln/internal/services"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type SecretHandler struct {
+	secretStore *services.SecretStore
+}
+
+type PutSecretRequest struct {
+	Value string `json:"value" binding:"required"`
+}
+
+func NewSecretHandler(secretStore *services.SecretStore) *SecretHandler {
+	return &SecretHandler{
+		secretStore: secretStore,
+	}
+}
+
+// ListSecrets lists the names of the user's secrets; values are never returned
+func (h *SecretHandler) ListSecrets(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	secrets, err := h.secretStore.List(userID)
+	if err != nil {
+		utils.InternalErrorResponse(c, "Failed to fetch secrets")
+		return
+	}
+
+	utils.SuccessResponse(c, secrets)
+}
+
+// PutSecret creates or replaces a secret, e.g. a session cookie for authenticated scans
+func (h *SecretHandler) PutSecret(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	var req PutSecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request: a secret value is required")
+		return
+	}
+
+	secret, err := h.secretStore.Put(userID, c.Param("name"), req.Value)
+	if err != nil {
+		respondSecretError(c, err)
+		return
+	}
+
+	utils.SuccessMessageResponse(c, "Secret saved successfully", secret)
+}
+
+// DeleteSecret removes a secret
+func (h *SecretHandler) DeleteSecret(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	if err := h.secretStore.Delete(userID, c.Param("name")); err != nil {
+		respondSecretError(c, err)
+		return
+	}
+
+	utils.SuccessMessageResponse(c, "Secret deleted successfully", nil)
+}
+
+// respondSecretError maps secret store errors to HTTP responses
+func respondSecretError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrSecretNotFound):
+		utils.NotFoundResponse(c, "Secret not found")
+	case errors.Is(err, services.ErrInvalidSecretName), errors.Is(err, services.ErrInvalidSecret):
+		utils.BadRequestResponse(c, err.Error())
+	default:
+		utils.InternalErrorResponse(c, "Failed to update secret")
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/datmedevil17/go-vuln/internal/middleware"
+	"github.com/datmedevil17/go-vuln/internal/services"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type TargetHandler struct {
+	targetService *services.TargetService
+}
+
+func NewTargetHandler(targetService *services.TargetService) *TargetHandler {
+	return &TargetHandler{
+		targetService: targetService,
+	}
+}
+
+// GetTargetHistory returns a severity timeline for a host across every workflow and standalone scan
+func (h *TargetHandler) GetTargetHistory(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	history, err := h.targetService.GetHistory(userID, c.Param("host"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTarget) {
+			utils.BadRequestResponse(c, "Invalid target host")
+			return
+		}
+		utils.InternalErrorResponse(c, "Failed to load target history")
+		return
+	}
+
+	utils.SuccessResponse(c, history)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/datmedevil17/go-vuln/internal/middleware"
+	"github.com/datmedevil17/go-vuln/internal/services"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ListWordlists lists the gobuster wordlists the user can reference by name
+func (h *ScannerHandler) ListWordlists(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	lists, err := h.scannerService.Wordlists().List(userID)
+	if err != nil {
+		utils.InternalErrorResponse(c, "Failed to fetch wordlists")
+		return
+	}
+
+	utils.SuccessResponse(c, lists)
+}
+
+// UploadWordlist stores a custom wordlist sent as multipart form fields "name" and "file"
+func (h *ScannerHandler) UploadWordlist(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	name := c.PostForm("name")
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.BadRequestResponse(c, "A wordlist file is required")
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.BadRequestResponse(c, "Failed to read uploaded file")
+		return
+	}
+	defer file.Close()
+
+	list, err := h.scannerService.Wordlists().Save(userID, name, file)
+	if err != nil {
+		respondWordlistError(c, err)
+		return
+	}
+
+	utils.SuccessMessageResponse(c, "Wordlist uploaded successfully", list)
+}
+
+// DeleteWordlist removes one of the user's custom wordlists
+func (h *ScannerHandler) DeleteWordlist(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	if err := h.scannerService.Wordlists().Delete(userID, c.Param("name")); err != nil {
+		respondWordlistError(c, err)
+		return
+	}
+
+	utils.SuccessMessageResponse(c, "Wordlist deleted successfully", nil)
+}
+
+// respondWordlistError maps wordlist registry errors to HTTP responses
+func respondWordlistError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrWordlistNotFound):
+		utils.NotFoundResponse(c, err.Error())
+	case errors.Is(err, services.ErrWordlistExists):
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrInvalidWordlistName),
+		errors.Is(err, services.ErrWordlistTooLarge),
+		errors.Is(err, services.ErrWordlistNotText):
+		utils.BadRequestResponse(c, err.Error())
+	default:
+		utils.InternalErrorResponse(c, "Failed to update wordlist")
+	}
+}
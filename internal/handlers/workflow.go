@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"strconv"
 
 	"github.com/datmedevil17/go-vuln/internal/middleware"
 	"github.com/datmedevil17/go-vuln/internal/models"
@@ -27,6 +31,35 @@ type UpdateWorkflowRequest struct {
 	IsActive        *bool          `json:"is_active,omitempty"`
 	ScheduleEnabled *bool          `json:"schedule_enabled,omitempty"`
 	ScheduleFreq    *string        `json:"schedule_frequency,omitempty"`
+	ScheduleTime    *string        `json:"schedule_time,omitempty"`
+	ScheduleDay     *int           `json:"schedule_day,omitempty"`
+	ScheduleTZ      *string        `json:"schedule_timezone,omitempty"`
+	MaxDuration     *int           `json:"max_duration,omitempty"`
+	FailOnNew       *bool          `json:"fail_on_new_findings,omitempty"`
+	StopOnCritical  *bool          `json:"stop_on_critical,omitempty"`
+	WebhookURL      *string        `json:"webhook_url,omitempty"`
+	WebhookSecret   *string        `json:"webhook_secret,omitempty"`
+	Concurrency     *string        `json:"concurrency_policy,omitempty"`
+}
+
+// ExecuteWorkflowRequest is the optional body of an execute request
+type ExecuteWorkflowRequest struct {
+	Tags  []string `json:"tags,omitempty"`
+	Notes string   `json:"notes,omitempty"`
+}
+
+type UpdateExecutionRequest struct {
+	Tags  *[]string `json:"tags,omitempty"`
+	Notes *string   `json:"notes,omitempty"`
+}
+
+type RollbackAutoFixRequest struct {
+	NodeID       string `json:"node_id,omitempty"`       // Needed only when several auto-fix nodes opened PRs
+	RevertMerged bool   `json:"revert_merged,omitempty"` // Open a revert PR if the fix was already merged
+}
+
+type SetBaselineRequest struct {
+	ExecutionID string `json:"execution_id" binding:"required"`
 }
 
 func NewWorkflowHandler(workflowService *services.WorkflowService) *WorkflowHandler {
@@ -81,6 +114,11 @@ func (h *WorkflowHandler) GetWorkflow(c *gin.Context) {
 	utils.SuccessResponse(c, workflow)
 }
 
+// ListNodeTypes returns the node types the executor supports, with a JSON schema for each node's data
+func (h *WorkflowHandler) ListNodeTypes(c *gin.Context) {
+	utils.SuccessResponse(c, services.NodeTypes())
+}
+
 // ListWorkflows retrieves all workflows for the user
 func (h *WorkflowHandler) ListWorkflows(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
@@ -138,6 +176,37 @@ func (h *WorkflowHandler) UpdateWorkflow(c *gin.Context) {
 	if req.ScheduleFreq != nil {
 		updates["schedule_frequency"] = *req.ScheduleFreq
 	}
+	if req.ScheduleTime != nil {
+		updates["schedule_time"] = *req.ScheduleTime
+	}
+	if req.ScheduleDay != nil {
+		updates["schedule_day"] = *req.ScheduleDay
+	}
+	if req.ScheduleTZ != nil {
+		updates["schedule_timezone"] = *req.ScheduleTZ
+	}
+	if req.MaxDuration != nil {
+		if *req.MaxDuration < 0 {
+			utils.BadRequestResponse(c, "max_duration must be zero or positive")
+			return
+		}
+		updates["max_duration"] = *req.MaxDuration
+	}
+	if req.FailOnNew != nil {
+		updates["fail_on_new_findings"] = *req.FailOnNew
+	}
+	if req.StopOnCritical != nil {
+		updates["stop_on_critical"] = *req.StopOnCritical
+	}
+	if req.WebhookURL != nil {
+		updates["webhook_url"] = *req.WebhookURL
+	}
+	if req.WebhookSecret != nil {
+		updates["webhook_secret"] = *req.WebhookSecret
+	}
+	if req.Concurrency != nil {
+		updates["concurrency_policy"] = *req.Concurrency
+	}
 
 	workflow, err := h.workflowService.UpdateWorkflow(workflowID, userID, updates)
 	if err != nil {
@@ -145,6 +214,10 @@ func (h *WorkflowHandler) UpdateWorkflow(c *gin.Context) {
 			utils.NotFoundResponse(c, "Workflow not found")
 			return
 		}
+		if errors.Is(err, services.ErrInvalidSchedule) || errors.Is(err, services.ErrInvalidWebhook) || errors.Is(err, services.ErrWorkflowTooLarge) || errors.Is(err, services.ErrDuplicateNodeID) || errors.Is(err, services.ErrInvalidConcurrencyPolicy) {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
 		log.Printf("Error updating workflow %s: %v", workflowID, err)
 		utils.InternalErrorResponse(c, "Failed to update workflow: "+err.Error())
 		return
@@ -175,6 +248,48 @@ func (h *WorkflowHandler) DeleteWorkflow(c *gin.Context) {
 	utils.SuccessMessageResponse(c, "Workflow deleted successfully", nil)
 }
 
+// SetBaseline marks an execution as the workflow's security baseline
+func (h *WorkflowHandler) SetBaseline(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	workflowID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid workflow ID")
+		return
+	}
+
+	var req SetBaselineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	executionID, err := uuid.Parse(req.ExecutionID)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid execution ID")
+		return
+	}
+
+	workflow, err := h.workflowService.SetBaseline(workflowID, userID, executionID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Workflow or execution not found")
+			return
+		}
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	utils.SuccessMessageResponse(c, "Baseline updated", gin.H{
+		"workflow_id":           workflow.ID.String(),
+		"baseline_execution_id": executionID.String(),
+	})
+}
+
 // ExecuteWorkflow executes a workflow
 func (h *WorkflowHandler) ExecuteWorkflow(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
@@ -196,21 +311,370 @@ func (h *WorkflowHandler) ExecuteWorkflow(c *gin.Context) {
 		return
 	}
 
+	// The body is optional, so a plain POST still starts a run
+	var req ExecuteWorkflowRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequestResponse(c, "Invalid request: "+err.Error())
+			return
+		}
+	}
+
 	// Execute workflow asynchronously
-	execution, err := h.workflowService.ExecuteWorkflow(workflow, userID)
+	execution, err := h.workflowService.ExecuteWorkflow(c.Request.Context(), workflow, userID, services.ExecutionLabels{Tags: req.Tags, Notes: req.Notes})
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidLabels) {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrWorkflowRunning) {
+			utils.ErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
 		utils.InternalErrorResponse(c, "Failed to start execution")
 		return
 	}
 
 	utils.SuccessResponse(c, gin.H{
-		"message":      "Workflow execution started",
+		"message":      launchMessage(execution.Status),
 		"execution_id": execution.ID.String(),
 		"workflow_id":  workflowID.String(),
+		"status":       execution.Status,
+	})
+}
+
+// TestNode checks one node's configuration without running the workflow: notification
+// nodes send a sample message and scanner nodes probe their target
+func (h *WorkflowHandler) TestNode(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	workflowID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid workflow ID")
+		return
+	}
+
+	result, err := h.workflowService.TestNode(c.Request.Context(), workflowID, userID, c.Param("nodeId"))
+	if err != nil {
+		switch {
+		case err == gorm.ErrRecordNotFound:
+			utils.NotFoundResponse(c, "Workflow not found")
+		case errors.Is(err, services.ErrNodeNotFound):
+			utils.NotFoundResponse(c, err.Error())
+		case errors.Is(err, services.ErrNodeNotTestable):
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			utils.InternalErrorResponse(c, "Failed to test node: "+err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, result)
+}
+
+// LintWorkflow warns about likely mistakes in a workflow, such as scanners with no target
+// upstream or nodes no trigger leads to, without running it
+func (h *WorkflowHandler) LintWorkflow(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	workflowID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid workflow ID")
+		return
+	}
+
+	warnings, err := h.workflowService.LintWorkflow(c.Request.Context(), workflowID, userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Workflow not found")
+			return
+		}
+		utils.InternalErrorResponse(c, "Failed to lint workflow: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"workflow_id": workflowID.String(),
+		"warnings":    warnings,
+	})
+}
+
+// ValidateWorkflow checks a workflow's graph for problems that would fail its runs, such as
+// dangling edges, cycles or unknown node types, listing every one found
+func (h *WorkflowHandler) ValidateWorkflow(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	workflowID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid workflow ID")
+		return
+	}
+
+	problems, err := h.workflowService.ValidateWorkflow(c.Request.Context(), workflowID, userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Workflow not found")
+			return
+		}
+		utils.InternalErrorResponse(c, "Failed to validate workflow: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"workflow_id": workflowID.String(),
+		"valid":       len(problems) == 0,
+		"errors":      problems,
+	})
+}
+
+// GetWorkflowExecution retrieves a single execution with the workflow snapshot it ran
+func (h *WorkflowHandler) GetWorkflowExecution(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	executionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid execution ID")
+		return
+	}
+
+	execution, err := h.workflowService.GetWorkflowExecution(executionID, userID)
+	if err != nil {
+		utils.NotFoundResponse(c, "Execution not found")
+		return
+	}
+
+	utils.SuccessResponse(c, execution)
+}
+
+// UpdateExecution edits an execution's tags and notes
+func (h *WorkflowHandler) UpdateExecution(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	executionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid execution ID")
+		return
+	}
+
+	var req UpdateExecutionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "Invalid request: "+err.Error())
+		return
+	}
+	if req.Tags == nil && req.Notes == nil {
+		utils.BadRequestResponse(c, "tags or notes are required")
+		return
+	}
+
+	execution, err := h.workflowService.UpdateExecutionLabels(executionID, userID, req.Tags, req.Notes)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Execution not found")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidLabels) {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		utils.InternalErrorResponse(c, "Failed to update execution")
+		return
+	}
+
+	utils.SuccessMessageResponse(c, "Execution updated successfully", execution)
+}
+
+// GetExecutionLogs retrieves the log lines an execution wrote, with timestamps and node context
+func (h *WorkflowHandler) GetExecutionLogs(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	executionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid execution ID")
+		return
+	}
+
+	logs, err := h.workflowService.GetExecutionLogs(executionID, userID)
+	if err != nil {
+		utils.NotFoundResponse(c, "Execution not found")
+		return
+	}
+
+	utils.SuccessResponse(c, logs)
+}
+
+// GetExecutionResults retrieves the node results recorded after ?since=<nodeId>, for incremental polling
+func (h *WorkflowHandler) GetExecutionResults(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	executionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid execution ID")
+		return
+	}
+
+	page, err := h.workflowService.GetExecutionResults(executionID, userID, c.Query("since"))
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownResultNode) {
+			utils.BadRequestResponse(c, "since must be a node that has already recorded a result")
+			return
+		}
+		utils.NotFoundResponse(c, "Execution not found")
+		return
+	}
+
+	utils.SuccessResponse(c, page)
+}
+
+// ReplayExecution re-runs a past execution with the same workflow snapshot and inputs
+func (h *WorkflowHandler) ReplayExecution(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	executionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid execution ID")
+		return
+	}
+
+	execution, err := h.workflowService.ReplayExecution(c.Request.Context(), executionID, userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Execution not found")
+			return
+		}
+		if errors.Is(err, services.ErrWorkflowRunning) {
+			utils.ErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		utils.BadRequestResponse(c, "Failed to replay execution: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"message":       launchMessage(execution.Status),
+		"execution_id":  execution.ID.String(),
+		"workflow_id":   execution.WorkflowID.String(),
+		"replayed_from": executionID.String(),
+		"status":        execution.Status,
 	})
 }
 
-// ListWorkflowExecutions retrieves all workflow executions
+// CancelExecution stops a queued or running execution
+func (h *WorkflowHandler) CancelExecution(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	executionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid execution ID")
+		return
+	}
+
+	if err := h.workflowService.CancelExecution(executionID, userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "Execution not found")
+			return
+		}
+		if errors.Is(err, services.ErrExecutionFinished) {
+			utils.NotFoundResponse(c, "Execution is not running")
+			return
+		}
+		utils.InternalErrorResponse(c, "Failed to cancel execution")
+		return
+	}
+
+	utils.SuccessMessageResponse(c, "Execution cancelled", gin.H{
+		"execution_id": executionID.String(),
+	})
+}
+
+// launchMessage describes what became of a run the workflow's concurrency policy admitted
+func launchMessage(status string) string {
+	switch status {
+	case "queued":
+		return "Workflow execution queued until the current run finishes"
+	case "skipped":
+		return "Workflow execution skipped because a run is already in progress"
+	}
+	return "Workflow execution started"
+}
+
+// RollbackAutoFix closes an execution's auto-fix PR and deletes its branch, or opens a revert PR once merged
+func (h *WorkflowHandler) RollbackAutoFix(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	executionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid execution ID")
+		return
+	}
+
+	var req RollbackAutoFixRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequestResponse(c, "Invalid request: "+err.Error())
+			return
+		}
+	}
+
+	rollback, err := h.workflowService.RollbackAutoFix(c.Request.Context(), executionID, userID, req.NodeID, req.RevertMerged)
+	if err != nil {
+		switch {
+		case err == gorm.ErrRecordNotFound:
+			utils.NotFoundResponse(c, "Execution not found")
+		case errors.Is(err, services.ErrNoAutoFix), errors.Is(err, services.ErrAmbiguousAutoFix), errors.Is(err, services.ErrMissingGitHubScope):
+			utils.BadRequestResponse(c, err.Error())
+		case errors.Is(err, services.ErrAutoFixRolledBack), errors.Is(err, services.ErrAutoFixMerged):
+			utils.ErrorResponse(c, http.StatusConflict, err.Error())
+		default:
+			utils.InternalErrorResponse(c, "Failed to roll back auto-fix: "+err.Error())
+		}
+		return
+	}
+
+	utils.SuccessMessageResponse(c, "Auto-fix rolled back", rollback)
+}
+
+// ListWorkflowExecutions retrieves a page of workflow executions, newest first, filtered by
+// ?status=, ?workflow_id= and every ?tag= given and paged with ?limit= and ?offset=
 func (h *WorkflowHandler) ListWorkflowExecutions(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
 	if !ok {
@@ -218,7 +682,29 @@ func (h *WorkflowHandler) ListWorkflowExecutions(c *gin.Context) {
 		return
 	}
 
-	executions, err := h.workflowService.ListWorkflowExecutions(userID)
+	filter := services.ExecutionListFilter{
+		Tags:   c.QueryArray("tag"),
+		Status: c.Query("status"),
+	}
+	if raw := c.Query("workflow_id"); raw != "" {
+		workflowID, err := uuid.Parse(raw)
+		if err != nil {
+			utils.BadRequestResponse(c, "Invalid workflow_id")
+			return
+		}
+		filter.WorkflowID = &workflowID
+	}
+	var err error
+	if filter.Limit, err = queryInt(c, "limit"); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+	if filter.Offset, err = queryInt(c, "offset"); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	executions, err := h.workflowService.ListWorkflowExecutions(userID, filter)
 	if err != nil {
 		utils.InternalErrorResponse(c, "Failed to fetch workflow executions")
 		return
@@ -226,3 +712,72 @@ func (h *WorkflowHandler) ListWorkflowExecutions(c *gin.Context) {
 
 	utils.SuccessResponse(c, executions)
 }
+
+// queryInt reads a non-negative integer query parameter, 0 when it is absent
+func queryInt(c *gin.Context, name string) (int, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", name)
+	}
+	return value, nil
+}
+
+// DeleteWorkflowExecutions bulk-deletes executions by ID or filter
+func (h *WorkflowHandler) DeleteWorkflowExecutions(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	ids, filter, ok := bindBulkDelete(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.workflowService.DeleteWorkflowExecutions(userID, ids, filter)
+	respondBulkDelete(c, deleted, err)
+}
+
+// ToggleWorkflowPin pins or unpins a workflow
+func (h *WorkflowHandler) ToggleWorkflowPin(c *gin.Context) {
+	h.togglePin(c, "Invalid workflow ID", "Workflow not found", h.workflowService.ToggleWorkflowPin)
+}
+
+// ToggleExecutionPin pins or unpins an execution
+func (h *WorkflowHandler) ToggleExecutionPin(c *gin.Context) {
+	h.togglePin(c, "Invalid execution ID", "Execution not found", h.workflowService.ToggleExecutionPin)
+}
+
+func (h *WorkflowHandler) togglePin(c *gin.Context, invalidMsg, notFoundMsg string, toggle func(id, userID uuid.UUID) (bool, error)) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequestResponse(c, invalidMsg)
+		return
+	}
+
+	pinned, err := toggle(id, userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, notFoundMsg)
+			return
+		}
+		utils.InternalErrorResponse(c, "Failed to update pin")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"id":     id.String(),
+		"pinned": pinned,
+	})
+}
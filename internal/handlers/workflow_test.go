@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestListWorkflowExecutionsRejectsBadQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Every case is rejected before the service is reached
+	handler := &WorkflowHandler{}
+	for _, query := range []string{
+		"?limit=abc",
+		"?limit=-1",
+		"?offset=1.5",
+		"?offset=-20",
+		"?workflow_id=not-a-uuid",
+	} {
+		t.Run(query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/workflows/reports"+query, nil)
+			c.Set("user_id", uuid.New())
+
+			handler.ListWorkflowExecutions(c)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status %d, want 400", w.Code)
+			}
+		})
+	}
+}
+
+func TestQueryInt(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		query   string
+		want    int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"?limit=0", 0, false},
+		{"?limit=75", 75, false},
+		{"?limit=", 0, false},
+		{"?limit=7e2", 0, true},
+		{"?limit=-3", 0, true},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/"+tt.query, nil)
+		got, err := queryInt(c, "limit")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("queryInt(%q) = %d, %v; want %d, error %v", tt.query, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware only lets through users listed in ADMIN_USERS.
+// It must run after AuthMiddleware. With no admins configured every request is rejected.
+func AdminMiddleware(cfg *config.Config) gin.HandlerFunc {
+	admins := make(map[string]struct{}, len(cfg.Security.AdminUsers))
+	for _, username := range cfg.Security.AdminUsers {
+		admins[strings.ToLower(username)] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		username, ok := GetUsername(c)
+		if _, isAdmin := admins[strings.ToLower(username)]; !ok || !isAdmin {
+			utils.ForbiddenResponse(c, "Admin access required")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
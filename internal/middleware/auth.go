@@ -8,9 +8,48 @@ import (
 	"github.com/datmedevil17/go-vuln/internal/utils"
 )
 
-// AuthMiddleware validates JWT token and sets user context
-func AuthMiddleware(jwtManager *utils.JWTManager) gin.HandlerFunc {
+// APIKeyAuthenticator resolves an API key to its owner and the scopes it was granted
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(key string) (userID uuid.UUID, username string, scopes []string, err error)
+}
+
+// AuthMiddleware validates JWT token and sets user context.
+// When apiKeys is non-nil an X-API-Key header is accepted instead; a read-scoped key
+// may only make GET and HEAD requests, and any other request needs the write scope.
+func AuthMiddleware(jwtManager *utils.JWTManager, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := c.GetHeader("X-API-Key"); key != "" && apiKeys != nil {
+			userID, username, scopes, err := apiKeys.AuthenticateAPIKey(key)
+			if err != nil {
+				utils.UnauthorizedResponse(c, "Invalid or revoked API key")
+				c.Abort()
+				return
+			}
+
+			required := "write"
+			if c.Request.Method == "GET" || c.Request.Method == "HEAD" {
+				required = "read"
+			}
+			allowed := false
+			for _, scope := range scopes {
+				if scope == required {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				utils.ForbiddenResponse(c, "API key lacks the "+required+" scope")
+				c.Abort()
+				return
+			}
+
+			c.Set("user_id", userID)
+			c.Set("username", username)
+			c.Set("api_key", true)
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			utils.UnauthorizedResponse(c, "Authorization header required")
@@ -44,6 +83,19 @@ func AuthMiddleware(jwtManager *utils.JWTManager) gin.HandlerFunc {
 	}
 }
 
+// SessionOnlyMiddleware rejects requests authenticated with an API key, so a leaked key
+// can't be used to mint or revoke keys. It must run after AuthMiddleware.
+func SessionOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool("api_key") {
+			utils.ForbiddenResponse(c, "This endpoint requires a session token, not an API key")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 // GetUserID retrieves user ID from context
 func GetUserID(c *gin.Context) (uuid.UUID, bool) {
 	userID, exists := c.Get("user_id")
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// fakeAPIKeys authenticates keys from a fixed table; keys missing from it are invalid or revoked
+type fakeAPIKeys map[string][]string
+
+func (k fakeAPIKeys) AuthenticateAPIKey(key string) (uuid.UUID, string, []string, error) {
+	scopes, ok := k[key]
+	if !ok {
+		return uuid.Nil, "", nil, errors.New("invalid or revoked API key")
+	}
+	return uuid.New(), "octocat", scopes, nil
+}
+
+func TestAuthMiddlewareAPIKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := fakeAPIKeys{
+		"vpk_read":  {"read"},
+		"vpk_write": {"write"},
+		"vpk_both":  {"read", "write"},
+	}
+	jwt := utils.NewJWTManager("test-secret", time.Hour)
+	token, err := jwt.GenerateToken(uuid.New(), "octocat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		want    int
+	}{
+		{"read key reads", "GET", "/api/workflows", map[string]string{"X-API-Key": "vpk_read"}, http.StatusOK},
+		{"read key can't write", "POST", "/api/workflows", map[string]string{"X-API-Key": "vpk_read"}, http.StatusForbidden},
+		{"read key can't delete", "DELETE", "/api/workflows", map[string]string{"X-API-Key": "vpk_read"}, http.StatusForbidden},
+		{"write key writes", "POST", "/api/workflows", map[string]string{"X-API-Key": "vpk_write"}, http.StatusOK},
+		{"write-only key can't read", "GET", "/api/workflows", map[string]string{"X-API-Key": "vpk_write"}, http.StatusForbidden},
+		{"full key does both", "DELETE", "/api/workflows", map[string]string{"X-API-Key": "vpk_both"}, http.StatusOK},
+		{"revoked key", "GET", "/api/workflows", map[string]string{"X-API-Key": "vpk_revoked"}, http.StatusUnauthorized},
+		{"revoked key with a valid token alongside", "GET", "/api/workflows", map[string]string{"X-API-Key": "vpk_revoked", "Authorization": "Bearer " + token}, http.StatusUnauthorized},
+		{"key can't manage keys", "GET", "/api/keys", map[string]string{"X-API-Key": "vpk_both"}, http.StatusForbidden},
+		{"session can manage keys", "GET", "/api/keys", map[string]string{"Authorization": "Bearer " + token}, http.StatusOK},
+		{"session token", "POST", "/api/workflows", map[string]string{"Authorization": "Bearer " + token}, http.StatusOK},
+		{"no credentials", "GET", "/api/workflows", nil, http.StatusUnauthorized},
+		{"malformed bearer", "GET", "/api/workflows", map[string]string{"Authorization": "Token " + token}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			api := router.Group("/api", AuthMiddleware(jwt, keys))
+			ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+			api.Any("/workflows", ok)
+			api.GET("/keys", SessionOnlyMiddleware(), ok)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
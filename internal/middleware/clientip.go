@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// clientIP returns the real client address for the request.
+//
+// Gin only honours X-Forwarded-For / X-Real-IP when the direct peer is one of
+// the trusted proxies configured on the engine (see ConfigureTrustedProxies);
+// otherwise the socket address is used. Trusting a proxy means trusting every
+// address it appends, so only list proxies you operate - any client that can
+// reach the server directly through a trusted range can spoof its IP.
+func clientIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// ConfigureTrustedProxies restricts which peers may set forwarding headers.
+// An empty list disables forwarding headers entirely, so the socket address is used.
+func ConfigureTrustedProxies(router *gin.Engine, proxies []string) error {
+	return router.SetTrustedProxies(proxies)
+}
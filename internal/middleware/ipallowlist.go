@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"log"
+	"net"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// IPAllowlistMiddleware rejects requests whose client IP is outside the configured allowlist.
+// An empty allowlist disables the check.
+func IPAllowlistMiddleware(cfg *config.Config) gin.HandlerFunc {
+	allowed := parseNetworks(cfg.Security.IPAllowlist)
+
+	return func(c *gin.Context) {
+		if len(allowed) == 0 {
+			c.Next()
+			return
+		}
+
+		ip := net.ParseIP(clientIP(c))
+		if ip == nil || !containsIP(allowed, ip) {
+			log.Printf("🚫 Blocked request from %v to %s", ip, c.Request.URL.Path)
+			utils.ForbiddenResponse(c, "Access denied from this address")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func parseNetworks(entries []string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		network, err := config.ParseCIDR(entry)
+		if err != nil {
+			log.Printf("⚠️ Ignoring invalid network %q: %v", entry, err)
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
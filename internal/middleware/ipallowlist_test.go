@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestIPAllowlistMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		allowlist  []string
+		proxies    []string
+		remoteAddr string
+		headers    map[string]string
+		want       int
+	}{
+		{
+			name:       "empty allowlist lets everyone through",
+			remoteAddr: "203.0.113.5:4000",
+			want:       http.StatusOK,
+		},
+		{
+			name:       "allowed address",
+			allowlist:  []string{"10.0.0.0/8"},
+			remoteAddr: "10.1.2.3:4000",
+			want:       http.StatusOK,
+		},
+		{
+			name:       "single IP entry",
+			allowlist:  []string{"198.51.100.7"},
+			remoteAddr: "198.51.100.7:4000",
+			want:       http.StatusOK,
+		},
+		{
+			name:       "denied address",
+			allowlist:  []string{"10.0.0.0/8"},
+			remoteAddr: "203.0.113.5:4000",
+			want:       http.StatusForbidden,
+		},
+		{
+			name:       "allowed IPv6",
+			allowlist:  []string{"2001:db8::/32"},
+			remoteAddr: "[2001:db8::1]:4000",
+			want:       http.StatusOK,
+		},
+		{
+			name:       "X-Forwarded-For ignored without trusted proxies",
+			allowlist:  []string{"10.0.0.0/8"},
+			remoteAddr: "203.0.113.5:4000",
+			headers:    map[string]string{"X-Forwarded-For": "10.1.2.3"},
+			want:       http.StatusForbidden,
+		},
+		{
+			name:       "X-Real-IP ignored without trusted proxies",
+			allowlist:  []string{"10.0.0.0/8"},
+			remoteAddr: "203.0.113.5:4000",
+			headers:    map[string]string{"X-Real-IP": "10.1.2.3"},
+			want:       http.StatusForbidden,
+		},
+		{
+			name:       "X-Forwarded-For ignored from an untrusted peer",
+			allowlist:  []string{"10.0.0.0/8"},
+			proxies:    []string{"192.0.2.1"},
+			remoteAddr: "203.0.113.5:4000",
+			headers:    map[string]string{"X-Forwarded-For": "10.1.2.3"},
+			want:       http.StatusForbidden,
+		},
+		{
+			name:       "X-Forwarded-For honoured from a trusted proxy",
+			allowlist:  []string{"10.0.0.0/8"},
+			proxies:    []string{"192.0.2.1"},
+			remoteAddr: "192.0.2.1:4000",
+			headers:    map[string]string{"X-Forwarded-For": "10.1.2.3"},
+			want:       http.StatusOK,
+		},
+		{
+			name:       "spoofed address prepended before the proxy's entry",
+			allowlist:  []string{"10.0.0.0/8"},
+			proxies:    []string{"192.0.2.1"},
+			remoteAddr: "192.0.2.1:4000",
+			headers:    map[string]string{"X-Forwarded-For": "10.1.2.3, 203.0.113.5"},
+			want:       http.StatusForbidden,
+		},
+		{
+			name:       "trusted proxy without a forwarded address",
+			allowlist:  []string{"10.0.0.0/8"},
+			proxies:    []string{"192.0.2.1"},
+			remoteAddr: "192.0.2.1:4000",
+			want:       http.StatusForbidden,
+		},
+		{
+			name:       "garbage X-Forwarded-For from a trusted proxy",
+			allowlist:  []string{"10.0.0.0/8"},
+			proxies:    []string{"192.0.2.1"},
+			remoteAddr: "192.0.2.1:4000",
+			headers:    map[string]string{"X-Forwarded-For": "not-an-ip"},
+			want:       http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Security.IPAllowlist = tt.allowlist
+
+			router := gin.New()
+			if err := ConfigureTrustedProxies(router, tt.proxies); err != nil {
+				t.Fatal(err)
+			}
+			router.Use(IPAllowlistMiddleware(cfg))
+			router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
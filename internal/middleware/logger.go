@@ -1,32 +1,138 @@
 package middleware
 
 import (
-	"log"
+	"bytes"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/datmedevil17/go-vuln/internal/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-func LoggerMiddleware() gin.HandlerFunc {
+// requestIDPattern bounds the request IDs accepted from clients and proxies
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+func LoggerMiddleware(cfg *config.Config) gin.HandlerFunc {
+	// Body capture is debug-only and never enabled in production
+	captureBodies := cfg.Logging.CaptureBodies && cfg.Logging.Level == "debug" && cfg.Server.Mode != "production"
+	limit := cfg.Logging.BodyLimit
+
 	return func(c *gin.Context) {
 		startTime := time.Now()
 
+		// Tag everything logged for this request, executions it starts included, with its ID
+		requestID := c.GetHeader("X-Request-ID")
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Header("X-Request-ID", requestID)
+		logger := slog.Default().With("request_id", requestID)
+		c.Request = c.Request.WithContext(utils.WithRequestID(utils.WithLogger(c.Request.Context(), logger), requestID))
+
+		// Secret values are write-only and never logged, redacted or not, and a new API key is
+		// returned in plaintext only when it is created
+		path := c.Request.URL.Path
+		capture := captureBodies && !strings.HasPrefix(path, "/api/secrets") && !strings.HasPrefix(path, "/api/user/api-keys")
+
+		var requestBody []byte
+		var responseBody *limitedBuffer
+		if capture {
+			requestBody = captureRequestBody(c, limit)
+			responseBody = &limitedBuffer{limit: limit}
+			c.Writer = &bodyLogWriter{ResponseWriter: c.Writer, body: responseBody}
+		}
+
 		// Process request
 		c.Next()
 
 		// Log after request
 		duration := time.Since(startTime)
 		statusCode := c.Writer.Status()
-		clientIP := c.ClientIP()
+		ip := clientIP(c)
 		method := c.Request.Method
-		path := c.Request.URL.Path
 
-		log.Printf("[%s] %s %s %d %v",
-			method,
-			path,
-			clientIP,
-			statusCode,
-			duration,
+		logger.Info("request",
+			"method", method,
+			"path", path,
+			"ip", ip,
+			"status", statusCode,
+			"duration", duration,
 		)
+
+		if capture {
+			logger.Debug("request body", "method", method, "path", path, "body", utils.RedactSecrets(string(requestBody)))
+			logger.Debug("response body", "method", method, "path", path, "body", utils.RedactSecrets(responseBody.String()))
+		}
+	}
+}
+
+// captureRequestBody reads up to limit bytes of the request body for logging and puts them
+// back in front of the rest, so a large upload isn't buffered here as well as in the handler
+func captureRequestBody(c *gin.Context, limit int) []byte {
+	if c.Request.Body == nil {
+		return nil
+	}
+
+	body := c.Request.Body
+	prefix, err := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), body), body}
+	if err != nil {
+		return nil
 	}
-}
\ No newline at end of file
+
+	if len(prefix) > limit {
+		return append(prefix[:limit:limit], []byte("...[truncated]")...)
+	}
+	return prefix
+}
+
+// limitedBuffer keeps at most limit bytes and records whether output was dropped
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) {
+	remaining := b.limit - b.buf.Len()
+	if remaining <= 0 {
+		b.truncated = b.truncated || len(p) > 0
+		return
+	}
+	if len(p) > remaining {
+		p = p[:remaining]
+		b.truncated = true
+	}
+	b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + "...[truncated]"
+	}
+	return b.buf.String()
+}
+
+// bodyLogWriter tees response bytes into a bounded buffer
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	body *limitedBuffer
+}
+
+func (w *bodyLogWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyLogWriter) WriteString(s string) (int, error) {
+	w.body.Write([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// countingReader records how many bytes have been read from it
+type countingReader struct {
+	r    io.Reader
+	read int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += n
+	return n, err
+}
+
+func TestCaptureRequestBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name     string
+		body     string
+		limit    int
+		captured string
+	}{
+		{"under limit", "hello", 10, "hello"},
+		{"at limit", "0123456789", 10, "0123456789"},
+		{"over limit", strings.Repeat("x", 64*1024), 8, "xxxxxxxx...[truncated]"},
+		{"empty", "", 10, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &countingReader{r: strings.NewReader(tt.body)}
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("POST", "/", io.NopCloser(source))
+
+			captured := captureRequestBody(c, tt.limit)
+			if string(captured) != tt.captured {
+				t.Fatalf("captured %q, want %q", captured, tt.captured)
+			}
+			if source.read > tt.limit+1 {
+				t.Fatalf("read %d bytes ahead of the handler, want at most %d", source.read, tt.limit+1)
+			}
+
+			rest, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(rest) != tt.body {
+				t.Fatalf("handler read %d bytes, want the full %d-byte body", len(rest), len(tt.body))
+			}
+		})
+	}
+}
+
+// captureLogs sends the default logger's output, debug lines included, to the returned buffer
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var out bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &out
+}
+
+// bodyLoggingRouter returns a router whose requests are logged with their bodies
+func bodyLoggingRouter(limit int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Server.Mode = "debug"
+	cfg.Logging = config.LoggingConfig{Level: "debug", CaptureBodies: true, BodyLimit: limit}
+	router := gin.New()
+	router.Use(LoggerMiddleware(cfg))
+	return router
+}
+
+func TestLoggerMiddlewareRedactsBodies(t *testing.T) {
+	out := captureLogs(t)
+	router := bodyLoggingRouter(4096)
+	router.POST("/api/auth/token", func(c *gin.Context) {
+		io.Copy(io.Discard, c.Request.Body)
+		c.JSON(http.StatusOK, gin.H{"accessToken": "at-response-secret", "user": "alice"})
+	})
+
+	req := httptest.NewRequest("POST", "/api/auth/token", strings.NewReader(`{"user":"alice","password":"pw-request-secret"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	logged := out.String()
+	for _, secret := range []string{"pw-request-secret", "at-response-secret"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("logged %q:\n%s", secret, logged)
+		}
+	}
+	if !strings.Contains(logged, "request body") || !strings.Contains(logged, "response body") || !strings.Contains(logged, "alice") {
+		t.Fatalf("bodies weren't logged:\n%s", logged)
+	}
+}
@@ -25,7 +25,7 @@ func RateLimitMiddleware(cfg *config.Config, redisClient *redis.Client) gin.Hand
 		if userID, exists := c.Get("user_id"); exists {
 			identifier = fmt.Sprintf("user:%s", userID.(uuid.UUID).String())
 		} else {
-			identifier = fmt.Sprintf("ip:%s", c.ClientIP())
+			identifier = fmt.Sprintf("ip:%s", clientIP(c))
 		}
 
 		// Check rate limit
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/datmedevil17/go-vuln/internal/utils"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -11,15 +12,23 @@ import (
 type ScanResult struct {
 	ID           uuid.UUID       `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
 	WorkflowID   *uuid.UUID      `gorm:"type:uuid" json:"workflow_id,omitempty"`
+	BatchID      *uuid.UUID      `gorm:"type:uuid;index" json:"batch_id,omitempty"`     // Set when the scan was started by POST /scan/bulk
+	ExecutionID  *uuid.UUID      `gorm:"type:uuid;index" json:"execution_id,omitempty"` // Set when a workflow's scanner node ran the scan
 	UserID       uuid.UUID       `gorm:"type:uuid;not null" json:"user_id"`
 	ScanType     string          `gorm:"not null" json:"scan_type"`
 	TargetURL    string          `gorm:"not null" json:"target_url"`
+	TargetHost   string          `gorm:"index" json:"target_host,omitempty"` // Normalized host of TargetURL
 	Status       string          `gorm:"default:'pending'" json:"status"`
 	Results      json.RawMessage `gorm:"type:jsonb" json:"results,omitempty"`
 	ErrorMessage string          `json:"error_message,omitempty"`
+	Signature    string          `json:"signature,omitempty"` // HMAC over the finished result, see GET /scan/results/:id/verify
 	StartedAt    *time.Time      `json:"started_at,omitempty"`
 	CompletedAt  *time.Time      `json:"completed_at,omitempty"`
 	CreatedAt    time.Time       `json:"created_at"`
+
+	// Set while the scan is queued behind the concurrency limit
+	QueuePosition    *int       `gorm:"-" json:"queue_position,omitempty"`
+	EstimatedStartAt *time.Time `gorm:"-" json:"estimated_start_at,omitempty"`
 }
 
 func (ScanResult) TableName() string {
@@ -30,5 +39,8 @@ func (s *ScanResult) BeforeCreate(tx *gorm.DB) error {
 	if s.ID == uuid.Nil {
 		s.ID = uuid.New()
 	}
+	if s.TargetHost == "" {
+		s.TargetHost = utils.NormalizeHost(s.TargetURL)
+	}
 	return nil
-}
\ No newline at end of file
+}
@@ -13,16 +13,56 @@ type WorkflowExecution struct {
 	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
 	WorkflowID  uuid.UUID  `gorm:"type:uuid;not null" json:"workflowId"`
 	UserID      uuid.UUID  `gorm:"type:uuid;not null" json:"userId"`
-	Status      string     `gorm:"default:'pending'" json:"status"` // pending, running, completed, failed
+	Status      string     `gorm:"default:'pending'" json:"status"` // queued, pending, running, completed, failed, timed_out, cancelled, skipped
 	CurrentNode string     `json:"currentNode,omitempty"`
+	Progress    int        `gorm:"default:0" json:"progress"`              // Finished nodes as a percentage of all nodes
 	Results     JSONMap    `gorm:"type:jsonb;default:'{}'" json:"results"` // Node ID -> Result
 	Error       string     `json:"error,omitempty"`
+	Pinned      bool       `gorm:"default:false" json:"pinned"`       // Pinned executions are kept by filter-based cleanup
+	TargetHost  string     `gorm:"index" json:"targetHost,omitempty"` // Normalized host of the trigger's target
 	StartedAt   *time.Time `json:"startedAt,omitempty"`
 	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	HeartbeatAt *time.Time `json:"-"` // Last time the server running this execution reported it alive
+	ClaimedAt   *time.Time `json:"-"` // When a server took the execution off the dispatch queue to run it
 	CreatedAt   time.Time  `json:"createdAt"`
 	UpdatedAt   time.Time  `json:"updatedAt"`
 	Name        string     `gorm:"->" json:"name"`    // Workflow name, joined from workflows table
 	Duration    int64      `gorm:"-" json:"duration"` // Duration in milliseconds
+
+	Snapshot     *WorkflowSnapshot `gorm:"type:jsonb;serializer:json" json:"snapshot,omitempty"`  // Workflow definition used for this run
+	ReplayedFrom *uuid.UUID        `gorm:"type:uuid" json:"replayedFrom,omitempty"`               // Original execution when this run is a replay
+	Logs         *ExecutionLog     `gorm:"type:jsonb;serializer:json" json:"-"`                   // Served separately by the logs endpoint
+	NodeOrder    []string          `gorm:"type:jsonb;serializer:json" json:"nodeOrder,omitempty"` // Node IDs in the order their results were recorded
+	Tags         []string          `gorm:"type:jsonb;serializer:json" json:"tags,omitempty"`      // User labels, e.g. "pre-release audit"
+	Notes        string            `json:"notes,omitempty"`
+
+	SeveritySummary map[string]int `gorm:"type:jsonb;serializer:json" json:"severitySummary,omitempty"` // Findings per severity, set when the execution finishes
+	RiskGrade       string         `json:"riskGrade,omitempty"`                                         // A (no findings above info) to F (a critical finding)
+}
+
+// ExecutionLog holds the log lines an execution produced, capped in size
+type ExecutionLog struct {
+	Entries   []ExecutionLogEntry `json:"entries"`
+	Truncated bool                `json:"truncated"` // Older entries were dropped to stay under the cap
+}
+
+// ExecutionLogEntry is a single log line, tagged with the node that was running when it was written
+type ExecutionLogEntry struct {
+	Time     time.Time `json:"time"`
+	NodeID   string    `json:"nodeId,omitempty"`
+	NodeType string    `json:"nodeType,omitempty"`
+	Message  string    `json:"message"`
+}
+
+// WorkflowSnapshot is a copy of the workflow definition taken when an execution starts
+type WorkflowSnapshot struct {
+	Name                string     `json:"name"`
+	Nodes               JSONArray  `json:"nodes"`
+	Edges               JSONArray  `json:"edges"`
+	MaxDuration         int        `json:"max_duration,omitempty"`
+	StopOnCritical      bool       `json:"stop_on_critical,omitempty"`
+	BaselineExecutionID *uuid.UUID `json:"baseline_execution_id,omitempty"`
+	FailOnNewFindings   bool       `json:"fail_on_new_findings,omitempty"`
 }
 
 // JSONMap custom type for handling JSONB maps
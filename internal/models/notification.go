@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationDelivery is a notification whose first send failed. It is retried with
+// backoff while pending, and kept as a dead letter once it runs out of attempts.
+type NotificationDelivery struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Channel       string     `gorm:"not null" json:"channel"` // email, slack or siem
+	Recipient     string     `json:"recipient,omitempty"`
+	Subject       string     `json:"subject"`
+	Body          string     `gorm:"type:text" json:"body"`
+	Attachments   JSONArray  `gorm:"type:jsonb;default:'[]'" json:"attachments,omitempty"`
+	Status        string     `gorm:"not null;default:'pending';index:idx_notification_deliveries_due" json:"status"` // pending, sent, dead
+	Attempts      int        `gorm:"default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"index:idx_notification_deliveries_due" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (NotificationDelivery) TableName() string {
+	return "notification_deliveries"
+}
+
+func (n *NotificationDelivery) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
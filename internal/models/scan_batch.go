@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ScanBatch groups the scans started together from one list of targets
+type ScanBatch struct {
+	ID        uuid.UUID        `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID    uuid.UUID        `gorm:"type:uuid;not null;index" json:"user_id"`
+	ScanType  string           `gorm:"not null" json:"scan_type"`
+	Total     int              `json:"total"`                                      // Scans started, one per accepted target
+	Rejected  []RejectedTarget `gorm:"type:jsonb;serializer:json" json:"rejected"` // Rows that were skipped, with why
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// RejectedTarget is a row of a batch that couldn't be scanned
+type RejectedTarget struct {
+	Row    int    `json:"row"` // 1-based position in the submitted list
+	Target string `json:"target"`
+	Reason string `json:"reason"`
+}
+
+func (ScanBatch) TableName() string {
+	return "scan_batches"
+}
+
+func (b *ScanBatch) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
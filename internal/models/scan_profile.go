@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ScanProfile is a saved, reusable scanner configuration
+type ScanProfile struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_scan_profiles_user_name" json:"user_id"`
+	Name        string    `gorm:"not null;uniqueIndex:idx_scan_profiles_user_name" json:"name"`
+	ScannerType string    `gorm:"not null" json:"scanner_type"`
+	Description string    `json:"description,omitempty"`
+	Parameters  JSONMap   `gorm:"type:jsonb;default:'{}'" json:"parameters"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (ScanProfile) TableName() string {
+	return "scan_profiles"
+}
+
+func (p *ScanProfile) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
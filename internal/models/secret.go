@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Secret is a user credential stored encrypted at rest and referenced from workflow nodes by name
+type Secret struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_secrets_user_name" json:"user_id"`
+	Name       string    `gorm:"not null;uniqueIndex:idx_secrets_user_name" json:"name"`
+	Ciphertext string    `gorm:"not null" json:"-"`           // AES-256-GCM, never returned by the API
+	KeyVersion int       `gorm:"not null;default:1" json:"-"` // Version of the encryption key the ciphertext was sealed with
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (Secret) TableName() string {
+	return "secrets"
+}
+
+func (s *Secret) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
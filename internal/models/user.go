@@ -8,14 +8,27 @@ import (
 )
 
 type User struct {
-	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	GitHubID    string    `gorm:"column:github_id;uniqueIndex;not null" json:"github_id"`
-	Username    string    `gorm:"not null" json:"username"`
-	Email       string    `json:"email"`
-	AvatarURL   string    `json:"avatar_url"`
-	AccessToken string    `json:"-"` // Hidden from JSON
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uuid.UUID       `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	GitHubID    string          `gorm:"column:github_id;uniqueIndex;not null" json:"github_id"`
+	Username    string          `gorm:"not null" json:"username"`
+	Email       string          `json:"email"`
+	AvatarURL   string          `json:"avatar_url"`
+	AccessToken string          `json:"-"`                                       // Hidden from JSON
+	GitHubScope string          `gorm:"column:github_scope" json:"github_scope"` // Scopes granted to AccessToken, comma-separated as GitHub reports them
+	Preferences UserPreferences `gorm:"embedded" json:"preferences"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// UserPreferences holds per-user defaults used when a workflow or request doesn't set its own
+type UserPreferences struct {
+	AIProvider          string `json:"ai_provider"`                                 // gemini, groq or claude; empty keeps the server's provider order
+	Language            string `gorm:"default:'en'" json:"language"`                // Locale for AI-generated reports
+	NotificationChannel string `gorm:"default:'email'" json:"notification_channel"` // Channel used by generic notify nodes
+	Timezone            string `gorm:"default:'UTC'" json:"timezone"`               // IANA zone for interpreting schedules
+	SIEMURL             string `json:"siem_url"`                                    // Endpoint findings are pushed to after each scan; empty disables
+	SIEMFormat          string `gorm:"default:'ecs'" json:"siem_format"`            // ecs or cef
+	SIEMSecret          string `json:"siem_secret"`                                 // Stored secret sent as the Authorization header
 }
 
 func (User) TableName() string {
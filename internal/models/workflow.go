@@ -10,18 +10,30 @@ import (
 )
 
 type Workflow struct {
-	ID                uuid.UUID       `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	UserID            uuid.UUID       `gorm:"type:uuid;not null" json:"user_id"`
-	Name              string          `gorm:"not null" json:"name"`
-	Nodes             JSONArray       `gorm:"type:jsonb;default:'[]'" json:"nodes"`
-	Edges             JSONArray       `gorm:"type:jsonb;default:'[]'" json:"edges"`
-	IsActive          bool            `gorm:"default:false" json:"is_active"`
-	ScheduleFrequency string          `json:"schedule_frequency,omitempty"`
-	ScheduleEnabled   bool            `gorm:"default:false" json:"schedule_enabled"`
-	NextRun           *time.Time      `json:"next_run,omitempty"`
-	LastExecution     json.RawMessage `gorm:"type:jsonb" json:"last_execution,omitempty"`
-	CreatedAt         time.Time       `json:"created_at"`
-	UpdatedAt         time.Time       `json:"updated_at"`
+	ID                  uuid.UUID       `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID              uuid.UUID       `gorm:"type:uuid;not null" json:"user_id"`
+	Name                string          `gorm:"not null" json:"name"`
+	Nodes               JSONArray       `gorm:"type:jsonb;default:'[]'" json:"nodes"`
+	Edges               JSONArray       `gorm:"type:jsonb;default:'[]'" json:"edges"`
+	IsActive            bool            `gorm:"default:false" json:"is_active"`
+	Pinned              bool            `gorm:"default:false" json:"pinned"`
+	ScheduleFrequency   string          `json:"schedule_frequency,omitempty"`
+	ScheduleEnabled     bool            `gorm:"default:false" json:"schedule_enabled"`
+	ScheduleTime        string          `json:"schedule_time,omitempty"`       // Local "HH:MM" the schedule fires at
+	ScheduleDay         int             `gorm:"default:0" json:"schedule_day"` // Weekday (0 = Sunday) for weekly, day of month for monthly
+	ScheduleTimezone    string          `json:"schedule_timezone,omitempty"`   // IANA zone; empty uses the owner's preference
+	NextRun             *time.Time      `json:"next_run,omitempty"`
+	LastRun             *time.Time      `json:"last_run,omitempty"`            // When the schedule last fired
+	MaxDuration         int             `gorm:"default:0" json:"max_duration"` // Total run time budget in seconds, 0 = unlimited
+	BaselineExecutionID *uuid.UUID      `gorm:"type:uuid" json:"baseline_execution_id,omitempty"`
+	FailOnNewFindings   bool            `gorm:"default:false" json:"fail_on_new_findings"`  // Fail runs that add findings absent from the baseline
+	StopOnCritical      bool            `gorm:"default:false" json:"stop_on_critical"`      // Skip the remaining nodes once any node reports a critical finding
+	WebhookURL          string          `json:"webhook_url,omitempty"`                      // Receives a signed summary whenever an execution finishes
+	WebhookSecret       string          `json:"webhook_secret,omitempty"`                   // Name of the stored secret deliveries are signed with
+	ConcurrencyPolicy   string          `gorm:"default:'reject'" json:"concurrency_policy"` // What happens to a run started while another is in progress: reject, skip, queue or allow
+	LastExecution       json.RawMessage `gorm:"type:jsonb" json:"last_execution,omitempty"`
+	CreatedAt           time.Time       `json:"created_at"`
+	UpdatedAt           time.Time       `json:"updated_at"`
 }
 
 // JSONArray custom type for handling JSONB arrays
@@ -51,4 +63,4 @@ func (w *Workflow) BeforeCreate(tx *gorm.DB) error {
 		w.ID = uuid.New()
 	}
 	return nil
-}
\ No newline at end of file
+}
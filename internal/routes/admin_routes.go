@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/datmedevil17/go-vuln/internal/handlers"
+	"github.com/datmedevil17/go-vuln/internal/middleware"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterAdminRoutes registers operator routes, restricted to ADMIN_USERS and the IP allowlist
+func RegisterAdminRoutes(rg *gin.RouterGroup, adminHandler *handlers.AdminHandler, jwtUtil *utils.JWTManager, cfg *config.Config, ipAllowlist gin.HandlerFunc) {
+	admin := rg.Group("/admin")
+	admin.Use(ipAllowlist, middleware.AuthMiddleware(jwtUtil, nil), middleware.AdminMiddleware(cfg))
+	{
+		admin.GET("/executions/active", adminHandler.ListActiveExecutions)
+		admin.GET("/notifications", adminHandler.ListNotifications)
+		admin.POST("/notifications/:id/resend", adminHandler.ResendNotification)
+		admin.POST("/rekey", adminHandler.Rekey)
+		admin.POST("/reprocess", adminHandler.Reprocess)
+		admin.GET("/metrics", adminHandler.Metrics)
+	}
+}
@@ -10,13 +10,25 @@ import (
 // RegisterAPIRoutes registers protected API routes
 func RegisterAPIRoutes(rg *gin.RouterGroup, cfg *APIRoutesConfig) {
 	protected := rg.Group("")
-	protected.Use(middleware.AuthMiddleware(cfg.JWTUtil))
+	protected.Use(middleware.AuthMiddleware(cfg.JWTUtil, cfg.APIKeys))
 	{
 		// User
 		protected.GET("/user", cfg.AuthHandler.GetCurrentUser)
+		protected.GET("/user/preferences", cfg.AuthHandler.GetPreferences)
+		protected.PATCH("/user/preferences", cfg.AuthHandler.UpdatePreferences)
+		protected.GET("/user/github/reauthorize", cfg.AuthHandler.Reauthorize)
+
+		// API keys for programmatic access; managing them needs a session token
+		apiKeys := protected.Group("/user/api-keys", middleware.SessionOnlyMiddleware())
+		{
+			apiKeys.GET("", cfg.APIKeyHandler.ListAPIKeys)
+			apiKeys.POST("", cfg.APIKeyHandler.CreateAPIKey)
+			apiKeys.DELETE("/:id", cfg.APIKeyHandler.RevokeAPIKey)
+		}
 
 		// AI Workflow Generation
 		protected.POST("/workflow/ai-generate", cfg.AIWorkflowHandler.GenerateWorkflow)
+		protected.GET("/workflow/node-types", cfg.WorkflowHandler.ListNodeTypes)
 
 		// Workflows
 		workflows := protected.Group("/workflows")
@@ -24,10 +36,24 @@ func RegisterAPIRoutes(rg *gin.RouterGroup, cfg *APIRoutesConfig) {
 			workflows.POST("", cfg.WorkflowHandler.CreateWorkflow)
 			workflows.GET("", cfg.WorkflowHandler.ListWorkflows)
 			workflows.GET("/reports", cfg.WorkflowHandler.ListWorkflowExecutions)
+			workflows.DELETE("/reports", cfg.WorkflowHandler.DeleteWorkflowExecutions)
+			workflows.GET("/executions/:id", cfg.WorkflowHandler.GetWorkflowExecution)
+			workflows.PATCH("/executions/:id", cfg.WorkflowHandler.UpdateExecution)
+			workflows.GET("/executions/:id/logs", cfg.WorkflowHandler.GetExecutionLogs)
+			workflows.GET("/executions/:id/results", cfg.WorkflowHandler.GetExecutionResults)
+			workflows.PATCH("/executions/:id/pin", cfg.WorkflowHandler.ToggleExecutionPin)
+			workflows.POST("/executions/:id/replay", cfg.WorkflowHandler.ReplayExecution)
+			workflows.POST("/executions/:id/cancel", cfg.WorkflowHandler.CancelExecution)
+			workflows.POST("/executions/:id/autofix/rollback", cfg.WorkflowHandler.RollbackAutoFix)
 			workflows.GET("/:id", cfg.WorkflowHandler.GetWorkflow)
 			workflows.PUT("/:id", cfg.WorkflowHandler.UpdateWorkflow)
 			workflows.DELETE("/:id", cfg.WorkflowHandler.DeleteWorkflow)
+			workflows.PATCH("/:id/pin", cfg.WorkflowHandler.ToggleWorkflowPin)
 			workflows.POST("/:id/execute", cfg.WorkflowHandler.ExecuteWorkflow)
+			workflows.POST("/:id/nodes/:nodeId/test", cfg.WorkflowHandler.TestNode)
+			workflows.POST("/:id/lint", cfg.WorkflowHandler.LintWorkflow)
+			workflows.POST("/:id/validate", cfg.WorkflowHandler.ValidateWorkflow)
+			workflows.POST("/:id/baseline", cfg.WorkflowHandler.SetBaseline)
 		}
 
 		// GitHub
@@ -44,8 +70,39 @@ func RegisterAPIRoutes(rg *gin.RouterGroup, cfg *APIRoutesConfig) {
 			scan.POST("/nmap", cfg.ScannerHandler.NmapScan)
 			scan.POST("/nikto", cfg.ScannerHandler.NiktoScan)
 			scan.POST("/gobuster", cfg.ScannerHandler.GobusterScan)
+			scan.POST("/bulk", cfg.ScannerHandler.BulkScan)
+			scan.GET("/batches/:id", cfg.ScannerHandler.GetBatch)
+			scan.GET("/capabilities", cfg.ScannerHandler.GetCapabilities)
 			scan.GET("/results", cfg.ScannerHandler.ListScanResults)
+			scan.DELETE("/results", cfg.ScannerHandler.DeleteScanResults)
 			scan.GET("/results/:id", cfg.ScannerHandler.GetScanResult)
+			scan.GET("/results/:id/verify", cfg.ScannerHandler.VerifyScanResult)
+
+			// Saved scan profiles
+			scan.GET("/profiles", cfg.ScanProfileHandler.ListProfiles)
+			scan.POST("/profiles", cfg.ScanProfileHandler.CreateProfile)
+			scan.GET("/profiles/:id", cfg.ScanProfileHandler.GetProfile)
+			scan.PUT("/profiles/:id", cfg.ScanProfileHandler.UpdateProfile)
+			scan.DELETE("/profiles/:id", cfg.ScanProfileHandler.DeleteProfile)
+		}
+
+		// Targets
+		protected.GET("/targets/:host/history", cfg.TargetHandler.GetTargetHistory)
+
+		// Secrets referenced by workflow nodes (values are write-only)
+		secrets := protected.Group("/secrets")
+		{
+			secrets.GET("", cfg.SecretHandler.ListSecrets)
+			secrets.PUT("/:name", cfg.SecretHandler.PutSecret)
+			secrets.DELETE("/:name", cfg.SecretHandler.DeleteSecret)
+		}
+
+		// Gobuster wordlists
+		wordlists := protected.Group("/wordlists")
+		{
+			wordlists.GET("", cfg.ScannerHandler.ListWordlists)
+			wordlists.POST("", cfg.ScannerHandler.UploadWordlist)
+			wordlists.DELETE("/:name", cfg.ScannerHandler.DeleteWordlist)
 		}
 
 		// Code analysis
@@ -54,12 +111,14 @@ func RegisterAPIRoutes(rg *gin.RouterGroup, cfg *APIRoutesConfig) {
 			code.POST("/analyze", cfg.CodeHandler.AnalyzeCode)
 			code.POST("/quick-scan", cfg.CodeHandler.QuickScan)
 			code.POST("/compare", cfg.CodeHandler.CompareCode)
+			code.POST("/diff", cfg.CodeHandler.DiffCode)
 		}
 
 		// Chatbot
 		chatbot := protected.Group("/chatbot")
 		{
 			chatbot.POST("/chat", cfg.ChatbotHandler.Chat)
+			chatbot.POST("/chat/stream", cfg.ChatbotHandler.ChatStream)
 			chatbot.POST("/explain", cfg.ChatbotHandler.ExplainVulnerability)
 			chatbot.POST("/remediate", cfg.ChatbotHandler.SuggestRemediation)
 			chatbot.POST("/ask", cfg.ChatbotHandler.AskSecurityQuestion)
@@ -69,12 +128,17 @@ func RegisterAPIRoutes(rg *gin.RouterGroup, cfg *APIRoutesConfig) {
 
 // APIRoutesConfig holds handlers for API routes
 type APIRoutesConfig struct {
-	AuthHandler       *handlers.AuthHandler
-	WorkflowHandler   *handlers.WorkflowHandler
-	GitHubHandler     *handlers.GitHubHandler
-	ScannerHandler    *handlers.ScannerHandler
-	CodeHandler       *handlers.CodeHandler
-	ChatbotHandler    *handlers.ChatbotHandler
-	AIWorkflowHandler *handlers.AIWorkflowHandler
-	JWTUtil           *utils.JWTManager
+	AuthHandler        *handlers.AuthHandler
+	WorkflowHandler    *handlers.WorkflowHandler
+	GitHubHandler      *handlers.GitHubHandler
+	ScannerHandler     *handlers.ScannerHandler
+	ScanProfileHandler *handlers.ScanProfileHandler
+	TargetHandler      *handlers.TargetHandler
+	SecretHandler      *handlers.SecretHandler
+	CodeHandler        *handlers.CodeHandler
+	ChatbotHandler     *handlers.ChatbotHandler
+	AIWorkflowHandler  *handlers.AIWorkflowHandler
+	APIKeyHandler      *handlers.APIKeyHandler
+	JWTUtil            *utils.JWTManager
+	APIKeys            middleware.APIKeyAuthenticator
 }
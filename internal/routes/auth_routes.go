@@ -5,12 +5,13 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// RegisterAuthRoutes registers public authentication routes
-func RegisterAuthRoutes(rg *gin.RouterGroup, authHandler *handlers.AuthHandler) {
+// RegisterAuthRoutes registers public authentication routes.
+// The OAuth callback is guarded by the IP allowlist since it mints session tokens.
+func RegisterAuthRoutes(rg *gin.RouterGroup, authHandler *handlers.AuthHandler, ipAllowlist gin.HandlerFunc) {
 	auth := rg.Group("/auth")
 	{
 		auth.GET("/github", authHandler.GetAuthURL)
-		auth.GET("/github/callback", authHandler.HandleCallback)
+		auth.GET("/github/callback", ipAllowlist, authHandler.HandleCallback)
 		auth.POST("/logout", authHandler.Logout)
 	}
 }
@@ -1,21 +1,30 @@
 package routes
 
 import (
+	"github.com/datmedevil17/go-vuln/internal/config"
 	"github.com/datmedevil17/go-vuln/internal/handlers"
+	"github.com/datmedevil17/go-vuln/internal/middleware"
 	"github.com/datmedevil17/go-vuln/internal/utils"
 	"github.com/gin-gonic/gin"
 )
 
 // RouterConfig holds all handler and utility dependencies
 type RouterConfig struct {
-	AuthHandler       *handlers.AuthHandler
-	WorkflowHandler   *handlers.WorkflowHandler
-	GitHubHandler     *handlers.GitHubHandler
-	ScannerHandler    *handlers.ScannerHandler
-	CodeHandler       *handlers.CodeHandler
-	ChatbotHandler    *handlers.ChatbotHandler
-	AIWorkflowHandler *handlers.AIWorkflowHandler
-	JWTUtil           *utils.JWTManager
+	AuthHandler        *handlers.AuthHandler
+	WorkflowHandler    *handlers.WorkflowHandler
+	GitHubHandler      *handlers.GitHubHandler
+	ScannerHandler     *handlers.ScannerHandler
+	ScanProfileHandler *handlers.ScanProfileHandler
+	TargetHandler      *handlers.TargetHandler
+	SecretHandler      *handlers.SecretHandler
+	CodeHandler        *handlers.CodeHandler
+	ChatbotHandler     *handlers.ChatbotHandler
+	AIWorkflowHandler  *handlers.AIWorkflowHandler
+	AdminHandler       *handlers.AdminHandler
+	APIKeyHandler      *handlers.APIKeyHandler
+	JWTUtil            *utils.JWTManager
+	APIKeys            middleware.APIKeyAuthenticator // Resolves X-API-Key headers on the protected API
+	Config             *config.Config
 }
 
 // SetupRoutes configures all application routes
@@ -28,22 +37,35 @@ func SetupRoutes(router *gin.Engine, cfg *RouterConfig) {
 		})
 	})
 
+	ipAllowlist := middleware.IPAllowlistMiddleware(cfg.Config)
+
 	// API routes
 	api := router.Group("/api")
+	if cfg.Config.Security.AllowlistAPI {
+		api.Use(ipAllowlist)
+	}
 	{
 		// Auth routes (public)
-		RegisterAuthRoutes(api, cfg.AuthHandler)
+		RegisterAuthRoutes(api, cfg.AuthHandler, ipAllowlist)
+
+		// Admin routes
+		RegisterAdminRoutes(api, cfg.AdminHandler, cfg.JWTUtil, cfg.Config, ipAllowlist)
 
 		// Protected API routes
 		RegisterAPIRoutes(api, &APIRoutesConfig{
-			AuthHandler:       cfg.AuthHandler,
-			WorkflowHandler:   cfg.WorkflowHandler,
-			GitHubHandler:     cfg.GitHubHandler,
-			ScannerHandler:    cfg.ScannerHandler,
-			CodeHandler:       cfg.CodeHandler,
-			ChatbotHandler:    cfg.ChatbotHandler,
-			AIWorkflowHandler: cfg.AIWorkflowHandler,
-			JWTUtil:           cfg.JWTUtil,
+			AuthHandler:        cfg.AuthHandler,
+			WorkflowHandler:    cfg.WorkflowHandler,
+			GitHubHandler:      cfg.GitHubHandler,
+			ScannerHandler:     cfg.ScannerHandler,
+			ScanProfileHandler: cfg.ScanProfileHandler,
+			TargetHandler:      cfg.TargetHandler,
+			SecretHandler:      cfg.SecretHandler,
+			CodeHandler:        cfg.CodeHandler,
+			ChatbotHandler:     cfg.ChatbotHandler,
+			AIWorkflowHandler:  cfg.AIWorkflowHandler,
+			APIKeyHandler:      cfg.APIKeyHandler,
+			JWTUtil:            cfg.JWTUtil,
+			APIKeys:            cfg.APIKeys,
 		})
 	}
 }
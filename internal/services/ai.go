@@ -4,19 +4,45 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/datmedevil17/go-vuln/internal/config"
 )
 
+// ErrAINotConfigured is returned by AI calls when no provider has an API key
+var ErrAINotConfigured = errors.New("no AI API keys configured")
+
+// ErrContentBlocked is returned when a provider's safety filters refused the prompt or its
+// answer. Security prompts (exploits, leaked secrets) trip these filters more than most, and
+// a rephrased prompt or another provider usually gets through.
+var ErrContentBlocked = errors.New("AI provider blocked the content")
+
+// geminiBlockedFinishReasons are the candidate finish reasons that mean the answer was withheld
+var geminiBlockedFinishReasons = map[string]bool{
+	"SAFETY":             true,
+	"RECITATION":         true,
+	"BLOCKLIST":          true,
+	"PROHIBITED_CONTENT": true,
+	"SPII":               true,
+}
+
 type AIService struct {
-	config *config.Config
+	config   *config.Config
+	breakers *CircuitBreakers
 }
 
 type GeminiRequest struct {
-	Contents []GeminiContent `json:"contents"`
+	Contents         []GeminiContent        `json:"contents"`
+	GenerationConfig GeminiGenerationConfig `json:"generationConfig"`
+}
+
+type GeminiGenerationConfig struct {
+	Temperature     float64 `json:"temperature"`
+	MaxOutputTokens int     `json:"maxOutputTokens"`
 }
 
 type GeminiContent struct {
@@ -34,12 +60,19 @@ type GeminiResponse struct {
 				Text string `json:"text"`
 			} `json:"parts"`
 		} `json:"content"`
+		FinishReason string `json:"finishReason"`
 	} `json:"candidates"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
 }
 
 type GroqRequest struct {
-	Model    string        `json:"model"`
-	Messages []GroqMessage `json:"messages"`
+	Model       string        `json:"model"`
+	Messages    []GroqMessage `json:"messages"`
+	Temperature float64       `json:"temperature"`
+	MaxTokens   int           `json:"max_tokens"`
+	Stream      bool          `json:"stream,omitempty"`
 }
 
 type GroqMessage struct {
@@ -55,8 +88,43 @@ type GroqResponse struct {
 	} `json:"choices"`
 }
 
-func NewAIService(cfg *config.Config) *AIService {
-	return &AIService{config: cfg}
+type ClaudeRequest struct {
+	Model       string        `json:"model"`
+	MaxTokens   int           `json:"max_tokens"`
+	Temperature float64       `json:"temperature"`
+	Messages    []GroqMessage `json:"messages"`
+	Stream      bool          `json:"stream,omitempty"`
+}
+
+type ClaudeResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+}
+
+// ClaudeError is the body of a failed Anthropic API call
+type ClaudeError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func NewAIService(cfg *config.Config, breakers *CircuitBreakers) *AIService {
+	return &AIService{config: cfg, breakers: breakers}
+}
+
+// Configured reports whether any AI provider has an API key.
+// Without one, AI-dependent workflow steps are skipped rather than failed.
+func (s *AIService) Configured() bool {
+	return s.config.AI.GeminiAPIKey != "" || s.config.AI.GroqAPIKey != "" || s.config.AI.ClaudeAPIKey != ""
+}
+
+// FixContextLines is how many lines either side of a finding fixes are given as context
+func (s *AIService) FixContextLines() int {
+	return s.config.AI.FixContextLines
 }
 
 // AnalyzeCode uses AI to analyze code for vulnerabilities
@@ -71,23 +139,17 @@ Provide a detailed security analysis including:
 Code:
 %s`, language, code)
 
-	// Try Gemini first, fallback to Groq
-	if s.config.AI.GeminiAPIKey != "" {
-		result, err := s.callGemini(ctx, prompt)
-		if err == nil {
-			return result, nil
-		}
-	}
+	return s.generateOrRephrase(ctx, prompt, defensivePreamble+prompt, s.config.AI.Analysis, s.config.AI.ProviderOrder...)
+}
 
-	if s.config.AI.GroqAPIKey != "" {
-		return s.callGroq(ctx, prompt)
+// GenerateSecurityRecommendations generates security recommendations in the given language (see SupportedLanguages).
+// When no AI provider is configured it falls back to a template summary of findings.
+func (s *AIService) GenerateSecurityRecommendations(ctx context.Context, scanResults string, findings []ReportFinding, language string) (string, error) {
+	if !s.Configured() {
+		return templateSecurityReport(findings), nil
 	}
+	scanResults = s.condense(ctx, "a workflow's scans", scanResults, s.config.AI.ReportTokenBudget)
 
-	return "", fmt.Errorf("no AI API keys configured")
-}
-
-// GenerateSecurityRecommendations generates security recommendations
-func (s *AIService) GenerateSecurityRecommendations(ctx context.Context, scanResults string) (string, error) {
 	prompt := fmt.Sprintf(`Based on the following security scan results and auto-fix actions, provide a detailed report:
 
 Scan Results & Actions:
@@ -97,20 +159,9 @@ Please provide:
 1. Executive Summary of Findings
 2. Review of Auto-Fix Actions taken (if any)
 3. Priority recommendations for remaining issues
-4. Best practices to follow`, scanResults)
+4. Best practices to follow`, scanResults) + languageInstruction(language)
 
-	if s.config.AI.GeminiAPIKey != "" {
-		result, err := s.callGemini(ctx, prompt)
-		if err == nil {
-			return result, nil
-		}
-	}
-
-	if s.config.AI.GroqAPIKey != "" {
-		return s.callGroq(ctx, prompt)
-	}
-
-	return "", fmt.Errorf("no AI API keys configured")
+	return s.generate(ctx, prompt, s.config.AI.Report, s.config.AI.ProviderOrder...)
 }
 
 // GenerateFix generates a fix for vulnerable code
@@ -123,48 +174,50 @@ Vulnerability: %s
 Code:
 %s`, vulnerability, code)
 
-	if s.config.AI.GeminiAPIKey != "" {
-		result, err := s.callGemini(ctx, prompt)
-		if err == nil {
-			return result, nil
-		}
-	}
+	return s.generateOrRephrase(ctx, prompt, defensivePreamble+prompt, s.config.AI.Fix, s.config.AI.ProviderOrder...)
+}
 
-	if s.config.AI.GroqAPIKey != "" {
-		return s.callGroq(ctx, prompt)
-	}
+// GenerateLineFix rewrites just the given lines of a file to resolve a vulnerability.
+// The answer replaces those lines verbatim, so the model is asked to keep the surrounding
+// context lines and indentation as they are.
+func (s *AIService) GenerateLineFix(ctx context.Context, path string, startLine int, lines string, vulnerability string) (string, error) {
+	prompt := fmt.Sprintf(`You are a security expert. Below are lines %d onwards of %s. Fix them to resolve the specified vulnerability.
+Change only what the fix requires and keep every other line, including indentation, exactly as it is.
+Return ONLY the replacement for these lines without any markdown formatting or explanation.
 
-	return "", fmt.Errorf("no AI API keys configured")
+Vulnerability: %s
+
+Lines:
+%s`, startLine, path, vulnerability, lines)
+
+	return s.generateOrRephrase(ctx, prompt, defensivePreamble+prompt, s.config.AI.Fix, s.config.AI.ProviderOrder...)
 }
 
 // ChatResponse generates a chatbot response
 func (s *AIService) ChatResponse(ctx context.Context, userMessage string, conversationHistory []map[string]string) (string, error) {
+	return s.generate(ctx, chatPrompt(userMessage, conversationHistory), s.config.AI.Chat, s.config.AI.FastProviderOrder...)
+}
+
+// chatPrompt frames a chat message and the conversation so far for the model
+func chatPrompt(userMessage string, conversationHistory []map[string]string) string {
 	prompt := "You are a cybersecurity expert assistant. Help users understand security vulnerabilities and provide guidance.\n\n"
 
 	// Add conversation history
 	for _, msg := range conversationHistory {
 		prompt += fmt.Sprintf("%s: %s\n", msg["role"], msg["content"])
 	}
-	prompt += fmt.Sprintf("User: %s\nAssistant:", userMessage)
-
-	if s.config.AI.GroqAPIKey != "" {
-		return s.callGroq(ctx, prompt)
-	}
-
-	if s.config.AI.GeminiAPIKey != "" {
-		return s.callGemini(ctx, prompt)
-	}
-
-	return "", fmt.Errorf("no AI API keys configured")
+	return prompt + fmt.Sprintf("User: %s\nAssistant:", userMessage)
 }
 
-// GenerateWorkflowJSON generates a workflow configuration from a prompt
+// GenerateWorkflowJSON generates a workflow configuration from a prompt.
+// Output that doesn't validate is sent back to the model with the validation error
+// so it can correct itself, up to maxWorkflowGenerationAttempts times.
 func (s *AIService) GenerateWorkflowJSON(ctx context.Context, userPrompt string) (string, error) {
 	prompt := fmt.Sprintf(`You are an expert Workflow Builder Assistant.
 Create a JSON configuration for a security workflow based on this request: "%s"
 
 The JSON must return an object with "nodes" and "edges" arrays.
-Node Types available: "trigger", "gobuster", "nikto", "nmap", "sqlmap", "wpscan", "owasp-vulnerabilities", "auto-fix", "email", "github-issue", "slack", "flow-chart".
+Node Types available: %s.
 
 Rules:
 1. Always start with a "trigger" node.
@@ -181,26 +234,117 @@ Example Structure:
   "edges": [
     { "id": "e1-2", "source": "1", "target": "2" }
   ]
-}`, userPrompt)
+}`, userPrompt, quotedList(generatableNodeTypes))
+
+	var lastErr error
+	attemptPrompt := prompt
+	for attempt := 1; attempt <= maxWorkflowGenerationAttempts; attempt++ {
+		result, err := s.generate(ctx, attemptPrompt, s.config.AI.Workflow, s.config.AI.ProviderOrder...)
+		if err != nil {
+			return "", err
+		}
+		// Clean markdown if present
+		result = cleanJSON(result)
 
-	if s.config.AI.GeminiAPIKey != "" {
-		result, err := s.callGemini(ctx, prompt)
-		if err == nil {
-			// Clean markdown if present
-			return cleanJSON(result), nil
+		if lastErr = validateGeneratedWorkflow(result, s.config.Workflow); lastErr == nil {
+			// The model numbers nodes "1", "2", ..., which collide as soon as two generated
+			// workflows are combined
+			return remapWorkflowJSON(result)
+		}
+		logf(ctx, "⚠️ Generated workflow invalid (attempt %d/%d): %v", attempt, maxWorkflowGenerationAttempts, lastErr)
+
+		attemptPrompt = fmt.Sprintf("%s\n\nYour previous output was invalid because: %v\n\nPrevious output:\n%s\n\nFix it and return ONLY the corrected JSON.", prompt, lastErr, result)
+	}
+
+	return "", fmt.Errorf("generated workflow is invalid after %d attempts: %w", maxWorkflowGenerationAttempts, lastErr)
+}
+
+// AI provider names, as accepted in user preferences
+const (
+	ProviderGemini = "gemini"
+	ProviderGroq   = "groq"
+	ProviderClaude = "claude"
+)
+
+type preferredProviderKey struct{}
+
+// WithPreferredProvider returns a context whose AI calls try provider before the default order
+func WithPreferredProvider(ctx context.Context, provider string) context.Context {
+	if provider == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, preferredProviderKey{}, provider)
+}
+
+// preferredFirst moves the preferred provider set on ctx, if any, to the front of order
+func preferredFirst(ctx context.Context, order []string) []string {
+	preferred, ok := ctx.Value(preferredProviderKey{}).(string)
+	if !ok {
+		return order
+	}
+	reordered := []string{preferred}
+	for _, provider := range order {
+		if provider != preferred {
+			reordered = append(reordered, provider)
 		}
 	}
+	return reordered
+}
 
-	if s.config.AI.GroqAPIKey != "" {
-		result, err := s.callGroq(ctx, prompt)
+// generate sends prompt with the given sampling parameters to each configured provider
+// in order until one succeeds. A preferred provider set on ctx is tried first.
+func (s *AIService) generate(ctx context.Context, prompt string, params config.AIGeneration, order ...string) (string, error) {
+	var lastErr, blockedErr error
+	for _, provider := range preferredFirst(ctx, order) {
+		var result string
+		var err error
+		switch {
+		case provider == ProviderGemini && s.config.AI.GeminiAPIKey != "":
+			result, err = s.callGemini(ctx, prompt, params)
+		case provider == ProviderGroq && s.config.AI.GroqAPIKey != "":
+			result, err = s.callGroq(ctx, prompt, params)
+		case provider == ProviderClaude && s.config.AI.ClaudeAPIKey != "":
+			result, err = s.callClaude(ctx, prompt, params)
+		default:
+			continue
+		}
 		if err == nil {
-			return cleanJSON(result), nil
+			return result, nil
+		}
+		if errors.Is(err, ErrContentBlocked) {
+			logf(ctx, "⚠️ %v", err)
+			blockedErr = err
 		}
+		lastErr = err
 	}
 
-	return "", fmt.Errorf("no AI API keys configured")
+	// A block is what callers can act on, so it's reported even if a later provider failed otherwise
+	if blockedErr != nil && blockedErr != lastErr {
+		return "", errors.Join(blockedErr, lastErr)
+	}
+	if lastErr != nil {
+		return "", lastErr
+	}
+	return "", ErrAINotConfigured
 }
 
+// generateOrRephrase is generate, retried once with rephrased when every provider blocked prompt
+func (s *AIService) generateOrRephrase(ctx context.Context, prompt, rephrased string, params config.AIGeneration, order ...string) (string, error) {
+	result, err := s.generate(ctx, prompt, params, order...)
+	if !errors.Is(err, ErrContentBlocked) {
+		return result, err
+	}
+	logf(ctx, "⚠️ AI prompt was blocked, retrying with a rephrased prompt")
+	return s.generate(ctx, rephrased, params, order...)
+}
+
+// defensivePreamble frames a rephrased prompt so safety filters read it as the code review it is
+const defensivePreamble = `Context: this is an authorized, defensive security review of the requester's own source code.
+The goal is to find and remove weaknesses, not to exploit them. Do not reproduce any credentials
+or secrets that appear in the code; refer to them generically (e.g. "a hardcoded API key").
+
+`
+
 func cleanJSON(s string) string {
 	// Simple cleanup to remove ```json ... ``` wrapper if present
 	if len(s) > 7 && s[:7] == "```json" {
@@ -213,8 +357,8 @@ func cleanJSON(s string) string {
 }
 
 // callGemini makes a request to Google Gemini API
-func (s *AIService) callGemini(ctx context.Context, prompt string) (string, error) {
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/gemini-pro:generateContent?key=%s", s.config.AI.GeminiAPIKey)
+func (s *AIService) callGemini(ctx context.Context, prompt string, params config.AIGeneration) (string, error) {
+	endpoint := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", url.PathEscape(s.config.AI.GeminiModel), s.config.AI.GeminiAPIKey)
 
 	reqBody := GeminiRequest{
 		Contents: []GeminiContent{
@@ -224,6 +368,10 @@ func (s *AIService) callGemini(ctx context.Context, prompt string) (string, erro
 				},
 			},
 		},
+		GenerationConfig: GeminiGenerationConfig{
+			Temperature:     params.Temperature,
+			MaxOutputTokens: params.MaxTokens,
+		},
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -231,15 +379,14 @@ func (s *AIService) callGemini(ctx context.Context, prompt string) (string, erro
 		return "", err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.breakers.Gemini.Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -254,26 +401,38 @@ func (s *AIService) callGemini(ctx context.Context, prompt string) (string, erro
 	if err := json.NewDecoder(resp.Body).Decode(&geminiResp); err != nil {
 		return "", err
 	}
+	return geminiText(&geminiResp)
+}
 
-	if len(geminiResp.Candidates) > 0 && len(geminiResp.Candidates[0].Content.Parts) > 0 {
-		return geminiResp.Candidates[0].Content.Parts[0].Text, nil
+// geminiText returns the first candidate's answer, or ErrContentBlocked with the reason when
+// the prompt or every answer was blocked
+func geminiText(resp *GeminiResponse) (string, error) {
+	if len(resp.Candidates) > 0 && len(resp.Candidates[0].Content.Parts) > 0 {
+		return resp.Candidates[0].Content.Parts[0].Text, nil
+	}
+	if reason := resp.PromptFeedback.BlockReason; reason != "" {
+		return "", fmt.Errorf("%w: Gemini blocked the prompt (%s)", ErrContentBlocked, reason)
+	}
+	if len(resp.Candidates) > 0 && geminiBlockedFinishReasons[resp.Candidates[0].FinishReason] {
+		return "", fmt.Errorf("%w: Gemini withheld the answer (%s)", ErrContentBlocked, resp.Candidates[0].FinishReason)
 	}
-
 	return "", fmt.Errorf("no response from Gemini")
 }
 
 // callGroq makes a request to Groq API
-func (s *AIService) callGroq(ctx context.Context, prompt string) (string, error) {
+func (s *AIService) callGroq(ctx context.Context, prompt string, params config.AIGeneration) (string, error) {
 	url := "https://api.groq.com/openai/v1/chat/completions"
 
 	reqBody := GroqRequest{
-		Model: "llama-3.3-70b-versatile",
+		Model: s.config.AI.GroqModel,
 		Messages: []GroqMessage{
 			{
 				Role:    "user",
 				Content: prompt,
 			},
 		},
+		Temperature: params.Temperature,
+		MaxTokens:   params.MaxTokens,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -289,8 +448,7 @@ func (s *AIService) callGroq(ctx context.Context, prompt string) (string, error)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+s.config.AI.GroqAPIKey)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.breakers.Groq.Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -312,3 +470,74 @@ func (s *AIService) callGroq(ctx context.Context, prompt string) (string, error)
 
 	return "", fmt.Errorf("no response from Groq")
 }
+
+// callClaude makes a request to the Anthropic Messages API
+func (s *AIService) callClaude(ctx context.Context, prompt string, params config.AIGeneration) (string, error) {
+	url := "https://api.anthropic.com/v1/messages"
+
+	reqBody := ClaudeRequest{
+		Model:       s.config.AI.ClaudeModel,
+		MaxTokens:   params.MaxTokens,
+		Temperature: min(params.Temperature, 1), // Anthropic takes 0-1, not the 0-2 of the others
+		Messages: []GroqMessage{
+			{
+				Role:    "user",
+				Content: prompt,
+			},
+		},
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", s.config.AI.ClaudeAPIKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+
+	resp, err := s.breakers.Claude.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", claudeAPIError(resp.Status, body)
+	}
+
+	var claudeResp ClaudeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&claudeResp); err != nil {
+		return "", err
+	}
+	return claudeText(&claudeResp)
+}
+
+// claudeAPIError describes a failed Claude call, using the error message from its body when
+// there is one
+func claudeAPIError(status string, body []byte) error {
+	var claudeErr ClaudeError
+	if json.Unmarshal(body, &claudeErr) == nil && claudeErr.Error.Message != "" {
+		return fmt.Errorf("Claude API error: %s - %s: %s", status, claudeErr.Error.Type, claudeErr.Error.Message)
+	}
+	return fmt.Errorf("Claude API error: %s - %s", status, string(body))
+}
+
+// claudeText returns the answer's first text block, or ErrContentBlocked when Claude refused
+func claudeText(resp *ClaudeResponse) (string, error) {
+	if resp.StopReason == "refusal" {
+		return "", fmt.Errorf("%w: Claude refused the prompt", ErrContentBlocked)
+	}
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			return block.Text, nil
+		}
+	}
+	return "", fmt.Errorf("no response from Claude")
+}
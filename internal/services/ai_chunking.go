@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	// charsPerToken is the rough size of a token, used to budget prompts without a tokenizer
+	charsPerToken = 4
+
+	// maxCondenseRounds bounds how often summaries are themselves summarized to fit a budget
+	maxCondenseRounds = 3
+)
+
+// estimateTokens approximates how many tokens text takes up in a prompt
+func estimateTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+// chunkText splits text into pieces of at most maxTokens, breaking between lines where it
+// can and inside a line only when the line alone is over the limit
+func chunkText(text string, maxTokens int) []string {
+	limit := maxTokens * charsPerToken
+	if limit < 1 || len(text) <= limit {
+		return []string{text}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+	}
+	for _, line := range strings.SplitAfter(text, "\n") {
+		for len(line) > limit {
+			flush()
+			chunks = append(chunks, line[:limit])
+			line = line[limit:]
+		}
+		if current.Len()+len(line) > limit {
+			flush()
+		}
+		current.WriteString(line)
+	}
+	flush()
+	return chunks
+}
+
+// truncateTokens cuts text to about maxTokens, marking that it was cut
+func truncateTokens(text string, maxTokens int) string {
+	limit := maxTokens * charsPerToken
+	if len(text) <= limit {
+		return text
+	}
+	return text[:limit] + "\n[... truncated]"
+}
+
+// CondenseScanOutput fits one scanner's output into the per-output token budget
+func (s *AIService) CondenseScanOutput(ctx context.Context, label, output string) string {
+	return s.condense(ctx, label, output, s.config.AI.OutputTokenBudget)
+}
+
+// condense returns text unchanged when it fits budget. Otherwise each chunk is summarized
+// and the summaries joined, repeating on the joined summaries while they're still too
+// large. Without an AI provider, or if summarizing fails, the text is truncated instead.
+func (s *AIService) condense(ctx context.Context, label, text string, budget int) string {
+	for round := 0; round < maxCondenseRounds && estimateTokens(text) > budget; round++ {
+		if !s.Configured() {
+			break
+		}
+		chunks := chunkText(text, budget)
+		summaries := make([]string, 0, len(chunks))
+		for i, chunk := range chunks {
+			summary, err := s.summarizeChunk(ctx, label, chunk, i+1, len(chunks), budget/len(chunks))
+			if err != nil {
+				logf(ctx, "⚠️ Failed to summarize %s output (part %d/%d), truncating instead: %v", label, i+1, len(chunks), err)
+				return truncateTokens(text, budget)
+			}
+			summaries = append(summaries, summary)
+		}
+		text = strings.Join(summaries, "\n\n")
+	}
+	return truncateTokens(text, budget)
+}
+
+// summarizeChunk asks the model to condense part of a scan output to about maxTokens
+func (s *AIService) summarizeChunk(ctx context.Context, label, chunk string, part, parts, maxTokens int) (string, error) {
+	if maxTokens < 50 {
+		maxTokens = 50
+	}
+	prompt := fmt.Sprintf(`You are a security analyst. Below is part %d of %d of the output of %s.
+Summarize it in at most %d words for a later security report. Keep every finding with its host,
+port, URL, file path, rule or CVE identifier and severity; drop banners, progress lines and noise.
+Return only the summary.
+
+Output:
+%s`, part, parts, label, maxTokens*3/4, chunk)
+
+	params := s.config.AI.Analysis
+	params.MaxTokens = maxTokens
+	return s.generate(ctx, prompt, params, s.config.AI.FastProviderOrder...)
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+)
+
+func TestChunkText(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		maxTokens int
+		want      []string
+	}{
+		{name: "fits", text: "22/tcp open ssh\n80/tcp open http\n", maxTokens: 100, want: []string{"22/tcp open ssh\n80/tcp open http\n"}},
+		{name: "no budget", text: "22/tcp open ssh\n", maxTokens: 0, want: []string{"22/tcp open ssh\n"}},
+		{name: "split between lines", text: "aaaa\nbbbb\ncccc\ndddd\n", maxTokens: 3, want: []string{"aaaa\nbbbb\n", "cccc\ndddd\n"}},
+		{name: "line over the limit", text: "short\n" + strings.Repeat("x", 20) + "\nend\n", maxTokens: 2, want: []string{"short\n", "xxxxxxxx", "xxxxxxxx", "xxxx\n", "end\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := chunkText(tt.text, tt.maxTokens)
+			if strings.Join(got, "") != tt.text {
+				t.Fatalf("chunks %q don't add up to the text", got)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("chunkText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// summarizingGroq answers each summary request with summarize applied to the part number
+// in its prompt, recording the prompts it was sent
+type summarizingGroq struct {
+	mu      sync.Mutex
+	prompts []string
+}
+
+var partPattern = regexp.MustCompile(`part (\d+) of (\d+)`)
+
+func (g *summarizingGroq) handler(t *testing.T, summarize func(part string) string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req GroqRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		prompt := req.Messages[0].Content
+		g.mu.Lock()
+		g.prompts = append(g.prompts, prompt)
+		g.mu.Unlock()
+
+		answer := "report"
+		if match := partPattern.FindStringSubmatch(prompt); match != nil {
+			answer = summarize(match[1])
+		}
+		data, _ := json.Marshal(answer)
+		fmt.Fprintf(w, `{"choices":[{"message":{"content":%s}}]}`, data)
+	}
+}
+
+// largeNmapOutput is nmap output of about tokens estimated tokens
+func largeNmapOutput(tokens int) string {
+	var b strings.Builder
+	for port := 1; b.Len() < tokens*charsPerToken; port++ {
+		fmt.Fprintf(&b, "%d/tcp open  http-alt\n", port)
+	}
+	return b.String()
+}
+
+func TestCondenseScanOutputSummarizesOversizedOutput(t *testing.T) {
+	groq := &summarizingGroq{}
+	s := fakeProviders(t, []string{ProviderGroq}, map[string]http.HandlerFunc{
+		ProviderGroq: groq.handler(t, func(part string) string { return "summary of part " + part }),
+	})
+	s.config.AI.OutputTokenBudget = 200
+
+	output := largeNmapOutput(1000)
+	got := s.CondenseScanOutput(context.Background(), "nmap", output)
+
+	// Every chunk of at most 200 tokens is summarized and none is cut off
+	parts := len(chunkText(output, 200))
+	if parts < 5 || len(groq.prompts) != parts {
+		t.Fatalf("summarized %d chunks, want %d", len(groq.prompts), parts)
+	}
+	summaries := make([]string, parts)
+	for i := range summaries {
+		summaries[i] = fmt.Sprintf("summary of part %d", i+1)
+	}
+	if want := strings.Join(summaries, "\n\n"); got != want {
+		t.Fatalf("CondenseScanOutput() = %q, want %q", got, want)
+	}
+	var sent strings.Builder
+	for _, prompt := range groq.prompts {
+		chunk := prompt[strings.Index(prompt, "Output:\n")+len("Output:\n"):]
+		if estimateTokens(chunk) > 200 {
+			t.Errorf("chunk of %d tokens sent, over the 200 token budget", estimateTokens(chunk))
+		}
+		if !strings.Contains(prompt, fmt.Sprintf("of %d of the output of nmap", parts)) {
+			t.Errorf("prompt doesn't say which part it is:\n%.200s", prompt)
+		}
+		sent.WriteString(chunk)
+	}
+	if sent.String() != output {
+		t.Error("the chunks sent don't add up to the whole output")
+	}
+
+	// Output within the budget goes through untouched
+	groq.prompts = nil
+	if small := "22/tcp open ssh\n"; s.CondenseScanOutput(context.Background(), "nmap", small) != small || len(groq.prompts) != 0 {
+		t.Error("output within the budget was summarized")
+	}
+}
+
+func TestCondenseResummarizesLargeSummaries(t *testing.T) {
+	groq := &summarizingGroq{}
+	s := fakeProviders(t, []string{ProviderGroq}, map[string]http.HandlerFunc{
+		// Summaries too large to fit the budget when joined, and never smaller
+		ProviderGroq: groq.handler(t, func(part string) string { return strings.Repeat("finding ", 60) }),
+	})
+
+	got := s.condense(context.Background(), "nmap", largeNmapOutput(1000), 200)
+	if estimateTokens(got) > 200+len("[... truncated]") {
+		t.Fatalf("condensed to %d tokens, over the 200 token budget", estimateTokens(got))
+	}
+	rounds := 0
+	for _, prompt := range groq.prompts {
+		if strings.Contains(prompt, "part 1 of") {
+			rounds++
+		}
+	}
+	if rounds != maxCondenseRounds {
+		t.Fatalf("summarized in %d rounds, want %d", rounds, maxCondenseRounds)
+	}
+}
+
+func TestCondenseFallsBackToTruncating(t *testing.T) {
+	output := largeNmapOutput(1000)
+	want := output[:200*charsPerToken] + "\n[... truncated]"
+
+	unconfigured := NewAIService(&config.Config{}, NewCircuitBreakers(5, time.Minute, &http.Client{}))
+	if got := unconfigured.condense(context.Background(), "nmap", output, 200); got != want {
+		t.Errorf("without a provider condensed to %.80q..., want the truncated output", got)
+	}
+
+	failing := fakeProviders(t, []string{ProviderGroq}, map[string]http.HandlerFunc{
+		ProviderGroq: func(w http.ResponseWriter, r *http.Request) { http.Error(w, "overloaded", http.StatusBadRequest) },
+	})
+	if got := failing.condense(context.Background(), "nmap", output, 200); got != want {
+		t.Errorf("with a failing provider condensed to %.80q..., want the truncated output", got)
+	}
+}
+
+func TestSecurityReportFitsReportBudget(t *testing.T) {
+	groq := &summarizingGroq{}
+	s := fakeProviders(t, []string{ProviderGroq}, map[string]http.HandlerFunc{
+		ProviderGroq: groq.handler(t, func(part string) string { return "summary of part " + part }),
+	})
+	s.config.AI.ReportTokenBudget = 500
+
+	output := largeNmapOutput(4000)
+	report, err := s.GenerateSecurityRecommendations(context.Background(), output, nil, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report != "report" {
+		t.Fatalf("report = %q", report)
+	}
+	reportPrompt := groq.prompts[len(groq.prompts)-1]
+	lastSummary := fmt.Sprintf("summary of part %d", len(chunkText(output, 500)))
+	if !strings.Contains(reportPrompt, lastSummary) || strings.Contains(reportPrompt, "http-alt") {
+		t.Fatalf("report prompt was built from the raw output instead of the summaries:\n%.300s", reportPrompt)
+	}
+}
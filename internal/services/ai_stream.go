@@ -0,0 +1,233 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+)
+
+// maxStreamLine bounds one server-sent event line from a provider
+const maxStreamLine = 1024 * 1024
+
+// errStreamStarted wraps a provider failure after part of the answer was already passed on,
+// when falling back to another provider would repeat it
+var errStreamStarted = errors.New("response was interrupted")
+
+// ChatResponseStream is ChatResponse, passing the answer to onToken piece by piece as the
+// provider produces it. Providers are tried in the fast order until one starts answering;
+// a failure after that is returned as is, since what was sent can't be taken back. An error
+// from onToken, such as a write to a client that went away, stops the stream.
+func (s *AIService) ChatResponseStream(ctx context.Context, userMessage string, conversationHistory []map[string]string, onToken func(string) error) (string, error) {
+	prompt := chatPrompt(userMessage, conversationHistory)
+	params := s.config.AI.Chat
+
+	var answer strings.Builder
+	emit := func(token string) error {
+		if token == "" {
+			return nil
+		}
+		answer.WriteString(token)
+		return onToken(token)
+	}
+
+	var lastErr error
+	for _, provider := range preferredFirst(ctx, s.config.AI.FastProviderOrder) {
+		var err error
+		switch {
+		case provider == ProviderGemini && s.config.AI.GeminiAPIKey != "":
+			err = s.streamGemini(ctx, prompt, params, emit)
+		case provider == ProviderGroq && s.config.AI.GroqAPIKey != "":
+			err = s.streamGroq(ctx, prompt, params, emit)
+		case provider == ProviderClaude && s.config.AI.ClaudeAPIKey != "":
+			err = s.streamClaude(ctx, prompt, params, emit)
+		default:
+			continue
+		}
+		if err == nil {
+			return answer.String(), nil
+		}
+		if answer.Len() > 0 || ctx.Err() != nil {
+			return answer.String(), fmt.Errorf("%w: %v", errStreamStarted, err)
+		}
+		if errors.Is(err, ErrContentBlocked) {
+			logf(ctx, "⚠️ %v", err)
+		}
+		lastErr = err
+	}
+
+	if lastErr != nil {
+		return "", lastErr
+	}
+	return "", ErrAINotConfigured
+}
+
+// openStream posts body to a provider's streaming endpoint, returning the response once it
+// has started. apiError describes a non-200 answer.
+func openStream(ctx context.Context, breaker *CircuitBreaker, endpoint string, body interface{}, headers map[string]string, apiError func(status string, body []byte) error) (*http.Response, error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := breaker.Stream(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return nil, apiError(resp.Status, data)
+	}
+	return resp, nil
+}
+
+// readEvents calls onData with the data of each server-sent event in r until r ends or
+// onData returns an error
+func readEvents(r io.Reader, onData func(data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		if err := onData(strings.TrimSpace(data)); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// streamGemini streams an answer from Gemini's streamGenerateContent endpoint
+func (s *AIService) streamGemini(ctx context.Context, prompt string, params config.AIGeneration, emit func(string) error) error {
+	endpoint := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:streamGenerateContent?alt=sse&key=%s", url.PathEscape(s.config.AI.GeminiModel), s.config.AI.GeminiAPIKey)
+	reqBody := GeminiRequest{
+		Contents: []GeminiContent{{Parts: []GeminiPart{{Text: prompt}}}},
+		GenerationConfig: GeminiGenerationConfig{
+			Temperature:     params.Temperature,
+			MaxOutputTokens: params.MaxTokens,
+		},
+	}
+	resp, err := openStream(ctx, s.breakers.Gemini, endpoint, reqBody, nil, func(status string, body []byte) error {
+		return fmt.Errorf("Gemini API error: %s - %s", status, string(body))
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return readEvents(resp.Body, func(data string) error {
+		var chunk GeminiResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("invalid Gemini stream event: %v", err)
+		}
+		text, err := geminiText(&chunk)
+		if errors.Is(err, ErrContentBlocked) {
+			return err
+		}
+		// Other chunks without text, such as the final one with only a finish reason, carry nothing to pass on
+		return emit(text)
+	})
+}
+
+// streamGroq streams an answer from Groq's OpenAI-compatible chat completions endpoint
+func (s *AIService) streamGroq(ctx context.Context, prompt string, params config.AIGeneration, emit func(string) error) error {
+	reqBody := GroqRequest{
+		Model:       s.config.AI.GroqModel,
+		Messages:    []GroqMessage{{Role: "user", Content: prompt}},
+		Temperature: params.Temperature,
+		MaxTokens:   params.MaxTokens,
+		Stream:      true,
+	}
+	headers := map[string]string{"Authorization": "Bearer " + s.config.AI.GroqAPIKey}
+	resp, err := openStream(ctx, s.breakers.Groq, "https://api.groq.com/openai/v1/chat/completions", reqBody, headers, func(status string, body []byte) error {
+		return fmt.Errorf("Groq API error: %s - %s", status, string(body))
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return readEvents(resp.Body, func(data string) error {
+		if data == "[DONE]" {
+			return nil
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("invalid Groq stream event: %v", err)
+		}
+		if len(chunk.Choices) == 0 {
+			return nil
+		}
+		return emit(chunk.Choices[0].Delta.Content)
+	})
+}
+
+// streamClaude streams an answer from the Anthropic Messages API
+func (s *AIService) streamClaude(ctx context.Context, prompt string, params config.AIGeneration, emit func(string) error) error {
+	reqBody := ClaudeRequest{
+		Model:       s.config.AI.ClaudeModel,
+		MaxTokens:   params.MaxTokens,
+		Temperature: min(params.Temperature, 1),
+		Messages:    []GroqMessage{{Role: "user", Content: prompt}},
+		Stream:      true,
+	}
+	headers := map[string]string{
+		"x-api-key":         s.config.AI.ClaudeAPIKey,
+		"anthropic-version": "2023-06-01",
+	}
+	resp, err := openStream(ctx, s.breakers.Claude, "https://api.anthropic.com/v1/messages", reqBody, headers, claudeAPIError)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return readEvents(resp.Body, func(data string) error {
+		var event struct {
+			Type  string `json:"type"`
+			Delta struct {
+				Type       string `json:"type"`
+				Text       string `json:"text"`
+				StopReason string `json:"stop_reason"`
+			} `json:"delta"`
+			ClaudeError
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("invalid Claude stream event: %v", err)
+		}
+		switch {
+		case event.Type == "error":
+			return fmt.Errorf("Claude API error: %s: %s", event.Error.Type, event.Error.Message)
+		case event.Type == "message_delta" && event.Delta.StopReason == "refusal":
+			return fmt.Errorf("%w: Claude refused the prompt", ErrContentBlocked)
+		case event.Type == "content_block_delta" && event.Delta.Type == "text_delta":
+			return emit(event.Delta.Text)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// streamFragments writes body in pieces of at most size bytes, flushing after each, so events
+// and even single lines arrive split across reads
+func streamFragments(body string, size int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for len(body) > 0 {
+			n := min(size, len(body))
+			w.Write([]byte(body[:n]))
+			flusher.Flush()
+			body = body[n:]
+		}
+	}
+}
+
+func TestChatResponseStreamReassemblesEvents(t *testing.T) {
+	tests := []struct {
+		provider string
+		body     string
+	}{
+		{ProviderGemini, "" +
+			"data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"Port 22 \"}]}}]}\r\n\r\n" +
+			"data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"is open — \"}]}}]}\r\n\r\n" +
+			"data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"close it.\"}]},\"finishReason\":\"STOP\"}]}\r\n\r\n"},
+		{ProviderGroq, "" +
+			": keep-alive\n\n" +
+			"data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n" +
+			"data: {\"choices\":[{\"delta\":{\"content\":\"Port 22 \"}}]}\n\n" +
+			"data: {\"choices\":[{\"delta\":{\"content\":\"is open — \"}}]}\n\n" +
+			"data: {\"choices\":[{\"delta\":{\"content\":\"close it.\"}}]}\n\n" +
+			"data: {\"choices\":[]}\n\n" +
+			"data: [DONE]\n\n"},
+		{ProviderClaude, "" +
+			"event: message_start\ndata: {\"type\":\"message_start\"}\n\n" +
+			"event: content_block_start\ndata: {\"type\":\"content_block_start\"}\n\n" +
+			"event: ping\ndata: {\"type\":\"ping\"}\n\n" +
+			"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Port 22 \"}}\n\n" +
+			"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"is open — \"}}\n\n" +
+			"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"close it.\"}}\n\n" +
+			"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"}}\n\n" +
+			"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"},
+	}
+	for _, tt := range tests {
+		// Splitting every 7 bytes cuts lines, and the multi-byte dash, across reads
+		for _, size := range []int{7, 1 << 20} {
+			t.Run(fmt.Sprintf("%s/%d-byte reads", tt.provider, size), func(t *testing.T) {
+				s := fakeProviders(t, []string{tt.provider}, map[string]http.HandlerFunc{
+					tt.provider: streamFragments(tt.body, size),
+				})
+				var tokens []string
+				answer, err := s.ChatResponseStream(context.Background(), "Is port 22 a problem?", nil, func(token string) error {
+					tokens = append(tokens, token)
+					return nil
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := []string{"Port 22 ", "is open — ", "close it."}
+				if answer != strings.Join(want, "") || strings.Join(tokens, "|") != strings.Join(want, "|") {
+					t.Fatalf("got answer %q from tokens %q", answer, tokens)
+				}
+			})
+		}
+	}
+}
+
+func TestChatResponseStreamFallsBackBeforeFirstToken(t *testing.T) {
+	s := fakeProviders(t, []string{ProviderGroq, ProviderClaude}, map[string]http.HandlerFunc{
+		ProviderGroq: func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"error":"overloaded"}`, http.StatusServiceUnavailable)
+		},
+		ProviderClaude: streamFragments("data: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"fallback\"}}\n\n", 5),
+	})
+	answer, err := s.ChatResponseStream(context.Background(), "hi", nil, func(string) error { return nil })
+	if err != nil || answer != "fallback" {
+		t.Fatalf("got %q, %v", answer, err)
+	}
+}
+
+func TestChatResponseStreamDoesNotFallBackMidAnswer(t *testing.T) {
+	s := fakeProviders(t, []string{ProviderGroq, ProviderClaude}, map[string]http.HandlerFunc{
+		ProviderGroq: streamFragments("data: {\"choices\":[{\"delta\":{\"content\":\"partial\"}}]}\n\ndata: {not json\n\n", 9),
+		// No Claude handler: a second provider must not be asked once tokens were sent
+	})
+	answer, err := s.ChatResponseStream(context.Background(), "hi", nil, func(string) error { return nil })
+	if !errors.Is(err, errStreamStarted) || answer != "partial" {
+		t.Fatalf("got %q, %v; want the partial answer and errStreamStarted", answer, err)
+	}
+}
+
+func TestChatResponseStreamStopsWhenClientGoesAway(t *testing.T) {
+	s := fakeProviders(t, []string{ProviderGroq}, map[string]http.HandlerFunc{
+		ProviderGroq: streamFragments("data: {\"choices\":[{\"delta\":{\"content\":\"one\"}}]}\n\ndata: {\"choices\":[{\"delta\":{\"content\":\"two\"}}]}\n\n", 64),
+	})
+	gone := errors.New("client went away")
+	var tokens []string
+	_, err := s.ChatResponseStream(context.Background(), "hi", nil, func(token string) error {
+		tokens = append(tokens, token)
+		return gone
+	})
+	if !errors.Is(err, errStreamStarted) || len(tokens) != 1 {
+		t.Fatalf("got %v after %q; want the stream stopped after the first token", err, tokens)
+	}
+}
+
+func TestChatResponseStreamClaudeRefusal(t *testing.T) {
+	s := fakeProviders(t, []string{ProviderClaude}, map[string]http.HandlerFunc{
+		ProviderClaude: streamFragments("data: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"refusal\"}}\n\n", 16),
+	})
+	if _, err := s.ChatResponseStream(context.Background(), "hi", nil, func(string) error { return nil }); !errors.Is(err, ErrContentBlocked) {
+		t.Fatalf("got %v, want ErrContentBlocked", err)
+	}
+}
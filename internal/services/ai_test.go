@@ -0,0 +1,398 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// fakeProviders serves each provider's API from handlers keyed by provider name, returning an
+// AIService that tries the providers in order and whose requests reach the server instead
+func fakeProviders(t *testing.T, order []string, handlers map[string]http.HandlerFunc) *AIService {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var provider string
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v1beta/models/"):
+			provider = ProviderGemini
+		case r.URL.Path == "/openai/v1/chat/completions":
+			provider = ProviderGroq
+		case r.URL.Path == "/v1/messages":
+			provider = ProviderClaude
+		}
+		handler, ok := handlers[provider]
+		if !ok {
+			t.Errorf("unexpected request to %s", r.URL)
+			http.Error(w, "unexpected", http.StatusNotFound)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	target, _ := url.Parse(server.URL)
+	client := &http.Client{Timeout: 5 * time.Second, Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+
+	cfg := &config.Config{AI: config.AIConfig{
+		GeminiAPIKey:      "gemini-key",
+		GroqAPIKey:        "groq-key",
+		ClaudeAPIKey:      "claude-key",
+		ProviderOrder:     order,
+		FastProviderOrder: order,
+	}}
+	return NewAIService(cfg, NewCircuitBreakers(5, time.Minute, client))
+}
+
+// providerAnswers are minimal successful non-streaming answers from each provider
+var providerAnswers = map[string]string{
+	ProviderGemini: `{"candidates":[{"content":{"parts":[{"text":"ok"}]}}]}`,
+	ProviderGroq:   `{"choices":[{"message":{"content":"ok"}}]}`,
+	ProviderClaude: `{"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn"}`,
+}
+
+// generationParams reads the temperature and token limit a provider was sent
+func generationParams(provider string, body map[string]interface{}) (float64, float64) {
+	if provider == ProviderGemini {
+		generation, _ := body["generationConfig"].(map[string]interface{})
+		temperature, _ := generation["temperature"].(float64)
+		maxTokens, _ := generation["maxOutputTokens"].(float64)
+		return temperature, maxTokens
+	}
+	temperature, _ := body["temperature"].(float64)
+	maxTokens, _ := body["max_tokens"].(float64)
+	return temperature, maxTokens
+}
+
+func TestAICallsSendGenerationParams(t *testing.T) {
+	calls := []struct {
+		name   string
+		params func(*config.AIConfig) config.AIGeneration
+		call   func(*AIService) error
+	}{
+		{"fix", func(c *config.AIConfig) config.AIGeneration { return c.Fix }, func(s *AIService) error {
+			_, err := s.GenerateFix(context.Background(), "eval(input)", "code injection")
+			return err
+		}},
+		{"analysis", func(c *config.AIConfig) config.AIGeneration { return c.Analysis }, func(s *AIService) error {
+			_, err := s.AnalyzeCode(context.Background(), "eval(input)", "python")
+			return err
+		}},
+		{"chat", func(c *config.AIConfig) config.AIGeneration { return c.Chat }, func(s *AIService) error {
+			_, err := s.ChatResponse(context.Background(), "hi", nil)
+			return err
+		}},
+	}
+	for _, provider := range []string{ProviderGemini, ProviderGroq, ProviderClaude} {
+		for _, call := range calls {
+			t.Run(provider+"/"+call.name, func(t *testing.T) {
+				var body map[string]interface{}
+				s := fakeProviders(t, []string{provider}, map[string]http.HandlerFunc{
+					provider: func(w http.ResponseWriter, r *http.Request) {
+						if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+							t.Error(err)
+						}
+						fmt.Fprint(w, providerAnswers[provider])
+					},
+				})
+				s.config.AI.Fix = config.AIGeneration{Temperature: 0.1, MaxTokens: 2048}
+				s.config.AI.Analysis = config.AIGeneration{Temperature: 0.3, MaxTokens: 3000}
+				s.config.AI.Chat = config.AIGeneration{Temperature: 0.9, MaxTokens: 512}
+
+				if err := call.call(s); err != nil {
+					t.Fatal(err)
+				}
+				want := call.params(&s.config.AI)
+				temperature, maxTokens := generationParams(provider, body)
+				if temperature != want.Temperature || int(maxTokens) != want.MaxTokens {
+					t.Fatalf("sent temperature %v and max tokens %v, want %+v", temperature, maxTokens, want)
+				}
+			})
+		}
+	}
+}
+
+func TestClaudeTemperatureCapped(t *testing.T) {
+	var body map[string]interface{}
+	s := fakeProviders(t, []string{ProviderClaude}, map[string]http.HandlerFunc{
+		ProviderClaude: func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&body)
+			fmt.Fprint(w, providerAnswers[ProviderClaude])
+		},
+	})
+	s.config.AI.Chat = config.AIGeneration{Temperature: 1.5, MaxTokens: 512}
+	if _, err := s.ChatResponse(context.Background(), "hi", nil); err != nil {
+		t.Fatal(err)
+	}
+	if temperature, _ := generationParams(ProviderClaude, body); temperature != 1 {
+		t.Fatalf("sent temperature %v, want 1", temperature)
+	}
+}
+
+// geminiBlocked is Gemini's answer to a prompt its safety filters refused
+const geminiBlocked = `{"promptFeedback":{"blockReason":"SAFETY","safetyRatings":[{"category":"HARM_CATEGORY_DANGEROUS_CONTENT","probability":"HIGH"}]}}`
+
+func TestGeminiText(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    string
+		want        string
+		wantBlocked string // Reason the error names, when blocked
+		wantErr     bool
+	}{
+		{name: "answer", response: providerAnswers[ProviderGemini], want: "ok"},
+		{name: "prompt blocked", response: geminiBlocked, wantBlocked: "SAFETY", wantErr: true},
+		{name: "answer withheld", response: `{"candidates":[{"finishReason":"RECITATION"}]}`, wantBlocked: "RECITATION", wantErr: true},
+		{name: "no candidates", response: `{}`, wantErr: true},
+		{name: "empty candidate", response: `{"candidates":[{"finishReason":"MAX_TOKENS"}]}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp GeminiResponse
+			if err := json.Unmarshal([]byte(tt.response), &resp); err != nil {
+				t.Fatal(err)
+			}
+			got, err := geminiText(&resp)
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Fatalf("geminiText() = %q, %v, want %q (error %v)", got, err, tt.want, tt.wantErr)
+			}
+			if blocked := errors.Is(err, ErrContentBlocked); blocked != (tt.wantBlocked != "") {
+				t.Fatalf("error %v is ErrContentBlocked: %v", err, blocked)
+			}
+			if tt.wantBlocked != "" && !strings.Contains(err.Error(), tt.wantBlocked) {
+				t.Fatalf("error %q doesn't give the reason %s", err, tt.wantBlocked)
+			}
+		})
+	}
+}
+
+// geminiBlockingUnless answers prompts starting with prefix and blocks the rest, recording them
+func geminiBlockingUnless(prefix string, prompts *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req GeminiRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		prompt := req.Contents[0].Parts[0].Text
+		*prompts = append(*prompts, prompt)
+		if prefix != "" && strings.HasPrefix(prompt, prefix) {
+			fmt.Fprint(w, providerAnswers[ProviderGemini])
+			return
+		}
+		fmt.Fprint(w, geminiBlocked)
+	}
+}
+
+func TestBlockedPromptRetriedRephrased(t *testing.T) {
+	calls := map[string]func(*AIService) (string, error){
+		"fix": func(s *AIService) (string, error) {
+			return s.GenerateFix(context.Background(), `password = "hunter2"`, "hardcoded credential")
+		},
+		"analysis": func(s *AIService) (string, error) {
+			return s.AnalyzeCode(context.Background(), `os.system(request.args["cmd"])`, "python")
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			var prompts []string
+			s := fakeProviders(t, []string{ProviderGemini}, map[string]http.HandlerFunc{
+				ProviderGemini: geminiBlockingUnless(defensivePreamble, &prompts),
+			})
+			got, err := call(s)
+			if err != nil || got != "ok" {
+				t.Fatalf("got %q, %v, want the answer to the rephrased prompt", got, err)
+			}
+			if len(prompts) != 2 || prompts[1] != defensivePreamble+prompts[0] {
+				t.Fatalf("prompts = %q, want the original and then the rephrased one", prompts)
+			}
+		})
+	}
+}
+
+func TestBlockedPromptFallsBackToNextProvider(t *testing.T) {
+	var prompts []string
+	s := fakeProviders(t, []string{ProviderGemini, ProviderGroq}, map[string]http.HandlerFunc{
+		ProviderGemini: geminiBlockingUnless("", &prompts),
+		ProviderGroq: func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, providerAnswers[ProviderGroq])
+		},
+	})
+	got, err := s.GenerateFix(context.Background(), `password = "hunter2"`, "hardcoded credential")
+	if err != nil || got != "ok" {
+		t.Fatalf("GenerateFix() = %q, %v, want Groq's answer", got, err)
+	}
+	if len(prompts) != 1 {
+		t.Fatalf("Gemini was asked %d times, want once before falling back", len(prompts))
+	}
+}
+
+func TestBlockedEverywhereIsReported(t *testing.T) {
+	var prompts []string
+	s := fakeProviders(t, []string{ProviderGemini, ProviderGroq}, map[string]http.HandlerFunc{
+		ProviderGemini: geminiBlockingUnless("", &prompts),
+		// A later provider failing otherwise doesn't hide the block
+		ProviderGroq: func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "overloaded", http.StatusBadRequest)
+		},
+	})
+	_, err := s.AnalyzeCode(context.Background(), `os.system(request.args["cmd"])`, "python")
+	if !errors.Is(err, ErrContentBlocked) || !strings.Contains(err.Error(), "SAFETY") {
+		t.Fatalf("AnalyzeCode() = %v, want ErrContentBlocked with the reason", err)
+	}
+	if len(prompts) != 2 || !strings.HasPrefix(prompts[1], defensivePreamble) {
+		t.Fatalf("prompts = %q, want the original and the rephrased one", prompts)
+	}
+}
+
+func TestProvidersFallBackToClaude(t *testing.T) {
+	var tried []string
+	var claudeHeaders http.Header
+	var claudeBody ClaudeRequest
+	s := fakeProviders(t, []string{ProviderGemini, ProviderGroq, ProviderClaude}, map[string]http.HandlerFunc{
+		ProviderGemini: func(w http.ResponseWriter, r *http.Request) {
+			tried = append(tried, ProviderGemini)
+			http.Error(w, `{"error":{"code":500,"message":"internal"}}`, http.StatusInternalServerError)
+		},
+		ProviderGroq: func(w http.ResponseWriter, r *http.Request) {
+			tried = append(tried, ProviderGroq)
+			http.Error(w, `{"error":{"message":"rate limited"}}`, http.StatusTooManyRequests)
+		},
+		ProviderClaude: func(w http.ResponseWriter, r *http.Request) {
+			tried = append(tried, ProviderClaude)
+			claudeHeaders = r.Header.Clone()
+			json.NewDecoder(r.Body).Decode(&claudeBody)
+			fmt.Fprint(w, `{"content":[{"type":"text","text":"answer from claude"}],"stop_reason":"end_turn"}`)
+		},
+	})
+	s.config.AI.ClaudeModel = "claude-test-model"
+
+	answer, err := s.ChatResponse(context.Background(), "hi", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "answer from claude" {
+		t.Fatalf("answer = %q, want Claude's", answer)
+	}
+	if strings.Join(tried, ",") != "gemini,groq,claude" {
+		t.Fatalf("tried %q, want each provider in order", tried)
+	}
+	if claudeHeaders.Get("x-api-key") != "claude-key" || claudeHeaders.Get("anthropic-version") == "" {
+		t.Errorf("Claude request headers %v", claudeHeaders)
+	}
+	if claudeBody.Model != "claude-test-model" || len(claudeBody.Messages) != 1 {
+		t.Errorf("Claude request %+v, want the configured model and the prompt", claudeBody)
+	}
+}
+
+func TestProviderOrderIsConfigurable(t *testing.T) {
+	var tried []string
+	answering := func(provider string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			tried = append(tried, provider)
+			fmt.Fprint(w, providerAnswers[provider])
+		}
+	}
+	s := fakeProviders(t, []string{ProviderClaude, ProviderGemini}, map[string]http.HandlerFunc{
+		ProviderGemini: answering(ProviderGemini),
+		ProviderClaude: answering(ProviderClaude),
+	})
+	if _, err := s.ChatResponse(context.Background(), "hi", nil); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(tried, ",") != "claude" {
+		t.Fatalf("tried %q, want only Claude, which is first", tried)
+	}
+}
+
+func TestClaudeErrorsSurfaced(t *testing.T) {
+	s := fakeProviders(t, []string{ProviderClaude}, map[string]http.HandlerFunc{
+		ProviderClaude: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(529)
+			fmt.Fprint(w, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)
+		},
+	})
+	_, err := s.ChatResponse(context.Background(), "hi", nil)
+	if err == nil || !strings.HasPrefix(err.Error(), "Claude API error: 529") || !strings.HasSuffix(err.Error(), " - overloaded_error: Overloaded") {
+		t.Fatalf("ChatResponse() = %v, want Claude's error message", err)
+	}
+
+	if got := claudeAPIError("502 Bad Gateway", []byte("<html>bad gateway</html>")).Error(); got != "Claude API error: 502 Bad Gateway - <html>bad gateway</html>" {
+		t.Errorf("error for a body that isn't JSON = %q", got)
+	}
+}
+
+func TestClaudeText(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    string
+		want    string
+		blocked bool
+		wantErr bool
+	}{
+		{name: "text", resp: providerAnswers[ProviderClaude], want: "ok"},
+		{name: "text after thinking", resp: `{"content":[{"type":"thinking"},{"type":"text","text":"ok"}]}`, want: "ok"},
+		{name: "refusal", resp: `{"content":[],"stop_reason":"refusal"}`, blocked: true, wantErr: true},
+		{name: "no text", resp: `{"content":[]}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp ClaudeResponse
+			if err := json.Unmarshal([]byte(tt.resp), &resp); err != nil {
+				t.Fatal(err)
+			}
+			got, err := claudeText(&resp)
+			if got != tt.want || (err != nil) != tt.wantErr || errors.Is(err, ErrContentBlocked) != tt.blocked {
+				t.Fatalf("claudeText() = %q, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestRequestsTargetConfiguredModel(t *testing.T) {
+	// requestedModel reads the model a request asked for: Gemini names it in the path, the
+	// others in the body
+	requestedModel := func(provider string, r *http.Request) string {
+		if provider == ProviderGemini {
+			return strings.TrimSuffix(strings.TrimPrefix(r.URL.EscapedPath(), "/v1beta/models/"), ":generateContent")
+		}
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		return body.Model
+	}
+	for _, provider := range []string{ProviderGemini, ProviderGroq, ProviderClaude} {
+		t.Run(provider, func(t *testing.T) {
+			var got string
+			s := fakeProviders(t, []string{provider}, map[string]http.HandlerFunc{
+				provider: func(w http.ResponseWriter, r *http.Request) {
+					got = requestedModel(provider, r)
+					fmt.Fprint(w, providerAnswers[provider])
+				},
+			})
+			s.config.AI.GeminiModel = "gemini-custom"
+			s.config.AI.GroqModel = "groq-custom"
+			s.config.AI.ClaudeModel = "claude-custom"
+
+			if _, err := s.GenerateFix(context.Background(), "eval(input)", "code injection"); err != nil {
+				t.Fatal(err)
+			}
+			if want := provider + "-custom"; got != want {
+				t.Fatalf("requested model %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	apiKeyPrefix       = "vpk_"
+	apiKeyBytes        = 32
+	apiKeyDisplayChars = 12 // Stored prefix length, including apiKeyPrefix
+
+	// apiKeyUsageInterval limits how often a key's last-used time is written
+	apiKeyUsageInterval = time.Minute
+
+	maxAPIKeysPerUser = 50
+)
+
+// API key scopes: read allows GET requests, write allows everything else
+const (
+	APIKeyScopeRead  = "read"
+	APIKeyScopeWrite = "write"
+)
+
+var APIKeyScopes = []string{APIKeyScopeRead, APIKeyScopeWrite}
+
+var (
+	ErrInvalidAPIKey      = errors.New("invalid or revoked API key")
+	ErrAPIKeyNotFound     = errors.New("API key not found")
+	ErrInvalidAPIKeyName  = errors.New("API key name must be 1-100 characters")
+	ErrInvalidAPIKeyScope = fmt.Errorf("API key scopes must be %s", strings.Join(APIKeyScopes, " and/or "))
+	ErrTooManyAPIKeys     = fmt.Errorf("at most %d active API keys are allowed", maxAPIKeysPerUser)
+)
+
+type APIKeyService struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyService(db *gorm.DB) *APIKeyService {
+	return &APIKeyService{db: db}
+}
+
+// Create generates a key for the user. The returned plaintext key is never stored and
+// can't be recovered later. With no scopes the key gets all of them.
+func (s *APIKeyService) Create(userID uuid.UUID, name string, scopes []string) (*models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, "", ErrInvalidAPIKeyName
+	}
+	scopes, err := normalizeAPIKeyScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var active int64
+	if err := s.db.Model(&models.APIKey{}).Where("user_id = ? AND revoked_at IS NULL", userID).Count(&active).Error; err != nil {
+		return nil, "", err
+	}
+	if active >= maxAPIKeysPerUser {
+		return nil, "", ErrTooManyAPIKeys
+	}
+
+	random, err := utils.GenerateRandomToken(apiKeyBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	key := apiKeyPrefix + random
+
+	apiKey := &models.APIKey{
+		UserID:  userID,
+		Name:    name,
+		Prefix:  key[:apiKeyDisplayChars],
+		KeyHash: utils.HashSHA256(key),
+		Scopes:  scopes,
+	}
+	if err := s.db.Create(apiKey).Error; err != nil {
+		return nil, "", err
+	}
+	return apiKey, key, nil
+}
+
+func normalizeAPIKeyScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return append([]string{}, APIKeyScopes...), nil
+	}
+	seen := make(map[string]bool, len(scopes))
+	var normalized []string
+	for _, scope := range scopes {
+		if scope != APIKeyScopeRead && scope != APIKeyScopeWrite {
+			return nil, ErrInvalidAPIKeyScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
+
+// List returns the user's keys, revoked ones included, newest first
+func (s *APIKeyService) List(userID uuid.UUID) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Revoke disables a key immediately; it stays listed with its revocation time
+func (s *APIKeyService) Revoke(userID, keyID uuid.UUID) error {
+	result := s.db.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// AuthenticateAPIKey resolves a key to its owner and scopes, recording that it was used
+func (s *APIKeyService) AuthenticateAPIKey(key string) (uuid.UUID, string, []string, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return uuid.Nil, "", nil, ErrInvalidAPIKey
+	}
+
+	var apiKey models.APIKey
+	if err := s.db.Where("key_hash = ? AND revoked_at IS NULL", utils.HashSHA256(key)).First(&apiKey).Error; err != nil {
+		return uuid.Nil, "", nil, ErrInvalidAPIKey
+	}
+	var user models.User
+	if err := s.db.Select("id", "username").First(&user, "id = ?", apiKey.UserID).Error; err != nil {
+		return uuid.Nil, "", nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyUsageInterval {
+		s.db.Model(&apiKey).Update("last_used_at", now)
+	}
+	return user.ID, user.Username, apiKey.Scopes, nil
+}
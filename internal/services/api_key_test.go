@@ -0,0 +1,111 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/google/uuid"
+)
+
+func TestNormalizeAPIKeyScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		want    []string
+		wantErr bool
+	}{
+		{"none grants all", nil, []string{"read", "write"}, false},
+		{"read only", []string{"read"}, []string{"read"}, false},
+		{"duplicates dropped", []string{"write", "read", "write"}, []string{"write", "read"}, false},
+		{"unknown scope", []string{"read", "admin"}, nil, true},
+		{"case matters", []string{"READ"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeAPIKeyScopes(tt.scopes)
+			if tt.wantErr {
+				if err != ErrInvalidAPIKeyScope {
+					t.Fatalf("got %v, want ErrInvalidAPIKeyScope", err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateAPIKeyRejectsWithoutPrefix(t *testing.T) {
+	db, _ := newMockDB(t)
+	// No query is expected: keys without the prefix are rejected before the lookup
+	if _, _, _, err := NewAPIKeyService(db).AuthenticateAPIKey("not-a-key"); err != ErrInvalidAPIKey {
+		t.Fatalf("got %v, want ErrInvalidAPIKey", err)
+	}
+}
+
+func TestAuthenticateAPIKeyRejectsRevokedKey(t *testing.T) {
+	db, mock := newMockDB(t)
+	key := "vpk_revoked"
+	mock.ExpectQuery(`SELECT \* FROM "api_keys" WHERE key_hash = \$1 AND revoked_at IS NULL`).
+		WithArgs(utils.HashSHA256(key), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	if _, _, _, err := NewAPIKeyService(db).AuthenticateAPIKey(key); err != ErrInvalidAPIKey {
+		t.Fatalf("got %v, want ErrInvalidAPIKey", err)
+	}
+}
+
+func TestAuthenticateAPIKeyReturnsOwnerAndScopes(t *testing.T) {
+	db, mock := newMockDB(t)
+	key := "vpk_active"
+	keyID, userID := uuid.New(), uuid.New()
+	mock.ExpectQuery(`SELECT \* FROM "api_keys" WHERE key_hash = \$1 AND revoked_at IS NULL`).
+		WithArgs(utils.HashSHA256(key), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "scopes"}).
+			AddRow(keyID, userID, `["read"]`))
+	mock.ExpectQuery(`SELECT "id","username" FROM "users" WHERE id = \$1`).
+		WithArgs(userID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(userID, "octocat"))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "api_keys" SET "last_used_at"=\$1 WHERE "id" = \$2`).
+		WithArgs(sqlmock.AnyArg(), keyID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	gotUser, username, scopes, err := NewAPIKeyService(db).AuthenticateAPIKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotUser != userID || username != "octocat" || !reflect.DeepEqual(scopes, []string{"read"}) {
+		t.Fatalf("got %s %q %v", gotUser, username, scopes)
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    int64
+		wantErr error
+	}{
+		{"active key", 1, nil},
+		{"already revoked or another user's key", 0, ErrAPIKeyNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			userID, keyID := uuid.New(), uuid.New()
+			mock.ExpectBegin()
+			mock.ExpectExec(`UPDATE "api_keys" SET "revoked_at"=\$1 WHERE id = \$2 AND user_id = \$3 AND revoked_at IS NULL`).
+				WithArgs(sqlmock.AnyArg(), keyID, userID).
+				WillReturnResult(sqlmock.NewResult(0, tt.rows))
+			mock.ExpectCommit()
+
+			if err := NewAPIKeyService(db).Revoke(userID, keyID); err != tt.wantErr {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
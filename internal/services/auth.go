@@ -3,9 +3,11 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/datmedevil17/go-vuln/internal/config"
@@ -15,6 +17,14 @@ import (
 	"gorm.io/gorm"
 )
 
+var (
+	ErrScopeNotAllowed    = errors.New("scope is not one of the configured GitHub write scopes")
+	ErrMissingGitHubScope = errors.New("GitHub token is missing a required scope")
+)
+
+// githubWriteScopes are the scopes that let a token open issues, comment and push fix branches
+var githubWriteScopes = []string{"repo", "public_repo"}
+
 type AuthService struct {
 	db     *gorm.DB
 	config *config.Config
@@ -33,7 +43,7 @@ func NewAuthService(db *gorm.DB, cfg *config.Config) *AuthService {
 		ClientID:     cfg.GitHub.ClientID,
 		ClientSecret: cfg.GitHub.ClientSecret,
 		RedirectURL:  cfg.GitHub.CallbackURL,
-		Scopes:       []string{"user:email", "repo"},
+		Scopes:       cfg.GitHub.Scopes,
 		Endpoint:     github.Endpoint,
 	}
 
@@ -54,6 +64,65 @@ func (s *AuthService) GetAuthURL(state string) string {
 	return s.oauth.AuthCodeURL(state, oauth2.AccessTypeOnline)
 }
 
+// GetReauthorizeURL returns a GitHub OAuth URL asking for scope on top of what the user has
+// already granted. GitHub replaces the grant with whatever the new authorization asks for,
+// so the existing scopes are requested again. The callback is the same as for sign-in.
+func (s *AuthService) GetReauthorizeURL(state string, user *models.User, scope string) (string, []string, error) {
+	allowed := false
+	for _, writeScope := range s.config.GitHub.WriteScopes {
+		if scope == writeScope {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return "", nil, ErrScopeNotAllowed
+	}
+
+	scopes := append([]string{}, s.oauth.Scopes...)
+	for _, granted := range append(splitScopes(user.GitHubScope), scope) {
+		if !HasGitHubScope(strings.Join(scopes, ","), granted) {
+			scopes = append(scopes, granted)
+		}
+	}
+	authURL := s.oauth.AuthCodeURL(state, oauth2.AccessTypeOnline, oauth2.SetAuthURLParam("scope", strings.Join(scopes, " ")))
+	return authURL, scopes, nil
+}
+
+// splitScopes parses a scope list as GitHub reports it, comma-separated
+func splitScopes(granted string) []string {
+	var scopes []string
+	for _, scope := range strings.Split(granted, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// HasGitHubScope reports whether any of scopes is in the granted list, counting repo as
+// covering public_repo
+func HasGitHubScope(granted string, scopes ...string) bool {
+	for _, have := range splitScopes(granted) {
+		for _, want := range scopes {
+			if have == want || (have == "repo" && want == "public_repo") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RequireGitHubWriteScope fails with a pointer to re-authorization when the user's token
+// can't write to repositories. Tokens from before scopes were recorded were always granted
+// repo, so an empty scope list is let through.
+func RequireGitHubWriteScope(user *models.User, action string) error {
+	if user.GitHubScope == "" || HasGitHubScope(user.GitHubScope, githubWriteScopes...) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s needs the repo scope; re-authorize with repo scope via GET /api/user/github/reauthorize?scope=repo", ErrMissingGitHubScope, action)
+}
+
 // HandleCallback processes GitHub OAuth callback
 func (s *AuthService) HandleCallback(ctx context.Context, code string) (*models.User, error) {
 	// Exchange code for token
@@ -73,12 +142,16 @@ func (s *AuthService) HandleCallback(ctx context.Context, code string) (*models.
 		githubUser.Email, _ = s.getGitHubEmail(ctx, token.AccessToken)
 	}
 
+	// GitHub reports the granted scopes, which may be fewer than requested
+	grantedScope, _ := token.Extra("scope").(string)
+
 	// Find or create user (FirstOrCreate avoids "record not found" log for new signups)
 	githubID := fmt.Sprintf("%d", githubUser.ID)
 	var user models.User
 	err = s.db.Where("github_id = ?", githubID).
 		Assign(models.User{
 			AccessToken: token.AccessToken,
+			GitHubScope: grantedScope,
 			AvatarURL:   githubUser.AvatarURL,
 		}).
 		Attrs(models.User{
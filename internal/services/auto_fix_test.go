@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/google/uuid"
+)
+
+// fakeFixRepo serves the GitHub calls of an auto-fix on acme/api and a Groq provider whose
+// answer is fix applied to the code it was sent. It records the prompt and the committed file.
+type fakeFixRepo struct {
+	file      string
+	fix       func(code string) string
+	prompt    string
+	committed string
+}
+
+func (f *fakeFixRepo) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/openai/v1/chat/completions":
+			var req GroqRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			f.prompt = req.Messages[0].Content
+			code := f.prompt[strings.LastIndex(f.prompt, ":\n")+2:]
+			answer, _ := json.Marshal(f.fix(code))
+			fmt.Fprintf(w, `{"choices":[{"message":{"content":%s}}]}`, answer)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/api/contents/store/users.go" && r.URL.Query().Get("ref") == "":
+			fmt.Fprint(w, f.file)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/api/contents/store/users.go":
+			fmt.Fprint(w, `{"sha":"file-sha"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/api/git/ref/heads/main":
+			fmt.Fprint(w, `{"ref":"refs/heads/main","object":{"sha":"base-sha"}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/api/git/refs":
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPut && r.URL.Path == "/repos/acme/api/contents/store/users.go":
+			var update UpdateFileRequest
+			json.NewDecoder(r.Body).Decode(&update)
+			content, _ := base64.StdEncoding.DecodeString(update.Content)
+			f.committed = string(content)
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/api/pulls":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"number":12,"html_url":"https://github.com/acme/api/pull/12"}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}
+}
+
+// autoFixExecutor returns an executor whose GitHub and AI calls go to repo
+func autoFixExecutor(t *testing.T, repo *fakeFixRepo, contextLines int) (*WorkflowExecutor, uuid.UUID) {
+	t.Helper()
+	server := httptest.NewServer(repo.handler(t))
+	t.Cleanup(server.Close)
+	target, _ := url.Parse(server.URL)
+	client := &http.Client{Timeout: 5 * time.Second, Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+
+	db, mock := newMockDB(t)
+	userID := uuid.New()
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "access_token", "github_scope"}).AddRow(userID, "gho_token", "repo"))
+	breakers := NewCircuitBreakers(5, time.Minute, client)
+	ai := NewAIService(&config.Config{AI: config.AIConfig{
+		GroqAPIKey:      "groq-key",
+		ProviderOrder:   []string{ProviderGroq},
+		FixContextLines: contextLines,
+	}}, breakers)
+	return NewWorkflowExecutor(db, nil, nil, nil, nil, ai, NewGitHubService(db, breakers, config.GitHubConfig{}), nil, nil, config.WorkflowConfig{}), userID
+}
+
+func autoFixNode() *WorkflowNode {
+	return &WorkflowNode{ID: "fix", Type: "auto-fix", Data: map[string]interface{}{
+		"owner": "acme", "repo": "api", "path": "store/users.go", "vulnerability": "SQL injection",
+	}}
+}
+
+// parameterize is the fix for goSource's injectable query
+func parameterize(code string) string {
+	code = strings.Replace(code, "\tquery := fmt.Sprintf(\"SELECT * FROM users WHERE name = '%s'\", name)\n\trow := db.QueryRow(query)",
+		"\trow := db.QueryRow(\"SELECT * FROM users WHERE name = $1\", name)", 1)
+	return "```go\n" + code + "\n```"
+}
+
+func TestAutoFixSendsWindowAndSplicesItBack(t *testing.T) {
+	// Enough lines after the function that the window can't be the whole file
+	file := goSource + strings.Repeat("\n// padding\n", 50)
+	repo := &fakeFixRepo{file: file, fix: parameterize}
+	e, userID := autoFixExecutor(t, repo, 1)
+	previous := map[string]interface{}{
+		"sast": withFindings(map[string]interface{}{"scanner": "semgrep", "output": `{"results":[
+			{"check_id":"go.lang.security.audit.database.string-formatted-query","path":"store/users.go","start":{"line":7},"end":{"line":8},"extra":{"severity":"ERROR"}}]}`}),
+	}
+
+	result, err := e.executeAutoFix(context.Background(), autoFixNode(), previous, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The AI sees the enclosing function, doc comment included, and nothing else
+	if !strings.Contains(repo.prompt, "lines 5 onwards of store/users.go") {
+		t.Errorf("prompt doesn't say where the lines are:\n%s", repo.prompt)
+	}
+	if !strings.Contains(repo.prompt, "// FindUser looks a user up by name") || strings.Contains(repo.prompt, "func other") || strings.Contains(repo.prompt, "// padding") {
+		t.Errorf("prompt sends more or less than the enclosing function:\n%s", repo.prompt)
+	}
+
+	want := strings.Replace(file, "\tquery := fmt.Sprintf(\"SELECT * FROM users WHERE name = '%s'\", name)\n\trow := db.QueryRow(query)",
+		"\trow := db.QueryRow(\"SELECT * FROM users WHERE name = $1\", name)", 1)
+	if repo.committed != want {
+		t.Fatalf("committed\n%s\nwant\n%s", repo.committed, want)
+	}
+	lines := result.(map[string]interface{})["lines"]
+	if fmt.Sprint(lines) != "map[from:5 to:14]" {
+		t.Errorf("result lines = %v, want 5 to 14", lines)
+	}
+}
+
+func TestAutoFixWithoutLocationRewritesWholeFile(t *testing.T) {
+	repo := &fakeFixRepo{file: goSource, fix: func(code string) string { return "package store\n" }}
+	e, userID := autoFixExecutor(t, repo, 1)
+
+	result, err := e.executeAutoFix(context.Background(), autoFixNode(), map[string]interface{}{}, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(repo.prompt, "func other") {
+		t.Errorf("prompt lacks the rest of the file:\n%s", repo.prompt)
+	}
+	if repo.committed != "package store\n" {
+		t.Errorf("committed %q, want the regenerated file", repo.committed)
+	}
+	if _, ok := result.(map[string]interface{})["lines"]; ok {
+		t.Error("a whole-file fix reports lines")
+	}
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNoAutoFix is returned when an execution has no auto-fix pull request to roll back
+	ErrNoAutoFix = errors.New("execution has no auto-fix pull request")
+
+	// ErrAmbiguousAutoFix is returned when several auto-fix nodes opened PRs and none was named
+	ErrAmbiguousAutoFix = errors.New("execution has several auto-fix pull requests; name one with node_id")
+
+	// ErrAutoFixRolledBack is returned when the auto-fix has already been rolled back
+	ErrAutoFixRolledBack = errors.New("auto-fix has already been rolled back")
+
+	// ErrAutoFixMerged is returned when the PR was merged and a revert PR wasn't asked for
+	ErrAutoFixMerged = errors.New("auto-fix pull request is already merged; set revert_merged to open a revert pull request")
+)
+
+// AutoFixRollback records how an auto-fix was undone
+type AutoFixRollback struct {
+	NodeID         string    `json:"node_id"`
+	Action         string    `json:"action"` // closed, branch_deleted or reverted
+	PRNumber       int       `json:"pr_number"`
+	RevertPRURL    string    `json:"revert_pr_url,omitempty"`
+	RevertPRNumber int       `json:"revert_pr_number,omitempty"`
+	RolledBackAt   time.Time `json:"rolled_back_at"`
+}
+
+// autoFixRecord is what an auto-fix node stored about the pull request it opened
+type autoFixRecord struct {
+	owner, repo, path  string
+	branch, baseBranch string
+	baseSHA            string
+	prNumber           int
+	rolledBack         bool
+}
+
+// RollbackAutoFix undoes the pull request an execution's auto-fix node opened. An open PR is
+// closed and its branch deleted; a PR closed without merging only has its branch deleted.
+// A merged PR can't be closed, so with revertMerged a PR restoring the file as it was before
+// the fix is opened instead.
+func (s *WorkflowService) RollbackAutoFix(ctx context.Context, executionID, userID uuid.UUID, nodeID string, revertMerged bool) (*AutoFixRollback, error) {
+	execution, err := s.GetWorkflowExecution(executionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	nodeID, fix, err := findAutoFix(execution.Results, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if fix.rolledBack {
+		return nil, ErrAutoFixRolledBack
+	}
+
+	var user models.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %v", err)
+	}
+	if user.AccessToken == "" {
+		return nil, fmt.Errorf("user has no GitHub access token")
+	}
+	if err := RequireGitHubWriteScope(&user, "rolling back an auto-fix"); err != nil {
+		return nil, err
+	}
+
+	github := s.executor.githubService
+	pr, err := github.GetPullRequest(ctx, user.AccessToken, fix.owner, fix.repo, fix.prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	rollback := &AutoFixRollback{NodeID: nodeID, PRNumber: fix.prNumber}
+	switch {
+	case pr.Merged:
+		if !revertMerged {
+			return nil, ErrAutoFixMerged
+		}
+		revert, err := s.openRevertPR(ctx, user.AccessToken, fix)
+		if err != nil {
+			return nil, err
+		}
+		rollback.Action = "reverted"
+		rollback.RevertPRURL = revert.HTMLURL
+		rollback.RevertPRNumber = revert.Number
+	case pr.State == "open":
+		if err := github.SetIssueState(ctx, user.AccessToken, fix.owner, fix.repo, fix.prNumber, "closed"); err != nil {
+			return nil, err
+		}
+		rollback.Action = "closed"
+	default:
+		rollback.Action = "branch_deleted"
+	}
+
+	// The fix branch is dead weight in every case; failing to delete it doesn't undo the rollback
+	if err := github.DeleteBranch(ctx, user.AccessToken, fix.owner, fix.repo, fix.branch); err != nil {
+		logf(ctx, "⚠️ Failed to delete auto-fix branch %s of %s/%s: %v", fix.branch, fix.owner, fix.repo, err)
+	}
+
+	rollback.RolledBackAt = time.Now()
+	if err := s.recordRollback(executionID, nodeID, rollback); err != nil {
+		return nil, err
+	}
+	return rollback, nil
+}
+
+// openRevertPR branches from the current base and restores the fixed file as it was when the fix was made
+func (s *WorkflowService) openRevertPR(ctx context.Context, token string, fix autoFixRecord) (*GitHubPR, error) {
+	github := s.executor.githubService
+	if fix.baseSHA == "" || fix.path == "" {
+		return nil, fmt.Errorf("auto-fix was recorded without the commit it was based on, so it can't be reverted automatically")
+	}
+
+	original, err := github.GetFileContentAt(ctx, token, fix.owner, fix.repo, fix.path, fix.baseSHA)
+	if err != nil {
+		return nil, err
+	}
+	base, err := github.GetReference(ctx, token, fix.owner, fix.repo, "heads/"+fix.baseBranch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get base ref: %v", err)
+	}
+
+	revertBranch := fmt.Sprintf("revert/%s", fix.branch)
+	if err := github.CreateBranch(ctx, token, fix.owner, fix.repo, revertBranch, base.Object.Sha); err != nil {
+		return nil, err
+	}
+	fileSha, err := github.GetFileSHA(ctx, token, fix.owner, fix.repo, fix.path, revertBranch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file sha: %v", err)
+	}
+	if err := github.UpdateFile(ctx, token, fix.owner, fix.repo, fix.path, original, fileSha, fmt.Sprintf("revert: auto-fix from #%d", fix.prNumber), revertBranch); err != nil {
+		return nil, fmt.Errorf("failed to update file: %v", err)
+	}
+
+	title := fmt.Sprintf("revert: auto-fix of %s (#%d)", fix.path, fix.prNumber)
+	body := fmt.Sprintf("This PR reverts the auto-fix merged in #%d, restoring `%s` to its state before the fix.\n\n*Generated by VulnPilot*", fix.prNumber, fix.path)
+	return github.CreatePullRequest(ctx, token, fix.owner, fix.repo, title, body, revertBranch, fix.baseBranch)
+}
+
+// findAutoFix picks the auto-fix result to roll back: the named node, or the only one that opened a PR
+func findAutoFix(results map[string]interface{}, nodeID string) (string, autoFixRecord, error) {
+	var found []string
+	for id, result := range results {
+		nodeMap, ok := result.(map[string]interface{})
+		if !ok || nodeMap["type"] != "auto-fix" || nodeMap["status"] != "created" {
+			continue
+		}
+		if nodeID == "" || id == nodeID {
+			found = append(found, id)
+		}
+	}
+	switch {
+	case len(found) == 0:
+		return "", autoFixRecord{}, ErrNoAutoFix
+	case len(found) > 1:
+		return "", autoFixRecord{}, ErrAmbiguousAutoFix
+	}
+
+	nodeMap := results[found[0]].(map[string]interface{})
+	str := func(key string) string {
+		value, _ := nodeMap[key].(string)
+		return value
+	}
+	fix := autoFixRecord{
+		owner:      str("owner"),
+		repo:       str("repo"),
+		path:       str("path"),
+		branch:     str("branch"),
+		baseBranch: str("base_branch"),
+		baseSHA:    str("base_sha"),
+		rolledBack: nodeMap["rollback"] != nil,
+	}
+	switch number := nodeMap["pr_number"].(type) {
+	case float64: // Results read back from the database decode as generic JSON
+		fix.prNumber = int(number)
+	case int:
+		fix.prNumber = number
+	}
+	if fix.owner == "" || fix.repo == "" || fix.branch == "" || fix.prNumber == 0 {
+		return "", autoFixRecord{}, fmt.Errorf("%w: node %s predates rollback support", ErrNoAutoFix, found[0])
+	}
+	return found[0], fix, nil
+}
+
+// recordRollback stores the rollback on the auto-fix node's result so it isn't repeated
+func (s *WorkflowService) recordRollback(executionID uuid.UUID, nodeID string, rollback *AutoFixRollback) error {
+	var execution models.WorkflowExecution
+	if err := s.db.Select("id", "results").First(&execution, "id = ?", executionID).Error; err != nil {
+		return err
+	}
+	nodeMap, ok := execution.Results[nodeID].(map[string]interface{})
+	if !ok {
+		return ErrNoAutoFix
+	}
+	nodeMap["rollback"] = rollback
+	return s.db.Model(&models.WorkflowExecution{}).Where("id = ?", executionID).Update("results", execution.Results).Error
+}
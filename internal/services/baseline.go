@@ -0,0 +1,74 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+)
+
+// BaselineComparison is the delta between an execution and the workflow's approved baseline
+type BaselineComparison struct {
+	BaselineExecutionID uuid.UUID `json:"baseline_execution_id"`
+	NewFindings         int       `json:"new_findings"`
+	ResolvedFindings    int       `json:"resolved_findings"`
+	New                 []string  `json:"new"`
+	Resolved            []string  `json:"resolved"`
+}
+
+// compareWithBaseline lists findings present in results but absent from the baseline, and vice versa
+func compareWithBaseline(baseline *models.WorkflowExecution, results map[string]interface{}) *BaselineComparison {
+	current := extractFindingKeys(results)
+	previous := extractFindingKeys(baseline.Results)
+
+	comparison := &BaselineComparison{
+		BaselineExecutionID: baseline.ID,
+		New:                 []string{},
+		Resolved:            []string{},
+	}
+	for key := range current {
+		if _, ok := previous[key]; !ok {
+			comparison.New = append(comparison.New, key)
+		}
+	}
+	for key := range previous {
+		if _, ok := current[key]; !ok {
+			comparison.Resolved = append(comparison.Resolved, key)
+		}
+	}
+	sort.Strings(comparison.New)
+	sort.Strings(comparison.Resolved)
+	comparison.NewFindings = len(comparison.New)
+	comparison.ResolvedFindings = len(comparison.Resolved)
+
+	return comparison
+}
+
+// extractFindingKeys builds a set of stable identifiers for the findings in node results
+func extractFindingKeys(results map[string]interface{}) map[string]struct{} {
+	keys := make(map[string]struct{})
+	for _, finding := range extractFindings(results) {
+		keys[finding.Key] = struct{}{}
+	}
+	return keys
+}
+
+// extractFindings lists the normalized findings in node results, deduplicated by key
+func extractFindings(results map[string]interface{}) []Finding {
+	seen := make(map[string]struct{})
+	var findings []Finding
+	for _, result := range results {
+		nodeMap, ok := result.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, f := range nodeFindings(nodeMap) {
+			if _, ok := seen[f.Key]; ok {
+				continue
+			}
+			seen[f.Key] = struct{}{}
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrNotOwned is returned when a bulk operation references records the user doesn't own
+var ErrNotOwned = errors.New("one or more records do not belong to the user")
+
+// ErrEmptyBulkDelete is returned when a bulk delete has neither IDs nor a filter
+var ErrEmptyBulkDelete = errors.New("ids or a filter are required")
+
+// ErrInvalidBulkStatus is returned when a bulk delete filters on a status records can't be deleted in
+var ErrInvalidBulkStatus = errors.New("invalid status")
+
+// activeStatuses are the statuses of executions and scans still being worked on. Bulk deletes
+// skip them, since the executor or scanner would go on writing to the deleted rows.
+var activeStatuses = []string{"pending", "queued", "running"}
+
+var (
+	// ExecutionDeletableStatuses are the execution statuses a bulk delete may filter on
+	ExecutionDeletableStatuses = []string{"completed", "failed", "timed_out", "cancelled", "skipped"}
+
+	// ScanDeletableStatuses are the scan statuses a bulk delete may filter on
+	ScanDeletableStatuses = []string{"completed", "failed", "timed_out"}
+)
+
+func isDeletableStatus(deletable []string, status string) bool {
+	for _, s := range deletable {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// BulkDeleteFilter selects records to delete when explicit IDs aren't given
+type BulkDeleteFilter struct {
+	Status    string
+	OlderThan time.Duration
+}
+
+// bulkDelete removes the user's records of model either by ID or by filter in a single transaction.
+// When IDs are given, every one must belong to the user or nothing is deleted. Records still
+// pending, queued or running are never deleted, and filter.Status must be one of deletable.
+// Optional retain scopes are applied to filter-based deletes to protect records from cleanup.
+func bulkDelete(db *gorm.DB, model interface{}, userID uuid.UUID, ids []uuid.UUID, filter BulkDeleteFilter, deletable []string, retain ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	if len(ids) == 0 && filter.Status == "" && filter.OlderThan <= 0 {
+		return 0, ErrEmptyBulkDelete
+	}
+	if filter.Status != "" && !isDeletableStatus(deletable, filter.Status) {
+		return 0, fmt.Errorf("%w %q: must be one of %s", ErrInvalidBulkStatus, filter.Status, strings.Join(deletable, ", "))
+	}
+
+	var deleted int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("user_id = ?", userID)
+
+		if len(ids) > 0 {
+			unique := make(map[uuid.UUID]struct{}, len(ids))
+			for _, id := range ids {
+				unique[id] = struct{}{}
+			}
+
+			var owned int64
+			if err := tx.Model(model).Where("id IN ? AND user_id = ?", ids, userID).Count(&owned).Error; err != nil {
+				return err
+			}
+			if owned != int64(len(unique)) {
+				return ErrNotOwned
+			}
+			query = query.Where("id IN ?", ids)
+		} else {
+			query = query.Scopes(retain...)
+		}
+
+		query = query.Where("status NOT IN ?", activeStatuses)
+		if filter.Status != "" {
+			query = query.Where("status = ?", filter.Status)
+		}
+		if filter.OlderThan > 0 {
+			query = query.Where("created_at < ?", time.Now().Add(-filter.OlderThan))
+		}
+
+		result := query.Delete(model)
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		return nil
+	})
+
+	return deleted, err
+}
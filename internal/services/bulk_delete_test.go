@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestBulkDeleteRejectsPartialOwnership(t *testing.T) {
+	db, mock := newMockDB(t)
+	userID := uuid.New()
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "workflow_executions" WHERE id IN \(\$1,\$2\) AND user_id = \$3`).
+		WithArgs(ids[0], ids[1], userID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	deleted, err := bulkDelete(db, &models.WorkflowExecution{}, userID, ids, BulkDeleteFilter{}, ExecutionDeletableStatuses)
+	if !errors.Is(err, ErrNotOwned) {
+		t.Fatalf("got %v, want ErrNotOwned", err)
+	}
+	if deleted != 0 {
+		t.Fatalf("deleted %d records of a partially owned set", deleted)
+	}
+}
+
+func TestBulkDeleteByIDSkipsActiveRecords(t *testing.T) {
+	db, mock := newMockDB(t)
+	userID := uuid.New()
+	id := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "workflow_executions"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectExec(`DELETE FROM "workflow_executions" WHERE user_id = \$1 AND id IN \(\$2\) AND status NOT IN \(\$3,\$4,\$5\)`).
+		WithArgs(userID, id, "pending", "queued", "running").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	deleted, err := bulkDelete(db, &models.WorkflowExecution{}, userID, []uuid.UUID{id}, BulkDeleteFilter{}, ExecutionDeletableStatuses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 0 {
+		t.Fatalf("deleted %d running executions", deleted)
+	}
+}
+
+func TestBulkDeleteByFilterKeepsActiveAndRetained(t *testing.T) {
+	db, mock := newMockDB(t)
+	userID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "workflow_executions" WHERE user_id = \$1 AND status NOT IN \(\$2,\$3,\$4\) AND status = \$5 AND pinned = \$6`).
+		WithArgs(userID, "pending", "queued", "running", "failed", false).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	service := &WorkflowService{db: db}
+	deleted, err := service.DeleteWorkflowExecutions(userID, nil, BulkDeleteFilter{Status: "failed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 3 {
+		t.Fatalf("deleted %d, want 3", deleted)
+	}
+}
+
+func TestBulkDeleteStatusValidation(t *testing.T) {
+	tests := []struct {
+		status    string
+		deletable []string
+		wantErr   bool
+	}{
+		{"running", ExecutionDeletableStatuses, true},
+		{"queued", ExecutionDeletableStatuses, true},
+		{"pending", ScanDeletableStatuses, true},
+		{"cancelled", ScanDeletableStatuses, true},
+		{"'; DROP TABLE scans; --", ScanDeletableStatuses, true},
+		{"Failed", ExecutionDeletableStatuses, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			db, _ := newMockDB(t) // No queries expected
+			_, err := bulkDelete(db, &models.ScanResult{}, uuid.New(), nil, BulkDeleteFilter{Status: tt.status}, tt.deletable)
+			if !errors.Is(err, ErrInvalidBulkStatus) {
+				t.Fatalf("got %v, want ErrInvalidBulkStatus", err)
+			}
+		})
+	}
+}
+
+func TestBulkDeleteRequiresIDsOrFilter(t *testing.T) {
+	db, _ := newMockDB(t)
+	if _, err := bulkDelete(db, &models.ScanResult{}, uuid.New(), nil, BulkDeleteFilter{}, ScanDeletableStatuses); !errors.Is(err, ErrEmptyBulkDelete) {
+		t.Fatalf("got %v, want ErrEmptyBulkDelete", err)
+	}
+}
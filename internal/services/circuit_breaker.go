@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling out when an upstream API has been failing
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"    // Calls go through
+	CircuitOpen     CircuitState = "open"      // Calls fail fast until the cooldown ends
+	CircuitHalfOpen CircuitState = "half-open" // One trial call decides whether to close again
+)
+
+// CircuitBreaker stops calling an upstream API after consecutive failures. Once the
+// cooldown has passed it lets a single trial request through: success closes the
+// breaker, failure opens it for another cooldown.
+type CircuitBreaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+	client    *http.Client
+	stream    *http.Client // client without its overall timeout, for responses read as they arrive
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	trial    bool // A half-open trial request is in flight
+}
+
+// CircuitBreakerStatus is a snapshot of one breaker
+type CircuitBreakerStatus struct {
+	Name     string       `json:"name"`
+	State    CircuitState `json:"state"`
+	Failures int          `json:"consecutive_failures"`
+	OpenedAt *time.Time   `json:"opened_at,omitempty"`
+}
+
+// NewAPIClient returns the HTTP client for calls to the AI and GitHub APIs. A call may take
+// at most timeout, body included, and connecting and the response headers are bounded
+// separately so a hung endpoint is noticed even by streams, which have no overall timeout.
+func NewAPIClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: timeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration, client *http.Client) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		client:    client,
+		stream:    &http.Client{Transport: client.Transport, CheckRedirect: client.CheckRedirect, Jar: client.Jar},
+		state:     CircuitClosed,
+	}
+}
+
+// Do sends req unless the breaker is open. Transport errors, timeouts, 5xx and 429 responses
+// count as failures; other responses mean the API is up, even if the call was rejected.
+func (b *CircuitBreaker) Do(req *http.Request) (*http.Response, error) {
+	return b.do(b.client, req)
+}
+
+// Stream is Do for a response read as it arrives, such as server-sent events, which may
+// outlast the client timeout. Only the wait for the response headers is bounded, so callers
+// should tie req to a context that ends when they stop reading.
+func (b *CircuitBreaker) Stream(req *http.Request) (*http.Response, error) {
+	return b.do(b.stream, req)
+}
+
+func (b *CircuitBreaker) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	switch {
+	case err != nil && req.Context().Err() != nil:
+		// Our caller gave up; that says nothing about the API
+		b.release()
+	case err != nil:
+		b.record(false)
+	default:
+		b.record(resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests)
+	}
+	return resp, err
+}
+
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen {
+		if remaining := b.cooldown - time.Since(b.openedAt); remaining > 0 {
+			return fmt.Errorf("%w: %s is unavailable, retrying in %s", ErrCircuitOpen, b.name, remaining.Round(time.Second))
+		}
+		b.state = CircuitHalfOpen
+	}
+	if b.state == CircuitHalfOpen {
+		if b.trial {
+			return fmt.Errorf("%w: %s is unavailable, a trial request is in progress", ErrCircuitOpen, b.name)
+		}
+		b.trial = true
+	}
+	return nil
+}
+
+// release ends a trial request without a verdict so the next call can try again
+func (b *CircuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+func (b *CircuitBreaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	if success {
+		if b.state != CircuitClosed {
+			log.Printf("✅ %s circuit closed", b.name)
+		}
+		b.state = CircuitClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		if b.state != CircuitOpen {
+			log.Printf("🔌 %s circuit opened after %d consecutive failures", b.name, b.failures)
+		}
+		b.state = CircuitOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// Status returns the breaker's current state
+func (b *CircuitBreaker) Status() CircuitBreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := CircuitBreakerStatus{Name: b.name, State: b.state, Failures: b.failures}
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.cooldown {
+		status.State = CircuitHalfOpen // The next call will be a trial
+	}
+	if b.state != CircuitClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+// CircuitBreakers holds one breaker per external API
+type CircuitBreakers struct {
+	Gemini *CircuitBreaker
+	Groq   *CircuitBreaker
+	Claude *CircuitBreaker
+	GitHub *CircuitBreaker
+}
+
+// NewCircuitBreakers creates the breakers, all sending their calls through client
+func NewCircuitBreakers(threshold int, cooldown time.Duration, client *http.Client) *CircuitBreakers {
+	return &CircuitBreakers{
+		Gemini: NewCircuitBreaker("Gemini API", threshold, cooldown, client),
+		Groq:   NewCircuitBreaker("Groq API", threshold, cooldown, client),
+		Claude: NewCircuitBreaker("Claude API", threshold, cooldown, client),
+		GitHub: NewCircuitBreaker("GitHub API", threshold, cooldown, client),
+	}
+}
+
+// Status returns the state of every breaker
+func (b *CircuitBreakers) Status() []CircuitBreakerStatus {
+	return []CircuitBreakerStatus{b.Gemini.Status(), b.Groq.Status(), b.Claude.Status(), b.GitHub.Status()}
+}
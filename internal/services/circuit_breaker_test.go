@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+)
+
+// flakyAPI answers every request with the status code currently set, counting the calls
+type flakyAPI struct {
+	*httptest.Server
+	status atomic.Int32
+	calls  atomic.Int32
+}
+
+func newFlakyAPI(t *testing.T, handler func(w http.ResponseWriter, r *http.Request)) *flakyAPI {
+	api := &flakyAPI{}
+	api.status.Store(http.StatusOK)
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.calls.Add(1)
+		if handler != nil {
+			handler(w, r)
+		}
+		w.WriteHeader(int(api.status.Load()))
+	}))
+	t.Cleanup(api.Close)
+	return api
+}
+
+// call sends one request through the breaker, returning the error the caller would see
+func (api *flakyAPI) call(t *testing.T, b *CircuitBreaker) error {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, api.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := b.Do(req)
+	if err == nil {
+		resp.Body.Close()
+	}
+	return err
+}
+
+// expireCooldown moves the time the breaker opened back past its cooldown
+func expireCooldown(b *CircuitBreaker) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.openedAt = time.Now().Add(-b.cooldown - time.Second)
+}
+
+func TestCircuitBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int // Responses before the breaker is checked
+		want     CircuitState
+	}{
+		{name: "all successes", statuses: []int{200, 200, 200}, want: CircuitClosed},
+		{name: "below the threshold", statuses: []int{500, 503}, want: CircuitClosed},
+		{name: "server errors", statuses: []int{500, 502, 503}, want: CircuitOpen},
+		{name: "rate limited", statuses: []int{429, 429, 429}, want: CircuitOpen},
+		{name: "client errors mean the API is up", statuses: []int{400, 401, 404, 422}, want: CircuitClosed},
+		{name: "success resets the count", statuses: []int{500, 500, 200, 500, 500}, want: CircuitClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newFlakyAPI(t, nil)
+			b := NewCircuitBreaker("Test API", 3, time.Minute, api.Client())
+			for _, status := range tt.statuses {
+				api.status.Store(int32(status))
+				if err := api.call(t, b); err != nil {
+					t.Fatalf("call with status %d failed: %v", status, err)
+				}
+			}
+			if got := b.Status().State; got != tt.want {
+				t.Fatalf("state = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerFailsFastWhileOpen(t *testing.T) {
+	api := newFlakyAPI(t, nil)
+	api.status.Store(http.StatusInternalServerError)
+	b := NewCircuitBreaker("Test API", 2, time.Minute, api.Client())
+	api.call(t, b)
+	api.call(t, b)
+
+	err := api.call(t, b)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("call on an open breaker = %v, want ErrCircuitOpen", err)
+	}
+	if calls := api.calls.Load(); calls != 2 {
+		t.Errorf("API called %d times, want 2; an open breaker must not call out", calls)
+	}
+	status := b.Status()
+	if status.Failures != 2 || status.OpenedAt == nil {
+		t.Errorf("status = %+v, want 2 failures and an opened_at time", status)
+	}
+}
+
+func TestCircuitBreakerRecovers(t *testing.T) {
+	api := newFlakyAPI(t, nil)
+	api.status.Store(http.StatusServiceUnavailable)
+	b := NewCircuitBreaker("Test API", 1, time.Minute, api.Client())
+	api.call(t, b)
+	if got := b.Status().State; got != CircuitOpen {
+		t.Fatalf("state = %s, want open", got)
+	}
+
+	// A failed trial reopens the breaker for another cooldown
+	expireCooldown(b)
+	if got := b.Status().State; got != CircuitHalfOpen {
+		t.Fatalf("state after the cooldown = %s, want half-open", got)
+	}
+	if err := api.call(t, b); err != nil {
+		t.Fatalf("trial call = %v, want it sent", err)
+	}
+	if got := b.Status().State; got != CircuitOpen {
+		t.Fatalf("state after a failed trial = %s, want open", got)
+	}
+	if err := api.call(t, b); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("call after a failed trial = %v, want ErrCircuitOpen", err)
+	}
+
+	// A successful trial closes it
+	expireCooldown(b)
+	api.status.Store(http.StatusOK)
+	if err := api.call(t, b); err != nil {
+		t.Fatalf("trial call = %v, want it sent", err)
+	}
+	status := b.Status()
+	if status.State != CircuitClosed || status.Failures != 0 || status.OpenedAt != nil {
+		t.Fatalf("status after a successful trial = %+v, want closed with no failures", status)
+	}
+	if calls := api.calls.Load(); calls != 3 {
+		t.Errorf("API called %d times, want 3", calls)
+	}
+}
+
+func TestCircuitBreakerSendsOneTrialAtATime(t *testing.T) {
+	arrived := make(chan struct{})
+	finish := make(chan struct{})
+	api := newFlakyAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("hold") != "" {
+			close(arrived)
+			<-finish
+		}
+	})
+	b := NewCircuitBreaker("Test API", 1, time.Minute, api.Client())
+	api.status.Store(http.StatusInternalServerError)
+	api.call(t, b)
+	api.status.Store(http.StatusOK)
+	expireCooldown(b)
+
+	trial := make(chan error, 1)
+	go func() {
+		req, _ := http.NewRequest(http.MethodGet, api.URL+"?hold=1", nil)
+		resp, err := b.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		trial <- err
+	}()
+	<-arrived
+
+	if err := api.call(t, b); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("call during the trial = %v, want ErrCircuitOpen", err)
+	}
+	close(finish)
+	if err := <-trial; err != nil {
+		t.Fatalf("trial call = %v", err)
+	}
+	if err := api.call(t, b); err != nil {
+		t.Fatalf("call after a successful trial = %v, want it sent", err)
+	}
+}
+
+func TestCircuitBreakerIgnoresCallerCancellation(t *testing.T) {
+	api := newFlakyAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	b := NewCircuitBreaker("Test API", 1, time.Minute, api.Client())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, api.URL, nil)
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, err := b.Do(req); err == nil {
+		t.Fatal("cancelled call succeeded")
+	}
+	if status := b.Status(); status.State != CircuitClosed || status.Failures != 0 {
+		t.Fatalf("status after a cancelled call = %+v, want closed with no failures", status)
+	}
+}
+
+// hungAPI never answers, holding each request until the caller gives up on it
+func hungAPI(t *testing.T) *flakyAPI {
+	return newFlakyAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		// Only once the body is read does the server notice the caller hanging up
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	})
+}
+
+func TestAPIClientTimesOutHungServer(t *testing.T) {
+	api := hungAPI(t)
+	b := NewCircuitBreaker("Test API", 5, time.Minute, NewAPIClient(100*time.Millisecond))
+
+	for name, send := range map[string]func(*http.Request) (*http.Response, error){"do": b.Do, "stream": b.Stream} {
+		t.Run(name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, api.URL, nil)
+			started := time.Now()
+			_, err := send(req)
+			var netErr net.Error
+			if !errors.As(err, &netErr) || !netErr.Timeout() {
+				t.Fatalf("call to a hung server returned %v, want a timeout", err)
+			}
+			if elapsed := time.Since(started); elapsed > 2*time.Second {
+				t.Fatalf("call took %s with a 100ms timeout", elapsed)
+			}
+		})
+	}
+}
+
+func TestAPIClientStreamOutlastsTimeout(t *testing.T) {
+	api := newFlakyAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(300 * time.Millisecond)
+		w.Write([]byte("data: done\n\n"))
+	})
+	b := NewCircuitBreaker("Test API", 5, time.Minute, NewAPIClient(100*time.Millisecond))
+
+	req, _ := http.NewRequest(http.MethodGet, api.URL, nil)
+	resp, err := b.Stream(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	// The headers came in time, so the body may take longer than the client timeout
+	if body, err := io.ReadAll(resp.Body); err != nil || string(body) != "data: done\n\n" {
+		t.Fatalf("stream read %q, %v", body, err)
+	}
+}
+
+func TestCallerDeadlineBeatsClientTimeout(t *testing.T) {
+	api := hungAPI(t)
+	b := NewCircuitBreaker("Test API", 5, time.Minute, NewAPIClient(time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, api.URL, nil)
+	started := time.Now()
+	if _, err := b.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("call returned %v, want the caller's deadline", err)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Fatalf("call took %s past the caller's 50ms deadline", elapsed)
+	}
+}
+
+func TestServicesTimeOutHungAPIs(t *testing.T) {
+	api := hungAPI(t)
+	target, _ := url.Parse(api.URL)
+	client := NewAPIClient(100 * time.Millisecond)
+	transport := client.Transport
+	client.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+		return transport.RoundTrip(req)
+	})
+	breakers := NewCircuitBreakers(5, time.Minute, client)
+
+	calls := map[string]func() error{
+		"github": func() error {
+			_, err := NewGitHubService(nil, breakers, config.GitHubConfig{}).GetFileContent(context.Background(), "gho_token", "acme", "api", "main.go")
+			return err
+		},
+		"ai": func() error {
+			ai := NewAIService(&config.Config{AI: config.AIConfig{GroqAPIKey: "groq-key", ProviderOrder: []string{ProviderGroq}, FastProviderOrder: []string{ProviderGroq}}}, breakers)
+			_, err := ai.ChatResponse(context.Background(), "hi", nil)
+			return err
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			started := time.Now()
+			err := call()
+			var netErr net.Error
+			if !errors.As(err, &netErr) || !netErr.Timeout() {
+				t.Fatalf("call to a hung API returned %v, want a timeout", err)
+			}
+			if elapsed := time.Since(started); elapsed > 2*time.Second {
+				t.Fatalf("call took %s with a 100ms timeout", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// completionWebhookChannel is the notification queue channel failed completion webhooks are retried on
+	completionWebhookChannel = "completion-webhook"
+
+	completionWebhookEvent   = "execution.finished"
+	completionWebhookTimeout = 30 * time.Second
+
+	// CompletionSignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the request body
+	CompletionSignatureHeader = "X-VulnPilot-Signature"
+)
+
+// ErrInvalidWebhook is returned for a workflow webhook URL that isn't http or https
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// terminalStatuses are the execution statuses a run can finish in
+var terminalStatuses = map[string]bool{
+	"completed": true,
+	"failed":    true,
+	"timed_out": true,
+	"cancelled": true,
+}
+
+// CompletionPayload is the summary POSTed to a workflow's webhook when an execution finishes
+type CompletionPayload struct {
+	Event        string           `json:"event"`
+	ExecutionID  uuid.UUID        `json:"executionId"`
+	WorkflowID   uuid.UUID        `json:"workflowId"`
+	WorkflowName string           `json:"workflowName"`
+	Status       string           `json:"status"`
+	Error        string           `json:"error,omitempty"`
+	TargetHost   string           `json:"targetHost,omitempty"`
+	StartedAt    *time.Time       `json:"startedAt,omitempty"`
+	CompletedAt  *time.Time       `json:"completedAt,omitempty"`
+	Duration     int64            `json:"duration"` // Milliseconds
+	Findings     int              `json:"findings"`
+	Severities   map[Severity]int `json:"severities"`
+}
+
+// CompletionWebhooks notifies a workflow's webhook, if it has one, whenever one of its
+// executions reaches a terminal status. Deliveries are signed with the workflow's stored
+// secret; failed ones are handed to the notification queue, which retries them and
+// eventually dead-letters them.
+type CompletionWebhooks struct {
+	db      *gorm.DB
+	secrets *SecretStore
+	queue   *NotificationQueue
+	client  *http.Client // Webhook URLs are user-supplied, so restricted to public addresses
+}
+
+func NewCompletionWebhooks(db *gorm.DB, secrets *SecretStore, queue *NotificationQueue) *CompletionWebhooks {
+	w := &CompletionWebhooks{
+		db:      db,
+		secrets: secrets,
+		queue:   queue,
+		client:  utils.NewSafeHTTPClient(completionWebhookTimeout),
+	}
+	queue.RegisterSender(completionWebhookChannel, w.deliver)
+	return w
+}
+
+// Notify sends the finished execution's summary to its workflow's webhook, queueing it on failure
+func (w *CompletionWebhooks) Notify(executionID uuid.UUID) {
+	if w == nil {
+		return
+	}
+
+	var execution models.WorkflowExecution
+	if err := w.db.First(&execution, "id = ?", executionID).Error; err != nil {
+		log.Printf("⚠️ Failed to load execution %s for completion webhook: %v", executionID, err)
+		return
+	}
+	if !terminalStatuses[execution.Status] {
+		return
+	}
+	var workflow models.Workflow
+	if err := w.db.Select("id", "name", "webhook_url").First(&workflow, "id = ?", execution.WorkflowID).Error; err != nil || workflow.WebhookURL == "" {
+		return
+	}
+
+	payload, err := json.Marshal(completionPayload(&execution, &workflow))
+	if err != nil {
+		log.Printf("⚠️ Failed to encode completion webhook for execution %s: %v", executionID, err)
+		return
+	}
+	if err := w.post(execution.UserID, workflow.ID, payload); err != nil {
+		log.Printf("⚠️ Completion webhook for execution %s failed, queueing for retry: %v", executionID, err)
+		if _, qErr := w.queue.Enqueue(execution.UserID, completionWebhookChannel, workflow.ID.String(), completionWebhookEvent, string(payload), nil, err); qErr != nil {
+			log.Printf("⚠️ %v", qErr)
+		}
+	}
+}
+
+func completionPayload(execution *models.WorkflowExecution, workflow *models.Workflow) CompletionPayload {
+	findings := extractFindings(execution.Results)
+	payload := CompletionPayload{
+		Event:        completionWebhookEvent,
+		ExecutionID:  execution.ID,
+		WorkflowID:   workflow.ID,
+		WorkflowName: workflow.Name,
+		Status:       execution.Status,
+		Error:        execution.Error,
+		TargetHost:   execution.TargetHost,
+		StartedAt:    execution.StartedAt,
+		CompletedAt:  execution.CompletedAt,
+		Findings:     len(findings),
+		Severities:   countBySeverity(findings),
+	}
+	if execution.StartedAt != nil && execution.CompletedAt != nil {
+		payload.Duration = execution.CompletedAt.Sub(*execution.StartedAt).Milliseconds()
+	}
+	return payload
+}
+
+// deliver retries a queued webhook; the delivery's recipient holds the workflow ID
+func (w *CompletionWebhooks) deliver(delivery *models.NotificationDelivery) error {
+	workflowID, err := uuid.Parse(delivery.Recipient)
+	if err != nil {
+		return fmt.Errorf("invalid workflow ID for completion webhook: %s", delivery.Recipient)
+	}
+	return w.post(delivery.UserID, workflowID, []byte(delivery.Body))
+}
+
+// post sends payload to the workflow's current webhook URL, looking the URL and secret
+// up on every attempt so a retry picks up any change to them
+func (w *CompletionWebhooks) post(userID, workflowID uuid.UUID, payload []byte) error {
+	var workflow models.Workflow
+	if err := w.db.Select("id", "webhook_url", "webhook_secret").First(&workflow, "id = ? AND user_id = ?", workflowID, userID).Error; err != nil {
+		return err
+	}
+	if workflow.WebhookURL == "" {
+		return fmt.Errorf("workflow %s no longer has a webhook configured", workflowID)
+	}
+
+	req, err := http.NewRequest("POST", workflow.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-VulnPilot-Event", completionWebhookEvent)
+	if workflow.WebhookSecret != "" {
+		secret, err := w.secrets.Resolve(userID, workflow.WebhookSecret)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(CompletionSignatureHeader, SignCompletionPayload(secret, payload))
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// SignCompletionPayload returns the signature header value for payload signed with secret
+func SignCompletionPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
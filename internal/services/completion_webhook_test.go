@@ -0,0 +1,162 @@
+package services
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/google/uuid"
+)
+
+func TestSignCompletionPayload(t *testing.T) {
+	// HMAC-SHA256 test vector from the Wikipedia HMAC article
+	got := SignCompletionPayload("key", []byte("The quick brown fox jumps over the lazy dog"))
+	want := "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"
+	if got != want {
+		t.Fatalf("SignCompletionPayload() = %s, want %s", got, want)
+	}
+	if other := SignCompletionPayload("other key", []byte("The quick brown fox jumps over the lazy dog")); other == want {
+		t.Error("a different secret gave the same signature")
+	}
+}
+
+func TestCompletionPayloadShape(t *testing.T) {
+	started := time.Date(2026, 4, 2, 12, 0, 0, 0, time.UTC)
+	completed := started.Add(90*time.Second + 250*time.Millisecond)
+	execution := &models.WorkflowExecution{
+		ID:          uuid.MustParse("0b9a1c2d-3e4f-4a5b-8c6d-7e8f9a0b1c2d"),
+		WorkflowID:  uuid.MustParse("1c2d3e4f-5a6b-4c7d-8e9f-0a1b2c3d4e5f"),
+		Status:      "failed",
+		Error:       "node ports timed out after 5m0s",
+		TargetHost:  "shop.example.com",
+		StartedAt:   &started,
+		CompletedAt: &completed,
+		Results: models.JSONMap{
+			"ports": withFindings(map[string]interface{}{"scanner": "nmap", "output": "22/tcp open ssh\n80/tcp open http"}),
+		},
+	}
+	workflow := &models.Workflow{ID: execution.WorkflowID, Name: "Nightly perimeter"}
+
+	data, err := json.Marshal(completionPayload(execution, workflow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"event":        "execution.finished",
+		"executionId":  "0b9a1c2d-3e4f-4a5b-8c6d-7e8f9a0b1c2d",
+		"workflowId":   "1c2d3e4f-5a6b-4c7d-8e9f-0a1b2c3d4e5f",
+		"workflowName": "Nightly perimeter",
+		"status":       "failed",
+		"error":        "node ports timed out after 5m0s",
+		"targetHost":   "shop.example.com",
+		"startedAt":    "2026-04-02T12:00:00Z",
+		"completedAt":  "2026-04-02T12:01:30.25Z",
+		"duration":     float64(90250),
+		"findings":     float64(2),
+		"severities":   map[string]interface{}{"critical": float64(0), "high": float64(0), "medium": float64(0), "low": float64(0), "info": float64(2)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		wantJSON, _ := json.Marshal(want)
+		t.Fatalf("payload = %s\nwant %s", data, wantJSON)
+	}
+
+	// A run that never started reports a zero duration, and no error when it has none
+	execution.StartedAt, execution.Error, execution.Status = nil, "", "cancelled"
+	data, _ = json.Marshal(completionPayload(execution, workflow))
+	got = nil
+	json.Unmarshal(data, &got)
+	if _, ok := got["error"]; ok {
+		t.Errorf("payload without an error has an error field: %s", data)
+	}
+	if got["duration"] != float64(0) {
+		t.Errorf("duration = %v without a start time, want 0", got["duration"])
+	}
+}
+
+func TestCompletionWebhookPost(t *testing.T) {
+	const secretValue = "whsec_0123456789"
+	keys, err := utils.NewKeyRing(map[int]string{1: "test key"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, version, err := keys.Encrypt(secretValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte(`{"event":"execution.finished","status":"completed"}`)
+
+	tests := []struct {
+		name          string
+		webhookSecret string
+		status        int
+		wantSigned    bool
+		wantErr       bool
+	}{
+		{name: "signed", webhookSecret: "ci-webhook", status: http.StatusNoContent, wantSigned: true},
+		{name: "unsigned without a secret", status: http.StatusOK},
+		{name: "receiver error", webhookSecret: "ci-webhook", status: http.StatusBadGateway, wantSigned: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header http.Header
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header.Clone()
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			t.Cleanup(server.Close)
+
+			db, mock := newMockDB(t)
+			userID, workflowID := uuid.New(), uuid.New()
+			mock.ExpectQuery(`SELECT "id","webhook_url","webhook_secret" FROM "workflows" WHERE id = \$1 AND user_id = \$2`).
+				WithArgs(workflowID, userID, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "webhook_url", "webhook_secret"}).AddRow(workflowID, server.URL+"/hooks/vulnpilot", tt.webhookSecret))
+			if tt.webhookSecret != "" {
+				mock.ExpectQuery(`SELECT \* FROM "secrets" WHERE user_id = \$1 AND name = \$2`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "ciphertext", "key_version"}).
+						AddRow(uuid.New(), userID, tt.webhookSecret, ciphertext, version))
+			}
+
+			// The test receiver listens on loopback, which the production client refuses
+			w := &CompletionWebhooks{db: db, secrets: NewSecretStore(db, keys), client: server.Client()}
+			err := w.post(userID, workflowID, payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("post() = %v, want error %v", err, tt.wantErr)
+			}
+
+			if string(body) != string(payload) {
+				t.Errorf("body = %s, want %s", body, payload)
+			}
+			if got := header.Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q", got)
+			}
+			if got := header.Get("X-VulnPilot-Event"); got != "execution.finished" {
+				t.Errorf("X-VulnPilot-Event = %q", got)
+			}
+			signature := header.Get(CompletionSignatureHeader)
+			if !tt.wantSigned {
+				if signature != "" {
+					t.Errorf("unsigned delivery has signature %q", signature)
+				}
+				return
+			}
+			// Receivers verify by recomputing the HMAC over the raw body with their copy of the secret
+			if want := SignCompletionPayload(secretValue, body); !hmac.Equal([]byte(signature), []byte(want)) {
+				t.Errorf("signature = %q, want %q", signature, want)
+			}
+		})
+	}
+}
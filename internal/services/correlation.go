@@ -0,0 +1,147 @@
+package services
+
+import (
+	"sort"
+	"strings"
+)
+
+// CorrelatedFinding is one issue reported by one or more scanners. Its Finding fields are
+// those of the most severe report, except that Line and EndLine span all of them; Sources
+// and Keys list every scanner and finding merged in.
+type CorrelatedFinding struct {
+	Finding
+	Category string   `json:"category,omitempty"`
+	Sources  []string `json:"sources"`
+	Keys     []string `json:"keys"`
+}
+
+// scannerCategories are scanners whose every finding is of one class
+var scannerCategories = map[string]string{
+	"gitleaks":    "secret",
+	"sqlmap":      "injection",
+	"trivy-sca":   "dependency",
+	"trivy-image": "dependency",
+}
+
+// categoryKeywords classify other findings by their rule and title, checked in order so the
+// more specific classes win (a "hardcoded-sql-password" rule is about a secret, not SQL)
+var categoryKeywords = []struct {
+	category string
+	keywords []string
+}{
+	{"secret", []string{"secret", "credential", "password", "passwd", "api-key", "apikey", "token", "private-key", "hardcoded"}},
+	{"xss", []string{"xss", "cross-site-scripting"}},
+	{"injection", []string{"sqli", "sql-injection", "injection", "command", "exec", "ssti"}},
+	{"path-traversal", []string{"traversal", "path-injection"}},
+	{"deserialization", []string{"deserializ", "pickle", "unsafe-yaml"}},
+	{"crypto", []string{"crypto", "md5", "sha1", "cipher", "insecure-random", "weak-hash"}},
+}
+
+// findingCategory returns the vulnerability class of a finding, or "" when it can't be classified
+func findingCategory(f Finding) string {
+	if category, ok := scannerCategories[f.Scanner]; ok {
+		return category
+	}
+	text := strings.ToLower(f.RuleID + " " + f.Title)
+	for _, c := range categoryKeywords {
+		for _, keyword := range c.keywords {
+			if strings.Contains(text, keyword) {
+				return c.category
+			}
+		}
+	}
+	return ""
+}
+
+// correlates reports whether f refers to the same issue as the group: the same file, a
+// related class, lines that overlap, and a scanner not already in the group. Findings
+// without lines only match ones without lines that have the same rule, e.g. one CVE
+// reported for a manifest by two scanners.
+func (c *CorrelatedFinding) correlates(f Finding, category string) bool {
+	if f.Path == "" || f.Path != c.Path || category == "" || category != c.Category {
+		return false
+	}
+	for _, source := range c.Sources {
+		if source == f.Scanner {
+			return false
+		}
+	}
+	if f.Line == 0 || c.Line == 0 {
+		return f.Line == c.Line && f.RuleID != "" && f.RuleID == c.RuleID
+	}
+	return f.Line <= c.EndLine && findingEndLine(f) >= c.Line
+}
+
+// merge adds f to the group, taking its fields when it is more severe
+func (c *CorrelatedFinding) merge(f Finding) {
+	c.Sources = append(c.Sources, f.Scanner)
+	c.Keys = append(c.Keys, f.Key)
+	start, end := c.Line, c.EndLine
+	if f.Line > 0 && f.Line < start {
+		start = f.Line
+	}
+	if findingEndLine(f) > end {
+		end = findingEndLine(f)
+	}
+	if f.Severity.Rank() > c.Severity.Rank() {
+		c.Finding = f
+	}
+	c.Line, c.EndLine = start, end
+}
+
+func findingEndLine(f Finding) int {
+	if f.EndLine > f.Line {
+		return f.EndLine
+	}
+	return f.Line
+}
+
+// correlateFindings groups findings that different scanners report for the same issue, so
+// e.g. a hardcoded password flagged by both gitleaks and semgrep is reported once. Findings
+// that match nothing are returned as groups of one.
+func correlateFindings(findings []Finding) []CorrelatedFinding {
+	sorted := append([]Finding(nil), findings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		if sorted[i].Line != sorted[j].Line {
+			return sorted[i].Line < sorted[j].Line
+		}
+		return sorted[i].Key < sorted[j].Key
+	})
+
+	groups := make([]CorrelatedFinding, 0, len(sorted))
+	for _, f := range sorted {
+		category := findingCategory(f)
+		merged := false
+		for i := range groups {
+			if groups[i].correlates(f, category) {
+				groups[i].merge(f)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			f.EndLine = findingEndLine(f)
+			groups = append(groups, CorrelatedFinding{
+				Finding:  f,
+				Category: category,
+				Sources:  []string{f.Scanner},
+				Keys:     []string{f.Key},
+			})
+		}
+	}
+	return groups
+}
+
+// crossScannerFindings returns only the groups more than one scanner contributed to
+func crossScannerFindings(results map[string]interface{}) []CorrelatedFinding {
+	var correlated []CorrelatedFinding
+	for _, group := range correlateFindings(extractFindings(results)) {
+		if len(group.Sources) > 1 {
+			correlated = append(correlated, group)
+		}
+	}
+	return correlated
+}
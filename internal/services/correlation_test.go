@@ -0,0 +1,136 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindingCategory(t *testing.T) {
+	tests := []struct {
+		finding Finding
+		want    string
+	}{
+		{Finding{Scanner: "gitleaks", RuleID: "generic-api-key"}, "secret"},
+		{Finding{Scanner: "trivy-sca", RuleID: "CVE-2021-44228"}, "dependency"},
+		{Finding{Scanner: "semgrep", RuleID: "python.lang.security.audit.formatted-sql-query.sql-injection"}, "injection"},
+		{Finding{Scanner: "semgrep", RuleID: "go.lang.security.audit.hardcoded-sql-password"}, "secret"},
+		{Finding{Scanner: "semgrep", RuleID: "javascript.browser.security.xss.innerhtml"}, "xss"},
+		{Finding{Scanner: "semgrep", RuleID: "python.lang.security.insecure-hash-algorithms.md5"}, "crypto"},
+		{Finding{Scanner: "semgrep", RuleID: "generic.todo", Title: "TODO left in code"}, ""},
+	}
+	for _, tt := range tests {
+		if got := findingCategory(tt.finding); got != tt.want {
+			t.Errorf("findingCategory(%s %s) = %q, want %q", tt.finding.Scanner, tt.finding.RuleID, got, tt.want)
+		}
+	}
+}
+
+// hardcodedPassword is the same leaked password as reported by gitleaks and semgrep
+var hardcodedPassword = []Finding{
+	{Key: "gitleaks: generic-password in config/db.py", Scanner: "gitleaks", RuleID: "generic-password", Severity: SeverityHigh, Path: "config/db.py", Line: 12},
+	{Key: "semgrep: hardcoded-password in config/db.py", Scanner: "semgrep", RuleID: "python.django.security.audit.hardcoded-password", Severity: SeverityMedium, Path: "config/db.py", Line: 11, EndLine: 13},
+}
+
+func TestCorrelateFindings(t *testing.T) {
+	tests := []struct {
+		name     string
+		findings []Finding
+		want     [][]string // Keys of each group
+	}{
+		{
+			name:     "two scanners flagging the same line",
+			findings: hardcodedPassword,
+			want:     [][]string{{"semgrep: hardcoded-password in config/db.py", "gitleaks: generic-password in config/db.py"}},
+		},
+		{
+			name: "different files",
+			findings: []Finding{
+				hardcodedPassword[0],
+				{Key: "semgrep: hardcoded-password in config/cache.py", Scanner: "semgrep", RuleID: "hardcoded-password", Path: "config/cache.py", Line: 12},
+			},
+			want: [][]string{{"semgrep: hardcoded-password in config/cache.py"}, {"gitleaks: generic-password in config/db.py"}},
+		},
+		{
+			name: "lines that don't overlap",
+			findings: []Finding{
+				hardcodedPassword[0],
+				{Key: "semgrep: hardcoded-password in config/db.py", Scanner: "semgrep", RuleID: "hardcoded-password", Path: "config/db.py", Line: 40},
+			},
+			want: [][]string{{"gitleaks: generic-password in config/db.py"}, {"semgrep: hardcoded-password in config/db.py"}},
+		},
+		{
+			name: "unrelated classes on the same line",
+			findings: []Finding{
+				hardcodedPassword[0],
+				{Key: "semgrep: sql-injection in config/db.py", Scanner: "semgrep", RuleID: "sql-injection", Path: "config/db.py", Line: 12},
+			},
+			want: [][]string{{"gitleaks: generic-password in config/db.py"}, {"semgrep: sql-injection in config/db.py"}},
+		},
+		{
+			name: "one scanner twice",
+			findings: []Finding{
+				hardcodedPassword[0],
+				{Key: "gitleaks: aws-access-key in config/db.py", Scanner: "gitleaks", RuleID: "aws-access-key", Path: "config/db.py", Line: 12},
+			},
+			want: [][]string{{"gitleaks: aws-access-key in config/db.py"}, {"gitleaks: generic-password in config/db.py"}},
+		},
+		{
+			name: "one CVE in a manifest, without lines",
+			findings: []Finding{
+				{Key: "trivy-sca: CVE-2021-44228 in log4j-core", Scanner: "trivy-sca", RuleID: "CVE-2021-44228", Path: "log4j-core"},
+				{Key: "trivy-image: CVE-2021-44228 in log4j-core", Scanner: "trivy-image", RuleID: "CVE-2021-44228", Path: "log4j-core"},
+				{Key: "trivy-image: CVE-2022-0001 in log4j-core", Scanner: "trivy-image", RuleID: "CVE-2022-0001", Path: "log4j-core"},
+			},
+			want: [][]string{
+				{"trivy-image: CVE-2021-44228 in log4j-core", "trivy-sca: CVE-2021-44228 in log4j-core"},
+				{"trivy-image: CVE-2022-0001 in log4j-core"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]string
+			for _, group := range correlateFindings(tt.findings) {
+				got = append(got, group.Keys)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("groups = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCorrelatedFindingTakesMostSevereReport(t *testing.T) {
+	groups := correlateFindings(hardcodedPassword)
+	if len(groups) != 1 {
+		t.Fatalf("got %d groups, want 1", len(groups))
+	}
+	group := groups[0]
+	if group.Scanner != "gitleaks" || group.Severity != SeverityHigh || group.Category != "secret" {
+		t.Errorf("group reported as %s %s %s, want the high gitleaks secret", group.Scanner, group.Severity, group.Category)
+	}
+	if group.Line != 11 || group.EndLine != 13 {
+		t.Errorf("group spans lines %d-%d, want 11-13", group.Line, group.EndLine)
+	}
+	if !reflect.DeepEqual(group.Sources, []string{"semgrep", "gitleaks"}) {
+		t.Errorf("sources = %q", group.Sources)
+	}
+}
+
+func TestCrossScannerFindingsFromNodeResults(t *testing.T) {
+	results := map[string]interface{}{
+		"secrets": withFindings(map[string]interface{}{"scanner": "gitleaks", "output": `{"findings":[
+			{"rule":"generic-password","file":"config/db.py","startLine":12,"endLine":12},
+			{"rule":"aws-access-key","file":"deploy.sh","startLine":3,"endLine":3}]}`}),
+		"code": withFindings(map[string]interface{}{"scanner": "semgrep", "output": `{"results":[
+			{"check_id":"python.django.security.audit.hardcoded-password","path":"config/db.py","start":{"line":12},"end":{"line":12},"extra":{"severity":"WARNING"}},
+			{"check_id":"python.lang.security.audit.eval-injection","path":"app.py","start":{"line":8},"end":{"line":8},"extra":{"severity":"ERROR"}}]}`}),
+	}
+	correlated := crossScannerFindings(results)
+	if len(correlated) != 1 {
+		t.Fatalf("got %d correlated findings, want the one password both scanners found: %+v", len(correlated), correlated)
+	}
+	if got := correlated[0]; got.Path != "config/db.py" || got.Line != 12 || len(got.Sources) != 2 {
+		t.Fatalf("correlated %+v", got)
+	}
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// dockerAPIVersion is the Engine API version requests are made against; Docker 20.10 and later support it
+const dockerAPIVersion = "v1.41"
+
+// ContainerSpec is a one-off container that runs a tool and is removed afterwards
+type ContainerSpec struct {
+	Image      string
+	Entrypoint []string
+	Cmd        []string
+	WorkingDir string
+	Binds      []string // host:container:mode
+	Memory     int64    // Bytes
+	NanoCPUs   int64
+	Network    string // Docker network name, or none
+	Stdout     bool   // Return only stdout rather than stdout and stderr interleaved
+	Env        []string
+}
+
+// DockerClient is the subset of the Docker Engine API the scanner sandbox uses
+type DockerClient interface {
+	Ping(ctx context.Context) error
+	// Run creates and starts a container, waits for it to exit and removes it, returning
+	// its output and a ContainerExitError when it exits non-zero
+	Run(ctx context.Context, spec ContainerSpec) ([]byte, error)
+}
+
+// ContainerExitError reports a sandboxed tool that exited non-zero
+type ContainerExitError struct {
+	Code int
+}
+
+func (e *ContainerExitError) Error() string { return fmt.Sprintf("exit status %d", e.Code) }
+
+// ExitCode matches exec.ExitError so callers can treat host and sandbox failures alike
+func (e *ContainerExitError) ExitCode() int { return e.Code }
+
+// dockerHTTPClient talks to the Docker Engine API over its unix socket or TCP
+type dockerHTTPClient struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewDockerClient returns a client for host, e.g. unix:///var/run/docker.sock or tcp://127.0.0.1:2375
+func NewDockerClient(host string) (DockerClient, error) {
+	parsed, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &http.Transport{}
+	baseURL := "http://docker/" + dockerAPIVersion
+	switch parsed.Scheme {
+	case "unix":
+		socket := parsed.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+	case "tcp":
+		baseURL = "http://" + parsed.Host + "/" + dockerAPIVersion
+	default:
+		return nil, fmt.Errorf("unsupported Docker host scheme %q", parsed.Scheme)
+	}
+
+	// No client timeout: Run waits for the container, bounded by the scan's context instead
+	return &dockerHTTPClient{client: &http.Client{Transport: transport}, baseURL: baseURL}, nil
+}
+
+func (d *dockerHTTPClient) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	_, err := d.call(ctx, "GET", "/_ping", nil, http.StatusOK)
+	return err
+}
+
+func (d *dockerHTTPClient) Run(ctx context.Context, spec ContainerSpec) ([]byte, error) {
+	id, err := d.create(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	// Removed even when the scan was cancelled, so use a fresh context
+	defer func() {
+		cleanup, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		d.call(cleanup, "DELETE", "/containers/"+id+"?force=true", nil, http.StatusNoContent)
+	}()
+
+	if _, err := d.call(ctx, "POST", "/containers/"+id+"/start", nil, http.StatusNoContent); err != nil {
+		return nil, err
+	}
+
+	body, err := d.call(ctx, "POST", "/containers/"+id+"/wait", nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	var wait struct {
+		StatusCode int `json:"StatusCode"`
+	}
+	if err := json.Unmarshal(body, &wait); err != nil {
+		return nil, fmt.Errorf("failed to parse container wait response: %v", err)
+	}
+
+	query := "stdout=1&stderr=1"
+	if spec.Stdout {
+		query = "stdout=1"
+	}
+	logs, err := d.call(ctx, "GET", "/containers/"+id+"/logs?"+query, nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	output := demuxDockerLogs(logs)
+
+	if wait.StatusCode != 0 {
+		return output, &ContainerExitError{Code: wait.StatusCode}
+	}
+	return output, nil
+}
+
+// create creates the container, pulling its image first if the daemon doesn't have it
+func (d *dockerHTTPClient) create(ctx context.Context, spec ContainerSpec) (string, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"Image":        spec.Image,
+		"Entrypoint":   spec.Entrypoint,
+		"Cmd":          spec.Cmd,
+		"WorkingDir":   spec.WorkingDir,
+		"Env":          spec.Env,
+		"AttachStdout": true,
+		"AttachStderr": true,
+		"HostConfig": map[string]interface{}{
+			"Binds":       spec.Binds,
+			"Memory":      spec.Memory,
+			"MemorySwap":  spec.Memory, // No swap on top of the memory limit
+			"NanoCpus":    spec.NanoCPUs,
+			"PidsLimit":   256,
+			"NetworkMode": spec.Network,
+			"CapDrop":     []string{"ALL"},
+			"CapAdd":      []string{"NET_RAW"}, // nmap -sV needs raw sockets
+			"SecurityOpt": []string{"no-new-privileges"},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	body, err := d.call(ctx, "POST", "/containers/create", payload, http.StatusCreated)
+	if isDockerNotFound(err) {
+		repository, tag := splitImageRef(spec.Image)
+		if _, err := d.call(ctx, "POST", "/images/create?fromImage="+url.QueryEscape(repository)+"&tag="+url.QueryEscape(tag), nil, http.StatusOK); err != nil {
+			return "", fmt.Errorf("failed to pull %s: %v", spec.Image, err)
+		}
+		body, err = d.call(ctx, "POST", "/containers/create", payload, http.StatusCreated)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil || created.ID == "" {
+		return "", fmt.Errorf("failed to parse container create response: %s", string(body))
+	}
+	return created.ID, nil
+}
+
+// splitImageRef splits an image reference into the repository and the tag or digest to pull.
+// An untagged image gets "latest"; without a tag the API pulls every tag of the repository.
+func splitImageRef(image string) (string, string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[:i], image[i+1:]
+	}
+	// A colon before the last slash is a registry port, not a tag
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
+
+// dockerError is a Docker API response with an unexpected status
+type dockerError struct {
+	Status  int
+	Message string
+}
+
+func (e *dockerError) Error() string {
+	return fmt.Sprintf("docker API returned %d: %s", e.Status, e.Message)
+}
+
+func isDockerNotFound(err error) bool {
+	apiErr, ok := err.(*dockerError)
+	return ok && apiErr.Status == http.StatusNotFound
+}
+
+// call makes an API request, returning the body when the response has the wanted status
+func (d *dockerHTTPClient) call(ctx context.Context, method, path string, payload []byte, want int) ([]byte, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, d.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Image pulls stream progress until they finish, so the body is always read in full
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != want {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(body))
+		}
+		return nil, &dockerError{Status: resp.StatusCode, Message: apiErr.Message}
+	}
+	return body, nil
+}
+
+// demuxDockerLogs strips the 8-byte frame headers Docker puts on the logs of a container
+// without a TTY, keeping the frames of both streams in the order they were written
+func demuxDockerLogs(raw []byte) []byte {
+	var output bytes.Buffer
+	for len(raw) >= 8 {
+		size := int(binary.BigEndian.Uint32(raw[4:8]))
+		raw = raw[8:]
+		if size > len(raw) {
+			size = len(raw)
+		}
+		output.Write(raw[:size])
+		raw = raw[size:]
+	}
+	return output.Bytes()
+}
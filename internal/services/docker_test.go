@@ -0,0 +1,22 @@
+package services
+
+import "testing"
+
+func TestSplitImageRef(t *testing.T) {
+	tests := []struct {
+		image, repository, tag string
+	}{
+		{"instrumentisto/nmap", "instrumentisto/nmap", "latest"},
+		{"instrumentisto/nmap:7.95", "instrumentisto/nmap", "7.95"},
+		{"ghcr.io/zaproxy/zaproxy:stable", "ghcr.io/zaproxy/zaproxy", "stable"},
+		{"registry.local:5000/scanners/nmap", "registry.local:5000/scanners/nmap", "latest"},
+		{"registry.local:5000/scanners/nmap:1.2", "registry.local:5000/scanners/nmap", "1.2"},
+		{"zricethezav/gitleaks@sha256:abc123", "zricethezav/gitleaks", "sha256:abc123"},
+	}
+	for _, tt := range tests {
+		repository, tag := splitImageRef(tt.image)
+		if repository != tt.repository || tag != tt.tag {
+			t.Errorf("splitImageRef(%q) = %q, %q; want %q, %q", tt.image, repository, tag, tt.repository, tt.tag)
+		}
+	}
+}
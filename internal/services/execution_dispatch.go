@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	// dispatchQueueKey is the Redis list of executions waiting for a server to run them
+	dispatchQueueKey = "workflow:executions:dispatch"
+
+	// cancelChannel is the Redis channel cancellations are broadcast on, so the server running
+	// an execution stops it whichever server received the request
+	cancelChannel = "workflow:executions:cancel"
+
+	// dispatchPollTimeout bounds each blocking pop so Stop is noticed promptly
+	dispatchPollTimeout = 5 * time.Second
+)
+
+// dispatchJob is an execution waiting on the dispatch queue
+type dispatchJob struct {
+	ExecutionID uuid.UUID `json:"execution_id"`
+	RequestID   string    `json:"request_id,omitempty"` // Request that launched it, for log correlation
+}
+
+// ExecutionDispatcher hands pending executions to servers through a Redis list, so servers
+// sharing the database share the work and executions launched just before a restart are
+// run once a server is back. The execution itself stays in Postgres: a server pops its ID,
+// claims it there and runs it from its snapshot. Claims are conditional updates, so an
+// execution pushed twice, e.g. by the execution monitor after a restart, runs once.
+type ExecutionDispatcher struct {
+	client   *redis.Client
+	db       *gorm.DB
+	executor *WorkflowExecutor
+
+	cancellations *redis.PubSub
+	cancel        context.CancelFunc
+	done          chan struct{}
+}
+
+// NewExecutionDispatcher routes the workflow service's executions through Redis. Until Start
+// is called, pushed executions wait in Redis for another server.
+func NewExecutionDispatcher(client *redis.Client, db *gorm.DB, workflows *WorkflowService) *ExecutionDispatcher {
+	d := &ExecutionDispatcher{
+		client:   client,
+		db:       db,
+		executor: workflows.executor,
+		done:     make(chan struct{}),
+	}
+	workflows.executor.dispatcher = d
+	return d
+}
+
+// Start runs executions popped from the queue and listens for cancellations in the background
+func (d *ExecutionDispatcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+
+	d.cancellations = d.client.Subscribe(ctx, cancelChannel)
+	go func() {
+		for message := range d.cancellations.Channel() {
+			if executionID, err := uuid.Parse(message.Payload); err == nil {
+				d.executor.active.Cancel(executionID)
+			}
+		}
+	}()
+
+	go func() {
+		defer close(d.done)
+		for ctx.Err() == nil {
+			d.next(ctx)
+		}
+	}()
+}
+
+// Stop stops taking executions off the queue; executions already started keep running
+func (d *ExecutionDispatcher) Stop() {
+	if d.cancel == nil {
+		return
+	}
+	d.cancel()
+	d.cancellations.Close()
+	<-d.done
+}
+
+// push queues a pending execution for whichever server takes it first
+func (d *ExecutionDispatcher) push(ctx context.Context, executionID uuid.UUID) error {
+	job, err := json.Marshal(dispatchJob{ExecutionID: executionID, RequestID: utils.RequestID(ctx)})
+	if err != nil {
+		return err
+	}
+	return d.client.LPush(ctx, dispatchQueueKey, job).Err()
+}
+
+// broadcastCancel asks every server to cancel the execution if it is running it
+func (d *ExecutionDispatcher) broadcastCancel(executionID uuid.UUID) error {
+	return d.client.Publish(context.Background(), cancelChannel, executionID.String()).Err()
+}
+
+// next waits for a queued execution and starts it if this server claims it
+func (d *ExecutionDispatcher) next(ctx context.Context) {
+	popped, err := d.client.BRPop(ctx, dispatchPollTimeout, dispatchQueueKey).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) && ctx.Err() == nil {
+			log.Printf("⚠️ Failed to read the execution dispatch queue: %v", err)
+			sleepContext(ctx, time.Second)
+		}
+		return
+	}
+
+	var job dispatchJob
+	if err := json.Unmarshal([]byte(popped[1]), &job); err != nil {
+		log.Printf("⚠️ Dropping malformed dispatch job %q: %v", popped[1], err)
+		return
+	}
+	d.run(job)
+}
+
+// run claims and starts a dispatched execution. Executions already claimed, cancelled or
+// deleted since they were queued are dropped.
+func (d *ExecutionDispatcher) run(job dispatchJob) {
+	var execution models.WorkflowExecution
+	if err := d.db.First(&execution, "id = ?", job.ExecutionID).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			log.Printf("⚠️ Failed to load dispatched execution %s: %v", job.ExecutionID, err)
+		}
+		return
+	}
+	logger := slog.Default()
+	if job.RequestID != "" {
+		logger = logger.With("request_id", job.RequestID)
+	}
+	ctx := utils.WithLogger(context.Background(), logger)
+	if execution.Snapshot == nil || !d.executor.claim(ctx, &execution) {
+		return
+	}
+	d.executor.start(ctx, &execution, workflowFromSnapshot(&execution))
+}
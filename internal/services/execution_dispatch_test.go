@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// newTestDispatcher returns an executor whose executions are dispatched through an in-memory Redis
+func newTestDispatcher(t *testing.T, db *gorm.DB, server *miniredis.Miniredis) (*WorkflowExecutor, *ExecutionDispatcher) {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	executor := &WorkflowExecutor{db: db, active: newExecutionRegistry(), slots: newExecutionSlots(0, 0)}
+	dispatcher := &ExecutionDispatcher{client: client, db: db, executor: executor, done: make(chan struct{})}
+	executor.dispatcher = dispatcher
+	return executor, dispatcher
+}
+
+func queuedJobs(t *testing.T, server *miniredis.Miniredis) []dispatchJob {
+	t.Helper()
+	if !server.Exists(dispatchQueueKey) {
+		return nil
+	}
+	raw, err := server.List(dispatchQueueKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := make([]dispatchJob, len(raw))
+	for i, item := range raw {
+		if err := json.Unmarshal([]byte(item), &jobs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return jobs
+}
+
+func TestDispatchPushesWithRequestID(t *testing.T) {
+	db, _ := newMockDB(t)
+	server := miniredis.RunT(t)
+	_, dispatcher := newTestDispatcher(t, db, server)
+
+	executionID := uuid.New()
+	ctx := utils.WithRequestID(context.Background(), "req-123")
+	if err := dispatcher.push(ctx, executionID); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs := queuedJobs(t, server)
+	if len(jobs) != 1 || jobs[0].ExecutionID != executionID || jobs[0].RequestID != "req-123" {
+		t.Fatalf("queued %+v", jobs)
+	}
+}
+
+func TestDispatcherDropsExecutionClaimedElsewhere(t *testing.T) {
+	db, mock := newMockDB(t)
+	server := miniredis.RunT(t)
+	executor, dispatcher := newTestDispatcher(t, db, server)
+
+	executionID := uuid.New()
+	if err := dispatcher.push(context.Background(), executionID); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM "workflow_executions" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "workflow_id", "user_id", "status", "snapshot"}).
+			AddRow(executionID, uuid.New(), uuid.New(), "pending", `{"name":"w","nodes":[],"edges":[]}`))
+	// Another server's claim already landed, so this one updates nothing
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "workflow_executions" SET .* WHERE id = \$\d+ AND status = \$\d+ AND claimed_at IS NULL`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	dispatcher.next(context.Background())
+
+	if executor.active.has(executionID) {
+		t.Fatal("started an execution another server had claimed")
+	}
+	if jobs := queuedJobs(t, server); len(jobs) != 0 {
+		t.Fatalf("job left on the queue: %+v", jobs)
+	}
+}
+
+func TestCancelReachesExecutionOnAnotherServer(t *testing.T) {
+	server := miniredis.RunT(t)
+	db, _ := newMockDB(t)
+	receiver, receiverDispatcher := newTestDispatcher(t, db, server)
+	sender, _ := newTestDispatcher(t, db, server)
+	receiverDispatcher.Start()
+	defer receiverDispatcher.Stop()
+
+	executionID := uuid.New()
+	ctx, cancel := context.WithCancelCause(context.Background())
+	receiver.active.add(ActiveExecution{ID: executionID}, cancel, newExecutionLog())
+
+	// The subscription may not be in place yet, so keep asking until the run stops
+	deadline := time.Now().Add(2 * time.Second)
+	for ctx.Err() == nil && time.Now().Before(deadline) {
+		if !sender.Cancel(&models.WorkflowExecution{ID: executionID, Status: "running"}) {
+			t.Fatal("cancel of a running execution was refused")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if !errors.Is(context.Cause(ctx), ErrExecutionCancelled) {
+		t.Fatalf("execution on the other server was not cancelled: %v", context.Cause(ctx))
+	}
+}
+
+func TestCancelUnclaimedPendingExecution(t *testing.T) {
+	db, mock := newMockDB(t)
+	executor, _ := newTestDispatcher(t, db, miniredis.RunT(t))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "workflow_executions" SET .* WHERE id = \$\d+ AND \(status = \$\d+ AND claimed_at IS NULL\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if !executor.Cancel(&models.WorkflowExecution{ID: uuid.New(), Status: "pending"}) {
+		t.Fatal("pending execution waiting on the dispatch queue was not cancelled")
+	}
+}
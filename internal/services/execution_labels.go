@@ -0,0 +1,112 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	maxExecutionTags   = 20
+	maxExecutionTagLen = 64
+	maxExecutionNotes  = 4000
+)
+
+// ErrInvalidLabels is returned for execution tags or notes that are too long or too many
+var ErrInvalidLabels = errors.New("invalid execution labels")
+
+// ExecutionLabels are the user's tags and free-form notes on an execution
+type ExecutionLabels struct {
+	Tags  []string
+	Notes string
+}
+
+// normalizeTags trims tags and drops blanks and duplicates, keeping the first spelling of each
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		if len(tag) > maxExecutionTagLen {
+			return nil, fmt.Errorf("%w: tags must be at most %d characters", ErrInvalidLabels, maxExecutionTagLen)
+		}
+		seen[strings.ToLower(tag)] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > maxExecutionTags {
+		return nil, fmt.Errorf("%w: at most %d tags are allowed", ErrInvalidLabels, maxExecutionTags)
+	}
+	return normalized, nil
+}
+
+func validateNotes(notes string) error {
+	if len(notes) > maxExecutionNotes {
+		return fmt.Errorf("%w: notes must be at most %d characters", ErrInvalidLabels, maxExecutionNotes)
+	}
+	return nil
+}
+
+// normalize validates labels given at launch
+func (l ExecutionLabels) normalize() (ExecutionLabels, error) {
+	tags, err := normalizeTags(l.Tags)
+	if err != nil {
+		return ExecutionLabels{}, err
+	}
+	if err := validateNotes(l.Notes); err != nil {
+		return ExecutionLabels{}, err
+	}
+	return ExecutionLabels{Tags: tags, Notes: l.Notes}, nil
+}
+
+// UpdateExecutionLabels replaces an execution's tags and/or notes; nil leaves a field unchanged
+func (s *WorkflowService) UpdateExecutionLabels(executionID, userID uuid.UUID, tags *[]string, notes *string) (*models.WorkflowExecution, error) {
+	updates := make(map[string]interface{})
+	if tags != nil {
+		normalized, err := normalizeTags(*tags)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := json.Marshal(normalized)
+		if err != nil {
+			return nil, err
+		}
+		updates["tags"] = string(encoded)
+	}
+	if notes != nil {
+		if err := validateNotes(*notes); err != nil {
+			return nil, err
+		}
+		updates["notes"] = *notes
+	}
+
+	result := s.db.Model(&models.WorkflowExecution{}).Where("id = ? AND user_id = ?", executionID, userID).Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(updates) > 0 && result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return s.GetWorkflowExecution(executionID, userID)
+}
+
+// withTags restricts an executions query to those carrying every one of tags
+func withTags(tags []string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		for _, tag := range tags {
+			if tag = strings.TrimSpace(tag); tag == "" {
+				continue
+			}
+			encoded, _ := json.Marshal([]string{tag})
+			tx = tx.Where("workflow_executions.tags @> ?::jsonb", string(encoded))
+		}
+		return tx
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tooMany := make([]string, maxExecutionTags+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag-%d", i)
+	}
+	tests := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "none", tags: nil, want: []string{}},
+		{name: "trimmed", tags: []string{"  release-1.2 ", "audit"}, want: []string{"release-1.2", "audit"}},
+		{name: "blanks dropped", tags: []string{"", "  ", "audit"}, want: []string{"audit"}},
+		{name: "duplicates keep the first spelling", tags: []string{"Audit", "audit", "AUDIT", "nightly"}, want: []string{"Audit", "nightly"}},
+		{name: "longest tag", tags: []string{strings.Repeat("a", maxExecutionTagLen)}, want: []string{strings.Repeat("a", maxExecutionTagLen)}},
+		{name: "tag too long", tags: []string{strings.Repeat("a", maxExecutionTagLen+1)}, wantErr: true},
+		{name: "most tags", tags: tooMany[:maxExecutionTags], want: tooMany[:maxExecutionTags]},
+		{name: "too many tags", tags: tooMany, wantErr: true},
+		{name: "duplicates don't count toward the limit", tags: append(tooMany[:maxExecutionTags:maxExecutionTags], "tag-0"), want: tooMany[:maxExecutionTags]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeTags(tt.tags)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidLabels) {
+					t.Fatalf("normalizeTags() = %v, %v, want ErrInvalidLabels", got, err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("normalizeTags() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestListWorkflowExecutionsFiltersByEveryTag(t *testing.T) {
+	db, mock := newMockDB(t)
+	service := &WorkflowService{db: db}
+	userID := uuid.New()
+
+	// Each tag narrows the list; blank tags from e.g. ?tag=audit&tag= are ignored
+	where := `WHERE workflow_executions\.user_id = \$1 AND workflow_executions\.tags @> \$2::jsonb AND workflow_executions\.tags @> \$3::jsonb`
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "workflow_executions" `+where+`$`).
+		WithArgs(userID, `["audit"]`, `["release-1.2"]`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(where+` ORDER BY`).
+		WithArgs(userID, `["audit"]`, `["release-1.2"]`, defaultExecutionPageSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tags"}).AddRow(uuid.New(), []byte(`["audit","release-1.2","nightly"]`)))
+
+	list, err := service.ListWorkflowExecutions(userID, ExecutionListFilter{Tags: []string{"audit", " ", " release-1.2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Total != 1 || len(list.Executions) != 1 {
+		t.Fatalf("got total %d and %d executions, want 1", list.Total, len(list.Executions))
+	}
+}
+
+func TestUpdateExecutionLabels(t *testing.T) {
+	t.Run("execution of another user", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		service := &WorkflowService{db: db}
+		executionID, userID := uuid.New(), uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "workflow_executions" SET "notes"=\$1,"tags"=\$2,"updated_at"=\$3 WHERE id = \$4 AND user_id = \$5`).
+			WithArgs("flaky target", `["audit"]`, sqlmock.AnyArg(), executionID, userID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		tags, notes := []string{"audit", "Audit"}, "flaky target"
+		if _, err := service.UpdateExecutionLabels(executionID, userID, &tags, &notes); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("UpdateExecutionLabels() = %v, want ErrRecordNotFound", err)
+		}
+	})
+
+	t.Run("notes too long", func(t *testing.T) {
+		db, _ := newMockDB(t)
+		service := &WorkflowService{db: db}
+		notes := strings.Repeat("n", maxExecutionNotes+1)
+		if _, err := service.UpdateExecutionLabels(uuid.New(), uuid.New(), nil, &notes); !errors.Is(err, ErrInvalidLabels) {
+			t.Fatalf("UpdateExecutionLabels() = %v, want ErrInvalidLabels", err)
+		}
+	})
+}
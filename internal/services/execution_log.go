@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+)
+
+// maxExecutionLogBytes caps the message bytes retained per execution; the oldest lines go first
+const maxExecutionLogBytes = 256 * 1024
+
+type executionLogKey struct{}
+
+type logNodeKey struct{}
+
+type logNode struct {
+	id       string
+	nodeType string
+}
+
+// executionLog collects the log lines written while an execution runs
+type executionLog struct {
+	mu        sync.Mutex
+	entries   []models.ExecutionLogEntry
+	size      int
+	truncated bool
+}
+
+func newExecutionLog() *executionLog {
+	return &executionLog{}
+}
+
+func (l *executionLog) append(entry models.ExecutionLogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+	l.size += len(entry.Message)
+	for l.size > maxExecutionLogBytes && len(l.entries) > 1 {
+		l.size -= len(l.entries[0].Message)
+		l.entries = l.entries[1:]
+		l.truncated = true
+	}
+}
+
+// Snapshot returns a copy of the lines collected so far
+func (l *executionLog) Snapshot() *models.ExecutionLog {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries := make([]models.ExecutionLogEntry, len(l.entries))
+	copy(entries, l.entries)
+	return &models.ExecutionLog{Entries: entries, Truncated: l.truncated}
+}
+
+// withExecutionLog returns a context whose logf calls are also recorded in l
+func withExecutionLog(ctx context.Context, l *executionLog) context.Context {
+	return context.WithValue(ctx, executionLogKey{}, l)
+}
+
+// withLogNode tags the log lines written under ctx with the node being executed
+func withLogNode(ctx context.Context, node *WorkflowNode) context.Context {
+	ctx = utils.WithLogger(ctx, utils.Logger(ctx).With("node_id", node.ID, "node_type", node.Type))
+	return context.WithValue(ctx, logNodeKey{}, logNode{id: node.ID, nodeType: node.Type})
+}
+
+// logf writes to the server log, with the fields of ctx's logger, and, when ctx belongs to an execution, to that execution's log
+func logf(ctx context.Context, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	utils.Logger(ctx).Info(message)
+
+	l, ok := ctx.Value(executionLogKey{}).(*executionLog)
+	if !ok {
+		return
+	}
+	entry := models.ExecutionLogEntry{Time: time.Now(), Message: message}
+	if node, ok := ctx.Value(logNodeKey{}).(logNode); ok {
+		entry.NodeID = node.id
+		entry.NodeType = node.nodeType
+	}
+	l.append(entry)
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/google/uuid"
+)
+
+func TestCompareBaselineLogsWithExecutionFields(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectQuery(`SELECT \* FROM "workflow_executions"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	var out bytes.Buffer
+	executionID := uuid.New()
+	logger := slog.New(slog.NewTextHandler(&out, nil)).With("execution_id", executionID.String())
+	ctx := utils.WithLogger(context.Background(), logger)
+
+	baselineID := uuid.New()
+	e := &WorkflowExecutor{db: db}
+	if comparison := e.compareBaseline(ctx, &models.Workflow{ID: uuid.New(), BaselineExecutionID: &baselineID}, nil); comparison != nil {
+		t.Fatal("compared against a baseline that doesn't exist")
+	}
+
+	line := out.String()
+	if !strings.Contains(line, "Baseline execution "+baselineID.String()+" unavailable") {
+		t.Fatalf("missing baseline warning in %q", line)
+	}
+	if !strings.Contains(line, "execution_id="+executionID.String()) {
+		t.Fatalf("warning lacks the execution's fields: %q", line)
+	}
+}
+
+func TestNodeLogsCarryCorrelationFields(t *testing.T) {
+	e, _ := newTestExecutor(t, config.WorkflowConfig{MaxParallelNodes: 4})
+	workflow := testWorkflow(
+		models.JSONArray{testNode("trigger", "trigger"), testNode("left", "test-scan"), testNode("right", "test-scan")},
+		models.JSONArray{testEdge("e1", "trigger", "left"), testEdge("e2", "trigger", "right")},
+	)
+	execution := &models.WorkflowExecution{ID: uuid.New(), WorkflowID: workflow.ID, UserID: workflow.UserID}
+
+	// The request that launched the run logs with its own fields, which the run keeps
+	var out bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&out, nil)).With("request_id", "req-1")
+	e.start(utils.WithLogger(context.Background(), logger), execution, workflow)
+	waitForExecution(t, e, execution.ID)
+
+	executed := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log line %q isn't JSON: %v", line, err)
+		}
+		if record["execution_id"] != execution.ID.String() || record["workflow_id"] != workflow.ID.String() || record["request_id"] != "req-1" {
+			t.Errorf("line lacks the execution's fields: %s", line)
+		}
+		message, _ := record["msg"].(string)
+		if !strings.Contains(message, "Executing node:") {
+			continue
+		}
+		nodeID, _ := record["node_id"].(string)
+		if !strings.Contains(message, "Executing node: "+nodeID+" (") || record["node_type"] == nil {
+			t.Errorf("node line lacks its node's fields: %s", line)
+		}
+		executed[nodeID] = true
+	}
+	if len(executed) != 3 {
+		t.Fatalf("logged the execution of nodes %v, want all three", executed)
+	}
+}
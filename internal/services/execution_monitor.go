@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// heartbeatInterval is how often a server marks the executions it is running as alive
+	heartbeatInterval = 30 * time.Second
+
+	// orphanedAfter is how long an execution may go without a heartbeat before the server
+	// running it is taken to have stopped
+	orphanedAfter = 2 * time.Minute
+)
+
+// ExecutionMonitor keeps pending and running executions from outliving the server running
+// them. Each server heartbeats the executions it has in flight; one whose heartbeat stops
+// was orphaned by a restart or crash. An orphaned run that had started is failed, keeping the
+// node results it saved, and one that was still waiting for a slot is started again here.
+type ExecutionMonitor struct {
+	db       *gorm.DB
+	executor *WorkflowExecutor
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewExecutionMonitor(db *gorm.DB, workflows *WorkflowService) *ExecutionMonitor {
+	return &ExecutionMonitor{
+		db:       db,
+		executor: workflows.executor,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start recovers executions orphaned before this server started, then heartbeats and
+// recovers in the background
+func (m *ExecutionMonitor) Start() {
+	m.recoverOrphaned()
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.heartbeat()
+				m.recoverOrphaned()
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts the monitor loop
+func (m *ExecutionMonitor) Stop() {
+	close(m.stop)
+	<-m.done
+}
+
+// heartbeat marks the executions this server has in flight as alive
+func (m *ExecutionMonitor) heartbeat() {
+	active := m.executor.ActiveExecutions()
+	if len(active) == 0 {
+		return
+	}
+	ids := make([]uuid.UUID, len(active))
+	for i, execution := range active {
+		ids[i] = execution.ID
+	}
+	if err := m.db.Model(&models.WorkflowExecution{}).Where("id IN ?", ids).
+		UpdateColumn("heartbeat_at", time.Now()).Error; err != nil {
+		log.Printf("⚠️ Failed to heartbeat executions: %v", err)
+	}
+}
+
+// recoverOrphaned fails or restarts every pending or running execution whose heartbeat has
+// stopped. Executions are claimed with a conditional update, so when several servers share
+// the database each orphan is recovered once.
+func (m *ExecutionMonitor) recoverOrphaned() {
+	cutoff := time.Now().Add(-orphanedAfter)
+	var orphaned []models.WorkflowExecution
+	if err := m.db.Where("status IN ? AND COALESCE(heartbeat_at, started_at, created_at) < ?", []string{"pending", "running"}, cutoff).
+		Order("created_at").Find(&orphaned).Error; err != nil {
+		log.Printf("⚠️ Failed to load orphaned executions: %v", err)
+		return
+	}
+
+	workflows := make(map[uuid.UUID]bool)
+	for i := range orphaned {
+		execution := &orphaned[i]
+		if m.executor.active.has(execution.ID) {
+			// Still in flight here; the heartbeat just hasn't caught up with it
+			continue
+		}
+		if execution.Status == "pending" && execution.Snapshot != nil {
+			if m.restart(execution, cutoff) {
+				log.Printf("🔁 Restarted execution %s orphaned before it started", execution.ID)
+			}
+			continue
+		}
+		if m.fail(execution, cutoff) {
+			log.Printf("⚠️ Failed execution %s orphaned by a stopped server", execution.ID)
+			m.executor.webhooks.Notify(execution.ID)
+			workflows[execution.WorkflowID] = true
+		}
+	}
+
+	// The orphans no longer hold their workflows, so queued runs behind them can start
+	for workflowID := range workflows {
+		m.executor.startQueued(workflowID)
+	}
+}
+
+// restart takes over an orphaned execution that never started and dispatches it again,
+// releasing the claim of the server that stopped
+func (m *ExecutionMonitor) restart(execution *models.WorkflowExecution, cutoff time.Time) bool {
+	now := time.Now()
+	claimed := m.db.Model(&models.WorkflowExecution{}).
+		Where("id = ? AND status = ? AND COALESCE(heartbeat_at, started_at, created_at) < ?", execution.ID, "pending", cutoff).
+		UpdateColumns(map[string]interface{}{"heartbeat_at": now, "claimed_at": nil})
+	if claimed.Error != nil {
+		log.Printf("⚠️ Failed to claim orphaned execution %s: %v", execution.ID, claimed.Error)
+		return false
+	}
+	if claimed.RowsAffected == 0 {
+		return false
+	}
+	execution.HeartbeatAt = &now
+	execution.ClaimedAt = nil
+	m.executor.dispatch(context.Background(), execution, workflowFromSnapshot(execution))
+	return true
+}
+
+// fail finalizes an orphaned execution as failed, keeping the node results it had saved
+func (m *ExecutionMonitor) fail(execution *models.WorkflowExecution, cutoff time.Time) bool {
+	results := map[string]interface{}(execution.Results)
+	if results == nil {
+		results = map[string]interface{}{}
+	}
+	severitySummary, riskGrade := riskSummary(results)
+	failed := m.db.Model(&models.WorkflowExecution{}).
+		Where("id = ? AND status IN ? AND COALESCE(heartbeat_at, started_at, created_at) < ?", execution.ID, []string{"pending", "running"}, cutoff).
+		Updates(map[string]interface{}{
+			"status":           "failed",
+			"error":            "Execution interrupted: the server running it stopped before it finished",
+			"completed_at":     time.Now(),
+			"severity_summary": severitySummaryJSON(severitySummary),
+			"risk_grade":       riskGrade,
+		})
+	if failed.Error != nil {
+		log.Printf("⚠️ Failed to fail orphaned execution %s: %v", execution.ID, failed.Error)
+		return false
+	}
+	return failed.RowsAffected > 0
+}
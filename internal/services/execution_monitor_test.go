@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+)
+
+// TestRecoverOrphanedAfterRestart simulates a server starting after another stopped mid-run:
+// the run that had started is failed, and the one still waiting for a slot is released and
+// dispatched again.
+func TestRecoverOrphanedAfterRestart(t *testing.T) {
+	db, mock := newMockDB(t)
+	server := miniredis.RunT(t)
+	executor, _ := newTestDispatcher(t, db, server)
+	monitor := &ExecutionMonitor{db: db, executor: executor}
+
+	stale := time.Now().Add(-10 * time.Minute)
+	running, pending := uuid.New(), uuid.New()
+	runningWorkflow := uuid.New()
+	mock.ExpectQuery(`SELECT \* FROM "workflow_executions" WHERE status IN \(\$1,\$2\) AND COALESCE\(heartbeat_at, started_at, created_at\) < \$3 ORDER BY created_at`).
+		WithArgs("pending", "running", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "workflow_id", "user_id", "status", "heartbeat_at", "claimed_at", "snapshot"}).
+			AddRow(running, runningWorkflow, uuid.New(), "running", stale, stale, `{"name":"scan","nodes":[],"edges":[]}`).
+			AddRow(pending, uuid.New(), uuid.New(), "pending", stale, stale, `{"name":"scan","nodes":[],"edges":[]}`))
+
+	// The started run is failed with the results it saved
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "workflow_executions" SET .*"error"=\$\d+.*"status"=\$\d+.* WHERE id = \$\d+ AND status IN \(\$\d+,\$\d+\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// The unstarted run is taken over, dropping the stopped server's claim
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "workflow_executions" SET "claimed_at"=\$1,"heartbeat_at"=\$2 WHERE id = \$3 AND status = \$4`).
+		WithArgs(nil, sqlmock.AnyArg(), pending, "pending", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// The failed run no longer holds its workflow, so queued runs behind it are looked for
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT \* FROM "workflow_executions" WHERE workflow_id = \$1 AND status = \$2`).
+		WithArgs(runningWorkflow, "queued", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	monitor.recoverOrphaned()
+
+	jobs := queuedJobs(t, server)
+	if len(jobs) != 1 || jobs[0].ExecutionID != pending {
+		t.Fatalf("expected only the unstarted run to be dispatched again, queued %+v", jobs)
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+)
+
+// ActiveExecution is a point-in-time view of an execution that is still in flight
+type ActiveExecution struct {
+	ID           uuid.UUID `json:"id"`
+	WorkflowID   uuid.UUID `json:"workflowId"`
+	WorkflowName string    `json:"workflowName"`
+	UserID       uuid.UUID `json:"userId"`
+	StartedAt    time.Time `json:"startedAt"`
+	CurrentNodes []string  `json:"currentNodes"` // Node IDs running right now; several when branches run in parallel
+}
+
+type inFlightExecution struct {
+	info         ActiveExecution
+	currentNodes map[string]struct{}
+	cancel       context.CancelCauseFunc
+	log          *executionLog
+}
+
+// executionRegistry tracks executions from launch until they finish, and holds
+// the cancel func for each so a running execution can be stopped from outside
+type executionRegistry struct {
+	mu         sync.RWMutex
+	executions map[uuid.UUID]*inFlightExecution
+}
+
+func newExecutionRegistry() *executionRegistry {
+	return &executionRegistry{
+		executions: make(map[uuid.UUID]*inFlightExecution),
+	}
+}
+
+// add registers an execution along with the func that cancels its context and its live log
+func (r *executionRegistry) add(info ActiveExecution, cancel context.CancelCauseFunc, log *executionLog) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.executions[info.ID] = &inFlightExecution{
+		info:         info,
+		currentNodes: make(map[string]struct{}),
+		cancel:       cancel,
+		log:          log,
+	}
+}
+
+// remove drops a finished execution and releases its context
+func (r *executionRegistry) remove(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if execution, ok := r.executions[id]; ok {
+		execution.cancel(nil)
+		delete(r.executions, id)
+	}
+}
+
+// has reports whether the execution is in flight on this server
+func (r *executionRegistry) has(id uuid.UUID) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.executions[id]
+	return ok
+}
+
+// nodeStarted records that a node of the execution began running
+func (r *executionRegistry) nodeStarted(id uuid.UUID, nodeID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if execution, ok := r.executions[id]; ok {
+		execution.currentNodes[nodeID] = struct{}{}
+	}
+}
+
+// nodeFinished records that a node of the execution stopped running
+func (r *executionRegistry) nodeFinished(id uuid.UUID, nodeID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if execution, ok := r.executions[id]; ok {
+		delete(execution.currentNodes, nodeID)
+	}
+}
+
+// Cancel cancels an in-flight execution's context with ErrExecutionCancelled as the cause; it
+// reports false if the execution isn't running
+func (r *executionRegistry) Cancel(id uuid.UUID) bool {
+	r.mu.RLock()
+	execution, ok := r.executions[id]
+	r.mu.RUnlock()
+	if !ok {
+		return false
+	}
+	execution.cancel(ErrExecutionCancelled)
+	return true
+}
+
+// Logs returns the log lines an in-flight execution has written so far
+func (r *executionRegistry) Logs(id uuid.UUID) (*models.ExecutionLog, bool) {
+	r.mu.RLock()
+	execution, ok := r.executions[id]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return execution.log.Snapshot(), true
+}
+
+// List returns a copy of every in-flight execution, oldest first
+func (r *executionRegistry) List() []ActiveExecution {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	active := make([]ActiveExecution, 0, len(r.executions))
+	for _, execution := range r.executions {
+		info := execution.info
+		info.CurrentNodes = make([]string, 0, len(execution.currentNodes))
+		for nodeID := range execution.currentNodes {
+			info.CurrentNodes = append(info.CurrentNodes, nodeID)
+		}
+		sort.Strings(info.CurrentNodes)
+		active = append(active, info)
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].StartedAt.Before(active[j].StartedAt)
+	})
+	return active
+}
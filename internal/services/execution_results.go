@@ -0,0 +1,139 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// resultsFlushInterval bounds how often node results are written back to the execution row
+const resultsFlushInterval = 500 * time.Millisecond
+
+// executionResults is a concurrency-safe accumulator for node results.
+// Nodes running in parallel write through Set, and a single flusher goroutine
+// persists the accumulated map so concurrent nodes never race on the DB row.
+type executionResults struct {
+	mu         sync.RWMutex
+	data       map[string]interface{}
+	order      []string // Node IDs in the order they were first Set
+	dirty      bool
+	totalNodes int
+
+	db          *gorm.DB
+	executionID uuid.UUID
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+func newExecutionResults(db *gorm.DB, executionID uuid.UUID, totalNodes int) *executionResults {
+	return &executionResults{
+		data:        make(map[string]interface{}),
+		totalNodes:  totalNodes,
+		db:          db,
+		executionID: executionID,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Set stores a node result and marks the accumulator for the next flush
+func (r *executionResults) Set(nodeID string, result interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.data[nodeID]; !ok {
+		r.order = append(r.order, nodeID)
+	}
+	r.data[nodeID] = result
+	r.dirty = true
+}
+
+// Get returns a single node result
+func (r *executionResults) Get(nodeID string) (interface{}, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result, ok := r.data[nodeID]
+	return result, ok
+}
+
+// Snapshot returns a shallow copy that callers may read without holding the lock
+func (r *executionResults) Snapshot() map[string]interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	snapshot := make(map[string]interface{}, len(r.data))
+	for k, v := range r.data {
+		snapshot[k] = v
+	}
+	return snapshot
+}
+
+// Progress returns the share of nodes that have finished (completed or skipped), 0-100.
+// Every finished node writes exactly one result, so parallel branches count correctly.
+func (r *executionResults) Progress() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.progressLocked()
+}
+
+func (r *executionResults) progressLocked() int {
+	if r.totalNodes == 0 {
+		return 100
+	}
+	progress := len(r.data) * 100 / r.totalNodes
+	if progress > 100 {
+		progress = 100
+	}
+	return progress
+}
+
+// Start launches the background flusher that batches result writes
+func (r *executionResults) Start() {
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(resultsFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.flush()
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts the flusher and performs a final flush of pending results
+func (r *executionResults) Stop() {
+	close(r.stop)
+	<-r.done
+	r.flush()
+}
+
+// flush writes the current results and progress if anything changed since the last write
+func (r *executionResults) flush() {
+	r.mu.Lock()
+	if !r.dirty {
+		r.mu.Unlock()
+		return
+	}
+	snapshot := make(models.JSONMap, len(r.data))
+	for k, v := range r.data {
+		snapshot[k] = v
+	}
+	order := append([]string(nil), r.order...)
+	progress := r.progressLocked()
+	r.dirty = false
+	r.mu.Unlock()
+
+	if err := r.db.Model(&models.WorkflowExecution{}).Where("id = ?", r.executionID).Updates(map[string]interface{}{
+		"results":    snapshot,
+		"node_order": order,
+		"progress":   progress,
+	}).Error; err != nil {
+		log.Printf("⚠️ Failed to persist results for execution %s: %v", r.executionID, err)
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+)
+
+// Run with -race: parallel nodes write while the flusher and readers run
+func TestExecutionResultsConcurrentWrites(t *testing.T) {
+	const nodes, rewrites = 50, 20
+	db, writes := newResultWritesDB(t)
+	r := newExecutionResults(db, uuid.New(), nodes)
+	r.Start()
+
+	var writers sync.WaitGroup
+	for i := 0; i < nodes; i++ {
+		writers.Add(1)
+		go func(nodeID string) {
+			defer writers.Done()
+			for j := 0; j < rewrites; j++ {
+				r.Set(nodeID, map[string]interface{}{"attempt": j})
+			}
+		}(fmt.Sprintf("node-%d", i))
+	}
+
+	stopReaders := make(chan struct{})
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for {
+			select {
+			case <-stopReaders:
+				return
+			default:
+			}
+			r.flush()
+			r.Get("node-0")
+			r.Progress()
+			for range r.Snapshot() {
+			}
+		}
+	}()
+
+	writers.Wait()
+	close(stopReaders)
+	readers.Wait()
+	r.Stop()
+
+	final := writes.last(t)
+	if progress := final["progress"]; progress != 100 {
+		t.Fatalf("final progress %v, want 100", progress)
+	}
+	results := final["results"].(models.JSONMap)
+	order := final["node_order"].([]string)
+	if len(results) != nodes || len(order) != nodes {
+		t.Fatalf("final write has %d results in order %d, want %d", len(results), len(order), nodes)
+	}
+	seen := make(map[string]bool, nodes)
+	for _, nodeID := range order {
+		if seen[nodeID] {
+			t.Fatalf("%s appears twice in the node order", nodeID)
+		}
+		seen[nodeID] = true
+		if result := results[nodeID].(map[string]interface{}); result["attempt"] != rewrites-1 {
+			t.Fatalf("%s persisted attempt %v, want the last write", nodeID, result["attempt"])
+		}
+	}
+}
+
+func TestExecutionResultsFlushesOnlyChanges(t *testing.T) {
+	db, writes := newResultWritesDB(t)
+	r := newExecutionResults(db, uuid.New(), 4)
+
+	r.flush()
+	r.Set("trigger", "ok")
+	r.flush()
+	r.flush()
+	r.Set("scan", "ok")
+	r.flush()
+
+	if len(writes.writes) != 2 {
+		t.Fatalf("got %d writes, want one per batch of changes", len(writes.writes))
+	}
+	if progress := writes.last(t)["progress"]; progress != 50 {
+		t.Fatalf("progress %v, want 50", progress)
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// UserExecutionStats is how many of one user's executions are running and waiting for a slot
+type UserExecutionStats struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Running int       `json:"running"`
+	Waiting int       `json:"waiting"`
+}
+
+// ExecutionLimits reports the execution limits and who is using them
+type ExecutionLimits struct {
+	Max     int                  `json:"max_concurrent"` // 0 when the total isn't limited
+	PerUser int                  `json:"per_user_limit"` // 0 when executions aren't limited per user
+	Running int                  `json:"running"`
+	Waiting int                  `json:"waiting"` // Executions queued for a slot
+	Users   []UserExecutionStats `json:"users"`
+}
+
+// executionSlots caps how many executions run at once in total and per user, so a burst of
+// runs can't exhaust the server and one user launching many can't take every slot. Runs
+// over a cap wait, and freed slots go to the users with the fewest runs going; a run held
+// back only by its user's cap doesn't block other users' runs behind it. Without a per-user
+// cap, runs launched while slots are free all start, so one user's burst can still fill
+// every slot until those runs finish.
+type executionSlots struct {
+	mu           sync.Mutex
+	max          int
+	perUser      int
+	running      map[uuid.UUID]int
+	runningTotal int
+	waiting      []*executionTicket
+}
+
+// executionTicket is a run waiting for a slot
+type executionTicket struct {
+	userID  uuid.UUID
+	ready   chan struct{}
+	granted bool
+}
+
+func newExecutionSlots(max, perUser int) *executionSlots {
+	return &executionSlots{
+		max:     max,
+		perUser: perUser,
+		running: make(map[uuid.UUID]int),
+	}
+}
+
+// acquire waits for a slot and returns the func that releases it. It gives up when ctx is
+// done, so a run cancelled while waiting doesn't hold its place.
+func (s *executionSlots) acquire(ctx context.Context, userID uuid.UUID) (func(), error) {
+	ticket := &executionTicket{userID: userID, ready: make(chan struct{})}
+	s.mu.Lock()
+	s.waiting = append(s.waiting, ticket)
+	s.dispatchLocked()
+	s.mu.Unlock()
+
+	select {
+	case <-ticket.ready:
+		return s.releaser(userID), nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !ticket.granted {
+			for i, waiting := range s.waiting {
+				if waiting == ticket {
+					s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+					break
+				}
+			}
+			return nil, ctx.Err()
+		}
+		// The slot was granted just as ctx ended; hand it on
+		s.releaseLocked(userID)
+		return nil, ctx.Err()
+	}
+}
+
+func (s *executionSlots) releaser(userID uuid.UUID) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.releaseLocked(userID)
+		})
+	}
+}
+
+// releaseLocked frees one of the user's slots and hands free slots to the waiting runs
+func (s *executionSlots) releaseLocked(userID uuid.UUID) {
+	s.running[userID]--
+	s.runningTotal--
+	if s.running[userID] <= 0 {
+		delete(s.running, userID)
+	}
+	s.dispatchLocked()
+}
+
+// dispatchLocked hands free slots to waiting runs. Each slot goes to the waiting run whose
+// user has the fewest executions running, the earliest launched among equals, so a user who
+// launched a burst doesn't keep every freed slot while others wait.
+func (s *executionSlots) dispatchLocked() {
+	for len(s.waiting) > 0 {
+		if s.max > 0 && s.runningTotal >= s.max {
+			return
+		}
+		next := -1
+		for i, ticket := range s.waiting {
+			running := s.running[ticket.userID]
+			if s.perUser > 0 && running >= s.perUser {
+				continue
+			}
+			if next < 0 || running < s.running[s.waiting[next].userID] {
+				next = i
+			}
+		}
+		if next < 0 {
+			return
+		}
+		ticket := s.waiting[next]
+		s.running[ticket.userID]++
+		s.runningTotal++
+		ticket.granted = true
+		close(ticket.ready)
+		s.waiting = append(s.waiting[:next], s.waiting[next+1:]...)
+	}
+}
+
+// limits returns the limits and each user's running and waiting executions
+func (s *executionSlots) limits() ExecutionLimits {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	limits := ExecutionLimits{
+		Max:     s.max,
+		PerUser: s.perUser,
+		Running: s.runningTotal,
+		Waiting: len(s.waiting),
+		Users:   []UserExecutionStats{},
+	}
+	waiting := make(map[uuid.UUID]int)
+	for _, ticket := range s.waiting {
+		waiting[ticket.userID]++
+	}
+	for userID, running := range s.running {
+		limits.Users = append(limits.Users, UserExecutionStats{UserID: userID, Running: running, Waiting: waiting[userID]})
+		delete(waiting, userID)
+	}
+	for userID, count := range waiting {
+		limits.Users = append(limits.Users, UserExecutionStats{UserID: userID, Waiting: count})
+	}
+	sort.Slice(limits.Users, func(i, j int) bool {
+		return limits.Users[i].UserID.String() < limits.Users[j].UserID.String()
+	})
+	return limits
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// waitForWaiting blocks until n runs are waiting for a slot
+func waitForWaiting(t *testing.T, s *executionSlots, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		waiting := len(s.waiting)
+		s.mu.Unlock()
+		if waiting == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d waiting runs", n)
+}
+
+func TestExecutionSlotsFreedSlotGoesToLeastBusyUser(t *testing.T) {
+	s := newExecutionSlots(2, 0)
+	busy, other := uuid.New(), uuid.New()
+
+	releaseFirst, err := s.acquire(context.Background(), busy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.acquire(context.Background(), busy); err != nil {
+		t.Fatal(err)
+	}
+
+	granted := make(chan uuid.UUID, 2)
+	go func() {
+		if _, err := s.acquire(context.Background(), busy); err == nil {
+			granted <- busy
+		}
+	}()
+	waitForWaiting(t, s, 1)
+	go func() {
+		if _, err := s.acquire(context.Background(), other); err == nil {
+			granted <- other
+		}
+	}()
+	waitForWaiting(t, s, 2)
+
+	releaseFirst()
+	select {
+	case userID := <-granted:
+		if userID != other {
+			t.Fatalf("freed slot went to the user already running an execution")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no waiting run was granted the freed slot")
+	}
+}
+
+func TestExecutionSlotsTiesGoInLaunchOrder(t *testing.T) {
+	s := newExecutionSlots(1, 0)
+	first, second := uuid.New(), uuid.New()
+
+	release, err := s.acquire(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	granted := make(chan uuid.UUID, 2)
+	for i, userID := range []uuid.UUID{first, second} {
+		userID := userID
+		go func() {
+			if release, err := s.acquire(context.Background(), userID); err == nil {
+				granted <- userID
+				release()
+			}
+		}()
+		waitForWaiting(t, s, i+1)
+	}
+
+	release()
+	for _, want := range []uuid.UUID{first, second} {
+		select {
+		case got := <-granted:
+			if got != want {
+				t.Fatalf("slots were not granted in launch order")
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("waiting run was never granted a slot")
+		}
+	}
+}
+
+func TestExecutionSlotsPerUserCapDoesNotBlockOthers(t *testing.T) {
+	s := newExecutionSlots(0, 1)
+	capped, other := uuid.New(), uuid.New()
+
+	if _, err := s.acquire(context.Background(), capped); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.acquire(ctx, capped)
+	waitForWaiting(t, s, 1)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.acquire(context.Background(), other)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("run was held back behind another user's capped run")
+	}
+}
+
+func TestExecutionSlotsCancelledWhileWaiting(t *testing.T) {
+	s := newExecutionSlots(1, 0)
+	if _, err := s.acquire(context.Background(), uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := s.acquire(ctx, uuid.New())
+		errs <- err
+	}()
+	waitForWaiting(t, s, 1)
+	cancel()
+
+	if err := <-errs; err != context.Canceled {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if limits := s.limits(); limits.Waiting != 0 || limits.Running != 1 {
+		t.Fatalf("cancelled run left the slots at %+v", limits)
+	}
+}
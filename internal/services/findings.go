@@ -0,0 +1,405 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// nmapOpenPortPattern matches open port lines in nmap's normal output
+var nmapOpenPortPattern = regexp.MustCompile(`^\d+/(tcp|udp)\s+open\b`)
+
+// sqlmapParameterPattern matches the injectable parameter lines in sqlmap's summary
+var sqlmapParameterPattern = regexp.MustCompile(`^Parameter: (.+?) \((.+)\)$`)
+
+// Finding is a single scanner finding in the schema shared by every scanner
+type Finding struct {
+	Key          string   `json:"key"` // Stable identity of the finding across runs
+	Scanner      string   `json:"scanner"`
+	RuleID       string   `json:"rule_id,omitempty"`
+	Title        string   `json:"title"`
+	Severity     Severity `json:"severity"`
+	Target       string   `json:"target,omitempty"`
+	Path         string   `json:"path,omitempty"` // File, URL path or package the finding is in
+	Line         int      `json:"line,omitempty"`
+	EndLine      int      `json:"end_line,omitempty"` // Last line of a multi-line finding
+	Description  string   `json:"description,omitempty"`
+	FixedVersion string   `json:"fixed_version,omitempty"` // Release of a vulnerable package that fixes it
+}
+
+// findingParsers converts each scanner's raw output into findings.
+// Adding a scanner means adding its parser here and its severity mapping in scannerSeverities.
+var findingParsers = map[string]func(scanner string, raw []byte) []Finding{
+	"nmap":        parseNmapFindings,
+	"nikto":       parseNiktoFindings,
+	"gobuster":    parseGobusterFindings,
+	"sqlmap":      parseSqlmapFindings,
+	"wpscan":      parseWpscanFindings,
+	"zap":         parseZAPFindings,
+	"gitleaks":    parseGitleaksFindings,
+	"semgrep":     parseSemgrepFindings,
+	"trivy-sca":   parseTrivyFindings,
+	"trivy-image": parseTrivyFindings,
+}
+
+// Normalize converts a scanner's raw output into findings; output it can't parse yields none
+func Normalize(scanner string, raw []byte) []Finding {
+	parse, ok := findingParsers[scanner]
+	if !ok {
+		return []Finding{}
+	}
+	findings := parse(scanner, raw)
+	if findings == nil {
+		return []Finding{}
+	}
+	return findings
+}
+
+// withFindings stores the normalized findings of a scanner node result next to its raw output
+func withFindings(result interface{}) interface{} {
+	nodeMap, ok := result.(map[string]interface{})
+	if !ok {
+		return result
+	}
+	scanner, _ := nodeMap["scanner"].(string)
+	if scanner == "" {
+		return nodeMap
+	}
+	output, _ := nodeMap["output"].(string)
+	target, _ := nodeMap["target"].(string)
+	findings := Normalize(scanner, []byte(output))
+	for i := range findings {
+		if findings[i].Target == "" {
+			findings[i].Target = target
+		}
+	}
+	nodeMap["findings"] = findings
+	return nodeMap
+}
+
+// nodeFindings returns the normalized findings of a node result, normalizing the raw
+// output of results stored before findings were recorded
+func nodeFindings(nodeMap map[string]interface{}) []Finding {
+	scanner, _ := nodeMap["scanner"].(string)
+	if scanner == "" {
+		return nil
+	}
+
+	switch stored := nodeMap["findings"].(type) {
+	case []Finding:
+		return stored
+	case []interface{}:
+		// Results read back from the database decode as generic JSON
+		var findings []Finding
+		if data, err := json.Marshal(stored); err == nil && json.Unmarshal(data, &findings) == nil {
+			return findings
+		}
+	}
+
+	output, _ := nodeMap["output"].(string)
+	return Normalize(scanner, []byte(output))
+}
+
+func parseNmapFindings(scanner string, raw []byte) []Finding {
+	var findings []Finding
+	for _, line := range strings.Split(string(raw), "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if !nmapOpenPortPattern.MatchString(line) {
+			continue
+		}
+		port := strings.Fields(line)[0]
+		findings = append(findings, Finding{
+			Key:      scanner + ": " + line,
+			Scanner:  scanner,
+			Title:    "Open port " + line,
+			Severity: ScannerSeverity(scanner, ""),
+			Path:     port,
+		})
+	}
+	return findings
+}
+
+// parseNiktoFindings reads nikto's JSON report, whose items are plain messages in
+// older versions and {id, url, msg} objects in newer ones
+func parseNiktoFindings(scanner string, raw []byte) []Finding {
+	var report struct {
+		Host            string        `json:"host"`
+		Vulnerabilities []interface{} `json:"vulnerabilities"`
+	}
+	if json.Unmarshal(raw, &report) != nil {
+		return nil
+	}
+
+	var findings []Finding
+	for _, item := range report.Vulnerabilities {
+		switch v := item.(type) {
+		case string:
+			findings = append(findings, Finding{
+				Key:      scanner + ": " + v,
+				Scanner:  scanner,
+				Title:    v,
+				Severity: ScannerSeverity(scanner, ""),
+				Target:   report.Host,
+			})
+		case map[string]interface{}:
+			id, _ := v["id"].(string)
+			url, _ := v["url"].(string)
+			msg, _ := v["msg"].(string)
+			findings = append(findings, Finding{
+				Key:      fmt.Sprintf("%s: %s %s", scanner, id, url),
+				Scanner:  scanner,
+				RuleID:   id,
+				Title:    msg,
+				Severity: ScannerSeverity(scanner, ""),
+				Target:   report.Host,
+				Path:     url,
+			})
+		}
+	}
+	return findings
+}
+
+func parseGobusterFindings(scanner string, raw []byte) []Finding {
+	var findings []Finding
+	for _, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.Contains(line, "(Status:") {
+			continue
+		}
+		findings = append(findings, Finding{
+			Key:      scanner + ": " + line,
+			Scanner:  scanner,
+			Title:    "Discovered path " + line,
+			Severity: ScannerSeverity(scanner, ""),
+			Path:     strings.Fields(line)[0],
+		})
+	}
+	return findings
+}
+
+// parseSqlmapFindings reports one finding per injectable parameter in sqlmap's summary
+func parseSqlmapFindings(scanner string, raw []byte) []Finding {
+	var findings []Finding
+	for _, line := range strings.Split(string(raw), "\n") {
+		match := sqlmapParameterPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		findings = append(findings, Finding{
+			Key:      fmt.Sprintf("%s: %s (%s)", scanner, match[1], match[2]),
+			Scanner:  scanner,
+			Title:    fmt.Sprintf("SQL injection in %s parameter %s", match[2], match[1]),
+			Severity: ScannerSeverity(scanner, ""),
+		})
+	}
+	return findings
+}
+
+// parseWpscanFindings reports each vulnerability title in wpscan's CLI output
+func parseWpscanFindings(scanner string, raw []byte) []Finding {
+	var findings []Finding
+	for _, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "[!] Title:") {
+			continue
+		}
+		title := strings.TrimSpace(strings.TrimPrefix(line, "[!] Title:"))
+		findings = append(findings, Finding{
+			Key:      scanner + ": " + title,
+			Scanner:  scanner,
+			Title:    title,
+			Severity: ScannerSeverity(scanner, ""),
+		})
+	}
+	return findings
+}
+
+// zapReport is the JSON report of ZAP's packaged scans
+type zapReport struct {
+	Sites []zapSite `json:"site"`
+}
+
+type zapSite struct {
+	Name   string     `json:"@name"`
+	Alerts []zapAlert `json:"alerts"`
+}
+
+type zapAlert struct {
+	PluginID    string        `json:"pluginid"`
+	Name        string        `json:"name"`
+	RiskCode    string        `json:"riskcode"` // 0 informational to 3 high
+	Description string        `json:"desc"`     // HTML
+	Solution    string        `json:"solution"` // HTML
+	Instances   []zapInstance `json:"instances"`
+}
+
+type zapInstance struct {
+	URI    string `json:"uri"`
+	Method string `json:"method"`
+}
+
+// htmlTagPattern matches the markup in ZAP's alert descriptions
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// zapText turns the HTML of a ZAP alert field into a single line of plain text
+func zapText(markup string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(htmlTagPattern.ReplaceAllString(markup, " "))), " ")
+}
+
+// parseZAPFindings reports one finding per alert and site in a ZAP JSON report, at the first
+// URL the alert was raised on
+func parseZAPFindings(scanner string, raw []byte) []Finding {
+	var report zapReport
+	if json.Unmarshal(raw, &report) != nil {
+		return nil
+	}
+
+	var findings []Finding
+	for _, site := range report.Sites {
+		for _, alert := range site.Alerts {
+			description := zapText(alert.Description)
+			if solution := zapText(alert.Solution); solution != "" {
+				description += "\nSolution: " + solution
+			}
+			finding := Finding{
+				Key:         fmt.Sprintf("%s: %s on %s", scanner, alert.PluginID, site.Name),
+				Scanner:     scanner,
+				RuleID:      alert.PluginID,
+				Title:       alert.Name,
+				Severity:    ScannerSeverity(scanner, alert.RiskCode),
+				Target:      site.Name,
+				Description: description,
+			}
+			if len(alert.Instances) > 0 {
+				finding.Path = alert.Instances[0].URI
+			}
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+func parseGitleaksFindings(scanner string, raw []byte) []Finding {
+	var report struct {
+		Findings []struct {
+			Rule      string `json:"rule"`
+			File      string `json:"file"`
+			StartLine int    `json:"startLine"`
+			EndLine   int    `json:"endLine"`
+			Message   string `json:"message"`
+		} `json:"findings"`
+	}
+	if json.Unmarshal(raw, &report) != nil {
+		return nil
+	}
+
+	findings := make([]Finding, 0, len(report.Findings))
+	for _, f := range report.Findings {
+		findings = append(findings, Finding{
+			Key:         fmt.Sprintf("%s: %s in %s", scanner, f.Rule, f.File),
+			Scanner:     scanner,
+			RuleID:      f.Rule,
+			Title:       "Leaked secret (" + f.Rule + ")",
+			Severity:    ScannerSeverity(scanner, ""),
+			Path:        f.File,
+			Line:        f.StartLine,
+			EndLine:     f.EndLine,
+			Description: f.Message,
+		})
+	}
+	return findings
+}
+
+func parseSemgrepFindings(scanner string, raw []byte) []Finding {
+	var report struct {
+		Results []struct {
+			CheckID string `json:"check_id"`
+			Path    string `json:"path"`
+			Start   struct {
+				Line int `json:"line"`
+			} `json:"start"`
+			End struct {
+				Line int `json:"line"`
+			} `json:"end"`
+			Extra struct {
+				Message  string `json:"message"`
+				Severity string `json:"severity"`
+			} `json:"extra"`
+		} `json:"results"`
+	}
+	if json.Unmarshal(raw, &report) != nil {
+		return nil
+	}
+
+	findings := make([]Finding, 0, len(report.Results))
+	for _, r := range report.Results {
+		findings = append(findings, Finding{
+			Key:         fmt.Sprintf("%s: %s in %s", scanner, r.CheckID, r.Path),
+			Scanner:     scanner,
+			RuleID:      r.CheckID,
+			Title:       r.CheckID,
+			Severity:    ScannerSeverity(scanner, r.Extra.Severity),
+			Path:        r.Path,
+			Line:        r.Start.Line,
+			EndLine:     r.End.Line,
+			Description: r.Extra.Message,
+		})
+	}
+	return findings
+}
+
+// parseTrivyFindings reads both the filesystem (VulnerabilityID/PkgName) and
+// image (ID/Package) vulnerability shapes
+func parseTrivyFindings(scanner string, raw []byte) []Finding {
+	var report struct {
+		Target          string `json:"Target"`
+		Image           string `json:"Image"`
+		Vulnerabilities []struct {
+			VulnerabilityID string `json:"VulnerabilityID"`
+			ID              string `json:"ID"`
+			PkgName         string `json:"PkgName"`
+			Package         string `json:"Package"`
+			Title           string `json:"Title"`
+			Description     string `json:"Description"`
+			Severity        string `json:"Severity"`
+			FixedVersion    string `json:"FixedVersion"`
+		} `json:"Vulnerabilities"`
+	}
+	if json.Unmarshal(raw, &report) != nil {
+		return nil
+	}
+
+	target := report.Target
+	if target == "" {
+		target = report.Image
+	}
+	findings := make([]Finding, 0, len(report.Vulnerabilities))
+	for _, v := range report.Vulnerabilities {
+		id := v.VulnerabilityID
+		if id == "" {
+			id = v.ID
+		}
+		pkg := v.PkgName
+		if pkg == "" {
+			pkg = v.Package
+		}
+		title := v.Title
+		if title == "" {
+			title = id + " in " + pkg
+		}
+		findings = append(findings, Finding{
+			Key:          fmt.Sprintf("%s: %s in %s", scanner, id, pkg),
+			Scanner:      scanner,
+			RuleID:       id,
+			Title:        title,
+			Severity:     ScannerSeverity(scanner, v.Severity),
+			Target:       target,
+			Path:         pkg,
+			Description:  v.Description,
+			FixedVersion: v.FixedVersion,
+		})
+	}
+	return findings
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		scanner string
+		raw     string
+		want    []Finding
+	}{
+		{
+			name:    "nikto messages and items",
+			scanner: "nikto",
+			raw: `{"host":"shop.example.com","vulnerabilities":[
+				"Server leaks inodes via ETags",
+				{"id":"999986","url":"/admin/","msg":"Admin login page found"}]}`,
+			want: []Finding{
+				{Key: "nikto: Server leaks inodes via ETags", Scanner: "nikto", Title: "Server leaks inodes via ETags", Severity: SeverityMedium, Target: "shop.example.com"},
+				{Key: "nikto: 999986 /admin/", Scanner: "nikto", RuleID: "999986", Title: "Admin login page found", Severity: SeverityMedium, Target: "shop.example.com", Path: "/admin/"},
+			},
+		},
+		{
+			name:    "trivy dependency scan",
+			scanner: "trivy-sca",
+			raw: `{"Target":"go.mod","Vulnerabilities":[
+				{"VulnerabilityID":"CVE-2023-44487","PkgName":"golang.org/x/net","FixedVersion":"0.17.0","Severity":"HIGH","Title":"HTTP/2 rapid reset","Description":"Rapid stream resets exhaust the server."},
+				{"VulnerabilityID":"CVE-2024-0001","PkgName":"example.com/lib","Severity":"UNKNOWN"}]}`,
+			want: []Finding{
+				{Key: "trivy-sca: CVE-2023-44487 in golang.org/x/net", Scanner: "trivy-sca", RuleID: "CVE-2023-44487", Title: "HTTP/2 rapid reset", Severity: SeverityHigh,
+					Target: "go.mod", Path: "golang.org/x/net", Description: "Rapid stream resets exhaust the server.", FixedVersion: "0.17.0"},
+				{Key: "trivy-sca: CVE-2024-0001 in example.com/lib", Scanner: "trivy-sca", RuleID: "CVE-2024-0001", Title: "CVE-2024-0001 in example.com/lib", Severity: SeverityMedium,
+					Target: "go.mod", Path: "example.com/lib"},
+			},
+		},
+		{
+			name:    "trivy image scan",
+			scanner: "trivy-image",
+			raw:     `{"Image":"nginx:1.25","Vulnerabilities":[{"ID":"CVE-2023-5678","Package":"openssl","FixedVersion":"3.0.13-1","Severity":"CRITICAL"}]}`,
+			want: []Finding{
+				{Key: "trivy-image: CVE-2023-5678 in openssl", Scanner: "trivy-image", RuleID: "CVE-2023-5678", Title: "CVE-2023-5678 in openssl", Severity: SeverityCritical,
+					Target: "nginx:1.25", Path: "openssl", FixedVersion: "3.0.13-1"},
+			},
+		},
+		{
+			name:    "semgrep",
+			scanner: "semgrep",
+			raw: `{"results":[{"check_id":"go.lang.security.audit.sqli","path":"store/users.go","start":{"line":12},"end":{"line":14},
+				"extra":{"message":"Query built from user input","severity":"ERROR"}}]}`,
+			want: []Finding{
+				{Key: "semgrep: go.lang.security.audit.sqli in store/users.go", Scanner: "semgrep", RuleID: "go.lang.security.audit.sqli", Title: "go.lang.security.audit.sqli",
+					Severity: SeverityHigh, Path: "store/users.go", Line: 12, EndLine: 14, Description: "Query built from user input"},
+			},
+		},
+		{
+			name:    "gitleaks",
+			scanner: "gitleaks",
+			raw:     `{"findings":[{"rule":"aws-access-key","file":"deploy.sh","startLine":4,"endLine":4,"message":"AWS access key"}]}`,
+			want: []Finding{
+				{Key: "gitleaks: aws-access-key in deploy.sh", Scanner: "gitleaks", RuleID: "aws-access-key", Title: "Leaked secret (aws-access-key)",
+					Severity: SeverityHigh, Path: "deploy.sh", Line: 4, EndLine: 4, Description: "AWS access key"},
+			},
+		},
+		{name: "no findings", scanner: "semgrep", raw: `{"results":[]}`, want: []Finding{}},
+		{name: "unparseable output", scanner: "trivy-sca", raw: "FATAL: no such file", want: []Finding{}},
+		{name: "scanner without a parser", scanner: "whois", raw: "Domain: example.com", want: []Finding{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.scanner, []byte(tt.raw)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Normalize() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithFindingsKeepsOutput(t *testing.T) {
+	output := `{"host":"shop.example.com","vulnerabilities":["Server leaks inodes via ETags"]}`
+	result := withFindings(map[string]interface{}{"scanner": "nikto", "target": "https://shop.example.com", "output": output}).(map[string]interface{})
+	if result["output"] != output {
+		t.Fatalf("raw output replaced with %v", result["output"])
+	}
+	findings, _ := result["findings"].([]Finding)
+	if len(findings) != 1 || findings[0].Target != "shop.example.com" {
+		t.Fatalf("findings = %+v, want the one nikto reported on its host", findings)
+	}
+	if got := nodeFindings(result); !reflect.DeepEqual(got, findings) {
+		t.Fatalf("nodeFindings() = %+v, want the stored findings", got)
+	}
+
+	// A finding without a target of its own takes the node's
+	result = withFindings(map[string]interface{}{"scanner": "nmap", "target": "example.com", "output": "22/tcp open ssh"}).(map[string]interface{})
+	if findings := result["findings"].([]Finding); len(findings) != 1 || findings[0].Target != "example.com" {
+		t.Fatalf("findings = %+v, want the node's target", findings)
+	}
+}
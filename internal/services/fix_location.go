@@ -0,0 +1,229 @@
+package services
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// maxEnclosingFuncLines bounds how large a function a fix window is widened to; past it the
+// whole function would cost more tokens than the extra context is worth
+const maxEnclosingFuncLines = 400
+
+// Location is a span of lines in a repository file that a finding points at.
+// StartLine and EndLine are 1-based and inclusive; zero means the scanner gave no lines.
+type Location struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+}
+
+// LocationExtractor reads the source locations out of a scanner's raw output
+type LocationExtractor func(raw []byte) []Location
+
+// locationExtractors are the scanners whose findings point into source files, by name.
+// Network scanners report hosts and URLs rather than files, so they have none.
+var locationExtractors = map[string]LocationExtractor{
+	"gitleaks":  gitleaksLocations,
+	"semgrep":   semgrepLocations,
+	"trivy-sca": trivyLocations,
+}
+
+func gitleaksLocations(raw []byte) []Location {
+	var report struct {
+		Findings []struct {
+			File      string `json:"file"`
+			StartLine int    `json:"startLine"`
+			EndLine   int    `json:"endLine"`
+		} `json:"findings"`
+	}
+	if json.Unmarshal(raw, &report) != nil {
+		return nil
+	}
+	locations := make([]Location, 0, len(report.Findings))
+	for _, f := range report.Findings {
+		locations = append(locations, newLocation(f.File, f.StartLine, f.EndLine))
+	}
+	return locations
+}
+
+func semgrepLocations(raw []byte) []Location {
+	var report struct {
+		Results []struct {
+			Path  string `json:"path"`
+			Start struct {
+				Line int `json:"line"`
+			} `json:"start"`
+			End struct {
+				Line int `json:"line"`
+			} `json:"end"`
+		} `json:"results"`
+	}
+	if json.Unmarshal(raw, &report) != nil {
+		return nil
+	}
+	locations := make([]Location, 0, len(report.Results))
+	for _, r := range report.Results {
+		locations = append(locations, newLocation(r.Path, r.Start.Line, r.End.Line))
+	}
+	return locations
+}
+
+// trivyLocations points at the manifest a vulnerable dependency was found in; trivy gives no lines
+func trivyLocations(raw []byte) []Location {
+	var report struct {
+		Target string `json:"Target"`
+	}
+	if json.Unmarshal(raw, &report) != nil || report.Target == "" {
+		return nil
+	}
+	return []Location{{Path: report.Target}}
+}
+
+// newLocation builds a location, treating a missing or inverted end as a single-line span
+func newLocation(path string, start, end int) Location {
+	if start < 0 {
+		start = 0
+	}
+	if end < start {
+		end = start
+	}
+	return Location{Path: path, StartLine: start, EndLine: end}
+}
+
+// sourceLocations returns the file locations of every source-scanner finding in node results
+func sourceLocations(results map[string]interface{}) []Location {
+	var locations []Location
+	for _, result := range results {
+		nodeMap, ok := result.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		scanner, _ := nodeMap["scanner"].(string)
+		extract, ok := locationExtractors[scanner]
+		if !ok {
+			continue
+		}
+		output, _ := nodeMap["output"].(string)
+		for _, location := range extract([]byte(output)) {
+			if location.Path != "" {
+				locations = append(locations, location)
+			}
+		}
+	}
+	return locations
+}
+
+// fixLocation picks the location an auto-fix should target: the first finding with lines
+// in path, or in any file when path is empty. It reports false when none has lines.
+func fixLocation(results map[string]interface{}, path string) (Location, bool) {
+	for _, location := range sourceLocations(results) {
+		if location.StartLine > 0 && (path == "" || location.Path == path) {
+			return location, true
+		}
+	}
+	return Location{}, false
+}
+
+// lineWindow is the part of a file sent to the AI for a surgical fix: the finding's lines plus
+// context either side. From and To index into the file's lines, To exclusive.
+type lineWindow struct {
+	From, To int
+	Lines    []string
+}
+
+// windowAround cuts the lines of location, with contextLines either side, out of content.
+// In Go files the window is widened to the whole function enclosing the finding, so the AI
+// sees its signature and every use of the variables involved. It reports false when the
+// location lies outside the file.
+func windowAround(content string, location Location, contextLines int) (lineWindow, bool) {
+	lines := strings.Split(content, "\n")
+	count := len(lines)
+	if strings.HasSuffix(content, "\n") {
+		count-- // The empty string after the final newline isn't a line
+	}
+	if location.StartLine < 1 || location.StartLine > count {
+		return lineWindow{}, false
+	}
+	endLine := location.EndLine
+	if endLine < location.StartLine {
+		endLine = location.StartLine
+	}
+
+	from := location.StartLine - 1 - contextLines
+	to := endLine + contextLines
+	if strings.HasSuffix(location.Path, ".go") {
+		if funcFrom, funcTo, ok := enclosingGoFunc(content, location.StartLine, endLine); ok {
+			from = min(from, funcFrom-1)
+			to = max(to, funcTo)
+		}
+	}
+	from = max(from, 0)
+	to = min(to, count)
+	return lineWindow{From: from, To: to, Lines: lines[from:to]}, true
+}
+
+// enclosingGoFunc returns the 1-based first and last lines of the Go function or method,
+// including its doc comment, that contains lines start to end. It reports false when the
+// source doesn't parse, the lines are outside any function, or the function is too large.
+func enclosingGoFunc(src string, start, end int) (int, int, bool) {
+	fset := token.NewFileSet()
+	// A file with syntax errors elsewhere still yields the functions that did parse, so
+	// only a missing file is a failure
+	file, _ := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if file == nil {
+		return 0, 0, false
+	}
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		first := fset.Position(fn.Pos()).Line
+		last := fset.Position(fn.End()).Line
+		if start < first || end > last {
+			continue
+		}
+		if last-first+1 > maxEnclosingFuncLines {
+			return 0, 0, false
+		}
+		if fn.Doc != nil {
+			first = fset.Position(fn.Doc.Pos()).Line
+		}
+		return first, last, true
+	}
+	return 0, 0, false
+}
+
+// spliceLines replaces the window's lines in content with replacement, leaving the rest
+// of the file byte-for-byte unchanged
+func spliceLines(content string, window lineWindow, replacement string) string {
+	lines := strings.Split(content, "\n")
+	replaced := strings.Split(strings.TrimSuffix(stripCodeFence(replacement), "\n"), "\n")
+	// Trimming the newline the model tends to end with also drops a blank line ending the window
+	if last := len(window.Lines) - 1; last >= 0 && window.Lines[last] == "" && replaced[len(replaced)-1] != "" {
+		replaced = append(replaced, "")
+	}
+
+	spliced := make([]string, 0, len(lines)-(window.To-window.From)+len(replaced))
+	spliced = append(spliced, lines[:window.From]...)
+	spliced = append(spliced, replaced...)
+	spliced = append(spliced, lines[window.To:]...)
+	return strings.Join(spliced, "\n")
+}
+
+// stripCodeFence removes a markdown code fence the model may wrap its answer in
+func stripCodeFence(s string) string {
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "```") || !strings.HasSuffix(trimmed, "```") || len(trimmed) < 6 {
+		return s
+	}
+	trimmed = strings.TrimSuffix(trimmed, "```")
+	if newline := strings.Index(trimmed, "\n"); newline >= 0 {
+		return trimmed[newline+1:]
+	}
+	return s
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const semgrepReport = `{
+  "results": [
+    {"check_id": "go.lang.security.audit.database.string-formatted-query", "path": "store/users.go", "start": {"line": 12, "col": 2}, "end": {"line": 13, "col": 40}},
+    {"check_id": "go.lang.security.audit.crypto.use-of-md5", "path": "auth/hash.go", "start": {"line": 7}, "end": {"line": 7}}
+  ]
+}`
+
+const gitleaksReport = `{
+  "findings": [
+    {"rule": "generic-api-key", "file": "config/settings.py", "startLine": 4, "endLine": 4, "secret": "sk_live_123"},
+    {"rule": "private-key", "file": "deploy/id_rsa", "startLine": 1, "endLine": 27},
+    {"rule": "generic-api-key", "file": "README.md", "startLine": 9}
+  ]
+}`
+
+func TestLocationExtractors(t *testing.T) {
+	tests := []struct {
+		name    string
+		scanner string
+		output  string
+		want    []Location
+	}{
+		{
+			name:    "semgrep",
+			scanner: "semgrep",
+			output:  semgrepReport,
+			want:    []Location{{Path: "store/users.go", StartLine: 12, EndLine: 13}, {Path: "auth/hash.go", StartLine: 7, EndLine: 7}},
+		},
+		{
+			name:    "gitleaks, with a missing end line",
+			scanner: "gitleaks",
+			output:  gitleaksReport,
+			want: []Location{
+				{Path: "config/settings.py", StartLine: 4, EndLine: 4},
+				{Path: "deploy/id_rsa", StartLine: 1, EndLine: 27},
+				{Path: "README.md", StartLine: 9, EndLine: 9},
+			},
+		},
+		{name: "trivy manifest", scanner: "trivy-sca", output: `{"Target": "go.mod", "Vulnerabilities": []}`, want: []Location{{Path: "go.mod"}}},
+		{name: "unparseable output", scanner: "semgrep", output: "semgrep: command not found", want: nil},
+		{name: "network scanner", scanner: "nmap", output: "22/tcp open ssh", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := map[string]interface{}{"scan": map[string]interface{}{"scanner": tt.scanner, "output": tt.output}}
+			if got := sourceLocations(results); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("sourceLocations() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFixLocation(t *testing.T) {
+	results := map[string]interface{}{
+		"trivy":   map[string]interface{}{"scanner": "trivy-sca", "output": `{"Target": "go.mod"}`},
+		"semgrep": map[string]interface{}{"scanner": "semgrep", "output": semgrepReport},
+	}
+	tests := []struct {
+		path   string
+		want   Location
+		wantOK bool
+	}{
+		{path: "auth/hash.go", want: Location{Path: "auth/hash.go", StartLine: 7, EndLine: 7}, wantOK: true},
+		{path: "go.mod"}, // The manifest has no lines to fix surgically
+		{path: "main.go"},
+	}
+	for _, tt := range tests {
+		got, ok := fixLocation(results, tt.path)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("fixLocation(%q) = %+v, %v, want %+v, %v", tt.path, got, ok, tt.want, tt.wantOK)
+		}
+	}
+	// Without a path the first finding with lines wins, skipping the manifest
+	want := Location{Path: "store/users.go", StartLine: 12, EndLine: 13}
+	if got, ok := fixLocation(results, ""); !ok || got != want {
+		t.Errorf("fixLocation(\"\") = %+v, %v, want %+v", got, ok, want)
+	}
+}
+
+// numberedLines returns "line 1" to "line n", one per line, ending with a newline
+func numberedLines(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	return b.String()
+}
+
+func TestWindowAround(t *testing.T) {
+	content := numberedLines(20)
+	tests := []struct {
+		name     string
+		location Location
+		context  int
+		wantFrom int
+		wantTo   int
+		wantOK   bool
+	}{
+		{name: "single line with context", location: Location{Path: "a.py", StartLine: 10, EndLine: 10}, context: 2, wantFrom: 7, wantTo: 12, wantOK: true},
+		{name: "span with context", location: Location{Path: "a.py", StartLine: 10, EndLine: 12}, context: 1, wantFrom: 8, wantTo: 13, wantOK: true},
+		{name: "clamped at the start", location: Location{Path: "a.py", StartLine: 1, EndLine: 1}, context: 5, wantFrom: 0, wantTo: 6, wantOK: true},
+		{name: "clamped at the end", location: Location{Path: "a.py", StartLine: 20, EndLine: 25}, context: 5, wantFrom: 14, wantTo: 20, wantOK: true},
+		{name: "past the end of the file", location: Location{Path: "a.py", StartLine: 21}, context: 5},
+		{name: "no line", location: Location{Path: "a.py"}, context: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, ok := windowAround(content, tt.location, tt.context)
+			if ok != tt.wantOK || window.From != tt.wantFrom || window.To != tt.wantTo {
+				t.Fatalf("windowAround() = [%d, %d), %v, want [%d, %d), %v", window.From, window.To, ok, tt.wantFrom, tt.wantTo, tt.wantOK)
+			}
+			if ok && len(window.Lines) != tt.wantTo-tt.wantFrom {
+				t.Fatalf("window has %d lines, want %d", len(window.Lines), tt.wantTo-tt.wantFrom)
+			}
+		})
+	}
+}
+
+const goSource = `package store
+
+import "fmt"
+
+// FindUser looks a user up by name
+func FindUser(db DB, name string) (*User, error) {
+	query := fmt.Sprintf("SELECT * FROM users WHERE name = '%s'", name)
+	row := db.QueryRow(query)
+	var u User
+	if err := row.Scan(&u.ID, &u.Name); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func other() {}
+`
+
+func TestWindowAroundWidensToGoFunction(t *testing.T) {
+	window, ok := windowAround(goSource, Location{Path: "store/users.go", StartLine: 7, EndLine: 7}, 1)
+	if !ok {
+		t.Fatal("location not found")
+	}
+	// Lines 5-14: the doc comment through the closing brace
+	if window.From != 4 || window.To != 14 {
+		t.Fatalf("window = [%d, %d), want [4, 14)", window.From, window.To)
+	}
+	if window.Lines[0] != "// FindUser looks a user up by name" || window.Lines[len(window.Lines)-1] != "}" {
+		t.Fatalf("window lines = %q", window.Lines)
+	}
+
+	// The same lines in a non-Go file only get the plain context
+	window, _ = windowAround(goSource, Location{Path: "store/users.txt", StartLine: 7, EndLine: 7}, 1)
+	if window.From != 5 || window.To != 8 {
+		t.Fatalf("window = [%d, %d), want [5, 8)", window.From, window.To)
+	}
+}
+
+func TestSpliceLinesAppliesFixSurgically(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		location    Location
+		context     int
+		replacement func(window lineWindow) string
+		want        string
+	}{
+		{
+			name:     "semgrep finding in Go",
+			content:  goSource,
+			location: Location{Path: "store/users.go", StartLine: 7, EndLine: 8},
+			context:  0,
+			replacement: func(window lineWindow) string {
+				fixed := strings.Join(window.Lines, "\n")
+				fixed = strings.Replace(fixed, "\tquery := fmt.Sprintf(\"SELECT * FROM users WHERE name = '%s'\", name)\n\trow := db.QueryRow(query)",
+					"\trow := db.QueryRow(\"SELECT * FROM users WHERE name = $1\", name)", 1)
+				return "```go\n" + fixed + "\n```"
+			},
+			want: strings.Replace(goSource, "\tquery := fmt.Sprintf(\"SELECT * FROM users WHERE name = '%s'\", name)\n\trow := db.QueryRow(query)",
+				"\trow := db.QueryRow(\"SELECT * FROM users WHERE name = $1\", name)", 1),
+		},
+		{
+			name:     "gitleaks finding with context",
+			content:  "import os\n\nDEBUG = False\nAPI_KEY = \"sk_live_123\"\nTIMEOUT = 30\n\nDB = \"app\"\n",
+			location: Location{Path: "config/settings.py", StartLine: 4, EndLine: 4},
+			context:  1,
+			replacement: func(window lineWindow) string {
+				return "DEBUG = False\nAPI_KEY = os.environ[\"API_KEY\"]\nTIMEOUT = 30\n"
+			},
+			want: "import os\n\nDEBUG = False\nAPI_KEY = os.environ[\"API_KEY\"]\nTIMEOUT = 30\n\nDB = \"app\"\n",
+		},
+		{
+			name:     "window ending in a blank line",
+			content:  "a\nsecret = 1\n\nb\n",
+			location: Location{Path: "x.py", StartLine: 2, EndLine: 2},
+			context:  1,
+			replacement: func(window lineWindow) string {
+				return "a\nsecret = env()\n"
+			},
+			want: "a\nsecret = env()\n\nb\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, ok := windowAround(tt.content, tt.location, tt.context)
+			if !ok {
+				t.Fatal("location not found")
+			}
+			got := spliceLines(tt.content, window, tt.replacement(window))
+			if got != tt.want {
+				t.Fatalf("spliceLines() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// fixVerificationTimeout bounds a verification, including waiting for the fix branch to be cloneable
+	fixVerificationTimeout = 10 * time.Minute
+	fixCloneAttempts       = 5
+	fixCloneRetryDelay     = 10 * time.Second
+)
+
+// githubNamePattern matches GitHub owner and repository names
+var githubNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// FixVerification is the outcome of rescanning an auto-fix branch
+type FixVerification struct {
+	Status        string                  `json:"status"` // resolved, persists, skipped or error
+	Reason        string                  `json:"reason,omitempty"`
+	Scanners      map[string]FindingDelta `json:"scanners,omitempty"`
+	CommentURL    string                  `json:"comment_url,omitempty"`
+	IssueReopened bool                    `json:"issue_reopened,omitempty"`
+}
+
+// FindingDelta compares one scanner's findings before and after a fix
+type FindingDelta struct {
+	Before     int      `json:"before"`
+	After      int      `json:"after"`
+	Persisting []string `json:"persisting,omitempty"` // Findings in the fixed file that are still reported
+}
+
+// fixTarget identifies the pull request an auto-fix opened
+type fixTarget struct {
+	token    string
+	owner    string
+	repo     string
+	branch   string
+	path     string
+	prNumber int
+}
+
+// verifyFix re-runs the code scanners that flagged the repository against the fix branch,
+// comments the before/after counts on the PR, and reopens the workflow's GitHub issue
+// if a finding in the fixed file is still reported.
+func (e *WorkflowExecutor) verifyFix(ctx context.Context, target fixTarget, previousResults map[string]interface{}) *FixVerification {
+	ctx, cancel := context.WithTimeout(ctx, fixVerificationTimeout)
+	defer cancel()
+
+	// Findings from the scanners that ran earlier in the workflow are the "before" side
+	before := make(map[string][]Finding)
+	for _, result := range previousResults {
+		nodeMap, ok := result.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		scanner, _ := nodeMap["scanner"].(string)
+		if _, ok := sourceScanners[scanner]; !ok {
+			continue
+		}
+		before[scanner] = append(before[scanner], nodeFindings(nodeMap)...)
+	}
+	if len(before) == 0 {
+		return &FixVerification{Status: "skipped", Reason: "no code scanner ran before auto-fix"}
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return &FixVerification{Status: "skipped", Reason: "git is not installed"}
+	}
+
+	dir, err := os.MkdirTemp("", "vulnpilot-fix-*")
+	if err != nil {
+		return &FixVerification{Status: "error", Reason: err.Error()}
+	}
+	defer os.RemoveAll(dir)
+
+	checkout := filepath.Join(dir, "repo")
+	if err := cloneFixBranch(ctx, target, checkout); err != nil {
+		return &FixVerification{Status: "error", Reason: err.Error()}
+	}
+
+	verification := &FixVerification{Status: "resolved", Scanners: make(map[string]FindingDelta)}
+	var skipped []string
+	for scanner, previous := range before {
+		output, err := sourceScanners[scanner](e.scannerService, ctx, checkout)
+		if errors.Is(err, ErrScannerNotInstalled) {
+			skipped = append(skipped, scanner)
+			continue
+		}
+		if err != nil {
+			return &FixVerification{Status: "error", Reason: err.Error()}
+		}
+
+		after := make(map[string]struct{})
+		current := Normalize(scanner, []byte(output))
+		for _, f := range current {
+			after[f.Key] = struct{}{}
+		}
+
+		delta := FindingDelta{Before: len(previous), After: len(current)}
+		for _, f := range previous {
+			if _, ok := after[f.Key]; ok && strings.Contains(f.Key, target.path) {
+				delta.Persisting = append(delta.Persisting, f.Key)
+			}
+		}
+		if len(delta.Persisting) > 0 {
+			verification.Status = "persists"
+		}
+		verification.Scanners[scanner] = delta
+	}
+	if len(verification.Scanners) == 0 {
+		sort.Strings(skipped)
+		return &FixVerification{Status: "skipped", Reason: "scanners not installed: " + strings.Join(skipped, ", ")}
+	}
+
+	commentURL, err := e.githubService.CreateIssueComment(ctx, target.token, target.owner, target.repo, target.prNumber, fixVerificationComment(verification))
+	if err != nil {
+		logf(ctx, "⚠️ Failed to comment fix verification on PR #%d: %v", target.prNumber, err)
+	} else {
+		verification.CommentURL = commentURL
+	}
+
+	if verification.Status == "persists" {
+		if issueNumber := workflowIssueNumber(previousResults); issueNumber > 0 {
+			if err := e.githubService.SetIssueState(ctx, target.token, target.owner, target.repo, issueNumber, "open"); err != nil {
+				logf(ctx, "⚠️ Failed to reopen issue #%d: %v", issueNumber, err)
+			} else {
+				verification.IssueReopened = true
+				body := fmt.Sprintf("The auto-fix in #%d did not resolve this vulnerability; the rescan still reports it.", target.prNumber)
+				if _, err := e.githubService.CreateIssueComment(ctx, target.token, target.owner, target.repo, issueNumber, body); err != nil {
+					logf(ctx, "⚠️ Failed to comment on issue #%d: %v", issueNumber, err)
+				}
+			}
+		}
+	}
+
+	return verification
+}
+
+// cloneFixBranch shallow-clones the fix branch, retrying while GitHub makes the new branch available
+func cloneFixBranch(ctx context.Context, target fixTarget, dir string) error {
+	var lastErr error
+	for attempt := 1; attempt <= fixCloneAttempts; attempt++ {
+		os.RemoveAll(dir)
+		err := gitClone(ctx, target.token, target.owner, target.repo, target.branch, dir)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		logf(ctx, "⏳ Fix branch %s not cloneable yet (attempt %d/%d)", target.branch, attempt, fixCloneAttempts)
+
+		if attempt < fixCloneAttempts {
+			if err := sleepContext(ctx, fixCloneRetryDelay); err != nil {
+				return err
+			}
+		}
+	}
+	return lastErr
+}
+
+// fixVerificationComment renders the PR comment for a verification
+func fixVerificationComment(v *FixVerification) string {
+	var b strings.Builder
+	if v.Status == "persists" {
+		b.WriteString("### ❌ Fix verification: vulnerability still present\n\n")
+	} else {
+		b.WriteString("### ✅ Fix verification: vulnerability resolved\n\n")
+	}
+	b.WriteString("| Scanner | Before | After |\n|---|---|---|\n")
+
+	scanners := make([]string, 0, len(v.Scanners))
+	for scanner := range v.Scanners {
+		scanners = append(scanners, scanner)
+	}
+	sort.Strings(scanners)
+	for _, scanner := range scanners {
+		delta := v.Scanners[scanner]
+		fmt.Fprintf(&b, "| %s | %d | %d |\n", scanner, delta.Before, delta.After)
+	}
+	for _, scanner := range scanners {
+		for _, key := range v.Scanners[scanner].Persisting {
+			fmt.Fprintf(&b, "\n- Still reported: `%s`", key)
+		}
+	}
+	b.WriteString("\n\n*Rescanned by VulnPilot*")
+	return b.String()
+}
+
+// workflowIssueNumber returns the number of the GitHub issue created earlier in the workflow, if any
+func workflowIssueNumber(previousResults map[string]interface{}) int {
+	for _, result := range previousResults {
+		nodeMap, ok := result.(map[string]interface{})
+		if !ok || nodeMap["type"] != "github-issue" {
+			continue
+		}
+		switch n := nodeMap["issue_number"].(type) {
+		case int:
+			return n
+		case float64: // decoded from a persisted result
+			return int(n)
+		}
+	}
+	return 0
+}
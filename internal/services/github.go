@@ -9,13 +9,16 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/datmedevil17/go-vuln/internal/config"
 	"github.com/datmedevil17/go-vuln/internal/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type GitHubService struct {
-	db *gorm.DB
+	db       *gorm.DB
+	breakers *CircuitBreakers
+	config   config.GitHubConfig
 }
 
 type GitHubRepo struct {
@@ -48,8 +51,8 @@ type GitHubIssue struct {
 	State   string `json:"state"`
 }
 
-func NewGitHubService(db *gorm.DB) *GitHubService {
-	return &GitHubService{db: db}
+func NewGitHubService(db *gorm.DB, breakers *CircuitBreakers, cfg config.GitHubConfig) *GitHubService {
+	return &GitHubService{db: db, breakers: breakers, config: cfg}
 }
 
 // ListRepositories fetches all repositories from GitHub API and syncs them to DB
@@ -67,8 +70,7 @@ func (s *GitHubService) ListRepositories(ctx context.Context, accessToken string
 		req.Header.Set("Authorization", "Bearer "+accessToken)
 		req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
+		resp, err := s.doRequest(req)
 		if err != nil {
 			return nil, err
 		}
@@ -136,8 +138,7 @@ func (s *GitHubService) GetRepositoryFiles(ctx context.Context, accessToken, own
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.doRequest(req)
 	if err != nil {
 		return nil, err
 	}
@@ -155,6 +156,34 @@ func (s *GitHubService) GetRepositoryFiles(ctx context.Context, accessToken, own
 	return files, nil
 }
 
+// GetRepository fetches a single repository's metadata
+func (s *GitHubService) GetRepository(ctx context.Context, accessToken, owner, repo string) (*GitHubRepo, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s", owner, repo)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get repository: %s", resp.Status)
+	}
+
+	var repository GitHubRepo
+	if err := json.NewDecoder(resp.Body).Decode(&repository); err != nil {
+		return nil, err
+	}
+	return &repository, nil
+}
+
 // GetFileContent fetches content of a specific file
 func (s *GitHubService) GetFileContent(ctx context.Context, accessToken, owner, repo, path string) (string, error) {
 	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s", owner, repo, path)
@@ -166,8 +195,7 @@ func (s *GitHubService) GetFileContent(ctx context.Context, accessToken, owner,
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Accept", "application/vnd.github.v3.raw")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.doRequest(req)
 	if err != nil {
 		return "", err
 	}
@@ -204,8 +232,7 @@ func (s *GitHubService) CreateIssue(ctx context.Context, accessToken, owner, rep
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.doRequest(req)
 	if err != nil {
 		return nil, err
 	}
@@ -255,6 +282,8 @@ type GitHubRef struct {
 type GitHubPR struct {
 	Number  int    `json:"number"`
 	HTMLURL string `json:"html_url"`
+	State   string `json:"state"`  // open or closed
+	Merged  bool   `json:"merged"` // Only reported when fetching a single pull request
 }
 
 // Methods
@@ -269,8 +298,7 @@ func (s *GitHubService) GetReference(ctx context.Context, accessToken, owner, re
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.doRequest(req)
 	if err != nil {
 		return nil, err
 	}
@@ -303,8 +331,7 @@ func (s *GitHubService) CreateBranch(ctx context.Context, accessToken, owner, re
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.doRequest(req)
 	if err != nil {
 		return err
 	}
@@ -327,8 +354,7 @@ func (s *GitHubService) GetFileSHA(ctx context.Context, accessToken, owner, repo
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.doRequest(req)
 	if err != nil {
 		return "", err
 	}
@@ -371,8 +397,7 @@ func (s *GitHubService) UpdateFile(ctx context.Context, accessToken, owner, repo
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.doRequest(req)
 	if err != nil {
 		return err
 	}
@@ -404,8 +429,7 @@ func (s *GitHubService) CreatePullRequest(ctx context.Context, accessToken, owne
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.doRequest(req)
 	if err != nil {
 		return nil, err
 	}
@@ -422,3 +446,138 @@ func (s *GitHubService) CreatePullRequest(ctx context.Context, accessToken, owne
 	}
 	return &pr, nil
 }
+
+// CreateIssueComment comments on an issue or pull request
+func (s *GitHubService) CreateIssueComment(ctx context.Context, accessToken, owner, repo string, number int, body string) (string, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues/%d/comments", owner, repo, number)
+
+	jsonData, _ := json.Marshal(map[string]string{"body": body})
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.doRequest(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to create comment: %s - %s", resp.Status, string(body))
+	}
+
+	var comment struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&comment); err != nil {
+		return "", err
+	}
+	return comment.HTMLURL, nil
+}
+
+// SetIssueState opens or closes an issue
+func (s *GitHubService) SetIssueState(ctx context.Context, accessToken, owner, repo string, number int, state string) error {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues/%d", owner, repo, number)
+
+	jsonData, _ := json.Marshal(map[string]string{"state": state})
+	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.doRequest(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to update issue: %s - %s", resp.Status, string(body))
+	}
+	return nil
+}
+
+// GetPullRequest fetches a pull request, including whether it has been merged
+func (s *GitHubService) GetPullRequest(ctx context.Context, accessToken, owner, repo string, number int) (*GitHubPR, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls/%d", owner, repo, number)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get PR: %s", resp.Status)
+	}
+
+	var pr GitHubPR
+	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// DeleteBranch deletes a branch; a branch that is already gone is not an error
+func (s *GitHubService) DeleteBranch(ctx context.Context, accessToken, owner, repo, branch string) error {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/refs/heads/%s", owner, repo, branch)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.doRequest(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// GitHub answers 422 "Reference does not exist" for a deleted branch
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound && resp.StatusCode != http.StatusUnprocessableEntity {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete branch: %s - %s", resp.Status, string(body))
+	}
+	return nil
+}
+
+// GetFileContentAt fetches the raw content of a file as of a branch, tag or commit
+func (s *GitHubService) GetFileContentAt(ctx context.Context, accessToken, owner, repo, path, ref string) (string, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s?ref=%s", owner, repo, path, ref)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/vnd.github.v3.raw")
+
+	resp, err := s.doRequest(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get file content: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
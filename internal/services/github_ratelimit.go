@@ -0,0 +1,96 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// githubSecondaryLimitWait is how long to back off from a secondary rate limit that doesn't
+// say when to retry, as GitHub's documentation advises
+const githubSecondaryLimitWait = time.Minute
+
+// ErrRateLimited is returned when GitHub keeps rate limiting a call after every retry, or asks
+// for a longer wait than GITHUB_RATE_LIMIT_MAX_WAIT
+var ErrRateLimited = errors.New("GitHub API rate limit exceeded")
+
+// doRequest sends a GitHub API request through the circuit breaker. A rate-limited response
+// is retried once the limit resets, up to the configured number of times, rather than being
+// returned to the caller.
+func (s *GitHubService) doRequest(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := s.breakers.GitHub.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		wait, limited := githubRateLimitWait(resp, time.Now())
+		if !limited {
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if attempt >= s.config.RateLimitRetries {
+			return nil, fmt.Errorf("%w: gave up on %s %s after %d retries", ErrRateLimited, req.Method, req.URL.Path, attempt)
+		}
+		if wait > s.config.RateLimitMaxWait {
+			return nil, fmt.Errorf("%w: %s %s is limited for another %s", ErrRateLimited, req.Method, req.URL.Path, wait.Round(time.Second))
+		}
+		logf(req.Context(), "⏳ GitHub rate limit hit on %s %s, retrying in %s", req.Method, req.URL.Path, wait.Round(time.Second))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+
+		// The body was consumed by the first attempt, so requests that have one are rebuilt
+		retry := req.Clone(req.Context())
+		if req.GetBody != nil {
+			if retry.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		req = retry
+	}
+}
+
+// githubRateLimitWait reports whether resp is a GitHub rate limit rejection and, if so, how
+// long to wait before retrying: Retry-After when given, otherwise until X-RateLimit-Reset
+// for an exhausted primary limit, otherwise a minute for a secondary limit. The body of a
+// 403 is read to tell a secondary limit from a permission error.
+func githubRateLimitWait(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			// A second past the reset allows for clock skew between us and GitHub
+			return max(time.Unix(reset, 0).Sub(now)+time.Second, 0), true
+		}
+		return githubSecondaryLimitWait, true
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return githubSecondaryLimitWait, true
+	}
+
+	// Secondary limits may come as a bare 403, told apart from a permission error by the
+	// message. The body is put back for callers reporting the error.
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if bytes.Contains(bytes.ToLower(body), []byte("rate limit")) {
+		return githubSecondaryLimitWait, true
+	}
+	return 0, false
+}
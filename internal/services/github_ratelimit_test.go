@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+)
+
+// fakeGitHub returns a GitHubService whose API calls go to handler
+func fakeGitHub(t *testing.T, cfg config.GitHubConfig, handler http.HandlerFunc) *GitHubService {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	target, _ := url.Parse(server.URL)
+	client := &http.Client{Timeout: 5 * time.Second, Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+	return NewGitHubService(nil, NewCircuitBreakers(5, time.Minute, client), cfg)
+}
+
+func TestGitHubRetriesOnceLimitResets(t *testing.T) {
+	var titles []string
+	s := fakeGitHub(t, config.GitHubConfig{RateLimitRetries: 2, RateLimitMaxWait: time.Minute}, func(w http.ResponseWriter, r *http.Request) {
+		var issue GitHubIssueRequest
+		json.NewDecoder(r.Body).Decode(&issue)
+		titles = append(titles, issue.Title)
+		if len(titles) == 1 {
+			// The limit has already reset by the time the retry waits for it
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix()-1, 10))
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, `{"message":"API rate limit exceeded"}`)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"number":7,"title":"SQL injection"}`)
+	})
+
+	issue, err := s.CreateIssue(context.Background(), "gho_token", "acme", "api", "SQL injection", "details")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issue.Number != 7 {
+		t.Fatalf("issue = %+v, want the retried call's", issue)
+	}
+	// The retry resent the request body the first attempt consumed
+	if strings.Join(titles, ",") != "SQL injection,SQL injection" {
+		t.Fatalf("GitHub was sent titles %q, want the issue twice", titles)
+	}
+}
+
+func TestGitHubRateLimitGivesUp(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		wantCalls  int
+	}{
+		{name: "retries exhausted", retryAfter: "0", wantCalls: 3},
+		{name: "reset too far off", retryAfter: "3600", wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			s := fakeGitHub(t, config.GitHubConfig{RateLimitRetries: 2, RateLimitMaxWait: time.Minute}, func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.Header().Set("Retry-After", tt.retryAfter)
+				w.WriteHeader(http.StatusTooManyRequests)
+			})
+
+			_, err := s.GetFileContent(context.Background(), "gho_token", "acme", "api", "main.go")
+			if !errors.Is(err, ErrRateLimited) {
+				t.Fatalf("GetFileContent() error = %v, want ErrRateLimited", err)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("GitHub was called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGitHubRateLimitWait(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tests := []struct {
+		name    string
+		status  int
+		headers map[string]string
+		body    string
+		want    time.Duration
+		limited bool
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "retry after", status: http.StatusForbidden, headers: map[string]string{"Retry-After": "30"}, want: 30 * time.Second, limited: true},
+		{name: "primary limit", status: http.StatusForbidden,
+			headers: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(now.Unix()+90, 10)},
+			want:    91 * time.Second, limited: true},
+		{name: "primary limit without reset", status: http.StatusForbidden, headers: map[string]string{"X-RateLimit-Remaining": "0"}, want: githubSecondaryLimitWait, limited: true},
+		{name: "bare 429", status: http.StatusTooManyRequests, want: githubSecondaryLimitWait, limited: true},
+		{name: "secondary limit", status: http.StatusForbidden, body: `{"message":"You have exceeded a secondary rate limit."}`, want: githubSecondaryLimitWait, limited: true},
+		{name: "permission error", status: http.StatusForbidden, body: `{"message":"Resource not accessible by integration"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(tt.body))}
+			for key, value := range tt.headers {
+				resp.Header.Set(key, value)
+			}
+			wait, limited := githubRateLimitWait(resp, now)
+			if wait != tt.want || limited != tt.limited {
+				t.Fatalf("githubRateLimitWait() = %s, %v, want %s, %v", wait, limited, tt.want, tt.limited)
+			}
+			// Callers reporting the error still get the whole body
+			if body, _ := io.ReadAll(resp.Body); string(body) != tt.body {
+				t.Fatalf("body left as %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+)
+
+var testGraphLimits = config.WorkflowConfig{MaxNodes: 5, MaxEdges: 4}
+
+// chainGraph returns n nodes and the edges linking them in a chain, capped at edges
+func chainGraph(n, edges int) (models.JSONArray, models.JSONArray) {
+	nodeList := make(models.JSONArray, n)
+	for i := range nodeList {
+		nodeList[i] = testNode(fmt.Sprintf("n%d", i), "test-scan")
+	}
+	edgeList := make(models.JSONArray, 0, edges)
+	for i := 0; i < edges; i++ {
+		edgeList = append(edgeList, testEdge(fmt.Sprintf("e%d", i), fmt.Sprintf("n%d", i%n), fmt.Sprintf("n%d", (i+1)%n)))
+	}
+	return nodeList, edgeList
+}
+
+func TestCheckGraphUpdateAtTheLimits(t *testing.T) {
+	atLimitNodes, atLimitEdges := chainGraph(testGraphLimits.MaxNodes, testGraphLimits.MaxEdges)
+	overNodes, _ := chainGraph(testGraphLimits.MaxNodes+1, 0)
+	_, overEdges := chainGraph(testGraphLimits.MaxNodes, testGraphLimits.MaxEdges+1)
+	existingNodes, existingEdges := chainGraph(3, 4)
+
+	tests := []struct {
+		name    string
+		updates map[string]interface{}
+		wantErr bool
+	}{
+		{name: "no graph change", updates: map[string]interface{}{"name": "renamed"}},
+		{name: "nodes at the limit", updates: map[string]interface{}{"nodes": atLimitNodes, "edges": atLimitEdges}},
+		{name: "one node too many", updates: map[string]interface{}{"nodes": overNodes, "edges": models.JSONArray{}}, wantErr: true},
+		{name: "one edge too many", updates: map[string]interface{}{"nodes": atLimitNodes, "edges": overEdges}, wantErr: true},
+		// The stored workflow already has the maximum 4 edges
+		{name: "nodes only, stored edges at the limit", updates: map[string]interface{}{"nodes": atLimitNodes}},
+		{name: "edges only, over the limit", updates: map[string]interface{}{"edges": overEdges}, wantErr: true},
+	}
+	service := &WorkflowService{limits: testGraphLimits}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := &models.Workflow{Nodes: existingNodes, Edges: existingEdges}
+			err := service.checkGraphUpdate(workflow, tt.updates)
+			if tt.wantErr != errors.Is(err, ErrWorkflowTooLarge) || (!tt.wantErr && err != nil) {
+				t.Fatalf("checkGraphUpdate() = %v, want ErrWorkflowTooLarge %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateWorkflowRejectsOversizedGraph(t *testing.T) {
+	db, mock := newMockDB(t)
+	service := &WorkflowService{db: db, limits: testGraphLimits}
+	workflowID, userID := uuid.New(), uuid.New()
+	mock.ExpectQuery(`SELECT \* FROM "workflows" WHERE id = \$1 AND user_id = \$2`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "nodes", "edges"}).AddRow(workflowID, userID, []byte(`[]`), []byte(`[]`)))
+
+	// Nothing is written: the mock fails the test on any statement after the lookup
+	nodes, _ := chainGraph(testGraphLimits.MaxNodes+1, 0)
+	_, err := service.UpdateWorkflow(workflowID, userID, map[string]interface{}{"nodes": nodes})
+	if !errors.Is(err, ErrWorkflowTooLarge) {
+		t.Fatalf("UpdateWorkflow() = %v, want ErrWorkflowTooLarge", err)
+	}
+	if want := "workflow too large: 6 nodes exceeds the limit of 5"; err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+}
+
+func TestTopologicalOrderPathologicalGraphs(t *testing.T) {
+	const n = 20000
+
+	// A long chain listed backwards, with every edge repeated
+	chain := make([]WorkflowNode, n)
+	for i := range chain {
+		chain[n-1-i] = WorkflowNode{ID: fmt.Sprintf("n%d", i)}
+	}
+	var chainEdges []WorkflowEdge
+	for i := 0; i+1 < n; i++ {
+		edge := WorkflowEdge{Source: fmt.Sprintf("n%d", i), Target: fmt.Sprintf("n%d", i+1)}
+		chainEdges = append(chainEdges, edge, edge)
+	}
+
+	// One node fanning out to every other, and every other fanning in to a sink
+	fan := []WorkflowNode{{ID: "source"}, {ID: "sink"}}
+	var fanEdges []WorkflowEdge
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("f%d", i)
+		fan = append(fan, WorkflowNode{ID: id})
+		fanEdges = append(fanEdges, WorkflowEdge{Source: "source", Target: id}, WorkflowEdge{Source: id, Target: "sink"})
+	}
+
+	tests := []struct {
+		name      string
+		nodes     []WorkflowNode
+		edges     []WorkflowEdge
+		wantFirst string
+		wantLast  string
+		wantErr   string
+	}{
+		{name: "long chain", nodes: chain, edges: chainEdges, wantFirst: "n0", wantLast: fmt.Sprintf("n%d", n-1)},
+		{name: "wide fan", nodes: fan, edges: fanEdges, wantFirst: "source", wantLast: "sink"},
+		{name: "chain closed into a cycle", nodes: chain, edges: append(chainEdges[:len(chainEdges):len(chainEdges)], WorkflowEdge{Source: fmt.Sprintf("n%d", n-1), Target: "n0"}), wantErr: "workflow contains cycles"},
+		{name: "self loop", nodes: []WorkflowNode{{ID: "a"}}, edges: []WorkflowEdge{{Source: "a", Target: "a"}}, wantErr: "workflow contains cycles"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			order, err := topologicalOrder(tt.nodes, tt.edges)
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("sorting took %s", elapsed)
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("topologicalOrder() = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(order) != len(tt.nodes) || order[0] != tt.wantFirst || order[len(order)-1] != tt.wantLast {
+				t.Fatalf("order has %d nodes from %s to %s, want %d from %s to %s",
+					len(order), order[0], order[len(order)-1], len(tt.nodes), tt.wantFirst, tt.wantLast)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/utils"
+)
+
+const (
+	// jiraTimeout bounds one call to a Jira site
+	jiraTimeout = 30 * time.Second
+	// jiraIssueType is the issue type findings are filed as; every Jira project template has it
+	jiraIssueType = "Task"
+)
+
+// JiraService files issues in Jira Cloud. Sites are user-supplied, so calls go through the
+// SSRF-safe client.
+type JiraService struct {
+	client *http.Client
+}
+
+// JiraIssue is a created Jira issue
+type JiraIssue struct {
+	ID   string `json:"id"`
+	Key  string `json:"key"`
+	Self string `json:"self"`
+	URL  string `json:"-"` // Browse link, built from the site URL and key
+}
+
+// jiraErrorResponse is the body of a rejected Jira REST call
+type jiraErrorResponse struct {
+	ErrorMessages []string          `json:"errorMessages"`
+	Errors        map[string]string `json:"errors"`
+}
+
+// adfNode is a node of the Atlassian Document Format that v3 descriptions are written in
+type adfNode struct {
+	Type    string                 `json:"type"`
+	Version int                    `json:"version,omitempty"`
+	Text    string                 `json:"text,omitempty"`
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+	Content []adfNode              `json:"content,omitempty"`
+}
+
+func NewJiraService() *JiraService {
+	return &JiraService{client: utils.NewSafeHTTPClient(jiraTimeout)}
+}
+
+// CreateIssue files an issue in the project through Jira's REST v3 API, authenticating with
+// the account's email and API token. The plain-text description is converted to ADF.
+func (s *JiraService) CreateIssue(ctx context.Context, baseURL, email, apiToken, projectKey, summary, description string) (*JiraIssue, error) {
+	site, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil || site.Scheme != "https" || site.Host == "" {
+		return nil, fmt.Errorf("jira base_url must be an https URL such as https://example.atlassian.net")
+	}
+
+	reqBody := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": projectKey},
+			"summary":     summary,
+			"issuetype":   map[string]string{"name": jiraIssueType},
+			"description": plainTextToADF(description),
+		},
+	}
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", site.String()+"/rest/api/3/issue", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(email, apiToken)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("failed to create jira issue: %s - %s", resp.Status, jiraErrorMessage(body))
+	}
+	return parseJiraIssue(body, site.String())
+}
+
+// parseJiraIssue reads the response to an issue creation, adding the issue's browse URL
+func parseJiraIssue(body []byte, baseURL string) (*JiraIssue, error) {
+	var issue JiraIssue
+	if err := json.Unmarshal(body, &issue); err != nil {
+		return nil, fmt.Errorf("invalid jira response: %v", err)
+	}
+	if issue.Key == "" {
+		return nil, fmt.Errorf("invalid jira response: no issue key")
+	}
+	issue.URL = strings.TrimRight(baseURL, "/") + "/browse/" + url.PathEscape(issue.Key)
+	return &issue, nil
+}
+
+// jiraErrorMessage flattens Jira's error body into one line, falling back to the raw body
+func jiraErrorMessage(body []byte) string {
+	var jiraErr jiraErrorResponse
+	if json.Unmarshal(body, &jiraErr) != nil {
+		return strings.TrimSpace(string(body))
+	}
+	messages := append([]string(nil), jiraErr.ErrorMessages...)
+	fields := make([]string, 0, len(jiraErr.Errors))
+	for field := range jiraErr.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		messages = append(messages, fmt.Sprintf("%s: %s", field, jiraErr.Errors[field]))
+	}
+	if len(messages) == 0 {
+		return strings.TrimSpace(string(body))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// plainTextToADF converts text to an ADF document: blank lines separate paragraphs, markdown
+// headings become headings, ``` fences become code blocks and other line breaks are kept
+func plainTextToADF(text string) adfNode {
+	doc := adfNode{Type: "doc", Version: 1, Content: []adfNode{}}
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		var content []adfNode
+		for i, line := range paragraph {
+			if i > 0 {
+				content = append(content, adfNode{Type: "hardBreak"})
+			}
+			if line != "" {
+				content = append(content, adfNode{Type: "text", Text: line})
+			}
+		}
+		doc.Content = append(doc.Content, adfNode{Type: "paragraph", Content: content})
+		paragraph = nil
+	}
+
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, strings.TrimRight(lines[i], "\r"))
+			}
+			block := adfNode{Type: "codeBlock"}
+			if joined := strings.Join(code, "\n"); joined != "" {
+				block.Content = []adfNode{{Type: "text", Text: joined}}
+			}
+			doc.Content = append(doc.Content, block)
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "#"):
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			heading := strings.TrimSpace(trimmed[level:])
+			if level > 6 || heading == "" || trimmed[level] != ' ' {
+				paragraph = append(paragraph, line)
+				continue
+			}
+			flush()
+			doc.Content = append(doc.Content, adfNode{
+				Type:    "heading",
+				Attrs:   map[string]interface{}{"level": level},
+				Content: []adfNode{{Type: "text", Text: heading}},
+			})
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flush()
+	return doc
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseJiraIssue(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		baseURL string
+		want    *JiraIssue
+	}{
+		{
+			name:    "created",
+			body:    `{"id":"10042","key":"SEC-17","self":"https://acme.atlassian.net/rest/api/3/issue/10042"}`,
+			baseURL: "https://acme.atlassian.net/",
+			want:    &JiraIssue{ID: "10042", Key: "SEC-17", Self: "https://acme.atlassian.net/rest/api/3/issue/10042", URL: "https://acme.atlassian.net/browse/SEC-17"},
+		},
+		{name: "no key", body: `{"id":"10042"}`, baseURL: "https://acme.atlassian.net"},
+		{name: "not json", body: `<html>Service Unavailable</html>`, baseURL: "https://acme.atlassian.net"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJiraIssue([]byte(tt.body), tt.baseURL)
+			if (err != nil) != (tt.want == nil) || !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseJiraIssue() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestJiraErrorMessage(t *testing.T) {
+	tests := map[string]string{
+		`{"errorMessages":["Issue does not exist"],"errors":{}}`:                                         "Issue does not exist",
+		`{"errorMessages":[],"errors":{"summary":"Field is required.","project":"Project is invalid."}}`: "project: Project is invalid.; summary: Field is required.",
+		`{}`:                 "{}",
+		`Unauthorized (401)`: "Unauthorized (401)",
+	}
+	for body, want := range tests {
+		if got := jiraErrorMessage([]byte(body)); got != want {
+			t.Errorf("jiraErrorMessage(%s) = %q, want %q", body, got, want)
+		}
+	}
+}
+
+func TestPlainTextToADF(t *testing.T) {
+	doc := plainTextToADF("## Findings\nPort 22 open\nPort 80 open\n\n```\nnmap -sV example.com\n```")
+	var types []string
+	for _, node := range doc.Content {
+		types = append(types, node.Type)
+	}
+	if doc.Type != "doc" || doc.Version != 1 || !reflect.DeepEqual(types, []string{"heading", "paragraph", "codeBlock"}) {
+		t.Fatalf("document %s v%d has %q", doc.Type, doc.Version, types)
+	}
+	if level := doc.Content[0].Attrs["level"]; level != 2 {
+		t.Errorf("heading level = %v, want 2", level)
+	}
+	if lines := doc.Content[1].Content; len(lines) != 3 || lines[1].Type != "hardBreak" {
+		t.Errorf("paragraph = %+v, want two lines split by a hard break", lines)
+	}
+	if code := doc.Content[2].Content[0].Text; code != "nmap -sV example.com" {
+		t.Errorf("code block = %q", code)
+	}
+}
+
+func TestJiraCreateIssue(t *testing.T) {
+	var fields map[string]interface{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if email, token, _ := r.BasicAuth(); r.URL.Path != "/rest/api/3/issue" || email != "bot@acme.com" || token != "jira-token" {
+			t.Errorf("request to %s as %s:%s", r.URL.Path, email, token)
+		}
+		var body struct {
+			Fields map[string]interface{} `json:"fields"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		fields = body.Fields
+		if fields["summary"] == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"errorMessages":[],"errors":{"summary":"You must specify a summary of the issue."}}`)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"id":"10042","key":"SEC-17"}`)
+	}))
+	defer server.Close()
+	s := &JiraService{client: server.Client()}
+
+	issue, err := s.CreateIssue(context.Background(), server.URL+"/", "bot@acme.com", "jira-token", "SEC", "Open SSH port", "Port 22 is open")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issue.Key != "SEC-17" || issue.URL != server.URL+"/browse/SEC-17" {
+		t.Fatalf("issue = %+v", issue)
+	}
+	if project := fields["project"].(map[string]interface{}); project["key"] != "SEC" {
+		t.Errorf("filed in project %v, want SEC", project)
+	}
+	if description := fields["description"].(map[string]interface{}); description["type"] != "doc" {
+		t.Errorf("description = %v, want an ADF document", description)
+	}
+
+	_, err = s.CreateIssue(context.Background(), server.URL, "bot@acme.com", "jira-token", "SEC", "", "")
+	if err == nil || !strings.HasSuffix(err.Error(), "summary: You must specify a summary of the issue.") {
+		t.Fatalf("CreateIssue() error = %v, want Jira's reason", err)
+	}
+	if _, err := s.CreateIssue(context.Background(), "http://acme.atlassian.net", "bot@acme.com", "jira-token", "SEC", "Open SSH port", ""); err == nil {
+		t.Error("filed an issue on a site that isn't https")
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultLanguage is used for AI output when neither the node nor the user chose one
+const DefaultLanguage = "en"
+
+// ErrUnsupportedLanguage is returned for locales outside SupportedLanguages
+var ErrUnsupportedLanguage = errors.New("unsupported language")
+
+// SupportedLanguages maps locale codes accepted for AI output to the language name given to the model
+var SupportedLanguages = map[string]string{
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"de": "German",
+	"it": "Italian",
+	"pt": "Portuguese",
+	"nl": "Dutch",
+	"pl": "Polish",
+	"ru": "Russian",
+	"tr": "Turkish",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"zh": "Chinese (Simplified)",
+	"hi": "Hindi",
+	"ar": "Arabic",
+}
+
+// NormalizeLanguage validates a locale such as "pt-BR" or "fr" and returns its base code
+func NormalizeLanguage(lang string) (string, error) {
+	code := strings.ToLower(strings.TrimSpace(lang))
+	if code == "" {
+		return DefaultLanguage, nil
+	}
+	if i := strings.IndexAny(code, "-_"); i > 0 {
+		code = code[:i]
+	}
+	if _, ok := SupportedLanguages[code]; !ok {
+		return "", fmt.Errorf("%w %q (supported: %s)", ErrUnsupportedLanguage, lang, strings.Join(supportedLanguageCodes(), ", "))
+	}
+	return code, nil
+}
+
+// languageInstruction returns the prompt suffix asking the model to answer in lang
+func languageInstruction(lang string) string {
+	name, ok := SupportedLanguages[lang]
+	if !ok || lang == DefaultLanguage {
+		return ""
+	}
+	return fmt.Sprintf("\n\nWrite the entire response in %s. Keep code, commands, CVE identifiers and tool names unchanged.", name)
+}
+
+func supportedLanguageCodes() []string {
+	codes := make([]string, 0, len(SupportedLanguages))
+	for code := range SupportedLanguages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+)
+
+// nodeProbeTimeout bounds the reachability check a scanner node test makes against the target
+const nodeProbeTimeout = 5 * time.Second
+
+var (
+	// ErrNodeNotFound is returned when a workflow has no node with the requested ID
+	ErrNodeNotFound = errors.New("workflow has no such node")
+
+	// ErrNodeNotTestable is returned for node types that can't be tested on their own
+	ErrNodeNotTestable = errors.New("node type can't be tested on its own; only notification and scanner nodes can")
+)
+
+// NodeTestCheck is one thing a node test verified
+type NodeTestCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// NodeTestResult reports whether a node is configured well enough to run
+type NodeTestResult struct {
+	NodeID string          `json:"node_id"`
+	Type   string          `json:"type"`
+	OK     bool            `json:"ok"`
+	Checks []NodeTestCheck `json:"checks"`
+}
+
+func (r *NodeTestResult) check(name string, err error, detail string) bool {
+	check := NodeTestCheck{Name: name, OK: err == nil, Detail: detail}
+	if err != nil {
+		check.Detail = err.Error()
+		r.OK = false
+	}
+	r.Checks = append(r.Checks, check)
+	return err == nil
+}
+
+// TestNode checks one node of a saved workflow without running the workflow. Notification
+// nodes send a sample message with the node's settings; scanner nodes resolve their profile,
+// credentials and flags and probe the trigger's target, but don't scan it.
+func (s *WorkflowService) TestNode(ctx context.Context, workflowID, userID uuid.UUID, nodeID string) (*NodeTestResult, error) {
+	workflow, err := s.GetWorkflow(workflowID, userID)
+	if err != nil {
+		return nil, err
+	}
+	nodes, _, err := s.executor.parseWorkflow(workflow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse workflow: %w", err)
+	}
+	node := s.executor.findNode(nodes, nodeID)
+	if node == nil {
+		return nil, ErrNodeNotFound
+	}
+	return s.executor.testNode(ctx, workflow, node, userID)
+}
+
+func (e *WorkflowExecutor) testNode(ctx context.Context, workflow *models.Workflow, node *WorkflowNode, userID uuid.UUID) (*NodeTestResult, error) {
+	result := &NodeTestResult{NodeID: node.ID, Type: node.Type, OK: true, Checks: []NodeTestCheck{}}
+
+	switch node.Type {
+	case "notify":
+		resolved := e.preferredChannelNode(ctx, node, userID)
+		result.check("channel", nil, "sends on "+resolved.Type)
+		e.testNotification(resolved, userID, result)
+	case "email", "slack":
+		e.testNotification(node, userID, result)
+	case "webhook":
+		statusCode, err := e.postWebhook(ctx, node, map[string]interface{}{
+			"test":    true,
+			"message": "Test delivery from VulnPilot; no scan was run.",
+			"target":  triggerTarget(workflow.Nodes),
+			"sent_at": time.Now(),
+		})
+		result.check("send", err, fmt.Sprintf("webhook returned %d", statusCode))
+	default:
+		scanner, ok := LookupScanner(node.Type)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNodeNotTestable, node.Type)
+		}
+		e.testScanner(ctx, workflow, node, scanner, userID, result)
+	}
+	return result, nil
+}
+
+// testNotification sends a sample message straight to the node's channel, bypassing the
+// dedup claim and retry queue so the result reflects this one attempt
+func (e *WorkflowExecutor) testNotification(node *WorkflowNode, userID uuid.UUID, result *NodeTestResult) {
+	if !e.notificationService.ChannelEnabled(node.Type) && !(node.Type == "slack" && getSlackWebhookURL(node) != "") {
+		result.check("channel", fmt.Errorf("%s notifications are not configured on this server", node.Type), "")
+		return
+	}
+
+	const sample = "This is a test notification from VulnPilot, sent while building a workflow. No scan was run."
+	switch node.Type {
+	case "email":
+		var user models.User
+		if err := e.db.First(&user, "id = ?", userID).Error; err != nil {
+			result.check("recipient", fmt.Errorf("user not found"), "")
+			return
+		}
+		recipient := e.getNotificationEmail(node, user.Email)
+		if !result.check("recipient", requireValue(recipient, "no recipient email provided"), recipient) {
+			return
+		}
+		err := e.notificationService.Deliver("email", recipient, "VulnPilot: Test notification", sample, nil)
+		result.check("send", err, "sent to "+recipient)
+	case "slack":
+		attachments := []Attachment{{Color: "good", Title: "Test notification", Text: sample}}
+		webhookURL := getSlackWebhookURL(node)
+		err := e.notificationService.Deliver("slack", webhookURL, "VulnPilot Test Notification", "", attachments)
+		if webhookURL != "" {
+			result.check("send", err, "sent to the node's Slack webhook")
+		} else {
+			result.check("send", err, "sent to the configured Slack webhook")
+		}
+	}
+}
+
+// testScanner runs everything a scanner node does before scanning, then checks the target
+// resolves and accepts connections
+func (e *WorkflowExecutor) testScanner(ctx context.Context, workflow *models.Workflow, node *WorkflowNode, scanner ScannerPlugin, userID uuid.UUID, result *NodeTestResult) {
+	probe := *node
+	result.check("profile", e.applyScanProfile(&probe, userID), "")
+
+	mode := ScanModeSimulated
+	if b, ok := scanner.(binaryScanner); ok {
+		mode = ScanModeMock
+		if !e.scannerService.mocked(b.Binary()) {
+			mode = ScanModeLive
+		}
+		args, err := extraArgs(&probe)
+		if err == nil {
+			err = e.scannerService.argPolicy.Check(b.Binary(), args)
+		}
+		result.check("flags", err, "")
+	}
+	result.check("binary", nil, mode)
+
+	_, err := e.resolveScanAuth(&probe, userID)
+	if err == nil && node.Type == "container-scan" {
+		_, err = e.resolveRegistryAuth(&probe, userID)
+	}
+	result.check("credentials", err, "")
+
+	needsTarget := false
+	for _, input := range scanner.Describe().Inputs {
+		needsTarget = needsTarget || input == NodeInputTarget
+	}
+	if !needsTarget {
+		return
+	}
+	target, _ := node.Data["target"].(string)
+	if target == "" {
+		target = triggerTarget(workflow.Nodes)
+	}
+	if !result.check("target", requireValue(target, "no target from the workflow's trigger node"), target) {
+		return
+	}
+	if !result.check("allowed", e.scannerService.ValidateTarget(ctx, target), "") {
+		return
+	}
+	address, err := probeAddress(target)
+	if err == nil {
+		dialer := net.Dialer{Timeout: nodeProbeTimeout}
+		var conn net.Conn
+		if conn, err = dialer.DialContext(ctx, "tcp", address); err == nil {
+			conn.Close()
+		}
+	}
+	result.check("reachable", err, address)
+}
+
+// probeAddress turns a trigger target (a URL or bare host) into a host:port to connect to
+func probeAddress(target string) (string, error) {
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+	parsed, err := url.Parse(target)
+	if err != nil || parsed.Hostname() == "" {
+		return "", fmt.Errorf("target %q has no host", target)
+	}
+	port := parsed.Port()
+	if port == "" {
+		port = "80"
+		if parsed.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(parsed.Hostname(), port), nil
+}
+
+func requireValue(value, problem string) error {
+	if value == "" {
+		return errors.New(problem)
+	}
+	return nil
+}
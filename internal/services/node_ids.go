@@ -0,0 +1,102 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrDuplicateNodeID is returned for a workflow whose nodes don't each have their own ID.
+// Results, edges and node lookups are all keyed by ID, so a repeated one silently
+// resolves to the wrong node.
+var ErrDuplicateNodeID = errors.New("workflow node IDs must be unique")
+
+// checkNodeIDs requires every node to have a non-empty ID that no other node has
+func checkNodeIDs(nodes models.JSONArray) error {
+	seen := make(map[string]bool, len(nodes))
+	for i, raw := range nodes {
+		node, _ := raw.(map[string]interface{})
+		id, _ := node["id"].(string)
+		if id == "" {
+			return fmt.Errorf("%w: node %d has no id", ErrDuplicateNodeID, i)
+		}
+		if seen[id] {
+			return fmt.Errorf("%w: %q is used by more than one node", ErrDuplicateNodeID, id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// remapNodeIDs gives every node a fresh UUID and rewrites the edges to match, so the graph
+// can be merged with others without IDs colliding. Edges get fresh IDs too, and edges whose
+// ends aren't nodes of the graph are dropped. It returns the old-to-new node ID mapping.
+// The inputs are left unchanged.
+func remapNodeIDs(nodes, edges []interface{}) ([]interface{}, []interface{}, map[string]string) {
+	mapping := make(map[string]string, len(nodes))
+	remappedNodes := make([]interface{}, 0, len(nodes))
+	for _, raw := range nodes {
+		node, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		copied := make(map[string]interface{}, len(node))
+		for k, v := range node {
+			copied[k] = v
+		}
+		newID := uuid.NewString()
+		if id, ok := node["id"].(string); ok && id != "" {
+			// A duplicate keeps its fresh ID, but edges can only follow the first node that had it
+			if _, seen := mapping[id]; !seen {
+				mapping[id] = newID
+			}
+		}
+		copied["id"] = newID
+		remappedNodes = append(remappedNodes, copied)
+	}
+
+	remappedEdges := make([]interface{}, 0, len(edges))
+	for _, raw := range edges {
+		edge, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		source, _ := edge["source"].(string)
+		target, _ := edge["target"].(string)
+		newSource, sourceOK := mapping[source]
+		newTarget, targetOK := mapping[target]
+		if !sourceOK || !targetOK {
+			continue
+		}
+		copied := make(map[string]interface{}, len(edge))
+		for k, v := range edge {
+			copied[k] = v
+		}
+		copied["id"] = uuid.NewString()
+		copied["source"] = newSource
+		copied["target"] = newTarget
+		remappedEdges = append(remappedEdges, copied)
+	}
+	return remappedNodes, remappedEdges, mapping
+}
+
+// remapWorkflowJSON applies remapNodeIDs to a {"nodes": [...], "edges": [...]} document,
+// keeping any other fields as they are
+func remapWorkflowJSON(raw string) (string, error) {
+	var workflow map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &workflow); err != nil {
+		return "", err
+	}
+	nodes, _ := workflow["nodes"].([]interface{})
+	edges, _ := workflow["edges"].([]interface{})
+	workflow["nodes"], workflow["edges"], _ = remapNodeIDs(nodes, edges)
+
+	remapped, err := json.Marshal(workflow)
+	if err != nil {
+		return "", err
+	}
+	return string(remapped), nil
+}
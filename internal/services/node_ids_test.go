@@ -0,0 +1,151 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestCheckNodeIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   models.JSONArray
+		wantErr string
+	}{
+		{name: "unique", nodes: models.JSONArray{testNode("1", "trigger"), testNode("2", "nmap")}},
+		{name: "empty graph", nodes: models.JSONArray{}},
+		{name: "duplicate", nodes: models.JSONArray{testNode("1", "trigger"), testNode("2", "nmap"), testNode("2", "nikto")},
+			wantErr: `workflow node IDs must be unique: "2" is used by more than one node`},
+		{name: "missing id", nodes: models.JSONArray{testNode("1", "trigger"), map[string]interface{}{"type": "nmap"}},
+			wantErr: "workflow node IDs must be unique: node 1 has no id"},
+		{name: "id that isn't a string", nodes: models.JSONArray{map[string]interface{}{"id": 1, "type": "nmap"}},
+			wantErr: "workflow node IDs must be unique: node 0 has no id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkNodeIDs(tt.nodes)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkNodeIDs() = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrDuplicateNodeID) || err.Error() != tt.wantErr {
+				t.Fatalf("checkNodeIDs() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateWorkflowRejectsDuplicateNodeIDs(t *testing.T) {
+	db, mock := newMockDB(t)
+	service := &WorkflowService{db: db, limits: testGraphLimits}
+	workflowID, userID := uuid.New(), uuid.New()
+	mock.ExpectQuery(`SELECT \* FROM "workflows" WHERE id = \$1 AND user_id = \$2`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "nodes", "edges"}).AddRow(workflowID, userID, []byte(`[]`), []byte(`[]`)))
+
+	// Nothing is written: the mock fails the test on any statement after the lookup
+	nodes := models.JSONArray{testNode("1", "trigger"), testNode("1", "nmap")}
+	if _, err := service.UpdateWorkflow(workflowID, userID, map[string]interface{}{"nodes": nodes}); !errors.Is(err, ErrDuplicateNodeID) {
+		t.Fatalf("UpdateWorkflow() = %v, want ErrDuplicateNodeID", err)
+	}
+}
+
+func TestRemapNodeIDs(t *testing.T) {
+	nodes := []interface{}{
+		map[string]interface{}{"id": "1", "type": "trigger", "data": map[string]interface{}{"sourceUrl": "https://example.com"}},
+		map[string]interface{}{"id": "2", "type": "nmap"},
+		map[string]interface{}{"id": "3", "type": "nikto"},
+	}
+	edges := []interface{}{
+		map[string]interface{}{"id": "e1-2", "source": "1", "target": "2", "animated": true},
+		map[string]interface{}{"id": "e2-3", "source": "2", "target": "3"},
+		map[string]interface{}{"id": "e2-9", "source": "2", "target": "9"}, // Dangling
+	}
+	original, _ := json.Marshal([]interface{}{nodes, edges})
+
+	remappedNodes, remappedEdges, mapping := remapNodeIDs(nodes, edges)
+
+	if after, _ := json.Marshal([]interface{}{nodes, edges}); string(after) != string(original) {
+		t.Fatal("remapNodeIDs changed its inputs")
+	}
+	if len(mapping) != 3 || len(remappedNodes) != 3 {
+		t.Fatalf("mapped %v onto %d nodes, want 3", mapping, len(remappedNodes))
+	}
+	seen := map[string]bool{}
+	for i, raw := range remappedNodes {
+		node := raw.(map[string]interface{})
+		id := node["id"].(string)
+		if _, err := uuid.Parse(id); err != nil || seen[id] {
+			t.Fatalf("node %d got id %q, want a fresh UUID", i, id)
+		}
+		seen[id] = true
+		old := nodes[i].(map[string]interface{})
+		if mapping[old["id"].(string)] != id || !reflect.DeepEqual(node["type"], old["type"]) || !reflect.DeepEqual(node["data"], old["data"]) {
+			t.Fatalf("node %d remapped to %v from %v", i, node, old)
+		}
+	}
+
+	if len(remappedEdges) != 2 {
+		t.Fatalf("got %d edges, want the dangling one dropped", len(remappedEdges))
+	}
+	for i, raw := range remappedEdges {
+		edge := raw.(map[string]interface{})
+		old := edges[i].(map[string]interface{})
+		if edge["source"] != mapping[old["source"].(string)] || edge["target"] != mapping[old["target"].(string)] {
+			t.Fatalf("edge %d = %v, want it to follow its nodes from %v", i, edge, old)
+		}
+		if edge["id"] == old["id"] || edge["animated"] != old["animated"] {
+			t.Fatalf("edge %d = %v from %v", i, edge, old)
+		}
+	}
+}
+
+func TestRemapNodeIDsMergesWithoutCollisions(t *testing.T) {
+	// Two generated workflows numbering their nodes the same way
+	generated := func() ([]interface{}, []interface{}) {
+		return []interface{}{testNode("1", "trigger"), testNode("2", "nmap")},
+			[]interface{}{testEdge("e1-2", "1", "2")}
+	}
+	firstNodes, firstEdges, _ := remapNodeIDs(generated())
+	secondNodes, secondEdges, _ := remapNodeIDs(generated())
+
+	merged := append(models.JSONArray(firstNodes), secondNodes...)
+	if err := checkNodeIDs(merged); err != nil {
+		t.Fatalf("merged workflows collide: %v", err)
+	}
+	if firstEdges[0].(map[string]interface{})["target"] == secondEdges[0].(map[string]interface{})["target"] {
+		t.Fatal("edges of both workflows point at the same node")
+	}
+}
+
+func TestRemapWorkflowJSON(t *testing.T) {
+	remapped, err := remapWorkflowJSON(`{"name":"recon","nodes":[{"id":"1","type":"trigger"},{"id":"1","type":"nmap"}],"edges":[{"id":"e","source":"1","target":"1"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var workflow struct {
+		Name  string                   `json:"name"`
+		Nodes []map[string]interface{} `json:"nodes"`
+		Edges []map[string]interface{} `json:"edges"`
+	}
+	if err := json.Unmarshal([]byte(remapped), &workflow); err != nil {
+		t.Fatal(err)
+	}
+	if workflow.Name != "recon" || len(workflow.Nodes) != 2 || workflow.Nodes[0]["id"] == workflow.Nodes[1]["id"] {
+		t.Fatalf("remapped to %s, want the name kept and distinct node IDs", remapped)
+	}
+	// Edges follow the first node that had a duplicated ID
+	if edge := workflow.Edges[0]; edge["source"] != workflow.Nodes[0]["id"] || edge["target"] != workflow.Nodes[0]["id"] {
+		t.Fatalf("edge = %v, want it on the first node", edge)
+	}
+
+	if _, err := remapWorkflowJSON("not json"); err == nil {
+		t.Fatal("remapped invalid JSON")
+	}
+}
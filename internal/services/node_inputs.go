@@ -0,0 +1,59 @@
+package services
+
+import "fmt"
+
+// Upstream inputs a node type can declare in NodeType.Inputs
+const (
+	NodeInputTarget     = "target"      // A target from the trigger
+	NodeInputRepository = "repository"  // A GitHub owner/repo, from node data or a github.com target
+	NodeInputSourcePath = "source-path" // A file in the repository, from node data or an upstream code scan finding
+)
+
+// nodeInputChecks resolve each input the way the node itself will, returning why it's missing
+var nodeInputChecks = map[string]func(e *WorkflowExecutor, node *WorkflowNode, previousResults map[string]interface{}) string{
+	NodeInputTarget: func(e *WorkflowExecutor, node *WorkflowNode, previousResults map[string]interface{}) string {
+		if e.getTarget(node, previousResults) == "" {
+			return "no target from upstream trigger"
+		}
+		return ""
+	},
+	NodeInputRepository: func(e *WorkflowExecutor, node *WorkflowNode, previousResults map[string]interface{}) string {
+		if owner, repo := e.nodeRepository(node, previousResults); owner == "" || repo == "" {
+			return "no GitHub repository; set owner and repo or trigger on a https://github.com/owner/repo URL"
+		}
+		return ""
+	},
+	NodeInputSourcePath: func(e *WorkflowExecutor, node *WorkflowNode, previousResults map[string]interface{}) string {
+		if path, _ := node.Data["path"].(string); path != "" {
+			return ""
+		}
+		if sourceFindingPath(previousResults) == "" {
+			return "no file to fix; set path or run a secret-scan or semgrep-scan node upstream"
+		}
+		return ""
+	},
+}
+
+// requiredInputs returns the upstream inputs the catalog declares for a node type
+func requiredInputs(nodeType string) []string {
+	if plugin, ok := LookupScanner(nodeType); ok {
+		return plugin.Describe().Inputs
+	}
+	for _, builtin := range builtinNodeTypes {
+		if builtin.Type == nodeType {
+			return builtin.Inputs
+		}
+	}
+	return nil
+}
+
+// checkNodeInputs fails fast, naming the node type and the missing input, before a node
+// runs without something it needs from upstream
+func (e *WorkflowExecutor) checkNodeInputs(node *WorkflowNode, previousResults map[string]interface{}) error {
+	for _, input := range requiredInputs(node.Type) {
+		if problem := nodeInputChecks[input](e, node, previousResults); problem != "" {
+			return fmt.Errorf("%s: %s", node.Type, problem)
+		}
+	}
+	return nil
+}
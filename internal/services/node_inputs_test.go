@@ -0,0 +1,64 @@
+package services
+
+import "testing"
+
+func TestCheckNodeInputs(t *testing.T) {
+	scanResult := map[string]interface{}{"status": "completed", "target": "https://github.com/acme/api"}
+	secretFinding := map[string]interface{}{
+		"scanner":  "gitleaks",
+		"findings": []Finding{{Key: "leak", Scanner: "gitleaks", Path: "config/keys.go"}},
+	}
+	tests := []struct {
+		name     string
+		nodeType string
+		data     map[string]interface{}
+		previous map[string]interface{}
+		upstream []string
+		wantErr  string // Exact error, "" when the inputs are there
+	}{
+		{name: "scanner without a trigger", nodeType: "nmap", wantErr: "nmap: no target from upstream trigger"},
+		{name: "scanner with a target in its data", nodeType: "nmap", data: map[string]interface{}{"target": "example.com"}},
+		{name: "scanner after a trigger", nodeType: "nikto", previous: map[string]interface{}{"trigger": scanResult}, upstream: []string{"trigger"}},
+		{name: "trigger has no inputs", nodeType: "trigger"},
+		{name: "unknown type has no inputs", nodeType: "no-such-node"},
+		{name: "jira issue without a target", nodeType: "jira-issue", wantErr: "jira-issue: no target from upstream trigger"},
+		{
+			name:     "github issue on a non-GitHub target",
+			nodeType: "github-issue",
+			previous: map[string]interface{}{"trigger": map[string]interface{}{"target": "https://example.com"}},
+			wantErr:  "github-issue: no GitHub repository; set owner and repo or trigger on a https://github.com/owner/repo URL",
+		},
+		{name: "github issue with owner and repo set", nodeType: "github-issue", data: map[string]interface{}{"target": "https://example.com", "owner": "acme", "repo": "api"}},
+		{name: "github issue on a GitHub target", nodeType: "github-issue", previous: map[string]interface{}{"trigger": scanResult}},
+		{
+			name:     "auto-fix without a file",
+			nodeType: "auto-fix",
+			previous: map[string]interface{}{"trigger": scanResult},
+			wantErr:  "auto-fix: no file to fix; set path or run a secret-scan or semgrep-scan node upstream",
+		},
+		{name: "auto-fix with a path", nodeType: "auto-fix", data: map[string]interface{}{"owner": "acme", "repo": "api", "path": "main.go"}},
+		{name: "auto-fix after a secret scan", nodeType: "auto-fix", previous: map[string]interface{}{"trigger": scanResult, "secrets": secretFinding}},
+		{
+			name:     "auto-fix reports the repository first",
+			nodeType: "auto-fix",
+			data:     map[string]interface{}{"path": "main.go"},
+			wantErr:  "auto-fix: no GitHub repository; set owner and repo or trigger on a https://github.com/owner/repo URL",
+		},
+	}
+	e := &WorkflowExecutor{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &WorkflowNode{ID: "node", Type: tt.nodeType, Data: tt.data, upstream: tt.upstream}
+			if node.Data == nil {
+				node.Data = map[string]interface{}{}
+			}
+			err := e.checkNodeInputs(node, tt.previous)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("checkNodeInputs() = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Fatalf("checkNodeInputs() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
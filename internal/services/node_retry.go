@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"regexp"
+	"time"
+)
+
+const (
+	// maxNodeRetries caps the retries a node may ask for
+	maxNodeRetries = 5
+
+	// defaultRetryBackoff is the delay before a node's first retry when it sets no retryBackoff
+	defaultRetryBackoff = time.Second
+
+	// maxRetryBackoff bounds the delay between retries as it doubles
+	maxRetryBackoff = time.Minute
+)
+
+// transientStatus matches the HTTP status lines upstream errors quote for overload, rate limits
+// and server-side failures, e.g. "503 Service Unavailable" or "429 Too Many Requests"
+var transientStatus = regexp.MustCompile(`\b(429|5\d\d) [A-Z][a-z]`)
+
+// nodeRetryPolicy is how often a node is retried after a transient failure, from retries and
+// retryBackoff (seconds) in its data
+type nodeRetryPolicy struct {
+	retries int
+	backoff time.Duration
+}
+
+func retryPolicyFor(node *WorkflowNode) nodeRetryPolicy {
+	policy := nodeRetryPolicy{backoff: defaultRetryBackoff}
+	if retries, ok := node.Data["retries"].(float64); ok && retries > 0 {
+		policy.retries = min(int(retries), maxNodeRetries)
+	}
+	if seconds, ok := node.Data["retryBackoff"].(float64); ok && seconds > 0 {
+		policy.backoff = min(time.Duration(seconds*float64(time.Second)), maxRetryBackoff)
+	}
+	return policy
+}
+
+// delay returns the wait before the given retry: the backoff doubled for each earlier retry,
+// capped, with jitter so nodes failing together don't retry in lockstep
+func (p nodeRetryPolicy) delay(retry int) time.Duration {
+	delay := p.backoff
+	for i := 1; i < retry && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxRetryBackoff)
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// withRetries calls run until it succeeds, fails with an error that isn't transient, or runs
+// out of retries, returning the attempts made. It stops waiting when ctx ends, so the node's
+// timeout and the workflow's budget cover every attempt.
+func withRetries(ctx context.Context, policy nodeRetryPolicy, run func(context.Context) (interface{}, error)) (interface{}, int, error) {
+	attempt := 1
+	for ; ; attempt++ {
+		result, err := run(ctx)
+		if err == nil || attempt > policy.retries || !isTransient(err) || ctx.Err() != nil {
+			return result, attempt, err
+		}
+		delay := policy.delay(attempt)
+		logf(ctx, "🔁 Attempt %d failed, retrying in %s: %v", attempt, delay.Round(time.Millisecond), err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return result, attempt, err
+		}
+	}
+}
+
+// isTransient reports whether err is likely to go away on its own: a network timeout, a
+// temporary DNS failure, or an upstream overload, rate limit or server error. Deterministic
+// failures, such as bad input, unknown node types or a scanner exiting with an error, aren't.
+func isTransient(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var dockerErr *dockerError
+	if errors.As(err, &dockerErr) {
+		return dockerErr.Status >= 500
+	}
+	return transientStatus.MatchString(err.Error())
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/datmedevil17/go-vuln/internal/models"
+)
+
+func init() {
+	RegisterScanner(flakyScanner{})
+}
+
+// flakyCalls counts the runs of each flakyScanner node, by data.key
+var flakyCalls sync.Map
+
+// flakyScanner is a scanner node type for executor tests that fails its first data.failures
+// runs with an upstream 503, then succeeds
+type flakyScanner struct{}
+
+func (flakyScanner) Name() string { return "test-flaky" }
+
+func (flakyScanner) Describe() NodeType {
+	return NodeType{DisplayName: "Test Flaky Scan", Category: NodeCategoryUtility}
+}
+
+func (flakyScanner) Run(ctx context.Context, env *ScanEnv, node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
+	key, _ := node.Data["key"].(string)
+	failures, _ := node.Data["failures"].(float64)
+	calls, _ := flakyCalls.LoadOrStore(key, new(int))
+	*calls.(*int)++
+	if *calls.(*int) <= int(failures) {
+		return nil, errors.New("scanner API error: 503 Service Unavailable")
+	}
+	return map[string]interface{}{"scanner": "test-flaky", "status": "completed"}, nil
+}
+
+// failingTimes returns a node function that fails with err the first failures calls, counting them
+func failingTimes(failures int, err error) (func(context.Context) (interface{}, error), *int) {
+	calls := 0
+	return func(context.Context) (interface{}, error) {
+		calls++
+		if calls <= failures {
+			return nil, err
+		}
+		return "ok", nil
+	}, &calls
+}
+
+func TestWithRetries(t *testing.T) {
+	transient := errors.New("Groq API error: 429 Too Many Requests")
+	tests := []struct {
+		name         string
+		retries      int
+		failures     int
+		err          error
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "fails twice then succeeds", retries: 3, failures: 2, err: transient, wantAttempts: 3},
+		{name: "first try", retries: 3, wantAttempts: 1},
+		{name: "retries run out", retries: 2, failures: 5, err: transient, wantAttempts: 3, wantErr: true},
+		{name: "no retries asked for", retries: 0, failures: 1, err: transient, wantAttempts: 1, wantErr: true},
+		{name: "deterministic failure", retries: 3, failures: 1, err: errors.New("unknown node type: port-knock"), wantAttempts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, calls := failingTimes(tt.failures, tt.err)
+			result, attempts, err := withRetries(context.Background(), nodeRetryPolicy{retries: tt.retries, backoff: time.Millisecond}, run)
+			if attempts != tt.wantAttempts || *calls != tt.wantAttempts || (err != nil) != tt.wantErr {
+				t.Fatalf("withRetries() = %v, %d attempts (%d calls), %v", result, attempts, *calls, err)
+			}
+			if !tt.wantErr && result != "ok" {
+				t.Fatalf("result = %v, want the successful attempt's", result)
+			}
+		})
+	}
+}
+
+func TestWithRetriesStopsWhenContextEnds(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	run, calls := failingTimes(5, errors.New("503 Service Unavailable"))
+
+	started := time.Now()
+	_, attempts, err := withRetries(ctx, nodeRetryPolicy{retries: 5, backoff: time.Minute}, run)
+	if err == nil || attempts != 1 || *calls != 1 {
+		t.Fatalf("withRetries() = %d attempts, %v, want the first failure", attempts, err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Fatalf("waited %s for a retry after the context ended", elapsed)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("Gemini API error: 503 Service Unavailable - overloaded"), true},
+		{errors.New("Groq API error: 429 Too Many Requests"), true},
+		{&net.DNSError{Err: "server misbehaving", Name: "example.com", IsTemporary: true}, true},
+		{&net.DNSError{Err: "no such host", Name: "example.com", IsNotFound: true}, false},
+		{fmt.Errorf("scan failed: %w", context.DeadlineExceeded), true},
+		{&dockerError{Status: 502, Message: "bad gateway"}, true},
+		{&dockerError{Status: 404, Message: "no such image"}, false},
+		{fmt.Errorf("%w: Groq is unavailable", ErrCircuitOpen), false},
+		{context.Canceled, false},
+		{errors.New("unknown node type: port-knock"), false},
+		{errors.New("nmap failed: exit status 1"), false},
+		{errors.New("scanned 5030 ports"), false},
+	}
+	for _, tt := range tests {
+		if got := isTransient(tt.err); got != tt.want {
+			t.Errorf("isTransient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyFor(t *testing.T) {
+	tests := []struct {
+		data map[string]interface{}
+		want nodeRetryPolicy
+	}{
+		{data: nil, want: nodeRetryPolicy{backoff: defaultRetryBackoff}},
+		{data: map[string]interface{}{"retries": 2.0, "retryBackoff": 0.5}, want: nodeRetryPolicy{retries: 2, backoff: 500 * time.Millisecond}},
+		{data: map[string]interface{}{"retries": 50.0, "retryBackoff": 3600.0}, want: nodeRetryPolicy{retries: maxNodeRetries, backoff: maxRetryBackoff}},
+		{data: map[string]interface{}{"retries": -1.0, "retryBackoff": "2"}, want: nodeRetryPolicy{backoff: defaultRetryBackoff}},
+	}
+	for _, tt := range tests {
+		if got := retryPolicyFor(&WorkflowNode{Data: tt.data}); got != tt.want {
+			t.Errorf("retryPolicyFor(%v) = %+v, want %+v", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelayBacksOff(t *testing.T) {
+	policy := nodeRetryPolicy{retries: 5, backoff: time.Second}
+	for retry, base := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 10: maxRetryBackoff} {
+		for i := 0; i < 20; i++ {
+			// Jitter keeps each delay between half and all of the doubled backoff
+			if delay := policy.delay(retry); delay < base/2 || delay > base {
+				t.Fatalf("delay(%d) = %s, want between %s and %s", retry, delay, base/2, base)
+			}
+		}
+	}
+}
+
+func TestNodeRetriesRecordAttempts(t *testing.T) {
+	e, writes := newTestExecutor(t, config.WorkflowConfig{MaxParallelNodes: 4})
+	workflow := testWorkflow(
+		models.JSONArray{
+			testNode("trigger", "trigger"),
+			dataNode("flaky", "test-flaky", map[string]interface{}{"key": t.Name(), "failures": 2.0, "retries": 3.0, "retryBackoff": 0.001}),
+		},
+		models.JSONArray{testEdge("e1", "trigger", "flaky")},
+	)
+	runTestWorkflow(t, e, workflow)
+
+	status, results := writes.finalState(t)
+	if status != "completed" {
+		t.Fatalf("execution finished %s, want completed", status)
+	}
+	flaky := results["flaky"].(map[string]interface{})
+	if flaky["status"] != "completed" || flaky["attempts"] != 3 {
+		t.Fatalf("flaky node recorded as %v, want completed on the third attempt", flaky)
+	}
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+)
+
+// What a node's failure does to the run, set per node with onError in its data
+const (
+	OnErrorFail     = "fail"     // Fail the execution (the default)
+	OnErrorSkip     = "skip"     // Record the node and every node after it as skipped
+	OnErrorContinue = "continue" // Record the node as failed, skip the nodes that depend on it and run the rest
+)
+
+// OnErrorPolicies lists the onError values a node may set
+var OnErrorPolicies = []string{OnErrorFail, OnErrorSkip, OnErrorContinue}
+
+// nodeErrorPolicy returns the node's onError policy, failing the run for unset or unknown values
+func nodeErrorPolicy(node *WorkflowNode) string {
+	switch policy, _ := node.Data["onError"].(string); policy {
+	case OnErrorSkip, OnErrorContinue:
+		return policy
+	default:
+		return OnErrorFail
+	}
+}
+
+// nodeOutcome is how one node's run ended
+type nodeOutcome struct {
+	node     *WorkflowNode
+	result   interface{}
+	err      error
+	attempts int           // Runs of the node, more than one when transient failures were retried
+	timeout  time.Duration // The node's own limit, 0 when it had none
+	timedOut bool          // The node ran past its own limit, as opposed to the workflow's
+}
+
+// runNodes executes the workflow's nodes, starting each one as soon as every node it depends
+// on has finished, with up to maxParallelNodes of them running at once. Independent branches,
+// such as several scanners hanging off one trigger, so run side by side.
+//
+// The first node to fail cancels the others and is returned once they have stopped, unless its
+// onError policy lets the run go on without it and the nodes that depend on it. With
+// StopOnCritical set, a critical finding lets running nodes finish but starts no more, recording
+// the rest as skipped. Nodes left without a result were not run because ctx ended.
+func (e *WorkflowExecutor) runNodes(ctx context.Context, executionID uuid.UUID, workflow *models.Workflow, nodes []WorkflowNode, edges []WorkflowEdge, order []string, results *executionResults) *nodeOutcome {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	g := newWorkflowGraph(nodes, edges)
+	for i := range nodes {
+		nodes[i].upstream = g.nearestAncestors(nodes[i].ID)
+	}
+	position := make(map[string]int, len(order))
+	pending := make(map[string]int, len(order)) // Unfinished dependencies of each node
+	var ready []string
+	for i, nodeID := range order {
+		position[nodeID] = i
+		pending[nodeID] = len(g.upstream[nodeID])
+		if pending[nodeID] == 0 {
+			ready = append(ready, nodeID)
+		}
+	}
+
+	limit := e.maxParallelNodes
+	if limit < 1 {
+		limit = 1
+	}
+	done := make(chan nodeOutcome)
+	running := make(map[string]bool)
+	var failure *nodeOutcome
+	halted := false
+
+	for {
+		for failure == nil && !halted && ctx.Err() == nil && len(ready) > 0 && len(running) < limit {
+			node := e.findNode(nodes, ready[0])
+			ready = ready[1:]
+			previousResults := results.Snapshot()
+			running[node.ID] = true
+			go func() {
+				done <- e.runNode(ctx, executionID, workflow, node, previousResults)
+			}()
+		}
+		if len(running) == 0 {
+			break
+		}
+
+		outcome := <-done
+		delete(running, outcome.node.ID)
+		if outcome.err != nil {
+			// A node stopped because the run ended can't be tolerated; its failure is the run's
+			if policy := nodeErrorPolicy(outcome.node); policy != OnErrorFail && ctx.Err() == nil {
+				skipped := tolerateFailure(g, results, outcome, policy)
+				logf(ctx, "⚠️ Node %s failed (onError: %s), skipping %d dependent node(s): %v", outcome.node.ID, policy, skipped, outcome.err)
+				continue
+			}
+			if failure == nil {
+				failure = &outcome
+				cancel()
+			}
+			continue
+		}
+		results.Set(outcome.node.ID, outcome.result)
+
+		// Abort early on a clearly broken target, finalizing with what has run so far
+		if workflow.StopOnCritical && !halted && hasCriticalFinding(outcome.node.ID, outcome.result) {
+			halted = true
+			skipped := 0
+			for _, nodeID := range order {
+				if _, ok := results.Get(nodeID); !ok && !running[nodeID] {
+					results.Set(nodeID, map[string]interface{}{
+						"status": "skipped",
+						"reason": fmt.Sprintf("critical finding in node %s", outcome.node.ID),
+					})
+					skipped++
+				}
+			}
+			logf(ctx, "🛑 Critical finding in node %s, skipping %d remaining node(s)", outcome.node.ID, skipped)
+		}
+
+		for _, nodeID := range g.downstream[outcome.node.ID] {
+			pending[nodeID]--
+			if pending[nodeID] == 0 {
+				ready = append(ready, nodeID)
+			}
+		}
+		// Start ready nodes in workflow order, so runs without parallelism keep it
+		sort.SliceStable(ready, func(i, j int) bool {
+			return position[ready[i]] < position[ready[j]]
+		})
+	}
+	return failure
+}
+
+// runNode executes one node under its timeout, retrying transient failures as the node asks
+// and tagging everything it logs with the node. A
+// scanner node's outcome is also recorded as a scan result of the execution, unless the node
+// was stopped because the execution ended.
+func (e *WorkflowExecutor) runNode(ctx context.Context, executionID uuid.UUID, workflow *models.Workflow, node *WorkflowNode, previousResults map[string]interface{}) nodeOutcome {
+	// Update current node
+	e.db.Model(&models.WorkflowExecution{}).Where("id = ?", executionID).Update("current_node", node.Type)
+
+	nodeCtx := withLogNode(ctx, node)
+	logf(nodeCtx, "⚙️  Executing node: %s (%s)", node.ID, node.Type)
+	outcome := nodeOutcome{node: node, timeout: e.nodeTimeoutFor(node)}
+	if outcome.timeout > 0 {
+		var cancel context.CancelFunc
+		nodeCtx, cancel = context.WithTimeout(nodeCtx, outcome.timeout)
+		defer cancel()
+	}
+
+	startedAt := time.Now()
+	e.active.nodeStarted(executionID, node.ID)
+	retry := retryPolicyFor(node)
+	outcome.result, outcome.attempts, outcome.err = withRetries(nodeCtx, retry, func(ctx context.Context) (interface{}, error) {
+		return e.executeNode(ctx, node, previousResults, workflow.UserID)
+	})
+	if result, ok := outcome.result.(map[string]interface{}); ok && outcome.err == nil && retry.retries > 0 {
+		result["attempts"] = outcome.attempts
+	}
+	e.active.nodeFinished(executionID, node.ID)
+	outcome.timedOut = outcome.err != nil && errors.Is(nodeCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+
+	if _, ok := LookupScanner(node.Type); ok && ctx.Err() == nil {
+		e.recordNodeScan(executionID, workflow, node, previousResults, startedAt, outcome)
+	}
+	return outcome
+}
+
+// recordNodeScan saves a scanner node's outcome as a scan result linked to the execution
+func (e *WorkflowExecutor) recordNodeScan(executionID uuid.UUID, workflow *models.Workflow, node *WorkflowNode, previousResults map[string]interface{}, startedAt time.Time, outcome nodeOutcome) {
+	completedAt := time.Now()
+	workflowID := workflow.ID
+	scanResult := &models.ScanResult{
+		WorkflowID:  &workflowID,
+		ExecutionID: &executionID,
+		UserID:      workflow.UserID,
+		ScanType:    node.Type,
+		TargetURL:   e.getTarget(node, previousResults),
+		StartedAt:   &startedAt,
+		CompletedAt: &completedAt,
+	}
+	e.scannerService.RecordWorkflowScan(scanResult, outcome.result, outcome.err)
+}
+
+// tolerateFailure records a failed node under its onError policy and skips every node after
+// it, returning how many were skipped. Those nodes never become ready, since the failed node
+// isn't counted as finished.
+func tolerateFailure(g *workflowGraph, results *executionResults, outcome nodeOutcome, policy string) int {
+	nodeID := outcome.node.ID
+	result := map[string]interface{}{
+		"status": "failed",
+		"error":  outcome.err.Error(),
+	}
+	if policy == OnErrorSkip {
+		result["status"] = "skipped"
+		result["reason"] = "node failed and onError is skip"
+	}
+	if outcome.attempts > 1 {
+		result["attempts"] = outcome.attempts
+	}
+	results.Set(nodeID, result)
+
+	skipped := 0
+	for id := range g.reachable(g.downstream[nodeID], g.downstream) {
+		if _, ok := results.Get(id); ok {
+			continue
+		}
+		results.Set(id, map[string]interface{}{
+			"status": "skipped",
+			"reason": fmt.Sprintf("upstream node %s failed", nodeID),
+		})
+		skipped++
+	}
+	return skipped
+}
+
+// unfinishedNodes returns the nodes of order that have no result yet
+func unfinishedNodes(order []string, results map[string]interface{}, except string) []string {
+	var remaining []string
+	for _, nodeID := range order {
+		if _, ok := results[nodeID]; !ok && nodeID != except {
+			remaining = append(remaining, nodeID)
+		}
+	}
+	return remaining
+}
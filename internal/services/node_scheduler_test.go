@@ -0,0 +1,134 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/datmedevil17/go-vuln/internal/models"
+)
+
+// nodeSleep is how long each middle node of diamondWorkflow runs
+const nodeSleep = 300 * time.Millisecond
+
+// diamondWorkflow fans a trigger out to two independent scans that join into a third
+func diamondWorkflow() *models.Workflow {
+	sleeping := func(id string) map[string]interface{} {
+		return map[string]interface{}{"id": id, "type": "test-scan", "data": map[string]interface{}{"sleepMs": float64(nodeSleep / time.Millisecond)}}
+	}
+	return testWorkflow(
+		models.JSONArray{testNode("trigger", "trigger"), sleeping("left"), sleeping("right"), testNode("join", "test-scan")},
+		models.JSONArray{
+			testEdge("e1", "trigger", "left"),
+			testEdge("e2", "trigger", "right"),
+			testEdge("e3", "left", "join"),
+			testEdge("e4", "right", "join"),
+		},
+	)
+}
+
+func TestIndependentNodesRunInParallel(t *testing.T) {
+	e, writes := newTestExecutor(t, config.WorkflowConfig{MaxParallelNodes: 4})
+
+	started := time.Now()
+	runTestWorkflow(t, e, diamondWorkflow())
+	// Run one after the other, the middle nodes alone would take twice nodeSleep
+	if elapsed := time.Since(started); elapsed >= 2*nodeSleep {
+		t.Fatalf("diamond took %s, want the middle nodes to overlap (each runs %s)", elapsed, nodeSleep)
+	}
+
+	status, results := writes.finalState(t)
+	if status != "completed" {
+		t.Fatalf("execution finished %s, want completed", status)
+	}
+	for _, nodeID := range []string{"left", "right", "join"} {
+		if got := nodeStatus(results, nodeID); got != "completed" {
+			t.Fatalf("node %s is %q, want completed", nodeID, got)
+		}
+	}
+}
+
+func TestMaxParallelNodesOfOneRunsInTurn(t *testing.T) {
+	e, writes := newTestExecutor(t, config.WorkflowConfig{MaxParallelNodes: 1})
+
+	started := time.Now()
+	runTestWorkflow(t, e, diamondWorkflow())
+	if elapsed := time.Since(started); elapsed < 2*nodeSleep {
+		t.Fatalf("diamond took %s, want the middle nodes one after the other", elapsed)
+	}
+	if status, _ := writes.finalState(t); status != "completed" {
+		t.Fatalf("execution finished %s, want completed", status)
+	}
+}
+
+func TestOnErrorPolicies(t *testing.T) {
+	tests := []struct {
+		policy       string
+		wantStatus   string
+		wantBroken   string
+		brokenReason string
+	}{
+		{policy: "", wantStatus: "failed"},
+		{policy: OnErrorFail, wantStatus: "failed"},
+		{policy: OnErrorSkip, wantStatus: "completed", wantBroken: "skipped", brokenReason: "node failed and onError is skip"},
+		{policy: OnErrorContinue, wantStatus: "completed", wantBroken: "failed"},
+	}
+	for _, tt := range tests {
+		t.Run("onError="+tt.policy, func(t *testing.T) {
+			e, writes := newTestExecutor(t, config.WorkflowConfig{MaxParallelNodes: 4})
+			broken := map[string]interface{}{"fail": "connection refused"}
+			if tt.policy != "" {
+				broken["onError"] = tt.policy
+			}
+			workflow := testWorkflow(
+				models.JSONArray{
+					testNode("trigger", "trigger"),
+					dataNode("broken", "test-scan", broken),
+					testNode("after", "test-scan"),
+					testNode("after-after", "test-scan"),
+					// Still running when broken fails, and left to finish unless the run fails
+					dataNode("sibling", "test-scan", map[string]interface{}{"sleepMs": float64(100)}),
+				},
+				models.JSONArray{
+					testEdge("e1", "trigger", "broken"),
+					testEdge("e2", "broken", "after"),
+					testEdge("e3", "after", "after-after"),
+					testEdge("e4", "trigger", "sibling"),
+				},
+			)
+			runTestWorkflow(t, e, workflow)
+
+			status, results := writes.finalState(t)
+			if status != tt.wantStatus {
+				t.Fatalf("execution finished %s, want %s", status, tt.wantStatus)
+			}
+			if tt.wantStatus == "failed" {
+				var errorMsg interface{}
+				writes.mu.Lock()
+				for _, columns := range writes.writes {
+					if columns["status"] == "failed" {
+						errorMsg = columns["error"]
+					}
+				}
+				writes.mu.Unlock()
+				if errorMsg != "Node broken failed: connection refused" {
+					t.Fatalf("execution failed with %v, want the node's error", errorMsg)
+				}
+				return
+			}
+
+			brokenResult := results["broken"].(map[string]interface{})
+			if brokenResult["status"] != tt.wantBroken || brokenResult["error"] != "connection refused" || (tt.brokenReason != "" && brokenResult["reason"] != tt.brokenReason) {
+				t.Errorf("failing node recorded as %v, want %s", brokenResult, tt.wantBroken)
+			}
+			for _, nodeID := range []string{"after", "after-after"} {
+				if got := nodeStatus(results, nodeID); got != "skipped" {
+					t.Errorf("node %s after the failure is %q, want skipped", nodeID, got)
+				}
+			}
+			if got := nodeStatus(results, "sibling"); got != "completed" {
+				t.Errorf("independent node is %q, want completed", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/datmedevil17/go-vuln/internal/models"
+)
+
+func init() {
+	RegisterScanner(commandScanner{})
+}
+
+// commandScanner is a scanner node type for executor tests that runs a long shell command on
+// the host, writing the command's PID to data.pidFile first
+type commandScanner struct{}
+
+func (commandScanner) Name() string { return "test-command" }
+
+func (commandScanner) Describe() NodeType {
+	return NodeType{DisplayName: "Test Command", Category: NodeCategoryUtility}
+}
+
+func (commandScanner) Run(ctx context.Context, env *ScanEnv, node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
+	pidFile, _ := node.Data["pidFile"].(string)
+	output, err := hostRunner{}.Run(ctx, ToolRun{Tool: "sh", Args: []string{"-c", `echo $$ > "$0"; exec sleep 30`, pidFile}})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"scanner": "test-command", "output": string(output)}, nil
+}
+
+// waitForPID reads the PID the command wrote to path
+func waitForPID(t *testing.T, path string) int {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if data, err := os.ReadFile(path); err == nil && strings.HasSuffix(string(data), "\n") {
+			pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return pid
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("the command never started")
+	return 0
+}
+
+func TestNodeTimeoutKillsCommand(t *testing.T) {
+	e, writes := newTestExecutor(t, config.WorkflowConfig{MaxParallelNodes: 4})
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	workflow := testWorkflow(
+		models.JSONArray{
+			testNode("trigger", "trigger"),
+			map[string]interface{}{"id": "slow", "type": "test-command", "data": map[string]interface{}{"pidFile": pidFile, "timeout": 0.2}},
+			testNode("after", "test-scan"),
+		},
+		models.JSONArray{testEdge("e1", "trigger", "slow"), testEdge("e2", "slow", "after")},
+	)
+
+	started := time.Now()
+	runTestWorkflow(t, e, workflow)
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Fatalf("workflow ran for %s past the node's 200ms timeout", elapsed)
+	}
+
+	// The command was killed, not left running after the node gave up on it
+	if err := syscall.Kill(waitForPID(t, pidFile), 0); !errors.Is(err, syscall.ESRCH) {
+		t.Fatalf("the command is still running after its node timed out (%v)", err)
+	}
+
+	status, results := writes.finalState(t)
+	if status != "failed" {
+		t.Fatalf("execution finished %s, want failed", status)
+	}
+	slow := results["slow"].(map[string]interface{})
+	if slow["status"] != "failed" || slow["error"] != "node slow timed out after 200ms" {
+		t.Fatalf("timed out node recorded as %v", slow)
+	}
+	if got := nodeStatus(results, "after"); got != "skipped" {
+		t.Fatalf("node after is %q, want skipped", got)
+	}
+}
+
+func TestNodeTimeoutFor(t *testing.T) {
+	e := NewWorkflowExecutor(nil, nil, nil, nil, nil, nil, nil, nil, nil, config.WorkflowConfig{NodeTimeout: time.Minute})
+	tests := []struct {
+		data map[string]interface{}
+		want time.Duration
+	}{
+		{data: nil, want: time.Minute},
+		{data: map[string]interface{}{"timeout": 1.5}, want: 1500 * time.Millisecond},
+		{data: map[string]interface{}{"timeout": 0.0}, want: time.Minute},
+		{data: map[string]interface{}{"timeout": -5.0}, want: time.Minute},
+		{data: map[string]interface{}{"timeout": "30"}, want: time.Minute},
+	}
+	for _, tt := range tests {
+		if got := e.nodeTimeoutFor(&WorkflowNode{Data: tt.data}); got != tt.want {
+			t.Errorf("nodeTimeoutFor(%v) = %s, want %s", tt.data, got, tt.want)
+		}
+	}
+}
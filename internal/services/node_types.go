@@ -0,0 +1,268 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+)
+
+// Node type categories shown in the workflow editor's palette
+const (
+	NodeCategoryTrigger      = "trigger"
+	NodeCategoryNetwork      = "network-scan"
+	NodeCategoryCode         = "code-scan"
+	NodeCategoryRemediation  = "remediation"
+	NodeCategoryNotification = "notification"
+	NodeCategoryUtility      = "utility"
+)
+
+// NodeType describes a workflow node type and the JSON schema of its data fields
+type NodeType struct {
+	Type        string                 `json:"type"`
+	DisplayName string                 `json:"display_name"`
+	Category    string                 `json:"category"`
+	Description string                 `json:"description"`
+	Schema      map[string]interface{} `json:"schema"`
+	Inputs      []string               `json:"inputs,omitempty"` // Upstream inputs checked before the node runs, see nodeInputChecks
+}
+
+// NodeTypeCatalog is every node type the executor supports. Version changes
+// whenever any type or schema does, so clients can cache the catalog by it.
+type NodeTypeCatalog struct {
+	Version   string     `json:"version"`
+	NodeTypes []NodeType `json:"node_types"`
+}
+
+// builtinNodeTypes are the node types handled directly by executeNode rather than through a ScannerPlugin
+var builtinNodeTypes = []NodeType{
+	{
+		Type:        "trigger",
+		DisplayName: "Trigger",
+		Category:    NodeCategoryTrigger,
+		Description: "Starts the workflow against a target URL, host or GitHub repository",
+		Schema: objectSchema(map[string]interface{}{
+			"sourceUrl": stringField("Target URL, host or https://github.com/owner/repo"),
+		}, "sourceUrl"),
+	},
+	{
+		Type:        "auto-fix",
+		DisplayName: "Auto-Fix",
+		Category:    NodeCategoryRemediation,
+		Description: "Generates a fix for a vulnerable file with AI and opens a pull request",
+		Schema: objectSchema(map[string]interface{}{
+			"owner":         stringField("Repository owner; defaults to the trigger's GitHub URL"),
+			"repo":          stringField("Repository name; defaults to the trigger's GitHub URL"),
+			"path":          stringField("File to fix; inferred from earlier code scan findings when empty"),
+			"branch":        stringField("Base branch for the pull request (default main)"),
+			"vulnerability": stringField("Vulnerability to fix; analysed with AI when empty"),
+			"verifyFix":     boolField("Rescan the fix branch and report before/after findings on the PR"),
+		}),
+		Inputs: []string{NodeInputRepository, NodeInputSourcePath},
+	},
+	{
+		Type:        "github-issue",
+		DisplayName: "GitHub Issue",
+		Category:    NodeCategoryRemediation,
+		Description: "Files the scan results as an issue on the target repository",
+		Schema: objectSchema(map[string]interface{}{
+			"owner":    stringField("Repository owner; defaults to the trigger's GitHub URL"),
+			"repo":     stringField("Repository name; defaults to the trigger's GitHub URL"),
+			"language": languageField(),
+			"detail":   enumField("full includes raw scanner output; defaults to summary on public repositories and full on private ones", ReportDetails...),
+		}),
+		Inputs: []string{NodeInputTarget, NodeInputRepository},
+	},
+	{
+		Type:        "jira-issue",
+		DisplayName: "Jira Issue",
+		Category:    NodeCategoryRemediation,
+		Description: "Files the scan results as an issue in a Jira Cloud project",
+		Schema: objectSchema(map[string]interface{}{
+			"config": map[string]interface{}{
+				"type":        "object",
+				"description": "Jira site and account the issue is filed with",
+				"properties": map[string]interface{}{
+					"base_url":    stringField("Jira site, e.g. https://example.atlassian.net"),
+					"email":       stringField("Email of the Jira account filing the issue"),
+					"project_key": stringField("Key of the project to file the issue in, e.g. SEC"),
+					"api_token":   stringField("Name of the secret holding the account's API token"),
+				},
+				"required": []string{"base_url", "email", "project_key", "api_token"},
+			},
+			"language": languageField(),
+			"detail":   enumField("full (the default) includes raw scanner output; summary only the finding counts", ReportDetails...),
+		}, "config"),
+		Inputs: []string{NodeInputTarget},
+	},
+	{
+		Type:        "email",
+		DisplayName: "Email",
+		Category:    NodeCategoryNotification,
+		Description: "Emails the workflow report",
+		Schema: objectSchema(map[string]interface{}{
+			"email":        stringField("Recipient; defaults to the workflow owner's email"),
+			"language":     languageField(),
+			"onlyNewPorts": onlyNewPortsField(),
+			"detail":       notificationDetailField(),
+		}),
+	},
+	{
+		Type:        "slack",
+		DisplayName: "Slack",
+		Category:    NodeCategoryNotification,
+		Description: "Posts the workflow report to Slack",
+		Schema: objectSchema(map[string]interface{}{
+			"webhook_url":  stringField("Slack incoming webhook (https) to post to; defaults to the server's"),
+			"language":     languageField(),
+			"onlyNewPorts": onlyNewPortsField(),
+			"detail":       notificationDetailField(),
+		}),
+	},
+	{
+		Type:        "notify",
+		DisplayName: "Notify",
+		Category:    NodeCategoryNotification,
+		Description: "Sends the report on the owner's preferred notification channel",
+		Schema: objectSchema(map[string]interface{}{
+			"channel":      enumField("Overrides the owner's preferred channel", NotificationChannels...),
+			"email":        stringField("Recipient when the channel is email"),
+			"webhook_url":  stringField("Slack incoming webhook when the channel is slack"),
+			"language":     languageField(),
+			"onlyNewPorts": onlyNewPortsField(),
+			"detail":       notificationDetailField(),
+		}),
+	},
+	{
+		Type:        "webhook",
+		DisplayName: "Webhook",
+		Category:    NodeCategoryNotification,
+		Description: "POSTs the accumulated results as JSON to a URL",
+		Schema: objectSchema(map[string]interface{}{
+			"url": stringField("http(s) URL to deliver results to"),
+		}, "url"),
+	},
+	{
+		Type:        "flow-chart",
+		DisplayName: "Flow Chart",
+		Category:    NodeCategoryUtility,
+		Description: "Pass-through node for grouping and layout",
+		Schema:      objectSchema(nil),
+	},
+}
+
+// NodeTypes returns the catalog of built-in node types plus every registered scanner plugin
+func NodeTypes() NodeTypeCatalog {
+	types := append([]NodeType{}, builtinNodeTypes...)
+	for _, name := range RegisteredScanners() {
+		plugin, _ := LookupScanner(name)
+		nodeType := plugin.Describe()
+		nodeType.Type = name
+		if nodeType.Schema == nil {
+			nodeType.Schema = objectSchema(nil)
+		}
+		types = append(types, nodeType)
+	}
+	sort.SliceStable(types, func(i, j int) bool {
+		if types[i].Category != types[j].Category {
+			return categoryOrder[types[i].Category] < categoryOrder[types[j].Category]
+		}
+		return types[i].Type < types[j].Type
+	})
+
+	encoded, _ := json.Marshal(types)
+	sum := sha256.Sum256(encoded)
+	return NodeTypeCatalog{
+		Version:   hex.EncodeToString(sum[:8]),
+		NodeTypes: types,
+	}
+}
+
+// categoryOrder lays out the palette in the order a workflow usually flows
+var categoryOrder = map[string]int{
+	NodeCategoryTrigger:      0,
+	NodeCategoryNetwork:      1,
+	NodeCategoryCode:         2,
+	NodeCategoryRemediation:  3,
+	NodeCategoryNotification: 4,
+	NodeCategoryUtility:      5,
+}
+
+// objectSchema builds a JSON schema for a node's data object. Every node accepts a timeout.
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	properties["timeout"] = map[string]interface{}{
+		"type":        "number",
+		"description": "Seconds the node may run before it is stopped and fails the run; defaults to the server's limit",
+		"minimum":     0,
+	}
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringField(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
+
+func boolField(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "boolean", "description": description}
+}
+
+func notificationDetailField() map[string]interface{} {
+	return enumField("summary sends only the AI summary and finding counts, never raw scanner output (default full)", ReportDetails...)
+}
+
+func onlyNewPortsField() map[string]interface{} {
+	return boolField("Send only when an nmap node found ports that weren't open in the target's previous scan")
+}
+
+func enumField(description string, values ...string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description, "enum": values}
+}
+
+func languageField() map[string]interface{} {
+	codes := make([]string, 0, len(SupportedLanguages))
+	for code := range SupportedLanguages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return enumField("Report language; defaults to the owner's preference", codes...)
+}
+
+// webScanFields are the data fields shared by scanners that make HTTP requests to the target
+func webScanFields(fields map[string]interface{}) map[string]interface{} {
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+	fields["profile"] = stringField("Saved scan profile (ID or name) whose parameters are used as defaults")
+	fields["authHeaders"] = map[string]interface{}{
+		"type":                 "object",
+		"description":          "Header name to the name of the secret holding its value",
+		"additionalProperties": map[string]interface{}{"type": "string"},
+	}
+	fields["cookies"] = stringField("Name of the secret holding a Cookie header for authenticated scans")
+	fields["extraArgs"] = extraArgsField()
+	fields["target"] = targetField()
+	return fields
+}
+
+func targetField() map[string]interface{} {
+	return stringField("Target to scan instead of the one from the nearest upstream trigger")
+}
+
+// extraArgsField describes the additional scanner flags a node may pass, limited by the server's allowlist
+func extraArgsField() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"description": "Additional scanner flags; only flags the server allowlists are accepted",
+		"items":       map[string]interface{}{"type": "string"},
+	}
+}
@@ -2,25 +2,68 @@ package services
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/smtp"
+	"net/url"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	// slackTimeout bounds one post to a Slack webhook
+	slackTimeout = 30 * time.Second
+	// slackSectionLimit is the most text Slack shows in one section block
+	slackSectionLimit = 3000
 )
 
 type NotificationService struct {
-	config *config.Config
+	config      *config.Config
+	slackClient *http.Client // For the server's own webhook
+	userClient  *http.Client // SSRF-safe, for webhooks set on workflow nodes
+
+	// Recently sent notification fingerprints, keyed by user and fingerprint
+	sentMu sync.Mutex
+	sent   map[string]time.Time
 }
 
 type SlackMessage struct {
-	Text        string       `json:"text"`
+	Text        string       `json:"text"` // Also the fallback shown in notifications when there are blocks
+	Blocks      []SlackBlock `json:"blocks,omitempty"`
 	Attachments []Attachment `json:"attachments,omitempty"`
 }
 
+// SlackBlock is a Block Kit layout block; only header, section and divider blocks are used
+type SlackBlock struct {
+	Type   string      `json:"type"`
+	Text   *SlackText  `json:"text,omitempty"`
+	Fields []SlackText `json:"fields,omitempty"`
+}
+
+// SlackText is a Block Kit text object, plain_text or mrkdwn
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// SlackDelivery is where Slack put a message. Incoming webhooks only answer "ok", so the
+// channel and timestamp are set just by endpoints that report them.
+type SlackDelivery struct {
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
 type Attachment struct {
 	Color  string  `json:"color"`
 	Title  string  `json:"title"`
@@ -35,7 +78,56 @@ type Field struct {
 }
 
 func NewNotificationService(cfg *config.Config) *NotificationService {
-	return &NotificationService{config: cfg}
+	return &NotificationService{
+		config:      cfg,
+		slackClient: &http.Client{Timeout: slackTimeout},
+		userClient:  utils.NewSafeHTTPClient(slackTimeout),
+		sent:        make(map[string]time.Time),
+	}
+}
+
+// NotificationFingerprint derives a stable fingerprint from the parts identifying a notification.
+// Parts are sorted so the same set of findings yields the same fingerprint regardless of order.
+func NotificationFingerprint(parts ...string) string {
+	sorted := append([]string(nil), parts...)
+	sort.Strings(sorted)
+	hash := sha256.Sum256([]byte(strings.Join(sorted, "\n")))
+	return hex.EncodeToString(hash[:])
+}
+
+// ClaimNotification records a fingerprint for the user and reports whether the notification
+// should be sent. It returns false when the same fingerprint was sent within the dedup window.
+func (s *NotificationService) ClaimNotification(userID uuid.UUID, fingerprint string) bool {
+	window := s.config.Notify.DedupWindow
+	if window <= 0 {
+		return true
+	}
+
+	key := userID.String() + ":" + fingerprint
+	now := time.Now()
+
+	s.sentMu.Lock()
+	defer s.sentMu.Unlock()
+
+	// Drop expired entries so the map doesn't grow without bound
+	for k, sentAt := range s.sent {
+		if now.Sub(sentAt) >= window {
+			delete(s.sent, k)
+		}
+	}
+
+	if _, ok := s.sent[key]; ok {
+		return false
+	}
+	s.sent[key] = now
+	return true
+}
+
+// ReleaseNotification forgets a claimed fingerprint, e.g. when delivery failed and may be retried
+func (s *NotificationService) ReleaseNotification(userID uuid.UUID, fingerprint string) {
+	s.sentMu.Lock()
+	defer s.sentMu.Unlock()
+	delete(s.sent, userID.String()+":"+fingerprint)
 }
 
 // SendScanCompletedEmail sends an email notification when a scan completes
@@ -67,6 +159,12 @@ func (s *NotificationService) SendWorkflowReport(to, target, status, aiReport st
 		return nil
 	}
 
+	subject, body := WorkflowReportEmail(target, status, aiReport)
+	return s.sendEmail(to, subject, body)
+}
+
+// WorkflowReportEmail builds the subject and body of a workflow report email
+func WorkflowReportEmail(target, status, aiReport string) (string, string) {
 	subject := fmt.Sprintf("VulnPilot: Security Audit Report - %s", target)
 	body := fmt.Sprintf(`
 VulnPilot Security Scan & Audit Report
@@ -85,7 +183,7 @@ This report was automatically generated by VulnPilot AI.
 Please log in to the dashboard for interactive details.
 `, target, status, "Today", aiReport)
 
-	return s.sendEmail(to, subject, body)
+	return subject, body
 }
 
 // SendVulnerabilityAlert sends an alert when vulnerabilities are found
@@ -163,6 +261,100 @@ func (s *NotificationService) SendSlackNotification(message string, attachments
 	return nil
 }
 
+// SendSlackMessage posts message to a Slack incoming webhook. An empty webhookURL means the
+// server's SLACK_WEBHOOK_URL; any other URL is user-supplied, so it must be https and is
+// called through the SSRF-safe client.
+func (s *NotificationService) SendSlackMessage(ctx context.Context, webhookURL string, message SlackMessage) (*SlackDelivery, error) {
+	client := s.userClient
+	if webhookURL == "" {
+		if !s.ChannelEnabled("slack") {
+			return nil, fmt.Errorf("slack notifications are not configured on this server")
+		}
+		webhookURL, client = s.config.Slack.WebhookURL, s.slackClient
+	} else if parsed, err := url.Parse(webhookURL); err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return nil, fmt.Errorf("slack webhook_url must be an https URL")
+	}
+
+	jsonData, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("slack notification failed: %s - %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var delivery SlackDelivery
+	json.Unmarshal(body, &delivery) // Incoming webhooks answer with plain text
+	return &delivery, nil
+}
+
+// WorkflowReportSlack builds the Block Kit message of a workflow report: the target and status,
+// the finding counts and the AI report, cut to what one section can show
+func WorkflowReportSlack(target, status, summary, aiReport string) SlackMessage {
+	if len(aiReport) > slackSectionLimit {
+		aiReport = strings.ToValidUTF8(aiReport[:slackSectionLimit-len("…")], "") + "…"
+	}
+	return SlackMessage{
+		Text: fmt.Sprintf("VulnPilot Security Workflow Report - %s", target),
+		Blocks: []SlackBlock{
+			{Type: "header", Text: &SlackText{Type: "plain_text", Text: "VulnPilot Security Workflow Report"}},
+			{Type: "section", Fields: []SlackText{
+				{Type: "mrkdwn", Text: "*Target*\n" + target},
+				{Type: "mrkdwn", Text: "*Status*\n" + status},
+			}},
+			{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: "*Scan summary*\n" + summary}},
+			{Type: "divider"},
+			{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: aiReport}},
+		},
+	}
+}
+
+// ChannelEnabled reports whether the server is configured to send on channel
+func (s *NotificationService) ChannelEnabled(channel string) bool {
+	switch channel {
+	case "email":
+		return s.config.Email.Enabled
+	case "slack":
+		return s.config.Slack.Enabled && s.config.Slack.WebhookURL != ""
+	}
+	return false
+}
+
+// Deliver sends a stored notification on its channel. Email uses subject and body;
+// Slack uses subject as the message text alongside the attachments, posted to recipient
+// when it holds a node's webhook URL and to the server's webhook otherwise.
+func (s *NotificationService) Deliver(channel, recipient, subject, body string, attachments []Attachment) error {
+	switch channel {
+	case "email":
+		if !s.config.Email.Enabled {
+			return nil
+		}
+		return s.sendEmail(recipient, subject, body)
+	case "slack":
+		if recipient != "" {
+			_, err := s.SendSlackMessage(context.Background(), recipient, SlackMessage{Text: subject, Attachments: attachments})
+			return err
+		}
+		return s.SendSlackNotification(subject, attachments)
+	default:
+		return fmt.Errorf("unknown notification channel: %s", channel)
+	}
+}
+
 // NotifyScanComplete sends notifications via all enabled channels
 func (s *NotificationService) NotifyScanComplete(userEmail, scanType, target, status string) error {
 	// Send email
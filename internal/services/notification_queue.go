@@ -0,0 +1,231 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// notificationPollInterval is how often the queue looks for due retries
+	notificationPollInterval = 15 * time.Second
+	// notificationRetryCap bounds the exponential backoff between attempts
+	notificationRetryCap = time.Hour
+	// notificationLease keeps other workers off a delivery while it is being sent
+	notificationLease = 5 * time.Minute
+	// notificationBatchSize limits deliveries retried per poll
+	notificationBatchSize = 20
+)
+
+// ErrNotificationSent is returned when resending a notification that was already delivered
+var ErrNotificationSent = errors.New("notification was already delivered")
+
+// NotificationQueue persists failed notifications and retries them with exponential backoff.
+// Deliveries that exhaust their attempts are kept with status "dead" for manual resend.
+type NotificationQueue struct {
+	db       *gorm.DB
+	notifier *NotificationService
+	config   *config.Config
+	senders  map[string]func(*models.NotificationDelivery) error // Channels delivered outside NotificationService
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewNotificationQueue(db *gorm.DB, notifier *NotificationService, cfg *config.Config) *NotificationQueue {
+	return &NotificationQueue{
+		db:       db,
+		notifier: notifier,
+		config:   cfg,
+		senders:  make(map[string]func(*models.NotificationDelivery) error),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// RegisterSender routes retries of channel to send; call it before Start
+func (q *NotificationQueue) RegisterSender(channel string, send func(*models.NotificationDelivery) error) {
+	q.senders[channel] = send
+}
+
+// Enqueue records a notification whose first delivery attempt failed
+func (q *NotificationQueue) Enqueue(userID uuid.UUID, channel, recipient, subject, body string, attachments []Attachment, sendErr error) (*models.NotificationDelivery, error) {
+	delivery := &models.NotificationDelivery{
+		UserID:      userID,
+		Channel:     channel,
+		Recipient:   recipient,
+		Subject:     subject,
+		Body:        body,
+		Attachments: attachmentsToJSON(attachments),
+		Status:      "pending",
+		Attempts:    1,
+		LastError:   sendErr.Error(),
+	}
+	delivery.NextAttemptAt = time.Now().Add(q.backoff(delivery.Attempts))
+	if delivery.Attempts >= q.config.Notify.MaxAttempts {
+		delivery.Status = "dead"
+	}
+
+	if err := q.db.Create(delivery).Error; err != nil {
+		return nil, fmt.Errorf("failed to queue notification: %w", err)
+	}
+	return delivery, nil
+}
+
+// Start launches the background retry loop
+func (q *NotificationQueue) Start() {
+	go func() {
+		defer close(q.done)
+		ticker := time.NewTicker(notificationPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				q.processDue()
+			case <-q.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts the retry loop
+func (q *NotificationQueue) Stop() {
+	close(q.stop)
+	<-q.done
+}
+
+// List returns queued deliveries, optionally filtered by status, newest first
+func (q *NotificationQueue) List(status string) ([]models.NotificationDelivery, error) {
+	var deliveries []models.NotificationDelivery
+	query := q.db.Order("created_at DESC").Limit(200)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// Resend attempts a pending or dead delivery immediately
+func (q *NotificationQueue) Resend(id uuid.UUID) (*models.NotificationDelivery, error) {
+	var delivery models.NotificationDelivery
+	if err := q.db.First(&delivery, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if delivery.Status == "sent" {
+		return &delivery, ErrNotificationSent
+	}
+
+	err := q.send(&delivery)
+	now := time.Now()
+	updates := map[string]interface{}{"attempts": delivery.Attempts + 1}
+	if err != nil {
+		updates["last_error"] = err.Error()
+	} else {
+		updates["status"] = "sent"
+		updates["sent_at"] = now
+	}
+	if dbErr := q.db.Model(&delivery).Updates(updates).Error; dbErr != nil {
+		return nil, fmt.Errorf("failed to update notification: %w", dbErr)
+	}
+	if err != nil {
+		return &delivery, fmt.Errorf("resend failed: %w", err)
+	}
+	return &delivery, nil
+}
+
+// processDue retries deliveries whose backoff has elapsed
+func (q *NotificationQueue) processDue() {
+	var due []models.NotificationDelivery
+	if err := q.db.Where("status = ? AND next_attempt_at <= ?", "pending", time.Now()).
+		Order("next_attempt_at").Limit(notificationBatchSize).Find(&due).Error; err != nil {
+		log.Printf("⚠️ Failed to load queued notifications: %v", err)
+		return
+	}
+
+	for i := range due {
+		delivery := &due[i]
+
+		// Take a lease so another instance polling the same table skips this row
+		claim := q.db.Model(&models.NotificationDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, "pending", delivery.NextAttemptAt).
+			Update("next_attempt_at", time.Now().Add(notificationLease))
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			continue
+		}
+
+		q.retry(delivery)
+	}
+}
+
+// retry makes one delivery attempt and schedules the next one or dead-letters the notification
+func (q *NotificationQueue) retry(delivery *models.NotificationDelivery) {
+	attempts := delivery.Attempts + 1
+	err := q.send(delivery)
+
+	updates := map[string]interface{}{"attempts": attempts}
+	switch {
+	case err == nil:
+		updates["status"] = "sent"
+		updates["sent_at"] = time.Now()
+		log.Printf("📨 Delivered queued %s notification %s on attempt %d", delivery.Channel, delivery.ID, attempts)
+	case attempts >= q.config.Notify.MaxAttempts:
+		updates["status"] = "dead"
+		updates["last_error"] = err.Error()
+		log.Printf("☠️ Notification %s dead-lettered after %d attempts: %v", delivery.ID, attempts, err)
+	default:
+		updates["last_error"] = err.Error()
+		updates["next_attempt_at"] = time.Now().Add(q.backoff(attempts))
+	}
+
+	if err := q.db.Model(delivery).Updates(updates).Error; err != nil {
+		log.Printf("⚠️ Failed to update notification %s: %v", delivery.ID, err)
+	}
+}
+
+func (q *NotificationQueue) send(delivery *models.NotificationDelivery) error {
+	if send, ok := q.senders[delivery.Channel]; ok {
+		return send(delivery)
+	}
+	return q.notifier.Deliver(delivery.Channel, delivery.Recipient, delivery.Subject, delivery.Body, attachmentsFromJSON(delivery.Attachments))
+}
+
+// backoff returns the delay after the given number of failed attempts: base, 2*base, 4*base... capped
+func (q *NotificationQueue) backoff(attempts int) time.Duration {
+	delay := q.config.Notify.RetryBase
+	for i := 1; i < attempts && delay < notificationRetryCap; i++ {
+		delay *= 2
+	}
+	if delay > notificationRetryCap {
+		delay = notificationRetryCap
+	}
+	return delay
+}
+
+func attachmentsToJSON(attachments []Attachment) models.JSONArray {
+	array := models.JSONArray{}
+	data, err := json.Marshal(attachments)
+	if err != nil || len(attachments) == 0 {
+		return array
+	}
+	_ = json.Unmarshal(data, &array)
+	return array
+}
+
+func attachmentsFromJSON(array models.JSONArray) []Attachment {
+	var attachments []Attachment
+	data, err := json.Marshal(array)
+	if err != nil {
+		return nil
+	}
+	_ = json.Unmarshal(data, &attachments)
+	return attachments
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/google/uuid"
+)
+
+func nmapResult(output string) map[string]interface{} {
+	return map[string]interface{}{"scanner": "nmap", "status": "completed", "output": output}
+}
+
+func TestNotificationFingerprintMatchesAcrossBranches(t *testing.T) {
+	e := &WorkflowExecutor{}
+	slack := &WorkflowNode{ID: "notify-a", Type: "slack"}
+	fingerprint := func(node *WorkflowNode, results map[string]interface{}) string {
+		return e.notificationFingerprint(node, "owner@example.com", "example.com", results)
+	}
+
+	// Two branches whose scanners found the same open ports, listed in a different order
+	branchA := map[string]interface{}{
+		"trigger": map[string]interface{}{"type": "trigger", "target": "example.com"},
+		"scan-a":  nmapResult("22/tcp open ssh\n80/tcp open http\n"),
+	}
+	branchB := map[string]interface{}{
+		"trigger": map[string]interface{}{"type": "trigger", "target": "example.com"},
+		"scan-b":  nmapResult("80/tcp  open  http\n22/tcp open ssh\n"),
+	}
+	same := fingerprint(slack, branchA)
+	if got := fingerprint(&WorkflowNode{ID: "notify-b", Type: "slack"}, branchB); got != same {
+		t.Fatal("the same findings from another branch got a different fingerprint")
+	}
+
+	differs := map[string]map[string]interface{}{
+		"another finding": {"scan": nmapResult("22/tcp open ssh\n80/tcp open http\n443/tcp open https\n")},
+		"fewer findings":  {"scan": nmapResult("22/tcp open ssh\n")},
+	}
+	for name, results := range differs {
+		if fingerprint(slack, results) == same {
+			t.Errorf("%s: got the same fingerprint", name)
+		}
+	}
+	if fingerprint(&WorkflowNode{ID: "mail", Type: "email"}, branchA) == same {
+		t.Error("another channel got the same fingerprint")
+	}
+	if e.notificationFingerprint(slack, "owner@example.com", "example.org", branchA) == same {
+		t.Error("another target got the same fingerprint")
+	}
+}
+
+func TestClaimNotificationSendsOnce(t *testing.T) {
+	s := NewNotificationService(&config.Config{Notify: config.NotifyConfig{DedupWindow: time.Minute}})
+	user, other := uuid.New(), uuid.New()
+	fingerprint := NotificationFingerprint("channel:slack", "finding:nmap: 22/tcp open ssh")
+
+	if !s.ClaimNotification(user, fingerprint) {
+		t.Fatal("first notification was suppressed")
+	}
+	if s.ClaimNotification(user, fingerprint) {
+		t.Fatal("the same notification was sent twice")
+	}
+	if !s.ClaimNotification(other, fingerprint) {
+		t.Fatal("another user's notification was suppressed")
+	}
+	if !s.ClaimNotification(user, NotificationFingerprint("channel:slack", "finding:nmap: 80/tcp open http")) {
+		t.Fatal("a notification with other findings was suppressed")
+	}
+
+	// A failed delivery releases its claim so a retry can send it
+	s.ReleaseNotification(user, fingerprint)
+	if !s.ClaimNotification(user, fingerprint) {
+		t.Fatal("a released notification was suppressed")
+	}
+}
+
+func TestClaimNotificationWindow(t *testing.T) {
+	fingerprint := NotificationFingerprint("channel:email")
+	user := uuid.New()
+
+	disabled := NewNotificationService(&config.Config{})
+	if !disabled.ClaimNotification(user, fingerprint) || !disabled.ClaimNotification(user, fingerprint) {
+		t.Fatal("notifications were deduplicated with the window disabled")
+	}
+
+	short := NewNotificationService(&config.Config{Notify: config.NotifyConfig{DedupWindow: 20 * time.Millisecond}})
+	short.ClaimNotification(user, fingerprint)
+	time.Sleep(30 * time.Millisecond)
+	if !short.ClaimNotification(user, fingerprint) {
+		t.Fatal("a notification was suppressed after the window passed")
+	}
+}
+
+// fakeSlackWebhook serves a Slack webhook that answers with answer, recording the messages
+// posted to it. The returned service posts to it in place of the SSRF-safe client, which
+// refuses the loopback address the webhook listens on.
+func fakeSlackWebhook(t *testing.T, answer string) (*NotificationService, string, *[]SlackMessage) {
+	t.Helper()
+	var messages []SlackMessage
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message SlackMessage
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Error(err)
+		}
+		messages = append(messages, message)
+		io.WriteString(w, answer)
+	}))
+	t.Cleanup(server.Close)
+
+	s := NewNotificationService(&config.Config{})
+	s.userClient = server.Client()
+	return s, server.URL + "/services/T000/B000/XXXX", &messages
+}
+
+func TestSlackNodePostsReportToWebhook(t *testing.T) {
+	notifications, webhookURL, messages := fakeSlackWebhook(t, `{"ok":true,"channel":"C0123","ts":"1700000000.000100"}`)
+	db, mock := newMockDB(t)
+	userID := uuid.New()
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(userID, "owner@example.com"))
+	e := NewWorkflowExecutor(db, nil, notifications, nil, nil, NewAIService(&config.Config{}, nil), nil, nil, nil, config.WorkflowConfig{})
+
+	node := &WorkflowNode{ID: "notify", Type: "slack", Data: map[string]interface{}{"config": map[string]interface{}{"webhook_url": webhookURL}}}
+	previous := map[string]interface{}{
+		"trigger": map[string]interface{}{"type": "trigger", "target": "example.com"},
+		"scan":    nmapResult("22/tcp open ssh\n"),
+	}
+	result, err := e.executeNotification(context.Background(), node, previous, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := result.(map[string]interface{})
+	if got["status"] != "sent" || got["channel"] != "C0123" || got["ts"] != "1700000000.000100" {
+		t.Fatalf("slack node result = %v, want sent with the channel and ts", got)
+	}
+
+	if len(*messages) != 1 {
+		t.Fatalf("webhook got %d messages, want 1", len(*messages))
+	}
+	message := (*messages)[0]
+	if message.Text != "VulnPilot Security Workflow Report - example.com" {
+		t.Errorf("fallback text = %q", message.Text)
+	}
+	var types []string
+	for _, block := range message.Blocks {
+		types = append(types, block.Type)
+	}
+	if len(types) != 5 || types[0] != "header" || types[3] != "divider" {
+		t.Fatalf("blocks = %q, want a header, the summary sections, a divider and the report", types)
+	}
+	if fields := message.Blocks[1].Fields; len(fields) != 2 || fields[0].Text != "*Target*\nexample.com" {
+		t.Errorf("summary fields = %+v, want the target first", fields)
+	}
+}
+
+func TestSendSlackMessage(t *testing.T) {
+	// Incoming webhooks answer a plain "ok", with no channel or ts to report
+	s, webhookURL, messages := fakeSlackWebhook(t, "ok")
+	delivery, err := s.SendSlackMessage(context.Background(), webhookURL, SlackMessage{Text: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *delivery != (SlackDelivery{}) || len(*messages) != 1 || (*messages)[0].Text != "hello" {
+		t.Fatalf("delivery = %+v, messages = %+v", delivery, *messages)
+	}
+
+	if _, err := s.SendSlackMessage(context.Background(), "http://hooks.slack.com/services/T/B/X", SlackMessage{Text: "hello"}); err == nil {
+		t.Error("sent to a webhook that isn't https")
+	}
+	if _, err := s.SendSlackMessage(context.Background(), "", SlackMessage{Text: "hello"}); err == nil {
+		t.Error("sent without a webhook while the server has none configured")
+	}
+	if len(*messages) != 1 {
+		t.Errorf("webhook got %d messages, want only the first", len(*messages))
+	}
+}
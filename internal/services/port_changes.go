@@ -0,0 +1,181 @@
+package services
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// portHistoryExecutions bounds how many recent executions are searched for a previous nmap run
+const portHistoryExecutions = 20
+
+// PortChanges compares the open ports of an nmap run with the previous nmap run against the
+// same host. The first run of a host has no previous run, and reports nothing as changed.
+type PortChanges struct {
+	OpenPorts      []string   `json:"open_ports"`
+	NewlyOpened    []string   `json:"newly_opened"`
+	NewlyClosed    []string   `json:"newly_closed"`
+	PreviousSource string     `json:"previous_source,omitempty"` // execution or scan
+	PreviousID     *uuid.UUID `json:"previous_id,omitempty"`
+	PreviousAt     *time.Time `json:"previous_at,omitempty"`
+}
+
+// openPorts lists the open ports in nmap's normal output as port/protocol, e.g. 443/tcp
+func openPorts(output string) []string {
+	seen := make(map[string]struct{})
+	ports := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if !nmapOpenPortPattern.MatchString(line) {
+			continue
+		}
+		port := strings.Fields(line)[0]
+		if _, dup := seen[port]; !dup {
+			seen[port] = struct{}{}
+			ports = append(ports, port)
+		}
+	}
+	sort.Strings(ports)
+	return ports
+}
+
+// diffPorts lists the ports open now but not before, and the other way round
+func diffPorts(previous, current []string) (opened, closed []string) {
+	before := make(map[string]struct{}, len(previous))
+	for _, port := range previous {
+		before[port] = struct{}{}
+	}
+	now := make(map[string]struct{}, len(current))
+	opened, closed = []string{}, []string{}
+	for _, port := range current {
+		now[port] = struct{}{}
+		if _, ok := before[port]; !ok {
+			opened = append(opened, port)
+		}
+	}
+	for _, port := range previous {
+		if _, ok := now[port]; !ok {
+			closed = append(closed, port)
+		}
+	}
+	return opened, closed
+}
+
+// ComparePorts diffs the open ports in an nmap output with the user's most recent finished
+// nmap run against the same host, from either a workflow execution or a standalone scan
+func (s *ScannerService) ComparePorts(userID uuid.UUID, target, output string) (*PortChanges, error) {
+	changes := &PortChanges{OpenPorts: openPorts(output), NewlyOpened: []string{}, NewlyClosed: []string{}}
+	host := utils.NormalizeHost(target)
+	if host == "" {
+		return changes, nil
+	}
+
+	previous, err := previousNmapRun(s.db, userID, host)
+	if err != nil || previous == nil {
+		return changes, err
+	}
+	changes.NewlyOpened, changes.NewlyClosed = diffPorts(openPorts(previous.output), changes.OpenPorts)
+	changes.PreviousSource = previous.source
+	changes.PreviousID = &previous.id
+	changes.PreviousAt = &previous.at
+	return changes, nil
+}
+
+type nmapRun struct {
+	source string
+	id     uuid.UUID
+	at     time.Time
+	output string
+}
+
+// previousNmapRun finds the latest finished nmap output for host. Runs still in progress,
+// including the one being compared, are never picked.
+func previousNmapRun(db *gorm.DB, userID uuid.UUID, host string) (*nmapRun, error) {
+	var latest *nmapRun
+
+	var scan models.ScanResult
+	err := db.Where("user_id = ? AND scan_type = ? AND status = ? AND target_host = ?", userID, "nmap", "completed", host).
+		Order("completed_at DESC").Limit(1).Find(&scan).Error
+	if err != nil {
+		return nil, err
+	}
+	if scan.ID != uuid.Nil {
+		if output, ok := scanResultAsNodeResult(scan)["output"].(string); ok {
+			latest = &nmapRun{source: "scan", id: scan.ID, at: scan.CreatedAt, output: output}
+			if scan.CompletedAt != nil {
+				latest.at = *scan.CompletedAt
+			}
+		}
+	}
+
+	var executions []models.WorkflowExecution
+	if err := db.Where("user_id = ? AND target_host = ? AND status IN ?", userID, host, []string{"completed", "failed", "timed_out"}).
+		Order("created_at DESC").Limit(portHistoryExecutions).Find(&executions).Error; err != nil {
+		return nil, err
+	}
+	for _, execution := range executions {
+		output, ok := executionNmapOutput(execution.Results)
+		if !ok {
+			continue
+		}
+		at := execution.CreatedAt
+		if execution.CompletedAt != nil {
+			at = *execution.CompletedAt
+		}
+		if latest == nil || at.After(latest.at) {
+			latest = &nmapRun{source: "execution", id: execution.ID, at: at, output: output}
+		}
+		break
+	}
+	return latest, nil
+}
+
+// executionNmapOutput joins the outputs of an execution's nmap nodes
+func executionNmapOutput(results map[string]interface{}) (string, bool) {
+	var outputs []string
+	for _, result := range results {
+		nodeMap, ok := result.(map[string]interface{})
+		if !ok || nodeMap["scanner"] != "nmap" {
+			continue
+		}
+		if output, ok := nodeMap["output"].(string); ok {
+			outputs = append(outputs, output)
+		}
+	}
+	return strings.Join(outputs, "\n"), len(outputs) > 0
+}
+
+// nodePortChanges returns the port comparison stored on an nmap node result
+func nodePortChanges(nodeMap map[string]interface{}) *PortChanges {
+	switch stored := nodeMap["port_changes"].(type) {
+	case *PortChanges:
+		return stored
+	case map[string]interface{}:
+		// Results read back from the database decode as generic JSON
+		var changes PortChanges
+		if data, err := json.Marshal(stored); err == nil && json.Unmarshal(data, &changes) == nil {
+			return &changes
+		}
+	}
+	return nil
+}
+
+// newlyOpenedPorts collects the newly opened ports reported by the nmap nodes in results
+func newlyOpenedPorts(results map[string]interface{}) []string {
+	var opened []string
+	for _, result := range results {
+		if nodeMap, ok := result.(map[string]interface{}); ok {
+			if changes := nodePortChanges(nodeMap); changes != nil {
+				opened = append(opened, changes.NewlyOpened...)
+			}
+		}
+	}
+	sort.Strings(opened)
+	return opened
+}
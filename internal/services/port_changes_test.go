@@ -0,0 +1,178 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+const nmapBefore = `Starting Nmap 7.94 ( https://nmap.org )
+Nmap scan report for shop.example.com (93.184.216.34)
+PORT    STATE    SERVICE
+22/tcp  open     ssh
+80/tcp  open     http
+443/tcp open     https
+3306/tcp filtered mysql
+`
+
+// nmapAfter differs from nmapBefore by one port: 8080 has been opened
+const nmapAfter = `Starting Nmap 7.94 ( https://nmap.org )
+Nmap scan report for shop.example.com (93.184.216.34)
+PORT     STATE    SERVICE
+22/tcp   open     ssh
+80/tcp   open     http
+443/tcp  open     https
+3306/tcp filtered mysql
+8080/tcp open     http-proxy
+`
+
+func TestOpenPorts(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{name: "no output", output: "", want: []string{}},
+		{name: "filtered and closed ports are not open", output: "25/tcp closed smtp\n3306/tcp filtered mysql", want: []string{}},
+		{name: "sorted", output: nmapAfter, want: []string{"22/tcp", "443/tcp", "80/tcp", "8080/tcp"}},
+		{name: "udp and duplicates", output: "53/udp open domain\n53/udp open domain\n53/tcp open domain", want: []string{"53/tcp", "53/udp"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := openPorts(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("openPorts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffPorts(t *testing.T) {
+	tests := []struct {
+		name               string
+		previous, current  []string
+		wantOpen, wantShut []string
+	}{
+		{name: "unchanged", previous: []string{"22/tcp", "80/tcp"}, current: []string{"22/tcp", "80/tcp"}, wantOpen: []string{}, wantShut: []string{}},
+		{name: "one opened", previous: []string{"22/tcp"}, current: []string{"22/tcp", "8080/tcp"}, wantOpen: []string{"8080/tcp"}, wantShut: []string{}},
+		{name: "one closed", previous: []string{"22/tcp", "23/tcp"}, current: []string{"22/tcp"}, wantOpen: []string{}, wantShut: []string{"23/tcp"}},
+		{name: "protocol matters", previous: []string{"53/tcp"}, current: []string{"53/udp"}, wantOpen: []string{"53/udp"}, wantShut: []string{"53/tcp"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opened, closed := diffPorts(tt.previous, tt.current)
+			if !reflect.DeepEqual(opened, tt.wantOpen) || !reflect.DeepEqual(closed, tt.wantShut) {
+				t.Fatalf("diffPorts() = %v, %v, want %v, %v", opened, closed, tt.wantOpen, tt.wantShut)
+			}
+		})
+	}
+}
+
+func TestComparePortsAgainstPreviousRun(t *testing.T) {
+	scanID, executionID := uuid.New(), uuid.New()
+	scanAt := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	executionAt := scanAt.Add(time.Hour)
+	scanRow := func(mock sqlmock.Sqlmock, output string) {
+		mock.ExpectQuery(`SELECT \* FROM "scan_results" WHERE user_id = \$1 AND scan_type = \$2 AND status = \$3 AND target_host = \$4 ORDER BY completed_at DESC`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "scan_type", "status", "results", "completed_at", "created_at"}).
+				AddRow(scanID, "nmap", "completed", []byte(`{"output":`+quoteJSON(t, output)+`}`), scanAt, scanAt))
+	}
+	noScans := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(`FROM "scan_results"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	}
+	executionRow := func(mock sqlmock.Sqlmock, output string) {
+		mock.ExpectQuery(`SELECT \* FROM "workflow_executions" WHERE user_id = \$1 AND target_host = \$2 AND status IN \(\$3,\$4,\$5\) ORDER BY created_at DESC`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status", "results", "completed_at", "created_at"}).
+				AddRow(executionID, "completed", []byte(`{"trigger":{"status":"completed"},"ports":{"scanner":"nmap","output":`+quoteJSON(t, output)+`}}`), executionAt, executionAt))
+	}
+	noExecutions := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(`FROM "workflow_executions"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	}
+
+	tests := []struct {
+		name       string
+		expect     func(mock sqlmock.Sqlmock)
+		output     string
+		want       PortChanges
+		wantSource string
+		wantID     uuid.UUID
+	}{
+		{
+			name:   "first run of the host",
+			expect: func(mock sqlmock.Sqlmock) { noScans(mock); noExecutions(mock) },
+			output: nmapAfter,
+			want:   PortChanges{OpenPorts: []string{"22/tcp", "443/tcp", "80/tcp", "8080/tcp"}, NewlyOpened: []string{}, NewlyClosed: []string{}},
+		},
+		{
+			name:       "port opened since the last scan",
+			expect:     func(mock sqlmock.Sqlmock) { scanRow(mock, nmapBefore); noExecutions(mock) },
+			output:     nmapAfter,
+			want:       PortChanges{OpenPorts: []string{"22/tcp", "443/tcp", "80/tcp", "8080/tcp"}, NewlyOpened: []string{"8080/tcp"}, NewlyClosed: []string{}},
+			wantSource: "scan",
+			wantID:     scanID,
+		},
+		{
+			name:       "port closed since the last execution",
+			expect:     func(mock sqlmock.Sqlmock) { noScans(mock); executionRow(mock, nmapAfter) },
+			output:     nmapBefore,
+			want:       PortChanges{OpenPorts: []string{"22/tcp", "443/tcp", "80/tcp"}, NewlyOpened: []string{}, NewlyClosed: []string{"8080/tcp"}},
+			wantSource: "execution",
+			wantID:     executionID,
+		},
+		{
+			name:       "newer execution wins over an older scan",
+			expect:     func(mock sqlmock.Sqlmock) { scanRow(mock, nmapBefore); executionRow(mock, nmapAfter) },
+			output:     nmapAfter,
+			want:       PortChanges{OpenPorts: []string{"22/tcp", "443/tcp", "80/tcp", "8080/tcp"}, NewlyOpened: []string{}, NewlyClosed: []string{}},
+			wantSource: "execution",
+			wantID:     executionID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			tt.expect(mock)
+			s := &ScannerService{db: db}
+
+			changes, err := s.ComparePorts(uuid.New(), "https://shop.example.com/", tt.output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changes.PreviousSource != tt.wantSource {
+				t.Errorf("previous source = %q, want %q", changes.PreviousSource, tt.wantSource)
+			}
+			if tt.wantID != uuid.Nil && (changes.PreviousID == nil || *changes.PreviousID != tt.wantID) {
+				t.Errorf("previous ID = %v, want %s", changes.PreviousID, tt.wantID)
+			}
+			got := PortChanges{OpenPorts: changes.OpenPorts, NewlyOpened: changes.NewlyOpened, NewlyClosed: changes.NewlyClosed}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ComparePorts() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewlyOpenedPorts(t *testing.T) {
+	results := map[string]interface{}{
+		"trigger": map[string]interface{}{"status": "completed"},
+		"web":     map[string]interface{}{"scanner": "nmap", "port_changes": &PortChanges{NewlyOpened: []string{"8080/tcp"}}},
+		// Read back from the database
+		"db": map[string]interface{}{"scanner": "nmap", "port_changes": map[string]interface{}{"newly_opened": []interface{}{"5432/tcp"}}},
+	}
+	want := []string{"5432/tcp", "8080/tcp"}
+	if got := newlyOpenedPorts(results); !reflect.DeepEqual(got, want) {
+		t.Fatalf("newlyOpenedPorts() = %v, want %v", got, want)
+	}
+}
+
+func quoteJSON(t *testing.T, s string) string {
+	t.Helper()
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+	_ "time/tzdata" // Validate timezones even on hosts without a zoneinfo database
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidPreference is returned when a preference value is not one of the accepted options
+var ErrInvalidPreference = errors.New("invalid preference")
+
+// NotificationChannels lists the channels a generic notify node can deliver to
+var NotificationChannels = []string{"email", "slack"}
+
+// PreferencesUpdate carries the preferences to change; nil fields are left untouched
+type PreferencesUpdate struct {
+	AIProvider          *string `json:"ai_provider,omitempty"`
+	Language            *string `json:"language,omitempty"`
+	NotificationChannel *string `json:"notification_channel,omitempty"`
+	Timezone            *string `json:"timezone,omitempty"`
+	SIEMURL             *string `json:"siem_url,omitempty"`
+	SIEMFormat          *string `json:"siem_format,omitempty"`
+	SIEMSecret          *string `json:"siem_secret,omitempty"`
+}
+
+// UpdatePreferences validates and stores the user's default settings
+func (s *AuthService) UpdatePreferences(userID uuid.UUID, update PreferencesUpdate) (*models.User, error) {
+	updates := make(map[string]interface{})
+
+	if update.AIProvider != nil {
+		switch *update.AIProvider {
+		case "", ProviderGemini, ProviderGroq, ProviderClaude:
+			updates["ai_provider"] = *update.AIProvider
+		default:
+			return nil, fmt.Errorf("%w: ai_provider must be %q, %q or %q", ErrInvalidPreference, ProviderGemini, ProviderGroq, ProviderClaude)
+		}
+	}
+	if update.Language != nil {
+		code, err := NormalizeLanguage(*update.Language)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPreference, err)
+		}
+		updates["language"] = code
+	}
+	if update.NotificationChannel != nil {
+		if !isNotificationChannel(*update.NotificationChannel) {
+			return nil, fmt.Errorf("%w: notification_channel must be one of %v", ErrInvalidPreference, NotificationChannels)
+		}
+		updates["notification_channel"] = *update.NotificationChannel
+	}
+	if update.Timezone != nil {
+		if _, err := time.LoadLocation(*update.Timezone); err != nil || *update.Timezone == "" {
+			return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidPreference, *update.Timezone)
+		}
+		updates["timezone"] = *update.Timezone
+	}
+	if update.SIEMURL != nil {
+		if *update.SIEMURL != "" && !isHTTPURL(*update.SIEMURL) {
+			return nil, fmt.Errorf("%w: siem_url must be an http or https URL", ErrInvalidPreference)
+		}
+		updates["siem_url"] = *update.SIEMURL
+	}
+	if update.SIEMFormat != nil {
+		if !IsSIEMFormat(*update.SIEMFormat) {
+			return nil, fmt.Errorf("%w: siem_format must be ecs or cef", ErrInvalidPreference)
+		}
+		updates["siem_format"] = *update.SIEMFormat
+	}
+	if update.SIEMSecret != nil {
+		updates["siem_secret"] = *update.SIEMSecret
+	}
+
+	if len(updates) > 0 {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+				return fmt.Errorf("failed to update preferences: %w", err)
+			}
+			if _, ok := updates["timezone"]; !ok {
+				return nil
+			}
+
+			// Schedules without their own timezone follow the user's, so their next run moves too
+			var workflows []models.Workflow
+			if err := tx.Where("user_id = ? AND schedule_enabled = ? AND (schedule_timezone = '' OR schedule_timezone IS NULL)", userID, true).
+				Find(&workflows).Error; err != nil {
+				return err
+			}
+			for i := range workflows {
+				if err := refreshNextRun(tx, &workflows[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.GetUserByID(userID)
+}
+
+// UserLocation returns the user's preferred timezone, falling back to UTC
+func UserLocation(prefs models.UserPreferences) *time.Location {
+	if prefs.Timezone != "" {
+		if loc, err := time.LoadLocation(prefs.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+func isHTTPURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+func isNotificationChannel(channel string) bool {
+	for _, c := range NotificationChannels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
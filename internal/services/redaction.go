@@ -0,0 +1,126 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	redactedValue = "[REDACTED]"
+	redactionMask = "****"
+
+	// Partial redaction keeps this many leading characters, enough to tell secrets apart,
+	// of values at least redactionMinPartial long; shorter values are masked entirely
+	redactionKeep       = 4
+	redactionMinPartial = 12
+)
+
+// sensitiveKeys are field names whose values are secrets, as used by gitleaks and other
+// scanners' JSON reports
+var sensitiveKeys = map[string]bool{
+	"secret": true, "match": true, "password": true, "passwd": true, "token": true,
+	"access_token": true, "api_key": true, "apikey": true, "private_key": true, "client_secret": true,
+}
+
+// sensitiveJSONField matches a sensitive key with a string value inside JSON text
+var sensitiveJSONField = regexp.MustCompile(`(?i)"(secret|match|password|passwd|token|access_token|api_key|apikey|private_key|client_secret)"\s*:\s*"((?:[^"\\]|\\.)*)"`)
+
+// sensitiveAssignment matches key=value and key: value pairs in plain text and URLs
+var sensitiveAssignment = regexp.MustCompile(`(?i)\b(password|passwd|pwd|secret|api[_-]?key|access[_-]?token|auth[_-]?token)\s*[:=]\s*['"]?([^\s'",;&]{4,})`)
+
+// secretPatterns match well-known credential formats wherever they appear
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN[A-Z ]*PRIVATE KEY-----[\s\S]*?-----END[A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}\b`),
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`),
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}\b`),
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\b`),
+}
+
+// Redactor masks secret values in scan output before it is stored, notified, filed as an
+// issue or exported, so the secrets a scan finds aren't leaked by the scan itself
+type Redactor struct {
+	full bool // Mask whole values instead of keeping a short prefix
+}
+
+// NewRedactor returns a redactor for mode, partial or full
+func NewRedactor(mode string) *Redactor {
+	return &Redactor{full: mode == "full"}
+}
+
+// mask hides value, keeping a short prefix in partial mode. Values that are already
+// masked come back unchanged, so redacting twice is harmless.
+func (r *Redactor) mask(value string) string {
+	if value == "" || value == redactedValue || strings.HasSuffix(value, redactionMask) {
+		return value
+	}
+	runes := []rune(value)
+	if r.full || len(runes) < redactionMinPartial {
+		return redactedValue
+	}
+	// Don't leave a dangling escape from JSON-encoded text
+	return strings.TrimRight(string(runes[:redactionKeep]), `\`) + redactionMask
+}
+
+// Text masks the secrets in free text or JSON
+func (r *Redactor) Text(text string) string {
+	if r == nil || text == "" {
+		return text
+	}
+	text = r.maskGroup(sensitiveJSONField, text, 2)
+	text = r.maskGroup(sensitiveAssignment, text, 2)
+	for _, pattern := range secretPatterns {
+		text = pattern.ReplaceAllStringFunc(text, r.mask)
+	}
+	return text
+}
+
+// maskGroup masks the given submatch of every match of pattern, leaving the rest of the match
+func (r *Redactor) maskGroup(pattern *regexp.Regexp, text string, group int) string {
+	matches := pattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[2*group], m[2*group+1]
+		if start < 0 {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(r.mask(text[start:end]))
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Value masks the secrets in a decoded JSON value, such as a node result, in place where
+// it can and returns the redacted value
+func (r *Redactor) Value(value interface{}) interface{} {
+	if r == nil {
+		return value
+	}
+	switch v := value.(type) {
+	case string:
+		return r.Text(v)
+	case map[string]interface{}:
+		for key, item := range v {
+			if s, ok := item.(string); ok && sensitiveKeys[strings.ToLower(key)] {
+				v[key] = r.mask(s)
+				continue
+			}
+			v[key] = r.Value(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.Value(item)
+		}
+		return v
+	}
+	return value
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// imageRefPattern matches an image reference: an optional registry host (with port), a
+// repository path, and an optional tag and digest, e.g. registry.internal:5000/team/app:1.2
+var imageRefPattern = regexp.MustCompile(`^([a-zA-Z0-9.-]+(:[0-9]+)?/)?[a-z0-9]+([._-]+[a-z0-9]+)*(/[a-z0-9]+([._-]+[a-z0-9]+)*)*(:[A-Za-z0-9_][A-Za-z0-9_.-]{0,127})?(@sha256:[a-f0-9]{64})?$`)
+
+// RegistryAuth carries the credentials for pulling an image from a private registry.
+// Values come from the secret store and must never be logged or written to results.
+type RegistryAuth struct {
+	Username string
+	Password string
+	Token    string // Registry bearer token, used instead of a username and password
+}
+
+// validateImageRef rejects image references trivy would misread, including ones that look like flags
+func validateImageRef(image string) error {
+	if image == "" {
+		return fmt.Errorf("no image given for the container scan")
+	}
+	if len(image) > 512 || !imageRefPattern.MatchString(image) {
+		return fmt.Errorf("invalid image reference %q", image)
+	}
+	return nil
+}
+
+// imageRegistry returns the registry host of an image reference, or docker.io when it has none
+func imageRegistry(image string) string {
+	host, _, ok := strings.Cut(image, "/")
+	// Like Docker, the first component is only a host if it looks like one
+	if !ok || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		return "docker.io"
+	}
+	return host
+}
+
+// resolveRegistryAuth builds registry credentials from a node's "registryUsername" and
+// "registryPassword" or "registryToken" fields. The password and token name secrets, so
+// workflow definitions never contain the credentials themselves.
+func (e *WorkflowExecutor) resolveRegistryAuth(node *WorkflowNode, userID uuid.UUID) (*RegistryAuth, error) {
+	username, _ := node.Data["registryUsername"].(string)
+	passwordSecret, _ := node.Data["registryPassword"].(string)
+	tokenSecret, _ := node.Data["registryToken"].(string)
+
+	switch {
+	case passwordSecret == "" && tokenSecret == "":
+		if username != "" {
+			return nil, fmt.Errorf("registryUsername needs a registryPassword secret")
+		}
+		return nil, nil
+	case passwordSecret != "" && tokenSecret != "":
+		return nil, fmt.Errorf("set either registryPassword or registryToken, not both")
+	case passwordSecret != "" && username == "":
+		return nil, fmt.Errorf("registryPassword needs a registryUsername")
+	}
+
+	auth := &RegistryAuth{Username: username}
+	if passwordSecret != "" {
+		value, err := e.secrets.Resolve(userID, passwordSecret)
+		if err != nil {
+			return nil, err
+		}
+		auth.Password = value
+	} else {
+		value, err := e.secrets.Resolve(userID, tokenSecret)
+		if err != nil {
+			return nil, err
+		}
+		auth.Token = value
+	}
+	if strings.ContainsAny(auth.Username+auth.Password+auth.Token, "\r\n\x00") {
+		return nil, fmt.Errorf("registry credentials contain invalid characters")
+	}
+	return auth, nil
+}
+
+// trivyEnv returns the environment trivy reads registry credentials from. They're passed in
+// the environment rather than as flags so they don't show up in process listings.
+func (a *RegistryAuth) trivyEnv() []string {
+	if a == nil {
+		return nil
+	}
+	if a.Token != "" {
+		return []string{"TRIVY_REGISTRY_TOKEN=" + a.Token}
+	}
+	return []string{"TRIVY_USERNAME=" + a.Username, "TRIVY_PASSWORD=" + a.Password}
+}
+
+// redact masks credential values that trivy echoes back in its output or errors
+func (a *RegistryAuth) redact(output []byte) []byte {
+	if a == nil {
+		return output
+	}
+	for _, value := range []string{a.Password, a.Token} {
+		if value != "" {
+			output = bytes.ReplaceAll(output, []byte(value), []byte("[REDACTED]"))
+		}
+	}
+	return output
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/google/uuid"
+)
+
+func TestValidateImageRef(t *testing.T) {
+	tests := []struct {
+		image   string
+		wantErr bool
+	}{
+		{image: "nginx"},
+		{image: "library/nginx:1.25"},
+		{image: "registry.internal:5000/team/app:1.2"},
+		{image: "ghcr.io/acme/api@sha256:" + strings.Repeat("a", 64)},
+		{image: "localhost/app:dev"},
+		{image: "", wantErr: true},
+		{image: "--config=/etc/passwd", wantErr: true},
+		{image: "Registry/App", wantErr: true},
+		{image: "app:1.2; rm -rf /", wantErr: true},
+		{image: "registry.internal/" + strings.Repeat("a", 512), wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := validateImageRef(tt.image); (err != nil) != tt.wantErr {
+			t.Errorf("validateImageRef(%q) = %v, want error %v", tt.image, err, tt.wantErr)
+		}
+	}
+}
+
+func TestImageRegistry(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                           "docker.io",
+		"library/nginx":                   "docker.io",
+		"registry.internal:5000/team/app": "registry.internal:5000",
+		"ghcr.io/acme/api:1.0":            "ghcr.io",
+		"localhost/app":                   "localhost",
+		"team/app@sha256:0123456789ab":    "docker.io",
+	}
+	for image, want := range tests {
+		if got := imageRegistry(image); got != want {
+			t.Errorf("imageRegistry(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+// registrySecrets returns an executor resolving secrets from a store holding values by name
+func registrySecrets(t *testing.T, userID uuid.UUID, values map[string]string) *WorkflowExecutor {
+	t.Helper()
+	keys, err := utils.NewKeyRing(map[int]string{1: "test key"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, mock := newMockDB(t)
+	mock.MatchExpectationsInOrder(false)
+	for name, value := range values {
+		ciphertext, version, err := keys.Encrypt(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mock.ExpectQuery(`SELECT \* FROM "secrets" WHERE user_id = \$1 AND name = \$2`).
+			WithArgs(userID, name, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "ciphertext", "key_version"}).
+				AddRow(uuid.New(), userID, name, ciphertext, version))
+	}
+	return &WorkflowExecutor{secrets: NewSecretStore(db, keys)}
+}
+
+func TestResolveRegistryAuth(t *testing.T) {
+	secrets := map[string]string{"ghcr-password": "s3cr3t-pa55", "ghcr-token": "ghp_registrytoken", "bad-password": "line\nbreak"}
+	tests := []struct {
+		name     string
+		data     map[string]interface{}
+		resolves string // Secret looked up once the fields are consistent
+		want     *RegistryAuth
+		wantErr  string
+	}{
+		{name: "public image", data: map[string]interface{}{}},
+		{name: "username and password", data: map[string]interface{}{"registryUsername": "ci", "registryPassword": "ghcr-password"},
+			resolves: "ghcr-password", want: &RegistryAuth{Username: "ci", Password: "s3cr3t-pa55"}},
+		{name: "token", data: map[string]interface{}{"registryToken": "ghcr-token"},
+			resolves: "ghcr-token", want: &RegistryAuth{Token: "ghp_registrytoken"}},
+		{name: "username alone", data: map[string]interface{}{"registryUsername": "ci"}, wantErr: "registryUsername needs a registryPassword secret"},
+		{name: "password alone", data: map[string]interface{}{"registryPassword": "ghcr-password"}, wantErr: "registryPassword needs a registryUsername"},
+		{name: "password and token", data: map[string]interface{}{"registryUsername": "ci", "registryPassword": "ghcr-password", "registryToken": "ghcr-token"},
+			wantErr: "set either registryPassword or registryToken, not both"},
+		{name: "password with a newline", data: map[string]interface{}{"registryUsername": "ci", "registryPassword": "bad-password"},
+			resolves: "bad-password", wantErr: "registry credentials contain invalid characters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			stored := map[string]string{}
+			if tt.resolves != "" {
+				stored[tt.resolves] = secrets[tt.resolves]
+			}
+			e := registrySecrets(t, userID, stored)
+			got, err := e.resolveRegistryAuth(&WorkflowNode{ID: "image", Data: tt.data}, userID)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("resolveRegistryAuth() = %+v, %v, want error %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("resolveRegistryAuth() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+
+	t.Run("missing secret", func(t *testing.T) {
+		userID := uuid.New()
+		db, mock := newMockDB(t)
+		mock.ExpectQuery(`SELECT \* FROM "secrets"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		e := &WorkflowExecutor{secrets: NewSecretStore(db, nil)}
+		_, err := e.resolveRegistryAuth(&WorkflowNode{Data: map[string]interface{}{"registryToken": "gone"}}, userID)
+		if !errors.Is(err, ErrSecretNotFound) {
+			t.Fatalf("resolveRegistryAuth() = %v, want ErrSecretNotFound", err)
+		}
+	})
+}
+
+// recordingRunner is a ToolRunner that records each run and answers with output and err
+type recordingRunner struct {
+	runs   []ToolRun
+	output string
+	err    error
+}
+
+func (r *recordingRunner) Available(string) bool { return true }
+
+func (r *recordingRunner) Run(ctx context.Context, run ToolRun) ([]byte, error) {
+	r.runs = append(r.runs, run)
+	return []byte(r.output), r.err
+}
+
+func trivyImageScanner(runner ToolRunner) *ScannerService {
+	return NewScannerService(nil, nil, NewResultSigner(""), nil, nil, nil, NewScanLimiter(1, 1, 0, time.Minute), runner, ScanSavePolicy{}, ScanMockPolicy{}, nil)
+}
+
+func TestRunTrivyImageWiresCredentials(t *testing.T) {
+	const password, token = "s3cr3t-pa55", "ghp_registrytoken"
+	tests := []struct {
+		name    string
+		auth    *RegistryAuth
+		wantEnv []string
+	}{
+		{name: "public image", auth: nil, wantEnv: nil},
+		{name: "username and password", auth: &RegistryAuth{Username: "ci", Password: password}, wantEnv: []string{"TRIVY_USERNAME=ci", "TRIVY_PASSWORD=" + password}},
+		{name: "token", auth: &RegistryAuth{Token: token}, wantEnv: []string{"TRIVY_REGISTRY_TOKEN=" + token}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var credential string
+			if tt.auth != nil {
+				credential = tt.auth.Password + tt.auth.Token
+			}
+			// A report that, like some registry errors, echoes the credential back
+			runner := &recordingRunner{output: `{"Results":[{"Target":"app","Vulnerabilities":[{"VulnerabilityID":"CVE-2024-0001","Title":"auth ` + credential + `"}]}]}`}
+			output, err := trivyImageScanner(runner).RunTrivyImage(context.Background(), "registry.internal:5000/team/app:1.2", tt.auth)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(runner.runs) != 1 {
+				t.Fatalf("trivy ran %d times, want once", len(runner.runs))
+			}
+			run := runner.runs[0]
+			if !reflect.DeepEqual(run.Env, tt.wantEnv) {
+				t.Errorf("env = %q, want %q", run.Env, tt.wantEnv)
+			}
+			if args := strings.Join(run.Args, " "); strings.Contains(args, password) || strings.Contains(args, token) || !strings.HasSuffix(args, "registry.internal:5000/team/app:1.2") {
+				t.Errorf("args = %q, want the image and no credentials", args)
+			}
+			if credential != "" && strings.Contains(output, credential) {
+				t.Errorf("output contains the credentials: %s", output)
+			}
+		})
+	}
+
+	t.Run("failed pull", func(t *testing.T) {
+		runner := &recordingRunner{output: "unauthorized: ci:" + password + " rejected", err: errors.New("exit status 1")}
+		_, err := trivyImageScanner(runner).RunTrivyImage(context.Background(), "registry.internal:5000/team/app:1.2", &RegistryAuth{Username: "ci", Password: password})
+		if err == nil || strings.Contains(err.Error(), password) || !strings.Contains(err.Error(), "[REDACTED]") {
+			t.Fatalf("RunTrivyImage() = %v, want an error with the password redacted", err)
+		}
+	})
+
+	t.Run("sandboxed", func(t *testing.T) {
+		// The sandbox hands the credentials to the container's environment, not its command
+		r := &sandboxRunner{}
+		spec := r.containerSpec("aquasec/trivy", ToolRun{Tool: "trivy", Args: []string{"image", "app"}, Env: []string{"TRIVY_PASSWORD=" + password}})
+		if !reflect.DeepEqual(spec.Env, []string{"TRIVY_PASSWORD=" + password}) || strings.Contains(strings.Join(spec.Cmd, " "), password) {
+			t.Fatalf("container spec env %q, cmd %q", spec.Env, spec.Cmd)
+		}
+	})
+}
+
+func TestContainerScanLogsNoCredentials(t *testing.T) {
+	const password = "s3cr3t-pa55"
+	userID := uuid.New()
+	e := registrySecrets(t, userID, map[string]string{"ghcr-password": password})
+	e.scannerService = trivyImageScanner(&recordingRunner{output: `{"Results":[]}`})
+
+	var out bytes.Buffer
+	ctx := utils.WithLogger(context.Background(), slog.New(slog.NewTextHandler(&out, nil)))
+	executionLog := newExecutionLog()
+	ctx = withExecutionLog(ctx, executionLog)
+
+	node := &WorkflowNode{ID: "image", Type: "container-scan", Data: map[string]interface{}{
+		"image": "registry.internal:5000/team/app:1.2", "registryUsername": "ci", "registryPassword": "ghcr-password",
+	}}
+	result, err := containerScanner{}.Run(ctx, &ScanEnv{Scanner: e.scannerService, UserID: userID, executor: e}, node, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorded, _ := json.Marshal(executionLog.Snapshot())
+	resultJSON, _ := json.Marshal(result)
+	for name, text := range map[string]string{"server log": out.String(), "execution log": string(recorded), "result": string(resultJSON)} {
+		if strings.Contains(text, password) {
+			t.Errorf("%s contains the password: %s", name, text)
+		}
+	}
+	if !strings.Contains(out.String(), "(registry registry.internal:5000)") {
+		t.Errorf("server log doesn't name the registry: %s", out.String())
+	}
+}
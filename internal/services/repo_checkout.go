@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+)
+
+// gitClone shallow-clones a GitHub repository at branch, or its default branch when branch is
+// empty. The token, if any, is passed through git's environment config so it never appears in
+// argv or the remote URL.
+func gitClone(ctx context.Context, token, owner, repo, branch, dir string) error {
+	if !githubNamePattern.MatchString(owner) || !githubNamePattern.MatchString(repo) {
+		return fmt.Errorf("invalid repository %s/%s", owner, repo)
+	}
+
+	args := []string{"clone", "--depth", "1"}
+	if branch != "" {
+		args = append(args, "--single-branch", "--branch", branch)
+	}
+	args = append(args, "--", fmt.Sprintf("https://github.com/%s/%s.git", owner, repo), dir)
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if token != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+		cmd.Env = append(cmd.Env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.https://github.com/.extraheader",
+			"GIT_CONFIG_VALUE_0=AUTHORIZATION: basic "+credentials,
+		)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git clone failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// nodeRepository resolves the GitHub repository a node works on: owner and repo from its data,
+// falling back to the trigger's github.com target
+func (e *WorkflowExecutor) nodeRepository(node *WorkflowNode, previousResults map[string]interface{}) (string, string) {
+	owner, repo := e.parseGitHubTarget(e.getTarget(node, previousResults))
+	if val, ok := node.Data["owner"].(string); ok && val != "" {
+		owner = val
+	}
+	if val, ok := node.Data["repo"].(string); ok && val != "" {
+		repo = val
+	}
+	return owner, repo
+}
+
+// checkoutRepository clones the repository into a temporary directory for a source scan,
+// with the user's GitHub token when they have one so private repositories can be read. The
+// returned func removes the checkout.
+func (e *WorkflowExecutor) checkoutRepository(ctx context.Context, userID uuid.UUID, owner, repo, branch string) (string, func(), error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", nil, fmt.Errorf("git is not installed")
+	}
+
+	var user models.User
+	if err := e.db.First(&user, "id = ?", userID).Error; err != nil {
+		return "", nil, fmt.Errorf("failed to fetch user: %v", err)
+	}
+
+	dir, err := os.MkdirTemp("", "vulnpilot-scan-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	checkout := filepath.Join(dir, "repo")
+	if err := gitClone(ctx, user.AccessToken, owner, repo, branch, checkout); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return checkout, cleanup, nil
+}
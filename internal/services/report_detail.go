@@ -0,0 +1,44 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// How much of the scan results notification and issue nodes include
+const (
+	ReportDetailFull    = "full"    // AI summary and the raw scanner output
+	ReportDetailSummary = "summary" // AI summary and finding counts only
+)
+
+// ReportDetails lists the detail levels a node may select
+var ReportDetails = []string{ReportDetailFull, ReportDetailSummary}
+
+// reportDetail reads the node's detail level, using fallback when it doesn't set one
+func reportDetail(node *WorkflowNode, fallback string) (string, error) {
+	detail, _ := node.Data["detail"].(string)
+	switch detail {
+	case "":
+		return fallback, nil
+	case ReportDetailFull, ReportDetailSummary:
+		return detail, nil
+	}
+	return "", fmt.Errorf("detail must be %s, got %q", strings.Join(ReportDetails, " or "), detail)
+}
+
+// findingCounts describes the findings in results by severity, most severe first.
+// It is what summary reports say about the scans instead of their output.
+func findingCounts(results map[string]interface{}) string {
+	findings := extractFindings(results)
+	if len(findings) == 0 {
+		return "No findings."
+	}
+	counts := countBySeverity(findings)
+	var parts []string
+	for _, severity := range Severities {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[severity], severity))
+		}
+	}
+	return fmt.Sprintf("%d finding(s): %s", len(findings), strings.Join(parts, ", "))
+}
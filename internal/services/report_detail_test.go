@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/google/uuid"
+)
+
+// rawReconOutput is scanner output whose header, unlike the open port it reports, must stay
+// out of summary reports
+const rawReconOutput = "Nmap scan report for internal-bastion.corp.example (10.0.4.12)\n22/tcp open ssh"
+
+const rawReconHeader = "Nmap scan report for internal-bastion.corp.example"
+
+func reconResults() map[string]interface{} {
+	return map[string]interface{}{
+		"trigger": map[string]interface{}{"status": "completed", "target": "https://github.com/acme/api"},
+		"ports":   withFindings(map[string]interface{}{"scanner": "nmap", "status": "completed", "output": rawReconOutput}),
+	}
+}
+
+func TestReportDetail(t *testing.T) {
+	tests := []struct {
+		name     string
+		detail   interface{}
+		fallback string
+		want     string
+		wantErr  bool
+	}{
+		{name: "fallback", fallback: ReportDetailSummary, want: ReportDetailSummary},
+		{name: "full", detail: "full", fallback: ReportDetailSummary, want: ReportDetailFull},
+		{name: "summary", detail: "summary", fallback: ReportDetailFull, want: ReportDetailSummary},
+		{name: "unknown", detail: "verbose", fallback: ReportDetailFull, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &WorkflowNode{Data: map[string]interface{}{}}
+			if tt.detail != nil {
+				node.Data["detail"] = tt.detail
+			}
+			got, err := reportDetail(node, tt.fallback)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Fatalf("reportDetail() = %q, %v, want %q (error %v)", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIssueBodyDetail(t *testing.T) {
+	e, _ := newTestExecutor(t, config.WorkflowConfig{})
+	results := reconResults()
+
+	full := e.issueBody(context.Background(), results, ReportDetailFull, "en")
+	if !strings.Contains(full, rawReconHeader) {
+		t.Errorf("full issue body leaves out the raw output:\n%s", full)
+	}
+
+	summary := e.issueBody(context.Background(), results, ReportDetailSummary, "en")
+	if strings.Contains(summary, "internal-bastion") {
+		t.Errorf("summary issue body contains raw output:\n%s", summary)
+	}
+	if !strings.Contains(summary, "1 finding(s): 1 info") {
+		t.Errorf("summary issue body leaves out the finding counts:\n%s", summary)
+	}
+}
+
+func TestGitHubIssueDetailFollowsRepositoryVisibility(t *testing.T) {
+	tests := []struct {
+		name       string
+		repository func(w http.ResponseWriter) // Answer to GET /repos/acme/api
+		detail     string                      // Set on the node when not empty
+		want       string
+	}{
+		{name: "public repository", repository: repositoryAnswer(false), want: ReportDetailSummary},
+		{name: "private repository", repository: repositoryAnswer(true), want: ReportDetailFull},
+		{name: "visibility unknown", repository: func(w http.ResponseWriter) { http.Error(w, "nope", http.StatusForbidden) }, want: ReportDetailSummary},
+		{name: "public repository asking for full output", repository: repositoryAnswer(false), detail: ReportDetailFull, want: ReportDetailFull},
+		{name: "private repository asking for a summary", repository: repositoryAnswer(true), detail: ReportDetailSummary, want: ReportDetailSummary},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var filed string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/api":
+					tt.repository(w)
+				case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/api/issues":
+					var issue GitHubIssueRequest
+					json.NewDecoder(r.Body).Decode(&issue)
+					filed = issue.Body
+					w.WriteHeader(http.StatusCreated)
+					json.NewEncoder(w).Encode(GitHubIssue{ID: 1, Number: 7, HTMLURL: "https://github.com/acme/api/issues/7"})
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL)
+					http.NotFound(w, r)
+				}
+			}))
+			t.Cleanup(server.Close)
+			target, _ := url.Parse(server.URL)
+			client := &http.Client{Timeout: 5 * time.Second, Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+				return http.DefaultTransport.RoundTrip(req)
+			})}
+
+			db, mock := newMockDB(t)
+			userID := uuid.New()
+			mock.ExpectQuery(`SELECT \* FROM "users" WHERE id = \$1`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "access_token", "github_scope"}).AddRow(userID, "gho_token", "repo"))
+			breakers := NewCircuitBreakers(5, time.Minute, client)
+			e := NewWorkflowExecutor(db, nil, nil, nil, nil, NewAIService(&config.Config{}, breakers),
+				NewGitHubService(db, breakers, config.GitHubConfig{}), nil, nil, config.WorkflowConfig{})
+
+			node := &WorkflowNode{ID: "issue", Type: "github-issue", Data: map[string]interface{}{}}
+			if tt.detail != "" {
+				node.Data["detail"] = tt.detail
+			}
+			result, err := e.executeGitHubIssue(context.Background(), node, reconResults(), userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := result.(map[string]interface{})["detail"]; got != tt.want {
+				t.Errorf("detail = %v, want %s", got, tt.want)
+			}
+			if hasRaw := strings.Contains(filed, rawReconHeader); hasRaw != (tt.want == ReportDetailFull) {
+				t.Errorf("issue body includes raw output: %v, want %v\n%s", hasRaw, tt.want == ReportDetailFull, filed)
+			}
+		})
+	}
+}
+
+func repositoryAnswer(private bool) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		json.NewEncoder(w).Encode(GitHubRepo{Name: "api", FullName: "acme/api", Private: private})
+	}
+}
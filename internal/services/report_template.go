@@ -0,0 +1,94 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxTemplateFindings caps how many findings of one severity the template report lists
+const maxTemplateFindings = 20
+
+// ReportFinding is a single finding passed to report generation
+type ReportFinding struct {
+	Title    string
+	Severity Severity
+}
+
+// reportFindings converts the findings in node results for report generation, listing an
+// issue several scanners found once with the scanners that reported it
+func reportFindings(results map[string]interface{}) []ReportFinding {
+	groups := correlateFindings(extractFindings(results))
+	findings := make([]ReportFinding, len(groups))
+	for i, group := range groups {
+		title := group.Key
+		if len(group.Sources) > 1 {
+			title = fmt.Sprintf("%s (reported by %s)", group.Key, strings.Join(group.Sources, ", "))
+		}
+		findings[i] = ReportFinding{Title: title, Severity: group.Severity}
+	}
+	return findings
+}
+
+// severityAdvice is the template recommendation for each severity that has findings
+var severityAdvice = map[Severity]string{
+	SeverityCritical: "Remediate critical findings immediately and consider taking affected services offline until they are fixed.",
+	SeverityHigh:     "Schedule high-severity findings for the current release and verify each fix with a rescan.",
+	SeverityMedium:   "Triage medium-severity findings and track them in the backlog.",
+	SeverityLow:      "Review low-severity findings during regular maintenance.",
+	SeverityInfo:     "Confirm that informational findings (open ports, exposed paths) are expected.",
+}
+
+// templateSecurityReport builds a report from structured findings without an AI provider
+func templateSecurityReport(findings []ReportFinding) string {
+	bySeverity := make(map[Severity][]string)
+	for _, f := range findings {
+		severity := f.Severity
+		if severity.Rank() == 0 {
+			severity = SeverityMedium
+		}
+		bySeverity[severity] = append(bySeverity[severity], f.Title)
+	}
+
+	var b strings.Builder
+	b.WriteString("## Executive Summary\n\n")
+	if len(findings) == 0 {
+		b.WriteString("Automated scans reported no findings.\n")
+	} else {
+		counts := make([]string, 0, len(Severities))
+		for _, severity := range Severities {
+			if n := len(bySeverity[severity]); n > 0 {
+				counts = append(counts, fmt.Sprintf("%d %s", n, severity))
+			}
+		}
+		fmt.Fprintf(&b, "Automated scans reported %d finding(s): %s.\n", len(findings), strings.Join(counts, ", "))
+	}
+	b.WriteString("\n_No AI provider is configured, so this summary was generated from the scanner findings._\n")
+
+	if len(findings) == 0 {
+		return b.String()
+	}
+
+	b.WriteString("\n## Findings by Severity\n")
+	for _, severity := range Severities {
+		titles := bySeverity[severity]
+		if len(titles) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s%s\n\n", strings.ToUpper(string(severity[:1])), severity[1:])
+		for i, title := range titles {
+			if i == maxTemplateFindings {
+				fmt.Fprintf(&b, "- ...and %d more\n", len(titles)-maxTemplateFindings)
+				break
+			}
+			fmt.Fprintf(&b, "- %s\n", title)
+		}
+	}
+
+	b.WriteString("\n## Recommendations\n\n")
+	for _, severity := range Severities {
+		if len(bySeverity[severity]) > 0 {
+			fmt.Fprintf(&b, "- %s\n", severityAdvice[severity])
+		}
+	}
+	return b.String()
+}
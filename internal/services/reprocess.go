@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Tables the reprocessor walks
+const (
+	ReprocessExecutions = "executions"
+	ReprocessScans      = "scans"
+)
+
+const (
+	// How many records one reprocess call examines by default, and at most
+	defaultReprocessLimit = 200
+	maxReprocessLimit     = 1000
+
+	// reprocessInterval paces the writes so a backfill doesn't starve live scans of the database
+	reprocessInterval = 50 * time.Millisecond
+)
+
+var (
+	// ErrReprocessRunning is returned when a reprocess is started while another is still going
+	ErrReprocessRunning = errors.New("a reprocess is already running")
+
+	// ErrInvalidReprocess is returned for an unknown table or a negative limit
+	ErrInvalidReprocess = errors.New("invalid reprocess request")
+)
+
+// structuredOutputScanners are scanners whose results carry their JSON report parsed into
+// "data", as the nikto node does for new runs
+var structuredOutputScanners = map[string]bool{"nikto": true}
+
+// ReprocessRequest selects the next page of records to reprocess. After is the Next cursor
+// of the previous call; leave it empty to start from the beginning.
+type ReprocessRequest struct {
+	Table string     `json:"table"`
+	After *uuid.UUID `json:"after,omitempty"`
+	Limit int        `json:"limit,omitempty"`
+}
+
+// ReprocessResult reports one page of a reprocess. Call again with After set to Next until Done.
+type ReprocessResult struct {
+	Table     string     `json:"table"`
+	Processed int        `json:"processed"`         // Records examined
+	Enriched  int        `json:"enriched"`          // Records rewritten with new structured data, findings or summaries
+	Skipped   []string   `json:"skipped,omitempty"` // Records left alone, with the reason
+	Next      *uuid.UUID `json:"next,omitempty"`    // Cursor for the next call
+	Done      bool       `json:"done"`
+}
+
+// Reprocessor re-runs the result parsers over stored executions and scan results, so records
+// saved before findings were normalized work with the findings-based features
+type Reprocessor struct {
+	db      *gorm.DB
+	signer  *ResultSigner
+	running sync.Mutex
+}
+
+func NewReprocessor(db *gorm.DB, signer *ResultSigner) *Reprocessor {
+	return &Reprocessor{db: db, signer: signer}
+}
+
+// Reprocess enriches one page of finished records of the requested table, in ID order.
+// Only one reprocess runs at a time; it stops early, with a cursor to resume from, when
+// ctx is cancelled.
+func (r *Reprocessor) Reprocess(ctx context.Context, req ReprocessRequest) (*ReprocessResult, error) {
+	if req.Limit < 0 {
+		return nil, fmt.Errorf("%w: limit must not be negative", ErrInvalidReprocess)
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultReprocessLimit
+	}
+	if req.Limit > maxReprocessLimit {
+		req.Limit = maxReprocessLimit
+	}
+	if req.Table == "" {
+		req.Table = ReprocessExecutions
+	}
+	if req.Table != ReprocessExecutions && req.Table != ReprocessScans {
+		return nil, fmt.Errorf("%w: table must be %s or %s", ErrInvalidReprocess, ReprocessExecutions, ReprocessScans)
+	}
+
+	if !r.running.TryLock() {
+		return nil, ErrReprocessRunning
+	}
+	defer r.running.Unlock()
+
+	result := &ReprocessResult{Table: req.Table, Next: req.After}
+	var ids []uuid.UUID
+	var err error
+	if req.Table == ReprocessExecutions {
+		ids, err = r.page(&models.WorkflowExecution{}, []string{"completed", "failed", "timed_out"}, req)
+	} else {
+		ids, err = r.page(&models.ScanResult{}, []string{"completed"}, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Done = len(ids) < req.Limit
+
+	for i, id := range ids {
+		if ctx.Err() != nil {
+			result.Done = false
+			break
+		}
+		var enriched bool
+		var reason string
+		if req.Table == ReprocessExecutions {
+			enriched, reason, err = r.reprocessExecution(id)
+		} else {
+			enriched, reason, err = r.reprocessScan(id)
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to reprocess %s: %w", id, err)
+		}
+
+		result.Processed++
+		next := ids[i]
+		result.Next = &next
+		if reason != "" {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %s", id, reason))
+		}
+		if enriched {
+			result.Enriched++
+			if err := sleepContext(ctx, reprocessInterval); err != nil {
+				result.Done = false
+				break
+			}
+		}
+	}
+	if result.Done {
+		result.Next = nil
+	}
+	return result, nil
+}
+
+// page lists the IDs of the next records in the given statuses after the request's cursor
+func (r *Reprocessor) page(model interface{}, statuses []string, req ReprocessRequest) ([]uuid.UUID, error) {
+	query := r.db.Model(model).Where("status IN ?", statuses)
+	if req.After != nil {
+		query = query.Where("id > ?", *req.After)
+	}
+	var ids []uuid.UUID
+	err := query.Order("id").Limit(req.Limit).Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *Reprocessor) reprocessExecution(id uuid.UUID) (bool, string, error) {
+	var execution models.WorkflowExecution
+	if err := r.db.Select("id", "results", "severity_summary", "risk_grade").First(&execution, "id = ?", id).Error; err != nil {
+		return false, "", err
+	}
+
+	results := map[string]interface{}(execution.Results)
+	if results == nil {
+		return false, "no results", nil
+	}
+	changed := enrichExecutionResults(results)
+	summary, grade := riskSummary(results)
+	if !changed && reflect.DeepEqual(summary, execution.SeveritySummary) && grade == execution.RiskGrade {
+		return false, "", nil
+	}
+
+	err := r.db.Model(&models.WorkflowExecution{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"results":          models.JSONMap(results),
+		"severity_summary": severitySummaryJSON(summary),
+		"risk_grade":       grade,
+	}).Error
+	return err == nil, "", err
+}
+
+// enrichExecutionResults brings stored node results up to what a run records today: parsed
+// JSON reports, normalized findings and cross-scanner correlations. It reports whether
+// anything changed.
+func enrichExecutionResults(results map[string]interface{}) bool {
+	changed := false
+	for _, result := range results {
+		nodeMap, ok := result.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		scanner, _ := nodeMap["scanner"].(string)
+		if scanner == "" {
+			continue
+		}
+
+		if _, ok := nodeMap["data"]; !ok && structuredOutputScanners[scanner] {
+			output, _ := nodeMap["output"].(string)
+			var data interface{}
+			if json.Unmarshal([]byte(output), &data) == nil {
+				nodeMap["data"] = data
+				changed = true
+			}
+		}
+
+		stored, hadFindings := nodeMap["findings"]
+		withFindings(nodeMap)
+		if !hadFindings || !sameFindings(stored, nodeMap["findings"]) {
+			changed = true
+		}
+	}
+
+	if correlated := crossScannerFindings(results); len(correlated) > 0 {
+		if !sameFindings(results["correlated_findings"], correlated) {
+			results["correlated_findings"] = correlated
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (r *Reprocessor) reprocessScan(id uuid.UUID) (bool, string, error) {
+	var scan models.ScanResult
+	if err := r.db.First(&scan, "id = ?", id).Error; err != nil {
+		return false, "", err
+	}
+
+	// Re-signing an altered record would hide the tampering its signature exists to reveal
+	if scan.Signature != "" && r.signer.Enabled() && !r.signer.Verify(&scan) {
+		return false, "signature does not match, left unchanged", nil
+	}
+
+	var stored map[string]interface{}
+	if json.Unmarshal(scan.Results, &stored) != nil {
+		return false, "results are not a JSON object", nil
+	}
+	findings := scanFindings(scan)
+	if previous, ok := stored["findings"]; ok && sameFindings(previous, findings) {
+		return false, "", nil
+	}
+	stored["findings"] = findings
+
+	updated, err := json.Marshal(stored)
+	if err != nil {
+		return false, "", err
+	}
+	scan.Results = updated
+	r.signer.Sign(&scan)
+
+	err = r.db.Model(&models.ScanResult{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"results":   scan.Results,
+		"signature": scan.Signature,
+	}).Error
+	return err == nil, "", err
+}
+
+// sameFindings compares findings however they are held: as typed values, or as the generic
+// JSON they decode to from the database
+func sameFindings(a, b interface{}) bool {
+	var decodedA, decodedB interface{}
+	dataA, errA := json.Marshal(a)
+	dataB, errB := json.Marshal(b)
+	if errA != nil || errB != nil || json.Unmarshal(dataA, &decodedA) != nil || json.Unmarshal(dataB, &decodedB) != nil {
+		return false
+	}
+	return reflect.DeepEqual(decodedA, decodedB)
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+)
+
+func signedScanResult(t *testing.T, signer *ResultSigner) *models.ScanResult {
+	t.Helper()
+	started := time.Date(2026, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	completed := started.Add(time.Minute)
+	result := &models.ScanResult{
+		ID:          uuid.New(),
+		UserID:      uuid.New(),
+		ScanType:    "nmap",
+		TargetURL:   "https://example.com",
+		Status:      "completed",
+		Results:     json.RawMessage(`{"ports": [80, 443], "host": "example.com"}`),
+		StartedAt:   &started,
+		CompletedAt: &completed,
+	}
+	signer.Sign(result)
+	if result.Signature == "" {
+		t.Fatal("result was not signed")
+	}
+	return result
+}
+
+func TestResultSignerDetectsTampering(t *testing.T) {
+	signer := NewResultSigner("test-key")
+	tests := []struct {
+		name   string
+		tamper func(*models.ScanResult)
+		valid  bool
+	}{
+		{"untouched", func(*models.ScanResult) {}, true},
+		{"database round trip", func(r *models.ScanResult) {
+			// jsonb reorders keys and drops whitespace; Postgres keeps microseconds
+			r.Results = json.RawMessage(`{"host":"example.com","ports":[80,443]}`)
+			started := r.StartedAt.Truncate(time.Microsecond).In(time.FixedZone("EST", -5*3600))
+			r.StartedAt = &started
+		}, true},
+		{"unsigned fields changed", func(r *models.ScanResult) { r.CreatedAt = time.Now() }, true},
+		{"findings edited", func(r *models.ScanResult) { r.Results = json.RawMessage(`{"ports":[80],"host":"example.com"}`) }, false},
+		{"status changed", func(r *models.ScanResult) { r.Status = "failed" }, false},
+		{"target changed", func(r *models.ScanResult) { r.TargetURL = "https://example.org" }, false},
+		{"owner changed", func(r *models.ScanResult) { r.UserID = uuid.New() }, false},
+		{"completion time changed", func(r *models.ScanResult) {
+			completed := r.CompletedAt.Add(time.Second)
+			r.CompletedAt = &completed
+		}, false},
+		{"error message added", func(r *models.ScanResult) { r.ErrorMessage = "timed out" }, false},
+		{"signature removed", func(r *models.ScanResult) { r.Signature = "" }, false},
+		{"signature not hex", func(r *models.ScanResult) { r.Signature = "not-a-signature" }, false},
+		{"signature truncated", func(r *models.ScanResult) { r.Signature = r.Signature[:32] }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := signedScanResult(t, signer)
+			tt.tamper(result)
+			if got := signer.Verify(result); got != tt.valid {
+				t.Fatalf("Verify = %v, want %v", got, tt.valid)
+			}
+		})
+	}
+}
+
+func TestResultSignerRejectsOtherKeys(t *testing.T) {
+	result := signedScanResult(t, NewResultSigner("test-key"))
+	if NewResultSigner("other-key").Verify(result) {
+		t.Fatal("result verified under a different key")
+	}
+}
+
+func TestResultSignerDisabled(t *testing.T) {
+	signer := NewResultSigner("")
+	result := &models.ScanResult{ID: uuid.New(), Status: "completed"}
+	signer.Sign(result)
+	if result.Signature != "" {
+		t.Fatal("disabled signer signed a result")
+	}
+	result.Signature = signedScanResult(t, NewResultSigner("test-key")).Signature
+	if signer.Verify(result) {
+		t.Fatal("disabled signer verified a result")
+	}
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/google/uuid"
+)
+
+// headerNamePattern matches an HTTP header field name (an RFC 7230 token)
+var headerNamePattern = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// reservedScanHeaders are set by the scanner itself and can't be overridden
+var reservedScanHeaders = map[string]bool{
+	"host":              true,
+	"content-length":    true,
+	"transfer-encoding": true,
+	"connection":        true,
+	"cookie":            true, // use "cookies" instead
+}
+
+// ScanHeader is an extra request header sent by a web scanner
+type ScanHeader struct {
+	Name  string
+	Value string
+}
+
+// ScanAuth carries session credentials for scanning authenticated areas of a site.
+// Values come from the secret store and must never be logged or written to results.
+type ScanAuth struct {
+	Headers []ScanHeader
+	Cookies string // Cookie header value, e.g. "session=abc; csrf=def"
+}
+
+// resolveScanAuth builds scan credentials from a node's "authHeaders" and "cookies" fields.
+// authHeaders maps header names to secret names, and cookies names the secret holding the
+// Cookie header, so workflow definitions never contain the credentials themselves.
+func (e *WorkflowExecutor) resolveScanAuth(node *WorkflowNode, userID uuid.UUID) (*ScanAuth, error) {
+	rawHeaders, hasHeaders := node.Data["authHeaders"]
+	rawCookies, hasCookies := node.Data["cookies"]
+	if !hasHeaders && !hasCookies {
+		return nil, nil
+	}
+
+	auth := &ScanAuth{}
+
+	if hasHeaders && rawHeaders != nil {
+		headers, ok := rawHeaders.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("authHeaders must map header names to secret names")
+		}
+		for name, ref := range headers {
+			if !headerNamePattern.MatchString(name) {
+				return nil, fmt.Errorf("invalid header name %q", name)
+			}
+			if reservedScanHeaders[strings.ToLower(name)] {
+				return nil, fmt.Errorf("header %q can't be set on scans", name)
+			}
+			secretName, ok := ref.(string)
+			if !ok || secretName == "" {
+				return nil, fmt.Errorf("header %q must reference a secret by name", name)
+			}
+			value, err := e.secrets.Resolve(userID, secretName)
+			if err != nil {
+				return nil, err
+			}
+			if !validHeaderValue(value) {
+				return nil, fmt.Errorf("secret %s is not a valid value for header %s", secretName, name)
+			}
+			auth.Headers = append(auth.Headers, ScanHeader{Name: name, Value: value})
+		}
+		sort.Slice(auth.Headers, func(i, j int) bool { return auth.Headers[i].Name < auth.Headers[j].Name })
+	}
+
+	if hasCookies && rawCookies != nil {
+		secretName, ok := rawCookies.(string)
+		if !ok {
+			return nil, fmt.Errorf("cookies must reference a secret by name")
+		}
+		if secretName != "" {
+			value, err := e.secrets.Resolve(userID, secretName)
+			if err != nil {
+				return nil, err
+			}
+			if !validCookieHeader(value) {
+				return nil, fmt.Errorf("secret %s is not a valid Cookie header (expected \"name=value; name2=value2\")", secretName)
+			}
+			auth.Cookies = strings.TrimSpace(value)
+		}
+	}
+
+	if len(auth.Headers) == 0 && auth.Cookies == "" {
+		return nil, nil
+	}
+	return auth, nil
+}
+
+// validHeaderValue rejects values that could smuggle extra headers into the request
+func validHeaderValue(value string) bool {
+	return strings.TrimSpace(value) != "" && !strings.ContainsAny(value, "\r\n\x00")
+}
+
+// validCookieHeader checks for semicolon-separated name=value pairs
+func validCookieHeader(value string) bool {
+	if !validHeaderValue(value) {
+		return false
+	}
+	for _, pair := range strings.Split(strings.TrimSuffix(strings.TrimSpace(value), ";"), ";") {
+		name, _, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || !headerNamePattern.MatchString(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// gobusterArgs returns gobuster flags for the credentials
+func (a *ScanAuth) gobusterArgs() []string {
+	if a == nil {
+		return nil
+	}
+	var args []string
+	for _, h := range a.Headers {
+		args = append(args, "-H", h.Name+": "+h.Value)
+	}
+	if a.Cookies != "" {
+		args = append(args, "-c", a.Cookies)
+	}
+	return args
+}
+
+// sqlmapArgs returns sqlmap flags for the credentials
+func (a *ScanAuth) sqlmapArgs() []string {
+	if a == nil {
+		return nil
+	}
+	var args []string
+	if len(a.Headers) > 0 {
+		lines := make([]string, len(a.Headers))
+		for i, h := range a.Headers {
+			lines[i] = h.Name + ": " + h.Value
+		}
+		args = append(args, "--headers", strings.Join(lines, "\n"))
+	}
+	if a.Cookies != "" {
+		args = append(args, "--cookie", a.Cookies)
+	}
+	return args
+}
+
+// wpscanArgs returns wpscan flags for the credentials
+func (a *ScanAuth) wpscanArgs() []string {
+	if a == nil {
+		return nil
+	}
+	var args []string
+	if len(a.Headers) > 0 {
+		pairs := make([]string, len(a.Headers))
+		for i, h := range a.Headers {
+			pairs[i] = h.Name + ": " + h.Value
+		}
+		args = append(args, "--headers", strings.Join(pairs, "; "))
+	}
+	if a.Cookies != "" {
+		args = append(args, "--cookie-string", a.Cookies)
+	}
+	return args
+}
+
+// niktoArgs returns nikto flags for the credentials. Nikto can't send arbitrary
+// headers, so only Basic Authorization (as -id) and cookies are supported.
+func (a *ScanAuth) niktoArgs() ([]string, error) {
+	if a == nil {
+		return nil, nil
+	}
+	var args []string
+	for _, h := range a.Headers {
+		if !strings.EqualFold(h.Name, "Authorization") {
+			return nil, fmt.Errorf("nikto only supports the Authorization header and cookies, not %s", h.Name)
+		}
+		scheme, encoded, _ := strings.Cut(h.Value, " ")
+		credentials, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if !strings.EqualFold(scheme, "Basic") || err != nil || !strings.Contains(string(credentials), ":") {
+			return nil, fmt.Errorf("nikto only supports Basic Authorization headers")
+		}
+		args = append(args, "-id", string(credentials))
+	}
+	if a.Cookies != "" {
+		args = append(args, "-Option", "STATIC-COOKIE="+a.Cookies)
+	}
+	return args, nil
+}
+
+// zapEnv returns the environment ZAP's packaged scans read an auth header from. ZAP takes a
+// single header that way, limited to the target's host, so the credentials can be one header
+// or the cookies but not both.
+func (a *ScanAuth) zapEnv(target string) ([]string, error) {
+	if a == nil {
+		return nil, nil
+	}
+	name, value := "Cookie", a.Cookies
+	switch {
+	case len(a.Headers) > 1 || (len(a.Headers) == 1 && a.Cookies != ""):
+		return nil, fmt.Errorf("zap only supports one auth header or cookies, not both")
+	case len(a.Headers) == 1:
+		name, value = a.Headers[0].Name, a.Headers[0].Value
+	}
+	return []string{
+		"ZAP_AUTH_HEADER=" + name,
+		"ZAP_AUTH_HEADER_VALUE=" + value,
+		"ZAP_AUTH_HEADER_SITE=" + utils.NormalizeHost(target),
+	}, nil
+}
+
+// redact masks credential values that a scanner echoes back in its output
+func (a *ScanAuth) redact(output []byte) []byte {
+	if a == nil {
+		return output
+	}
+	values := []string{a.Cookies}
+	for _, h := range a.Headers {
+		values = append(values, h.Value)
+		// Nikto is given the decoded user:password rather than the header value
+		if scheme, encoded, ok := strings.Cut(h.Value, " "); ok && strings.EqualFold(scheme, "Basic") {
+			if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded)); err == nil {
+				values = append(values, string(decoded))
+			}
+		}
+	}
+	for _, value := range values {
+		if value != "" {
+			output = bytes.ReplaceAll(output, []byte(value), []byte("[REDACTED]"))
+		}
+	}
+	return output
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/google/uuid"
+)
+
+// maxBatchTargets bounds one bulk request; the scans themselves are paced by the scan limiter
+const maxBatchTargets = 1000
+
+var (
+	ErrUnsupportedBatchScan = errors.New("scan_type must be nmap, nikto, gobuster, sqlmap or wpscan")
+	ErrEmptyBatch           = errors.New("no targets given")
+	ErrBatchTooLarge        = fmt.Errorf("a batch may have at most %d targets", maxBatchTargets)
+)
+
+// batchScanTypes are the scanners a batch can run, one scan per target
+var batchScanTypes = map[string]bool{"nmap": true, "nikto": true, "gobuster": true, "sqlmap": true, "wpscan": true}
+
+// BatchRow is one entry of a submitted target list
+type BatchRow struct {
+	Row    int
+	Target string
+	Error  string // Set when the row itself couldn't be read
+}
+
+// BatchScanOptions apply to every scan in a batch
+type BatchScanOptions struct {
+	Ports    string // nmap only
+	Wordlist string // gobuster only
+}
+
+// BatchScan is the progress of one target in a batch
+type BatchScan struct {
+	ID               uuid.UUID  `json:"id"`
+	Target           string     `json:"target"`
+	Status           string     `json:"status"`
+	Error            string     `json:"error,omitempty"`
+	QueuePosition    *int       `json:"queue_position,omitempty"`
+	EstimatedStartAt *time.Time `json:"estimated_start_at,omitempty"`
+	StartedAt        *time.Time `json:"started_at,omitempty"`
+	CompletedAt      *time.Time `json:"completed_at,omitempty"`
+}
+
+// ScanBatchStatus is a batch with the aggregate and per-target state of its scans
+type ScanBatchStatus struct {
+	models.ScanBatch
+	Status string         `json:"status"` // running until every scan has finished, then completed or failed
+	Counts map[string]int `json:"counts"` // Scans by status
+	Scans  []BatchScan    `json:"scans"`
+}
+
+// ParseTargetCSV reads targets from the first column of a CSV file. A header row naming the
+// column (target, host or url) is skipped, as are blank lines and lines starting with #.
+// Rows that aren't valid CSV are returned with Error set instead of failing the whole file.
+func ParseTargetCSV(r io.Reader) ([]BatchRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	var rows []BatchRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, BatchRow{Row: len(rows) + 1, Error: fmt.Sprintf("malformed CSV on line %d: %v", parseErr.Line, parseErr.Err)})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		target := strings.TrimSpace(record[0])
+		if len(rows) == 0 {
+			switch strings.ToLower(target) {
+			case "target", "host", "url":
+				continue
+			}
+		}
+		rows = append(rows, BatchRow{Row: len(rows) + 1, Target: target})
+	}
+	return rows, nil
+}
+
+// TargetRows numbers a plain list of targets
+func TargetRows(targets []string) []BatchRow {
+	rows := make([]BatchRow, len(targets))
+	for i, target := range targets {
+		rows[i] = BatchRow{Row: i + 1, Target: strings.TrimSpace(target)}
+	}
+	return rows
+}
+
+// checkBatchTarget rejects rows that can't be handed to a scanner as a target
+func checkBatchTarget(target string) string {
+	switch {
+	case target == "":
+		return "target is empty"
+	case strings.HasPrefix(target, "-"):
+		return "target may not start with '-'"
+	case strings.ContainsAny(target, " \t\r\n"):
+		return "target may not contain whitespace"
+	case utils.NormalizeHost(target) == "":
+		return "target has no usable host"
+	}
+	return ""
+}
+
+// StartBatch starts one scanType scan per valid row and records the batch. Invalid rows are
+// listed in the batch's Rejected entries; the rest run in the background, as many at once
+// as the scan limiter allows.
+func (s *ScannerService) StartBatch(userID uuid.UUID, scanType string, rows []BatchRow, options BatchScanOptions) (*models.ScanBatch, error) {
+	if !batchScanTypes[scanType] {
+		return nil, ErrUnsupportedBatchScan
+	}
+	if len(rows) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	if len(rows) > maxBatchTargets {
+		return nil, ErrBatchTooLarge
+	}
+
+	var wordlistPath string
+	if scanType == "gobuster" {
+		// Resolve up front so a bad name fails the request instead of every scan
+		path, err := s.wordlists.Resolve(userID, options.Wordlist)
+		if err != nil {
+			return nil, err
+		}
+		wordlistPath = path
+		if options.Wordlist == "" {
+			options.Wordlist = DefaultWordlist
+		}
+	}
+	if scanType == "nmap" && options.Ports == "" {
+		options.Ports = "1-1000"
+	}
+
+	batch := &models.ScanBatch{UserID: userID, ScanType: scanType, Rejected: []models.RejectedTarget{}}
+	var targets []string
+	for _, row := range rows {
+		reason := row.Error
+		if reason == "" {
+			reason = checkBatchTarget(row.Target)
+		}
+		if reason != "" {
+			batch.Rejected = append(batch.Rejected, models.RejectedTarget{Row: row.Row, Target: row.Target, Reason: reason})
+			continue
+		}
+		targets = append(targets, row.Target)
+	}
+	batch.Total = len(targets)
+
+	if err := s.db.Create(batch).Error; err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return batch, nil
+	}
+
+	// Every scan is queued; each is marked running as the limiter lets it start
+	scans := make([]models.ScanResult, len(targets))
+	for i, target := range targets {
+		scans[i] = models.ScanResult{
+			UserID:    userID,
+			BatchID:   &batch.ID,
+			ScanType:  scanType,
+			TargetURL: target,
+			Status:    "queued",
+		}
+	}
+	if err := s.db.Create(&scans).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range scans {
+		go s.runBatchScan(&scans[i], options, wordlistPath)
+	}
+	return batch, nil
+}
+
+// runBatchScan runs one scan of a batch and stores its result in the same shape as a single scan
+func (s *ScannerService) runBatchScan(scanResult *models.ScanResult, options BatchScanOptions, wordlistPath string) {
+	ctx := withQueuedScan(context.Background(), scanResult)
+	target := scanResult.TargetURL
+
+	var results json.RawMessage
+	var err error
+	switch scanResult.ScanType {
+	case "nmap":
+		var output string
+		if output, err = s.RunNmap(ctx, target, options.Ports, nil); err == nil {
+			results, _ = json.Marshal(map[string]interface{}{"output": output, "ports": options.Ports})
+		}
+	case "nikto":
+		var output []byte
+		if output, err = s.RunNikto(ctx, target, nil, nil); err == nil {
+			results = json.RawMessage(output)
+		}
+	case "gobuster":
+		var output string
+		if output, err = s.RunGobuster(ctx, target, wordlistPath, nil, nil); err == nil {
+			results, _ = json.Marshal(map[string]interface{}{"output": output, "wordlist": options.Wordlist})
+		}
+	case "sqlmap":
+		var output string
+		if output, err = s.RunSqlmap(ctx, target, nil, nil); err == nil {
+			results, _ = json.Marshal(map[string]interface{}{"output": output})
+		}
+	case "wpscan":
+		var output string
+		if output, err = s.RunWpscan(ctx, target, nil, nil); err == nil {
+			results, _ = json.Marshal(map[string]interface{}{"output": output})
+		}
+	}
+
+	completeTime := time.Now()
+	scanResult.CompletedAt = &completeTime
+	if err != nil {
+		scanResult.Status = "failed"
+		scanResult.ErrorMessage = err.Error()
+	} else {
+		scanResult.Status = "completed"
+		scanResult.Results = results
+	}
+	s.finish(scanResult)
+}
+
+// GetBatch returns a batch owned by the user with the progress of each of its scans
+func (s *ScannerService) GetBatch(batchID, userID uuid.UUID) (*ScanBatchStatus, error) {
+	var batch models.ScanBatch
+	if err := s.db.Where("id = ? AND user_id = ?", batchID, userID).First(&batch).Error; err != nil {
+		return nil, err
+	}
+
+	// Leave out the scan output, which can be large; each scan links to its full result
+	var scans []models.ScanResult
+	if err := s.db.Select("id", "target_url", "status", "error_message", "started_at", "completed_at").
+		Where("batch_id = ? AND user_id = ?", batchID, userID).Order("created_at ASC").Find(&scans).Error; err != nil {
+		return nil, err
+	}
+
+	status := &ScanBatchStatus{
+		ScanBatch: batch,
+		Counts:    make(map[string]int),
+		Scans:     make([]BatchScan, 0, len(scans)),
+	}
+	for _, scan := range scans {
+		s.annotateQueue(&scan)
+		status.Counts[scan.Status]++
+		status.Scans = append(status.Scans, BatchScan{
+			ID:               scan.ID,
+			Target:           scan.TargetURL,
+			Status:           scan.Status,
+			Error:            scan.ErrorMessage,
+			QueuePosition:    scan.QueuePosition,
+			EstimatedStartAt: scan.EstimatedStartAt,
+			StartedAt:        scan.StartedAt,
+			CompletedAt:      scan.CompletedAt,
+		})
+	}
+
+	switch {
+	case status.Counts["running"] > 0 || status.Counts["queued"] > 0 || status.Counts["pending"] > 0:
+		status.Status = "running"
+	case len(scans) > 0 && status.Counts["failed"] == len(scans):
+		status.Status = "failed"
+	default:
+		status.Status = "completed"
+	}
+	return status, nil
+}
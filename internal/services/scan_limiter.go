@@ -0,0 +1,484 @@
+package services
+
+import (
+	"context"
+	"log"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// scanDurationFastWeight and scanDurationSlowWeight smooth per-scanner durations into a
+	// recent average and a long-run baseline; a recent average well above the baseline means
+	// scans are slowing down because the host is contended
+	scanDurationFastWeight = 0.3
+	scanDurationSlowWeight = 0.05
+	scanSlowdownFactor     = 1.5
+	scanMinSamples         = 5
+
+	// Load per CPU above which concurrency is cut, and below which it may grow
+	scanLoadHigh = 0.9
+	scanLoadLow  = 0.7
+
+	// scanDefaultDuration stands in for scanners with no recorded duration when estimating waits
+	scanDefaultDuration = time.Minute
+)
+
+// scanDurations tracks the recent and baseline duration of one scanner, in seconds
+type scanDurations struct {
+	fast, slow float64
+	samples    int
+}
+
+func (d *scanDurations) record(seconds float64) {
+	if d.samples == 0 {
+		d.fast, d.slow = seconds, seconds
+	} else {
+		d.fast += scanDurationFastWeight * (seconds - d.fast)
+		d.slow += scanDurationSlowWeight * (seconds - d.slow)
+	}
+	d.samples++
+}
+
+// expected is the duration a new scan is assumed to take: the long-run baseline
+func (d *scanDurations) expected() time.Duration {
+	if d == nil || d.samples == 0 {
+		return scanDefaultDuration
+	}
+	return time.Duration(d.slow * float64(time.Second))
+}
+
+func (d *scanDurations) slowedDown() bool {
+	return d.samples >= scanMinSamples && d.fast > scanSlowdownFactor*d.slow
+}
+
+// ScanConcurrencyStats is a snapshot of the scan limiter
+type ScanConcurrencyStats struct {
+	Limit      int                `json:"limit"`
+	Min        int                `json:"min"`
+	Max        int                `json:"max"`
+	PerUser    int                `json:"per_user_limit"` // 0 when users aren't capped below the limit
+	Active     int                `json:"active"`
+	Waiting    int                `json:"waiting"`
+	LoadPerCPU *float64           `json:"load_per_cpu,omitempty"` // Omitted where the load average can't be read
+	Durations  map[string]float64 `json:"recent_duration_seconds"`
+	Users      []UserScanStats    `json:"users"`
+}
+
+// UserScanStats is how many of one user's scans hold and wait for a slot
+type UserScanStats struct {
+	UserID  uuid.UUID `json:"user_id"` // Nil for scans started outside any user's request
+	Active  int       `json:"active"`
+	Waiting int       `json:"waiting"`
+}
+
+// scanTicket is one scan waiting for or holding a slot. ScanID is set for scans that have a
+// stored result, so their place in the queue can be looked up.
+type scanTicket struct {
+	ready   chan struct{}
+	scanner string
+	scanID  uuid.UUID
+	owner   uuid.UUID
+	started time.Time
+}
+
+// scanOwnerKey carries the user a workflow run's scans are for through to the scan limiter
+type scanOwnerKey struct{}
+
+func withScanOwner(ctx context.Context, userID uuid.UUID) context.Context {
+	return context.WithValue(ctx, scanOwnerKey{}, userID)
+}
+
+// scanOwner returns the user a scan is for, from its stored result or the run it belongs to
+func scanOwner(ctx context.Context) uuid.UUID {
+	if scanResult := queuedScan(ctx); scanResult != nil {
+		return scanResult.UserID
+	}
+	owner, _ := ctx.Value(scanOwnerKey{}).(uuid.UUID)
+	return owner
+}
+
+// ScanQueueEstimate is where a queued scan stands and when it is expected to start
+type ScanQueueEstimate struct {
+	Position int       // 1-based
+	StartAt  time.Time // Estimated
+}
+
+// ScanLimiter bounds how many scanner processes run at once. A controller adjusts the
+// limit between min and max: it halves the limit when the host's load per CPU is high
+// or scans take markedly longer than usual, and raises it by one when the host has
+// headroom and scans are queueing.
+//
+// Free slots go to users in turn rather than in arrival order, so one user queueing many
+// scans can't starve the others: the next slot goes to the waiting user with the fewest
+// scans running, the one served longest ago among equals. Each user's own scans start in
+// arrival order, and perUser, when set, caps how many slots one user holds.
+type ScanLimiter struct {
+	mu        sync.Mutex
+	min, max  int
+	limit     int
+	perUser   int
+	running   map[*scanTicket]struct{}
+	queue     []*scanTicket
+	owners    map[uuid.UUID]int    // Slots each user holds
+	served    map[uuid.UUID]uint64 // When each user was last granted a slot, counted in grants
+	grants    uint64
+	durations map[string]*scanDurations
+	interval  time.Duration
+	readLoad  func() (float64, bool)
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewScanLimiter(min, max, perUser int, interval time.Duration) *ScanLimiter {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	return &ScanLimiter{
+		min:       min,
+		max:       max,
+		limit:     min,
+		perUser:   perUser,
+		running:   make(map[*scanTicket]struct{}),
+		owners:    make(map[uuid.UUID]int),
+		served:    make(map[uuid.UUID]uint64),
+		durations: make(map[string]*scanDurations),
+		interval:  interval,
+		readLoad:  loadPerCPU,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Acquire waits for a scan slot, taking turns with other users' scans, and returns the func
+// that releases it. It gives up when ctx is done so cancelled or timed-out runs don't hold
+// their place.
+func (l *ScanLimiter) Acquire(ctx context.Context, scanner string) (func(), error) {
+	ticket := &scanTicket{ready: make(chan struct{}), scanner: scanner, owner: scanOwner(ctx)}
+	if scanResult := queuedScan(ctx); scanResult != nil {
+		ticket.scanID = scanResult.ID
+	}
+
+	l.mu.Lock()
+	l.queue = append(l.queue, ticket)
+	l.dispatchLocked()
+	l.mu.Unlock()
+
+	select {
+	case <-ticket.ready:
+		return l.releaser(ticket), nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for i, waiting := range l.queue {
+			if waiting == ticket {
+				l.queue = append(l.queue[:i], l.queue[i+1:]...)
+				return nil, ctx.Err()
+			}
+		}
+		// The slot was granted just as ctx ended; hand it on
+		l.stopLocked(ticket)
+		l.dispatchLocked()
+		return nil, ctx.Err()
+	}
+}
+
+func (l *ScanLimiter) startLocked(ticket *scanTicket) {
+	ticket.started = time.Now()
+	l.running[ticket] = struct{}{}
+	l.owners[ticket.owner]++
+	l.grants++
+	l.served[ticket.owner] = l.grants
+}
+
+func (l *ScanLimiter) stopLocked(ticket *scanTicket) {
+	delete(l.running, ticket)
+	if l.owners[ticket.owner]--; l.owners[ticket.owner] <= 0 {
+		delete(l.owners, ticket.owner)
+	}
+	// Forget users with nothing running or queued, so the map doesn't grow with every user
+	if _, holding := l.owners[ticket.owner]; !holding {
+		for _, waiting := range l.queue {
+			if waiting.owner == ticket.owner {
+				return
+			}
+		}
+		delete(l.served, ticket.owner)
+	}
+}
+
+func (l *ScanLimiter) releaser(ticket *scanTicket) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.stopLocked(ticket)
+			if l.durations[ticket.scanner] == nil {
+				l.durations[ticket.scanner] = &scanDurations{}
+			}
+			l.durations[ticket.scanner].record(time.Since(ticket.started).Seconds())
+			l.dispatchLocked()
+		})
+	}
+}
+
+// dispatchLocked grants slots to queued scans while the limit allows
+func (l *ScanLimiter) dispatchLocked() {
+	for len(l.running) < l.limit {
+		i := l.nextLocked(l.queue, l.owners, l.served)
+		if i < 0 {
+			return
+		}
+		ticket := l.queue[i]
+		l.queue = append(l.queue[:i], l.queue[i+1:]...)
+		l.startLocked(ticket)
+		close(ticket.ready)
+	}
+}
+
+// nextLocked returns the index in queue of the scan that gets the next free slot, given the
+// slots each user holds and when each was last served, or -1 when every waiting user is at
+// the per-user cap
+func (l *ScanLimiter) nextLocked(queue []*scanTicket, owners map[uuid.UUID]int, served map[uuid.UUID]uint64) int {
+	next := -1
+	considered := make(map[uuid.UUID]bool)
+	for i, ticket := range queue {
+		owner := ticket.owner
+		if considered[owner] {
+			continue // Only each user's oldest scan competes
+		}
+		considered[owner] = true
+		if l.perUser > 0 && owners[owner] >= l.perUser {
+			continue
+		}
+		if next < 0 {
+			next = i
+			continue
+		}
+		best := queue[next].owner
+		if owners[owner] < owners[best] || (owners[owner] == owners[best] && served[owner] < served[best]) {
+			next = i
+		}
+	}
+	return next
+}
+
+// dispatchOrderLocked returns the queued scans in the order they are expected to start,
+// counting each one against its user's slots as if nothing finished in between
+func (l *ScanLimiter) dispatchOrderLocked() []*scanTicket {
+	queue := append([]*scanTicket(nil), l.queue...)
+	owners := make(map[uuid.UUID]int, len(l.owners))
+	for owner, count := range l.owners {
+		owners[owner] = count
+	}
+	served := make(map[uuid.UUID]uint64, len(l.served))
+	for owner, grant := range l.served {
+		served[owner] = grant
+	}
+	grants := l.grants
+
+	order := make([]*scanTicket, 0, len(queue))
+	for len(queue) > 0 {
+		i := l.nextLocked(queue, owners, served)
+		if i < 0 {
+			// Everyone left is at the cap; their scans start as their own ones finish
+			order = append(order, queue...)
+			break
+		}
+		ticket := queue[i]
+		queue = append(queue[:i], queue[i+1:]...)
+		owners[ticket.owner]++
+		grants++
+		served[ticket.owner] = grants
+		order = append(order, ticket)
+	}
+	return order
+}
+
+// Seed sets the duration assumed for scanner before any scan of it has finished here
+func (l *ScanLimiter) Seed(scanner string, seconds float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.durations[scanner] == nil {
+		l.durations[scanner] = &scanDurations{}
+		l.durations[scanner].record(seconds)
+	}
+}
+
+// Position estimates when the queued scan with the given ID will start. It reports false
+// once the scan holds a slot, or before it has asked for one.
+func (l *ScanLimiter) Position(scanID uuid.UUID) (ScanQueueEstimate, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	order := l.dispatchOrderLocked()
+	for i, ticket := range order {
+		if ticket.scanID == scanID {
+			return ScanQueueEstimate{Position: i + 1, StartAt: time.Now().Add(l.waitLocked(order[:i]))}, true
+		}
+	}
+	return ScanQueueEstimate{}, false
+}
+
+// Forecast estimates the wait of a scan the user asks a slot for now. It reports false when
+// the scan would start straight away.
+func (l *ScanLimiter) Forecast(userID uuid.UUID) (ScanQueueEstimate, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	probe := &scanTicket{owner: userID}
+	l.queue = append(l.queue, probe)
+	order := l.dispatchOrderLocked()
+	l.queue = l.queue[:len(l.queue)-1]
+
+	for i, ticket := range order {
+		if ticket == probe {
+			if i < l.limit-len(l.running) && (l.perUser <= 0 || l.owners[userID] < l.perUser) {
+				return ScanQueueEstimate{}, false
+			}
+			return ScanQueueEstimate{Position: i + 1, StartAt: time.Now().Add(l.waitLocked(order[:i]))}, true
+		}
+	}
+	return ScanQueueEstimate{}, false
+}
+
+// waitLocked estimates how long a scan behind the given queued scans waits: the work left
+// in running scans plus the expected duration of those ahead of it, spread across the
+// current limit
+func (l *ScanLimiter) waitLocked(ahead []*scanTicket) time.Duration {
+	var work time.Duration
+	for ticket := range l.running {
+		if left := l.durations[ticket.scanner].expected() - time.Since(ticket.started); left > 0 {
+			work += left
+		}
+	}
+	for _, ticket := range ahead {
+		work += l.durations[ticket.scanner].expected()
+	}
+	return (work / time.Duration(l.limit)).Round(time.Second)
+}
+
+// Start begins adjusting the limit in the background
+func (l *ScanLimiter) Start() {
+	go func() {
+		defer close(l.done)
+		ticker := time.NewTicker(l.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.stop:
+				return
+			case <-ticker.C:
+				l.adjust()
+			}
+		}
+	}()
+}
+
+// Stop halts the controller; scans already admitted are unaffected
+func (l *ScanLimiter) Stop() {
+	close(l.stop)
+	<-l.done
+}
+
+func (l *ScanLimiter) adjust() {
+	load, haveLoad := l.readLoad()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	slowed := false
+	for _, d := range l.durations {
+		if d.slowedDown() {
+			slowed = true
+			break
+		}
+	}
+
+	previous := l.limit
+	switch {
+	case (haveLoad && load > scanLoadHigh) || slowed:
+		l.limit = l.limit / 2
+		if l.limit < l.min {
+			l.limit = l.min
+		}
+	case len(l.queue) > 0 && (!haveLoad || load < scanLoadLow) && l.limit < l.max:
+		l.limit++
+		l.dispatchLocked()
+	}
+	if l.limit != previous {
+		log.Printf("🎚️ Scan concurrency %d -> %d (load per CPU %.2f, slowed down: %v)", previous, l.limit, load, slowed)
+	}
+}
+
+// Stats returns the current limit and usage
+func (l *ScanLimiter) Stats() ScanConcurrencyStats {
+	load, haveLoad := l.readLoad()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stats := ScanConcurrencyStats{
+		Limit:     l.limit,
+		Min:       l.min,
+		Max:       l.max,
+		PerUser:   l.perUser,
+		Active:    len(l.running),
+		Waiting:   len(l.queue),
+		Durations: make(map[string]float64, len(l.durations)),
+		Users:     []UserScanStats{},
+	}
+	users := make(map[uuid.UUID]*UserScanStats)
+	user := func(id uuid.UUID) *UserScanStats {
+		if users[id] == nil {
+			users[id] = &UserScanStats{UserID: id}
+		}
+		return users[id]
+	}
+	for owner, active := range l.owners {
+		user(owner).Active = active
+	}
+	for _, ticket := range l.queue {
+		user(ticket.owner).Waiting++
+	}
+	for _, u := range users {
+		stats.Users = append(stats.Users, *u)
+	}
+	sort.Slice(stats.Users, func(i, j int) bool {
+		return stats.Users[i].UserID.String() < stats.Users[j].UserID.String()
+	})
+	if haveLoad {
+		stats.LoadPerCPU = &load
+	}
+	for scanner, d := range l.durations {
+		stats.Durations[scanner] = d.fast
+	}
+	return stats
+}
+
+// loadPerCPU reads the one-minute load average divided by the CPU count. It is only
+// available where /proc/loadavg exists (Linux).
+func loadPerCPU() (float64, bool) {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, false
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, false
+	}
+	return load / float64(runtime.NumCPU()), true
+}
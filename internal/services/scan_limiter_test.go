@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// scanGrant is a slot the scan limiter granted to one of a user's scans
+type scanGrant struct {
+	owner   uuid.UUID
+	release func()
+}
+
+// queueScan asks l for a slot for one of owner's scans in the background, sending the grant
+// on granted, and returns once the scan is queued or holds a slot
+func queueScan(t *testing.T, l *ScanLimiter, owner uuid.UUID, granted chan<- scanGrant) {
+	t.Helper()
+	l.mu.Lock()
+	before := len(l.queue) + len(l.running)
+	l.mu.Unlock()
+
+	go func() {
+		release, err := l.Acquire(withScanOwner(context.Background(), owner), "nmap")
+		if err == nil {
+			granted <- scanGrant{owner, release}
+		}
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		l.mu.Lock()
+		now := len(l.queue) + len(l.running)
+		l.mu.Unlock()
+		if now > before {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("the scan never asked for a slot")
+}
+
+func TestScanLimiterInterleavesUsers(t *testing.T) {
+	l := NewScanLimiter(1, 1, 0, time.Minute)
+	busy, other := uuid.New(), uuid.New()
+	granted := make(chan scanGrant, 10)
+
+	// The busy user queues all their scans before the other user queues any
+	for i := 0; i < 4; i++ {
+		queueScan(t, l, busy, granted)
+	}
+	for i := 0; i < 3; i++ {
+		queueScan(t, l, other, granted)
+	}
+
+	names := map[uuid.UUID]string{busy: "busy", other: "other"}
+	var order []string
+	for len(order) < 7 {
+		select {
+		case grant := <-granted:
+			order = append(order, names[grant.owner])
+			grant.release()
+		case <-time.After(2 * time.Second):
+			t.Fatalf("scans stalled after %q", order)
+		}
+	}
+	want := []string{"busy", "other", "busy", "other", "busy", "other", "busy"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("slots went to %q, want the users in turn %q", order, want)
+		}
+	}
+}
+
+func TestScanLimiterPerUserCap(t *testing.T) {
+	l := NewScanLimiter(2, 2, 1, time.Minute)
+	capped, other := uuid.New(), uuid.New()
+	granted := make(chan scanGrant, 3)
+
+	queueScan(t, l, capped, granted)
+	first := <-granted
+	// A slot is free, but the user already holds as many as they may
+	queueScan(t, l, capped, granted)
+	queueScan(t, l, other, granted)
+	if grant := <-granted; grant.owner != other {
+		t.Fatal("the capped user was granted a second slot")
+	}
+
+	stats := l.Stats()
+	if stats.PerUser != 1 || stats.Active != 2 || stats.Waiting != 1 {
+		t.Fatalf("stats = %+v, want 2 active, 1 waiting under a per-user limit of 1", stats)
+	}
+
+	first.release()
+	select {
+	case grant := <-granted:
+		if grant.owner != capped {
+			t.Fatal("the freed slot went to the wrong user")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the capped user's waiting scan never started after their first finished")
+	}
+}
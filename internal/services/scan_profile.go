@@ -0,0 +1,167 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// portSpecPattern matches nmap port specifications such as "22,80,443" or "1-1000"
+var portSpecPattern = regexp.MustCompile(`^[0-9]{1,5}(-[0-9]{1,5})?(,[0-9]{1,5}(-[0-9]{1,5})?)*$`)
+
+// profileParameters lists the parameters each scanner type accepts, with a validator per parameter
+var profileParameters = map[string]map[string]func(value interface{}) error{
+	"nmap": {
+		"extraArgs": validateExtraArgs,
+		"ports": func(value interface{}) error {
+			ports, ok := value.(string)
+			if !ok || !portSpecPattern.MatchString(ports) {
+				return fmt.Errorf("ports must look like \"80,443\" or \"1-1000\"")
+			}
+			return nil
+		},
+	},
+	"gobuster": {
+		"extraArgs": validateExtraArgs,
+		"wordlist": func(value interface{}) error {
+			if _, ok := value.(string); !ok {
+				return fmt.Errorf("wordlist must be a string")
+			}
+			return nil
+		},
+	},
+	"nikto":    {"extraArgs": validateExtraArgs},
+	"sqlmap":   {"extraArgs": validateExtraArgs},
+	"wpscan":   {"extraArgs": validateExtraArgs},
+	"zap-scan": {"extraArgs": validateExtraArgs},
+}
+
+// validateExtraArgs checks the shape of extraArgs; which flags are allowed is decided when the scan runs
+func validateExtraArgs(value interface{}) error {
+	_, err := extraArgs(&WorkflowNode{Data: map[string]interface{}{"extraArgs": value}})
+	return err
+}
+
+type ScanProfileService struct {
+	db *gorm.DB
+}
+
+func NewScanProfileService(db *gorm.DB) *ScanProfileService {
+	return &ScanProfileService{db: db}
+}
+
+// ValidateScanProfile checks that the scanner type is known and every parameter is valid for it
+func ValidateScanProfile(scannerType string, parameters map[string]interface{}) error {
+	allowed, ok := profileParameters[scannerType]
+	if !ok {
+		return fmt.Errorf("unsupported scanner type: %s", scannerType)
+	}
+	for name, value := range parameters {
+		validate, ok := allowed[name]
+		if !ok {
+			return fmt.Errorf("parameter %q is not valid for %s", name, scannerType)
+		}
+		if err := validate(value); err != nil {
+			return fmt.Errorf("invalid %s parameter %q: %w", scannerType, name, err)
+		}
+	}
+	return nil
+}
+
+// CreateProfile saves a new scan profile
+func (s *ScanProfileService) CreateProfile(userID uuid.UUID, name, scannerType, description string, parameters map[string]interface{}) (*models.ScanProfile, error) {
+	if err := ValidateScanProfile(scannerType, parameters); err != nil {
+		return nil, err
+	}
+
+	profile := &models.ScanProfile{
+		UserID:      userID,
+		Name:        name,
+		ScannerType: scannerType,
+		Description: description,
+		Parameters:  models.JSONMap(parameters),
+	}
+	if profile.Parameters == nil {
+		profile.Parameters = models.JSONMap{}
+	}
+
+	if err := s.db.Create(profile).Error; err != nil {
+		return nil, fmt.Errorf("failed to create scan profile: %w", err)
+	}
+	return profile, nil
+}
+
+// GetProfile retrieves a profile by ID
+func (s *ScanProfileService) GetProfile(profileID, userID uuid.UUID) (*models.ScanProfile, error) {
+	var profile models.ScanProfile
+	if err := s.db.Where("id = ? AND user_id = ?", profileID, userID).First(&profile).Error; err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// ListProfiles retrieves all profiles for a user
+func (s *ScanProfileService) ListProfiles(userID uuid.UUID) ([]models.ScanProfile, error) {
+	var profiles []models.ScanProfile
+	if err := s.db.Where("user_id = ?", userID).Order("name ASC").Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// UpdateProfile updates a profile, re-validating parameters against the resulting scanner type
+func (s *ScanProfileService) UpdateProfile(profileID, userID uuid.UUID, name, description *string, parameters map[string]interface{}) (*models.ScanProfile, error) {
+	profile, err := s.GetProfile(profileID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	if name != nil {
+		updates["name"] = *name
+	}
+	if description != nil {
+		updates["description"] = *description
+	}
+	if parameters != nil {
+		if err := ValidateScanProfile(profile.ScannerType, parameters); err != nil {
+			return nil, err
+		}
+		updates["parameters"] = models.JSONMap(parameters)
+	}
+
+	if err := s.db.Model(profile).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update scan profile: %w", err)
+	}
+	return profile, nil
+}
+
+// DeleteProfile deletes a profile
+func (s *ScanProfileService) DeleteProfile(profileID, userID uuid.UUID) error {
+	result := s.db.Where("id = ? AND user_id = ?", profileID, userID).Delete(&models.ScanProfile{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// resolveScanProfile looks up a profile by ID or name for the user
+func resolveScanProfile(db *gorm.DB, userID uuid.UUID, ref string) (*models.ScanProfile, error) {
+	var profile models.ScanProfile
+	query := db.Where("user_id = ?", userID)
+	if id, err := uuid.Parse(ref); err == nil {
+		query = query.Where("id = ?", id)
+	} else {
+		query = query.Where("name = ?", ref)
+	}
+	if err := query.First(&profile).Error; err != nil {
+		return nil, fmt.Errorf("scan profile %q not found", ref)
+	}
+	return &profile, nil
+}
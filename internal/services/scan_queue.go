@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+)
+
+// queuedScanKey carries the stored result of a scan through to the scan limiter, which
+// uses it to report the scan's place in the queue and to mark it running once it starts
+type queuedScanKey struct{}
+
+func withQueuedScan(ctx context.Context, scanResult *models.ScanResult) context.Context {
+	return context.WithValue(ctx, queuedScanKey{}, scanResult)
+}
+
+func queuedScan(ctx context.Context) *models.ScanResult {
+	scanResult, _ := ctx.Value(queuedScanKey{}).(*models.ScanResult)
+	return scanResult
+}
+
+// admit sets the initial state of a new scan: running when a slot is free, otherwise
+// queued with its expected place and start time
+func (s *ScannerService) admit(scanResult *models.ScanResult) {
+	if estimate, queued := s.limiter.Forecast(scanResult.UserID); queued {
+		scanResult.Status = "queued"
+		setQueueEstimate(scanResult, estimate)
+		return
+	}
+	now := time.Now()
+	scanResult.Status = "running"
+	scanResult.StartedAt = &now
+}
+
+// acquireSlot waits for a scan slot and moves a queued scan to running once it has one
+func (s *ScannerService) acquireSlot(ctx context.Context, scanner string) (func(), error) {
+	release, err := s.limiter.Acquire(ctx, scanner)
+	if err != nil {
+		return nil, err
+	}
+	if scanResult := queuedScan(ctx); scanResult != nil && scanResult.Status == "queued" {
+		now := time.Now()
+		scanResult.Status = "running"
+		scanResult.StartedAt = &now
+		scanResult.QueuePosition, scanResult.EstimatedStartAt = nil, nil
+		if err := s.db.Model(scanResult).Updates(map[string]interface{}{"status": "running", "started_at": now}).Error; err != nil {
+			logf(ctx, "⚠️ Failed to mark scan %s running: %v", scanResult.ID, err)
+		}
+	}
+	return release, nil
+}
+
+// annotateQueue fills in the queue position and estimated start of a queued scan
+func (s *ScannerService) annotateQueue(scanResult *models.ScanResult) {
+	if scanResult.Status != "queued" {
+		return
+	}
+	if estimate, ok := s.limiter.Position(scanResult.ID); ok {
+		setQueueEstimate(scanResult, estimate)
+	}
+}
+
+func setQueueEstimate(scanResult *models.ScanResult, estimate ScanQueueEstimate) {
+	scanResult.QueuePosition = &estimate.Position
+	scanResult.EstimatedStartAt = &estimate.StartAt
+}
+
+// LoadScanDurations seeds the limiter's wait estimates with the average duration of past
+// scans of each type, so queue ETAs are meaningful from the first scan after a restart
+func (s *ScannerService) LoadScanDurations() error {
+	var averages []struct {
+		ScanType string
+		Seconds  float64
+	}
+	err := s.db.Model(&models.ScanResult{}).
+		Select("scan_type, AVG(EXTRACT(EPOCH FROM completed_at - started_at)) AS seconds").
+		Where("status = ? AND started_at IS NOT NULL AND completed_at IS NOT NULL", "completed").
+		Group("scan_type").Scan(&averages).Error
+	if err != nil {
+		return err
+	}
+	for _, average := range averages {
+		s.limiter.Seed(average.ScanType, average.Seconds)
+	}
+	return nil
+}
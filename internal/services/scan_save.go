@@ -0,0 +1,98 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+)
+
+// ScanSavePolicy controls how finished scan results survive a database outage
+type ScanSavePolicy struct {
+	Attempts    int           // Tries before falling back to a file
+	RetryBase   time.Duration // Delay before the first retry; doubles on each further attempt
+	FallbackDir string        // Where results are written once every attempt has failed
+}
+
+// saveFinished saves a finished scan result, retrying with backoff. A scan can take many
+// minutes, so when the database stays unavailable the result is written to the fallback
+// directory rather than dropped; RecoverScanFallbacks imports it on the next start.
+func (s *ScannerService) saveFinished(scanResult *models.ScanResult) {
+	attempts := s.savePolicy.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := s.savePolicy.RetryBase
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = s.save(scanResult); err == nil {
+			return
+		}
+		log.Printf("⚠️ Failed to save scan %s (attempt %d/%d): %v", scanResult.ID, attempt, attempts, err)
+		if attempt < attempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+
+	path, fileErr := s.writeScanFallback(scanResult)
+	if fileErr != nil {
+		log.Printf("❌ Scan %s lost: database save failed (%v) and fallback file failed (%v)", scanResult.ID, err, fileErr)
+		return
+	}
+	log.Printf("💾 Scan %s written to %s until the database is reachable", scanResult.ID, path)
+}
+
+func (s *ScannerService) writeScanFallback(scanResult *models.ScanResult) (string, error) {
+	if err := os.MkdirAll(s.savePolicy.FallbackDir, 0o700); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(scanResult)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(s.savePolicy.FallbackDir, "scan-"+scanResult.ID.String()+".json")
+	return path, os.WriteFile(path, data, 0o600)
+}
+
+// RecoverScanFallbacks saves the scan results left in the fallback directory by earlier
+// outages, removing each file once its result is in the database
+func (s *ScannerService) RecoverScanFallbacks() (int, error) {
+	entries, err := os.ReadDir(s.savePolicy.FallbackDir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	recovered := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), "scan-") || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(s.savePolicy.FallbackDir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return recovered, err
+		}
+		var scanResult models.ScanResult
+		if err := json.Unmarshal(data, &scanResult); err != nil {
+			log.Printf("⚠️ Skipping unreadable scan fallback %s: %v", path, err)
+			continue
+		}
+		if err := s.save(&scanResult); err != nil {
+			return recovered, err
+		}
+		if err := os.Remove(path); err != nil {
+			return recovered, err
+		}
+		recovered++
+	}
+	return recovered, nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+)
+
+var errDatabaseDown = errors.New("database is down")
+
+// failingSaves returns a scanner whose saves fail the first failures times, recording every
+// result that gets saved
+func failingSaves(t *testing.T, attempts, failures int) (*ScannerService, *[]*models.ScanResult, *int) {
+	t.Helper()
+	s := NewScannerService(nil, nil, NewResultSigner(""), nil, nil, nil, nil, nil, ScanSavePolicy{
+		Attempts:    attempts,
+		RetryBase:   time.Millisecond,
+		FallbackDir: t.TempDir(),
+	}, ScanMockPolicy{}, nil)
+
+	var saved []*models.ScanResult
+	calls := 0
+	s.save = func(scanResult *models.ScanResult) error {
+		calls++
+		if calls <= failures {
+			return errDatabaseDown
+		}
+		saved = append(saved, scanResult)
+		return nil
+	}
+	return s, &saved, &calls
+}
+
+func finishedScan() *models.ScanResult {
+	return &models.ScanResult{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		ScanType:  "nmap",
+		TargetURL: "example.com",
+		Status:    "completed",
+		Results:   json.RawMessage(`{"output":"22/tcp open ssh"}`),
+	}
+}
+
+func fallbackFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "scan-*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestSaveFinishedRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		attempts     int
+		failures     int
+		wantCalls    int
+		wantFallback bool
+	}{
+		{name: "first attempt", attempts: 3, failures: 0, wantCalls: 1},
+		{name: "succeeds on a retry", attempts: 3, failures: 2, wantCalls: 3},
+		{name: "every attempt fails", attempts: 3, failures: 3, wantCalls: 3, wantFallback: true},
+		{name: "no retries configured", attempts: 0, failures: 1, wantCalls: 1, wantFallback: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, saved, calls := failingSaves(t, tt.attempts, tt.failures)
+			s.saveFinished(finishedScan())
+
+			if *calls != tt.wantCalls {
+				t.Errorf("save called %d times, want %d", *calls, tt.wantCalls)
+			}
+			files := fallbackFiles(t, s.savePolicy.FallbackDir)
+			if tt.wantFallback {
+				if len(files) != 1 || len(*saved) != 0 {
+					t.Fatalf("%d fallback files and %d saves, want the result only in a fallback file", len(files), len(*saved))
+				}
+			} else if len(files) != 0 || len(*saved) != 1 {
+				t.Fatalf("%d fallback files and %d saves, want the result only in the database", len(files), len(*saved))
+			}
+		})
+	}
+}
+
+func TestScanFallbackRecovery(t *testing.T) {
+	s, saved, _ := failingSaves(t, 2, 3)
+	scan := finishedScan()
+	s.saveFinished(scan)
+	if files := fallbackFiles(t, s.savePolicy.FallbackDir); len(files) != 1 {
+		t.Fatalf("%d fallback files after the outage, want 1", len(files))
+	}
+	if err := os.WriteFile(filepath.Join(s.savePolicy.FallbackDir, "scan-garbled.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The database is still down on the first restart, so the file stays
+	if recovered, err := s.RecoverScanFallbacks(); !errors.Is(err, errDatabaseDown) || recovered != 0 {
+		t.Fatalf("RecoverScanFallbacks() = %d, %v, want 0 and the save error", recovered, err)
+	}
+	if files := fallbackFiles(t, s.savePolicy.FallbackDir); len(files) != 2 {
+		t.Fatalf("%d fallback files after a failed recovery, want both kept", len(files))
+	}
+
+	recovered, err := s.RecoverScanFallbacks()
+	if err != nil || recovered != 1 {
+		t.Fatalf("RecoverScanFallbacks() = %d, %v, want 1 recovered", recovered, err)
+	}
+	if len(*saved) != 1 {
+		t.Fatalf("%d results saved, want 1", len(*saved))
+	}
+	got := (*saved)[0]
+	if got.ID != scan.ID || got.UserID != scan.UserID || string(got.Results) != string(scan.Results) || got.Status != scan.Status {
+		t.Errorf("recovered %+v, want %+v", got, scan)
+	}
+	files := fallbackFiles(t, s.savePolicy.FallbackDir)
+	if len(files) != 1 || filepath.Base(files[0]) != "scan-garbled.json" {
+		t.Errorf("fallback files left = %v, want only the unreadable one", files)
+	}
+}
+
+func TestRecoverScanFallbacksWithoutDirectory(t *testing.T) {
+	s, _, _ := failingSaves(t, 1, 0)
+	s.savePolicy.FallbackDir = filepath.Join(t.TempDir(), "missing")
+	if recovered, err := s.RecoverScanFallbacks(); err != nil || recovered != 0 {
+		t.Fatalf("RecoverScanFallbacks() = %d, %v, want 0 and no error", recovered, err)
+	}
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/datmedevil17/go-vuln/internal/utils"
+)
+
+// ErrTargetNotAllowed is returned for a scan target the server won't point scanners at
+var ErrTargetNotAllowed = errors.New("scan target not allowed")
+
+// targetResolver is the subset of net.Resolver used to look up scan targets
+type targetResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// ScanTargetPolicy decides which hosts scanners may be pointed at. A target is rejected when
+// any address it resolves to is loopback, private, link-local (cloud metadata included) or
+// otherwise reserved, or in a configured blocked network, unless an allowlist entry names
+// the host or covers the address.
+type ScanTargetPolicy struct {
+	allowedHosts    map[string]bool
+	allowedNetworks []*net.IPNet
+	blockedNetworks []*net.IPNet
+	resolver        targetResolver
+}
+
+// NewScanTargetPolicy builds a policy from allowlist entries (hostnames, IPs or CIDRs) and
+// blocklist entries (IPs or CIDRs)
+func NewScanTargetPolicy(allowed, blocked []string) (*ScanTargetPolicy, error) {
+	policy := &ScanTargetPolicy{
+		allowedHosts: make(map[string]bool),
+		resolver:     net.DefaultResolver,
+	}
+	for _, entry := range allowed {
+		if network, err := parseNetwork(entry); err == nil {
+			policy.allowedNetworks = append(policy.allowedNetworks, network)
+			continue
+		}
+		host := utils.NormalizeHost(entry)
+		if host == "" || strings.ContainsAny(entry, "/:") {
+			return nil, fmt.Errorf("scan target allowlist entries must be hostnames, IPs or CIDRs, got %q", entry)
+		}
+		policy.allowedHosts[host] = true
+	}
+	for _, entry := range blocked {
+		network, err := parseNetwork(entry)
+		if err != nil {
+			return nil, fmt.Errorf("scan target blocklist entries must be IPs or CIDRs, got %q", entry)
+		}
+		policy.blockedNetworks = append(policy.blockedNetworks, network)
+	}
+	return policy, nil
+}
+
+// parseNetwork reads a CIDR, or a single IP as the network holding just that address
+func parseNetwork(entry string) (*net.IPNet, error) {
+	entry = strings.TrimSpace(entry)
+	if ip := net.ParseIP(entry); ip != nil {
+		bits := 128
+		if v4 := ip.To4(); v4 != nil {
+			ip, bits = v4, 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(entry)
+	return network, err
+}
+
+// Check resolves the target's host and returns ErrTargetNotAllowed, saying why, if scanning
+// it could reach an internal address. Scanners resolve the host again themselves, so this
+// keeps honest mistakes and casual probing out rather than a host that changes its DNS.
+func (p *ScanTargetPolicy) Check(ctx context.Context, target string) error {
+	host := utils.NormalizeHost(target)
+	if host == "" {
+		return fmt.Errorf("%w: no host in %q", ErrTargetNotAllowed, target)
+	}
+	if p.allowedHosts[host] {
+		return nil
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		addrs, err := p.resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return fmt.Errorf("%w: %s does not resolve: %v", ErrTargetNotAllowed, host, err)
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+	if len(ips) == 0 {
+		return fmt.Errorf("%w: no addresses found for %s", ErrTargetNotAllowed, host)
+	}
+
+	for _, ip := range ips {
+		if containsIP(p.allowedNetworks, ip) {
+			continue
+		}
+		address := host
+		if ip.String() != host {
+			address = fmt.Sprintf("%s (resolved from %s)", ip, host)
+		}
+		if containsIP(p.blockedNetworks, ip) {
+			return fmt.Errorf("%w: %s is blocked on this server", ErrTargetNotAllowed, address)
+		}
+		if !utils.IsPublicIP(ip) {
+			return fmt.Errorf("%w: %s is a private or reserved address; add it to SCAN_TARGET_ALLOWLIST to scan it", ErrTargetNotAllowed, address)
+		}
+	}
+	return nil
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTarget checks that target may be scanned from this server
+func (s *ScannerService) ValidateTarget(ctx context.Context, target string) error {
+	return s.targets.Check(ctx, target)
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeResolver answers lookups from a fixed table, handing out the next answer for a host on
+// each lookup so DNS changes between checks can be simulated
+type fakeResolver map[string][][]string
+
+func (r fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	answers, ok := r[host]
+	if !ok || len(answers) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	answer := answers[0]
+	if len(answers) > 1 {
+		r[host] = answers[1:]
+	}
+	addrs := make([]net.IPAddr, len(answer))
+	for i, ip := range answer {
+		addrs[i] = net.IPAddr{IP: net.ParseIP(ip)}
+	}
+	return addrs, nil
+}
+
+func TestScanTargetPolicyCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		blocked []string
+		target  string
+		wantErr string // Substring of the error, "" when the target is allowed
+	}{
+		{name: "public host", target: "https://example.com/login"},
+		{name: "public IP", target: "93.184.216.34"},
+		{name: "cloud metadata", target: "http://169.254.169.254/latest/meta-data/", wantErr: "private or reserved"},
+		{name: "private CIDR", target: "10.0.0.5", wantErr: "private or reserved"},
+		{name: "loopback", target: "127.0.0.1:8080", wantErr: "private or reserved"},
+		{name: "IPv6 loopback", target: "http://[::1]:8080/", wantErr: "private or reserved"},
+		{name: "host resolving to a private address", target: "intranet.example.com", wantErr: "192.168.10.4 (resolved from intranet.example.com)"},
+		{name: "host with one private address among public ones", target: "split.example.com", wantErr: "10.1.1.1"},
+		{name: "unresolvable host", target: "nowhere.invalid", wantErr: "does not resolve"},
+		{name: "no host", target: "http://", wantErr: "no host"},
+		{name: "allowlisted host", allowed: []string{"intranet.example.com"}, target: "intranet.example.com"},
+		{name: "allowlisted CIDR", allowed: []string{"10.0.0.0/24"}, target: "10.0.0.5"},
+		{name: "outside allowlisted CIDR", allowed: []string{"10.0.0.0/24"}, target: "10.0.1.5", wantErr: "private or reserved"},
+		{name: "blocklisted public address", blocked: []string{"93.184.216.0/24"}, target: "example.com", wantErr: "blocked on this server"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewScanTargetPolicy(tt.allowed, tt.blocked)
+			if err != nil {
+				t.Fatal(err)
+			}
+			policy.resolver = fakeResolver{
+				"example.com":          {{"93.184.216.34"}},
+				"intranet.example.com": {{"192.168.10.4"}},
+				"split.example.com":    {{"93.184.216.34", "10.1.1.1"}},
+			}
+
+			err = policy.Check(context.Background(), tt.target)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected rejection: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrTargetNotAllowed) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got %v, want ErrTargetNotAllowed mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScanTargetPolicyResolvesOnEveryCheck(t *testing.T) {
+	policy, err := NewScanTargetPolicy(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The host rebinds to the metadata address after its first check
+	policy.resolver = fakeResolver{"rebind.example.com": {{"93.184.216.34"}, {"169.254.169.254"}}}
+
+	if err := policy.Check(context.Background(), "rebind.example.com"); err != nil {
+		t.Fatalf("first check: %v", err)
+	}
+	if err := policy.Check(context.Background(), "rebind.example.com"); !errors.Is(err, ErrTargetNotAllowed) {
+		t.Fatalf("second check: got %v, want the rebound address rejected", err)
+	}
+}
+
+func TestNewScanTargetPolicyRejectsBadEntries(t *testing.T) {
+	if _, err := NewScanTargetPolicy([]string{"http://example.com/path"}, nil); err == nil {
+		t.Error("accepted a URL as an allowlist entry")
+	}
+	if _, err := NewScanTargetPolicy(nil, []string{"example.com"}); err == nil {
+		t.Error("accepted a hostname as a blocklist entry")
+	}
+}
@@ -3,8 +3,10 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os/exec"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/datmedevil17/go-vuln/internal/models"
@@ -13,31 +15,71 @@ import (
 )
 
 type ScannerService struct {
-	db *gorm.DB
+	db        *gorm.DB
+	wordlists *WordlistRegistry
+	signer    *ResultSigner
+	redactor  *Redactor
+	argPolicy *ScannerArgPolicy
+	targets   *ScanTargetPolicy
+	limiter   *ScanLimiter
+	runner    ToolRunner
+
+	savePolicy ScanSavePolicy
+	mocks      ScanMockPolicy
+	save       func(*models.ScanResult) error
+	siem       *SIEMExporter
 }
 
-func NewScannerService(db *gorm.DB) *ScannerService {
-	return &ScannerService{db: db}
+func NewScannerService(db *gorm.DB, wordlists *WordlistRegistry, signer *ResultSigner, redactor *Redactor, argPolicy *ScannerArgPolicy, targets *ScanTargetPolicy, limiter *ScanLimiter, runner ToolRunner, savePolicy ScanSavePolicy, mocks ScanMockPolicy, siem *SIEMExporter) *ScannerService {
+	return &ScannerService{
+		db:         db,
+		wordlists:  wordlists,
+		signer:     signer,
+		redactor:   redactor,
+		argPolicy:  argPolicy,
+		targets:    targets,
+		limiter:    limiter,
+		runner:     runner,
+		savePolicy: savePolicy,
+		mocks:      mocks,
+		save: func(scanResult *models.ScanResult) error {
+			return db.Save(scanResult).Error
+		},
+		siem: siem,
+	}
+}
+
+// Wordlists returns the registry used to resolve gobuster wordlist names
+func (s *ScannerService) Wordlists() *WordlistRegistry {
+	return s.wordlists
+}
+
+// Redactor returns the redactor applied to scan output before it is stored
+func (s *ScannerService) Redactor() *Redactor {
+	return s.redactor
 }
 
 // NmapScan performs network port scanning
 func (s *ScannerService) NmapScan(ctx context.Context, userID uuid.UUID, target string, ports string) (*models.ScanResult, error) {
+	if err := s.ValidateTarget(ctx, target); err != nil {
+		return nil, err
+	}
+
 	scanResult := &models.ScanResult{
 		UserID:    userID,
 		ScanType:  "nmap",
 		TargetURL: target,
-		Status:    "running",
 	}
-	now := time.Now()
-	scanResult.StartedAt = &now
+	s.admit(scanResult)
 
 	if err := s.db.Create(scanResult).Error; err != nil {
 		return nil, err
 	}
 
-	// Run nmap in background
+	// Run nmap in background, answering with a copy the goroutine won't touch
+	response := *scanResult
 	go func() {
-		output, err := s.RunNmap(target, ports)
+		output, err := s.RunNmap(withQueuedScan(context.Background(), scanResult), target, ports, nil)
 		completeTime := time.Now()
 		scanResult.CompletedAt = &completeTime
 
@@ -50,28 +92,46 @@ func (s *ScannerService) NmapScan(ctx context.Context, userID uuid.UUID, target
 				"output": output,
 				"ports":  ports,
 			}
+			if changes, err := s.ComparePorts(userID, target, output); err != nil {
+				logf(ctx, "⚠️ Failed to compare scan %s with the previous nmap run: %v", scanResult.ID, err)
+			} else {
+				result["port_changes"] = changes
+			}
 			jsonResult, _ := json.Marshal(result)
 			scanResult.Results = jsonResult
 		}
-		s.db.Save(scanResult)
+		s.finish(scanResult)
 	}()
 
-	return scanResult, nil
+	return &response, nil
 }
 
-// RunNmap executes nmap synchronously
-func (s *ScannerService) RunNmap(target, ports string) (string, error) {
-	// Check if nmap is installed
-	_, err := exec.LookPath("nmap")
+// RunNmap executes nmap synchronously, appending extraArgs once the arg policy allows them
+func (s *ScannerService) RunNmap(ctx context.Context, target, ports string, extraArgs []string) (string, error) {
+	if err := s.ValidateTarget(ctx, target); err != nil {
+		return "", err
+	}
+	if err := s.argPolicy.Check("nmap", extraArgs); err != nil {
+		return "", err
+	}
+	release, err := s.acquireSlot(ctx, "nmap")
 	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	// Check if nmap is installed
+	if s.mocked("nmap") {
 		// Mock execution if tool missing
-		time.Sleep(2 * time.Second) // Simulate work
-		return fmt.Sprintf("[MOCK] Nmap scan for %s ports %s\nHost is up (0.001s latency).\nPORT STATE SERVICE\n80/tcp open http\n443/tcp open https", target, ports), nil
+		if err := s.mockDelay(ctx, 2*time.Second); err != nil { // Simulate work
+			return "", err
+		}
+		return s.mockNmap(target, ports), nil
 	}
 
-	args := []string{"-p", ports, "-sV", target}
-	cmd := exec.Command("nmap", args...)
-	output, err := cmd.CombinedOutput()
+	args := append([]string{"-p", ports, "-sV"}, extraArgs...)
+	args = append(args, target)
+	output, err := s.runner.Run(ctx, ToolRun{Tool: "nmap", Args: args, Network: true})
 	if err != nil {
 		return "", fmt.Errorf("nmap execution failed: %v, output: %s", err, string(output))
 	}
@@ -80,21 +140,24 @@ func (s *ScannerService) RunNmap(target, ports string) (string, error) {
 
 // NiktoScan performs web server vulnerability scanning
 func (s *ScannerService) NiktoScan(ctx context.Context, userID uuid.UUID, target string) (*models.ScanResult, error) {
+	if err := s.ValidateTarget(ctx, target); err != nil {
+		return nil, err
+	}
+
 	scanResult := &models.ScanResult{
 		UserID:    userID,
 		ScanType:  "nikto",
 		TargetURL: target,
-		Status:    "running",
 	}
-	now := time.Now()
-	scanResult.StartedAt = &now
+	s.admit(scanResult)
 
 	if err := s.db.Create(scanResult).Error; err != nil {
 		return nil, err
 	}
 
+	response := *scanResult
 	go func() {
-		output, err := s.RunNikto(target)
+		output, err := s.RunNikto(withQueuedScan(context.Background(), scanResult), target, nil, nil)
 		completeTime := time.Now()
 		scanResult.CompletedAt = &completeTime
 
@@ -105,31 +168,41 @@ func (s *ScannerService) NiktoScan(ctx context.Context, userID uuid.UUID, target
 			scanResult.Status = "completed"
 			scanResult.Results = json.RawMessage(output)
 		}
-		s.db.Save(scanResult)
+		s.finish(scanResult)
 	}()
 
-	return scanResult, nil
+	return &response, nil
 }
 
-// RunNikto executes nikto synchronously
-func (s *ScannerService) RunNikto(target string) ([]byte, error) {
-	_, err := exec.LookPath("nikto")
+// RunNikto executes nikto synchronously, authenticating with auth when it's non-nil
+func (s *ScannerService) RunNikto(ctx context.Context, target string, auth *ScanAuth, extraArgs []string) ([]byte, error) {
+	if err := s.ValidateTarget(ctx, target); err != nil {
+		return nil, err
+	}
+	if err := s.argPolicy.Check("nikto", extraArgs); err != nil {
+		return nil, err
+	}
+	release, err := s.acquireSlot(ctx, "nikto")
 	if err != nil {
-		time.Sleep(3 * time.Second)
-		mockResult := map[string]interface{}{
-			"host": target,
-			"ip":   "127.0.0.1",
-			"vulnerabilities": []string{
-				"No CGI Directories found (use '-C all' to force check all possible dirs)",
-				"Allowed HTTP Methods: GET, HEAD, POST, OPTIONS",
-				"OSVDB-3092: /admin/: This might be interesting...",
-			},
+		return nil, err
+	}
+	defer release()
+	authArgs, err := auth.niktoArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.mocked("nikto") {
+		if err := s.mockDelay(ctx, 3*time.Second); err != nil {
+			return nil, err
 		}
-		return json.Marshal(mockResult)
+		return s.mockNikto(target)
 	}
 
-	cmd := exec.Command("nikto", "-h", target, "-Format", "json")
-	output, err := cmd.CombinedOutput()
+	args := append([]string{"-h", target, "-Format", "json"}, authArgs...)
+	args = append(args, extraArgs...)
+	output, err := s.runner.Run(ctx, ToolRun{Tool: "nikto", Args: args, Network: true})
+	output = auth.redact(output)
 	if err != nil {
 		return nil, fmt.Errorf("nikto execution failed: %v", err)
 	}
@@ -138,21 +211,33 @@ func (s *ScannerService) RunNikto(target string) ([]byte, error) {
 
 // GobusterScan performs directory/file brute-forcing
 func (s *ScannerService) GobusterScan(ctx context.Context, userID uuid.UUID, target, wordlist string) (*models.ScanResult, error) {
+	// Check the target and resolve the wordlist before creating the record, so either being
+	// bad fails the request instead of the scan
+	if err := s.ValidateTarget(ctx, target); err != nil {
+		return nil, err
+	}
+	wordlistPath, err := s.wordlists.Resolve(userID, wordlist)
+	if err != nil {
+		return nil, err
+	}
+	if wordlist == "" {
+		wordlist = DefaultWordlist
+	}
+
 	scanResult := &models.ScanResult{
 		UserID:    userID,
 		ScanType:  "gobuster",
 		TargetURL: target,
-		Status:    "running",
 	}
-	now := time.Now()
-	scanResult.StartedAt = &now
+	s.admit(scanResult)
 
 	if err := s.db.Create(scanResult).Error; err != nil {
 		return nil, err
 	}
 
+	response := *scanResult
 	go func() {
-		output, err := s.RunGobuster(target, wordlist)
+		output, err := s.RunGobuster(withQueuedScan(context.Background(), scanResult), target, wordlistPath, nil, nil)
 		completeTime := time.Now()
 		scanResult.CompletedAt = &completeTime
 
@@ -168,26 +253,37 @@ func (s *ScannerService) GobusterScan(ctx context.Context, userID uuid.UUID, tar
 			jsonResult, _ := json.Marshal(result)
 			scanResult.Results = jsonResult
 		}
-		s.db.Save(scanResult)
+		s.finish(scanResult)
 	}()
 
-	return scanResult, nil
+	return &response, nil
 }
 
-// RunGobuster executes gobuster synchronously
-func (s *ScannerService) RunGobuster(target, wordlist string) (string, error) {
-	if wordlist == "" {
-		wordlist = "/usr/share/wordlists/dirb/common.txt"
+// RunGobuster executes gobuster synchronously against a wordlist path returned by WordlistRegistry.Resolve
+func (s *ScannerService) RunGobuster(ctx context.Context, target, wordlistPath string, auth *ScanAuth, extraArgs []string) (string, error) {
+	if err := s.ValidateTarget(ctx, target); err != nil {
+		return "", err
 	}
-
-	_, err := exec.LookPath("gobuster")
+	if err := s.argPolicy.Check("gobuster", extraArgs); err != nil {
+		return "", err
+	}
+	release, err := s.acquireSlot(ctx, "gobuster")
 	if err != nil {
-		time.Sleep(2 * time.Second)
-		return fmt.Sprintf("[MOCK] Gobuster results for %s:\n/images (Status: 200)\n/css (Status: 200)\n/js (Status: 200)\n/admin (Status: 301)", target), nil
+		return "", err
+	}
+	defer release()
+
+	if s.mocked("gobuster") {
+		if err := s.mockDelay(ctx, 2*time.Second); err != nil {
+			return "", err
+		}
+		return s.mockGobuster(target), nil
 	}
 
-	cmd := exec.Command("gobuster", "dir", "-u", target, "-w", wordlist, "-q")
-	output, err := cmd.CombinedOutput()
+	args := append([]string{"dir", "-u", target, "-w", wordlistPath, "-q"}, auth.gobusterArgs()...)
+	args = append(args, extraArgs...)
+	output, err := s.runner.Run(ctx, ToolRun{Tool: "gobuster", Args: args, Mounts: []Mount{{Path: wordlistPath}}, Network: true})
+	output = auth.redact(output)
 	if err != nil {
 		return "", fmt.Errorf("gobuster execution failed: %v", err)
 	}
@@ -196,21 +292,24 @@ func (s *ScannerService) RunGobuster(target, wordlist string) (string, error) {
 
 // SqlmapScan performs SQL injection testing
 func (s *ScannerService) SqlmapScan(ctx context.Context, userID uuid.UUID, target string) (*models.ScanResult, error) {
+	if err := s.ValidateTarget(ctx, target); err != nil {
+		return nil, err
+	}
+
 	scanResult := &models.ScanResult{
 		UserID:    userID,
 		ScanType:  "sqlmap",
 		TargetURL: target,
-		Status:    "running",
 	}
-	now := time.Now()
-	scanResult.StartedAt = &now
+	s.admit(scanResult)
 
 	if err := s.db.Create(scanResult).Error; err != nil {
 		return nil, err
 	}
 
+	response := *scanResult
 	go func() {
-		output, err := s.RunSqlmap(target)
+		output, err := s.RunSqlmap(withQueuedScan(context.Background(), scanResult), target, nil, nil)
 		completeTime := time.Now()
 		scanResult.CompletedAt = &completeTime
 
@@ -225,23 +324,38 @@ func (s *ScannerService) SqlmapScan(ctx context.Context, userID uuid.UUID, targe
 			jsonResult, _ := json.Marshal(result)
 			scanResult.Results = jsonResult
 		}
-		s.db.Save(scanResult)
+		s.finish(scanResult)
 	}()
 
-	return scanResult, nil
+	return &response, nil
 }
 
 // RunSqlmap executes sqlmap synchronously
-func (s *ScannerService) RunSqlmap(target string) (string, error) {
-	_, err := exec.LookPath("sqlmap")
+func (s *ScannerService) RunSqlmap(ctx context.Context, target string, auth *ScanAuth, extraArgs []string) (string, error) {
+	if err := s.ValidateTarget(ctx, target); err != nil {
+		return "", err
+	}
+	if err := s.argPolicy.Check("sqlmap", extraArgs); err != nil {
+		return "", err
+	}
+	release, err := s.acquireSlot(ctx, "sqlmap")
 	if err != nil {
-		time.Sleep(2 * time.Second)
-		return fmt.Sprintf("[MOCK] Sqlmap results for %s:\nTarget is not vulnerable to SQL injection", target), nil
+		return "", err
+	}
+	defer release()
+
+	if s.mocked("sqlmap") {
+		if err := s.mockDelay(ctx, 2*time.Second); err != nil {
+			return "", err
+		}
+		return s.mockSqlmap(target), nil
 	}
 
 	// Basic non-interactive batch scan
-	cmd := exec.Command("sqlmap", "-u", target, "--batch", "--random-agent", "--level=1", "--risk=1")
-	output, err := cmd.CombinedOutput()
+	args := append([]string{"-u", target, "--batch", "--random-agent", "--level=1", "--risk=1"}, auth.sqlmapArgs()...)
+	args = append(args, extraArgs...)
+	output, err := s.runner.Run(ctx, ToolRun{Tool: "sqlmap", Args: args, Network: true})
+	output = auth.redact(output)
 	if err != nil {
 		// sqlmap returns non-zero exit code sometimes even if successful but found nothing? checking output might be better?
 		// for now, strict error check. sqlmap usually returns 0.
@@ -252,21 +366,24 @@ func (s *ScannerService) RunSqlmap(target string) (string, error) {
 
 // WpscanScan performs WordPress vulnerability scanning
 func (s *ScannerService) WpscanScan(ctx context.Context, userID uuid.UUID, target string) (*models.ScanResult, error) {
+	if err := s.ValidateTarget(ctx, target); err != nil {
+		return nil, err
+	}
+
 	scanResult := &models.ScanResult{
 		UserID:    userID,
 		ScanType:  "wpscan",
 		TargetURL: target,
-		Status:    "running",
 	}
-	now := time.Now()
-	scanResult.StartedAt = &now
+	s.admit(scanResult)
 
 	if err := s.db.Create(scanResult).Error; err != nil {
 		return nil, err
 	}
 
+	response := *scanResult
 	go func() {
-		output, err := s.RunWpscan(target)
+		output, err := s.RunWpscan(withQueuedScan(context.Background(), scanResult), target, nil, nil)
 		completeTime := time.Now()
 		scanResult.CompletedAt = &completeTime
 
@@ -281,29 +398,45 @@ func (s *ScannerService) WpscanScan(ctx context.Context, userID uuid.UUID, targe
 			jsonResult, _ := json.Marshal(result)
 			scanResult.Results = jsonResult
 		}
-		s.db.Save(scanResult)
+		s.finish(scanResult)
 	}()
 
-	return scanResult, nil
+	return &response, nil
 }
 
 // RunWpscan executes wpscan synchronously
-func (s *ScannerService) RunWpscan(target string) (string, error) {
-	_, err := exec.LookPath("wpscan")
+func (s *ScannerService) RunWpscan(ctx context.Context, target string, auth *ScanAuth, extraArgs []string) (string, error) {
+	if err := s.ValidateTarget(ctx, target); err != nil {
+		return "", err
+	}
+	if err := s.argPolicy.Check("wpscan", extraArgs); err != nil {
+		return "", err
+	}
+	release, err := s.acquireSlot(ctx, "wpscan")
 	if err != nil {
-		time.Sleep(2 * time.Second)
-		return fmt.Sprintf("[MOCK] WPScan results for %s:\n[+] WordPress version 5.8 identified (Latest, released on 2021-07-20)", target), nil
+		return "", err
 	}
+	defer release()
 
-	cmd := exec.Command("wpscan", "--url", target, "--no-update", "--stealthy")
-	output, err := cmd.CombinedOutput()
+	if s.mocked("wpscan") {
+		if err := s.mockDelay(ctx, 2*time.Second); err != nil {
+			return "", err
+		}
+		return s.mockWpscan(target), nil
+	}
+
+	args := append([]string{"--url", target, "--no-update", "--stealthy"}, auth.wpscanArgs()...)
+	args = append(args, extraArgs...)
+	output, err := s.runner.Run(ctx, ToolRun{Tool: "wpscan", Args: args, Network: true})
+	output = auth.redact(output)
 	if err != nil {
 		// wpscan often returns non-zero codes for found vulnerabilities
 		// Code 0: No error
 		// Code 1: Error
 		// Code 2: Vulnerabilities found
 		// So we might want to allow code 2.
-		if exitError, ok := err.(*exec.ExitError); ok {
+		var exitError interface{ ExitCode() int }
+		if errors.As(err, &exitError) {
 			if exitError.ExitCode() == 2 || exitError.ExitCode() == 3 || exitError.ExitCode() == 4 {
 				// 2: Short output (vulnerabilities found)
 				// 3: Detailed output (vulnerabilities found)
@@ -317,20 +450,174 @@ func (s *ScannerService) RunWpscan(target string) (string, error) {
 	return string(output), nil
 }
 
+// zapBinary is ZAP's packaged baseline scan script, also the name its flags and sandbox image are configured under
+const zapBinary = "zap-baseline.py"
+
+// RunZAP runs ZAP's baseline scan synchronously, spidering the target and reporting the alerts
+// of its passive rules as JSON. The baseline script can only write its report to a file, so it
+// gets a temporary directory for it.
+func (s *ScannerService) RunZAP(ctx context.Context, target string, auth *ScanAuth, extraArgs []string) ([]byte, error) {
+	if err := s.ValidateTarget(ctx, target); err != nil {
+		return nil, err
+	}
+	if err := s.argPolicy.Check(zapBinary, extraArgs); err != nil {
+		return nil, err
+	}
+	release, err := s.acquireSlot(ctx, zapBinary)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	env, err := auth.zapEnv(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.mocked(zapBinary) {
+		if err := s.mockDelay(ctx, 3*time.Second); err != nil {
+			return nil, err
+		}
+		return s.mockZAP(target)
+	}
+
+	dir, err := os.MkdirTemp("", "vulnpilot-zap-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	// ZAP's image runs the scan as its own user, which has to be able to write the report
+	if err := os.Chmod(dir, 0o777); err != nil {
+		return nil, err
+	}
+	report := filepath.Join(dir, "report.json")
+
+	// -I keeps warnings from failing the run. An absolute -J path is used as given rather
+	// than under ZAP's /zap/wrk.
+	args := append([]string{"-t", target, "-J", report, "-I"}, extraArgs...)
+	output, err := s.runner.Run(ctx, ToolRun{Tool: zapBinary, Args: args, Mounts: []Mount{{Path: dir, Writable: true}}, Network: true, Env: env})
+	if err != nil {
+		// Exit codes 1 and 2 mean alerts at FAIL or WARN level; the report is still complete
+		var exitError interface{ ExitCode() int }
+		if ctx.Err() != nil || !errors.As(err, &exitError) || (exitError.ExitCode() != 1 && exitError.ExitCode() != 2) {
+			return nil, fmt.Errorf("zap baseline scan failed: %v, output: %s", err, auth.redact(output))
+		}
+	}
+	data, err := os.ReadFile(report)
+	if err != nil {
+		return nil, fmt.Errorf("zap baseline scan wrote no report: %v", err)
+	}
+	return auth.redact(data), nil
+}
+
+// finish redacts and signs a scan result that has reached its final state, saves it with
+// its normalized findings and exports them
+func (s *ScannerService) finish(scanResult *models.ScanResult) {
+	// Decode first so JSON reports embedded in the output are redacted unescaped
+	var decoded interface{}
+	if json.Unmarshal(scanResult.Results, &decoded) == nil {
+		if redacted, err := json.Marshal(s.redactor.Value(decoded)); err == nil {
+			scanResult.Results = redacted
+		}
+	}
+	scanResult.ErrorMessage = s.redactor.Text(scanResult.ErrorMessage)
+
+	var findings []Finding
+	if scanResult.Status == "completed" {
+		findings = scanFindings(*scanResult)
+		var stored map[string]interface{}
+		if json.Unmarshal(scanResult.Results, &stored) == nil {
+			stored["findings"] = findings
+			if enriched, err := json.Marshal(stored); err == nil {
+				scanResult.Results = enriched
+			}
+		}
+	}
+	s.signer.Sign(scanResult)
+	s.saveFinished(scanResult)
+
+	// A workflow's scans are exported with the findings of their execution
+	if scanResult.Status != "completed" || scanResult.ExecutionID != nil {
+		return
+	}
+	s.siem.Export(FindingsEvent{
+		Source:   "scan",
+		SourceID: scanResult.ID,
+		UserID:   scanResult.UserID,
+		Time:     *scanResult.CompletedAt,
+		Findings: findings,
+	})
+}
+
+// RecordWorkflowScan stores the outcome of a workflow's scanner node as a scan result, so
+// it is listed, verified and reprocessed like a standalone scan. Secrets are masked and the
+// result is signed the same way.
+func (s *ScannerService) RecordWorkflowScan(scanResult *models.ScanResult, result interface{}, runErr error) {
+	if runErr != nil {
+		scanResult.Status = "failed"
+		scanResult.ErrorMessage = runErr.Error()
+	} else {
+		scanResult.Status = "completed"
+	}
+	if result != nil {
+		if raw, err := json.Marshal(result); err == nil {
+			scanResult.Results = raw
+		}
+	}
+	s.finish(scanResult)
+}
+
+// scanFindings normalizes the raw output of a completed scan result
+func scanFindings(scan models.ScanResult) []Finding {
+	output, _ := scanResultAsNodeResult(scan)["output"].(string)
+	findings := Normalize(scan.ScanType, []byte(output))
+	for i := range findings {
+		if findings[i].Target == "" {
+			findings[i].Target = scan.TargetURL
+		}
+	}
+	return findings
+}
+
 // GetScanResult retrieves a scan result
 func (s *ScannerService) GetScanResult(scanID, userID uuid.UUID) (*models.ScanResult, error) {
 	var scanResult models.ScanResult
 	if err := s.db.Where("id = ? AND user_id = ?", scanID, userID).First(&scanResult).Error; err != nil {
 		return nil, err
 	}
+	s.annotateQueue(&scanResult)
 	return &scanResult, nil
 }
 
-// ListScanResults lists all scan results for a user
-func (s *ScannerService) ListScanResults(userID uuid.UUID) ([]models.ScanResult, error) {
+// ListScanResults lists all scan results for a user, only those of one workflow execution
+// when executionID is set
+func (s *ScannerService) ListScanResults(userID uuid.UUID, executionID *uuid.UUID) ([]models.ScanResult, error) {
+	query := s.db.Where("user_id = ?", userID)
+	if executionID != nil {
+		query = query.Where("execution_id = ?", *executionID)
+	}
 	var results []models.ScanResult
-	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&results).Error; err != nil {
+	if err := query.Order("created_at DESC").Find(&results).Error; err != nil {
 		return nil, err
 	}
+	for i := range results {
+		s.annotateQueue(&results[i])
+	}
 	return results, nil
 }
+
+// sleepContext waits for d or until ctx is done, whichever comes first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DeleteScanResults bulk-deletes the user's scan results by ID or filter
+func (s *ScannerService) DeleteScanResults(userID uuid.UUID, ids []uuid.UUID, filter BulkDeleteFilter) (int64, error) {
+	return bulkDelete(s.db, &models.ScanResult{}, userID, ids, filter, ScanDeletableStatuses)
+}
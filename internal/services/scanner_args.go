@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrForbiddenScannerArg is returned when a node or profile passes a scanner flag the server doesn't allow
+var ErrForbiddenScannerArg = errors.New("scanner argument not allowed")
+
+// defaultAllowedScannerFlags are the extra flags nodes may pass to each scanner unless
+// SCANNER_ALLOWED_FLAGS overrides them. A trailing "=" marks a flag that takes a value.
+// Anything that runs scripts, writes files or changes the target is deliberately absent.
+var defaultAllowedScannerFlags = map[string][]string{
+	"nmap":     {"-Pn", "-F", "-sT", "-sV", "-T2", "-T3", "-T4", "--open", "--top-ports=", "--version-intensity="},
+	"nikto":    {"-ssl", "-nossl", "-Tuning=", "-timeout=", "-maxtime="},
+	"gobuster": {"-k", "-r", "-x=", "-s=", "-b=", "-t=", "--timeout=", "--exclude-length="},
+	"sqlmap":   {"--forms", "--level=", "--risk=", "--dbms=", "--technique=", "-p=", "--threads=", "--crawl="},
+	"wpscan":   {"--disable-tls-checks", "-e=", "--enumerate=", "--plugins-detection=", "--detection-mode="},
+	zapBinary:  {"-a", "-j", "-m=", "-T=", "-D="},
+}
+
+// ScannerArgPolicy decides which extra flags workflow nodes may add to scanner commands.
+// Denied flags always win; a scanner with no allowed flags accepts no extra arguments.
+type ScannerArgPolicy struct {
+	allowed map[string]map[string]bool // scanner -> flag -> takes a value
+	denied  map[string]map[string]struct{}
+}
+
+// NewScannerArgPolicy builds a policy from "scanner:flag" entries. Allowed entries replace the
+// defaults for the scanners they name; denied entries apply on top of whatever is allowed.
+func NewScannerArgPolicy(allowed, denied []string) (*ScannerArgPolicy, error) {
+	policy := &ScannerArgPolicy{
+		allowed: make(map[string]map[string]bool),
+		denied:  make(map[string]map[string]struct{}),
+	}
+	for scanner, flags := range defaultAllowedScannerFlags {
+		policy.allowed[scanner] = parseAllowedFlags(flags)
+	}
+
+	overrides := make(map[string][]string)
+	for _, entry := range allowed {
+		scanner, flag, err := splitScannerFlag(entry)
+		if err != nil {
+			return nil, err
+		}
+		overrides[scanner] = append(overrides[scanner], flag)
+	}
+	for scanner, flags := range overrides {
+		policy.allowed[scanner] = parseAllowedFlags(flags)
+	}
+
+	for _, entry := range denied {
+		scanner, flag, err := splitScannerFlag(entry)
+		if err != nil {
+			return nil, err
+		}
+		if policy.denied[scanner] == nil {
+			policy.denied[scanner] = make(map[string]struct{})
+		}
+		policy.denied[scanner][strings.TrimSuffix(flag, "=")] = struct{}{}
+	}
+	return policy, nil
+}
+
+func splitScannerFlag(entry string) (string, string, error) {
+	scanner, flag, ok := strings.Cut(strings.TrimSpace(entry), ":")
+	if !ok || scanner == "" || !strings.HasPrefix(flag, "-") {
+		return "", "", fmt.Errorf("scanner flag entries must look like nmap:--top-ports=, got %q", entry)
+	}
+	return scanner, flag, nil
+}
+
+func parseAllowedFlags(flags []string) map[string]bool {
+	parsed := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		parsed[strings.TrimSuffix(flag, "=")] = strings.HasSuffix(flag, "=")
+	}
+	return parsed
+}
+
+// Check validates extra arguments for scanner. Each argument must be an allowed flag;
+// a flag that takes a value gets it either as --flag=value or as the next argument,
+// so stray positional arguments (which could add targets) are rejected.
+func (p *ScannerArgPolicy) Check(scanner string, args []string) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			return fmt.Errorf("%w: %s does not accept positional argument %q", ErrForbiddenScannerArg, scanner, arg)
+		}
+
+		flag, _, hasValue := strings.Cut(arg, "=")
+		if _, denied := p.denied[scanner][flag]; denied {
+			return fmt.Errorf("%w: %s flag %s is denied on this server", ErrForbiddenScannerArg, scanner, flag)
+		}
+		takesValue, allowed := p.allowed[scanner][flag]
+		if !allowed {
+			return fmt.Errorf("%w: %s flag %s is not in the allowlist", ErrForbiddenScannerArg, scanner, flag)
+		}
+
+		switch {
+		case takesValue && !hasValue:
+			if i+1 == len(args) {
+				return fmt.Errorf("%w: %s flag %s needs a value", ErrForbiddenScannerArg, scanner, flag)
+			}
+			i++ // The value may be anything, including something that looks like a flag
+		case !takesValue && hasValue:
+			return fmt.Errorf("%w: %s flag %s does not take a value", ErrForbiddenScannerArg, scanner, flag)
+		}
+	}
+	return nil
+}
+
+// extraArgs reads the extraArgs list from node data
+func extraArgs(node *WorkflowNode) ([]string, error) {
+	raw, ok := node.Data["extraArgs"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("extraArgs must be a list of strings")
+	}
+	args := make([]string, 0, len(items))
+	for _, item := range items {
+		arg, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("extraArgs must be a list of strings")
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
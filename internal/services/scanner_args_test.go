@@ -0,0 +1,69 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestScannerArgPolicyCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		denied  []string
+		scanner string
+		args    []string
+		wantErr string // Substring of the error, "" when the arguments are allowed
+	}{
+		{name: "no arguments", scanner: "nmap"},
+		{name: "default flags", scanner: "nmap", args: []string{"-Pn", "--open", "-T4"}},
+		{name: "value joined with =", scanner: "nmap", args: []string{"--top-ports=100"}},
+		{name: "value as next argument", scanner: "gobuster", args: []string{"-x", "php,html", "-k"}},
+		{name: "value that looks like a flag", scanner: "gobuster", args: []string{"-b", "-1"}},
+		{name: "script flag", scanner: "nmap", args: []string{"--script=vuln"}, wantErr: "nmap flag --script is not in the allowlist"},
+		{name: "output file flag", scanner: "sqlmap", args: []string{"--forms", "--output-dir=/tmp"}, wantErr: "sqlmap flag --output-dir is not in the allowlist"},
+		{name: "flag of another scanner", scanner: "nikto", args: []string{"--open"}, wantErr: "nikto flag --open is not in the allowlist"},
+		{name: "unknown scanner", scanner: "masscan", args: []string{"-Pn"}, wantErr: "masscan flag -Pn is not in the allowlist"},
+		{name: "positional target", scanner: "nmap", args: []string{"-Pn", "10.0.0.1"}, wantErr: `nmap does not accept positional argument "10.0.0.1"`},
+		{name: "missing value", scanner: "nmap", args: []string{"--top-ports"}, wantErr: "nmap flag --top-ports needs a value"},
+		{name: "value on a switch", scanner: "nmap", args: []string{"-Pn=yes"}, wantErr: "nmap flag -Pn does not take a value"},
+		{name: "denied default flag", denied: []string{"nmap:-sV"}, scanner: "nmap", args: []string{"-sV"}, wantErr: "nmap flag -sV is denied on this server"},
+		{name: "denied flag with value", denied: []string{"sqlmap:--risk="}, scanner: "sqlmap", args: []string{"--risk=3"}, wantErr: "sqlmap flag --risk is denied on this server"},
+		{name: "deny wins over allow", allowed: []string{"nmap:-A"}, denied: []string{"nmap:-A"}, scanner: "nmap", args: []string{"-A"}, wantErr: "nmap flag -A is denied on this server"},
+		{name: "allowed override", allowed: []string{"nmap:-A", "nmap:--max-retries="}, scanner: "nmap", args: []string{"-A", "--max-retries", "2"}},
+		{name: "override replaces the defaults", allowed: []string{"nmap:-A"}, scanner: "nmap", args: []string{"-Pn"}, wantErr: "nmap flag -Pn is not in the allowlist"},
+		{name: "override leaves other scanners alone", allowed: []string{"nmap:-A"}, scanner: "nikto", args: []string{"-ssl"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewScannerArgPolicy(tt.allowed, tt.denied)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = policy.Check(tt.scanner, tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Check(%q, %q) = %v, want nil", tt.scanner, tt.args, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrForbiddenScannerArg) {
+				t.Fatalf("Check(%q, %q) = %v, want ErrForbiddenScannerArg", tt.scanner, tt.args, err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Check(%q, %q) = %q, want it to mention %q", tt.scanner, tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewScannerArgPolicyRejectsMalformedEntries(t *testing.T) {
+	for _, entry := range []string{"nmap", ":-Pn", "nmap:Pn", "nmap:"} {
+		if _, err := NewScannerArgPolicy([]string{entry}, nil); err == nil {
+			t.Errorf("allowed entry %q accepted", entry)
+		}
+		if _, err := NewScannerArgPolicy(nil, []string{entry}); err == nil {
+			t.Errorf("denied entry %q accepted", entry)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import "os/exec"
+
+// scannerBinaries lists every external tool the server can use. The network scanners fall
+// back to mock output when missing; the source scanners and git are needed for fix verification.
+var scannerBinaries = []string{"nmap", "nikto", "gobuster", "sqlmap", "wpscan", zapBinary, "gitleaks", "semgrep", "trivy", "git"}
+
+// binaryScanner is implemented by scanner plugins that shell out to a tool and mock it when absent
+type binaryScanner interface {
+	Binary() string
+}
+
+// Scanner node modes reported by SelfTest
+const (
+	ScanModeLive      = "live"      // The binary is installed and runs for real
+	ScanModeMock      = "mock"      // The binary is missing or mocks are forced, so the node returns canned output
+	ScanModeSimulated = "simulated" // The node always returns sample data
+)
+
+// BinaryStatus reports whether one scanner binary is on the PATH
+type BinaryStatus struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	Path      string `json:"path,omitempty"`
+}
+
+// NodeCapability reports how a scanner node type will run on this server
+type NodeCapability struct {
+	Type   string `json:"type"`
+	Binary string `json:"binary,omitempty"`
+	Mode   string `json:"mode"`
+}
+
+// ScannerCapabilities is the result of a scanner self-test
+type ScannerCapabilities struct {
+	Binaries  []BinaryStatus   `json:"binaries"`
+	NodeTypes []NodeCapability `json:"node_types"`
+}
+
+// SelfTest looks up every scanner binary and reports which node types will return mock data
+func (s *ScannerService) SelfTest() *ScannerCapabilities {
+	capabilities := &ScannerCapabilities{}
+	available := make(map[string]bool, len(scannerBinaries))
+	for _, name := range scannerBinaries {
+		status := BinaryStatus{Name: name}
+		if path, err := exec.LookPath(name); err == nil {
+			status.Available = true
+			status.Path = path
+		}
+		available[name] = status.Available
+		capabilities.Binaries = append(capabilities.Binaries, status)
+	}
+
+	for _, name := range RegisteredScanners() {
+		scanner, _ := LookupScanner(name)
+		capability := NodeCapability{Type: name, Mode: ScanModeSimulated}
+		if b, ok := scanner.(binaryScanner); ok {
+			capability.Binary = b.Binary()
+			capability.Mode = ScanModeMock
+			if available[capability.Binary] && !s.mocks.Force {
+				capability.Mode = ScanModeLive
+			}
+		}
+		capabilities.NodeTypes = append(capabilities.NodeTypes, capability)
+	}
+	return capabilities
+}
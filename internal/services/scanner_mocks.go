@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// ScanMockPolicy controls the canned output network scanners return in place of running a tool
+type ScanMockPolicy struct {
+	Force bool  // Mock every scanner even when its binary is installed, without the simulated delays
+	Seed  int64 // Mock output is picked from the seed, tool and target, so equal inputs give equal output
+}
+
+// Candidate mock results; each mock run reports a seeded selection of them
+var (
+	mockOpenPorts = []string{
+		"21/tcp   open  ftp",
+		"22/tcp   open  ssh",
+		"25/tcp   open  smtp",
+		"80/tcp   open  http",
+		"443/tcp  open  https",
+		"3306/tcp open  mysql",
+		"8080/tcp open  http-proxy",
+	}
+	mockNiktoItems = []string{
+		"No CGI Directories found (use '-C all' to force check all possible dirs)",
+		"Allowed HTTP Methods: GET, HEAD, POST, OPTIONS",
+		"OSVDB-3092: /admin/: This might be interesting...",
+		"The anti-clickjacking X-Frame-Options header is not present.",
+		"The X-Content-Type-Options header is not set.",
+		"OSVDB-3233: /icons/README: Apache default file found.",
+	}
+	mockPaths = []string{
+		"/images (Status: 200)",
+		"/css (Status: 200)",
+		"/js (Status: 200)",
+		"/admin (Status: 301)",
+		"/backup (Status: 403)",
+		"/login (Status: 200)",
+		"/.git (Status: 403)",
+	}
+	mockZAPAlerts = []zapAlert{
+		{PluginID: "10038", Name: "Content Security Policy (CSP) Header Not Set", RiskCode: "2", Description: "<p>Content Security Policy (CSP) is an added layer of security that helps to detect and mitigate certain types of attacks.</p>", Solution: "<p>Ensure that your web server is configured to set the Content-Security-Policy header.</p>"},
+		{PluginID: "10020", Name: "Missing Anti-clickjacking Header", RiskCode: "2", Description: "<p>The response does not protect against 'ClickJacking' attacks.</p>", Solution: "<p>Set the X-Frame-Options header or a frame-ancestors Content-Security-Policy directive.</p>"},
+		{PluginID: "10010", Name: "Cookie No HttpOnly Flag", RiskCode: "1", Description: "<p>A cookie has been set without the HttpOnly flag.</p>", Solution: "<p>Ensure that the HttpOnly flag is set for all cookies.</p>"},
+		{PluginID: "10021", Name: "X-Content-Type-Options Header Missing", RiskCode: "1", Description: "<p>The Anti-MIME-Sniffing header X-Content-Type-Options was not set to 'nosniff'.</p>", Solution: "<p>Set the X-Content-Type-Options header to 'nosniff' for all web pages.</p>"},
+		{PluginID: "10036", Name: "Server Leaks Version Information via \"Server\" HTTP Response Header Field", RiskCode: "1", Description: "<p>The web server is leaking version information via the \"Server\" HTTP response header.</p>", Solution: "<p>Suppress the \"Server\" header or provide generic details.</p>"},
+		{PluginID: "10109", Name: "Modern Web Application", RiskCode: "0", Description: "<p>The application appears to be a modern web application.</p>"},
+	}
+	mockSqlmapParameters = []string{"id (GET)", "q (GET)", "username (POST)"}
+	mockWordPressIssues  = []string{
+		"WordPress 5.8 - Authenticated XSS in Post Slugs",
+		"WordPress < 5.8.2 - Expired DST Root CA X3 Certificate",
+		"Contact Form 7 < 5.3.2 - Unrestricted File Upload",
+	}
+)
+
+// mocked reports whether a scan with tool returns mock output: when mocks are forced, or the tool isn't installed
+func (s *ScannerService) mocked(tool string) bool {
+	return s.mocks.Force || !s.runner.Available(tool)
+}
+
+// requireSourceScanner returns ErrScannerNotInstalled when a source scan can't run tool for
+// real. Source scans have no mock of their own, so forced mocks fail them the same way.
+func (s *ScannerService) requireSourceScanner(tool string) error {
+	if s.mocks.Force {
+		return fmt.Errorf("%s: %w (mock scanners are forced)", tool, ErrScannerNotInstalled)
+	}
+	if !s.runner.Available(tool) {
+		return fmt.Errorf("%s: %w", tool, ErrScannerNotInstalled)
+	}
+	return nil
+}
+
+// mockDelay stands in for the time a real scan takes, except when mocks are forced for fast runs
+func (s *ScannerService) mockDelay(ctx context.Context, d time.Duration) error {
+	if s.mocks.Force {
+		return ctx.Err()
+	}
+	return sleepContext(ctx, d)
+}
+
+// mockRand returns the generator a mock scan of target with tool draws its output from
+func (s *ScannerService) mockRand(tool, target string) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(tool + "\x00" + target))
+	return rand.New(rand.NewSource(s.mocks.Seed ^ int64(h.Sum64())))
+}
+
+// pickMock keeps each candidate with even odds, in their original order, and at least min of them
+func pickMock(r *rand.Rand, candidates []string, min int) []string {
+	var picked []string
+	for _, candidate := range candidates {
+		if r.Intn(2) == 0 {
+			picked = append(picked, candidate)
+		}
+	}
+	if len(picked) < min {
+		picked = candidates[:min]
+	}
+	return picked
+}
+
+func (s *ScannerService) mockNmap(target, ports string) string {
+	r := s.mockRand("nmap", target)
+	return fmt.Sprintf("[MOCK] Nmap scan for %s ports %s\nHost is up (0.%03ds latency).\nPORT     STATE SERVICE\n%s",
+		target, ports, 1+r.Intn(50), strings.Join(pickMock(r, mockOpenPorts, 1), "\n"))
+}
+
+func (s *ScannerService) mockNikto(target string) ([]byte, error) {
+	r := s.mockRand("nikto", target)
+	return json.Marshal(map[string]interface{}{
+		"host":            target,
+		"ip":              fmt.Sprintf("10.0.%d.%d", r.Intn(256), 1+r.Intn(254)),
+		"vulnerabilities": pickMock(r, mockNiktoItems, 1),
+	})
+}
+
+func (s *ScannerService) mockGobuster(target string) string {
+	r := s.mockRand("gobuster", target)
+	return fmt.Sprintf("[MOCK] Gobuster results for %s:\n%s", target, strings.Join(pickMock(r, mockPaths, 1), "\n"))
+}
+
+func (s *ScannerService) mockSqlmap(target string) string {
+	r := s.mockRand("sqlmap", target)
+	// Most targets come back clean, so demos still show what a clean run looks like
+	if r.Intn(3) != 0 {
+		return fmt.Sprintf("[MOCK] Sqlmap results for %s:\nTarget is not vulnerable to SQL injection", target)
+	}
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "[MOCK] Sqlmap results for %s:\nsqlmap identified the following injection point(s):\n---", target)
+	for _, parameter := range pickMock(r, mockSqlmapParameters, 1) {
+		fmt.Fprintf(&summary, "\nParameter: %s\n    Type: boolean-based blind", parameter)
+	}
+	summary.WriteString("\n---")
+	return summary.String()
+}
+
+func (s *ScannerService) mockWpscan(target string) string {
+	r := s.mockRand("wpscan", target)
+	var output strings.Builder
+	fmt.Fprintf(&output, "[MOCK] WPScan results for %s:\n[+] WordPress version 5.8 identified (Insecure, released on 2021-07-20)", target)
+	for _, issue := range pickMock(r, mockWordPressIssues, 0) {
+		fmt.Fprintf(&output, "\n [!] Title: %s", issue)
+	}
+	return output.String()
+}
+
+func (s *ScannerService) mockZAP(target string) ([]byte, error) {
+	r := s.mockRand("zap", target)
+	var alerts []zapAlert
+	for _, alert := range mockZAPAlerts {
+		if r.Intn(2) == 0 {
+			alert.Instances = []zapInstance{{URI: strings.TrimSuffix(target, "/") + "/", Method: "GET"}}
+			alerts = append(alerts, alert)
+		}
+	}
+	return json.Marshal(zapReport{Sites: []zapSite{{Name: target, Alerts: alerts}}})
+}
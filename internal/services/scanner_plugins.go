@@ -0,0 +1,684 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Built-in scanner node types. Network scanners wrap ScannerService; the code
+// scanners run their tool over a checkout of the workflow's repository, or over
+// the image for container-scan, and are simulated when it isn't available.
+func init() {
+	RegisterScanner(nmapScanner{})
+	RegisterScanner(niktoScanner{})
+	RegisterScanner(gobusterScanner{})
+	RegisterScanner(sqlmapScanner{})
+	RegisterScanner(wpscanScanner{})
+	RegisterScanner(zapScanner{})
+	RegisterScanner(aliasScanner{name: "owasp-vulnerabilities", displayName: "OWASP Vulnerabilities", ScannerPlugin: niktoScanner{}}) // Map OWASP to Nikto for now
+	RegisterScanner(secretScanner{})
+	RegisterScanner(dependencyScanner{})
+	RegisterScanner(semgrepScanner{})
+	RegisterScanner(containerScanner{})
+}
+
+// nmapScanner port-scans the workflow target with nmap
+type nmapScanner struct{}
+
+func (nmapScanner) Name() string { return "nmap" }
+
+func (nmapScanner) Binary() string { return "nmap" }
+
+func (nmapScanner) Describe() NodeType {
+	return NodeType{
+		DisplayName: "Nmap",
+		Category:    NodeCategoryNetwork,
+		Description: "Port and service scan of the target host",
+		Schema: objectSchema(map[string]interface{}{
+			"ports":     map[string]interface{}{"type": "string", "description": "Ports to scan (default 1-1000)", "pattern": portSpecPattern.String()},
+			"profile":   stringField("Saved scan profile (ID or name) whose parameters are used as defaults"),
+			"extraArgs": extraArgsField(),
+			"target":    targetField(),
+		}),
+		Inputs: []string{NodeInputTarget},
+	}
+}
+
+func (nmapScanner) Run(ctx context.Context, env *ScanEnv, node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
+	// Get target from trigger node
+	target := env.Target(node, previousResults)
+	if target == "" {
+		return nil, fmt.Errorf("no target found for nmap")
+	}
+
+	// Get config from node data if available
+	ports := "1-1000" // Default
+	if p, ok := node.Data["ports"].(string); ok && p != "" {
+		ports = p
+	}
+
+	args, err := extraArgs(node)
+	if err != nil {
+		return nil, err
+	}
+
+	logf(ctx, "🔍 Running Nmap scan on: %s ports: %s", target, ports)
+
+	output, err := env.Scanner.RunNmap(ctx, target, ports, args)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"scanner": "nmap",
+		"target":  target,
+		"output":  output,
+		"status":  "completed",
+	}
+	if changes, err := env.Scanner.ComparePorts(env.UserID, target, output); err != nil {
+		logf(ctx, "⚠️ Failed to compare with the previous nmap run: %v", err)
+	} else {
+		if len(changes.NewlyOpened) > 0 {
+			logf(ctx, "🚪 Newly opened ports on %s: %s", target, strings.Join(changes.NewlyOpened, ", "))
+		}
+		result["port_changes"] = changes
+	}
+	return result, nil
+}
+
+// niktoScanner checks the target web server with nikto
+type niktoScanner struct{}
+
+func (niktoScanner) Name() string { return "nikto" }
+
+func (niktoScanner) Binary() string { return "nikto" }
+
+func (niktoScanner) Describe() NodeType {
+	return NodeType{
+		DisplayName: "Nikto",
+		Category:    NodeCategoryNetwork,
+		Description: "Web server misconfiguration and vulnerability scan",
+		Schema:      objectSchema(webScanFields(nil)),
+		Inputs:      []string{NodeInputTarget},
+	}
+}
+
+func (niktoScanner) Run(ctx context.Context, env *ScanEnv, node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
+	target := env.Target(node, previousResults)
+	if target == "" {
+		return nil, fmt.Errorf("no target found for nikto")
+	}
+
+	auth, err := env.Auth(node)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := extraArgs(node)
+	if err != nil {
+		return nil, err
+	}
+
+	logf(ctx, "🔍 Running Nikto scan on: %s", target)
+
+	output, err := env.Scanner.RunNikto(ctx, target, auth, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Try to parse JSON if possible, otherwise return raw output
+	var jsonOutput interface{}
+	if json.Unmarshal(output, &jsonOutput) == nil {
+		return map[string]interface{}{
+			"scanner": "nikto",
+			"target":  target,
+			"data":    jsonOutput,
+			"output":  string(output), // Include raw output for reporting
+			"status":  "completed",
+		}, nil
+	}
+
+	return map[string]interface{}{
+		"scanner": "nikto",
+		"target":  target,
+		"output":  string(output),
+		"status":  "completed",
+	}, nil
+}
+
+// gobusterScanner brute-forces paths on the target with gobuster
+type gobusterScanner struct{}
+
+func (gobusterScanner) Name() string { return "gobuster" }
+
+func (gobusterScanner) Binary() string { return "gobuster" }
+
+func (gobusterScanner) Describe() NodeType {
+	return NodeType{
+		DisplayName: "Gobuster",
+		Category:    NodeCategoryNetwork,
+		Description: "Directory and file brute-forcing",
+		Schema: objectSchema(webScanFields(map[string]interface{}{
+			"wordlist": stringField("Wordlist name from /api/wordlists (default " + DefaultWordlist + ")"),
+		})),
+		Inputs: []string{NodeInputTarget},
+	}
+}
+
+func (gobusterScanner) Run(ctx context.Context, env *ScanEnv, node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
+	target := env.Target(node, previousResults)
+	if target == "" {
+		return nil, fmt.Errorf("no target found for gobuster")
+	}
+
+	wordlist := DefaultWordlist
+	if w, ok := node.Data["wordlist"].(string); ok && w != "" {
+		wordlist = w
+	}
+	wordlistPath, err := env.Scanner.Wordlists().Resolve(env.UserID, wordlist)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := env.Auth(node)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := extraArgs(node)
+	if err != nil {
+		return nil, err
+	}
+
+	logf(ctx, "🔍 Running Gobuster scan on: %s wordlist: %s", target, wordlist)
+
+	output, err := env.Scanner.RunGobuster(ctx, target, wordlistPath, auth, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"scanner": "gobuster",
+		"target":  target,
+		"output":  output,
+		"status":  "completed",
+	}, nil
+}
+
+// sqlmapScanner tests the target for SQL injection with sqlmap
+type sqlmapScanner struct{}
+
+func (sqlmapScanner) Name() string { return "sqlmap" }
+
+func (sqlmapScanner) Binary() string { return "sqlmap" }
+
+func (sqlmapScanner) Describe() NodeType {
+	return NodeType{
+		DisplayName: "SQLMap",
+		Category:    NodeCategoryNetwork,
+		Description: "SQL injection testing",
+		Schema:      objectSchema(webScanFields(nil)),
+		Inputs:      []string{NodeInputTarget},
+	}
+}
+
+func (sqlmapScanner) Run(ctx context.Context, env *ScanEnv, node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
+	target := env.Target(node, previousResults)
+	if target == "" {
+		return nil, fmt.Errorf("no target found for sqlmap")
+	}
+
+	auth, err := env.Auth(node)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := extraArgs(node)
+	if err != nil {
+		return nil, err
+	}
+
+	logf(ctx, "🔍 Running Sqlmap scan on: %s", target)
+
+	output, err := env.Scanner.RunSqlmap(ctx, target, auth, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"scanner": "sqlmap",
+		"target":  target,
+		"output":  output,
+		"status":  "completed",
+	}, nil
+}
+
+// wpscanScanner checks a WordPress target with wpscan
+type wpscanScanner struct{}
+
+func (wpscanScanner) Name() string { return "wpscan" }
+
+func (wpscanScanner) Binary() string { return "wpscan" }
+
+func (wpscanScanner) Describe() NodeType {
+	return NodeType{
+		DisplayName: "WPScan",
+		Category:    NodeCategoryNetwork,
+		Description: "WordPress core, plugin and theme vulnerability scan",
+		Schema:      objectSchema(webScanFields(nil)),
+		Inputs:      []string{NodeInputTarget},
+	}
+}
+
+func (wpscanScanner) Run(ctx context.Context, env *ScanEnv, node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
+	target := env.Target(node, previousResults)
+	if target == "" {
+		return nil, fmt.Errorf("no target found for wpscan")
+	}
+
+	auth, err := env.Auth(node)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := extraArgs(node)
+	if err != nil {
+		return nil, err
+	}
+
+	logf(ctx, "🔍 Running WPScan on: %s", target)
+
+	output, err := env.Scanner.RunWpscan(ctx, target, auth, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"scanner": "wpscan",
+		"target":  target,
+		"output":  output,
+		"status":  "completed",
+	}, nil
+}
+
+// zapScanner runs OWASP ZAP's baseline scan against the target
+type zapScanner struct{}
+
+func (zapScanner) Name() string { return "zap-scan" }
+
+func (zapScanner) Binary() string { return zapBinary }
+
+func (zapScanner) Describe() NodeType {
+	return NodeType{
+		DisplayName: "OWASP ZAP",
+		Category:    NodeCategoryNetwork,
+		Description: "Dynamic application scan: spiders the site and reports ZAP's passive alerts; authenticates with one header or the cookies",
+		Schema:      objectSchema(webScanFields(nil)),
+		Inputs:      []string{NodeInputTarget},
+	}
+}
+
+func (zapScanner) Run(ctx context.Context, env *ScanEnv, node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
+	target := env.Target(node, previousResults)
+	if target == "" {
+		return nil, fmt.Errorf("no target found for zap")
+	}
+
+	auth, err := env.Auth(node)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := extraArgs(node)
+	if err != nil {
+		return nil, err
+	}
+
+	logf(ctx, "🔍 Running ZAP baseline scan on: %s", target)
+
+	output, err := env.Scanner.RunZAP(ctx, target, auth, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"scanner": "zap",
+		"target":  target,
+		"output":  string(output),
+		"status":  "completed",
+	}, nil
+}
+
+// secretScanner runs Gitleaks over a checkout of the workflow's GitHub repository,
+// simulating it when gitleaks isn't installed or there is no repository
+type secretScanner struct{}
+
+func (secretScanner) Name() string { return "secret-scan" }
+
+func (secretScanner) Describe() NodeType {
+	return NodeType{
+		DisplayName: "Secret Scan",
+		Category:    NodeCategoryCode,
+		Description: "Finds leaked credentials in the repository (Gitleaks)",
+		Schema: objectSchema(map[string]interface{}{
+			"owner":  stringField("Repository owner; defaults to the trigger's GitHub URL"),
+			"repo":   stringField("Repository name; defaults to the trigger's GitHub URL"),
+			"branch": stringField("Branch to scan; defaults to the repository's default branch"),
+		}),
+	}
+}
+
+func (secretScanner) Run(ctx context.Context, env *ScanEnv, node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
+	if owner, repo := env.Repository(node, previousResults); owner != "" && repo != "" && !env.Scanner.mocked("gitleaks") {
+		logf(ctx, "🔑 Executing Secret Scan (Gitleaks) of %s/%s...", owner, repo)
+		branch, _ := node.Data["branch"].(string)
+		dir, cleanup, err := env.Checkout(ctx, owner, repo, branch)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		output, err := env.Scanner.RunGitleaks(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+		return gitleaksResult(output, fmt.Sprintf("%s/%s", owner, repo)), nil
+	}
+
+	logf(ctx, "🔑 Executing Secret Scan (Gitleaks)...")
+	if err := env.Scanner.mockDelay(ctx, 2*time.Second); err != nil { // Simulate work
+		return nil, err
+	}
+
+	// Mock findings: Using README.md as it likely exists in any repo
+	output := `
+{
+  "findings": [
+    {
+      "rule": "generic-secret",
+      "file": "README.md",
+      "startLine": 1,
+      "secret": "password123",
+      "message": "Simulated secret found for Auto-Fix testing"
+    }
+  ]
+}`
+	return gitleaksResult(output, ""), nil
+}
+
+// gitleaksResult builds the secret-scan node result, with the report's findings parsed into
+// data.findings for nodes that work from file paths
+func gitleaksResult(output, repository string) map[string]interface{} {
+	var report struct {
+		Findings []interface{} `json:"findings"`
+	}
+	json.Unmarshal([]byte(output), &report)
+	if report.Findings == nil {
+		report.Findings = []interface{}{}
+	}
+
+	result := map[string]interface{}{
+		"scanner": "gitleaks",
+		"status":  "completed",
+		"output":  output,
+		"data": map[string]interface{}{
+			"findings":       report.Findings,
+			"leaked_secrets": len(report.Findings),
+		},
+	}
+	if repository != "" {
+		result["repository"] = repository
+	}
+	return result
+}
+
+// dependencyScanner runs Trivy over a checkout of the workflow's GitHub repository,
+// simulating it when trivy isn't installed or there is no repository
+type dependencyScanner struct{}
+
+func (dependencyScanner) Name() string { return "dependency-check" }
+
+func (dependencyScanner) Describe() NodeType {
+	return NodeType{
+		DisplayName: "Dependency Check",
+		Category:    NodeCategoryCode,
+		Description: "Finds vulnerable dependencies (Trivy)",
+		Schema: objectSchema(map[string]interface{}{
+			"owner":  stringField("Repository owner; defaults to the trigger's GitHub URL"),
+			"repo":   stringField("Repository name; defaults to the trigger's GitHub URL"),
+			"branch": stringField("Branch to scan; defaults to the repository's default branch"),
+		}),
+	}
+}
+
+func (dependencyScanner) Run(ctx context.Context, env *ScanEnv, node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
+	if owner, repo := env.Repository(node, previousResults); owner != "" && repo != "" && !env.Scanner.mocked("trivy") {
+		logf(ctx, "📦 Executing Dependency Check (Trivy) of %s/%s...", owner, repo)
+		branch, _ := node.Data["branch"].(string)
+		dir, cleanup, err := env.Checkout(ctx, owner, repo, branch)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		output, err := env.Scanner.RunTrivyFS(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+		return trivySCAResult(output, fmt.Sprintf("%s/%s", owner, repo))
+	}
+
+	logf(ctx, "📦 Executing Dependency Check (Trivy)...")
+	if err := env.Scanner.mockDelay(ctx, 2*time.Second); err != nil {
+		return nil, err
+	}
+
+	output := `
+{
+  "Target": "go.mod",
+  "Vulnerabilities": [
+    {
+      "VulnerabilityID": "CVE-2023-1234",
+      "PkgName": "golang.org/x/net",
+      "InstalledVersion": "v0.7.0",
+      "FixedVersion": "v0.17.0",
+      "Severity": "HIGH"
+    }
+  ]
+}`
+	return trivySCAResult(output, "")
+}
+
+// trivySCAResult builds the dependency-check node result, with the vulnerabilities and their
+// counts by severity in data
+func trivySCAResult(output, repository string) (map[string]interface{}, error) {
+	vulnerabilities, err := parseTrivyVulnerabilities([]byte(output))
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{
+		"vulnerabilities":       vulnerabilities,
+		"vulnerabilities_found": len(vulnerabilities),
+	}
+	severities := make([]Severity, 0, len(vulnerabilities))
+	for _, v := range vulnerabilities {
+		severities = append(severities, NormalizeSeverity(v.Severity))
+	}
+	addSeverityCounts(data, severities)
+
+	result := map[string]interface{}{
+		"scanner": "trivy-sca",
+		"status":  "completed",
+		"output":  output,
+		"data":    data,
+	}
+	if repository != "" {
+		result["repository"] = repository
+	}
+	return result, nil
+}
+
+// semgrepScanner runs Semgrep over a checkout of the workflow's GitHub repository,
+// simulating it when semgrep isn't installed or there is no repository
+type semgrepScanner struct{}
+
+func (semgrepScanner) Name() string { return "semgrep-scan" }
+
+func (semgrepScanner) Describe() NodeType {
+	return NodeType{
+		DisplayName: "Semgrep",
+		Category:    NodeCategoryCode,
+		Description: "Static analysis for insecure code patterns",
+		Schema: objectSchema(map[string]interface{}{
+			"owner":  stringField("Repository owner; defaults to the trigger's GitHub URL"),
+			"repo":   stringField("Repository name; defaults to the trigger's GitHub URL"),
+			"branch": stringField("Branch to scan; defaults to the repository's default branch"),
+			"config": stringField("Ruleset: auto (the default) or a registry ruleset such as p/owasp-top-ten"),
+		}),
+	}
+}
+
+func (semgrepScanner) Run(ctx context.Context, env *ScanEnv, node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
+	if owner, repo := env.Repository(node, previousResults); owner != "" && repo != "" && !env.Scanner.mocked("semgrep") {
+		config, _ := node.Data["config"].(string)
+		if config != "" && config != "auto" && !semgrepRegistryConfig.MatchString(config) {
+			return nil, ErrInvalidSemgrepConfig
+		}
+		logf(ctx, "🔬 Executing Semgrep SAST of %s/%s...", owner, repo)
+		branch, _ := node.Data["branch"].(string)
+		dir, cleanup, err := env.Checkout(ctx, owner, repo, branch)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		output, err := env.Scanner.RunSemgrepConfig(ctx, dir, config)
+		if err != nil {
+			return nil, err
+		}
+		return semgrepResult(output, fmt.Sprintf("%s/%s", owner, repo))
+	}
+
+	logf(ctx, "🔬 Executing Semgrep SAST...")
+	if err := env.Scanner.mockDelay(ctx, 2*time.Second); err != nil {
+		return nil, err
+	}
+
+	// Mock findings: Using main.go as it likely exists
+	output := `
+{
+  "results": [
+    {
+      "check_id": "go.lang.security.audit.xss.reflect.xss",
+      "path": "main.go",
+      "start": { "line": 1, "col": 1 },
+      "extra": { "message": "Potential XSS vulnerability detected (Simulated)", "severity": "ERROR" }
+    }
+  ]
+}`
+	return semgrepResult(output, "")
+}
+
+// semgrepResult builds the semgrep-scan node result, with the findings and their counts by
+// severity in data
+func semgrepResult(output, repository string) (map[string]interface{}, error) {
+	findings, err := parseSemgrepReport([]byte(output))
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{"findings": findings}
+	severities := make([]Severity, 0, len(findings))
+	for _, f := range findings {
+		severities = append(severities, ScannerSeverity("semgrep", f.Severity))
+	}
+	addSeverityCounts(data, severities)
+
+	result := map[string]interface{}{
+		"scanner": "semgrep",
+		"status":  "completed",
+		"output":  output,
+		"data":    data,
+	}
+	if repository != "" {
+		result["repository"] = repository
+	}
+	return result, nil
+}
+
+// addSeverityCounts records how many findings there are of each severity as data.severity_<level>
+func addSeverityCounts(data map[string]interface{}, severities []Severity) {
+	counts := make(map[Severity]int)
+	for _, severity := range severities {
+		counts[severity]++
+	}
+	for _, severity := range []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow} {
+		data["severity_"+string(severity)] = counts[severity]
+	}
+}
+
+// containerScanner scans a container image with trivy, simulating the scan when trivy isn't available
+type containerScanner struct{}
+
+func (containerScanner) Name() string { return "container-scan" }
+
+func (containerScanner) Describe() NodeType {
+	return NodeType{
+		DisplayName: "Container Scan",
+		Category:    NodeCategoryCode,
+		Description: "Finds vulnerable packages in a container image (Trivy)",
+		Schema: objectSchema(map[string]interface{}{
+			"image":            stringField("Image to scan, e.g. registry.example.com:5000/team/app:1.2"),
+			"registryUsername": stringField("Username for a private registry"),
+			"registryPassword": stringField("Name of the secret holding the registry password"),
+			"registryToken":    stringField("Name of the secret holding a registry token, instead of a username and password"),
+		}),
+	}
+}
+
+func (containerScanner) Run(ctx context.Context, env *ScanEnv, node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
+	image, _ := node.Data["image"].(string)
+	image = strings.TrimSpace(image)
+	auth, err := env.RegistryAuth(node)
+	if err != nil {
+		return nil, err
+	}
+
+	if image != "" {
+		logf(ctx, "🐳 Executing Container Scan of %s (registry %s)...", image, imageRegistry(image))
+		output, err := env.Scanner.RunTrivyImage(ctx, image, auth)
+		if err == nil {
+			return map[string]interface{}{
+				"scanner": "trivy-image",
+				"status":  "completed",
+				"image":   image,
+				"output":  output,
+			}, nil
+		}
+		if !errors.Is(err, ErrScannerNotInstalled) {
+			return nil, err
+		}
+	} else {
+		logf(ctx, "🐳 Executing Container Scan...")
+	}
+
+	if err := env.Scanner.mockDelay(ctx, 2*time.Second); err != nil {
+		return nil, err
+	}
+	output := `
+{
+  "Image": "app:latest",
+  "OS": "alpine:3.14",
+  "Vulnerabilities": [
+    {
+      "ID": "CVE-2022-4567",
+      "Package": "openssl",
+      "Severity": "CRITICAL"
+    }
+  ]
+}`
+	return map[string]interface{}{
+		"scanner": "trivy-image",
+		"status":  "completed",
+		"output":  output,
+	}, nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ScannerPlugin runs one scanner node type. Plugins register themselves with
+// RegisterScanner from init, and executeNode dispatches any node type it doesn't
+// handle itself to the plugin registered under that name.
+type ScannerPlugin interface {
+	// Name is the workflow node type the plugin handles
+	Name() string
+	// Describe documents the node type for the editor; the Type field is filled in from Name
+	Describe() NodeType
+	// Run scans the workflow target, returning a node result with "scanner", "target", "output" and "status"
+	Run(ctx context.Context, env *ScanEnv, node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error)
+}
+
+// ScanEnv gives plugins the services and user context a node runs with
+type ScanEnv struct {
+	Scanner *ScannerService
+	UserID  uuid.UUID
+
+	executor *WorkflowExecutor
+}
+
+// Target returns the node's target: its own, or the one set by the nearest upstream trigger
+func (env *ScanEnv) Target(node *WorkflowNode, previousResults map[string]interface{}) string {
+	return env.executor.getTarget(node, previousResults)
+}
+
+// Auth resolves the node's authHeaders/cookies from the user's secrets, or nil if it has none
+func (env *ScanEnv) Auth(node *WorkflowNode) (*ScanAuth, error) {
+	return env.executor.resolveScanAuth(node, env.UserID)
+}
+
+// Repository returns the GitHub owner and repo the node scans, from its data or a github.com
+// target, or empty strings when it has none
+func (env *ScanEnv) Repository(node *WorkflowNode, previousResults map[string]interface{}) (string, string) {
+	return env.executor.nodeRepository(node, previousResults)
+}
+
+// Checkout clones a repository for a source scan, returning its directory and the func that removes it
+func (env *ScanEnv) Checkout(ctx context.Context, owner, repo, branch string) (string, func(), error) {
+	return env.executor.checkoutRepository(ctx, env.UserID, owner, repo, branch)
+}
+
+// RegistryAuth resolves the node's private registry credentials from the user's secrets, or nil if it has none
+func (env *ScanEnv) RegistryAuth(node *WorkflowNode) (*RegistryAuth, error) {
+	return env.executor.resolveRegistryAuth(node, env.UserID)
+}
+
+var (
+	scannerRegistryMu sync.RWMutex
+	scannerRegistry   = make(map[string]ScannerPlugin)
+)
+
+// RegisterScanner makes a scanner available as a workflow node type.
+// It panics on an empty or duplicate name, as both are programming errors.
+func RegisterScanner(plugin ScannerPlugin) {
+	scannerRegistryMu.Lock()
+	defer scannerRegistryMu.Unlock()
+
+	name := plugin.Name()
+	if name == "" {
+		panic("scanner plugin has no name")
+	}
+	if _, exists := scannerRegistry[name]; exists {
+		panic(fmt.Sprintf("scanner plugin %q registered twice", name))
+	}
+	scannerRegistry[name] = plugin
+}
+
+// LookupScanner returns the plugin registered for a node type
+func LookupScanner(name string) (ScannerPlugin, bool) {
+	scannerRegistryMu.RLock()
+	defer scannerRegistryMu.RUnlock()
+	plugin, ok := scannerRegistry[name]
+	return plugin, ok
+}
+
+// RegisteredScanners lists the registered scanner node types in name order
+func RegisteredScanners() []string {
+	scannerRegistryMu.RLock()
+	defer scannerRegistryMu.RUnlock()
+	names := make([]string, 0, len(scannerRegistry))
+	for name := range scannerRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// aliasScanner exposes an existing plugin under another node type
+type aliasScanner struct {
+	ScannerPlugin
+	name        string
+	displayName string
+}
+
+func (a aliasScanner) Name() string { return a.name }
+
+func (a aliasScanner) Describe() NodeType {
+	nodeType := a.ScannerPlugin.Describe()
+	nodeType.DisplayName = a.displayName
+	nodeType.Description += " (alias of " + a.ScannerPlugin.Name() + ")"
+	return nodeType
+}
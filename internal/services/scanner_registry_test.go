@@ -0,0 +1,65 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuiltinScannersRegistered(t *testing.T) {
+	for _, name := range []string{
+		"nmap", "nikto", "gobuster", "sqlmap", "wpscan", "zap-scan", "owasp-vulnerabilities",
+		"secret-scan", "dependency-check", "semgrep-scan", "container-scan",
+	} {
+		plugin, ok := LookupScanner(name)
+		if !ok {
+			t.Errorf("%s is not registered", name)
+			continue
+		}
+		if plugin.Name() != name {
+			t.Errorf("%s is registered under the plugin named %s", name, plugin.Name())
+		}
+	}
+
+	// Node types the executor handles itself must not be shadowed by a plugin
+	for _, nodeType := range builtinNodeTypes {
+		if _, ok := LookupScanner(nodeType.Type); ok {
+			t.Errorf("built-in node type %s is also a scanner plugin", nodeType.Type)
+		}
+	}
+	if _, ok := LookupScanner("unknown"); ok {
+		t.Error("an unregistered scanner was found")
+	}
+}
+
+func TestRegisteredScannersSorted(t *testing.T) {
+	names := RegisteredScanners()
+	for i := 1; i < len(names); i++ {
+		if names[i-1] >= names[i] {
+			t.Fatalf("scanners aren't sorted by name: %v", names)
+		}
+	}
+}
+
+func TestRegisterScannerRejectsBadNames(t *testing.T) {
+	for name, plugin := range map[string]ScannerPlugin{
+		"duplicate": nmapScanner{},
+		"empty":     aliasScanner{ScannerPlugin: nmapScanner{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("registration didn't panic")
+				}
+			}()
+			RegisterScanner(plugin)
+		})
+	}
+}
+
+func TestAliasScannerDescribesTarget(t *testing.T) {
+	plugin, _ := LookupScanner("owasp-vulnerabilities")
+	described := plugin.Describe()
+	if described.DisplayName != "OWASP Vulnerabilities" || !strings.HasSuffix(described.Description, "(alias of nikto)") {
+		t.Fatalf("alias described as %+v", described)
+	}
+}
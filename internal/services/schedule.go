@@ -0,0 +1,272 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+)
+
+// ErrInvalidSchedule is returned for schedule settings that can't produce run times
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// Schedule is a workflow's recurrence, evaluated in the wall-clock time of Location
+type Schedule struct {
+	Frequency string // hourly, daily, weekly, monthly or a five-field cron expression
+	Hour      int
+	Minute    int
+	Day       int // weekday for weekly (0 = Sunday), day of month for monthly (1-28)
+	Location  *time.Location
+
+	cron *cronSpec // Set when Frequency is a cron expression
+}
+
+// ParseSchedule validates schedule settings. at is "HH:MM" local time (only the minute is
+// used for hourly schedules) and timezone is an IANA name such as "Europe/Berlin". A
+// frequency that isn't one of the named ones is parsed as a cron expression, which sets the
+// times itself, so at and day are ignored for it.
+func ParseSchedule(frequency, at string, day int, timezone string) (*Schedule, error) {
+	schedule := &Schedule{Frequency: frequency, Day: day}
+
+	switch frequency {
+	case "hourly", "daily":
+	case "weekly":
+		if day < 0 || day > 6 {
+			return nil, fmt.Errorf("%w: weekly schedules need a weekday from 0 (Sunday) to 6", ErrInvalidSchedule)
+		}
+	case "monthly":
+		// Capped at 28 so every month has the day
+		if day < 1 || day > 28 {
+			return nil, fmt.Errorf("%w: monthly schedules need a day from 1 to 28", ErrInvalidSchedule)
+		}
+	default:
+		cron, err := parseCron(frequency)
+		if err != nil {
+			return nil, err
+		}
+		schedule.cron = cron
+	}
+
+	if at == "" {
+		at = "00:00"
+	}
+	clock, err := time.Parse("15:04", at)
+	if err != nil {
+		return nil, fmt.Errorf("%w: time must be HH:MM, got %q", ErrInvalidSchedule, at)
+	}
+	schedule.Hour, schedule.Minute = clock.Hour(), clock.Minute()
+
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	schedule.Location, err = time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, timezone)
+	}
+	if schedule.cron != nil && schedule.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("%w: cron expression %q never matches a date", ErrInvalidSchedule, frequency)
+	}
+
+	return schedule, nil
+}
+
+// Next returns the first run time strictly after the given instant.
+//
+// Calendar arithmetic is done on local dates with time.Date rather than by adding
+// fixed durations, so a daily 09:00 run stays at 09:00 local across DST changes
+// (the interval between runs is 23 or 25 hours on transition days). A wall-clock
+// time skipped by a spring-forward transition fires just after the gap, and one
+// repeated by a fall-back transition fires once.
+func (s *Schedule) Next(after time.Time) time.Time {
+	if s.cron != nil {
+		return s.cron.next(after, s.Location)
+	}
+	local := after.In(s.Location)
+	y, m, d := local.Date()
+
+	switch s.Frequency {
+	case "hourly":
+		next := time.Date(y, m, d, local.Hour(), s.Minute, 0, 0, s.Location)
+		for !next.After(after) {
+			next = next.Add(time.Hour)
+		}
+		return next
+
+	case "weekly":
+		offset := (s.Day - int(local.Weekday()) + 7) % 7
+		next := s.at(y, m, d+offset)
+		if !next.After(after) {
+			next = s.at(y, m, d+offset+7)
+		}
+		return next
+
+	case "monthly":
+		next := s.at(y, m, s.Day)
+		if !next.After(after) {
+			next = s.at(y, m+1, s.Day)
+		}
+		return next
+
+	default: // daily
+		next := s.at(y, m, d)
+		if !next.After(after) {
+			next = s.at(y, m, d+1)
+		}
+		return next
+	}
+}
+
+// at returns the schedule's clock time on the given local date.
+// When that time falls in a DST gap, time.Date may resolve it to a wall clock before
+// the gap; shift it forward by the difference so it lands after the transition.
+func (s *Schedule) at(year int, month time.Month, day int) time.Time {
+	t := time.Date(year, month, day, s.Hour, s.Minute, 0, 0, s.Location)
+	// Compare wall clocks as if they were UTC to measure how far back the gap pushed us
+	want := time.Date(year, month, day, s.Hour, s.Minute, 0, 0, time.UTC)
+	got := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	if got.Before(want) {
+		t = t.Add(want.Sub(got))
+	}
+	return t
+}
+
+// workflowSchedule builds the schedule for a workflow, falling back to the owner's timezone preference
+func workflowSchedule(workflow *models.Workflow, prefs models.UserPreferences) (*Schedule, error) {
+	timezone := workflow.ScheduleTimezone
+	if timezone == "" {
+		timezone = prefs.Timezone
+	}
+	return ParseSchedule(workflow.ScheduleFrequency, workflow.ScheduleTime, workflow.ScheduleDay, timezone)
+}
+
+// cronSpec is a parsed five-field cron expression: minute, hour, day of month, month and
+// day of week. Each field is a bit set of the values it matches.
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool // The day field was "*", so matching days follow the other field alone
+}
+
+// cronSearchYears bounds the search for the next match of an expression
+const cronSearchYears = 5
+
+// parseCron parses a standard cron expression. Fields take *, values, ranges (a-b), steps
+// (*/n, a-b/n, a/n) and comma-separated lists of them; day of week 7 is also Sunday.
+func parseCron(expr string) (*cronSpec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: unknown frequency %q; use hourly, daily, weekly, monthly or a five-field cron expression", ErrInvalidSchedule, expr)
+	}
+
+	spec := &cronSpec{
+		domAny: strings.HasPrefix(fields[2], "*"),
+		dowAny: strings.HasPrefix(fields[4], "*"),
+	}
+	bounds := []struct {
+		bits     *uint64
+		name     string
+		min, max int
+	}{
+		{&spec.minute, "minute", 0, 59},
+		{&spec.hour, "hour", 0, 23},
+		{&spec.dom, "day of month", 1, 31},
+		{&spec.month, "month", 1, 12},
+		{&spec.dow, "day of week", 0, 7},
+	}
+	for i, field := range bounds {
+		bits, err := parseCronField(fields[i], field.min, field.max)
+		if err != nil {
+			return nil, fmt.Errorf("%w: cron %s field %q: %v", ErrInvalidSchedule, field.name, fields[i], err)
+		}
+		*field.bits = bits
+	}
+	if spec.dow&(1<<7) != 0 {
+		spec.dow |= 1
+	}
+	return spec, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if base, stepText, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepText)
+			}
+			part, step = base, n
+		}
+
+		lo, hi := min, max
+		if part != "*" {
+			first, last, isRange := strings.Cut(part, "-")
+			var err error
+			if lo, err = strconv.Atoi(first); err != nil {
+				return 0, fmt.Errorf("invalid value %q", first)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(last); err != nil {
+					return 0, fmt.Errorf("invalid value %q", last)
+				}
+			} else if step > 1 {
+				hi = max // a/n runs from a to the end of the range
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("values must be from %d to %d", min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// dayMatches follows cron: when both day fields are restricted a day matching either runs
+func (c *cronSpec) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<int(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// next returns the first matching minute strictly after the given instant, in loc's wall
+// clock, or the zero time when nothing matches within cronSearchYears
+func (c *cronSpec) next(after time.Time, loc *time.Location) time.Time {
+	t := after.In(loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronSearchYears, 0, 0)
+	for t.Before(limit) {
+		y, m, d := t.Date()
+		switch {
+		case c.month&(1<<int(m)) == 0:
+			t = cronAdvance(t, y, m+1, 1, 0, loc)
+		case !c.dayMatches(t):
+			t = cronAdvance(t, y, m, d+1, 0, loc)
+		case c.hour&(1<<t.Hour()) == 0:
+			t = cronAdvance(t, y, m, d, t.Hour()+1, loc)
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// cronAdvance moves the search from t to the start of the given local hour. Hours are stepped
+// on the wall clock, as time.Truncate would land on :30 in half-hour zones like Asia/Kolkata.
+// A start in a DST gap can resolve to before the gap, so the result is pushed on by hours
+// until it is after t and the search can't go backwards or stall.
+func cronAdvance(t time.Time, year int, month time.Month, day, hour int, loc *time.Location) time.Time {
+	next := time.Date(year, month, day, hour, 0, 0, 0, loc)
+	for !next.After(t) {
+		next = next.Add(time.Hour)
+	}
+	return next
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+)
+
+func utc(value string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// New York moves to EDT at 2026-03-08 02:00 (07:00 UTC) and back to EST at 2026-11-01 02:00 (06:00 UTC)
+func TestScheduleNextAcrossDST(t *testing.T) {
+	tests := []struct {
+		name      string
+		frequency string
+		at        string
+		day       int
+		timezone  string
+		after     time.Time
+		want      time.Time
+	}{
+		{"daily before spring forward", "daily", "09:00", 0, "America/New_York", utc("2026-03-07 14:00"), utc("2026-03-08 13:00")},
+		{"daily before fall back", "daily", "09:00", 0, "America/New_York", utc("2026-10-31 13:00"), utc("2026-11-01 14:00")},
+		{"daily in the spring gap fires after it", "daily", "02:30", 0, "America/New_York", utc("2026-03-08 05:00"), utc("2026-03-08 07:30")},
+		{"daily in the repeated hour fires once", "daily", "01:30", 0, "America/New_York", utc("2026-11-01 05:30"), utc("2026-11-02 06:30")},
+		{"daily first of the repeated hour", "daily", "01:30", 0, "America/New_York", utc("2026-11-01 04:00"), utc("2026-11-01 05:30")},
+		{"daily later the same day", "daily", "18:00", 0, "Asia/Kolkata", utc("2026-03-08 06:00"), utc("2026-03-08 12:30")},
+		{"daily at the run time moves to the next day", "daily", "09:00", 0, "UTC", utc("2026-03-08 09:00"), utc("2026-03-09 09:00")},
+		{"hourly across the spring gap", "hourly", "00:15", 0, "America/New_York", utc("2026-03-08 06:20"), utc("2026-03-08 07:15")},
+		{"hourly in a half-hour zone", "hourly", "00:45", 0, "Asia/Kolkata", utc("2026-03-08 06:00"), utc("2026-03-08 06:15")},
+		{"weekly across spring forward", "weekly", "09:00", 1, "Europe/Berlin", utc("2026-03-27 12:00"), utc("2026-03-30 07:00")},
+		{"weekly on the day, before the time", "weekly", "09:00", 1, "Europe/Berlin", utc("2026-03-30 06:00"), utc("2026-03-30 07:00")},
+		{"monthly across fall back", "monthly", "09:00", 15, "America/New_York", utc("2026-10-15 14:00"), utc("2026-11-15 14:00")},
+		{"monthly in December rolls over the year", "monthly", "00:00", 1, "UTC", utc("2026-12-01 00:00"), utc("2027-01-01 00:00")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.frequency, tt.at, tt.day, tt.timezone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.Next(tt.after); !got.Equal(tt.want) {
+				t.Fatalf("Next(%s) = %s, want %s", tt.after, got.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleDailyKeepsLocalTimeAcrossDST(t *testing.T) {
+	schedule, err := ParseSchedule("daily", "09:00", 0, "America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := utc("2026-03-01 00:00")
+	for i := 0; i < 300; i++ {
+		next = schedule.Next(next)
+		if local := next.In(schedule.Location); local.Hour() != 9 || local.Minute() != 0 {
+			t.Fatalf("run %d fired at %s local", i, local)
+		}
+	}
+}
+
+// Santiago skips midnight on 2026-09-06, moving from -04 to -03
+func TestCronScheduleNext(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		timezone string
+		after    time.Time
+		want     time.Time
+	}{
+		{"working hours over a weekend", "*/15 9-17 * * 1-5", "UTC", utc("2026-10-16 17:50"), utc("2026-10-19 09:00")},
+		{"on the hour in a half-hour zone", "0 11 * * *", "Asia/Kolkata", utc("2026-03-08 04:37"), utc("2026-03-08 05:30")},
+		{"every other hour in a half-hour zone", "30 */2 * * *", "Asia/Kolkata", utc("2026-03-08 06:00"), utc("2026-03-08 07:00")},
+		{"daily across spring forward", "0 9 * * *", "America/New_York", utc("2026-03-07 14:00"), utc("2026-03-08 13:00")},
+		{"Sunday midnight skipped by the gap", "30 0 * * 0", "America/Santiago", utc("2026-09-05 12:00"), utc("2026-09-13 03:30")},
+		{"Sunday hourly from the end of the gap", "0 * * * 0", "America/Santiago", utc("2026-09-05 12:00"), utc("2026-09-06 04:00")},
+		{"leap day", "0 0 29 2 *", "UTC", utc("2026-03-01 00:00"), utc("2028-02-29 00:00")},
+		{"day of month or weekday", "0 12 1 * 1", "UTC", utc("2026-10-16 00:00"), utc("2026-10-19 12:00")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.expr, "", 0, tt.timezone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.Next(tt.after); !got.Equal(tt.want) {
+				t.Fatalf("Next(%s) = %s, want %s", tt.after, got.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCronScheduleNeverMatching(t *testing.T) {
+	if _, err := ParseSchedule("0 0 31 2 *", "", 0, "UTC"); !errors.Is(err, ErrInvalidSchedule) {
+		t.Fatalf("February 31st accepted: %v", err)
+	}
+}
+
+func TestParseScheduleRejectsInvalidSettings(t *testing.T) {
+	tests := []struct {
+		name      string
+		frequency string
+		at        string
+		day       int
+		timezone  string
+	}{
+		{"unknown timezone", "daily", "09:00", 0, "Mars/Olympus_Mons"},
+		{"offset instead of a zone", "daily", "09:00", 0, "+02:00"},
+		{"time out of range", "daily", "25:00", 0, "UTC"},
+		{"time not HH:MM", "daily", "9am", 0, "UTC"},
+		{"weekday out of range", "weekly", "09:00", 7, "UTC"},
+		{"negative weekday", "weekly", "09:00", -1, "UTC"},
+		{"day of month missing from some months", "monthly", "09:00", 29, "UTC"},
+		{"day of month zero", "monthly", "09:00", 0, "UTC"},
+		{"unknown frequency", "fortnightly", "09:00", 0, "UTC"},
+		{"cron with too few fields", "0 9 * *", "09:00", 0, "UTC"},
+		{"cron value out of range", "0 24 * * *", "09:00", 0, "UTC"},
+		{"cron step of zero", "*/0 * * * *", "09:00", 0, "UTC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseSchedule(tt.frequency, tt.at, tt.day, tt.timezone); !errors.Is(err, ErrInvalidSchedule) {
+				t.Fatalf("got %v, want ErrInvalidSchedule", err)
+			}
+		})
+	}
+}
+
+func TestWorkflowScheduleTimezone(t *testing.T) {
+	prefs := models.UserPreferences{Timezone: "Asia/Tokyo"}
+	tests := []struct {
+		workflowZone string
+		want         string
+	}{
+		{"Europe/London", "Europe/London"},
+		{"", "Asia/Tokyo"},
+	}
+	for _, tt := range tests {
+		workflow := &models.Workflow{ScheduleFrequency: "daily", ScheduleTime: "09:00", ScheduleTimezone: tt.workflowZone}
+		schedule, err := workflowSchedule(workflow, prefs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if schedule.Location.String() != tt.want {
+			t.Fatalf("schedule with timezone %q runs in %s, want %s", tt.workflowZone, schedule.Location, tt.want)
+		}
+	}
+
+	schedule, err := workflowSchedule(&models.Workflow{ScheduleFrequency: "daily"}, models.UserPreferences{})
+	if err != nil || schedule.Location.String() != "UTC" {
+		t.Fatalf("schedule without any timezone: %v, %v", schedule, err)
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxSecretSize caps a single stored secret value
+const maxSecretSize = 16 << 10
+
+// secretNamePattern matches the names workflow nodes use to reference secrets
+var secretNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]{0,63}$`)
+
+var (
+	ErrSecretNotFound    = errors.New("secret not found")
+	ErrInvalidSecretName = errors.New("secret name must be 1-64 letters, digits, '-' or '_'")
+	ErrInvalidSecret     = fmt.Errorf("secret value must be 1-%d bytes", maxSecretSize)
+)
+
+// SecretStore keeps per-user credentials encrypted in the database.
+// Values are write-only over the API and are only decrypted when a scan needs them.
+type SecretStore struct {
+	db   *gorm.DB
+	keys *utils.KeyRing
+}
+
+func NewSecretStore(db *gorm.DB, keys *utils.KeyRing) *SecretStore {
+	return &SecretStore{
+		db:   db,
+		keys: keys,
+	}
+}
+
+// Put creates or replaces a secret
+func (s *SecretStore) Put(userID uuid.UUID, name, value string) (*models.Secret, error) {
+	if !secretNamePattern.MatchString(name) {
+		return nil, ErrInvalidSecretName
+	}
+	if value == "" || len(value) > maxSecretSize {
+		return nil, ErrInvalidSecret
+	}
+
+	ciphertext, version, err := s.keys.Encrypt(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+
+	secret := &models.Secret{UserID: userID, Name: name, Ciphertext: ciphertext, KeyVersion: version}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"ciphertext", "key_version", "updated_at"}),
+	}).Create(secret).Error; err != nil {
+		return nil, fmt.Errorf("failed to save secret: %w", err)
+	}
+
+	// Reload so the ID reflects the existing row when the secret was replaced
+	if err := s.db.Where("user_id = ? AND name = ?", userID, name).First(secret).Error; err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// List returns the user's secrets without their values
+func (s *SecretStore) List(userID uuid.UUID) ([]models.Secret, error) {
+	var secrets []models.Secret
+	if err := s.db.Where("user_id = ?", userID).Order("name ASC").Find(&secrets).Error; err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// Delete removes a secret
+func (s *SecretStore) Delete(userID uuid.UUID, name string) error {
+	result := s.db.Where("user_id = ? AND name = ?", userID, name).Delete(&models.Secret{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSecretNotFound
+	}
+	return nil
+}
+
+// Resolve decrypts a secret by name; the value must never be logged or persisted in results
+func (s *SecretStore) Resolve(userID uuid.UUID, name string) (string, error) {
+	var secret models.Secret
+	if err := s.db.Where("user_id = ? AND name = ?", userID, name).First(&secret).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+		}
+		return "", err
+	}
+
+	value, err := s.keys.Decrypt(secret.Ciphertext, secret.KeyVersion)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret %s", name)
+	}
+	return value, nil
+}
+
+// RekeyResult reports a re-encryption pass over the stored secrets
+type RekeyResult struct {
+	KeyVersion int `json:"key_version"`
+	Rekeyed    int `json:"rekeyed"`
+}
+
+// Rekey re-encrypts every secret sealed with an older key version using the current key.
+// Each row is updated only if it still holds the ciphertext that was read, so a secret
+// replaced concurrently through Put is left alone.
+func (s *SecretStore) Rekey() (*RekeyResult, error) {
+	result := &RekeyResult{KeyVersion: s.keys.Current()}
+
+	var secrets []models.Secret
+	if err := s.db.Where("key_version <> ?", s.keys.Current()).Find(&secrets).Error; err != nil {
+		return nil, err
+	}
+	for _, secret := range secrets {
+		value, err := s.keys.Decrypt(secret.Ciphertext, secret.KeyVersion)
+		if err != nil {
+			return result, fmt.Errorf("failed to decrypt secret %s: %w", secret.ID, err)
+		}
+		ciphertext, version, err := s.keys.Encrypt(value)
+		if err != nil {
+			return result, fmt.Errorf("failed to encrypt secret %s: %w", secret.ID, err)
+		}
+		update := s.db.Model(&models.Secret{}).
+			Where("id = ? AND ciphertext = ?", secret.ID, secret.Ciphertext).
+			Updates(map[string]interface{}{"ciphertext": ciphertext, "key_version": version})
+		if update.Error != nil {
+			return result, update.Error
+		}
+		result.Rekeyed += int(update.RowsAffected)
+	}
+	return result, nil
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Severity is a finding's seriousness on the canonical scale every scanner is mapped onto
+type Severity string
+
+// Finding severities, from most to least serious
+const (
+	SeverityCritical Severity = "critical"
+	SeverityHigh     Severity = "high"
+	SeverityMedium   Severity = "medium"
+	SeverityLow      Severity = "low"
+	SeverityInfo     Severity = "info"
+)
+
+// Severities lists every severity in descending order
+var Severities = []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo}
+
+// Rank orders severities for comparison: critical is highest, unknown values rank below info
+func (s Severity) Rank() int {
+	for i, severity := range Severities {
+		if s == severity {
+			return len(Severities) - i
+		}
+	}
+	return 0
+}
+
+// AtLeast reports whether s is as serious as threshold or more
+func (s Severity) AtLeast(threshold Severity) bool {
+	return s.Rank() >= threshold.Rank()
+}
+
+// NormalizeSeverity maps common severity labels onto Severities.
+// Unknown labels are treated as medium so they're neither hidden nor over-reported.
+func NormalizeSeverity(raw string) Severity {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "critical", "crit":
+		return SeverityCritical
+	case "high", "error":
+		return SeverityHigh
+	case "medium", "moderate", "warning", "warn":
+		return SeverityMedium
+	case "low", "note":
+		return SeverityLow
+	case "info", "informational", "none":
+		return SeverityInfo
+	default:
+		return SeverityMedium
+	}
+}
+
+// scannerSeverities maps each scanner's own severity labels onto the canonical scale.
+// Adding a scanner means adding its mapping here; scanners without one use NormalizeSeverity.
+var scannerSeverities = map[string]func(raw string) Severity{
+	// Trivy already uses CRITICAL/HIGH/MEDIUM/LOW, plus UNKNOWN for unrated advisories
+	"trivy-sca":   NormalizeSeverity,
+	"trivy-image": NormalizeSeverity,
+	"semgrep":     semgrepSeverity,
+	// Any leaked secret is treated as high
+	"gitleaks": fixedSeverity(SeverityHigh),
+	// Nikto reports no severity; its items are mostly misconfigurations worth a look
+	"nikto": fixedSeverity(SeverityMedium),
+	// Open ports and discovered paths are inventory rather than vulnerabilities
+	"nmap":     fixedSeverity(SeverityInfo),
+	"gobuster": fixedSeverity(SeverityInfo),
+	// An injectable parameter is exploitable as reported
+	"sqlmap": fixedSeverity(SeverityHigh),
+	// WPScan's CLI output doesn't include the CVSS rating
+	"wpscan": fixedSeverity(SeverityMedium),
+	"zap":    zapSeverity,
+}
+
+// ScannerSeverity maps a severity label reported by scanner onto the canonical scale
+func ScannerSeverity(scanner, raw string) Severity {
+	if mapping, ok := scannerSeverities[scanner]; ok {
+		return mapping(raw)
+	}
+	return NormalizeSeverity(raw)
+}
+
+// semgrepSeverity maps semgrep's rule severities, where INFO marks a low-risk pattern rather than inventory
+func semgrepSeverity(raw string) Severity {
+	switch strings.ToUpper(strings.TrimSpace(raw)) {
+	case "ERROR":
+		return SeverityHigh
+	case "WARNING":
+		return SeverityMedium
+	case "INFO":
+		return SeverityLow
+	default:
+		return NormalizeSeverity(raw)
+	}
+}
+
+// zapSeverity maps ZAP's risk codes; ZAP has no level above high
+func zapSeverity(raw string) Severity {
+	switch strings.TrimSpace(raw) {
+	case "3":
+		return SeverityHigh
+	case "2":
+		return SeverityMedium
+	case "1":
+		return SeverityLow
+	case "0":
+		return SeverityInfo
+	default:
+		return NormalizeSeverity(raw)
+	}
+}
+
+// fixedSeverity is the mapping for scanners that don't rate their findings
+func fixedSeverity(severity Severity) func(string) Severity {
+	return func(string) Severity {
+		return severity
+	}
+}
+
+// countBySeverity tallies findings per severity, always including every severity key
+func countBySeverity(findings []Finding) map[Severity]int {
+	counts := make(map[Severity]int, len(Severities))
+	for _, severity := range Severities {
+		counts[severity] = 0
+	}
+	for _, finding := range findings {
+		counts[finding.Severity]++
+	}
+	return counts
+}
+
+// riskGrades maps the most serious severity present onto a letter grade; no findings at all is an A
+var riskGrades = map[Severity]string{
+	SeverityCritical: "F",
+	SeverityHigh:     "D",
+	SeverityMedium:   "C",
+	SeverityLow:      "B",
+	SeverityInfo:     "A",
+}
+
+// riskSummary aggregates the normalized findings of every node result into per-severity
+// counts and a risk grade
+func riskSummary(results map[string]interface{}) (map[string]int, string) {
+	counts := countBySeverity(extractFindings(results))
+	summary := make(map[string]int, len(counts))
+	grade := "A"
+	for _, severity := range Severities {
+		summary[string(severity)] = counts[severity]
+		if counts[severity] > 0 && grade == "A" {
+			grade = riskGrades[severity]
+		}
+	}
+	return summary, grade
+}
+
+// totalFindings sums a severity summary
+func totalFindings(summary map[string]int) int {
+	total := 0
+	for _, count := range summary {
+		total += count
+	}
+	return total
+}
+
+// severitySummaryJSON encodes a severity summary for a map-based column update, which
+// bypasses the model's JSON serializer
+func severitySummaryJSON(summary map[string]int) string {
+	data, _ := json.Marshal(summary)
+	return string(data)
+}
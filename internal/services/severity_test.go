@@ -0,0 +1,223 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/datmedevil17/go-vuln/internal/models"
+)
+
+func TestScannerSeverity(t *testing.T) {
+	tests := []struct {
+		scanner string
+		raw     string
+		want    Severity
+	}{
+		{"trivy-sca", "CRITICAL", SeverityCritical},
+		{"trivy-sca", "HIGH", SeverityHigh},
+		{"trivy-sca", "MEDIUM", SeverityMedium},
+		{"trivy-sca", "LOW", SeverityLow},
+		{"trivy-sca", "UNKNOWN", SeverityMedium},
+		{"trivy-image", "CRITICAL", SeverityCritical},
+		{"semgrep", "ERROR", SeverityHigh},
+		{"semgrep", "WARNING", SeverityMedium},
+		{"semgrep", "INFO", SeverityLow},
+		{"semgrep", "critical", SeverityCritical},
+		{"gitleaks", "", SeverityHigh},
+		{"nikto", "", SeverityMedium},
+		{"nmap", "", SeverityInfo},
+		{"gobuster", "", SeverityInfo},
+		{"sqlmap", "", SeverityHigh},
+		{"wpscan", "", SeverityMedium},
+		{"zap", "3", SeverityHigh},
+		{"zap", "2", SeverityMedium},
+		{"zap", "1", SeverityLow},
+		{"zap", "0", SeverityInfo},
+		{"zap", "High", SeverityHigh},
+		{"kube-bench", "FAIL", SeverityMedium},
+		{"kube-bench", "WARN", SeverityMedium},
+		{"unmapped", "Crit", SeverityCritical},
+		{"unmapped", " high ", SeverityHigh},
+		{"unmapped", "moderate", SeverityMedium},
+		{"unmapped", "note", SeverityLow},
+		{"unmapped", "informational", SeverityInfo},
+		{"unmapped", "bogus", SeverityMedium},
+	}
+	for _, tt := range tests {
+		if got := ScannerSeverity(tt.scanner, tt.raw); got != tt.want {
+			t.Errorf("ScannerSeverity(%q, %q) = %s, want %s", tt.scanner, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestEveryParsedScannerHasSeverityMapping(t *testing.T) {
+	for scanner := range findingParsers {
+		if _, ok := scannerSeverities[scanner]; !ok {
+			t.Errorf("%s findings are parsed but its severities aren't mapped", scanner)
+		}
+	}
+}
+
+func TestSeverityOrdering(t *testing.T) {
+	for i := 1; i < len(Severities); i++ {
+		higher, lower := Severities[i-1], Severities[i]
+		if !higher.AtLeast(lower) || lower.AtLeast(higher) {
+			t.Errorf("%s should outrank %s", higher, lower)
+		}
+	}
+	if Severity("bogus").AtLeast(SeverityInfo) {
+		t.Error("an unknown severity outranks info")
+	}
+	if !SeverityHigh.AtLeast(SeverityHigh) {
+		t.Error("a severity is below itself")
+	}
+}
+
+// semgrepMixed is static analysis output with one finding at each semgrep severity
+const semgrepMixed = `{"results":[
+{"check_id":"sql-injection","path":"store/users.go","start":{"line":12},"end":{"line":12},"extra":{"severity":"ERROR"}},
+{"check_id":"weak-hash","path":"auth/hash.go","start":{"line":7},"end":{"line":7},"extra":{"severity":"WARNING"}},
+{"check_id":"todo-comment","path":"main.go","start":{"line":3},"end":{"line":3},"extra":{"severity":"INFO"}}]}`
+
+func TestRiskSummaryAcrossNodes(t *testing.T) {
+	nmap := func() interface{} {
+		return withFindings(map[string]interface{}{"scanner": "nmap", "output": "22/tcp open ssh\n443/tcp open https"})
+	}
+	tests := []struct {
+		name      string
+		results   map[string]interface{}
+		want      map[string]int
+		wantGrade string
+	}{
+		{
+			name:      "no scanners",
+			results:   map[string]interface{}{"trigger": map[string]interface{}{"status": "completed"}},
+			want:      map[string]int{"critical": 0, "high": 0, "medium": 0, "low": 0, "info": 0},
+			wantGrade: "A",
+		},
+		{
+			name:      "inventory only",
+			results:   map[string]interface{}{"ports": nmap()},
+			want:      map[string]int{"critical": 0, "high": 0, "medium": 0, "low": 0, "info": 2},
+			wantGrade: "A",
+		},
+		{
+			name: "medium at worst",
+			results: map[string]interface{}{
+				"ports": nmap(),
+				"web":   withFindings(map[string]interface{}{"scanner": "nikto", "output": `{"host":"shop.example.com","vulnerabilities":["Server leaks inodes via ETags"]}`}),
+			},
+			want:      map[string]int{"critical": 0, "high": 0, "medium": 1, "low": 0, "info": 2},
+			wantGrade: "C",
+		},
+		{
+			name: "mixed severities",
+			results: map[string]interface{}{
+				"ports":   nmap(),
+				"deps":    withFindings(map[string]interface{}{"scanner": "trivy-sca", "output": trivyCritical}),
+				"code":    withFindings(map[string]interface{}{"scanner": "semgrep", "output": semgrepMixed}),
+				"secrets": withFindings(map[string]interface{}{"scanner": "gitleaks", "output": `{"findings":[{"rule":"aws-access-key","file":"deploy.sh","startLine":4}]}`}),
+			},
+			want:      map[string]int{"critical": 1, "high": 2, "medium": 1, "low": 2, "info": 2},
+			wantGrade: "F",
+		},
+		{
+			name: "findings read back from the database and raw output alone",
+			results: map[string]interface{}{
+				"code": map[string]interface{}{"scanner": "semgrep", "output": semgrepMixed},
+				"secrets": map[string]interface{}{"scanner": "gitleaks", "findings": []interface{}{
+					map[string]interface{}{"key": "gitleaks: aws-access-key in deploy.sh", "scanner": "gitleaks", "severity": "high"},
+				}},
+			},
+			want:      map[string]int{"critical": 0, "high": 2, "medium": 1, "low": 1, "info": 0},
+			wantGrade: "D",
+		},
+		{
+			name: "the same finding from two nodes counts once",
+			results: map[string]interface{}{
+				"ports":       nmap(),
+				"ports-again": nmap(),
+			},
+			want:      map[string]int{"critical": 0, "high": 0, "medium": 0, "low": 0, "info": 2},
+			wantGrade: "A",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, grade := riskSummary(tt.results)
+			if !reflect.DeepEqual(summary, tt.want) || grade != tt.wantGrade {
+				t.Fatalf("riskSummary() = %v, %s, want %v, %s", summary, grade, tt.want, tt.wantGrade)
+			}
+		})
+	}
+}
+
+func TestExecutionRecordsSeveritySummary(t *testing.T) {
+	e, writes := newTestExecutor(t, config.WorkflowConfig{MaxParallelNodes: 4})
+	workflow := testWorkflow(
+		models.JSONArray{
+			testNode("trigger", "trigger"),
+			map[string]interface{}{"id": "deps", "type": "test-scan", "data": map[string]interface{}{"scanner": "trivy-sca", "output": trivyCritical}},
+			map[string]interface{}{"id": "code", "type": "test-scan", "data": map[string]interface{}{"scanner": "semgrep", "output": semgrepMixed}},
+		},
+		models.JSONArray{testEdge("e1", "trigger", "deps"), testEdge("e2", "trigger", "code")},
+	)
+	runTestWorkflow(t, e, workflow)
+
+	var final map[string]interface{}
+	writes.mu.Lock()
+	defer writes.mu.Unlock()
+	for _, columns := range writes.writes {
+		if _, ok := columns["severity_summary"]; ok {
+			final = columns
+		}
+	}
+	if final["status"] != "completed" {
+		t.Fatalf("execution finished %v with a severity summary, want completed", final["status"])
+	}
+	var summary map[string]int
+	if err := json.Unmarshal([]byte(final["severity_summary"].(string)), &summary); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"critical": 1, "high": 1, "medium": 1, "low": 2, "info": 0}
+	if !reflect.DeepEqual(summary, want) || final["risk_grade"] != "F" {
+		t.Fatalf("recorded %v, grade %v, want %v, grade F", summary, final["risk_grade"], want)
+	}
+}
+
+func TestAIReportGradesFindings(t *testing.T) {
+	tests := []struct {
+		name         string
+		scanner      string
+		output       string
+		wantGrade    string
+		wantTotal    int
+		wantCritical int
+	}{
+		{name: "no findings", scanner: "nmap", output: "22/tcp open ssh", wantGrade: "A", wantTotal: 1},
+		{name: "critical present", scanner: "trivy-sca", output: trivyCritical, wantGrade: "F", wantTotal: 2, wantCritical: 1},
+		{name: "mixed", scanner: "semgrep", output: semgrepMixed, wantGrade: "D", wantTotal: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, writes := newTestExecutor(t, config.WorkflowConfig{MaxParallelNodes: 4})
+			workflow := testWorkflow(
+				models.JSONArray{
+					testNode("trigger", "trigger"),
+					dataNode("scan", "test-scan", map[string]interface{}{"scanner": tt.scanner, "output": tt.output}),
+				},
+				models.JSONArray{testEdge("e1", "trigger", "scan")},
+			)
+			runTestWorkflow(t, e, workflow)
+
+			_, results := writes.finalState(t)
+			report, _ := results["ai_report"].(map[string]interface{})
+			if report["security_grade"] != tt.wantGrade || report["total_issues"] != tt.wantTotal || report["critical_issues"] != tt.wantCritical {
+				t.Fatalf("ai_report graded %v with %v issues, %v critical, want %s with %d, %d critical",
+					report["security_grade"], report["total_issues"], report["critical_issues"], tt.wantGrade, tt.wantTotal, tt.wantCritical)
+			}
+		})
+	}
+}
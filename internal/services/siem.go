@@ -0,0 +1,293 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// siemChannel is the notification queue channel failed SIEM pushes are retried on
+	siemChannel = "siem"
+
+	// Queued SIEM deliveries name their destination rather than store its URL and
+	// credentials, which are looked up again on every attempt
+	siemGlobal = "global"
+	siemUser   = "user"
+
+	siemTimeout = 30 * time.Second
+	ecsVersion  = "8.11.0"
+)
+
+// FindingsEvent is the set of findings from one finished scan or workflow execution
+type FindingsEvent struct {
+	Source   string // scan or execution
+	SourceID uuid.UUID
+	UserID   uuid.UUID
+	Time     time.Time
+	Findings []Finding
+}
+
+// FindingFormatter renders a findings event as the body of a SIEM request
+type FindingFormatter interface {
+	ContentType() string
+	Format(event FindingsEvent) ([]byte, error)
+}
+
+// findingFormatters are the SIEM formats that can be configured, by name
+var findingFormatters = map[string]FindingFormatter{
+	"ecs": ecsFormatter{},
+	"cef": cefFormatter{},
+}
+
+// IsSIEMFormat reports whether format names a finding formatter
+func IsSIEMFormat(format string) bool {
+	_, ok := findingFormatters[format]
+	return ok
+}
+
+// SIEMExporter pushes normalized findings to the server-wide SIEM endpoint and to each
+// user's own endpoint, when configured. Pushes are best effort: a failed one is handed
+// to the notification queue, which retries it and eventually dead-letters it.
+type SIEMExporter struct {
+	db      *gorm.DB
+	secrets *SecretStore
+	queue   *NotificationQueue
+	config  *config.Config
+
+	globalClient *http.Client // The operator's endpoint, which may be on a private network
+	userClient   *http.Client // User-supplied endpoints, restricted to public addresses
+}
+
+func NewSIEMExporter(db *gorm.DB, secrets *SecretStore, queue *NotificationQueue, cfg *config.Config) *SIEMExporter {
+	e := &SIEMExporter{
+		db:           db,
+		secrets:      secrets,
+		queue:        queue,
+		config:       cfg,
+		globalClient: &http.Client{Timeout: siemTimeout},
+		userClient:   utils.NewSafeHTTPClient(siemTimeout),
+	}
+	queue.RegisterSender(siemChannel, e.deliver)
+	return e
+}
+
+// Export sends event to every configured destination, queueing the pushes that fail
+func (e *SIEMExporter) Export(event FindingsEvent) {
+	if e == nil || len(event.Findings) == 0 {
+		return
+	}
+
+	destinations := map[string]string{} // Destination -> format
+	if e.config.SIEM.URL != "" {
+		destinations[siemGlobal] = e.config.SIEM.Format
+	}
+	var user models.User
+	if err := e.db.Select("id", "siem_url", "siem_format").First(&user, "id = ?", event.UserID).Error; err == nil && user.Preferences.SIEMURL != "" {
+		destinations[siemUser] = user.Preferences.SIEMFormat
+	}
+
+	for destination, format := range destinations {
+		formatter, ok := findingFormatters[format]
+		if !ok {
+			log.Printf("⚠️ Unknown SIEM format %q for %s destination, skipping", format, destination)
+			continue
+		}
+		payload, err := formatter.Format(event)
+		if err != nil {
+			log.Printf("⚠️ Failed to format findings for SIEM: %v", err)
+			continue
+		}
+		if err := e.post(event.UserID, destination, format, payload); err != nil {
+			log.Printf("⚠️ SIEM push to %s destination failed, queueing for retry: %v", destination, err)
+			if _, qErr := e.queue.Enqueue(event.UserID, siemChannel, destination, format, string(payload), nil, err); qErr != nil {
+				log.Printf("⚠️ %v", qErr)
+			}
+		}
+	}
+}
+
+// deliver retries a queued push; the delivery's subject holds the payload format
+func (e *SIEMExporter) deliver(delivery *models.NotificationDelivery) error {
+	return e.post(delivery.UserID, delivery.Recipient, delivery.Subject, []byte(delivery.Body))
+}
+
+func (e *SIEMExporter) post(userID uuid.UUID, destination, format string, payload []byte) error {
+	var endpoint, authorization string
+	client := e.globalClient
+	switch destination {
+	case siemGlobal:
+		endpoint, authorization = e.config.SIEM.URL, e.config.SIEM.Authorization
+	case siemUser:
+		var user models.User
+		if err := e.db.Select("id", "siem_url", "siem_secret").First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		endpoint = user.Preferences.SIEMURL
+		if user.Preferences.SIEMSecret != "" {
+			value, err := e.secrets.Resolve(userID, user.Preferences.SIEMSecret)
+			if err != nil {
+				return err
+			}
+			authorization = value
+		}
+		client = e.userClient
+	default:
+		return fmt.Errorf("unknown SIEM destination: %s", destination)
+	}
+	if endpoint == "" {
+		return fmt.Errorf("no SIEM endpoint configured for %s destination", destination)
+	}
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", findingFormatters[format].ContentType())
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("SIEM endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+// cefSeverity maps severities onto the 0-10 scale shared by CEF and ECS event.severity
+func cefSeverity(severity Severity) int {
+	switch severity {
+	case SeverityCritical:
+		return 10
+	case SeverityHigh:
+		return 8
+	case SeverityMedium:
+		return 5
+	case SeverityLow:
+		return 3
+	default:
+		return 1
+	}
+}
+
+// ecsFormatter renders findings as a JSON array of Elastic Common Schema documents
+type ecsFormatter struct{}
+
+func (ecsFormatter) ContentType() string { return "application/json" }
+
+func (ecsFormatter) Format(event FindingsEvent) ([]byte, error) {
+	documents := make([]map[string]interface{}, 0, len(event.Findings))
+	for _, finding := range event.Findings {
+		severity := string(finding.Severity)
+		if severity != "" {
+			severity = strings.ToUpper(severity[:1]) + severity[1:]
+		}
+		vulnerability := map[string]interface{}{
+			"severity": severity,
+			"scanner":  map[string]interface{}{"vendor": finding.Scanner},
+			"category": []string{finding.Scanner},
+		}
+		if finding.RuleID != "" {
+			vulnerability["id"] = finding.RuleID
+		}
+		if finding.Description != "" {
+			vulnerability["description"] = finding.Description
+		}
+
+		document := map[string]interface{}{
+			"@timestamp": event.Time.UTC().Format(time.RFC3339Nano),
+			"ecs":        map[string]interface{}{"version": ecsVersion},
+			"message":    finding.Title,
+			"event": map[string]interface{}{
+				"kind":     "alert",
+				"category": []string{"vulnerability"},
+				"type":     []string{"info"},
+				"module":   "vulnpilot",
+				"dataset":  "vulnpilot.findings",
+				"severity": cefSeverity(finding.Severity),
+				"id":       utils.HashSHA256(event.SourceID.String() + finding.Key)[:32],
+			},
+			"observer": map[string]interface{}{
+				"vendor":  "VulnPilot",
+				"product": "VulnPilot",
+				"type":    "vulnerability-scanner",
+			},
+			"vulnerability": vulnerability,
+			"labels": map[string]interface{}{
+				"vulnpilot_source":    event.Source,
+				"vulnpilot_source_id": event.SourceID.String(),
+				"vulnpilot_key":       finding.Key,
+			},
+		}
+		if host := utils.NormalizeHost(finding.Target); host != "" {
+			document["host"] = map[string]interface{}{"name": host}
+		}
+		if strings.Contains(finding.Target, "://") {
+			document["url"] = map[string]interface{}{"original": finding.Target}
+		}
+		if finding.Path != "" {
+			document["file"] = map[string]interface{}{"path": finding.Path}
+		}
+		documents = append(documents, document)
+	}
+	return json.Marshal(documents)
+}
+
+// cefFormatter renders findings as ArcSight Common Event Format lines, one per finding
+type cefFormatter struct{}
+
+func (cefFormatter) ContentType() string { return "text/plain" }
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r\n", `\n`, "\n", `\n`, "\r", `\r`)
+)
+
+func (cefFormatter) Format(event FindingsEvent) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, finding := range event.Findings {
+		signature := finding.RuleID
+		if signature == "" {
+			signature = finding.Scanner
+		}
+		fmt.Fprintf(&buf, "CEF:0|VulnPilot|VulnPilot|1.0|%s|%s|%d|",
+			cefHeaderEscaper.Replace(signature), cefHeaderEscaper.Replace(finding.Title), cefSeverity(finding.Severity))
+
+		extensions := []string{"rt=" + strconv.FormatInt(event.Time.UnixMilli(), 10)}
+		add := func(key, value string) {
+			if value != "" {
+				extensions = append(extensions, key+"="+cefExtensionEscaper.Replace(value))
+			}
+		}
+		add("dhost", utils.NormalizeHost(finding.Target))
+		if strings.Contains(finding.Target, "://") {
+			add("request", finding.Target)
+		}
+		add("fname", finding.Path)
+		add("msg", finding.Description)
+		add("cs1Label", "scanner")
+		add("cs1", finding.Scanner)
+		add("cs2Label", "severity")
+		add("cs2", string(finding.Severity))
+		add("cs3Label", event.Source+"Id")
+		add("cs3", event.SourceID.String())
+		buf.WriteString(strings.Join(extensions, " "))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
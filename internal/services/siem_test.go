@@ -0,0 +1,174 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func testFindingsEvent() FindingsEvent {
+	return FindingsEvent{
+		Source:   "scan",
+		SourceID: uuid.MustParse("6f1c2d3e-4b5a-4c6d-8e7f-0a1b2c3d4e5f"),
+		UserID:   uuid.New(),
+		Time:     time.Date(2026, 3, 14, 9, 26, 53, 589000000, time.FixedZone("CET", 3600)),
+		Findings: []Finding{
+			{
+				Key:         "nikto-1",
+				Scanner:     "nikto",
+				RuleID:      "OSVDB-3092",
+				Title:       "/admin/: This might be interesting",
+				Severity:    SeverityMedium,
+				Target:      "https://shop.example.com:8443/",
+				Description: "Admin directory found",
+			},
+			{
+				Key:      "nmap-22",
+				Scanner:  "nmap",
+				Title:    "22/tcp open ssh",
+				Severity: SeverityInfo,
+				Target:   "10.0.0.5",
+			},
+			{
+				Key:      "gitleaks-1",
+				Scanner:  "gitleaks",
+				RuleID:   "aws-access-token",
+				Title:    "AWS access token",
+				Severity: SeverityCritical,
+				Path:     "config/prod.env",
+			},
+		},
+	}
+}
+
+func TestECSFormatter(t *testing.T) {
+	event := testFindingsEvent()
+	body, err := ecsFormatter{}.Format(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var documents []map[string]interface{}
+	if err := json.Unmarshal(body, &documents); err != nil {
+		t.Fatalf("output is not a JSON array of documents: %v\n%s", err, body)
+	}
+	if len(documents) != len(event.Findings) {
+		t.Fatalf("%d documents for %d findings", len(documents), len(event.Findings))
+	}
+
+	// Fields every ECS document needs, and the ones the findings fill in
+	tests := []struct {
+		name  string
+		index int
+		field string
+		want  interface{} // nil when the field must be absent
+	}{
+		{name: "timestamp in UTC", index: 0, field: "@timestamp", want: "2026-03-14T08:26:53.589Z"},
+		{name: "ECS version", index: 0, field: "ecs.version", want: ecsVersion},
+		{name: "message", index: 0, field: "message", want: "/admin/: This might be interesting"},
+		{name: "event kind", index: 0, field: "event.kind", want: "alert"},
+		{name: "event category", index: 0, field: "event.category", want: []interface{}{"vulnerability"}},
+		{name: "event type", index: 0, field: "event.type", want: []interface{}{"info"}},
+		{name: "numeric severity", index: 0, field: "event.severity", want: float64(5)},
+		{name: "vulnerability severity", index: 0, field: "vulnerability.severity", want: "Medium"},
+		{name: "vulnerability id", index: 0, field: "vulnerability.id", want: "OSVDB-3092"},
+		{name: "scanner vendor", index: 0, field: "vulnerability.scanner.vendor", want: "nikto"},
+		{name: "host from a URL", index: 0, field: "host.name", want: "shop.example.com"},
+		{name: "URL", index: 0, field: "url.original", want: "https://shop.example.com:8443/"},
+		{name: "source label", index: 0, field: "labels.vulnpilot_source_id", want: "6f1c2d3e-4b5a-4c6d-8e7f-0a1b2c3d4e5f"},
+		{name: "bare host", index: 1, field: "host.name", want: "10.0.0.5"},
+		{name: "no URL for a bare host", index: 1, field: "url", want: nil},
+		{name: "no id without a rule", index: 1, field: "vulnerability.id", want: nil},
+		{name: "info severity", index: 1, field: "event.severity", want: float64(1)},
+		{name: "file path", index: 2, field: "file.path", want: "config/prod.env"},
+		{name: "critical severity", index: 2, field: "event.severity", want: float64(10)},
+		{name: "no host without a target", index: 2, field: "host", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := lookupField(documents[tt.index], tt.field)
+			if tt.want == nil {
+				if ok {
+					t.Fatalf("%s = %v, want it absent", tt.field, got)
+				}
+				return
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(tt.want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Fatalf("%s = %s, want %s", tt.field, gotJSON, wantJSON)
+			}
+		})
+	}
+
+	// Event IDs let the SIEM drop documents pushed twice, so they must be stable and distinct
+	again, _ := ecsFormatter{}.Format(event)
+	if string(again) != string(body) {
+		t.Error("formatting the same event twice gave different documents")
+	}
+	first, _ := lookupField(documents[0], "event.id")
+	second, _ := lookupField(documents[1], "event.id")
+	if first == second {
+		t.Errorf("two findings share event.id %v", first)
+	}
+}
+
+func TestECSFormatterWithoutFindings(t *testing.T) {
+	body, err := ecsFormatter{}.Format(FindingsEvent{SourceID: uuid.New(), Time: time.Now()})
+	if err != nil || string(body) != "[]" {
+		t.Fatalf("Format() = %s, %v, want an empty array", body, err)
+	}
+}
+
+func TestCEFFormatter(t *testing.T) {
+	event := testFindingsEvent()
+	event.Findings[0].Title = "Pipe | and backslash \\ in title"
+	event.Findings[0].Description = "a=b\nnext line"
+
+	body, err := cefFormatter{}.Format(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")
+	if len(lines) != len(event.Findings) {
+		t.Fatalf("%d lines for %d findings:\n%s", len(lines), len(event.Findings), body)
+	}
+
+	tests := []struct {
+		name  string
+		index int
+		want  string
+	}{
+		{name: "header", index: 0, want: `CEF:0|VulnPilot|VulnPilot|1.0|OSVDB-3092|Pipe \| and backslash \\ in title|5|`},
+		{name: "escaped extension", index: 0, want: `msg=a\=b\nnext line`},
+		{name: "receipt time", index: 0, want: "rt=1773476813589"},
+		{name: "request URL", index: 0, want: "request=https://shop.example.com:8443/"},
+		{name: "scanner as signature without a rule", index: 1, want: "|nmap|22/tcp open ssh|1|"},
+		{name: "source", index: 2, want: "cs3Label=scanId cs3=6f1c2d3e-4b5a-4c6d-8e7f-0a1b2c3d4e5f"},
+		{name: "file", index: 2, want: "fname=config/prod.env"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(lines[tt.index], tt.want) {
+				t.Fatalf("line %d = %q, want it to contain %q", tt.index, lines[tt.index], tt.want)
+			}
+		})
+	}
+}
+
+// lookupField follows a dotted path through nested JSON objects
+func lookupField(document map[string]interface{}, path string) (interface{}, bool) {
+	var value interface{} = document
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrScannerNotInstalled is returned by source scans when the tool isn't on the host.
+// Unlike the network scanners these are never mocked, since their findings decide
+// whether a fix is reported as verified.
+var ErrScannerNotInstalled = errors.New("scanner is not installed")
+
+// sourceScanners maps the scanner names recorded in node results to the scan of a checkout.
+// Each returns output in the same shape as the workflow nodes so findings compare by key.
+var sourceScanners = map[string]func(s *ScannerService, ctx context.Context, dir string) (string, error){
+	"gitleaks":  (*ScannerService).RunGitleaks,
+	"semgrep":   (*ScannerService).RunSemgrep,
+	"trivy-sca": (*ScannerService).RunTrivyFS,
+}
+
+// GitleaksFinding is one leak from a gitleaks report, with its file relative to the checkout
+type GitleaksFinding struct {
+	Rule      string `json:"rule"`
+	File      string `json:"file"`
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+	Secret    string `json:"secret"`
+	Match     string `json:"match"`
+}
+
+// parseGitleaksReport reads a gitleaks JSON report; an empty report has no findings
+func parseGitleaksReport(data []byte, dir string) ([]GitleaksFinding, error) {
+	var leaks []struct {
+		RuleID    string `json:"RuleID"`
+		File      string `json:"File"`
+		StartLine int    `json:"StartLine"`
+		EndLine   int    `json:"EndLine"`
+		Secret    string `json:"Secret"`
+		Match     string `json:"Match"`
+	}
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err := json.Unmarshal(data, &leaks); err != nil {
+			return nil, fmt.Errorf("failed to parse gitleaks report: %v", err)
+		}
+	}
+
+	findings := make([]GitleaksFinding, 0, len(leaks))
+	for _, leak := range leaks {
+		findings = append(findings, GitleaksFinding{
+			Rule:      leak.RuleID,
+			File:      relativeTo(dir, leak.File),
+			StartLine: leak.StartLine,
+			EndLine:   leak.EndLine,
+			Secret:    leak.Secret,
+			Match:     leak.Match,
+		})
+	}
+	return findings, nil
+}
+
+// RunGitleaks scans a checkout for secrets, returning {"findings": [...]} of GitleaksFinding
+func (s *ScannerService) RunGitleaks(ctx context.Context, dir string) (string, error) {
+	if err := s.requireSourceScanner("gitleaks"); err != nil {
+		return "", err
+	}
+	release, err := s.limiter.Acquire(ctx, "gitleaks")
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	// The report goes in a directory of its own so a sandbox only needs that one mounted writable
+	reportDir, err := os.MkdirTemp("", "gitleaks-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(reportDir)
+	reportPath := filepath.Join(reportDir, "report.json")
+
+	args := []string{"detect", "--source", dir, "--no-git", "--no-banner",
+		"--report-format", "json", "--report-path", reportPath, "--exit-code", "0"}
+	if output, err := s.runner.Run(ctx, ToolRun{Tool: "gitleaks", Args: args, Mounts: []Mount{{Path: dir}, {Path: reportDir, Writable: true}}}); err != nil {
+		return "", fmt.Errorf("gitleaks execution failed: %v, output: %s", err, string(output))
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		return "", err
+	}
+	findings, err := parseGitleaksReport(data, dir)
+	if err != nil {
+		return "", err
+	}
+	output, _ := json.Marshal(map[string]interface{}{"findings": findings})
+	return string(output), nil
+}
+
+// ErrInvalidSemgrepConfig is returned for a ruleset that isn't "auto" or a registry ruleset
+var ErrInvalidSemgrepConfig = errors.New("semgrep config must be auto or a registry ruleset such as p/owasp-top-ten")
+
+// semgrepRegistryConfig matches registry rulesets (p/...) and rules (r/...); local paths are
+// refused so a workflow can't point semgrep at files on the server
+var semgrepRegistryConfig = regexp.MustCompile(`^[pr](/[A-Za-z0-9_-][A-Za-z0-9_.-]*)+$`)
+
+// SemgrepFinding is one result from a semgrep JSON report
+type SemgrepFinding struct {
+	CheckID  string `json:"check_id"`
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"` // ERROR, WARNING or INFO
+}
+
+// parseSemgrepReport reads the results of a semgrep JSON report
+func parseSemgrepReport(output []byte) ([]SemgrepFinding, error) {
+	var report struct {
+		Results []struct {
+			CheckID string `json:"check_id"`
+			Path    string `json:"path"`
+			Start   struct {
+				Line int `json:"line"`
+			} `json:"start"`
+			Extra struct {
+				Message  string `json:"message"`
+				Severity string `json:"severity"`
+			} `json:"extra"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse semgrep report: %v", err)
+	}
+
+	findings := make([]SemgrepFinding, 0, len(report.Results))
+	for _, result := range report.Results {
+		findings = append(findings, SemgrepFinding{
+			CheckID:  result.CheckID,
+			Path:     result.Path,
+			Line:     result.Start.Line,
+			Message:  result.Extra.Message,
+			Severity: result.Extra.Severity,
+		})
+	}
+	return findings, nil
+}
+
+// RunSemgrep runs semgrep's registry rules over a checkout, returning its JSON report
+func (s *ScannerService) RunSemgrep(ctx context.Context, dir string) (string, error) {
+	return s.RunSemgrepConfig(ctx, dir, "auto")
+}
+
+// RunSemgrepConfig runs the given semgrep ruleset over a checkout, returning its JSON report.
+// An empty config means "auto".
+func (s *ScannerService) RunSemgrepConfig(ctx context.Context, dir, config string) (string, error) {
+	if config == "" {
+		config = "auto"
+	}
+	if config != "auto" && !semgrepRegistryConfig.MatchString(config) {
+		return "", ErrInvalidSemgrepConfig
+	}
+	if err := s.requireSourceScanner("semgrep"); err != nil {
+		return "", err
+	}
+	release, err := s.limiter.Acquire(ctx, "semgrep")
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	// Run from inside the checkout so reported paths are repository-relative
+	output, err := s.runner.Run(ctx, ToolRun{Tool: "semgrep", Args: []string{"scan", "--config", config, "--json", "--quiet"}, Dir: dir, Network: true, Stdout: true})
+	if err != nil {
+		return "", fmt.Errorf("semgrep execution failed: %v", err)
+	}
+	return string(output), nil
+}
+
+// RunTrivyFS scans a checkout's dependencies, returning {"Vulnerabilities": [...]} across all targets
+func (s *ScannerService) RunTrivyFS(ctx context.Context, dir string) (string, error) {
+	if err := s.requireSourceScanner("trivy"); err != nil {
+		return "", err
+	}
+	release, err := s.limiter.Acquire(ctx, "trivy")
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	// Trivy downloads its vulnerability database, so it keeps network access
+	output, err := s.runner.Run(ctx, ToolRun{Tool: "trivy", Args: []string{"fs", "--quiet", "--format", "json", "--scanners", "vuln", dir}, Mounts: []Mount{{Path: dir}}, Network: true, Stdout: true})
+	if err != nil {
+		return "", fmt.Errorf("trivy execution failed: %v", err)
+	}
+
+	var report struct {
+		Results []struct {
+			Vulnerabilities []map[string]interface{} `json:"Vulnerabilities"`
+		} `json:"Results"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		return "", fmt.Errorf("failed to parse trivy report: %v", err)
+	}
+
+	vulnerabilities := []map[string]interface{}{}
+	for _, result := range report.Results {
+		vulnerabilities = append(vulnerabilities, result.Vulnerabilities...)
+	}
+	flattened, _ := json.Marshal(map[string]interface{}{"Vulnerabilities": vulnerabilities})
+	return string(flattened), nil
+}
+
+// TrivyVulnerability is one vulnerable package from a trivy report
+type TrivyVulnerability struct {
+	VulnerabilityID  string `json:"VulnerabilityID"`
+	PkgName          string `json:"PkgName"`
+	InstalledVersion string `json:"InstalledVersion"`
+	FixedVersion     string `json:"FixedVersion"` // Empty when no release fixes it yet
+	Severity         string `json:"Severity"`
+}
+
+// parseTrivyVulnerabilities reads the vulnerabilities from RunTrivyFS output, or from a raw
+// trivy report with them nested under Results
+func parseTrivyVulnerabilities(output []byte) ([]TrivyVulnerability, error) {
+	var report struct {
+		Vulnerabilities []TrivyVulnerability `json:"Vulnerabilities"`
+		Results         []struct {
+			Vulnerabilities []TrivyVulnerability `json:"Vulnerabilities"`
+		} `json:"Results"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse trivy report: %v", err)
+	}
+	vulnerabilities := append([]TrivyVulnerability{}, report.Vulnerabilities...)
+	for _, result := range report.Results {
+		vulnerabilities = append(vulnerabilities, result.Vulnerabilities...)
+	}
+	return vulnerabilities, nil
+}
+
+// RunTrivyImage scans a container image, pulling it with auth when it's in a private registry.
+// It returns {"Image": image, "Vulnerabilities": [...]} across all of the image's targets.
+func (s *ScannerService) RunTrivyImage(ctx context.Context, image string, auth *RegistryAuth) (string, error) {
+	if err := validateImageRef(image); err != nil {
+		return "", err
+	}
+	if err := s.requireSourceScanner("trivy"); err != nil {
+		return "", err
+	}
+	release, err := s.limiter.Acquire(ctx, "trivy")
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	// Pull from the registry directly, so the image needn't be on this host's Docker daemon
+	args := []string{"image", "--quiet", "--format", "json", "--scanners", "vuln", "--image-src", "remote", image}
+	output, err := s.runner.Run(ctx, ToolRun{Tool: "trivy", Args: args, Network: true, Stdout: true, Env: auth.trivyEnv()})
+	if err != nil {
+		return "", fmt.Errorf("trivy execution failed: %s", auth.redact([]byte(fmt.Sprintf("%v, output: %s", err, output))))
+	}
+
+	var report struct {
+		Results []struct {
+			Vulnerabilities []map[string]interface{} `json:"Vulnerabilities"`
+		} `json:"Results"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		return "", fmt.Errorf("failed to parse trivy report: %v", err)
+	}
+
+	vulnerabilities := []map[string]interface{}{}
+	for _, result := range report.Results {
+		vulnerabilities = append(vulnerabilities, result.Vulnerabilities...)
+	}
+	flattened, _ := json.Marshal(map[string]interface{}{"Image": image, "Vulnerabilities": vulnerabilities})
+	return string(auth.redact(flattened)), nil
+}
+
+// relativeTo strips the checkout directory from a reported path
+func relativeTo(dir, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
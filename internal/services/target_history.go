@@ -0,0 +1,177 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/datmedevil17/go-vuln/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// targetHistoryLimit caps how many runs of each kind are included in a history
+const targetHistoryLimit = 200
+
+// ErrInvalidTarget is returned when a target has no usable host
+var ErrInvalidTarget = errors.New("invalid target host")
+
+// TargetHistoryPoint is one execution or standalone scan of a target
+type TargetHistoryPoint struct {
+	Source     string           `json:"source"` // execution or scan
+	ID         uuid.UUID        `json:"id"`
+	WorkflowID *uuid.UUID       `json:"workflow_id,omitempty"`
+	Scanners   []string         `json:"scanners"`
+	Status     string           `json:"status"`
+	Timestamp  time.Time        `json:"timestamp"`
+	Counts     map[Severity]int `json:"counts"`
+	Total      int              `json:"total"`
+}
+
+// TargetHistory is a host's finding counts over time across all workflows and scans
+type TargetHistory struct {
+	Host     string               `json:"host"`
+	Timeline []TargetHistoryPoint `json:"timeline"`
+	Trend    string               `json:"trend"` // improving, regressing, stable or unknown
+	Latest   *TargetHistoryPoint  `json:"latest,omitempty"`
+}
+
+type TargetService struct {
+	db *gorm.DB
+}
+
+func NewTargetService(db *gorm.DB) *TargetService {
+	return &TargetService{db: db}
+}
+
+// GetHistory aggregates every finished execution and scan of host for the user, oldest first
+func (s *TargetService) GetHistory(userID uuid.UUID, target string) (*TargetHistory, error) {
+	host := utils.NormalizeHost(target)
+	if host == "" {
+		return nil, ErrInvalidTarget
+	}
+
+	history := &TargetHistory{Host: host, Timeline: []TargetHistoryPoint{}}
+
+	var executions []models.WorkflowExecution
+	if err := s.db.Where("user_id = ? AND target_host = ? AND status IN ?", userID, host, []string{"completed", "failed", "timed_out"}).
+		Order("created_at DESC").Limit(targetHistoryLimit).Find(&executions).Error; err != nil {
+		return nil, err
+	}
+	for _, execution := range executions {
+		findings := extractFindings(execution.Results)
+		timestamp := execution.CreatedAt
+		if execution.CompletedAt != nil {
+			timestamp = *execution.CompletedAt
+		}
+		workflowID := execution.WorkflowID
+		history.Timeline = append(history.Timeline, newHistoryPoint("execution", execution.ID, &workflowID,
+			resultScanners(execution.Results), execution.Status, timestamp, findings))
+	}
+
+	// Rows created before target_host existed are matched on the raw URL and checked here.
+	// Scans run by a workflow are already part of their execution above.
+	var scans []models.ScanResult
+	if err := s.db.Where("user_id = ? AND status = ? AND execution_id IS NULL", userID, "completed").
+		Where("target_host = ? OR (COALESCE(target_host, '') = '' AND target_url ILIKE ?)", host, "%"+host+"%").
+		Order("created_at DESC").Limit(targetHistoryLimit).Find(&scans).Error; err != nil {
+		return nil, err
+	}
+	for _, scan := range scans {
+		if scan.TargetHost == "" && utils.NormalizeHost(scan.TargetURL) != host {
+			continue
+		}
+		timestamp := scan.CreatedAt
+		if scan.CompletedAt != nil {
+			timestamp = *scan.CompletedAt
+		}
+		findings := extractFindings(map[string]interface{}{"scan": scanResultAsNodeResult(scan)})
+		history.Timeline = append(history.Timeline, newHistoryPoint("scan", scan.ID, scan.WorkflowID,
+			[]string{scan.ScanType}, scan.Status, timestamp, findings))
+	}
+
+	sort.Slice(history.Timeline, func(i, j int) bool {
+		return history.Timeline[i].Timestamp.Before(history.Timeline[j].Timestamp)
+	})
+
+	history.Trend = "unknown"
+	if n := len(history.Timeline); n > 0 {
+		history.Latest = &history.Timeline[n-1]
+		if n > 1 {
+			history.Trend = compareSeverityCounts(history.Timeline[n-2].Counts, history.Timeline[n-1].Counts)
+		}
+	}
+
+	return history, nil
+}
+
+func newHistoryPoint(source string, id uuid.UUID, workflowID *uuid.UUID, scanners []string, status string, timestamp time.Time, findings []Finding) TargetHistoryPoint {
+	return TargetHistoryPoint{
+		Source:     source,
+		ID:         id,
+		WorkflowID: workflowID,
+		Scanners:   scanners,
+		Status:     status,
+		Timestamp:  timestamp,
+		Counts:     countBySeverity(findings),
+		Total:      len(findings),
+	}
+}
+
+// compareSeverityCounts compares two runs from the most severe level down;
+// the first level that differs decides whether the target improved or regressed
+func compareSeverityCounts(previous, current map[Severity]int) string {
+	for _, severity := range Severities {
+		switch {
+		case current[severity] < previous[severity]:
+			return "improving"
+		case current[severity] > previous[severity]:
+			return "regressing"
+		}
+	}
+	return "stable"
+}
+
+// resultScanners lists the scanners that produced results in an execution
+func resultScanners(results map[string]interface{}) []string {
+	seen := make(map[string]struct{})
+	scanners := []string{}
+	for _, result := range results {
+		nodeMap, ok := result.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if scanner, ok := nodeMap["scanner"].(string); ok && scanner != "" {
+			if _, dup := seen[scanner]; !dup {
+				seen[scanner] = struct{}{}
+				scanners = append(scanners, scanner)
+			}
+		}
+	}
+	sort.Strings(scanners)
+	return scanners
+}
+
+// scanResultAsNodeResult reshapes a standalone scan's stored results like a workflow node result
+func scanResultAsNodeResult(scan models.ScanResult) map[string]interface{} {
+	result := map[string]interface{}{"scanner": scan.ScanType}
+
+	var stored map[string]interface{}
+	if json.Unmarshal(scan.Results, &stored) != nil {
+		return result
+	}
+	if output, ok := stored["output"].(string); ok {
+		result["output"] = output
+	}
+	// Nikto stores its JSON report directly
+	if _, ok := stored["vulnerabilities"]; ok {
+		result["output"] = string(scan.Results)
+	}
+	if raw, ok := result["output"].(string); ok && strings.TrimSpace(raw) == "" {
+		delete(result, "output")
+	}
+	return result
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+)
+
+// ErrSandboxUnavailable is returned when a scanner must run in a container but can't,
+// and running it on the host isn't allowed
+var ErrSandboxUnavailable = errors.New("scanner sandbox is unavailable")
+
+// ToolRun describes one invocation of a scanner binary
+type ToolRun struct {
+	Tool    string
+	Args    []string
+	Dir     string   // Working directory, if any; mounted read-only into a sandbox
+	Mounts  []Mount  // Other host paths the tool reads or writes
+	Network bool     // Whether the tool needs to reach the network
+	Stdout  bool     // Capture only stdout; by default stdout and stderr are combined
+	Env     []string // Extra KEY=value environment, e.g. credentials; never logged
+}
+
+// Mount is a host path made visible to a sandboxed tool at the same path
+type Mount struct {
+	Path     string
+	Writable bool
+}
+
+// ToolRunner runs scanner binaries. A non-zero exit is returned as an error with an
+// ExitCode() int method, alongside whatever the tool printed.
+type ToolRunner interface {
+	// Available reports whether tool can be run; scanners fall back to mock output when it can't
+	Available(tool string) bool
+	Run(ctx context.Context, run ToolRun) ([]byte, error)
+}
+
+// NewToolRunner returns the runner selected by SCANNER_SANDBOX
+func NewToolRunner(cfg config.ScanningConfig) ToolRunner {
+	host := hostRunner{}
+	if cfg.Sandbox != "docker" {
+		return host
+	}
+
+	images := make(map[string]string, len(cfg.SandboxImages))
+	for _, entry := range cfg.SandboxImages {
+		if tool, image, ok := strings.Cut(entry, "="); ok {
+			images[strings.TrimSpace(tool)] = strings.TrimSpace(image)
+		}
+	}
+	docker, err := NewDockerClient(cfg.DockerHost)
+	if err != nil {
+		log.Printf("⚠️ Invalid DOCKER_HOST %q: %v", cfg.DockerHost, err)
+	}
+	return &sandboxRunner{
+		docker:   docker,
+		images:   images,
+		memory:   int64(cfg.SandboxMemoryMB) << 20,
+		nanoCPUs: int64(cfg.SandboxCPUs * 1e9),
+		network:  cfg.SandboxNetwork,
+		host:     host,
+		fallback: cfg.SandboxHostFallback,
+	}
+}
+
+// hostRunner runs tools directly on the host with exec
+type hostRunner struct{}
+
+func (hostRunner) Available(tool string) bool {
+	_, err := exec.LookPath(tool)
+	return err == nil
+}
+
+func (hostRunner) Run(ctx context.Context, run ToolRun) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, run.Tool, run.Args...)
+	cmd.Dir = run.Dir
+	// Don't wait forever on output pipes held open by a killed tool's children
+	cmd.WaitDelay = 5 * time.Second
+	if len(run.Env) > 0 {
+		cmd.Env = append(os.Environ(), run.Env...)
+	}
+	if run.Stdout {
+		return cmd.Output()
+	}
+	return cmd.CombinedOutput()
+}
+
+// sandboxRunner runs each tool in a short-lived container with resource limits, only the
+// paths it needs mounted, and no network unless it scans one
+type sandboxRunner struct {
+	docker   DockerClient
+	images   map[string]string // Tool -> image
+	memory   int64             // Bytes
+	nanoCPUs int64
+	network  string
+	host     ToolRunner
+	fallback bool
+}
+
+func (r *sandboxRunner) Available(tool string) bool {
+	if _, ok := r.images[tool]; ok {
+		return true
+	}
+	return r.fallback && r.host.Available(tool)
+}
+
+func (r *sandboxRunner) Run(ctx context.Context, run ToolRun) ([]byte, error) {
+	image, ok := r.images[run.Tool]
+	if !ok {
+		if !r.fallback {
+			return nil, fmt.Errorf("%w: no image configured for %s", ErrSandboxUnavailable, run.Tool)
+		}
+		return r.host.Run(ctx, run)
+	}
+
+	if err := r.ping(ctx); err != nil {
+		if !r.fallback {
+			return nil, fmt.Errorf("%w: %v", ErrSandboxUnavailable, err)
+		}
+		logf(ctx, "⚠️ Docker unreachable, running %s on the host: %v", run.Tool, err)
+		return r.host.Run(ctx, run)
+	}
+
+	return r.docker.Run(ctx, r.containerSpec(image, run))
+}
+
+func (r *sandboxRunner) ping(ctx context.Context) error {
+	if r.docker == nil {
+		return fmt.Errorf("no Docker client")
+	}
+	return r.docker.Ping(ctx)
+}
+
+// containerSpec maps a tool run onto a locked-down container
+func (r *sandboxRunner) containerSpec(image string, run ToolRun) ContainerSpec {
+	spec := ContainerSpec{
+		Image:      image,
+		Entrypoint: []string{run.Tool},
+		Cmd:        run.Args,
+		WorkingDir: run.Dir,
+		Memory:     r.memory,
+		NanoCPUs:   r.nanoCPUs,
+		Network:    "none",
+		Stdout:     run.Stdout,
+		Env:        run.Env,
+	}
+	if run.Network {
+		spec.Network = r.network
+	}
+	if run.Dir != "" {
+		spec.Binds = append(spec.Binds, run.Dir+":"+run.Dir+":ro")
+	}
+	for _, mount := range run.Mounts {
+		mode := "ro"
+		if mount.Writable {
+			mode = "rw"
+		}
+		spec.Binds = append(spec.Binds, mount.Path+":"+mount.Path+":"+mode)
+	}
+	return spec
+}
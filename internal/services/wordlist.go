@@ -0,0 +1,260 @@
+package services
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// DefaultWordlist is used when a gobuster scan doesn't name a wordlist
+const DefaultWordlist = "common"
+
+// maxWordlistSize caps uploaded wordlists
+const maxWordlistSize = 20 << 20
+
+//go:embed wordlists/common.txt
+var embeddedCommonWordlist []byte
+
+// systemWordlists are well-known wordlists that are offered when installed on the host
+var systemWordlists = map[string]string{
+	"dirb-common": "/usr/share/wordlists/dirb/common.txt",
+	"dirb-big":    "/usr/share/wordlists/dirb/big.txt",
+	"raft-small":  "/usr/share/seclists/Discovery/Web-Content/raft-small-directories.txt",
+	"raft-medium": "/usr/share/seclists/Discovery/Web-Content/raft-medium-directories.txt",
+}
+
+// wordlistNamePattern restricts wordlist names to a single safe path segment
+var wordlistNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]{0,63}$`)
+
+var (
+	ErrWordlistNotFound    = errors.New("wordlist not found")
+	ErrInvalidWordlistName = errors.New("wordlist name must be 1-64 letters, digits, '-' or '_'")
+	ErrWordlistExists      = errors.New("a bundled wordlist with this name already exists")
+	ErrWordlistTooLarge    = fmt.Errorf("wordlist exceeds %d MB", maxWordlistSize>>20)
+	ErrWordlistNotText     = errors.New("wordlist must be a plain text file")
+)
+
+// Wordlist describes a wordlist available to a user
+type Wordlist struct {
+	Name    string `json:"name"`
+	Source  string `json:"source"` // bundled, system or custom
+	Size    int64  `json:"size"`
+	Entries int    `json:"entries,omitempty"`
+}
+
+// WordlistRegistry resolves gobuster wordlist names to files on disk.
+// Names are the only thing callers pass around; paths are never accepted from users.
+type WordlistRegistry struct {
+	dir      string
+	bundleMu sync.Mutex
+}
+
+func NewWordlistRegistry(dir string) *WordlistRegistry {
+	return &WordlistRegistry{dir: dir}
+}
+
+// Resolve returns the file path for a wordlist name visible to the user.
+// Custom wordlists take the user's namespace; bundled and system names are shared.
+func (r *WordlistRegistry) Resolve(userID uuid.UUID, name string) (string, error) {
+	if name == "" {
+		name = DefaultWordlist
+	}
+
+	// Accept the legacy absolute path of a known system wordlist
+	for listName, path := range systemWordlists {
+		if name == path {
+			name = listName
+			break
+		}
+	}
+
+	if !wordlistNamePattern.MatchString(name) {
+		return "", r.unknownWordlist(userID, name)
+	}
+
+	if name == DefaultWordlist {
+		return r.ensureBundled()
+	}
+	if path, ok := systemWordlists[name]; ok {
+		if fileExists(path) {
+			return path, nil
+		}
+		return "", r.unknownWordlist(userID, name)
+	}
+
+	path, err := r.customPath(userID, name)
+	if err != nil {
+		return "", err
+	}
+	if !fileExists(path) {
+		return "", r.unknownWordlist(userID, name)
+	}
+	return path, nil
+}
+
+// List returns the wordlists the user can reference by name
+func (r *WordlistRegistry) List(userID uuid.UUID) ([]Wordlist, error) {
+	lists := []Wordlist{{
+		Name:    DefaultWordlist,
+		Source:  "bundled",
+		Size:    int64(len(embeddedCommonWordlist)),
+		Entries: bytes.Count(embeddedCommonWordlist, []byte("\n")),
+	}}
+
+	for name, path := range systemWordlists {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			lists = append(lists, Wordlist{Name: name, Source: "system", Size: info.Size()})
+		}
+	}
+
+	entries, err := os.ReadDir(r.userDir(userID))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read wordlists: %w", err)
+	}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".txt")
+		if !entry.Type().IsRegular() || !wordlistNamePattern.MatchString(name) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		lists = append(lists, Wordlist{Name: name, Source: "custom", Size: info.Size()})
+	}
+
+	sort.Slice(lists, func(i, j int) bool { return lists[i].Name < lists[j].Name })
+	return lists, nil
+}
+
+// Save stores an uploaded wordlist under the user's namespace, replacing any previous upload with the same name
+func (r *WordlistRegistry) Save(userID uuid.UUID, name string, content io.Reader) (*Wordlist, error) {
+	if !wordlistNamePattern.MatchString(name) {
+		return nil, ErrInvalidWordlistName
+	}
+	if _, ok := systemWordlists[name]; ok || name == DefaultWordlist {
+		return nil, ErrWordlistExists
+	}
+
+	data, err := io.ReadAll(io.LimitReader(content, maxWordlistSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wordlist: %w", err)
+	}
+	if len(data) > maxWordlistSize {
+		return nil, ErrWordlistTooLarge
+	}
+	if len(bytes.TrimSpace(data)) == 0 || bytes.IndexByte(data, 0) >= 0 {
+		return nil, ErrWordlistNotText
+	}
+
+	path, err := r.customPath(userID, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create wordlist directory: %w", err)
+	}
+
+	// Write to a temp file first so a scan never reads a half-written list
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to store wordlist: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to store wordlist: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to store wordlist: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, fmt.Errorf("failed to store wordlist: %w", err)
+	}
+
+	return &Wordlist{
+		Name:    name,
+		Source:  "custom",
+		Size:    int64(len(data)),
+		Entries: bytes.Count(data, []byte("\n")),
+	}, nil
+}
+
+// Delete removes one of the user's custom wordlists
+func (r *WordlistRegistry) Delete(userID uuid.UUID, name string) error {
+	if !wordlistNamePattern.MatchString(name) {
+		return ErrInvalidWordlistName
+	}
+	path, err := r.customPath(userID, name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return r.unknownWordlist(userID, name)
+		}
+		return fmt.Errorf("failed to delete wordlist: %w", err)
+	}
+	return nil
+}
+
+// ensureBundled writes the embedded default wordlist to disk on first use so gobuster can read it
+func (r *WordlistRegistry) ensureBundled() (string, error) {
+	r.bundleMu.Lock()
+	defer r.bundleMu.Unlock()
+
+	path := filepath.Join(r.dir, "bundled", DefaultWordlist+".txt")
+	if info, err := os.Stat(path); err == nil && info.Size() == int64(len(embeddedCommonWordlist)) {
+		return path, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return "", fmt.Errorf("failed to create wordlist directory: %w", err)
+	}
+	if err := os.WriteFile(path, embeddedCommonWordlist, 0o640); err != nil {
+		return "", fmt.Errorf("failed to write bundled wordlist: %w", err)
+	}
+	return path, nil
+}
+
+func (r *WordlistRegistry) userDir(userID uuid.UUID) string {
+	return filepath.Join(r.dir, "users", userID.String())
+}
+
+// customPath builds the path for a user's wordlist and verifies it stays inside the user's directory
+func (r *WordlistRegistry) customPath(userID uuid.UUID, name string) (string, error) {
+	base, err := filepath.Abs(r.userDir(userID))
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(base, name+".txt")
+	if filepath.Dir(path) != base {
+		return "", ErrInvalidWordlistName
+	}
+	return path, nil
+}
+
+// unknownWordlist builds an error naming the wordlists that are available
+func (r *WordlistRegistry) unknownWordlist(userID uuid.UUID, name string) error {
+	var names []string
+	if lists, err := r.List(userID); err == nil {
+		for _, list := range lists {
+			names = append(names, list.Name)
+		}
+	}
+	return fmt.Errorf("%w: %q (available: %s)", ErrWordlistNotFound, name, strings.Join(names, ", "))
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// traversalNames are wordlist names that try to reach files outside the user's directory
+var traversalNames = []string{
+	"../secret",
+	"../../users/other/list",
+	"/etc/passwd",
+	"..",
+	".hidden",
+	"a/b",
+	`a\b`,
+	"list.txt",
+	"list\x00",
+	"name with spaces",
+	strings.Repeat("a", 65),
+}
+
+func TestWordlistRegistryResolveRejectsTraversal(t *testing.T) {
+	dir := t.TempDir()
+	r := NewWordlistRegistry(dir)
+	userID := uuid.New()
+	if err := os.MkdirAll(filepath.Join(dir, "users"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	// A file "../secret" would reach if names were joined into paths unchecked
+	if err := os.WriteFile(filepath.Join(dir, "users", "secret.txt"), []byte("secret\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range traversalNames {
+		t.Run(name, func(t *testing.T) {
+			path, err := r.Resolve(userID, name)
+			if !errors.Is(err, ErrWordlistNotFound) {
+				t.Fatalf("Resolve(%q) = %q, %v; want ErrWordlistNotFound", name, path, err)
+			}
+		})
+	}
+}
+
+func TestWordlistRegistrySaveAndDeleteRejectTraversal(t *testing.T) {
+	dir := t.TempDir()
+	r := NewWordlistRegistry(dir)
+	userID := uuid.New()
+
+	for _, name := range traversalNames {
+		t.Run(name, func(t *testing.T) {
+			if _, err := r.Save(userID, name, strings.NewReader("admin\n")); !errors.Is(err, ErrInvalidWordlistName) {
+				t.Fatalf("Save(%q) = %v, want ErrInvalidWordlistName", name, err)
+			}
+			if err := r.Delete(userID, name); !errors.Is(err, ErrInvalidWordlistName) {
+				t.Fatalf("Delete(%q) = %v, want ErrInvalidWordlistName", name, err)
+			}
+		})
+	}
+
+	// Nothing may have been written anywhere
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			t.Errorf("rejected upload left %s behind", path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWordlistRegistryCustomListsStayPrivate(t *testing.T) {
+	r := NewWordlistRegistry(t.TempDir())
+	owner, other := uuid.New(), uuid.New()
+
+	if _, err := r.Save(owner, "mine", strings.NewReader("admin\nlogin\n")); err != nil {
+		t.Fatal(err)
+	}
+	path, err := r.Resolve(owner, "mine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(path) != r.userDir(owner) {
+		t.Fatalf("custom wordlist resolved outside the owner's directory: %s", path)
+	}
+
+	if _, err := r.Resolve(other, "mine"); !errors.Is(err, ErrWordlistNotFound) {
+		t.Fatalf("another user resolved the wordlist: %v", err)
+	}
+	if err := r.Delete(other, "mine"); !errors.Is(err, ErrWordlistNotFound) {
+		t.Fatalf("another user deleted the wordlist: %v", err)
+	}
+	if _, err := r.Resolve(owner, "mine"); err != nil {
+		t.Fatalf("owner lost the wordlist: %v", err)
+	}
+}
+
+func TestWordlistRegistrySaveRejectsSharedNames(t *testing.T) {
+	r := NewWordlistRegistry(t.TempDir())
+	for _, name := range []string{DefaultWordlist, "dirb-common"} {
+		if _, err := r.Save(uuid.New(), name, strings.NewReader("admin\n")); !errors.Is(err, ErrWordlistExists) {
+			t.Fatalf("Save(%q) = %v, want ErrWordlistExists", name, err)
+		}
+	}
+}
@@ -1,22 +1,39 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/datmedevil17/go-vuln/internal/config"
 	"github.com/datmedevil17/go-vuln/internal/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+var (
+	// ErrUnknownResultNode is returned when ?since= names a node that hasn't recorded a result
+	ErrUnknownResultNode = errors.New("no result recorded for that node")
+
+	// ErrExecutionFinished is returned when cancelling an execution that has already finished
+	ErrExecutionFinished = errors.New("execution has already finished")
+
+	// ErrExecutionCancelled is the cause recorded on the context of an execution cancelled by its owner
+	ErrExecutionCancelled = errors.New("execution cancelled")
+)
+
 type WorkflowService struct {
 	db       *gorm.DB
 	executor *WorkflowExecutor
+	limits   config.WorkflowConfig
 }
 
-func NewWorkflowService(db *gorm.DB, scannerService *ScannerService, notificationService *NotificationService, aiService *AIService, githubService *GitHubService) *WorkflowService {
+func NewWorkflowService(db *gorm.DB, scannerService *ScannerService, notificationService *NotificationService, notificationQueue *NotificationQueue, secrets *SecretStore, aiService *AIService, githubService *GitHubService, siem *SIEMExporter, webhooks *CompletionWebhooks, limits config.WorkflowConfig) *WorkflowService {
 	return &WorkflowService{
 		db:       db,
-		executor: NewWorkflowExecutor(db, scannerService, notificationService, aiService, githubService),
+		limits:   limits,
+		executor: NewWorkflowExecutor(db, scannerService, notificationService, notificationQueue, secrets, aiService, githubService, siem, webhooks, limits),
 	}
 }
 
@@ -48,7 +65,7 @@ func (s *WorkflowService) GetWorkflow(workflowID, userID uuid.UUID) (*models.Wor
 // ListWorkflows retrieves all workflows for a user
 func (s *WorkflowService) ListWorkflows(userID uuid.UUID) ([]models.Workflow, error) {
 	var workflows []models.Workflow
-	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&workflows).Error; err != nil {
+	if err := s.db.Where("user_id = ?", userID).Order("pinned DESC, created_at DESC").Find(&workflows).Error; err != nil {
 		return nil, err
 	}
 	return workflows, nil
@@ -60,14 +77,140 @@ func (s *WorkflowService) UpdateWorkflow(workflowID, userID uuid.UUID, updates m
 	if err := s.db.Where("id = ? AND user_id = ?", workflowID, userID).First(&workflow).Error; err != nil {
 		return nil, err
 	}
+	if err := s.checkGraphUpdate(&workflow, updates); err != nil {
+		return nil, err
+	}
+	if webhookURL, ok := updates["webhook_url"].(string); ok && webhookURL != "" && !isHTTPURL(webhookURL) {
+		return nil, fmt.Errorf("%w: webhook_url must be an http or https URL", ErrInvalidWebhook)
+	}
+	if policy, ok := updates["concurrency_policy"].(string); ok && !isConcurrencyPolicy(policy) {
+		return nil, fmt.Errorf("%w: concurrency_policy must be one of %v", ErrInvalidConcurrencyPolicy, ConcurrencyPolicies)
+	}
 
-	if err := s.db.Model(&workflow).Updates(updates).Error; err != nil {
-		return nil, fmt.Errorf("failed to update workflow: %w", err)
+	// Schedule changes are validated after applying them, so roll back if the result is unusable
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&workflow).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update workflow: %w", err)
+		}
+		if !schedulingChanged(updates) {
+			return nil
+		}
+		if err := tx.First(&workflow, "id = ?", workflow.ID).Error; err != nil {
+			return err
+		}
+		return refreshNextRun(tx, &workflow)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &workflow, nil
 }
 
+// checkGraphUpdate enforces the graph size limits on the nodes and edges the workflow would
+// have after updates, and that new nodes have unique IDs
+func (s *WorkflowService) checkGraphUpdate(workflow *models.Workflow, updates map[string]interface{}) error {
+	nodes, nodesChanged := updates["nodes"].(models.JSONArray)
+	edges, edgesChanged := updates["edges"].(models.JSONArray)
+	if !nodesChanged && !edgesChanged {
+		return nil
+	}
+	if nodesChanged {
+		if err := checkNodeIDs(nodes); err != nil {
+			return err
+		}
+	}
+	if !nodesChanged {
+		nodes = workflow.Nodes
+	}
+	if !edgesChanged {
+		edges = workflow.Edges
+	}
+	return checkGraphSize(s.limits, len(nodes), len(edges))
+}
+
+// scheduleFields are the columns that affect when a workflow next runs
+var scheduleFields = []string{"schedule_enabled", "schedule_frequency", "schedule_time", "schedule_day", "schedule_timezone"}
+
+func schedulingChanged(updates map[string]interface{}) bool {
+	for _, field := range scheduleFields {
+		if _, ok := updates[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshNextRun recomputes next_run from the workflow's schedule, clearing it when scheduling is off
+func refreshNextRun(db *gorm.DB, workflow *models.Workflow) error {
+	var nextRun *time.Time
+	if workflow.ScheduleEnabled {
+		var owner models.User
+		if err := db.First(&owner, "id = ?", workflow.UserID).Error; err != nil {
+			return fmt.Errorf("failed to load workflow owner: %w", err)
+		}
+		schedule, err := workflowSchedule(workflow, owner.Preferences)
+		if err != nil {
+			return err
+		}
+		next := schedule.Next(time.Now()).UTC()
+		nextRun = &next
+	}
+
+	if err := db.Model(workflow).Update("next_run", nextRun).Error; err != nil {
+		return fmt.Errorf("failed to update next run: %w", err)
+	}
+	workflow.NextRun = nextRun
+	return nil
+}
+
+// SetBaseline marks a completed execution as the approved baseline for its workflow
+func (s *WorkflowService) SetBaseline(workflowID, userID, executionID uuid.UUID) (*models.Workflow, error) {
+	workflow, err := s.GetWorkflow(workflowID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	execution, err := s.GetWorkflowExecution(executionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if execution.WorkflowID != workflowID {
+		return nil, fmt.Errorf("execution does not belong to this workflow")
+	}
+	if execution.Status != "completed" {
+		return nil, fmt.Errorf("only completed executions can be used as a baseline")
+	}
+
+	if err := s.db.Model(workflow).Update("baseline_execution_id", executionID).Error; err != nil {
+		return nil, fmt.Errorf("failed to set baseline: %w", err)
+	}
+
+	return workflow, nil
+}
+
+// ToggleWorkflowPin flips the pinned flag on a workflow and returns the new value
+func (s *WorkflowService) ToggleWorkflowPin(workflowID, userID uuid.UUID) (bool, error) {
+	return togglePinned(s.db, &models.Workflow{}, workflowID, userID)
+}
+
+// togglePinned flips the pinned column of a user-owned record
+func togglePinned(db *gorm.DB, model interface{}, id, userID uuid.UUID) (bool, error) {
+	result := db.Model(model).Where("id = ? AND user_id = ?", id, userID).Update("pinned", gorm.Expr("NOT pinned"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, gorm.ErrRecordNotFound
+	}
+
+	var pinned bool
+	if err := db.Model(model).Where("id = ?", id).Select("pinned").Scan(&pinned).Error; err != nil {
+		return false, err
+	}
+	return pinned, nil
+}
+
 // DeleteWorkflow deletes a workflow
 func (s *WorkflowService) DeleteWorkflow(workflowID, userID uuid.UUID) error {
 	result := s.db.Where("id = ? AND user_id = ?", workflowID, userID).Delete(&models.Workflow{})
@@ -81,32 +224,218 @@ func (s *WorkflowService) DeleteWorkflow(workflowID, userID uuid.UUID) error {
 }
 
 // ExecuteWorkflow executes a workflow asynchronously
-func (s *WorkflowService) ExecuteWorkflow(workflow *models.Workflow, userID uuid.UUID) (*models.WorkflowExecution, error) {
-	return s.executor.Execute(workflow, userID)
+func (s *WorkflowService) ExecuteWorkflow(ctx context.Context, workflow *models.Workflow, userID uuid.UUID, labels ExecutionLabels) (*models.WorkflowExecution, error) {
+	return s.executor.Execute(ctx, workflow, userID, labels)
+}
+
+// ActiveExecutions lists executions that are currently pending or running, across all users
+func (s *WorkflowService) ActiveExecutions() []ActiveExecution {
+	return s.executor.ActiveExecutions()
+}
+
+// ExecutionLimits reports the per-user execution limit and who is using it
+func (s *WorkflowService) ExecutionLimits() ExecutionLimits {
+	return s.executor.ExecutionLimits()
+}
+
+// GetWorkflowExecution retrieves a single execution owned by the user.
+// The workflow name comes from the execution's snapshot so edits made after the
+// run don't change how it is presented.
+func (s *WorkflowService) GetWorkflowExecution(executionID, userID uuid.UUID) (*models.WorkflowExecution, error) {
+	var execution models.WorkflowExecution
+	if err := s.db.Where("id = ? AND user_id = ?", executionID, userID).First(&execution).Error; err != nil {
+		return nil, err
+	}
+
+	if execution.Snapshot != nil {
+		execution.Name = execution.Snapshot.Name
+	}
+	if execution.StartedAt != nil && execution.CompletedAt != nil {
+		execution.Duration = execution.CompletedAt.Sub(*execution.StartedAt).Milliseconds()
+	}
+
+	return &execution, nil
+}
+
+// GetExecutionLogs returns the log lines of an execution owned by the user, live while it is still running
+func (s *WorkflowService) GetExecutionLogs(executionID, userID uuid.UUID) (*models.ExecutionLog, error) {
+	execution, err := s.GetWorkflowExecution(executionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if logs, ok := s.executor.LiveLogs(executionID); ok {
+		return logs, nil
+	}
+	if execution.Logs == nil {
+		return &models.ExecutionLog{Entries: []models.ExecutionLogEntry{}}, nil
+	}
+	return execution.Logs, nil
+}
+
+// ExecutionResultsPage holds the node results an execution recorded after a given node
+type ExecutionResultsPage struct {
+	Status   string                 `json:"status"`
+	Progress int                    `json:"progress"`
+	Nodes    []string               `json:"nodes"` // Node IDs in Results, in the order they finished
+	Results  map[string]interface{} `json:"results"`
+	Cursor   string                 `json:"cursor,omitempty"` // Pass as ?since= to fetch only later results
+}
+
+// GetExecutionResults returns the results recorded after node since, or all of them when since
+// is empty, so polling clients only download what is new. Entries written when the run ends,
+// such as the AI report or nodes skipped at a timeout, are included whenever they exist.
+func (s *WorkflowService) GetExecutionResults(executionID, userID uuid.UUID, since string) (*ExecutionResultsPage, error) {
+	execution, err := s.GetWorkflowExecution(executionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	order := execution.NodeOrder
+	if since != "" {
+		found := false
+		for i, nodeID := range order {
+			if nodeID == since {
+				order, found = order[i+1:], true
+				break
+			}
+		}
+		if !found {
+			return nil, ErrUnknownResultNode
+		}
+	}
+
+	page := &ExecutionResultsPage{
+		Status:   execution.Status,
+		Progress: execution.Progress,
+		Nodes:    []string{},
+		Results:  make(map[string]interface{}),
+		Cursor:   since,
+	}
+	for _, nodeID := range order {
+		if result, ok := execution.Results[nodeID]; ok {
+			page.Nodes = append(page.Nodes, nodeID)
+			page.Results[nodeID] = result
+			page.Cursor = nodeID
+		}
+	}
+
+	recorded := make(map[string]struct{}, len(execution.NodeOrder))
+	for _, nodeID := range execution.NodeOrder {
+		recorded[nodeID] = struct{}{}
+	}
+	for key, result := range execution.Results {
+		if _, ok := recorded[key]; !ok {
+			page.Results[key] = result
+		}
+	}
+	return page, nil
 }
 
-// ListWorkflowExecutions retrieves all workflow executions for a user with workflow names
-func (s *WorkflowService) ListWorkflowExecutions(userID uuid.UUID) ([]models.WorkflowExecution, error) {
-	var executions []models.WorkflowExecution
+// ReplayExecution starts a new execution from the snapshot of a previous one
+func (s *WorkflowService) ReplayExecution(ctx context.Context, executionID, userID uuid.UUID) (*models.WorkflowExecution, error) {
+	original, err := s.GetWorkflowExecution(executionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.executor.Replay(ctx, original)
+}
+
+// CancelExecution stops a queued or running execution owned by the user
+func (s *WorkflowService) CancelExecution(executionID, userID uuid.UUID) error {
+	execution, err := s.GetWorkflowExecution(executionID, userID)
+	if err != nil {
+		return err
+	}
+	if !s.executor.Cancel(execution) {
+		return ErrExecutionFinished
+	}
+	return nil
+}
+
+// DeleteWorkflowExecutions bulk-deletes the user's executions by ID or filter
+func (s *WorkflowService) DeleteWorkflowExecutions(userID uuid.UUID, ids []uuid.UUID, filter BulkDeleteFilter) (int64, error) {
+	return bulkDelete(s.db, &models.WorkflowExecution{}, userID, ids, filter, ExecutionDeletableStatuses, func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("pinned = ?", false)
+	})
+}
+
+// ToggleExecutionPin flips the pinned flag on an execution and returns the new value
+func (s *WorkflowService) ToggleExecutionPin(executionID, userID uuid.UUID) (bool, error) {
+	return togglePinned(s.db, &models.WorkflowExecution{}, executionID, userID)
+}
+
+const (
+	defaultExecutionPageSize = 50
+	maxExecutionPageSize     = 200
+)
+
+// ExecutionListFilter narrows and pages the executions listed for a user
+type ExecutionListFilter struct {
+	Tags       []string // Keep executions carrying every one of these
+	Status     string
+	WorkflowID *uuid.UUID
+	Limit      int // Defaults to 50, at most 200
+	Offset     int
+}
+
+// ExecutionList is one page of a user's executions, newest first
+type ExecutionList struct {
+	Executions []models.WorkflowExecution `json:"executions"`
+	Total      int64                      `json:"total"` // Executions matching the filter across all pages
+	Limit      int                        `json:"limit"`
+	Offset     int                        `json:"offset"`
+}
+
+// ListWorkflowExecutions retrieves a page of the user's executions matching filter, with
+// workflow names and the total the filter matches
+func (s *WorkflowService) ListWorkflowExecutions(userID uuid.UUID, filter ExecutionListFilter) (*ExecutionList, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultExecutionPageSize
+	}
+	if filter.Limit > maxExecutionPageSize {
+		filter.Limit = maxExecutionPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	matching := func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Table("workflow_executions").
+			Where("workflow_executions.user_id = ?", userID).
+			Scopes(withTags(filter.Tags))
+		if filter.Status != "" {
+			tx = tx.Where("workflow_executions.status = ?", filter.Status)
+		}
+		if filter.WorkflowID != nil {
+			tx = tx.Where("workflow_executions.workflow_id = ?", *filter.WorkflowID)
+		}
+		return tx
+	}
+
+	list := &ExecutionList{Executions: []models.WorkflowExecution{}, Limit: filter.Limit, Offset: filter.Offset}
+	if err := s.db.Scopes(matching).Count(&list.Total).Error; err != nil {
+		return nil, err
+	}
 
 	// Use a join to get the workflow name
-	err := s.db.Table("workflow_executions").
+	err := s.db.Scopes(matching).
 		Select("workflow_executions.*, workflows.name as name").
 		Joins("left join workflows on workflows.id = workflow_executions.workflow_id").
-		Where("workflow_executions.user_id = ?", userID).
 		Order("workflow_executions.created_at DESC").
-		Scan(&executions).Error
-
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Scan(&list.Executions).Error
 	if err != nil {
 		return nil, err
 	}
 
 	// Calculate durations
-	for i := range executions {
-		if executions[i].StartedAt != nil && executions[i].CompletedAt != nil {
-			executions[i].Duration = executions[i].CompletedAt.Sub(*executions[i].StartedAt).Milliseconds()
+	for i := range list.Executions {
+		execution := &list.Executions[i]
+		if execution.StartedAt != nil && execution.CompletedAt != nil {
+			execution.Duration = execution.CompletedAt.Sub(*execution.StartedAt).Milliseconds()
 		}
 	}
 
-	return executions, nil
+	return list, nil
 }
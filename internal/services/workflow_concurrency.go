@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// What happens to a run of a workflow that already has one in progress
+const (
+	ConcurrencyReject = "reject" // Refuse it with ErrWorkflowRunning
+	ConcurrencySkip   = "skip"   // Record it as skipped without running it
+	ConcurrencyQueue  = "queue"  // Run it once the current run finishes; further runs join the queued one
+	ConcurrencyAllow  = "allow"  // Run it alongside the current run
+)
+
+// ConcurrencyPolicies lists the policies a workflow may set
+var ConcurrencyPolicies = []string{ConcurrencyReject, ConcurrencySkip, ConcurrencyQueue, ConcurrencyAllow}
+
+var (
+	// ErrWorkflowRunning is returned when a run is refused because the workflow already has one in progress
+	ErrWorkflowRunning = errors.New("workflow already has a run in progress")
+
+	// ErrInvalidConcurrencyPolicy is returned for a concurrency_policy outside ConcurrencyPolicies
+	ErrInvalidConcurrencyPolicy = errors.New("invalid concurrency policy")
+)
+
+// staleRunAfter is how long a run without a time budget counts as in progress. A server that
+// stopped mid-run leaves its executions running forever, and they mustn't block the workflow.
+const staleRunAfter = 24 * time.Hour
+
+func isConcurrencyPolicy(policy string) bool {
+	for _, p := range ConcurrencyPolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// lockWorkflow takes a transaction-scoped Postgres advisory lock on the workflow, so server
+// instances launching runs of it at the same moment see each other's executions
+func lockWorkflow(tx *gorm.DB, workflowID uuid.UUID) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "workflow:"+workflowID.String()).Error
+}
+
+// activeRun returns the workflow's oldest run in progress, or nil when it has none
+func activeRun(tx *gorm.DB, workflowID uuid.UUID, maxDuration int) (*models.WorkflowExecution, error) {
+	window := staleRunAfter
+	if maxDuration > 0 {
+		// Runs time out on their own after maxDuration; the grace covers the final AI report
+		window = time.Duration(maxDuration)*time.Second + 5*time.Minute
+	}
+
+	var active models.WorkflowExecution
+	err := tx.Where("workflow_id = ? AND status IN ? AND COALESCE(started_at, created_at) > ?", workflowID, []string{"pending", "running"}, time.Now().Add(-window)).
+		Order("created_at").First(&active).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &active, nil
+}
+
+// admitRun decides, under the workflow lock, how a new run starts: "pending" to run now,
+// "queued" or "skipped", or an already queued run it joins. It fails with ErrWorkflowRunning
+// under the reject policy.
+func admitRun(tx *gorm.DB, workflowID uuid.UUID, maxDuration int) (string, *models.WorkflowExecution, error) {
+	if err := lockWorkflow(tx, workflowID); err != nil {
+		return "", nil, err
+	}
+
+	// Read the stored policy rather than the caller's copy, which for replays is a snapshot without one
+	var workflow models.Workflow
+	if err := tx.Select("id", "concurrency_policy").First(&workflow, "id = ?", workflowID).Error; err != nil && err != gorm.ErrRecordNotFound {
+		return "", nil, err
+	}
+	if workflow.ConcurrencyPolicy == ConcurrencyAllow {
+		return "pending", nil, nil
+	}
+
+	active, err := activeRun(tx, workflowID, maxDuration)
+	if err != nil || active == nil {
+		return "pending", nil, err
+	}
+
+	switch workflow.ConcurrencyPolicy {
+	case ConcurrencySkip:
+		return "skipped", nil, nil
+	case ConcurrencyQueue:
+		var queued models.WorkflowExecution
+		err := tx.Where("workflow_id = ? AND status = ?", workflowID, "queued").Order("created_at").First(&queued).Error
+		if err == nil {
+			return "", &queued, nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return "", nil, err
+		}
+		return "queued", nil, nil
+	default:
+		return "", nil, fmt.Errorf("%w (execution %s)", ErrWorkflowRunning, active.ID)
+	}
+}
+
+// startQueued starts the workflow's oldest queued run, if any, once the current one has finished
+func (e *WorkflowExecutor) startQueued(workflowID uuid.UUID) {
+	var next models.WorkflowExecution
+	started := false
+	err := e.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockWorkflow(tx, workflowID); err != nil {
+			return err
+		}
+		if err := tx.Where("workflow_id = ? AND status = ?", workflowID, "queued").Order("created_at").First(&next).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			return err
+		}
+		// A run allowed alongside, or launched by another instance, may hold the workflow already;
+		// whichever finishes last starts the queued run
+		active, err := activeRun(tx, workflowID, next.Snapshot.MaxDuration)
+		if err != nil || active != nil {
+			return err
+		}
+		started = true
+		now := time.Now()
+		next.Status = "pending"
+		next.HeartbeatAt = &now
+		// The heartbeat keeps the execution monitor from taking a long-queued run for an orphan
+		return tx.Model(&next).Updates(map[string]interface{}{"status": "pending", "heartbeat_at": now}).Error
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to start queued run of workflow %s: %v", workflowID, err)
+		return
+	}
+	if started {
+		e.dispatch(context.Background(), &next, workflowFromSnapshot(&next))
+	}
+}
+
+// workflowFromSnapshot rebuilds the workflow definition an execution was started with
+func workflowFromSnapshot(execution *models.WorkflowExecution) *models.Workflow {
+	return &models.Workflow{
+		ID:                  execution.WorkflowID,
+		UserID:              execution.UserID,
+		Name:                execution.Snapshot.Name,
+		Nodes:               execution.Snapshot.Nodes,
+		Edges:               execution.Snapshot.Edges,
+		MaxDuration:         execution.Snapshot.MaxDuration,
+		StopOnCritical:      execution.Snapshot.StopOnCritical,
+		BaselineExecutionID: execution.Snapshot.BaselineExecutionID,
+		FailOnNewFindings:   execution.Snapshot.FailOnNewFindings,
+	}
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+// expectAdmission expects admitRun's lock and policy lookup, and the in-progress run it finds
+func expectAdmission(mock sqlmock.Sqlmock, workflowID uuid.UUID, policy string, active *uuid.UUID) {
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(hashtext\(\$1\)\)`).
+		WithArgs("workflow:" + workflowID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT "id","concurrency_policy" FROM "workflows" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "concurrency_policy"}).AddRow(workflowID, policy))
+	if policy == ConcurrencyAllow {
+		return
+	}
+	rows := sqlmock.NewRows([]string{"id", "workflow_id", "status"})
+	if active != nil {
+		rows.AddRow(*active, workflowID, "running")
+	}
+	mock.ExpectQuery(`SELECT \* FROM "workflow_executions" WHERE workflow_id = \$1 AND status IN \(\$2,\$3\) AND COALESCE\(started_at, created_at\) > \$4 ORDER BY created_at`).
+		WithArgs(workflowID, "pending", "running", sqlmock.AnyArg(), 1).
+		WillReturnRows(rows)
+}
+
+func TestAdmitRun(t *testing.T) {
+	running := uuid.New()
+	tests := []struct {
+		name       string
+		policy     string
+		active     *uuid.UUID
+		queued     bool // Whether a run is already queued, for the queue policy
+		wantStatus string
+		wantJoin   bool
+		wantErr    error
+	}{
+		{name: "reject, nothing running", policy: ConcurrencyReject, wantStatus: "pending"},
+		{name: "reject while running", policy: ConcurrencyReject, active: &running, wantErr: ErrWorkflowRunning},
+		{name: "default policy while running", policy: "", active: &running, wantErr: ErrWorkflowRunning},
+		{name: "skip, nothing running", policy: ConcurrencySkip, wantStatus: "pending"},
+		{name: "skip while running", policy: ConcurrencySkip, active: &running, wantStatus: "skipped"},
+		{name: "queue while running", policy: ConcurrencyQueue, active: &running, wantStatus: "queued"},
+		{name: "queue behind a queued run", policy: ConcurrencyQueue, active: &running, queued: true, wantJoin: true},
+		{name: "allow", policy: ConcurrencyAllow, wantStatus: "pending"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			workflowID := uuid.New()
+			expectAdmission(mock, workflowID, tt.policy, tt.active)
+			queuedID := uuid.New()
+			if tt.policy == ConcurrencyQueue && tt.active != nil {
+				rows := sqlmock.NewRows([]string{"id", "workflow_id", "status"})
+				if tt.queued {
+					rows.AddRow(queuedID, workflowID, "queued")
+				}
+				mock.ExpectQuery(`SELECT \* FROM "workflow_executions" WHERE workflow_id = \$1 AND status = \$2 ORDER BY created_at`).
+					WithArgs(workflowID, "queued", 1).
+					WillReturnRows(rows)
+			}
+
+			status, joined, err := admitRun(db, workflowID, 0)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("admitRun() = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantJoin {
+				if joined == nil || joined.ID != queuedID {
+					t.Fatalf("admitRun() joined %v, want the queued run %s", joined, queuedID)
+				}
+				return
+			}
+			if status != tt.wantStatus || joined != nil {
+				t.Fatalf("admitRun() = %q, %v, want %q", status, joined, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestLaunchWhileRunning(t *testing.T) {
+	t.Run("rejected", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		e := &WorkflowExecutor{db: db}
+		workflow := testWorkflow(nil, nil)
+		running := uuid.New()
+		mock.ExpectBegin()
+		expectAdmission(mock, workflow.ID, ConcurrencyReject, &running)
+		mock.ExpectRollback()
+
+		// Nothing is recorded: the mock fails the test on an insert
+		_, err := e.Execute(context.Background(), workflow, workflow.UserID, ExecutionLabels{})
+		if !errors.Is(err, ErrWorkflowRunning) {
+			t.Fatalf("Execute() = %v, want ErrWorkflowRunning", err)
+		}
+	})
+
+	t.Run("skipped", func(t *testing.T) {
+		db, mock := newMockDB(t)
+		e := &WorkflowExecutor{db: db}
+		workflow := testWorkflow(nil, nil)
+		running := uuid.New()
+		mock.ExpectBegin()
+		expectAdmission(mock, workflow.ID, ConcurrencySkip, &running)
+		mock.ExpectQuery(`INSERT INTO "workflow_executions"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectCommit()
+
+		// A skipped run is recorded but never claimed or started
+		execution, err := e.Execute(context.Background(), workflow, workflow.UserID, ExecutionLabels{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if execution.Status != "skipped" || execution.CompletedAt == nil || execution.Error == "" {
+			t.Fatalf("execution recorded as %s at %v (%q), want skipped and finished", execution.Status, execution.CompletedAt, execution.Error)
+		}
+	})
+}
@@ -1,14 +1,21 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"net/url"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/datmedevil17/go-vuln/internal/config"
 	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/datmedevil17/go-vuln/internal/utils"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -17,17 +24,38 @@ type WorkflowExecutor struct {
 	db                  *gorm.DB
 	scannerService      *ScannerService
 	notificationService *NotificationService
+	notificationQueue   *NotificationQueue
+	secrets             *SecretStore
 	aiService           *AIService
 	githubService       *GitHubService
+	jiraService         *JiraService
+	siem                *SIEMExporter
+	webhooks            *CompletionWebhooks
+	webhookClient       *http.Client
+	active              *executionRegistry
+	dispatcher          *ExecutionDispatcher // Shares pending executions between servers; nil runs them here
+	slots               *executionSlots
+	nodeTimeout         time.Duration // Default limit on each node's run time (0 = none)
+	maxParallelNodes    int           // Nodes of one execution that may run at once
 }
 
-func NewWorkflowExecutor(db *gorm.DB, scannerService *ScannerService, notificationService *NotificationService, aiService *AIService, githubService *GitHubService) *WorkflowExecutor {
+func NewWorkflowExecutor(db *gorm.DB, scannerService *ScannerService, notificationService *NotificationService, notificationQueue *NotificationQueue, secrets *SecretStore, aiService *AIService, githubService *GitHubService, siem *SIEMExporter, webhooks *CompletionWebhooks, limits config.WorkflowConfig) *WorkflowExecutor {
 	return &WorkflowExecutor{
 		db:                  db,
 		scannerService:      scannerService,
 		notificationService: notificationService,
+		notificationQueue:   notificationQueue,
+		secrets:             secrets,
 		aiService:           aiService,
 		githubService:       githubService,
+		jiraService:         NewJiraService(),
+		siem:                siem,
+		webhooks:            webhooks,
+		webhookClient:       utils.NewSafeHTTPClient(30 * time.Second),
+		active:              newExecutionRegistry(),
+		slots:               newExecutionSlots(limits.MaxConcurrent, limits.MaxConcurrentPerUser),
+		nodeTimeout:         limits.NodeTimeout,
+		maxParallelNodes:    limits.MaxParallelNodes,
 	}
 }
 
@@ -37,6 +65,8 @@ type WorkflowNode struct {
 	Type     string                 `json:"type"`
 	Data     map[string]interface{} `json:"data"`
 	Position map[string]interface{} `json:"position"`
+
+	upstream []string // IDs of the nodes this one depends on, nearest first; set when the workflow runs
 }
 
 // WorkflowEdge represents an edge in the workflow graph
@@ -46,31 +76,212 @@ type WorkflowEdge struct {
 	Target string `json:"target"`
 }
 
-// Execute runs a workflow asynchronously
-func (e *WorkflowExecutor) Execute(workflow *models.Workflow, userID uuid.UUID) (*models.WorkflowExecution, error) {
+// Execute runs a workflow asynchronously; the run logs with the fields of ctx's logger
+func (e *WorkflowExecutor) Execute(ctx context.Context, workflow *models.Workflow, userID uuid.UUID, labels ExecutionLabels) (*models.WorkflowExecution, error) {
+	labels, err := labels.normalize()
+	if err != nil {
+		return nil, err
+	}
+	return e.launch(ctx, workflow, userID, nil, labels)
+}
+
+// Replay re-runs a past execution using the workflow snapshot it was started with
+func (e *WorkflowExecutor) Replay(ctx context.Context, original *models.WorkflowExecution) (*models.WorkflowExecution, error) {
+	if original.Snapshot == nil {
+		return nil, fmt.Errorf("execution %s has no workflow snapshot to replay", original.ID)
+	}
+
+	workflow := workflowFromSnapshot(original)
+
+	// Replays keep the original's tags so they show up under the same filters
+	return e.launch(ctx, workflow, original.UserID, &original.ID, ExecutionLabels{Tags: original.Tags})
+}
+
+// launch records a new execution with a snapshot of the workflow and starts it, unless the
+// workflow's concurrency policy queues, skips or rejects it because a run is in progress
+func (e *WorkflowExecutor) launch(ctx context.Context, workflow *models.Workflow, userID uuid.UUID, replayedFrom *uuid.UUID, labels ExecutionLabels) (*models.WorkflowExecution, error) {
 	// Create execution record
 	execution := &models.WorkflowExecution{
 		WorkflowID: workflow.ID,
 		UserID:     userID,
 		Status:     "pending",
 		Results:    make(models.JSONMap),
+		Snapshot: &models.WorkflowSnapshot{
+			Name:                workflow.Name,
+			Nodes:               workflow.Nodes,
+			Edges:               workflow.Edges,
+			MaxDuration:         workflow.MaxDuration,
+			StopOnCritical:      workflow.StopOnCritical,
+			BaselineExecutionID: workflow.BaselineExecutionID,
+			FailOnNewFindings:   workflow.FailOnNewFindings,
+		},
+		ReplayedFrom: replayedFrom,
+		TargetHost:   utils.NormalizeHost(triggerTarget(workflow.Nodes)),
+		Tags:         labels.Tags,
+		Notes:        labels.Notes,
 	}
 
-	if err := e.db.Create(execution).Error; err != nil {
-		return nil, fmt.Errorf("failed to create execution record: %w", err)
+	// Whether it runs now depends on the workflow's concurrency policy and any run in progress
+	err := e.db.Transaction(func(tx *gorm.DB) error {
+		status, queued, err := admitRun(tx, workflow.ID, workflow.MaxDuration)
+		if err != nil {
+			return err
+		}
+		if queued != nil {
+			execution = queued
+			return nil
+		}
+		execution.Status = status
+		if status == "skipped" {
+			now := time.Now()
+			execution.CompletedAt = &now
+			execution.Error = "skipped: the previous run of this workflow was still in progress"
+		}
+		if err := tx.Create(execution).Error; err != nil {
+			return fmt.Errorf("failed to create execution record: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	execution.Name = workflow.Name
+	if execution.Status == "pending" {
+		e.dispatch(ctx, execution, workflow)
+	}
+	return execution, nil
+}
+
+// dispatch runs a pending execution on whichever server claims it from the dispatch queue, or
+// on this one when there is no queue or it can't be reached
+func (e *WorkflowExecutor) dispatch(ctx context.Context, execution *models.WorkflowExecution, workflow *models.Workflow) {
+	if e.dispatcher != nil {
+		err := e.dispatcher.push(ctx, execution.ID)
+		if err == nil {
+			return
+		}
+		logf(ctx, "⚠️ Failed to queue execution %s for dispatch, running it here: %v", execution.ID, err)
+	}
+	if e.claim(ctx, execution) {
+		e.start(ctx, execution, workflow)
+	}
+}
+
+// claim marks a pending execution as taken by this server. It reports false when another
+// server claimed it first or it is no longer pending, e.g. cancelled while it was queued.
+func (e *WorkflowExecutor) claim(ctx context.Context, execution *models.WorkflowExecution) bool {
+	now := time.Now()
+	claimed := e.db.Model(&models.WorkflowExecution{}).
+		Where("id = ? AND status = ? AND claimed_at IS NULL", execution.ID, "pending").
+		Updates(map[string]interface{}{"claimed_at": now, "heartbeat_at": now})
+	if claimed.Error != nil {
+		logf(ctx, "⚠️ Failed to claim execution %s: %v", execution.ID, claimed.Error)
+		return false
+	}
+	if claimed.RowsAffected == 0 {
+		return false
+	}
+	execution.ClaimedAt = &now
+	execution.HeartbeatAt = &now
+	return true
+}
+
+// start registers a pending execution and runs it in the background. The run outlives parent
+// but keeps its logger, tagged with the execution and workflow IDs.
+func (e *WorkflowExecutor) start(parent context.Context, execution *models.WorkflowExecution, workflow *models.Workflow) {
+	logger := utils.Logger(parent).With("execution_id", execution.ID, "workflow_id", workflow.ID)
+
+	// Register before launching so the run is visible (and cancellable) immediately
+	ctx, cancel := context.WithCancelCause(utils.WithLogger(context.Background(), logger))
+	executionLog := newExecutionLog()
+	ctx = withExecutionLog(ctx, executionLog)
+	ctx = withScanOwner(ctx, execution.UserID)
+	e.active.add(ActiveExecution{
+		ID:           execution.ID,
+		WorkflowID:   workflow.ID,
+		WorkflowName: workflow.Name,
+		UserID:       execution.UserID,
+		StartedAt:    time.Now(),
+	}, cancel, executionLog)
 
 	// Launch async execution
-	go e.executeAsync(execution.ID, workflow)
+	go e.executeAsync(ctx, execution.ID, workflow)
+}
 
-	return execution, nil
+// Cancel stops a queued or in-flight execution: a queued one, or a pending one no server has
+// claimed yet, is marked cancelled before it starts, and a running one stops starting nodes
+// and kills the scanners it has running, on whichever server runs it. It reports false when
+// the execution has already finished.
+func (e *WorkflowExecutor) Cancel(execution *models.WorkflowExecution) bool {
+	if execution.Status == "queued" && e.cancelUnstarted(execution.ID, "status = ?", "queued") {
+		return true
+	}
+	if e.active.Cancel(execution.ID) {
+		return true
+	}
+	if execution.Status == "pending" && e.cancelUnstarted(execution.ID, "status = ? AND claimed_at IS NULL", "pending") {
+		return true
+	}
+	if e.dispatcher != nil && (execution.Status == "pending" || execution.Status == "running") {
+		if err := e.dispatcher.broadcastCancel(execution.ID); err != nil {
+			log.Printf("⚠️ Failed to broadcast cancellation of execution %s: %v", execution.ID, err)
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// cancelUnstarted marks an execution that no server is running as cancelled, if it still
+// matches condition
+func (e *WorkflowExecutor) cancelUnstarted(executionID uuid.UUID, condition string, args ...interface{}) bool {
+	cancelled := e.db.Model(&models.WorkflowExecution{}).Where("id = ?", executionID).Where(condition, args...).Updates(map[string]interface{}{
+		"status":       "cancelled",
+		"error":        "Execution cancelled",
+		"completed_at": time.Now(),
+	})
+	if cancelled.Error != nil || cancelled.RowsAffected == 0 {
+		return false
+	}
+	e.webhooks.Notify(executionID)
+	return true
+}
+
+// ExecutionLimits reports the execution limits, how many runs hold or wait for a slot, and each
+// user's share
+func (e *WorkflowExecutor) ExecutionLimits() ExecutionLimits {
+	return e.slots.limits()
+}
+
+// ActiveExecutions lists the executions currently in flight
+func (e *WorkflowExecutor) ActiveExecutions() []ActiveExecution {
+	return e.active.List()
+}
+
+// LiveLogs returns the logs of an in-flight execution; it reports false once the execution has finished
+func (e *WorkflowExecutor) LiveLogs(executionID uuid.UUID) (*models.ExecutionLog, bool) {
+	return e.active.Logs(executionID)
 }
 
 // executeAsync runs the workflow in the background
-func (e *WorkflowExecutor) executeAsync(executionID uuid.UUID, workflow *models.Workflow) {
-	log.Printf("🚀 Starting workflow execution: %s", executionID)
+func (e *WorkflowExecutor) executeAsync(ctx context.Context, executionID uuid.UUID, workflow *models.Workflow) {
+	// Deferred in reverse so the logs are saved before the run leaves the registry, the
+	// completion webhook goes out once the run's final state is stored, and a queued run of
+	// the workflow starts last
+	defer e.startQueued(workflow.ID)
+	defer e.webhooks.Notify(executionID)
+	defer e.active.remove(executionID)
+	defer e.saveLog(ctx, executionID)
+
+	// Wait for an execution slot; the run stays pending until it has one
+	release, err := e.slots.acquire(ctx, workflow.UserID)
+	if err != nil {
+		e.cancelExecution(ctx, executionID, nil, map[string]interface{}{})
+		return
+	}
+	defer release()
+	logf(ctx, "🚀 Starting workflow execution: %s", executionID)
 
 	// Update status to running
 	startTime := time.Now()
@@ -82,83 +293,152 @@ func (e *WorkflowExecutor) executeAsync(executionID uuid.UUID, workflow *models.
 	// Parse nodes and edges
 	nodes, edges, err := e.parseWorkflow(workflow)
 	if err != nil {
-		e.failExecution(executionID, fmt.Sprintf("Failed to parse workflow: %v", err))
+		e.failExecution(ctx, executionID, fmt.Sprintf("Failed to parse workflow: %v", err))
 		return
 	}
 
 	// Get execution order
 	executionOrder, err := e.topologicalSort(nodes, edges)
 	if err != nil {
-		e.failExecution(executionID, fmt.Sprintf("Failed to sort workflow: %v", err))
+		e.failExecution(ctx, executionID, fmt.Sprintf("Failed to sort workflow: %v", err))
 		return
 	}
 
-	log.Printf("📋 Execution order: %v", executionOrder)
+	logf(ctx, "📋 Execution order: %v", executionOrder)
 
-	// Execute nodes in order
-	results := make(map[string]interface{})
-	for _, nodeID := range executionOrder {
-		node := e.findNode(nodes, nodeID)
-		if node == nil {
-			e.failExecution(executionID, fmt.Sprintf("Node not found: %s", nodeID))
-			return
-		}
-
-		// Update current node
-		e.db.Model(&models.WorkflowExecution{}).Where("id = ?", executionID).Update("current_node", node.Type)
-
-		log.Printf("⚙️  Executing node: %s (%s)", node.ID, node.Type)
+	// Enforce the workflow-level time budget across all nodes
+	prefs := e.userPreferences(ctx, workflow.UserID)
+	ctx = WithPreferredProvider(ctx, prefs.AIProvider)
+	if workflow.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(workflow.MaxDuration)*time.Second)
+		defer cancel()
+	}
 
-		// Execute the node
-		result, err := e.executeNode(node, results, workflow.UserID)
-		if err != nil {
-			e.failExecution(executionID, fmt.Sprintf("Node %s failed: %v", node.ID, err))
+	// Execute nodes, running independent branches in parallel
+	results := newExecutionResults(e.db, executionID, len(executionOrder))
+	results.Start()
+	failure := e.runNodes(ctx, executionID, workflow, nodes, edges, executionOrder, results)
+	results.Stop()
+	switch {
+	case errors.Is(context.Cause(ctx), ErrExecutionCancelled):
+		e.cancelExecution(ctx, executionID, unfinishedNodes(executionOrder, results.Snapshot(), ""), results.Snapshot())
+		return
+	case failure != nil && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		e.timeOutExecution(ctx, executionID, unfinishedNodes(executionOrder, results.Snapshot(), ""), results.Snapshot(), workflow.MaxDuration)
+		return
+	case failure != nil && failure.timedOut:
+		e.timeOutNode(ctx, executionID, failure.node, failure.timeout, unfinishedNodes(executionOrder, results.Snapshot(), failure.node.ID), results.Snapshot())
+		return
+	case failure != nil && failure.attempts > 1:
+		e.failExecution(ctx, executionID, fmt.Sprintf("Node %s failed after %d attempts: %v", failure.node.ID, failure.attempts, failure.err))
+		return
+	case failure != nil:
+		e.failExecution(ctx, executionID, fmt.Sprintf("Node %s failed: %v", failure.node.ID, failure.err))
+		return
+	case ctx.Err() != nil:
+		// The run ended before every node could start
+		if remaining := unfinishedNodes(executionOrder, results.Snapshot(), ""); len(remaining) > 0 {
+			e.timeOutExecution(ctx, executionID, remaining, results.Snapshot(), workflow.MaxDuration)
 			return
 		}
-
-		// Store result
-		results[node.ID] = result
-		e.db.Model(&models.WorkflowExecution{}).Where("id = ?", executionID).Update("results", models.JSONMap(results))
 	}
 
 	// Generate AI Report
-	log.Printf("🤖 Generating AI Security Report...")
+	logf(ctx, "🤖 Generating AI Security Report...")
+	finalResults := results.Snapshot()
+	severitySummary, riskGrade := riskSummary(finalResults)
+	if correlated := crossScannerFindings(finalResults); len(correlated) > 0 {
+		finalResults["correlated_findings"] = correlated
+	}
 	var scanSummaries string
-	for nodeID, result := range results {
+	for nodeID, result := range finalResults {
 		if nodeMap, ok := result.(map[string]interface{}); ok {
 			if output, ok := nodeMap["output"].(string); ok {
+				output = e.aiService.CondenseScanOutput(ctx, fmt.Sprintf("%v", nodeMap["scanner"]), output)
 				scanSummaries += fmt.Sprintf("Node %s (%s) Output:\n%s\n\n", nodeID, nodeMap["scanner"], output)
 			}
 		}
 	}
 
 	if scanSummaries != "" {
-		aiReport, err := e.aiService.GenerateSecurityRecommendations(context.Background(), scanSummaries)
+		aiReport, err := e.aiService.GenerateSecurityRecommendations(ctx, scanSummaries, reportFindings(finalResults), prefs.Language)
 		if err != nil {
-			log.Printf("⚠️ Failed to generate AI report: %v", err)
-			results["ai_report_error"] = err.Error()
+			logf(ctx, "⚠️ Failed to generate AI report: %v", err)
+			finalResults["ai_report_error"] = err.Error()
 		} else {
-			results["ai_report"] = map[string]interface{}{
+			generatedBy := "VulnPilot AI"
+			if !e.aiService.Configured() {
+				generatedBy = "VulnPilot (template, no AI configured)"
+			}
+			finalResults["ai_report"] = map[string]interface{}{
 				"ai_report":       aiReport,
-				"security_grade":  "B", // Placeholder, ideally specific extraction logic would be better but keeping it simple
-				"total_issues":    5,   // Placeholder
-				"critical_issues": 0,
+				"security_grade":  riskGrade,
+				"total_issues":    totalFindings(severitySummary),
+				"critical_issues": severitySummary[string(SeverityCritical)],
 				"report_date":     time.Now(),
-				"generated_by":    "VulnPilot AI",
+				"generated_by":    generatedBy,
 			}
-			e.db.Model(&models.WorkflowExecution{}).Where("id = ?", executionID).Update("results", models.JSONMap(results))
+		}
+	}
+
+	// Compare against the approved baseline, gating the run on regressions if configured
+	status := "completed"
+	errorMsg := ""
+	if comparison := e.compareBaseline(ctx, workflow, finalResults); comparison != nil {
+		finalResults["baseline_comparison"] = comparison
+		if workflow.FailOnNewFindings && comparison.NewFindings > 0 {
+			status = "failed"
+			errorMsg = fmt.Sprintf("%d new finding(s) compared to baseline execution %s", comparison.NewFindings, comparison.BaselineExecutionID)
 		}
 	}
 
 	// Mark as completed
 	completedTime := time.Now()
 	e.db.Model(&models.WorkflowExecution{}).Where("id = ?", executionID).Updates(map[string]interface{}{
-		"status":       "completed",
-		"completed_at": completedTime,
-		"results":      models.JSONMap(results),
+		"status":           status,
+		"error":            errorMsg,
+		"completed_at":     completedTime,
+		"results":          models.JSONMap(finalResults),
+		"progress":         100,
+		"severity_summary": severitySummaryJSON(severitySummary),
+		"risk_grade":       riskGrade,
 	})
 
-	log.Printf("✅ Workflow execution completed: %s (duration: %v)", executionID, completedTime.Sub(startTime))
+	logf(ctx, "✅ Workflow execution completed: %s (duration: %v)", executionID, completedTime.Sub(startTime))
+
+	e.siem.Export(FindingsEvent{
+		Source:   "execution",
+		SourceID: executionID,
+		UserID:   workflow.UserID,
+		Time:     completedTime,
+		Findings: extractFindings(finalResults),
+	})
+}
+
+// hasCriticalFinding reports whether a node result contains a finding of critical severity
+func hasCriticalFinding(nodeID string, result interface{}) bool {
+	for _, finding := range extractFindings(map[string]interface{}{nodeID: result}) {
+		if finding.Severity == SeverityCritical {
+			return true
+		}
+	}
+	return false
+}
+
+// compareBaseline diffs results against the workflow's baseline execution, if one is set
+func (e *WorkflowExecutor) compareBaseline(ctx context.Context, workflow *models.Workflow, results map[string]interface{}) *BaselineComparison {
+	if workflow.BaselineExecutionID == nil {
+		return nil
+	}
+
+	var baseline models.WorkflowExecution
+	if err := e.db.Where("id = ? AND workflow_id = ?", *workflow.BaselineExecutionID, workflow.ID).First(&baseline).Error; err != nil {
+		logf(ctx, "⚠️ Baseline execution %s unavailable: %v", *workflow.BaselineExecutionID, err)
+		return nil
+	}
+
+	return compareWithBaseline(&baseline, results)
 }
 
 // parseWorkflow extracts nodes and edges from workflow
@@ -189,27 +469,43 @@ func (e *WorkflowExecutor) parseWorkflow(workflow *models.Workflow) ([]WorkflowN
 
 // topologicalSort returns nodes in execution order
 func (e *WorkflowExecutor) topologicalSort(nodes []WorkflowNode, edges []WorkflowEdge) ([]string, error) {
+	return topologicalOrder(nodes, edges)
+}
+
+// topologicalOrder orders nodes so every node comes after its dependencies, failing on cycles.
+// Duplicate node IDs and edges to unknown nodes are rejected up front, since either would
+// leave in-degrees that never reach zero and be misreported as a cycle.
+func topologicalOrder(nodes []WorkflowNode, edges []WorkflowEdge) ([]string, error) {
 	// Build adjacency list and in-degree map
-	adjList := make(map[string][]string)
-	inDegree := make(map[string]int)
+	adjList := make(map[string][]string, len(nodes))
+	inDegree := make(map[string]int, len(nodes))
 
 	// Initialize
 	for _, node := range nodes {
+		if _, exists := inDegree[node.ID]; exists {
+			return nil, fmt.Errorf("workflow has more than one node with ID %q", node.ID)
+		}
 		inDegree[node.ID] = 0
 		adjList[node.ID] = []string{}
 	}
 
 	// Build graph
 	for _, edge := range edges {
+		_, sourceOK := inDegree[edge.Source]
+		_, targetOK := inDegree[edge.Target]
+		if !sourceOK || !targetOK {
+			return nil, fmt.Errorf("edge %q connects %q to %q, which are not both nodes of the workflow", edge.ID, edge.Source, edge.Target)
+		}
 		adjList[edge.Source] = append(adjList[edge.Source], edge.Target)
 		inDegree[edge.Target]++
 	}
 
-	// Find nodes with no dependencies
-	queue := []string{}
-	for nodeID, degree := range inDegree {
-		if degree == 0 {
-			queue = append(queue, nodeID)
+	// Find nodes with no dependencies, in the order the workflow lists them so every run of a
+	// workflow gets the same order (neighbors keep the order of the edges)
+	queue := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if inDegree[node.ID] == 0 {
+			queue = append(queue, node.ID)
 		}
 	}
 
@@ -238,200 +534,118 @@ func (e *WorkflowExecutor) topologicalSort(nodes []WorkflowNode, edges []Workflo
 }
 
 // executeNode executes a single node
-func (e *WorkflowExecutor) executeNode(node *WorkflowNode, previousResults map[string]interface{}, userID uuid.UUID) (interface{}, error) {
+func (e *WorkflowExecutor) executeNode(ctx context.Context, node *WorkflowNode, previousResults map[string]interface{}, userID uuid.UUID) (interface{}, error) {
+	if err := e.applyScanProfile(node, userID); err != nil {
+		return nil, err
+	}
+	if err := e.checkNodeInputs(node, previousResults); err != nil {
+		return nil, err
+	}
+
 	switch node.Type {
 	case "trigger":
-		return e.executeTrigger(node)
-	case "nmap":
-		return e.executeNmap(node, previousResults)
-	case "nikto":
-		return e.executeNikto(node, previousResults)
-	case "gobuster":
-		return e.executeGobuster(node, previousResults)
-	case "sqlmap":
-		return e.executeSqlmap(node, previousResults)
-	case "wpscan":
-		return e.executeWpscan(node, previousResults)
+		return e.executeTrigger(ctx, node)
 	case "email", "slack":
-		return e.executeNotification(node, previousResults, userID)
+		return e.executeNotification(ctx, node, previousResults, userID)
+	case "notify":
+		return e.executeNotification(ctx, e.preferredChannelNode(ctx, node, userID), previousResults, userID)
 	case "github-issue":
-		return e.executeGitHubIssue(node, previousResults, userID)
+		return e.executeGitHubIssue(ctx, node, previousResults, userID)
+	case "jira-issue":
+		return e.executeJiraIssue(ctx, node, previousResults, userID)
 	case "auto-fix":
-		return e.executeAutoFix(node, previousResults, userID)
-	case "owasp-vulnerabilities":
-		return e.executeNikto(node, previousResults) // Map OWASP to Nikto for now
+		return e.executeAutoFix(ctx, node, previousResults, userID)
 	case "flow-chart":
-		return e.executeFlowChart(node, previousResults)
-	case "secret-scan":
-		return e.executeSecretScan(node, previousResults)
-	case "dependency-check":
-		return e.executeDependencyCheck(node, previousResults)
-	case "semgrep-scan":
-		return e.executeSemgrep(node, previousResults)
-	case "container-scan":
-		return e.executeContainerScan(node, previousResults)
+		return e.executeFlowChart(ctx, node, previousResults)
+	case "webhook":
+		return e.executeWebhook(ctx, node, previousResults)
 	default:
+		if scanner, ok := LookupScanner(node.Type); ok {
+			result, err := scanner.Run(ctx, &ScanEnv{Scanner: e.scannerService, UserID: userID, executor: e}, node, previousResults)
+			if err != nil {
+				return nil, err
+			}
+			// Keep the raw output, with secrets masked, and record the findings in the unified schema next to it
+			return withFindings(e.scannerService.Redactor().Value(result)), nil
+		}
 		return nil, fmt.Errorf("unknown node type: %s", node.Type)
 	}
 }
 
-// executeTrigger gets the target from trigger node
-func (e *WorkflowExecutor) executeTrigger(node *WorkflowNode) (interface{}, error) {
-	targetURL, ok := node.Data["sourceUrl"].(string)
-	if !ok || targetURL == "" {
-		// Fallback for demo if not set
-		targetURL = "example.com"
-	}
-
-	return map[string]interface{}{
-		"target": targetURL,
-		"type":   "trigger",
-	}, nil
-}
-
-// executeNmap runs nmap scanner
-func (e *WorkflowExecutor) executeNmap(node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
-	// Get target from trigger node
-	target := e.getTarget(previousResults)
-	if target == "" {
-		return nil, fmt.Errorf("no target found for nmap")
-	}
-
-	// Get config from node data if available
-	ports := "1-1000" // Default
-	if p, ok := node.Data["ports"].(string); ok && p != "" {
-		ports = p
-	}
-
-	log.Printf("🔍 Running Nmap scan on: %s ports: %s", target, ports)
-
-	output, err := e.scannerService.RunNmap(target, ports)
-	if err != nil {
-		return nil, err
-	}
-
-	return map[string]interface{}{
-		"scanner": "nmap",
-		"target":  target,
-		"output":  output,
-		"status":  "completed",
-	}, nil
-}
-
-// executeNikto runs nikto scanner
-func (e *WorkflowExecutor) executeNikto(node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
-	target := e.getTarget(previousResults)
-	if target == "" {
-		return nil, fmt.Errorf("no target found for nikto")
+// applyScanProfile merges a saved scan profile referenced by node.Data["profile"] into the node.
+// Profile parameters act as defaults; values set inline on the node take precedence.
+func (e *WorkflowExecutor) applyScanProfile(node *WorkflowNode, userID uuid.UUID) error {
+	ref, ok := node.Data["profile"].(string)
+	if !ok || ref == "" {
+		return nil
 	}
 
-	log.Printf("🔍 Running Nikto scan on: %s", target)
-
-	output, err := e.scannerService.RunNikto(target)
+	profile, err := resolveScanProfile(e.db, userID, ref)
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	// Try to parse JSON if possible, otherwise return raw output
-	var jsonOutput interface{}
-	if json.Unmarshal(output, &jsonOutput) == nil {
-		return map[string]interface{}{
-			"scanner": "nikto",
-			"target":  target,
-			"data":    jsonOutput,
-			"output":  string(output), // Include raw output for reporting
-			"status":  "completed",
-		}, nil
-	}
-
-	return map[string]interface{}{
-		"scanner": "nikto",
-		"target":  target,
-		"output":  string(output),
-		"status":  "completed",
-	}, nil
-}
-
-// executeGobuster runs gobuster scanner
-func (e *WorkflowExecutor) executeGobuster(node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
-	target := e.getTarget(previousResults)
-	if target == "" {
-		return nil, fmt.Errorf("no target found for gobuster")
+	if profile.ScannerType != node.Type {
+		return fmt.Errorf("scan profile %q is for %s, not %s", profile.Name, profile.ScannerType, node.Type)
 	}
 
-	wordlist := ""
-	if w, ok := node.Data["wordlist"].(string); ok {
-		wordlist = w
+	data := make(map[string]interface{}, len(node.Data)+len(profile.Parameters))
+	for k, v := range profile.Parameters {
+		data[k] = v
 	}
-
-	log.Printf("🔍 Running Gobuster scan on: %s", target)
-
-	output, err := e.scannerService.RunGobuster(target, wordlist)
-	if err != nil {
-		return nil, err
+	for k, v := range node.Data {
+		// Empty inline fields are form defaults, not overrides
+		if str, ok := v.(string); ok && str == "" {
+			if _, fromProfile := data[k]; fromProfile {
+				continue
+			}
+		}
+		data[k] = v
 	}
-
-	return map[string]interface{}{
-		"scanner": "gobuster",
-		"target":  target,
-		"output":  output,
-		"status":  "completed",
-	}, nil
+	node.Data = data
+	return nil
 }
 
-// executeSqlmap runs sqlmap scanner
-func (e *WorkflowExecutor) executeSqlmap(node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
-	target := e.getTarget(previousResults)
-	if target == "" {
-		return nil, fmt.Errorf("no target found for sqlmap")
-	}
-
-	log.Printf("🔍 Running Sqlmap scan on: %s", target)
-
-	output, err := e.scannerService.RunSqlmap(target)
-	if err != nil {
-		return nil, err
+// triggerTarget returns the target configured on the workflow's trigger node
+func triggerTarget(nodes models.JSONArray) string {
+	for _, raw := range nodes {
+		node, ok := raw.(map[string]interface{})
+		if !ok || node["type"] != "trigger" {
+			continue
+		}
+		if data, ok := node["data"].(map[string]interface{}); ok {
+			if target, ok := data["sourceUrl"].(string); ok && target != "" {
+				return target
+			}
+		}
+		// Matches executeTrigger's fallback
+		return "example.com"
 	}
-
-	return map[string]interface{}{
-		"scanner": "sqlmap",
-		"target":  target,
-		"output":  output,
-		"status":  "completed",
-	}, nil
+	return ""
 }
 
-// executeWpscan runs wpscan scanner
-func (e *WorkflowExecutor) executeWpscan(node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
-	target := e.getTarget(previousResults)
-	if target == "" {
-		return nil, fmt.Errorf("no target found for wpscan")
-	}
-
-	log.Printf("🔍 Running WPScan on: %s", target)
-
-	output, err := e.scannerService.RunWpscan(target)
-	if err != nil {
-		return nil, err
+// executeTrigger gets the target from trigger node
+func (e *WorkflowExecutor) executeTrigger(ctx context.Context, node *WorkflowNode) (interface{}, error) {
+	targetURL, ok := node.Data["sourceUrl"].(string)
+	if !ok || targetURL == "" {
+		// Fallback for demo if not set
+		targetURL = "example.com"
 	}
 
 	return map[string]interface{}{
-		"scanner": "wpscan",
-		"target":  target,
-		"output":  output,
-		"status":  "completed",
+		"target": targetURL,
+		"type":   "trigger",
 	}, nil
 }
 
 // executeNotification sends notification with results
 // Email nodes send email only; Slack nodes send Slack only (no duplicate emails)
-func (e *WorkflowExecutor) executeNotification(node *WorkflowNode, previousResults map[string]interface{}, userID uuid.UUID) (interface{}, error) {
-	log.Printf("📧 Sending %s notification with results", node.Type)
+func (e *WorkflowExecutor) executeNotification(ctx context.Context, node *WorkflowNode, previousResults map[string]interface{}, userID uuid.UUID) (interface{}, error) {
+	logf(ctx, "📧 Sending %s notification with results", node.Type)
 
 	// Fetch user to get email
 	var user models.User
 	if err := e.db.First(&user, "id = ?", userID).Error; err != nil {
-		log.Printf("⚠️ Failed to fetch user for notification: %v", err)
+		logf(ctx, "⚠️ Failed to fetch user for notification: %v", err)
 		return map[string]interface{}{
 			"type":   node.Type,
 			"status": "failed",
@@ -439,19 +653,50 @@ func (e *WorkflowExecutor) executeNotification(node *WorkflowNode, previousResul
 		}, nil
 	}
 
+	language, err := reportLanguage(node, &user)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get target from previous results
-	target := e.getTarget(previousResults)
+	target := e.getTarget(node, previousResults)
+
+	newPorts := newlyOpenedPorts(previousResults)
+	if onlyNewPorts, _ := node.Data["onlyNewPorts"].(bool); onlyNewPorts && len(newPorts) == 0 {
+		logf(ctx, "🔕 Skipping %s notification: no newly opened ports on %s", node.Type, target)
+		return map[string]interface{}{
+			"type":   node.Type,
+			"status": "skipped",
+			"reason": "no newly opened ports",
+		}, nil
+	}
 
-	// Aggregate results for AI
+	detail, err := reportDetail(node, ReportDetailFull)
+	if err != nil {
+		return nil, err
+	}
+
+	// Aggregate results for AI; summary reports leave out the raw scanner output
 	var scanSummaries string
+	if detail == ReportDetailSummary && len(extractFindings(previousResults)) > 0 {
+		scanSummaries = findingCounts(previousResults) + "\n\n"
+	}
 	for nodeID, result := range previousResults {
 		if nodeMap, ok := result.(map[string]interface{}); ok {
-			// Check for structured data first
-			if data, ok := nodeMap["data"]; ok {
-				formatted := formatScanData(data)
-				scanSummaries += fmt.Sprintf("Node %s (%s) Output:\n%s\n\n", nodeID, nodeMap["scanner"], formatted)
-			} else if output, ok := nodeMap["output"].(string); ok {
-				scanSummaries += fmt.Sprintf("Node %s (%s) Output:\n%s\n\n", nodeID, nodeMap["scanner"], output)
+			if detail == ReportDetailFull {
+				// Check for structured data first
+				if data, ok := nodeMap["data"]; ok {
+					formatted := formatScanData(data)
+					scanSummaries += fmt.Sprintf("Node %s (%s) Output:\n%s\n\n", nodeID, nodeMap["scanner"], formatted)
+				} else if output, ok := nodeMap["output"].(string); ok {
+					output = e.aiService.CondenseScanOutput(ctx, fmt.Sprintf("%v", nodeMap["scanner"]), output)
+					scanSummaries += fmt.Sprintf("Node %s (%s) Output:\n%s\n\n", nodeID, nodeMap["scanner"], output)
+				}
+			}
+
+			if changes := nodePortChanges(nodeMap); changes != nil && (len(changes.NewlyOpened) > 0 || len(changes.NewlyClosed) > 0) {
+				scanSummaries += fmt.Sprintf("🚪 Port changes since the previous scan (Node %s):\nNewly opened: %s\nNewly closed: %s\n\n",
+					nodeID, strings.Join(changes.NewlyOpened, ", "), strings.Join(changes.NewlyClosed, ", "))
 			}
 
 			// Special handling for Auto-Fix results
@@ -463,43 +708,81 @@ func (e *WorkflowExecutor) executeNotification(node *WorkflowNode, previousResul
 		}
 	}
 
+	// Suppress duplicates, e.g. parallel branches that found the same issues notifying the same channel
+	fingerprint := e.notificationFingerprint(node, user.Email, target, previousResults)
+	if !e.notificationService.ClaimNotification(userID, fingerprint) {
+		logf(ctx, "🔕 Suppressing duplicate %s notification for %s", node.Type, target)
+		return map[string]interface{}{
+			"type":        node.Type,
+			"status":      "suppressed",
+			"reason":      "duplicate notification",
+			"fingerprint": fingerprint,
+		}, nil
+	}
+
 	// Generate Report (only when sending email or slack that needs it)
 	aiReport := "No scan data available for analysis."
 	if scanSummaries != "" {
-		report, err := e.aiService.GenerateSecurityRecommendations(context.Background(), scanSummaries)
+		report, err := e.aiService.GenerateSecurityRecommendations(ctx, scanSummaries, reportFindings(previousResults), language)
 		if err == nil {
 			aiReport = report
 		} else {
-			log.Printf("⚠️ Failed to generate AI report for notification: %v", err)
+			logf(ctx, "⚠️ Failed to generate AI report for notification: %v", err)
 			aiReport = fmt.Sprintf("AI Analysis Failed: %v", err)
 		}
 	}
+	if detail == ReportDetailSummary {
+		aiReport += "\n\n" + findingCounts(previousResults)
+	}
 
 	switch node.Type {
 	case "email":
 		// Email node: send workflow report via email only
 		recipientEmail := e.getNotificationEmail(node, user.Email)
 		if recipientEmail == "" {
-			log.Printf("⚠️ No recipient email available for email notification")
+			logf(ctx, "⚠️ No recipient email available for email notification")
+			e.notificationService.ReleaseNotification(userID, fingerprint)
 			return map[string]interface{}{
 				"type":   node.Type,
 				"status": "failed",
 				"error":  "no recipient email provided",
 			}, nil
 		}
-		log.Printf("📧 Sending email to: %s", recipientEmail)
+		logf(ctx, "📧 Sending email to: %s", recipientEmail)
 		if err := e.notificationService.SendWorkflowReport(recipientEmail, target, "completed", aiReport); err != nil {
-			log.Printf("⚠️ Failed to send email to %s: %v", recipientEmail, err)
+			logf(ctx, "⚠️ Failed to send email to %s: %v", recipientEmail, err)
+			subject, body := WorkflowReportEmail(target, "completed", aiReport)
+			return e.queueFailedNotification(ctx, node, userID, fingerprint, recipientEmail, subject, body, nil, err), nil
+		}
+		return map[string]interface{}{"type": node.Type, "status": "sent"}, nil
+
+	case "slack":
+		// Slack node: send to Slack only (not email), on the node's own webhook if it has one
+		webhookURL := getSlackWebhookURL(node)
+		if webhookURL == "" && !e.notificationService.ChannelEnabled("slack") {
+			logf(ctx, "⚠️ No Slack webhook available for Slack notification")
+			e.notificationService.ReleaseNotification(userID, fingerprint)
 			return map[string]interface{}{
 				"type":   node.Type,
 				"status": "failed",
-				"error":  err.Error(),
+				"error":  "no Slack webhook configured",
 			}, nil
 		}
-		return map[string]interface{}{"type": node.Type, "status": "sent"}, nil
+		message := WorkflowReportSlack(target, "completed", findingCounts(previousResults), aiReport)
+		delivery, err := e.notificationService.SendSlackMessage(ctx, webhookURL, message)
+		if err == nil {
+			result := map[string]interface{}{"type": node.Type, "status": "sent"}
+			if delivery.Channel != "" {
+				result["channel"] = delivery.Channel
+			}
+			if delivery.TS != "" {
+				result["ts"] = delivery.TS
+			}
+			return result, nil
+		}
 
-	case "slack":
-		// Slack node: send to Slack only (not email)
+		// Retries carry the report as a classic attachment, which the delivery queue stores
+		logf(ctx, "⚠️ Failed to send Slack notification: %v", err)
 		attachments := []Attachment{
 			{
 				Color: "good",
@@ -511,15 +794,7 @@ func (e *WorkflowExecutor) executeNotification(node *WorkflowNode, previousResul
 				},
 			},
 		}
-		if err := e.notificationService.SendSlackNotification("VulnPilot Security Workflow Report", attachments); err != nil {
-			log.Printf("⚠️ Failed to send Slack notification: %v", err)
-			return map[string]interface{}{
-				"type":   node.Type,
-				"status": "failed",
-				"error":  err.Error(),
-			}, nil
-		}
-		return map[string]interface{}{"type": node.Type, "status": "sent"}, nil
+		return e.queueFailedNotification(ctx, node, userID, fingerprint, webhookURL, "VulnPilot Security Workflow Report", "", attachments, err), nil
 
 	default:
 		return map[string]interface{}{
@@ -530,6 +805,127 @@ func (e *WorkflowExecutor) executeNotification(node *WorkflowNode, previousResul
 	}
 }
 
+// queueFailedNotification hands a failed send to the retry queue so transient SMTP/Slack
+// outages don't lose the alert. If it can't be queued the dedup claim is released instead,
+// letting a later run send it.
+func (e *WorkflowExecutor) queueFailedNotification(ctx context.Context, node *WorkflowNode, userID uuid.UUID, fingerprint, recipient, subject, body string, attachments []Attachment, sendErr error) map[string]interface{} {
+	delivery, err := e.notificationQueue.Enqueue(userID, node.Type, recipient, subject, body, attachments, sendErr)
+	if err != nil {
+		logf(ctx, "⚠️ %v", err)
+		e.notificationService.ReleaseNotification(userID, fingerprint)
+		return map[string]interface{}{
+			"type":   node.Type,
+			"status": "failed",
+			"error":  sendErr.Error(),
+		}
+	}
+
+	return map[string]interface{}{
+		"type":        node.Type,
+		"status":      "queued",
+		"error":       sendErr.Error(),
+		"delivery_id": delivery.ID.String(),
+		"next_retry":  delivery.NextAttemptAt,
+	}
+}
+
+// preferredChannelNode resolves a generic notify node to a concrete channel node.
+// An explicit data.channel wins; otherwise the user's notification_channel preference is used.
+func (e *WorkflowExecutor) preferredChannelNode(ctx context.Context, node *WorkflowNode, userID uuid.UUID) *WorkflowNode {
+	channel, _ := node.Data["channel"].(string)
+	if !isNotificationChannel(channel) {
+		channel = e.userPreferences(ctx, userID).NotificationChannel
+	}
+	if !isNotificationChannel(channel) {
+		channel = "email"
+	}
+
+	resolved := *node
+	resolved.Type = channel
+	return &resolved
+}
+
+// executeWebhook posts the accumulated results to a user-supplied URL
+func (e *WorkflowExecutor) executeWebhook(ctx context.Context, node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
+	statusCode, err := e.postWebhook(ctx, node, map[string]interface{}{
+		"target":  e.getTarget(node, previousResults),
+		"results": previousResults,
+		"sent_at": time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"type":        "webhook",
+		"status":      "sent",
+		"status_code": statusCode,
+	}, nil
+}
+
+// postWebhook sends payload to a webhook node's url, returning the response status code.
+// The request goes through the SSRF-safe client so internal addresses are refused at dial time.
+func (e *WorkflowExecutor) postWebhook(ctx context.Context, node *WorkflowNode, payload map[string]interface{}) (int, error) {
+	webhookURL, _ := node.Data["url"].(string)
+	if !utils.ValidateURL(webhookURL) {
+		return 0, fmt.Errorf("webhook node requires a valid url")
+	}
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return 0, fmt.Errorf("webhook url must use http or https")
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewBuffer(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "VulnPilot-Webhook")
+
+	logf(ctx, "🪝 Sending webhook to: %s", parsed.Host)
+	resp, err := e.webhookClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// notificationFingerprint identifies a notification by channel, recipient, target and findings.
+// Finding keys are used rather than the AI report text, which differs between generations.
+func (e *WorkflowExecutor) notificationFingerprint(node *WorkflowNode, defaultEmail, target string, previousResults map[string]interface{}) string {
+	parts := []string{"channel:" + node.Type, "target:" + target}
+	if node.Type == "email" {
+		parts = append(parts, "to:"+e.getNotificationEmail(node, defaultEmail))
+	}
+
+	findings := extractFindingKeys(previousResults)
+	if len(findings) == 0 {
+		// Fall back to raw outputs for scanners without recognised findings
+		for _, result := range previousResults {
+			if nodeMap, ok := result.(map[string]interface{}); ok {
+				if output, ok := nodeMap["output"].(string); ok {
+					parts = append(parts, "output:"+output)
+				}
+			}
+		}
+	}
+	for key := range findings {
+		parts = append(parts, "finding:"+key)
+	}
+
+	return NotificationFingerprint(parts...)
+}
+
 // getNotificationEmail extracts recipient from node config or user
 func (e *WorkflowExecutor) getNotificationEmail(node *WorkflowNode, defaultEmail string) string {
 	if config, ok := node.Data["config"].(map[string]interface{}); ok {
@@ -549,11 +945,41 @@ func (e *WorkflowExecutor) getNotificationEmail(node *WorkflowNode, defaultEmail
 	return defaultEmail
 }
 
-// getTarget extracts target from previous results
-func (e *WorkflowExecutor) getTarget(previousResults map[string]interface{}) string {
-	for _, result := range previousResults {
-		if resultMap, ok := result.(map[string]interface{}); ok {
-			if target, ok := resultMap["target"].(string); ok {
+// getSlackWebhookURL extracts a Slack node's own incoming webhook from its config, or returns
+// "" to use the server's
+func getSlackWebhookURL(node *WorkflowNode) string {
+	if config, ok := node.Data["config"].(map[string]interface{}); ok {
+		if webhookURL, ok := config["webhook_url"].(string); ok && webhookURL != "" {
+			return webhookURL
+		}
+	}
+	webhookURL, _ := node.Data["webhook_url"].(string)
+	return webhookURL
+}
+
+// getTarget resolves the target a node works on: the "target" in its own data, else that of
+// the nearest upstream node with one, so each chain of a workflow with several triggers keeps
+// its own target. Failing both it takes any result's target, as single-trigger workflows did.
+func (e *WorkflowExecutor) getTarget(node *WorkflowNode, previousResults map[string]interface{}) string {
+	if target, _ := node.Data["target"].(string); target != "" {
+		return target
+	}
+	for _, nodeID := range node.upstream {
+		if resultMap, ok := previousResults[nodeID].(map[string]interface{}); ok {
+			if target, ok := resultMap["target"].(string); ok && target != "" {
+				return target
+			}
+		}
+	}
+
+	nodeIDs := make([]string, 0, len(previousResults))
+	for nodeID := range previousResults {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	sort.Strings(nodeIDs)
+	for _, nodeID := range nodeIDs {
+		if resultMap, ok := previousResults[nodeID].(map[string]interface{}); ok {
+			if target, ok := resultMap["target"].(string); ok && target != "" {
 				return target
 			}
 		}
@@ -561,6 +987,103 @@ func (e *WorkflowExecutor) getTarget(previousResults map[string]interface{}) str
 	return ""
 }
 
+// timeOutExecution marks the remaining nodes as skipped and finalizes the execution as timed out
+func (e *WorkflowExecutor) timeOutExecution(ctx context.Context, executionID uuid.UUID, remaining []string, results map[string]interface{}, maxDuration int) {
+	for _, nodeID := range remaining {
+		results[nodeID] = map[string]interface{}{
+			"status": "skipped",
+			"reason": "workflow time budget exceeded",
+		}
+	}
+
+	errorMsg := fmt.Sprintf("Workflow exceeded its maximum duration of %ds", maxDuration)
+	logf(ctx, "⏱️ Workflow execution timed out: %s - %s", executionID, errorMsg)
+	completedTime := time.Now()
+	severitySummary, riskGrade := riskSummary(results)
+	e.db.Model(&models.WorkflowExecution{}).Where("id = ?", executionID).Updates(map[string]interface{}{
+		"status":           "timed_out",
+		"error":            errorMsg,
+		"results":          models.JSONMap(results),
+		"progress":         100,
+		"completed_at":     completedTime,
+		"severity_summary": severitySummaryJSON(severitySummary),
+		"risk_grade":       riskGrade,
+	})
+}
+
+// cancelExecution marks the nodes that didn't finish as skipped and finalizes the execution as
+// cancelled, keeping the results of the nodes that did
+func (e *WorkflowExecutor) cancelExecution(ctx context.Context, executionID uuid.UUID, remaining []string, results map[string]interface{}) {
+	for _, nodeID := range remaining {
+		results[nodeID] = map[string]interface{}{
+			"status": "skipped",
+			"reason": "execution cancelled",
+		}
+	}
+
+	logf(ctx, "🛑 Workflow execution cancelled: %s", executionID)
+	completedTime := time.Now()
+	severitySummary, riskGrade := riskSummary(results)
+	e.db.Model(&models.WorkflowExecution{}).Where("id = ?", executionID).Updates(map[string]interface{}{
+		"status":           "cancelled",
+		"error":            "Execution cancelled",
+		"results":          models.JSONMap(results),
+		"progress":         100,
+		"completed_at":     completedTime,
+		"severity_summary": severitySummaryJSON(severitySummary),
+		"risk_grade":       riskGrade,
+	})
+}
+
+// nodeTimeoutFor returns how long a node may run: its own timeout in seconds from the node's
+// data, or the server default (0 = no limit beyond the workflow's)
+func (e *WorkflowExecutor) nodeTimeoutFor(node *WorkflowNode) time.Duration {
+	if seconds, ok := node.Data["timeout"].(float64); ok && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	return e.nodeTimeout
+}
+
+// timeOutNode records a node that ran past its timeout as failed, skips the nodes after it and
+// finalizes the execution as failed
+func (e *WorkflowExecutor) timeOutNode(ctx context.Context, executionID uuid.UUID, node *WorkflowNode, timeout time.Duration, remaining []string, results map[string]interface{}) {
+	errorMsg := fmt.Sprintf("node %s timed out after %s", node.ID, timeout)
+	results[node.ID] = map[string]interface{}{
+		"status": "failed",
+		"error":  errorMsg,
+	}
+	for _, nodeID := range remaining {
+		results[nodeID] = map[string]interface{}{
+			"status": "skipped",
+			"reason": errorMsg,
+		}
+	}
+
+	logf(ctx, "❌ Workflow execution failed: %s - %s", executionID, errorMsg)
+	completedTime := time.Now()
+	severitySummary, riskGrade := riskSummary(results)
+	e.db.Model(&models.WorkflowExecution{}).Where("id = ?", executionID).Updates(map[string]interface{}{
+		"status":           "failed",
+		"error":            errorMsg,
+		"results":          models.JSONMap(results),
+		"progress":         100,
+		"completed_at":     completedTime,
+		"severity_summary": severitySummaryJSON(severitySummary),
+		"risk_grade":       riskGrade,
+	})
+}
+
+// saveLog persists the execution's log lines once it has finished
+func (e *WorkflowExecutor) saveLog(ctx context.Context, executionID uuid.UUID) {
+	executionLog, ok := ctx.Value(executionLogKey{}).(*executionLog)
+	if !ok {
+		return
+	}
+	if err := e.db.Model(&models.WorkflowExecution{}).Where("id = ?", executionID).Update("logs", executionLog.Snapshot()).Error; err != nil {
+		utils.Logger(ctx).Warn("failed to save execution logs", "error", err)
+	}
+}
+
 // findNode finds a node by ID
 func (e *WorkflowExecutor) findNode(nodes []WorkflowNode, nodeID string) *WorkflowNode {
 	for i := range nodes {
@@ -572,8 +1095,8 @@ func (e *WorkflowExecutor) findNode(nodes []WorkflowNode, nodeID string) *Workfl
 }
 
 // failExecution marks execution as failed
-func (e *WorkflowExecutor) failExecution(executionID uuid.UUID, errorMsg string) {
-	log.Printf("❌ Workflow execution failed: %s - %s", executionID, errorMsg)
+func (e *WorkflowExecutor) failExecution(ctx context.Context, executionID uuid.UUID, errorMsg string) {
+	logf(ctx, "❌ Workflow execution failed: %s - %s", executionID, errorMsg)
 	completedTime := time.Now()
 	e.db.Model(&models.WorkflowExecution{}).Where("id = ?", executionID).Updates(map[string]interface{}{
 		"status":       "failed",
@@ -583,8 +1106,8 @@ func (e *WorkflowExecutor) failExecution(executionID uuid.UUID, errorMsg string)
 }
 
 // executeGitHubIssue creates a GitHub issue with results
-func (e *WorkflowExecutor) executeGitHubIssue(node *WorkflowNode, previousResults map[string]interface{}, userID uuid.UUID) (interface{}, error) {
-	log.Printf("🐙 Creating GitHub Issue")
+func (e *WorkflowExecutor) executeGitHubIssue(ctx context.Context, node *WorkflowNode, previousResults map[string]interface{}, userID uuid.UUID) (interface{}, error) {
+	logf(ctx, "🐙 Creating GitHub Issue")
 
 	// Fetch user to get access token
 	var user models.User
@@ -595,9 +1118,17 @@ func (e *WorkflowExecutor) executeGitHubIssue(node *WorkflowNode, previousResult
 	if user.AccessToken == "" {
 		return nil, fmt.Errorf("user has no GitHub access token")
 	}
+	if err := RequireGitHubWriteScope(&user, "creating an issue"); err != nil {
+		return nil, err
+	}
+
+	language, err := reportLanguage(node, &user)
+	if err != nil {
+		return nil, err
+	}
 
 	// Get target from previous results
-	target := e.getTarget(previousResults)
+	target := e.getTarget(node, previousResults)
 	if target == "" {
 		return nil, fmt.Errorf("no target found for issue creation")
 	}
@@ -625,51 +1156,148 @@ func (e *WorkflowExecutor) executeGitHubIssue(node *WorkflowNode, previousResult
 		return nil, fmt.Errorf("could not determine GitHub owner/repo from target: %s", target)
 	}
 
+	// Raw recon output posted to a public repository is visible to anyone, so unless the
+	// node asks otherwise only private repositories get it
+	defaultDetail := ReportDetailSummary
+	if repository, err := e.githubService.GetRepository(ctx, user.AccessToken, owner, repo); err != nil {
+		logf(ctx, "⚠️ Couldn't tell whether %s/%s is private, leaving out raw output: %v", owner, repo, err)
+	} else if repository.Private {
+		defaultDetail = ReportDetailFull
+	}
+	detail, err := reportDetail(node, defaultDetail)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate Issue Content
+	title := fmt.Sprintf("Security Vulnerabilities Detected in %s/%s", owner, repo)
+	body := e.issueBody(ctx, previousResults, detail, language)
+
+	// Create Issue
+	issue, err := e.githubService.CreateIssue(ctx, user.AccessToken, owner, repo, title, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create github issue: %v", err)
+	}
+
+	logf(ctx, "✅ Created GitHub Issue #%d: %s", issue.Number, issue.HTMLURL)
+
+	return map[string]interface{}{
+		"type":         "github-issue",
+		"issue_url":    issue.HTMLURL,
+		"issue_id":     issue.ID,
+		"issue_number": issue.Number,
+		"status":       "created",
+		"repository":   fmt.Sprintf("%s/%s", owner, repo),
+		"detail":       detail,
+	}, nil
+}
+
+// issueBody writes the body of an issue filing the scan results: the raw output of each scan,
+// or just the finding counts for summary detail, under an AI analysis when one can be made
+func (e *WorkflowExecutor) issueBody(ctx context.Context, previousResults map[string]interface{}, detail, language string) string {
 	// Aggregate results for Issue Body
 	var scanSummaries string
-	for nodeID, result := range previousResults {
-		if nodeMap, ok := result.(map[string]interface{}); ok {
-			// Check for structured data first
-			if data, ok := nodeMap["data"]; ok {
-				formatted := formatScanData(data)
-				scanSummaries += fmt.Sprintf("## Scan: %s (Node %s)\n%s\n\n", nodeMap["scanner"], nodeID, formatted)
-			} else if output, ok := nodeMap["output"].(string); ok {
-				scanSummaries += fmt.Sprintf("## Scan: %s (Node %s)\n```\n%s\n```\n\n", nodeMap["scanner"], nodeID, output)
+	if detail == ReportDetailFull {
+		for nodeID, result := range previousResults {
+			if nodeMap, ok := result.(map[string]interface{}); ok {
+				// Check for structured data first
+				if data, ok := nodeMap["data"]; ok {
+					formatted := formatScanData(data)
+					scanSummaries += fmt.Sprintf("## Scan: %s (Node %s)\n%s\n\n", nodeMap["scanner"], nodeID, formatted)
+				} else if output, ok := nodeMap["output"].(string); ok {
+					scanSummaries += fmt.Sprintf("## Scan: %s (Node %s)\n```\n%s\n```\n\n", nodeMap["scanner"], nodeID, output)
+				}
 			}
 		}
+	} else if len(extractFindings(previousResults)) > 0 {
+		scanSummaries = findingCounts(previousResults)
 	}
 
-	// Generate Issue Content
-	title := fmt.Sprintf("Security Vulnerabilities Detected in %s/%s", owner, repo)
 	body := fmt.Sprintf("# Security Scan Results\n\nAutomated scan detected potential issues.\n\n%s\n\n*Report generated by VulnPilot*", scanSummaries)
 
 	// Use AI to generate better title/body if available
 	if scanSummaries != "" {
-		aiRecommendation, err := e.aiService.GenerateSecurityRecommendations(context.Background(), scanSummaries)
+		aiRecommendation, err := e.aiService.GenerateSecurityRecommendations(ctx, scanSummaries, reportFindings(previousResults), language)
 		if err == nil {
-			body = fmt.Sprintf("# Security Analysis\n\n%s\n\n## Raw Logs\n\n%s", aiRecommendation, scanSummaries)
+			section := "Raw Logs"
+			if detail == ReportDetailSummary {
+				section = "Findings"
+			}
+			body = fmt.Sprintf("# Security Analysis\n\n%s\n\n## %s\n\n%s", aiRecommendation, section, scanSummaries)
 		}
 	}
+	return body
+}
 
-	// Create Issue
-	issue, err := e.githubService.CreateIssue(context.Background(), user.AccessToken, owner, repo, title, body)
+// executeJiraIssue files the scan results as an issue in a Jira Cloud project. The site, account
+// email, project key and the name of the secret holding the API token come from the node's config.
+func (e *WorkflowExecutor) executeJiraIssue(ctx context.Context, node *WorkflowNode, previousResults map[string]interface{}, userID uuid.UUID) (interface{}, error) {
+	logf(ctx, "📋 Creating Jira Issue")
+
+	config, _ := node.Data["config"].(map[string]interface{})
+	baseURL, _ := config["base_url"].(string)
+	email, _ := config["email"].(string)
+	projectKey, _ := config["project_key"].(string)
+	tokenSecret, _ := config["api_token"].(string)
+	if baseURL == "" || email == "" || projectKey == "" || tokenSecret == "" {
+		return nil, fmt.Errorf("jira-issue node needs config.base_url, config.email, config.project_key and config.api_token")
+	}
+	apiToken, err := e.secrets.Resolve(userID, tokenSecret)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create github issue: %v", err)
+		return nil, err
 	}
 
-	log.Printf("✅ Created GitHub Issue #%d: %s", issue.Number, issue.HTMLURL)
+	var user models.User
+	if err := e.db.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %v", err)
+	}
+	language, err := reportLanguage(node, &user)
+	if err != nil {
+		return nil, err
+	}
+
+	target := e.getTarget(node, previousResults)
+	if target == "" {
+		return nil, fmt.Errorf("no target found for issue creation")
+	}
+
+	// Jira projects aren't public, so raw output is included unless the node asks otherwise
+	detail, err := reportDetail(node, ReportDetailFull)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := fmt.Sprintf("Security Vulnerabilities Detected in %s", target)
+	issue, err := e.jiraService.CreateIssue(ctx, baseURL, email, apiToken, projectKey, summary, e.issueBody(ctx, previousResults, detail, language))
+	if err != nil {
+		return nil, err
+	}
+
+	logf(ctx, "✅ Created Jira Issue %s: %s", issue.Key, issue.URL)
 
 	return map[string]interface{}{
-		"type":       "github-issue",
-		"issue_url":  issue.HTMLURL,
-		"issue_id":   issue.ID,
-		"status":     "created",
-		"repository": fmt.Sprintf("%s/%s", owner, repo),
+		"type":      "jira-issue",
+		"issue_url": issue.URL,
+		"issue_id":  issue.ID,
+		"issue_key": issue.Key,
+		"status":    "created",
+		"project":   projectKey,
+		"detail":    detail,
 	}, nil
 }
 
-func (e *WorkflowExecutor) executeAutoFix(node *WorkflowNode, previousResults map[string]interface{}, userID uuid.UUID) (interface{}, error) {
-	log.Printf("🔧 Execute Auto-Fix Agent")
+func (e *WorkflowExecutor) executeAutoFix(ctx context.Context, node *WorkflowNode, previousResults map[string]interface{}, userID uuid.UUID) (interface{}, error) {
+	logf(ctx, "🔧 Execute Auto-Fix Agent")
+
+	// Fixes are AI-generated, so without a provider the step is skipped instead of failing the run
+	if !e.aiService.Configured() {
+		logf(ctx, "⏭️ Skipping auto-fix: no AI configured")
+		return map[string]interface{}{
+			"type":   "auto-fix",
+			"status": "skipped",
+			"reason": "no AI configured",
+		}, nil
+	}
 
 	// 1. Authenticate
 	var user models.User
@@ -680,9 +1308,12 @@ func (e *WorkflowExecutor) executeAutoFix(node *WorkflowNode, previousResults ma
 	if user.AccessToken == "" {
 		return nil, fmt.Errorf("user has no GitHub access token")
 	}
+	if err := RequireGitHubWriteScope(&user, "opening a fix pull request"); err != nil {
+		return nil, err
+	}
 
 	// 2. Parse Context (Owner, Repo, Path, Branch)
-	target := e.getTarget(previousResults)
+	target := e.getTarget(node, previousResults)
 	owner, repo := e.parseGitHubTarget(target)
 
 	if val, ok := node.Data["owner"].(string); ok && val != "" {
@@ -701,34 +1332,9 @@ func (e *WorkflowExecutor) executeAutoFix(node *WorkflowNode, previousResults ma
 	// Dynamic Path Inference
 	// If path is missing, try to find it in previous scanner results
 	if path == "" {
-		log.Printf("🔍 Path not provided. searching previous scanner results...")
-		for _, result := range previousResults {
-			if resMap, ok := result.(map[string]interface{}); ok {
-				// Check Gitleaks/Semgrep findings
-				if output, ok := resMap["output"].(string); ok {
-					// Extremely simple heuristic to find a file path in JSON
-					// In a real app, unmarshal properly based on scanner type
-					if strings.Contains(output, `"file": "`) {
-						start := strings.Index(output, `"file": "`) + 9
-						end := strings.Index(output[start:], `"`)
-						if start > 9 && end > 0 {
-							path = output[start : start+end]
-							log.Printf("🎯 Inferred path from scanner: %s", path)
-							break
-						}
-					}
-					// Semgrep style
-					if strings.Contains(output, `"path": "`) {
-						start := strings.Index(output, `"path": "`) + 9
-						end := strings.Index(output[start:], `"`)
-						if start > 9 && end > 0 {
-							path = output[start : start+end]
-							log.Printf("🎯 Inferred path from scanner: %s", path)
-							break
-						}
-					}
-				}
-			}
+		logf(ctx, "🔍 Path not provided. searching previous scanner results...")
+		if path = sourceFindingPath(previousResults); path != "" {
+			logf(ctx, "🎯 Inferred path from scanner: %s", path)
 		}
 	}
 
@@ -737,17 +1343,29 @@ func (e *WorkflowExecutor) executeAutoFix(node *WorkflowNode, previousResults ma
 	}
 
 	// 3. Fetch File Content
-	log.Printf("📖 Reading file: %s/%s/%s", owner, repo, path)
-	content, err := e.githubService.GetFileContent(context.Background(), user.AccessToken, owner, repo, path)
+	logf(ctx, "📖 Reading file: %s/%s/%s", owner, repo, path)
+	content, err := e.githubService.GetFileContent(ctx, user.AccessToken, owner, repo, path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %v", err)
 	}
 
+	// When a scanner pinpointed lines in the file, only those (with some context) are
+	// sent to the AI and replaced; otherwise the whole file is regenerated
+	code := content
+	location, hasLocation := fixLocation(previousResults, path)
+	window, surgical := lineWindow{}, false
+	if hasLocation {
+		if window, surgical = windowAround(content, location, e.aiService.FixContextLines()); surgical {
+			code = strings.Join(window.Lines, "\n")
+			logf(ctx, "🎯 Fixing lines %d-%d of %s", window.From+1, window.To, path)
+		}
+	}
+
 	// 4. Identify Vulnerability
 	vulnerability, _ := node.Data["vulnerability"].(string)
 	if vulnerability == "" {
 		// If not provided, analyze the code now
-		log.Printf("🔍 Analyzing code for vulnerabilities...")
+		logf(ctx, "🔍 Analyzing code for vulnerabilities...")
 
 		// Check for previous scanner results to help the analysis
 		var scannerContext string
@@ -764,70 +1382,117 @@ func (e *WorkflowExecutor) executeAutoFix(node *WorkflowNode, previousResults ma
 		// ... simplified language detection ...
 
 		// Pass scanner context if available
-		inputContext := content
+		inputContext := code
 		if scannerContext != "" {
-			inputContext = fmt.Sprintf("SCANNER FINDINGS:\n%s\n\nCODE TO FIX:\n%s", scannerContext, content)
+			inputContext = fmt.Sprintf("SCANNER FINDINGS:\n%s\n\nCODE TO FIX:\n%s", scannerContext, code)
 		}
 
-		analysis, err := e.aiService.AnalyzeCode(context.Background(), inputContext, lang)
+		analysis, err := e.aiService.AnalyzeCode(ctx, inputContext, lang)
 		if err != nil {
-			return nil, fmt.Errorf("analysis failed: %v", err)
+			return nil, fmt.Errorf("analysis failed: %w", err)
 		}
 		vulnerability = analysis
 	}
 
 	// 5. Generate Fix
-	log.Printf("🤖 Generating fix for vulnerability...")
-	fixedCode, err := e.aiService.GenerateFix(context.Background(), content, vulnerability)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate fix: %v", err)
+	logf(ctx, "🤖 Generating fix for vulnerability...")
+	var fixedCode string
+	if surgical {
+		fixedLines, err := e.aiService.GenerateLineFix(ctx, path, window.From+1, code, vulnerability)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate fix: %w", err)
+		}
+		fixedCode = spliceLines(content, window, fixedLines)
+	} else {
+		fixedCode, err = e.aiService.GenerateFix(ctx, content, vulnerability)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate fix: %w", err)
+		}
 	}
 
 	// 6. Create Branch
 	fixBranch := fmt.Sprintf("fix/vuln-%d", time.Now().Unix())
-	log.Printf("🌿 Creating branch: %s", fixBranch)
+	logf(ctx, "🌿 Creating branch: %s", fixBranch)
 
 	// Get base SHA
-	ref, err := e.githubService.GetReference(context.Background(), user.AccessToken, owner, repo, "heads/"+branch)
+	ref, err := e.githubService.GetReference(ctx, user.AccessToken, owner, repo, "heads/"+branch)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get base ref: %v", err)
 	}
 
 	// Create branch
-	if err := e.githubService.CreateBranch(context.Background(), user.AccessToken, owner, repo, fixBranch, ref.Object.Sha); err != nil {
+	if err := e.githubService.CreateBranch(ctx, user.AccessToken, owner, repo, fixBranch, ref.Object.Sha); err != nil {
 		return nil, fmt.Errorf("failed to create branch: %v", err)
 	}
 
 	// 7. Update File (Commit)
 	// Get file SHA for update
-	fileSha, err := e.githubService.GetFileSHA(context.Background(), user.AccessToken, owner, repo, path, fixBranch)
+	fileSha, err := e.githubService.GetFileSHA(ctx, user.AccessToken, owner, repo, path, fixBranch)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get file sha: %v", err)
 	}
 
-	log.Printf("💾 Committing fix...")
-	if err := e.githubService.UpdateFile(context.Background(), user.AccessToken, owner, repo, path, fixedCode, fileSha, "fix: resolve security vulnerability", fixBranch); err != nil {
+	logf(ctx, "💾 Committing fix...")
+	if err := e.githubService.UpdateFile(ctx, user.AccessToken, owner, repo, path, fixedCode, fileSha, "fix: resolve security vulnerability", fixBranch); err != nil {
 		return nil, fmt.Errorf("failed to update file: %v", err)
 	}
 
 	// 8. Create Pull Request
-	log.Printf("🚀 Creating Pull Request...")
+	logf(ctx, "🚀 Creating Pull Request...")
 	prTitle := "fix: resolve security vulnerability in " + path
 	prBody := fmt.Sprintf("This PR fixes a detected vulnerability.\n\n**Vulnerability:**\n%s\n\n*Generated by VulnPilot*", vulnerability)
 
-	pr, err := e.githubService.CreatePullRequest(context.Background(), user.AccessToken, owner, repo, prTitle, prBody, fixBranch, branch)
+	pr, err := e.githubService.CreatePullRequest(ctx, user.AccessToken, owner, repo, prTitle, prBody, fixBranch, branch)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create PR: %v", err)
 	}
 
-	return map[string]interface{}{
+	result := map[string]interface{}{
 		"type":      "auto-fix",
 		"pr_url":    pr.HTMLURL,
 		"pr_number": pr.Number,
 		"status":    "created",
 		"branch":    fixBranch,
 		"output":    fmt.Sprintf("Auto-Fix PR Created: %s", pr.HTMLURL),
-	}, nil
+
+		// What a rollback needs to close the PR or revert it once merged
+		"owner":       owner,
+		"repo":        repo,
+		"path":        path,
+		"base_branch": branch,
+		"base_sha":    ref.Object.Sha,
+	}
+	if surgical {
+		result["location"] = location
+		result["lines"] = map[string]int{"from": window.From + 1, "to": window.To}
+	}
+
+	// 9. Optionally rescan the fix branch and report whether the finding is gone
+	if verify, _ := node.Data["verifyFix"].(bool); verify {
+		logf(ctx, "🔁 Verifying fix on branch %s...", fixBranch)
+		verification := e.verifyFix(ctx, fixTarget{
+			token:    user.AccessToken,
+			owner:    owner,
+			repo:     repo,
+			branch:   fixBranch,
+			path:     path,
+			prNumber: pr.Number,
+		}, previousResults)
+		logf(ctx, "🔁 Fix verification for PR #%d: %s", pr.Number, verification.Status)
+		result["verification"] = verification
+	}
+
+	return result, nil
+}
+
+// sourceFindingPath returns the file of the first secret or SAST finding in node results
+func sourceFindingPath(results map[string]interface{}) string {
+	for _, f := range extractFindings(results) {
+		if (f.Scanner == "gitleaks" || f.Scanner == "semgrep") && f.Path != "" {
+			return f.Path
+		}
+	}
+	return ""
 }
 
 func (e *WorkflowExecutor) parseGitHubTarget(target string) (string, string) {
@@ -859,10 +1524,10 @@ func splitParam(s, sep string) []string {
 }
 
 // executeFlowChart handles flow-chart nodes (pass-through)
-func (e *WorkflowExecutor) executeFlowChart(node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
-	log.Printf("📊 Executing Flow Chart Node (Pass-through)")
+func (e *WorkflowExecutor) executeFlowChart(ctx context.Context, node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
+	logf(ctx, "📊 Executing Flow Chart Node (Pass-through)")
 
-	target := e.getTarget(previousResults)
+	target := e.getTarget(node, previousResults)
 
 	return map[string]interface{}{
 		"type":   "flow-chart",
@@ -871,6 +1536,32 @@ func (e *WorkflowExecutor) executeFlowChart(node *WorkflowNode, previousResults
 	}, nil
 }
 
+// reportLanguage picks the AI output language: node config first, then the user's profile
+func reportLanguage(node *WorkflowNode, user *models.User) (string, error) {
+	if lang, ok := node.Data["language"].(string); ok && lang != "" {
+		return NormalizeLanguage(lang)
+	}
+	if code, err := NormalizeLanguage(user.Preferences.Language); err == nil {
+		return code, nil
+	}
+	return DefaultLanguage, nil
+}
+
+// userPreferences loads the user's defaults, normalizing the language so it is always usable
+func (e *WorkflowExecutor) userPreferences(ctx context.Context, userID uuid.UUID) models.UserPreferences {
+	var user models.User
+	if err := e.db.First(&user, "id = ?", userID).Error; err != nil {
+		logf(ctx, "⚠️ Failed to load preferences for user %s: %v", userID, err)
+	}
+	prefs := user.Preferences
+	if code, err := NormalizeLanguage(prefs.Language); err == nil {
+		prefs.Language = code
+	} else {
+		prefs.Language = DefaultLanguage
+	}
+	return prefs
+}
+
 func formatScanData(data interface{}) string {
 	// If it's the specific Nikto format we use
 	if dataMap, ok := data.(map[string]interface{}); ok {
@@ -891,109 +1582,3 @@ func formatScanData(data interface{}) string {
 	bytes, _ := json.MarshalIndent(data, "", "  ")
 	return fmt.Sprintf("```json\n%s\n```", string(bytes))
 }
-
-// executeSecretScan simulates a Gitleaks scan
-func (e *WorkflowExecutor) executeSecretScan(node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
-	log.Printf("🔑 Executing Secret Scan (Gitleaks)...")
-	time.Sleep(2 * time.Second) // Simulate work
-
-	// Mock findings: Using README.md as it likely exists in any repo
-	output := `
-{
-  "findings": [
-    {
-      "rule": "generic-secret",
-      "file": "README.md",
-      "startLine": 1,
-      "secret": "password123",
-      "message": "Simulated secret found for Auto-Fix testing"
-    }
-  ]
-}`
-	return map[string]interface{}{
-		"scanner": "gitleaks",
-		"status":  "completed",
-		"output":  output,
-		"data": map[string]interface{}{
-			"leaked_secrets": 1,
-			"files_scanned":  15,
-		},
-	}, nil
-}
-
-// executeDependencyCheck simulates a Trivy/SCA scan
-func (e *WorkflowExecutor) executeDependencyCheck(node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
-	log.Printf("📦 Executing Dependency Check (Trivy)...")
-	time.Sleep(2 * time.Second)
-
-	output := `
-{
-  "Target": "go.mod",
-  "Vulnerabilities": [
-    {
-      "VulnerabilityID": "CVE-2023-1234",
-      "PkgName": "golang.org/x/net",
-      "InstalledVersion": "v0.7.0",
-      "FixedVersion": "v0.17.0",
-      "Severity": "HIGH"
-    }
-  ]
-}`
-	return map[string]interface{}{
-		"scanner": "trivy-sca",
-		"status":  "completed",
-		"output":  output,
-		"data": map[string]interface{}{
-			"vulnerabilities_found": 1,
-			"severity_high":         1,
-		},
-	}, nil
-}
-
-// executeSemgrep simulates a Semgrep SAST scan
-func (e *WorkflowExecutor) executeSemgrep(node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
-	log.Printf("🔬 Executing Semgrep SAST...")
-	time.Sleep(2 * time.Second)
-
-	// Mock findings: Using main.go as it likely exists
-	output := `
-{
-  "results": [
-    {
-      "check_id": "go.lang.security.audit.xss.reflect.xss",
-      "path": "main.go",
-      "start": { "line": 1, "col": 1 },
-      "extra": { "message": "Potential XSS vulnerability detected (Simulated)" }
-    }
-  ]
-}`
-	return map[string]interface{}{
-		"scanner": "semgrep",
-		"status":  "completed",
-		"output":  output,
-	}, nil
-}
-
-// executeContainerScan simulates a Container scan
-func (e *WorkflowExecutor) executeContainerScan(node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
-	log.Printf("🐳 Executing Container Scan...")
-	time.Sleep(2 * time.Second)
-
-	output := `
-{
-  "Image": "app:latest",
-  "OS": "alpine:3.14",
-  "Vulnerabilities": [
-    {
-      "ID": "CVE-2022-4567",
-      "Package": "openssl",
-      "Severity": "CRITICAL"
-    }
-  ]
-}`
-	return map[string]interface{}{
-		"scanner": "trivy-image",
-		"status":  "completed",
-		"output":  output,
-	}, nil
-}
@@ -0,0 +1,436 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func init() {
+	RegisterScanner(testScanner{})
+}
+
+// testScanner is a scanner node type for executor tests. It runs for data.sleepMs
+// milliseconds, stopping early when its context ends, then fails with data.fail if set.
+// Otherwise it reports data.output as the output of the scanner named by data.scanner.
+type testScanner struct{}
+
+func (testScanner) Name() string { return "test-scan" }
+
+func (testScanner) Describe() NodeType {
+	return NodeType{DisplayName: "Test Scan", Category: NodeCategoryUtility}
+}
+
+func (testScanner) Run(ctx context.Context, env *ScanEnv, node *WorkflowNode, previousResults map[string]interface{}) (interface{}, error) {
+	if ms, ok := node.Data["sleepMs"].(float64); ok {
+		select {
+		case <-time.After(time.Duration(ms) * time.Millisecond):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if message, ok := node.Data["fail"].(string); ok {
+		return nil, errors.New(message)
+	}
+	result := map[string]interface{}{
+		"scanner": "test-scan",
+		"target":  env.Target(node, previousResults),
+		"status":  "completed",
+	}
+	if scanner, ok := node.Data["scanner"].(string); ok {
+		result["scanner"] = scanner
+	}
+	if output, ok := node.Data["output"].(string); ok {
+		result["output"] = output
+	}
+	return withFindings(result), nil
+}
+
+// resultWrites records the columns of every update; the statements are never run
+type resultWrites struct {
+	mu     sync.Mutex
+	writes []map[string]interface{}
+}
+
+func newResultWritesDB(t *testing.T) (*gorm.DB, *resultWrites) {
+	t.Helper()
+	mock, _ := newMockDB(t)
+	db := mock.Session(&gorm.Session{DryRun: true, SkipDefaultTransaction: true})
+	w := &resultWrites{}
+	err := db.Callback().Update().After("gorm:update").Register("test:record_results", func(tx *gorm.DB) {
+		columns, _ := tx.Statement.Dest.(map[string]interface{})
+		w.mu.Lock()
+		w.writes = append(w.writes, columns)
+		w.mu.Unlock()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, w
+}
+
+func (w *resultWrites) last(t *testing.T) map[string]interface{} {
+	t.Helper()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.writes) == 0 {
+		t.Fatal("results were never written")
+	}
+	return w.writes[len(w.writes)-1]
+}
+
+// newTestExecutor returns an executor whose database writes are recorded instead of run
+func newTestExecutor(t *testing.T, limits config.WorkflowConfig) (*WorkflowExecutor, *resultWrites) {
+	t.Helper()
+	db, writes := newResultWritesDB(t)
+	scanner := NewScannerService(db, nil, NewResultSigner(""), nil, nil, nil, nil, nil, ScanSavePolicy{}, ScanMockPolicy{}, nil)
+	ai := NewAIService(&config.Config{}, NewCircuitBreakers(5, time.Minute, &http.Client{}))
+	return NewWorkflowExecutor(db, scanner, nil, nil, nil, ai, nil, nil, nil, limits), writes
+}
+
+func testNode(id, nodeType string) map[string]interface{} {
+	return map[string]interface{}{"id": id, "type": nodeType}
+}
+
+func testEdge(id, source, target string) map[string]interface{} {
+	return map[string]interface{}{"id": id, "source": source, "target": target}
+}
+
+// testWorkflow builds a workflow from nodes and the edges between them
+func testWorkflow(nodes, edges models.JSONArray) *models.Workflow {
+	return &models.Workflow{ID: uuid.New(), UserID: uuid.New(), Name: "test", Nodes: nodes, Edges: edges}
+}
+
+// runTestWorkflow runs the workflow the way a launch does and waits for it to finish
+func runTestWorkflow(t *testing.T, e *WorkflowExecutor, workflow *models.Workflow) uuid.UUID {
+	t.Helper()
+	execution := &models.WorkflowExecution{ID: uuid.New(), WorkflowID: workflow.ID, UserID: workflow.UserID}
+	e.start(context.Background(), execution, workflow)
+	waitForExecution(t, e, execution.ID)
+	return execution.ID
+}
+
+// waitForExecution blocks until the execution has left the registry of running executions
+func waitForExecution(t *testing.T, e *WorkflowExecutor, executionID uuid.UUID) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for e.active.has(executionID) {
+		if time.Now().After(deadline) {
+			t.Fatal("execution never finished")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// finalState returns the last status written for the execution and the results written with it
+func (w *resultWrites) finalState(t *testing.T) (string, map[string]interface{}) {
+	t.Helper()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := len(w.writes) - 1; i >= 0; i-- {
+		status, ok := w.writes[i]["status"].(string)
+		if !ok || status == "running" {
+			continue
+		}
+		results, _ := w.writes[i]["results"].(models.JSONMap)
+		return status, results
+	}
+	t.Fatal("the execution was never finalized")
+	return "", nil
+}
+
+// nodeStatus returns the status recorded in a node's result
+func nodeStatus(results map[string]interface{}, nodeID string) string {
+	result, _ := results[nodeID].(map[string]interface{})
+	status, _ := result["status"].(string)
+	return status
+}
+
+func TestExecutionCutOffAtMaxDuration(t *testing.T) {
+	e, writes := newTestExecutor(t, config.WorkflowConfig{MaxParallelNodes: 4})
+	workflow := testWorkflow(
+		models.JSONArray{
+			testNode("trigger", "trigger"),
+			map[string]interface{}{"id": "slow", "type": "test-scan", "data": map[string]interface{}{"sleepMs": 60000}},
+			testNode("after", "test-scan"),
+		},
+		models.JSONArray{testEdge("e1", "trigger", "slow"), testEdge("e2", "slow", "after")},
+	)
+	workflow.MaxDuration = 1
+
+	started := time.Now()
+	runTestWorkflow(t, e, workflow)
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Fatalf("workflow ran for %s past its 1s budget", elapsed)
+	}
+
+	status, results := writes.finalState(t)
+	if status != "timed_out" {
+		t.Fatalf("execution finished %s, want timed_out", status)
+	}
+	if got := nodeStatus(results, "trigger"); got != "" {
+		t.Fatalf("trigger result was replaced with %s", got)
+	}
+	for _, nodeID := range []string{"slow", "after"} {
+		if got := nodeStatus(results, nodeID); got != "skipped" {
+			t.Fatalf("node %s is %q, want skipped", nodeID, got)
+		}
+	}
+	if reason := results["after"].(map[string]interface{})["reason"]; reason != "workflow time budget exceeded" {
+		t.Fatalf("skip reason %v", reason)
+	}
+}
+
+func TestExecutionWithinMaxDurationCompletes(t *testing.T) {
+	e, writes := newTestExecutor(t, config.WorkflowConfig{MaxParallelNodes: 4})
+	nodes := models.JSONArray{testNode("trigger", "trigger")}
+	edges := models.JSONArray{}
+	for i := 0; i < 3; i++ {
+		id := fmt.Sprintf("scan-%d", i)
+		nodes = append(nodes, map[string]interface{}{"id": id, "type": "test-scan", "data": map[string]interface{}{"sleepMs": 10}})
+		edges = append(edges, testEdge("e-"+id, "trigger", id))
+	}
+	workflow := testWorkflow(nodes, edges)
+	workflow.MaxDuration = 30
+
+	runTestWorkflow(t, e, workflow)
+	if status, _ := writes.finalState(t); status != "completed" {
+		t.Fatalf("execution finished %s, want completed", status)
+	}
+}
+
+// trivyCritical is dependency-check output with one critical vulnerability
+const trivyCritical = `{"Target":"package-lock.json","Vulnerabilities":[{"VulnerabilityID":"CVE-2021-44228","PkgName":"log4j-core","Severity":"CRITICAL"},{"VulnerabilityID":"CVE-2020-0001","PkgName":"left-pad","Severity":"LOW"}]}`
+
+func stopOnCriticalWorkflow() *models.Workflow {
+	return testWorkflow(
+		models.JSONArray{
+			testNode("trigger", "trigger"),
+			map[string]interface{}{"id": "trivy", "type": "test-scan", "data": map[string]interface{}{"scanner": "trivy-sca", "output": trivyCritical}},
+			map[string]interface{}{"id": "slow", "type": "test-scan", "data": map[string]interface{}{"sleepMs": 200}},
+			testNode("after-trivy", "test-scan"),
+			testNode("after-slow", "test-scan"),
+		},
+		models.JSONArray{
+			testEdge("e1", "trigger", "trivy"),
+			testEdge("e2", "trigger", "slow"),
+			testEdge("e3", "trivy", "after-trivy"),
+			testEdge("e4", "slow", "after-slow"),
+		},
+	)
+}
+
+func TestStopOnCriticalSkipsRemainingNodes(t *testing.T) {
+	e, writes := newTestExecutor(t, config.WorkflowConfig{MaxParallelNodes: 4})
+	workflow := stopOnCriticalWorkflow()
+	workflow.StopOnCritical = true
+	runTestWorkflow(t, e, workflow)
+
+	status, results := writes.finalState(t)
+	if status != "completed" {
+		t.Fatalf("execution finished %s, want completed with what ran", status)
+	}
+	// The node already running when the critical finding came in finishes
+	for _, nodeID := range []string{"trivy", "slow"} {
+		if got := nodeStatus(results, nodeID); got != "completed" {
+			t.Fatalf("node %s is %q, want completed", nodeID, got)
+		}
+	}
+	for _, nodeID := range []string{"after-trivy", "after-slow"} {
+		result, _ := results[nodeID].(map[string]interface{})
+		if result["status"] != "skipped" || result["reason"] != "critical finding in node trivy" {
+			t.Fatalf("node %s recorded as %v, want skipped for the critical finding", nodeID, result)
+		}
+	}
+}
+
+func TestWithoutStopOnCriticalEveryNodeRuns(t *testing.T) {
+	e, writes := newTestExecutor(t, config.WorkflowConfig{MaxParallelNodes: 4})
+	runTestWorkflow(t, e, stopOnCriticalWorkflow())
+
+	_, results := writes.finalState(t)
+	for _, nodeID := range []string{"trivy", "slow", "after-trivy", "after-slow"} {
+		if got := nodeStatus(results, nodeID); got != "completed" {
+			t.Fatalf("node %s is %q, want completed", nodeID, got)
+		}
+	}
+}
+
+func TestCancelRunningExecution(t *testing.T) {
+	e, writes := newTestExecutor(t, config.WorkflowConfig{MaxParallelNodes: 4})
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	workflow := testWorkflow(
+		models.JSONArray{
+			testNode("trigger", "trigger"),
+			map[string]interface{}{"id": "slow", "type": "test-command", "data": map[string]interface{}{"pidFile": pidFile}},
+			testNode("after", "test-scan"),
+		},
+		models.JSONArray{testEdge("e1", "trigger", "slow"), testEdge("e2", "slow", "after")},
+	)
+	execution := &models.WorkflowExecution{ID: uuid.New(), WorkflowID: workflow.ID, UserID: workflow.UserID}
+	e.start(context.Background(), execution, workflow)
+	pid := waitForPID(t, pidFile)
+
+	if !e.Cancel(&models.WorkflowExecution{ID: execution.ID, Status: "running"}) {
+		t.Fatal("running execution was not cancelled")
+	}
+	waitForExecution(t, e, execution.ID)
+	if err := syscall.Kill(pid, 0); !errors.Is(err, syscall.ESRCH) {
+		t.Fatalf("the scanner is still running after the execution was cancelled (%v)", err)
+	}
+
+	status, results := writes.finalState(t)
+	if status != "cancelled" {
+		t.Fatalf("execution finished %s, want cancelled", status)
+	}
+	for _, nodeID := range []string{"slow", "after"} {
+		result, _ := results[nodeID].(map[string]interface{})
+		if result["status"] != "skipped" || result["reason"] != "execution cancelled" {
+			t.Fatalf("node %s recorded as %v, want skipped for the cancellation", nodeID, result)
+		}
+	}
+
+	// Nothing is written for the nodes after the cancellation
+	writes.mu.Lock()
+	defer writes.mu.Unlock()
+	for _, write := range writes.writes {
+		results, _ := write["results"].(models.JSONMap)
+		if status := nodeStatus(results, "after"); status != "" && status != "skipped" {
+			t.Fatalf("node after was recorded as %s", status)
+		}
+	}
+	if e.Cancel(&models.WorkflowExecution{ID: execution.ID, Status: "cancelled"}) {
+		t.Fatal("cancelled a finished execution")
+	}
+}
+
+func TestTopologicalOrderIsDeterministic(t *testing.T) {
+	// Three roots, listed out of ID order, fanning into shared nodes
+	workflow := testWorkflow(
+		models.JSONArray{
+			testNode("web", "trigger"), testNode("api", "trigger"), testNode("repo", "trigger"),
+			testNode("nmap", "nmap"), testNode("nikto", "nikto"), testNode("secrets", "secret-scan"),
+			testNode("report", "email"),
+		},
+		models.JSONArray{
+			testEdge("e1", "web", "nmap"), testEdge("e2", "web", "nikto"), testEdge("e3", "api", "nikto"),
+			testEdge("e4", "repo", "secrets"), testEdge("e5", "secrets", "report"),
+			testEdge("e6", "nikto", "report"), testEdge("e7", "nmap", "report"),
+		},
+	)
+	// Roots in the order the workflow lists them, then neighbors in the order of the edges
+	want := []string{"web", "api", "repo", "nmap", "nikto", "secrets", "report"}
+
+	e := &WorkflowExecutor{}
+	for i := 0; i < 100; i++ {
+		nodes, edges, err := e.parseWorkflow(workflow)
+		if err != nil {
+			t.Fatal(err)
+		}
+		order, err := topologicalOrder(nodes, edges)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(order, want) {
+			t.Fatalf("run %d ordered %q, want %q", i, order, want)
+		}
+	}
+}
+
+func TestEachChainScansItsOwnTrigger(t *testing.T) {
+	e, writes := newTestExecutor(t, config.WorkflowConfig{MaxParallelNodes: 4})
+	trigger := func(id, url string) map[string]interface{} {
+		return map[string]interface{}{"id": id, "type": "trigger", "data": map[string]interface{}{"sourceUrl": url}}
+	}
+	workflow := testWorkflow(
+		models.JSONArray{
+			trigger("trigger-b", "https://b.example.com"),
+			trigger("trigger-a", "https://a.example.com"),
+			testNode("scan-a", "test-scan"), testNode("after-a", "test-scan"),
+			testNode("scan-b", "test-scan"), testNode("after-b", "test-scan"),
+			map[string]interface{}{"id": "override", "type": "test-scan", "data": map[string]interface{}{"target": "https://c.example.com"}},
+		},
+		models.JSONArray{
+			testEdge("e1", "trigger-a", "scan-a"), testEdge("e2", "scan-a", "after-a"),
+			testEdge("e3", "trigger-b", "scan-b"), testEdge("e4", "scan-b", "after-b"),
+			testEdge("e5", "trigger-a", "override"),
+		},
+	)
+	runTestWorkflow(t, e, workflow)
+
+	_, results := writes.finalState(t)
+	want := map[string]string{
+		"scan-a": "https://a.example.com", "after-a": "https://a.example.com",
+		"scan-b": "https://b.example.com", "after-b": "https://b.example.com",
+		"override": "https://c.example.com",
+	}
+	for nodeID, target := range want {
+		if got := results[nodeID].(map[string]interface{})["target"]; got != target {
+			t.Errorf("node %s scanned %v, want %s", nodeID, got, target)
+		}
+	}
+}
+
+func TestGetTargetWithOneTrigger(t *testing.T) {
+	e := &WorkflowExecutor{}
+	previous := map[string]interface{}{"trigger": map[string]interface{}{"target": "https://example.com"}}
+	// A node that doesn't know its upstream nodes still finds the only trigger's target
+	if got := e.getTarget(&WorkflowNode{ID: "scan"}, previous); got != "https://example.com" {
+		t.Fatalf("getTarget() = %q, want the trigger's target", got)
+	}
+}
+
+func TestScannerNodesRecordScanResults(t *testing.T) {
+	e, _ := newTestExecutor(t, config.WorkflowConfig{MaxParallelNodes: 4})
+	var mu sync.Mutex
+	var saved []*models.ScanResult
+	e.scannerService.save = func(scanResult *models.ScanResult) error {
+		mu.Lock()
+		defer mu.Unlock()
+		saved = append(saved, scanResult)
+		return nil
+	}
+	workflow := testWorkflow(
+		models.JSONArray{
+			dataNode("trigger", "trigger", map[string]interface{}{"target": "example.com"}),
+			testNode("ok", "test-scan"),
+			dataNode("broken", "test-scan", map[string]interface{}{"fail": "connection refused", "onError": "continue"}),
+		},
+		models.JSONArray{testEdge("e1", "trigger", "ok"), testEdge("e2", "trigger", "broken")},
+	)
+
+	executionID := runTestWorkflow(t, e, workflow)
+
+	// Only the two scanner nodes are scans; the trigger isn't
+	if len(saved) != 2 {
+		t.Fatalf("saved %d scan results, want one per scanner node", len(saved))
+	}
+	sort.Slice(saved, func(i, j int) bool { return saved[i].Status < saved[j].Status })
+	for _, scan := range saved {
+		if *scan.ExecutionID != executionID || *scan.WorkflowID != workflow.ID || scan.UserID != workflow.UserID {
+			t.Errorf("scan %+v isn't linked to the execution, workflow and user", scan)
+		}
+		if scan.ScanType != "test-scan" || scan.TargetURL != "example.com" || scan.StartedAt == nil || scan.CompletedAt == nil {
+			t.Errorf("scan %+v lacks its type, target or times", scan)
+		}
+	}
+	if completed := saved[0]; completed.Status != "completed" || len(completed.Results) == 0 {
+		t.Errorf("successful node saved as %s with results %s", completed.Status, completed.Results)
+	}
+	if failed := saved[1]; failed.Status != "failed" || failed.ErrorMessage != "connection refused" {
+		t.Errorf("failed node saved as %s with error %q", failed.Status, failed.ErrorMessage)
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+)
+
+// maxWorkflowGenerationAttempts bounds how often the model is asked to correct invalid workflow JSON
+const maxWorkflowGenerationAttempts = 3
+
+// generatableNodeTypes are the node types the AI workflow generator may use
+var generatableNodeTypes = []string{
+	"trigger", "gobuster", "nikto", "nmap", "sqlmap", "wpscan", "zap-scan", "owasp-vulnerabilities",
+	"auto-fix", "email", "github-issue", "jira-issue", "slack", "notify", "webhook", "flow-chart",
+}
+
+// ErrWorkflowTooLarge is returned for a workflow graph with more nodes or edges than configured
+var ErrWorkflowTooLarge = errors.New("workflow too large")
+
+// checkGraphSize enforces the configured node and edge limits on a workflow graph
+func checkGraphSize(limits config.WorkflowConfig, nodes, edges int) error {
+	if nodes > limits.MaxNodes {
+		return fmt.Errorf("%w: %d nodes exceeds the limit of %d", ErrWorkflowTooLarge, nodes, limits.MaxNodes)
+	}
+	if edges > limits.MaxEdges {
+		return fmt.Errorf("%w: %d edges exceeds the limit of %d", ErrWorkflowTooLarge, edges, limits.MaxEdges)
+	}
+	return nil
+}
+
+// validateGeneratedWorkflow checks that AI output is a runnable workflow graph within limits.
+// Errors are phrased for the model, since they're fed back to it for correction.
+func validateGeneratedWorkflow(raw string, limits config.WorkflowConfig) error {
+	var workflow struct {
+		Nodes []WorkflowNode `json:"nodes"`
+		Edges []WorkflowEdge `json:"edges"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(raw)), &workflow); err != nil {
+		return fmt.Errorf("it is not valid JSON matching {\"nodes\": [...], \"edges\": [...]}: %v", err)
+	}
+	if len(workflow.Nodes) == 0 {
+		return fmt.Errorf("\"nodes\" is missing or empty")
+	}
+	if len(workflow.Nodes) > limits.MaxNodes || len(workflow.Edges) > limits.MaxEdges {
+		return fmt.Errorf("the workflow is too large; use at most %d nodes and %d edges", limits.MaxNodes, limits.MaxEdges)
+	}
+
+	allowed := make(map[string]bool, len(generatableNodeTypes))
+	for _, t := range generatableNodeTypes {
+		allowed[t] = true
+	}
+
+	ids := make(map[string]bool, len(workflow.Nodes))
+	triggers := 0
+	for i, node := range workflow.Nodes {
+		if node.ID == "" {
+			return fmt.Errorf("node %d has no \"id\"", i)
+		}
+		if ids[node.ID] {
+			return fmt.Errorf("node id %q is used more than once", node.ID)
+		}
+		ids[node.ID] = true
+		if !allowed[node.Type] {
+			return fmt.Errorf("node %q has unknown type %q; use one of %s", node.ID, node.Type, quotedList(generatableNodeTypes))
+		}
+		if node.Type == "trigger" {
+			triggers++
+		}
+	}
+	if triggers != 1 {
+		return fmt.Errorf("the workflow must have exactly one \"trigger\" node, found %d", triggers)
+	}
+
+	for _, edge := range workflow.Edges {
+		if !ids[edge.Source] || !ids[edge.Target] {
+			return fmt.Errorf("edge %q connects %q to %q but both must be node ids", edge.ID, edge.Source, edge.Target)
+		}
+	}
+	if _, err := topologicalOrder(workflow.Nodes, workflow.Edges); err != nil {
+		return fmt.Errorf("the edges form a cycle; workflows must be acyclic")
+	}
+
+	return nil
+}
+
+// quotedList renders values as a comma-separated list of JSON strings
+func quotedList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return strings.Join(quoted, ", ")
+}
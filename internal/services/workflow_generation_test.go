@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+)
+
+// groqAnswering serves each Groq call the next of answers, recording the prompts it was sent
+func groqAnswering(t *testing.T, answers []string, prompts *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req GroqRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		*prompts = append(*prompts, req.Messages[0].Content)
+		if len(*prompts) > len(answers) {
+			t.Errorf("unexpected call %d", len(*prompts))
+			return
+		}
+		answer, _ := json.Marshal(answers[len(*prompts)-1])
+		fmt.Fprintf(w, `{"choices":[{"message":{"content":%s}}]}`, answer)
+	}
+}
+
+func newWorkflowGenerator(t *testing.T, answers []string, prompts *[]string) *AIService {
+	t.Helper()
+	s := fakeProviders(t, []string{ProviderGroq}, map[string]http.HandlerFunc{
+		ProviderGroq: groqAnswering(t, answers, prompts),
+	})
+	s.config.Workflow = config.WorkflowConfig{MaxNodes: 20, MaxEdges: 40}
+	return s
+}
+
+const validGeneratedWorkflow = `{"nodes":[{"id":"1","type":"trigger","data":{"sourceUrl":"https://example.com"}},{"id":"2","type":"nmap"}],"edges":[{"id":"e1-2","source":"1","target":"2"}]}`
+
+func TestGenerateWorkflowJSONCorrectsInvalidOutput(t *testing.T) {
+	var prompts []string
+	s := newWorkflowGenerator(t, []string{
+		"```json\n{\"nodes\": [{\"id\": \"1\", \"type\": \"trigger\"},]}\n```",
+		validGeneratedWorkflow,
+	}, &prompts)
+
+	result, err := s.GenerateWorkflowJSON(context.Background(), "scan example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prompts) != 2 {
+		t.Fatalf("model was called %d times, want 2", len(prompts))
+	}
+	if strings.Contains(prompts[0], "previous output was invalid") {
+		t.Fatal("first prompt already asked for a correction")
+	}
+	if !strings.Contains(prompts[1], "Your previous output was invalid because: it is not valid JSON") {
+		t.Fatalf("correction prompt doesn't explain the error:\n%s", prompts[1])
+	}
+	if validateGeneratedWorkflow(result, s.config.Workflow) != nil {
+		t.Fatalf("returned invalid workflow %s", result)
+	}
+}
+
+func TestGenerateWorkflowJSONGivesUp(t *testing.T) {
+	var prompts []string
+	noTrigger := `{"nodes":[{"id":"1","type":"nmap"}],"edges":[]}`
+	s := newWorkflowGenerator(t, []string{noTrigger, noTrigger, noTrigger}, &prompts)
+
+	_, err := s.GenerateWorkflowJSON(context.Background(), "scan example.com")
+	if err == nil || !strings.Contains(err.Error(), "exactly one \"trigger\" node") {
+		t.Fatalf("got %v, want the last validation error", err)
+	}
+	if len(prompts) != maxWorkflowGenerationAttempts {
+		t.Fatalf("model was called %d times, want %d", len(prompts), maxWorkflowGenerationAttempts)
+	}
+}
+
+func TestValidateGeneratedWorkflow(t *testing.T) {
+	limits := config.WorkflowConfig{MaxNodes: 3, MaxEdges: 3}
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"valid", validGeneratedWorkflow, ""},
+		{"not JSON", `nodes: []`, "not valid JSON"},
+		{"no nodes", `{"nodes":[],"edges":[]}`, `"nodes" is missing or empty`},
+		{"too many nodes", `{"nodes":[{"id":"1","type":"trigger"},{"id":"2","type":"nmap"},{"id":"3","type":"nmap"},{"id":"4","type":"nmap"}]}`, "too large"},
+		{"missing id", `{"nodes":[{"type":"trigger"}]}`, `has no "id"`},
+		{"duplicate id", `{"nodes":[{"id":"1","type":"trigger"},{"id":"1","type":"nmap"}]}`, "used more than once"},
+		{"unknown type", `{"nodes":[{"id":"1","type":"trigger"},{"id":"2","type":"rm-rf"}]}`, `unknown type "rm-rf"`},
+		{"two triggers", `{"nodes":[{"id":"1","type":"trigger"},{"id":"2","type":"trigger"}]}`, "found 2"},
+		{"dangling edge", `{"nodes":[{"id":"1","type":"trigger"}],"edges":[{"id":"e","source":"1","target":"9"}]}`, "both must be node ids"},
+		{"cycle", `{"nodes":[{"id":"1","type":"trigger"},{"id":"2","type":"nmap"},{"id":"3","type":"nikto"}],"edges":[{"id":"a","source":"2","target":"3"},{"id":"b","source":"3","target":"2"}]}`, "cycle"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateGeneratedWorkflow(tt.raw, limits)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Fatal(err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Fatalf("got %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+)
+
+// Lint warning codes
+const (
+	LintNoTrigger           = "no-trigger"
+	LintUnreachable         = "unreachable-node"
+	LintUnknownType         = "unknown-node-type"
+	LintMissingTarget       = "missing-target"
+	LintMissingRepository   = "missing-repository"
+	LintMissingSourcePath   = "missing-source-path"
+	LintUnconfiguredChannel = "unconfigured-notification"
+	LintInvalidOnError      = "invalid-on-error"
+)
+
+// sourceFindingNodes are the node types whose findings give auto-fix a file to fix
+var sourceFindingNodes = map[string]bool{"secret-scan": true, "semgrep-scan": true}
+
+// LintWarning is a likely mistake in a workflow that doesn't stop it from being saved or run
+type LintWarning struct {
+	Code       string `json:"code"`
+	NodeID     string `json:"node_id,omitempty"` // Empty for warnings about the workflow as a whole
+	NodeType   string `json:"node_type,omitempty"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion"`
+}
+
+// LintWorkflow checks a saved workflow for common mistakes without running it
+func (s *WorkflowService) LintWorkflow(ctx context.Context, workflowID, userID uuid.UUID) ([]LintWarning, error) {
+	workflow, err := s.GetWorkflow(workflowID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.executor.lintWorkflow(ctx, workflow, userID)
+}
+
+// workflowGraph is a parsed workflow with its edges indexed both ways
+type workflowGraph struct {
+	nodes      []WorkflowNode
+	downstream map[string][]string
+	upstream   map[string][]string
+}
+
+func newWorkflowGraph(nodes []WorkflowNode, edges []WorkflowEdge) *workflowGraph {
+	g := &workflowGraph{
+		nodes:      nodes,
+		downstream: make(map[string][]string),
+		upstream:   make(map[string][]string),
+	}
+	for _, edge := range edges {
+		g.downstream[edge.Source] = append(g.downstream[edge.Source], edge.Target)
+		g.upstream[edge.Target] = append(g.upstream[edge.Target], edge.Source)
+	}
+	return g
+}
+
+// reachable returns the IDs reachable from the given nodes along the edges, including them
+func (g *workflowGraph) reachable(from []string, edges map[string][]string) map[string]bool {
+	seen := make(map[string]bool)
+	stack := append([]string(nil), from...)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		stack = append(stack, edges[id]...)
+	}
+	return seen
+}
+
+// nearestAncestors returns the IDs upstream of id, nearest first
+func (g *workflowGraph) nearestAncestors(id string) []string {
+	seen := map[string]bool{id: true}
+	var found []string
+	queue := append([]string(nil), g.upstream[id]...)
+	for len(queue) > 0 {
+		nodeID := queue[0]
+		queue = queue[1:]
+		if seen[nodeID] {
+			continue
+		}
+		seen[nodeID] = true
+		found = append(found, nodeID)
+		queue = append(queue, g.upstream[nodeID]...)
+	}
+	return found
+}
+
+// ancestors returns the nodes upstream of id, not including it, in workflow order
+func (g *workflowGraph) ancestors(id string) []*WorkflowNode {
+	upstream := g.reachable(g.upstream[id], g.upstream)
+	var found []*WorkflowNode
+	for i := range g.nodes {
+		if upstream[g.nodes[i].ID] && g.nodes[i].ID != id {
+			found = append(found, &g.nodes[i])
+		}
+	}
+	return found
+}
+
+// lintWorkflow runs every check over the parsed graph, returning warnings in node order
+func (e *WorkflowExecutor) lintWorkflow(ctx context.Context, workflow *models.Workflow, userID uuid.UUID) ([]LintWarning, error) {
+	nodes, edges, err := e.parseWorkflow(workflow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse workflow: %w", err)
+	}
+	g := newWorkflowGraph(nodes, edges)
+	warnings := []LintWarning{}
+
+	var triggers []string
+	for _, node := range nodes {
+		if node.Type == "trigger" {
+			triggers = append(triggers, node.ID)
+		}
+	}
+	if len(nodes) > 0 && len(triggers) == 0 {
+		warnings = append(warnings, LintWarning{
+			Code:       LintNoTrigger,
+			Message:    "The workflow has no trigger node, so nothing gives its scanners a target",
+			Suggestion: "Add a trigger node with a sourceUrl and connect it to the first scanner",
+		})
+	}
+	fromTrigger := g.reachable(triggers, g.downstream)
+
+	for i := range nodes {
+		node := &nodes[i]
+		warn := func(code, message, suggestion string) {
+			warnings = append(warnings, LintWarning{Code: code, NodeID: node.ID, NodeType: node.Type, Message: message, Suggestion: suggestion})
+		}
+
+		if _, isScanner := LookupScanner(node.Type); !isScanner && !isBuiltinNodeType(node.Type) {
+			warn(LintUnknownType, fmt.Sprintf("%q is not a known node type, so the run will fail here", node.Type),
+				"Replace it with a node type from GET /api/workflow/node-types")
+			continue
+		}
+		if len(triggers) > 0 && !fromTrigger[node.ID] {
+			warn(LintUnreachable, "No trigger leads to this node, so it gets none of the trigger's inputs",
+				"Connect it below a trigger or a node the trigger leads to, or remove it")
+		}
+
+		ancestors := g.ancestors(node.ID)
+		for _, input := range requiredInputs(node.Type) {
+			switch input {
+			case NodeInputTarget:
+				if override, _ := node.Data["target"].(string); override == "" && upstreamTarget(ancestors) == "" {
+					warn(LintMissingTarget, "No upstream trigger sets a sourceUrl, so this node has no target (an empty trigger scans example.com)",
+						"Connect a trigger with a sourceUrl upstream of this node, or set target on the node")
+				}
+			case NodeInputRepository:
+				if !hasRepository(e, node, ancestors) {
+					warn(LintMissingRepository, "This node has no GitHub repository to work on",
+						"Set owner and repo on the node, or trigger on a https://github.com/owner/repo URL upstream")
+				}
+			case NodeInputSourcePath:
+				if !hasSourcePath(node, ancestors) {
+					warn(LintMissingSourcePath, "This node has no file to fix: no path is set and no code scan runs before it",
+						"Set path on the node, or add a secret-scan or semgrep-scan node upstream")
+				}
+			}
+		}
+
+		if policy, ok := node.Data["onError"]; ok && !isOnErrorPolicy(policy) {
+			warn(LintInvalidOnError, fmt.Sprintf("onError %v is not a known policy, so a failure here fails the run", policy),
+				"Set onError to fail, skip or continue")
+		}
+
+		if problem, suggestion := e.notificationProblem(ctx, node, userID); problem != "" {
+			warn(LintUnconfiguredChannel, problem, suggestion)
+		}
+	}
+
+	order := make(map[string]int, len(nodes))
+	for i, node := range nodes {
+		order[node.ID] = i + 1 // Workflow-level warnings have no node and sort first
+	}
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return order[warnings[i].NodeID] < order[warnings[j].NodeID]
+	})
+	return warnings, ctx.Err()
+}
+
+func isOnErrorPolicy(value interface{}) bool {
+	for _, policy := range OnErrorPolicies {
+		if value == policy {
+			return true
+		}
+	}
+	return false
+}
+
+func isBuiltinNodeType(nodeType string) bool {
+	for _, builtin := range builtinNodeTypes {
+		if builtin.Type == nodeType {
+			return true
+		}
+	}
+	return false
+}
+
+// upstreamTarget returns the sourceUrl of the first upstream trigger that sets one
+func upstreamTarget(ancestors []*WorkflowNode) string {
+	for _, ancestor := range ancestors {
+		if ancestor.Type != "trigger" {
+			continue
+		}
+		if target, _ := ancestor.Data["sourceUrl"].(string); target != "" {
+			return target
+		}
+	}
+	return ""
+}
+
+// hasRepository mirrors the repository input check: owner and repo from the node's data,
+// falling back to an upstream trigger's GitHub URL
+func hasRepository(e *WorkflowExecutor, node *WorkflowNode, ancestors []*WorkflowNode) bool {
+	owner, repo := e.parseGitHubTarget(upstreamTarget(ancestors))
+	if val, _ := node.Data["owner"].(string); val != "" {
+		owner = val
+	}
+	if val, _ := node.Data["repo"].(string); val != "" {
+		repo = val
+	}
+	return owner != "" && repo != ""
+}
+
+func hasSourcePath(node *WorkflowNode, ancestors []*WorkflowNode) bool {
+	if path, _ := node.Data["path"].(string); path != "" {
+		return true
+	}
+	for _, ancestor := range ancestors {
+		if sourceFindingNodes[ancestor.Type] {
+			return true
+		}
+	}
+	return false
+}
+
+// notificationProblem explains why a notification node can't deliver, or returns "" when it can
+func (e *WorkflowExecutor) notificationProblem(ctx context.Context, node *WorkflowNode, userID uuid.UUID) (string, string) {
+	channel := node.Type
+	switch node.Type {
+	case "webhook":
+		if url, _ := node.Data["url"].(string); url == "" {
+			return "The webhook node has no url to deliver to", "Set url to an http(s) endpoint"
+		}
+		return "", ""
+	case "notify":
+		resolved := e.preferredChannelNode(ctx, node, userID)
+		channel, node = resolved.Type, resolved
+	case "email", "slack":
+	default:
+		return "", ""
+	}
+	if channel == "slack" && getSlackWebhookURL(node) != "" {
+		return "", ""
+	}
+	if !e.notificationService.ChannelEnabled(channel) {
+		return fmt.Sprintf("%s notifications are not configured on this server, so this node will send nothing", channel),
+			"Ask an operator to configure " + channel + ", or use a webhook node instead"
+	}
+	return "", ""
+}
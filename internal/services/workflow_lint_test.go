@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/datmedevil17/go-vuln/internal/config"
+	"github.com/datmedevil17/go-vuln/internal/models"
+)
+
+// dataNode is a test node with data
+func dataNode(id, nodeType string, data map[string]interface{}) map[string]interface{} {
+	node := testNode(id, nodeType)
+	node["data"] = data
+	return node
+}
+
+func TestLintWorkflow(t *testing.T) {
+	trigger := dataNode("trigger", "trigger", map[string]interface{}{"sourceUrl": "https://github.com/acme/api"})
+	tests := []struct {
+		name  string
+		nodes models.JSONArray
+		edges models.JSONArray
+		want  []string // code@node of each warning, in order
+	}{
+		{
+			name:  "clean",
+			nodes: models.JSONArray{trigger, testNode("nmap", "nmap"), testNode("secrets", "secret-scan"), testNode("fix", "auto-fix")},
+			edges: models.JSONArray{testEdge("e1", "trigger", "nmap"), testEdge("e2", "trigger", "secrets"), testEdge("e3", "secrets", "fix")},
+		},
+		{
+			name:  "no trigger",
+			nodes: models.JSONArray{dataNode("nmap", "nmap", map[string]interface{}{"target": "https://example.com"})},
+			want:  []string{"no-trigger@"},
+		},
+		{
+			name:  "unreachable node",
+			nodes: models.JSONArray{trigger, testNode("nmap", "nmap"), dataNode("orphan", "nmap", map[string]interface{}{"target": "https://example.com"})},
+			edges: models.JSONArray{testEdge("e1", "trigger", "nmap")},
+			want:  []string{"unreachable-node@orphan"},
+		},
+		{
+			name:  "unknown node type",
+			nodes: models.JSONArray{trigger, testNode("mystery", "port-knock")},
+			edges: models.JSONArray{testEdge("e1", "trigger", "mystery")},
+			want:  []string{"unknown-node-type@mystery"},
+		},
+		{
+			name:  "scanner without a target",
+			nodes: models.JSONArray{testNode("trigger", "trigger"), testNode("nmap", "nmap")},
+			edges: models.JSONArray{testEdge("e1", "trigger", "nmap")},
+			want:  []string{"missing-target@nmap"},
+		},
+		{
+			name: "auto-fix without a repository or file",
+			nodes: models.JSONArray{dataNode("trigger", "trigger", map[string]interface{}{"sourceUrl": "https://example.com"}),
+				testNode("nmap", "nmap"), testNode("fix", "auto-fix")},
+			edges: models.JSONArray{testEdge("e1", "trigger", "nmap"), testEdge("e2", "nmap", "fix")},
+			want:  []string{"missing-repository@fix", "missing-source-path@fix"},
+		},
+		{
+			name:  "auto-fix with its repository and file set",
+			nodes: models.JSONArray{testNode("trigger", "trigger"), dataNode("fix", "auto-fix", map[string]interface{}{"owner": "acme", "repo": "api", "path": "main.go"})},
+			edges: models.JSONArray{testEdge("e1", "trigger", "fix")},
+		},
+		{
+			name: "notifications without config",
+			nodes: models.JSONArray{trigger, testNode("hook", "webhook"), testNode("mail", "email"), testNode("chat", "slack"),
+				dataNode("own-slack", "slack", map[string]interface{}{"webhook_url": "https://hooks.slack.com/services/T/B/X"})},
+			edges: models.JSONArray{testEdge("e1", "trigger", "hook"), testEdge("e2", "trigger", "mail"), testEdge("e3", "trigger", "chat"), testEdge("e4", "trigger", "own-slack")},
+			want:  []string{"unconfigured-notification@hook", "unconfigured-notification@mail", "unconfigured-notification@chat"},
+		},
+		{
+			name:  "invalid onError",
+			nodes: models.JSONArray{trigger, dataNode("nmap", "nmap", map[string]interface{}{"onError": "retry"}), dataNode("nikto", "nikto", map[string]interface{}{"onError": "skip"})},
+			edges: models.JSONArray{testEdge("e1", "trigger", "nmap"), testEdge("e2", "trigger", "nikto")},
+			want:  []string{"invalid-on-error@nmap"},
+		},
+		{
+			name:  "empty workflow",
+			nodes: models.JSONArray{},
+		},
+	}
+	e := NewWorkflowExecutor(nil, nil, NewNotificationService(&config.Config{}), nil, nil, nil, nil, nil, nil, config.WorkflowConfig{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := testWorkflow(tt.nodes, tt.edges)
+			warnings, err := e.lintWorkflow(context.Background(), workflow, workflow.UserID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, warning := range warnings {
+				if warning.Message == "" || warning.Suggestion == "" {
+					t.Errorf("warning %+v lacks a message or suggestion", warning)
+				}
+				got = append(got, warning.Code+"@"+warning.NodeID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("warnings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
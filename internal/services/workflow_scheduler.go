@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"gorm.io/gorm"
+)
+
+// schedulePollInterval is how often the scheduler looks for workflows that are due
+const schedulePollInterval = 30 * time.Second
+
+// WorkflowScheduler starts scheduled workflows on behalf of their owners once their next
+// run time has passed
+type WorkflowScheduler struct {
+	db        *gorm.DB
+	workflows *WorkflowService
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func NewWorkflowScheduler(db *gorm.DB, workflows *WorkflowService) *WorkflowScheduler {
+	return &WorkflowScheduler{
+		db:        db,
+		workflows: workflows,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start launches the background scheduling loop
+func (s *WorkflowScheduler) Start() {
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(schedulePollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.runDue()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts the scheduling loop
+func (s *WorkflowScheduler) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+// runDue fires every enabled workflow whose next run has passed
+func (s *WorkflowScheduler) runDue() {
+	var due []models.Workflow
+	if err := s.db.Where("schedule_enabled = ? AND next_run <= ?", true, time.Now()).
+		Order("next_run").Find(&due).Error; err != nil {
+		log.Printf("⚠️ Failed to load scheduled workflows: %v", err)
+		return
+	}
+
+	for i := range due {
+		s.fire(&due[i])
+	}
+}
+
+// fire advances a due workflow's next run and executes it. Runs missed while the server was
+// down collapse into this one, since the next run is computed from now.
+func (s *WorkflowScheduler) fire(workflow *models.Workflow) {
+	var owner models.User
+	if err := s.db.First(&owner, "id = ?", workflow.UserID).Error; err != nil {
+		log.Printf("⚠️ Failed to load owner of scheduled workflow %s: %v", workflow.ID, err)
+		return
+	}
+	schedule, err := workflowSchedule(workflow, owner.Preferences)
+	if err != nil {
+		log.Printf("⚠️ Scheduled workflow %s has an invalid schedule: %v", workflow.ID, err)
+		return
+	}
+
+	// Claim this run by moving next_run on, so another instance polling the same table, or a
+	// slow poll overlapping the next one, can't fire it twice
+	now := time.Now()
+	next := schedule.Next(now).UTC()
+	claim := s.db.Model(&models.Workflow{}).
+		Where("id = ? AND next_run = ?", workflow.ID, workflow.NextRun).
+		Updates(map[string]interface{}{"next_run": next, "last_run": now})
+	if claim.Error != nil || claim.RowsAffected == 0 {
+		return
+	}
+	workflow.NextRun, workflow.LastRun = &next, &now
+
+	execution, err := s.workflows.ExecuteWorkflow(context.Background(), workflow, workflow.UserID, ExecutionLabels{Tags: []string{"scheduled"}})
+	if err != nil {
+		log.Printf("⚠️ Scheduled run of workflow %s failed to start: %v", workflow.ID, err)
+		return
+	}
+	log.Printf("⏰ Started scheduled run %s of workflow %s, next at %s", execution.ID, workflow.ID, next.Format(time.RFC3339))
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestSchedulerClaimsDueRunBeforeFiring(t *testing.T) {
+	db, mock := newMockDB(t)
+	workflowID, userID := uuid.New(), uuid.New()
+	due := time.Now().Add(-time.Minute).UTC()
+	mock.ExpectQuery(`SELECT \* FROM "workflows" WHERE schedule_enabled = \$1 AND next_run <= \$2 ORDER BY next_run`).
+		WithArgs(true, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "schedule_enabled", "schedule_frequency", "schedule_time", "schedule_timezone", "next_run"}).
+			AddRow(workflowID, userID, true, "daily", "09:00", "Europe/Berlin", due))
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
+
+	// Another instance moved next_run on first, so this one must not start the workflow:
+	// the scheduler has no WorkflowService to start it with
+	var lastRun, nextRun capturedArg
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "workflows" SET "last_run"=\$1,"next_run"=\$2,"updated_at"=\$3 WHERE id = \$4 AND next_run = \$5`).
+		WithArgs(&lastRun, &nextRun, sqlmock.AnyArg(), workflowID, due).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	NewWorkflowScheduler(db, nil).runDue()
+
+	next, ok := nextRun.value.(time.Time)
+	if !ok {
+		t.Fatalf("next_run written as %v", nextRun.value)
+	}
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	if local := next.In(berlin); !next.After(time.Now()) || next.Sub(time.Now()) > 25*time.Hour || local.Hour() != 9 || local.Minute() != 0 {
+		t.Fatalf("next_run = %s, want the next 09:00 in Berlin", local)
+	}
+}
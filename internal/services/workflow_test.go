@@ -0,0 +1,66 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestListWorkflowExecutionsFiltersAndPages(t *testing.T) {
+	db, mock := newMockDB(t)
+	service := &WorkflowService{db: db}
+	userID, workflowID := uuid.New(), uuid.New()
+
+	where := `WHERE workflow_executions.user_id = \$1 AND workflow_executions.status = \$2 AND workflow_executions.workflow_id = \$3 AND workflow_executions.tags @> \$4::jsonb`
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "workflow_executions" `+where).
+		WithArgs(userID, "failed", workflowID, `["audit"]`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(245))
+	started := time.Now().Add(-time.Minute)
+	mock.ExpectQuery(`SELECT workflow_executions\.\*, workflows\.name as name FROM "workflow_executions" left join workflows on workflows\.id = workflow_executions\.workflow_id `+where+` ORDER BY workflow_executions\.created_at DESC LIMIT \$5 OFFSET \$6`).
+		WithArgs(userID, "failed", workflowID, `["audit"]`, 200, 200).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "workflow_id", "status", "name", "started_at", "completed_at"}).
+			AddRow(uuid.New(), workflowID, "failed", "nightly", started, started.Add(1500*time.Millisecond)))
+
+	list, err := service.ListWorkflowExecutions(userID, ExecutionListFilter{
+		Tags:       []string{"audit"},
+		Status:     "failed",
+		WorkflowID: &workflowID,
+		Limit:      1000,
+		Offset:     200,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Total != 245 || list.Limit != maxExecutionPageSize || list.Offset != 200 {
+		t.Fatalf("got total %d, limit %d, offset %d", list.Total, list.Limit, list.Offset)
+	}
+	if len(list.Executions) != 1 || list.Executions[0].Name != "nightly" || list.Executions[0].Duration != 1500 {
+		t.Fatalf("got executions %+v", list.Executions)
+	}
+}
+
+func TestListWorkflowExecutionsDefaults(t *testing.T) {
+	db, mock := newMockDB(t)
+	service := &WorkflowService{db: db}
+	userID := uuid.New()
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "workflow_executions" WHERE workflow_executions\.user_id = \$1$`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`ORDER BY workflow_executions\.created_at DESC LIMIT \$2$`).
+		WithArgs(userID, defaultExecutionPageSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	list, err := service.ListWorkflowExecutions(userID, ExecutionListFilter{Offset: -5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Limit != defaultExecutionPageSize || list.Offset != 0 {
+		t.Fatalf("got limit %d, offset %d", list.Limit, list.Offset)
+	}
+	if list.Executions == nil {
+		t.Fatal("an empty page must encode as [], not null")
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+	"github.com/google/uuid"
+)
+
+// Validation error codes
+const (
+	ValidationMalformed     = "malformed-graph"
+	ValidationMissingNodeID = "missing-node-id"
+	ValidationDuplicateNode = "duplicate-node-id"
+	ValidationDanglingEdge  = "dangling-edge"
+	ValidationNoTrigger     = "no-trigger"
+	ValidationUnknownType   = "unknown-node-type"
+	ValidationCycle         = "cycle"
+)
+
+// ValidationError is a problem that stops a workflow from running. Unlike a LintWarning, an
+// execution of the workflow would fail on it.
+type ValidationError struct {
+	Code    string `json:"code"`
+	NodeID  string `json:"node_id,omitempty"`
+	EdgeID  string `json:"edge_id,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidateWorkflow checks a saved workflow's graph for problems that would fail its runs,
+// returning every one found; an empty list means the graph is runnable
+func (s *WorkflowService) ValidateWorkflow(ctx context.Context, workflowID, userID uuid.UUID) ([]ValidationError, error) {
+	workflow, err := s.GetWorkflow(workflowID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.executor.validateWorkflow(workflow), ctx.Err()
+}
+
+// validateWorkflow parses the graph the way an execution does and reports every reason it
+// would fail to run it. Cycles are looked for among the well-formed nodes and edges, so one
+// broken edge doesn't hide them.
+func (e *WorkflowExecutor) validateWorkflow(workflow *models.Workflow) []ValidationError {
+	problems := []ValidationError{}
+	nodes, edges, err := e.parseWorkflow(workflow)
+	if err != nil {
+		return append(problems, ValidationError{
+			Code:    ValidationMalformed,
+			Message: fmt.Sprintf("The nodes or edges can't be read: %v", err),
+		})
+	}
+
+	// Keep the first node with each ID, so the checks below see the graph the run would
+	seen := make(map[string]bool, len(nodes))
+	unique := make([]WorkflowNode, 0, len(nodes))
+	hasTrigger := false
+	for i, node := range nodes {
+		switch {
+		case node.ID == "":
+			problems = append(problems, ValidationError{
+				Code:    ValidationMissingNodeID,
+				Message: fmt.Sprintf("Node %d (%s) has no id", i, node.Type),
+			})
+			continue
+		case seen[node.ID]:
+			problems = append(problems, ValidationError{
+				Code:    ValidationDuplicateNode,
+				NodeID:  node.ID,
+				Message: fmt.Sprintf("%q is used by more than one node", node.ID),
+			})
+			continue
+		}
+		seen[node.ID] = true
+		unique = append(unique, node)
+
+		if node.Type == "trigger" {
+			hasTrigger = true
+		}
+		if _, isScanner := LookupScanner(node.Type); !isScanner && !isBuiltinNodeType(node.Type) {
+			problems = append(problems, ValidationError{
+				Code:    ValidationUnknownType,
+				NodeID:  node.ID,
+				Message: fmt.Sprintf("%q is not a known node type", node.Type),
+			})
+		}
+	}
+	if !hasTrigger {
+		problems = append(problems, ValidationError{
+			Code:    ValidationNoTrigger,
+			Message: "The workflow has no trigger node",
+		})
+	}
+
+	connected := make([]WorkflowEdge, 0, len(edges))
+	for _, edge := range edges {
+		var missing string
+		switch {
+		case !seen[edge.Source] && !seen[edge.Target]:
+			missing = fmt.Sprintf("neither %q nor %q is a node", edge.Source, edge.Target)
+		case !seen[edge.Source]:
+			missing = fmt.Sprintf("its source %q is not a node", edge.Source)
+		case !seen[edge.Target]:
+			missing = fmt.Sprintf("its target %q is not a node", edge.Target)
+		}
+		if missing != "" {
+			problems = append(problems, ValidationError{
+				Code:    ValidationDanglingEdge,
+				EdgeID:  edge.ID,
+				Message: fmt.Sprintf("Edge %q is dangling: %s of the workflow", edge.ID, missing),
+			})
+			continue
+		}
+		connected = append(connected, edge)
+	}
+
+	if _, err := e.topologicalSort(unique, connected); err != nil {
+		g := newWorkflowGraph(unique, connected)
+		for _, node := range unique {
+			if g.reachable(g.downstream[node.ID], g.downstream)[node.ID] {
+				problems = append(problems, ValidationError{
+					Code:    ValidationCycle,
+					NodeID:  node.ID,
+					Message: fmt.Sprintf("Node %q is part of a cycle, so it would wait on itself", node.ID),
+				})
+			}
+		}
+	}
+	return problems
+}
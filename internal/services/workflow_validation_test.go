@@ -0,0 +1,117 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/datmedevil17/go-vuln/internal/models"
+)
+
+func TestValidateWorkflow(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes models.JSONArray
+		edges models.JSONArray
+		want  []ValidationError
+	}{
+		{
+			name:  "runnable",
+			nodes: models.JSONArray{testNode("t", "trigger"), testNode("scan", "nmap"), testNode("issue", "github-issue")},
+			edges: models.JSONArray{testEdge("e1", "t", "scan"), testEdge("e2", "scan", "issue")},
+			want:  []ValidationError{},
+		},
+		{
+			name:  "malformed nodes",
+			nodes: models.JSONArray{"not a node"},
+			want:  []ValidationError{{Code: ValidationMalformed}},
+		},
+		{
+			name:  "malformed edges",
+			nodes: models.JSONArray{testNode("t", "trigger")},
+			edges: models.JSONArray{map[string]interface{}{"id": "e1", "source": 7}},
+			want:  []ValidationError{{Code: ValidationMalformed}},
+		},
+		{
+			name: "empty graph",
+			want: []ValidationError{{Code: ValidationNoTrigger}},
+		},
+		{
+			name:  "missing id",
+			nodes: models.JSONArray{testNode("t", "trigger"), testNode("", "nmap")},
+			want:  []ValidationError{{Code: ValidationMissingNodeID}},
+		},
+		{
+			name:  "duplicate id",
+			nodes: models.JSONArray{testNode("t", "trigger"), testNode("scan", "nmap"), testNode("scan", "nikto")},
+			edges: models.JSONArray{testEdge("e1", "t", "scan")},
+			want:  []ValidationError{{Code: ValidationDuplicateNode, NodeID: "scan"}},
+		},
+		{
+			name:  "unknown type",
+			nodes: models.JSONArray{testNode("t", "trigger"), testNode("x", "port-knocker")},
+			want:  []ValidationError{{Code: ValidationUnknownType, NodeID: "x"}},
+		},
+		{
+			name:  "dangling edges",
+			nodes: models.JSONArray{testNode("t", "trigger"), testNode("scan", "nmap")},
+			edges: models.JSONArray{testEdge("e1", "t", "gone"), testEdge("e2", "gone", "scan"), testEdge("e3", "a", "b")},
+			want: []ValidationError{
+				{Code: ValidationDanglingEdge, EdgeID: "e1"},
+				{Code: ValidationDanglingEdge, EdgeID: "e2"},
+				{Code: ValidationDanglingEdge, EdgeID: "e3"},
+			},
+		},
+		{
+			name:  "cycle",
+			nodes: models.JSONArray{testNode("t", "trigger"), testNode("a", "nmap"), testNode("b", "nikto"), testNode("c", "github-issue")},
+			edges: models.JSONArray{testEdge("e1", "t", "a"), testEdge("e2", "a", "b"), testEdge("e3", "b", "a"), testEdge("e4", "b", "c")},
+			want: []ValidationError{
+				{Code: ValidationCycle, NodeID: "a"},
+				{Code: ValidationCycle, NodeID: "b"},
+			},
+		},
+		{
+			name:  "self loop",
+			nodes: models.JSONArray{testNode("t", "trigger"), testNode("a", "nmap")},
+			edges: models.JSONArray{testEdge("e1", "t", "a"), testEdge("e2", "a", "a")},
+			want:  []ValidationError{{Code: ValidationCycle, NodeID: "a"}},
+		},
+		{
+			name:  "cycle behind a dangling edge",
+			nodes: models.JSONArray{testNode("t", "trigger"), testNode("a", "nmap"), testNode("b", "nikto")},
+			edges: models.JSONArray{testEdge("e1", "a", "b"), testEdge("e2", "b", "a"), testEdge("e3", "t", "gone")},
+			want: []ValidationError{
+				{Code: ValidationDanglingEdge, EdgeID: "e3"},
+				{Code: ValidationCycle, NodeID: "a"},
+				{Code: ValidationCycle, NodeID: "b"},
+			},
+		},
+		{
+			name:  "every problem reported",
+			nodes: models.JSONArray{testNode("a", "nmap"), testNode("a", "nmap"), testNode("x", "mystery")},
+			edges: models.JSONArray{testEdge("e1", "a", "missing")},
+			want: []ValidationError{
+				{Code: ValidationDuplicateNode, NodeID: "a"},
+				{Code: ValidationUnknownType, NodeID: "x"},
+				{Code: ValidationNoTrigger},
+				{Code: ValidationDanglingEdge, EdgeID: "e1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := (&WorkflowExecutor{}).validateWorkflow(&models.Workflow{Nodes: tt.nodes, Edges: tt.edges})
+			got := make([]ValidationError, 0, len(problems))
+			for _, problem := range problems {
+				if problem.Message == "" {
+					t.Errorf("%s problem has no message", problem.Code)
+				}
+				problem.Message = ""
+				got = append(got, problem)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// zapReportFixture is a trimmed report of ZAP's baseline scan, with HTML in the alert text as ZAP writes it
+const zapReportFixture = `{
+  "@version": "2.14.0",
+  "site": [
+    {
+      "@name": "https://shop.example.com",
+      "alerts": [
+        {
+          "pluginid": "10038",
+          "name": "Content Security Policy (CSP) Header Not Set",
+          "riskcode": "2",
+          "desc": "<p>Content Security Policy (CSP) is an added layer of security &amp; helps detect attacks.</p>",
+          "solution": "<p>Ensure that your web server sets the Content-Security-Policy header.</p>",
+          "instances": [
+            {"uri": "https://shop.example.com/", "method": "GET"},
+            {"uri": "https://shop.example.com/cart", "method": "GET"}
+          ]
+        },
+        {
+          "pluginid": "10096",
+          "name": "Timestamp Disclosure - Unix",
+          "riskcode": "0",
+          "desc": "<p>A timestamp was disclosed by the application.</p>",
+          "solution": "",
+          "instances": []
+        }
+      ]
+    },
+    {
+      "@name": "https://api.example.com",
+      "alerts": [
+        {"pluginid": "40012", "name": "Cross Site Scripting (Reflected)", "riskcode": "3", "desc": "<p>XSS</p>", "solution": "<p>Encode output.</p>",
+         "instances": [{"uri": "https://api.example.com/search?q=x", "method": "GET"}]}
+      ]
+    }
+  ]
+}`
+
+func TestParseZAPFindings(t *testing.T) {
+	want := []Finding{
+		{
+			Key: "zap: 10038 on https://shop.example.com", Scanner: "zap", RuleID: "10038", Title: "Content Security Policy (CSP) Header Not Set",
+			Severity: SeverityMedium, Target: "https://shop.example.com", Path: "https://shop.example.com/",
+			Description: "Content Security Policy (CSP) is an added layer of security & helps detect attacks.\nSolution: Ensure that your web server sets the Content-Security-Policy header.",
+		},
+		{
+			Key: "zap: 10096 on https://shop.example.com", Scanner: "zap", RuleID: "10096", Title: "Timestamp Disclosure - Unix",
+			Severity: SeverityInfo, Target: "https://shop.example.com", Description: "A timestamp was disclosed by the application.",
+		},
+		{
+			Key: "zap: 40012 on https://api.example.com", Scanner: "zap", RuleID: "40012", Title: "Cross Site Scripting (Reflected)",
+			Severity: SeverityHigh, Target: "https://api.example.com", Path: "https://api.example.com/search?q=x", Description: "XSS\nSolution: Encode output.",
+		},
+	}
+	if got := Normalize("zap", []byte(zapReportFixture)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Normalize() =\n%+v\nwant\n%+v", got, want)
+	}
+	if got := Normalize("zap", []byte("ZAP failed to start")); len(got) != 0 {
+		t.Fatalf("findings from output that isn't a report: %+v", got)
+	}
+}
+
+// zapExit is the error of a ZAP run that exited with the given code
+type zapExit int
+
+func (e zapExit) Error() string { return "exit status " + strconv.Itoa(int(e)) }
+func (e zapExit) ExitCode() int { return int(e) }
+
+// zapRunner is a ToolRunner that writes report to the path given with -J and exits with exit
+type zapRunner struct {
+	report string
+	exit   error
+}
+
+func (r zapRunner) Available(string) bool { return true }
+
+func (r zapRunner) Run(ctx context.Context, run ToolRun) ([]byte, error) {
+	for i, arg := range run.Args {
+		if arg == "-J" {
+			if err := os.WriteFile(run.Args[i+1], []byte(r.report), 0o600); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return []byte("WARN-NEW: Content Security Policy (CSP) Header Not Set [10038]"), r.exit
+}
+
+func TestRunZAPReadsReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		exit    error
+		wantErr bool
+	}{
+		{name: "no alerts"},
+		// ZAP exits 1 or 2 when it raised alerts; the report is complete all the same
+		{name: "warnings", exit: zapExit(2)},
+		{name: "crashed", exit: zapExit(3), wantErr: true},
+	}
+	targets, err := NewScanTargetPolicy([]string{"shop.example.com"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScannerService(nil, nil, NewResultSigner(""), nil, nil, targets, NewScanLimiter(1, 1, 0, time.Minute), zapRunner{zapReportFixture, tt.exit},
+				ScanSavePolicy{}, ScanMockPolicy{}, nil)
+			output, err := s.RunZAP(context.Background(), "https://shop.example.com", nil, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("RunZAP() succeeded after ZAP crashed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if findings := Normalize("zap", output); len(findings) != 3 {
+				t.Fatalf("report gave %d findings, want 3", len(findings))
+			}
+		})
+	}
+}
+
+func TestZAPMockParses(t *testing.T) {
+	output, err := forcedMockScanner(t, 1).RunZAP(context.Background(), "https://scanme.example.com", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if findings := Normalize("zap", output); len(findings) == 0 {
+		t.Fatalf("mock ZAP report gave no findings: %s", output)
+	}
+}
@@ -120,6 +120,48 @@ func DeriveKey(password string) []byte {
 	return hash[:]
 }
 
+// KeyRing holds versioned AES-256 keys. New data is encrypted with the current
+// version; existing ciphertext is decrypted with the version stored beside it.
+type KeyRing struct {
+	keys    map[int][]byte
+	current int
+}
+
+// NewKeyRing derives a key for each version; current must be one of them
+func NewKeyRing(keys map[int]string, current int) (*KeyRing, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("no key for current version %d", current)
+	}
+	ring := &KeyRing{keys: make(map[int][]byte, len(keys)), current: current}
+	for version, key := range keys {
+		ring.keys[version] = DeriveKey(key)
+	}
+	return ring, nil
+}
+
+// Current returns the version new data is encrypted with
+func (k *KeyRing) Current() int {
+	return k.current
+}
+
+// Encrypt encrypts with the current key and returns the version used
+func (k *KeyRing) Encrypt(plaintext string) (string, int, error) {
+	ciphertext, err := Encrypt(plaintext, k.keys[k.current])
+	if err != nil {
+		return "", 0, err
+	}
+	return ciphertext, k.current, nil
+}
+
+// Decrypt decrypts ciphertext that was encrypted with the given key version
+func (k *KeyRing) Decrypt(ciphertext string, version int) (string, error) {
+	key, ok := k.keys[version]
+	if !ok {
+		return "", fmt.Errorf("no key for version %d", version)
+	}
+	return Decrypt(ciphertext, key)
+}
+
 // GenerateAPIKey generates a secure API key
 func GenerateAPIKey() (string, error) {
 	return GenerateRandomToken(32)
@@ -0,0 +1,343 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultDiffContext is the number of unchanged lines shown around each change
+const DefaultDiffContext = 3
+
+// DiffLine is a single line of a hunk. Type is " " (context), "+" (added) or "-" (removed).
+// OldLine and NewLine are 1-based and zero when the line doesn't exist on that side.
+type DiffLine struct {
+	Type    string `json:"type"`
+	Content string `json:"content"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+	NoEOL   bool   `json:"no_newline_at_eof,omitempty"`
+}
+
+// DiffHunk is a contiguous group of changes with surrounding context
+type DiffHunk struct {
+	Header   string     `json:"header"`
+	OldStart int        `json:"old_start"`
+	OldLines int        `json:"old_lines"`
+	NewStart int        `json:"new_start"`
+	NewLines int        `json:"new_lines"`
+	Added    int        `json:"added"`
+	Removed  int        `json:"removed"`
+	Lines    []DiffLine `json:"lines"`
+}
+
+// DiffLines computes a line diff between original and modified using Myers' algorithm
+// and groups the result into hunks with the given number of context lines.
+func DiffLines(original, modified string, context int) []DiffHunk {
+	if context < 0 {
+		context = 0
+	}
+	a := splitLines(original)
+	b := splitLines(modified)
+
+	d := &differ{
+		a:       a,
+		b:       b,
+		removed: make([]bool, len(a)),
+		added:   make([]bool, len(b)),
+	}
+	d.compare(0, len(a), 0, len(b))
+
+	return buildHunks(d.script(), context)
+}
+
+// UnifiedDiff renders hunks in unified diff format
+func UnifiedDiff(oldName, newName string, hunks []DiffHunk) string {
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for _, hunk := range hunks {
+		sb.WriteString(hunk.Header)
+		sb.WriteByte('\n')
+		for _, line := range hunk.Lines {
+			sb.WriteString(line.Type)
+			sb.WriteString(line.Content)
+			sb.WriteByte('\n')
+			if line.NoEOL {
+				sb.WriteString("\\ No newline at end of file\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// splitLines splits text into lines, keeping the newline so a missing final newline is a difference
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// differ marks which lines of a were removed and which lines of b were added.
+// It uses the linear-space variant of Myers' algorithm: find the middle of the
+// shortest edit path by searching forwards and backwards at once, then recurse
+// on both halves.
+type differ struct {
+	a, b    []string
+	removed []bool
+	added   []bool
+}
+
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	// Common prefix and suffix are never part of the edit
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		aLo++
+		bLo++
+	}
+	for aLo < aHi && bLo < bHi && d.a[aHi-1] == d.b[bHi-1] {
+		aHi--
+		bHi--
+	}
+
+	switch {
+	case aLo == aHi:
+		for j := bLo; j < bHi; j++ {
+			d.added[j] = true
+		}
+		return
+	case bLo == bHi:
+		for i := aLo; i < aHi; i++ {
+			d.removed[i] = true
+		}
+		return
+	}
+
+	x, y, ok := d.bisect(aLo, aHi, bLo, bHi)
+	if !ok {
+		for i := aLo; i < aHi; i++ {
+			d.removed[i] = true
+		}
+		for j := bLo; j < bHi; j++ {
+			d.added[j] = true
+		}
+		return
+	}
+	d.compare(aLo, x, bLo, y)
+	d.compare(x, aHi, y, bHi)
+}
+
+// bisect finds a point on the shortest edit path that splits the problem into two smaller ones
+func (d *differ) bisect(aLo, aHi, bLo, bHi int) (int, int, bool) {
+	n := aHi - aLo
+	m := bHi - bLo
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	size := 2*maxD + 2
+
+	forward := make([]int, size)
+	backward := make([]int, size)
+	for i := range forward {
+		forward[i] = -1
+		backward[i] = -1
+	}
+	forward[offset+1] = 0
+	backward[offset+1] = 0
+
+	delta := n - m
+	// When delta is odd the paths can only meet while extending the forward path
+	checkForward := delta%2 != 0
+	var fStart, fEnd, bStart, bEnd int
+
+	split := func(x, y int) (int, int, bool) {
+		if (x == 0 && y == 0) || (x == n && y == m) {
+			return 0, 0, false
+		}
+		return aLo + x, bLo + y, true
+	}
+
+	for step := 0; step < maxD; step++ {
+		for k := -step + fStart; k <= step-fEnd; k += 2 {
+			idx := offset + k
+			var x int
+			if k == -step || (k != step && forward[idx-1] < forward[idx+1]) {
+				x = forward[idx+1]
+			} else {
+				x = forward[idx-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			forward[idx] = x
+
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case checkForward:
+				bIdx := offset + delta - k
+				if bIdx >= 0 && bIdx < size && backward[bIdx] != -1 && x >= n-backward[bIdx] {
+					return split(x, y)
+				}
+			}
+		}
+
+		for k := -step + bStart; k <= step-bEnd; k += 2 {
+			idx := offset + k
+			var x int
+			if k == -step || (k != step && backward[idx-1] < backward[idx+1]) {
+				x = backward[idx+1]
+			} else {
+				x = backward[idx-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aHi-x-1] == d.b[bHi-y-1] {
+				x++
+				y++
+			}
+			backward[idx] = x
+
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !checkForward:
+				fIdx := offset + delta - k
+				if fIdx >= 0 && fIdx < size && forward[fIdx] != -1 {
+					fx := forward[fIdx]
+					fy := fx - (fIdx - offset)
+					if fx >= n-x {
+						return split(fx, fy)
+					}
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// script converts the removed/added marks into an ordered edit script
+func (d *differ) script() []DiffLine {
+	var lines []DiffLine
+	i, j := 0, 0
+	for i < len(d.a) || j < len(d.b) {
+		switch {
+		case i < len(d.a) && d.removed[i]:
+			lines = append(lines, newDiffLine("-", d.a[i], i+1, 0))
+			i++
+		case j < len(d.b) && d.added[j]:
+			lines = append(lines, newDiffLine("+", d.b[j], 0, j+1))
+			j++
+		default:
+			lines = append(lines, newDiffLine(" ", d.a[i], i+1, j+1))
+			i++
+			j++
+		}
+	}
+	return lines
+}
+
+func newDiffLine(kind, text string, oldLine, newLine int) DiffLine {
+	content := strings.TrimSuffix(text, "\n")
+	return DiffLine{
+		Type:    kind,
+		Content: content,
+		OldLine: oldLine,
+		NewLine: newLine,
+		NoEOL:   content == text,
+	}
+}
+
+// buildHunks groups an edit script into hunks, merging changes whose context would overlap
+func buildHunks(script []DiffLine, context int) []DiffHunk {
+	var hunks []DiffHunk
+	for start := 0; start < len(script); {
+		// Find the next change
+		first := start
+		for first < len(script) && script[first].Type == " " {
+			first++
+		}
+		if first == len(script) {
+			break
+		}
+
+		// Extend while the gap of unchanged lines to the next change is within 2*context
+		last := first
+		for i := first + 1; i < len(script); i++ {
+			if script[i].Type == " " {
+				if i-last > 2*context {
+					break
+				}
+				continue
+			}
+			last = i
+		}
+
+		from := first - context
+		if from < start {
+			from = start
+		}
+		if from < 0 {
+			from = 0
+		}
+		to := last + context + 1
+		if to > len(script) {
+			to = len(script)
+		}
+
+		hunks = append(hunks, newHunk(script, from, to))
+		start = to
+	}
+	return hunks
+}
+
+func newHunk(script []DiffLine, from, to int) DiffHunk {
+	hunk := DiffHunk{Lines: append([]DiffLine(nil), script[from:to]...)}
+
+	// Line numbers before the hunk, for hunks that are empty on one side
+	oldBefore, newBefore := 0, 0
+	for _, line := range script[:from] {
+		if line.Type != "+" {
+			oldBefore++
+		}
+		if line.Type != "-" {
+			newBefore++
+		}
+	}
+
+	for _, line := range hunk.Lines {
+		switch line.Type {
+		case "+":
+			hunk.Added++
+			hunk.NewLines++
+		case "-":
+			hunk.Removed++
+			hunk.OldLines++
+		default:
+			hunk.OldLines++
+			hunk.NewLines++
+		}
+	}
+
+	hunk.OldStart = oldBefore
+	if hunk.OldLines > 0 {
+		hunk.OldStart++
+	}
+	hunk.NewStart = newBefore
+	if hunk.NewLines > 0 {
+		hunk.NewStart++
+	}
+	hunk.Header = fmt.Sprintf("@@ -%d,%d +%d,%d @@", hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines)
+	return hunk
+}
@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		modified string
+		want     string
+	}{
+		{
+			name:     "identical",
+			original: "a\nb\n",
+			modified: "a\nb\n",
+			want:     "",
+		},
+		{
+			name:     "add",
+			original: "a\nb\nc\n",
+			modified: "a\nb\nnew\nc\n",
+			want:     "--- a\n+++ b\n@@ -1,3 +1,4 @@\n a\n b\n+new\n c\n",
+		},
+		{
+			name:     "remove",
+			original: "a\nb\nc\n",
+			modified: "a\nc\n",
+			want:     "--- a\n+++ b\n@@ -1,3 +1,2 @@\n a\n-b\n c\n",
+		},
+		{
+			name:     "modify",
+			original: "query = \"SELECT * FROM users WHERE id = \" + id\nrun(query)\n",
+			modified: "query = \"SELECT * FROM users WHERE id = ?\"\nrun(query, id)\n",
+			want: "--- a\n+++ b\n@@ -1,2 +1,2 @@\n" +
+				"-query = \"SELECT * FROM users WHERE id = \" + id\n-run(query)\n" +
+				"+query = \"SELECT * FROM users WHERE id = ?\"\n+run(query, id)\n",
+		},
+		{
+			name:     "new file",
+			original: "",
+			modified: "a\nb\n",
+			want:     "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:     "deleted file",
+			original: "a\nb\n",
+			modified: "",
+			want:     "--- a\n+++ b\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name:     "missing final newline",
+			original: "a\nb\n",
+			modified: "a\nb",
+			want:     "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n",
+		},
+		{
+			name:     "changes far apart get separate hunks",
+			original: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			modified: "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n",
+			want: "--- a\n+++ b\n" +
+				"@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n" +
+				"@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n",
+		},
+		{
+			name:     "changes close together share a hunk",
+			original: "1\n2\n3\n4\n5\n6\n7\n8\n",
+			modified: "one\n2\n3\n4\n5\n6\n7\neight\n",
+			want:     "--- a\n+++ b\n@@ -1,8 +1,8 @@\n-1\n+one\n 2\n 3\n 4\n 5\n 6\n 7\n-8\n+eight\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := UnifiedDiff("a", "b", DiffLines(tt.original, tt.modified, DefaultDiffContext))
+			if got != tt.want {
+				t.Fatalf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffHunkMetadata(t *testing.T) {
+	hunks := DiffLines("a\nb\nc\nd\n", "a\nB\nc\nd\ne\n", 1)
+	if len(hunks) != 1 {
+		t.Fatalf("got %d hunks, want 1", len(hunks))
+	}
+	h := hunks[0]
+	if h.Added != 2 || h.Removed != 1 || h.OldStart != 1 || h.OldLines != 4 || h.NewStart != 1 || h.NewLines != 5 {
+		t.Fatalf("unexpected hunk %+v", h)
+	}
+	for _, line := range h.Lines {
+		switch {
+		case line.Type == "+" && (line.OldLine != 0 || line.NewLine == 0),
+			line.Type == "-" && (line.NewLine != 0 || line.OldLine == 0),
+			line.Type == " " && (line.OldLine == 0 || line.NewLine == 0):
+			t.Fatalf("line %+v has the wrong line numbers", line)
+		}
+	}
+}
+
+// lcsLength is the length of the longest common subsequence of a and b
+func lcsLength(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// applyHunks rebuilds both sides from the edit script, which must cover every line with no context limit
+func applyHunks(hunks []DiffHunk) (string, string) {
+	var before, after strings.Builder
+	for _, hunk := range hunks {
+		for _, line := range hunk.Lines {
+			text := line.Content
+			if !line.NoEOL {
+				text += "\n"
+			}
+			if line.Type != "+" {
+				before.WriteString(text)
+			}
+			if line.Type != "-" {
+				after.WriteString(text)
+			}
+		}
+	}
+	return before.String(), after.String()
+}
+
+func TestDiffLinesIsMinimal(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	text := func() string {
+		var sb strings.Builder
+		for i := random.Intn(30); i > 0; i-- {
+			sb.WriteString(string(rune('a' + random.Intn(4))))
+			sb.WriteByte('\n')
+		}
+		return sb.String()
+	}
+
+	for i := 0; i < 500; i++ {
+		original, modified := text(), text()
+		hunks := DiffLines(original, modified, len(original)+len(modified))
+		if original == modified {
+			if len(hunks) != 0 {
+				t.Fatalf("identical texts produced %d hunks", len(hunks))
+			}
+			continue
+		}
+		if len(hunks) != 1 {
+			t.Fatalf("got %d hunks with unlimited context", len(hunks))
+		}
+		if oldText, newText := applyHunks(hunks); oldText != original || newText != modified {
+			t.Fatalf("diff of %q and %q doesn't rebuild them", original, modified)
+		}
+		a, b := splitLines(original), splitLines(modified)
+		edits := hunks[0].Added + hunks[0].Removed
+		if want := len(a) + len(b) - 2*lcsLength(a, b); edits != want {
+			t.Fatalf("diff of %q and %q has %d edits, want the minimum %d", original, modified, edits, want)
+		}
+	}
+}
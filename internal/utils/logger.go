@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type loggerKey struct{}
+
+type requestIDKey struct{}
+
+// NewLogger returns the server's structured logger: JSON lines in production, for log
+// collectors, and readable key=value lines otherwise
+func NewLogger(mode, level string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: logLevel(level)}
+	if mode == "production" {
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts))
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, opts))
+}
+
+// logLevel maps LOG_LEVEL to a slog level, defaulting to info
+func logLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithLogger returns a context carrying logger, so work started under it logs with its fields
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// Logger returns the logger carried by ctx, or the default logger
+func Logger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// WithRequestID returns a context carrying the ID of the request it serves, so work handed to
+// another server can still be logged under it
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID carried by ctx, or "" outside a request
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"regexp"
+)
+
+// sensitiveFieldPattern matches JSON fields and query/form parameters whose values are secrets.
+// Key names may be snake_case, kebab-case or camelCase. A quoted value may contain escaped
+// quotes, and one cut off by a body limit runs to the end of the input.
+var sensitiveFieldPattern = regexp.MustCompile(`(?i)("?(?:access[_-]?token|refresh[_-]?token|token|password|passwd|secret|client[_-]?secret|api[_-]?key|authorization|cookie)"?\s*[:=]\s*)("(?:[^"\\]|\\.)*(?:"|\\?$)|[^&\s,}]+)`)
+
+// bearerPattern matches bearer credentials embedded in free text
+var bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[a-z0-9\-._~+/]+=*`)
+
+// apiKeyPattern matches the application's own API keys wherever they appear
+var apiKeyPattern = regexp.MustCompile(`vpk_[A-Za-z0-9_-]+`)
+
+// RedactSecrets masks secret values in request/response payloads and log lines
+func RedactSecrets(input string) string {
+	input = bearerPattern.ReplaceAllString(input, "${1}[REDACTED]")
+	input = apiKeyPattern.ReplaceAllString(input, "[REDACTED]")
+	return sensitiveFieldPattern.ReplaceAllStringFunc(input, func(match string) string {
+		parts := sensitiveFieldPattern.FindStringSubmatch(match)
+		if len(parts) < 3 {
+			return match
+		}
+		if len(parts[2]) > 0 && parts[2][0] == '"' {
+			return parts[1] + `"[REDACTED]"`
+		}
+		return parts[1] + "[REDACTED]"
+	})
+}
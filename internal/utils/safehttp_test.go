@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// sequenceResolver answers each lookup with the next address list, repeating the last one
+type sequenceResolver struct {
+	answers [][]string
+	calls   int
+}
+
+func (r *sequenceResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	answer := r.answers[len(r.answers)-1]
+	if r.calls < len(r.answers) {
+		answer = r.answers[r.calls]
+	}
+	r.calls++
+	addrs := make([]net.IPAddr, len(answer))
+	for i, ip := range answer {
+		addrs[i] = net.IPAddr{IP: net.ParseIP(ip)}
+	}
+	return addrs, nil
+}
+
+func TestSafeDialRejectsRebindingHost(t *testing.T) {
+	// Public when a caller validates the URL, loopback by the time the connection is opened
+	resolver := &sequenceResolver{answers: [][]string{{"93.184.216.34"}, {"127.0.0.1"}}}
+	addrs, _ := resolver.LookupIPAddr(context.Background(), "rebind.example")
+	if !IsPublicIP(addrs[0].IP) {
+		t.Fatal("first lookup should look public")
+	}
+
+	_, err := safeDial(context.Background(), &net.Dialer{Timeout: time.Second}, resolver, "tcp", "rebind.example:80")
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("got %v, want ErrBlockedAddress", err)
+	}
+	if resolver.calls != 2 {
+		t.Fatalf("dial resolved the host %d times in total, want a fresh lookup at dial time", resolver.calls)
+	}
+}
+
+func TestSafeDialRejectsInternalAddresses(t *testing.T) {
+	tests := []struct {
+		name  string
+		addrs []string
+	}{
+		{"loopback", []string{"127.0.0.1"}},
+		{"cloud metadata", []string{"169.254.169.254"}},
+		{"private", []string{"10.0.0.5"}},
+		{"unspecified", []string{"0.0.0.0"}},
+		{"IPv6 loopback", []string{"::1"}},
+		{"IPv6 link-local", []string{"fe80::1"}},
+		{"IPv4-mapped loopback", []string{"::ffff:127.0.0.1"}},
+		{"NAT64 of metadata", []string{"64:ff9b::a9fe:a9fe"}},
+		{"one internal among public", []string{"93.184.216.34", "192.168.1.1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := &sequenceResolver{answers: [][]string{tt.addrs}}
+			_, err := safeDial(context.Background(), &net.Dialer{Timeout: time.Second}, resolver, "tcp", "target.example:443")
+			if !errors.Is(err, ErrBlockedAddress) {
+				t.Fatalf("got %v, want ErrBlockedAddress", err)
+			}
+		})
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"172.15.255.255", true},
+		{"172.16.0.1", false},
+		{"100.64.0.1", false},
+		{"198.18.0.1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"fd00::1", false},
+		{"::", false},
+	}
+	for _, tt := range tests {
+		if got := IsPublicIP(net.ParseIP(tt.ip)); got != tt.public {
+			t.Errorf("IsPublicIP(%s) = %v, want %v", tt.ip, got, tt.public)
+		}
+	}
+}
+
+func TestSafeHTTPClientRefusesLoopbackServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached a loopback server")
+	}))
+	defer server.Close()
+
+	_, err := NewSafeHTTPClient(5 * time.Second).Get(server.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("got %v, want ErrBlockedAddress", err)
+	}
+}
@@ -26,6 +26,28 @@ func ValidateURL(urlString string) bool {
 	return parsedURL.Scheme != "" && parsedURL.Host != ""
 }
 
+// NormalizeHost reduces a scan target to its lowercase hostname or IP, so that
+// "https://Example.com:8443/app", "example.com/" and "EXAMPLE.COM" all map to
+// "example.com". IPv6 literals are returned without brackets. It returns "" when
+// no host can be determined.
+func NormalizeHost(target string) string {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return ""
+	}
+	if !strings.Contains(target, "://") {
+		target = "scan://" + target
+	}
+
+	parsedURL, err := url.Parse(target)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.ToLower(parsedURL.Hostname())
+	return strings.TrimSuffix(host, ".")
+}
+
 // ValidateUsername validates username format
 func ValidateUsername(username string) bool {
 	if len(username) < 3 || len(username) > 32 {
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_workflow_executions_replayed_from;
+ALTER TABLE IF EXISTS workflow_executions DROP COLUMN IF EXISTS replayed_from;
+ALTER TABLE IF EXISTS workflow_executions DROP COLUMN IF EXISTS snapshot;
@@ -0,0 +1,7 @@
+-- Snapshot of the workflow definition used by each execution, and replay lineage
+ALTER TABLE IF EXISTS workflow_executions ADD COLUMN IF NOT EXISTS snapshot JSONB;
+ALTER TABLE IF EXISTS workflow_executions ADD COLUMN IF NOT EXISTS replayed_from UUID;
+
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_replayed_from ON workflow_executions(replayed_from);
+
+COMMENT ON COLUMN workflow_executions.snapshot IS 'Workflow nodes/edges captured when the execution started';
@@ -10,6 +10,8 @@ import {
   XCircle, 
   Eye, 
   RefreshCw,
+  ChevronLeft,
+  ChevronRight,
   AlertTriangle,
   Shield,
   Bug,
@@ -34,6 +36,8 @@ interface ExecutionResult {
   duration?: number;
 }
 
+const PAGE_SIZE = 50;
+
 interface NodeResult {
   type: string;
   success: boolean;
@@ -44,6 +48,8 @@ interface NodeResult {
 
 const ReportCardPage = () => {
   const [reports, setReports] = useState<ExecutionResult[]>([]);
+  const [total, setTotal] = useState(0);
+  const [offset, setOffset] = useState(0);
   const [loading, setLoading] = useState(true);
   const [selectedReport, setSelectedReport] = useState<ExecutionResult | null>(null);
   const [showDetails, setShowDetails] = useState(false);
@@ -52,8 +58,15 @@ const ReportCardPage = () => {
   const fetchReports = async () => {
     try {
       setLoading(true);
-      const response = await workflowApi.getAllExecutionResults();
-      setReports(response.data || []);
+      const response = await workflowApi.getAllExecutionResults({ limit: PAGE_SIZE, offset });
+      const executions = response.data?.executions || [];
+      // Reports deleted since the page was opened can leave it past the end
+      if (executions.length === 0 && offset > 0) {
+        setOffset(Math.max(0, offset - PAGE_SIZE));
+        return;
+      }
+      setReports(executions);
+      setTotal(response.data?.total || 0);
     } catch (error) {
       console.error("Error fetching reports:", error);
       toast.error("Failed to load reports");
@@ -201,7 +214,7 @@ const ReportCardPage = () => {
     // Auto-refresh every 30 seconds
     const interval = setInterval(fetchReports, 30000);
     return () => clearInterval(interval);
-  }, []);
+  }, [offset]);
 
   if (loading) {
     return (
@@ -294,6 +307,34 @@ const ReportCardPage = () => {
           </div>
         )}
 
+        {total > PAGE_SIZE && (
+          <div className="flex items-center justify-between mt-6">
+            <span className="text-sm text-gray-500">
+              Showing {offset + 1}–{Math.min(offset + reports.length, total)} of {total}
+            </span>
+            <div className="flex gap-2">
+              <Button
+                variant="outline"
+                size="sm"
+                disabled={offset === 0}
+                onClick={() => setOffset(Math.max(0, offset - PAGE_SIZE))}
+              >
+                <ChevronLeft className="h-4 w-4 mr-1" />
+                Previous
+              </Button>
+              <Button
+                variant="outline"
+                size="sm"
+                disabled={offset + PAGE_SIZE >= total}
+                onClick={() => setOffset(offset + PAGE_SIZE)}
+              >
+                Next
+                <ChevronRight className="h-4 w-4 ml-1" />
+              </Button>
+            </div>
+          </div>
+        )}
+
         {/* Details Modal */}
         {showDetails && selectedReport && (
           <div className="fixed inset-0 bg-black/50 flex items-center justify-center z-50">
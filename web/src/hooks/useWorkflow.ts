@@ -1,4 +1,4 @@
-import { Workflow } from "@/types/workflow";
+import { ExecutionList, ExecutionListParams, Workflow } from "@/types/workflow";
 import api from "@/lib/api";
 import { API_ENDPOINTS } from "@/lib/apiEndpoints";
 
@@ -103,9 +103,9 @@ export const workflowApi = {
     }
   },
 
-  getAllExecutionResults: async (): Promise<any> => {
+  getAllExecutionResults: async (params?: ExecutionListParams): Promise<{ success: boolean; data: ExecutionList }> => {
     try {
-      const response = await api.get(API_ENDPOINTS.WORKFLOWS.REPORTS);
+      const response = await api.get<{ success: boolean; data: ExecutionList }>(API_ENDPOINTS.WORKFLOWS.REPORTS, { params });
       return response.data;
     } catch (error) {
       console.error("Error getting execution results:", error);
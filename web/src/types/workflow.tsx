@@ -42,3 +42,20 @@ export interface Workflow {
   nodes: WorkflowNode[];
   edges: WorkflowEdge[];
 }
+
+// Paging and filters for GET /api/workflows/reports
+export interface ExecutionListParams {
+  limit?: number; // Defaults to 50, at most 200
+  offset?: number;
+  status?: string;
+  workflow_id?: string;
+  tag?: string;
+}
+
+// One page of executions from GET /api/workflows/reports, newest first
+export interface ExecutionList<T = any> {
+  executions: T[];
+  total: number; // Executions matching the filters across all pages
+  limit: number;
+  offset: number;
+}